@@ -58,6 +58,9 @@ type serializedKnownAddress struct {
 	LastSuccess int64
 	Services    wire.ServiceFlag
 	SrcServices wire.ServiceFlag
+	// Network is only set for addresses of networks which can't be
+	// represented by a wire.NetAddress.
+	Network wire.NetworkID `json:",omitempty"`
 	// no refcount or tried, that is available from context.
 }
 
@@ -148,6 +151,14 @@ const (
 	// will consider evicting an address.
 	minBadDays = 7
 
+	// recentSuccessHours is the number of hours after a successful
+	// connection during which an address is preferred by GetAddress.
+	recentSuccessHours = 24
+
+	// recentSuccessFactor is the factor by which the selection probability
+	// of addresses which were connected to recently is increased.
+	recentSuccessFactor = 2
+
 	// getAddrMax is the most addresses that we will send in response
 	// to a getAddr (in practise the most addresses we will return from a
 	// call to AddressCache()).
@@ -158,20 +169,20 @@ const (
 	getAddrPercent = 23
 
 	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 2
+	serialisationVersion = 3
 )
 
 // updateAddress is a helper function to either update an address already known
 // to the address manager, or to add the address if not already known.
-func (a *AddrManager) updateAddress(netAddr, srcAddr *wire.NetAddress) {
+func (a *AddrManager) updateAddress(netAddr *wire.NetAddressV2, srcAddr *wire.NetAddress) {
 	// Filter out non-routable addresses. Note that non-routable
 	// also includes invalid and local addresses.
-	if !IsRoutable(netAddr) {
+	if !IsRoutableV2(netAddr) {
 		return
 	}
 
-	addr := NetAddressKey(netAddr)
-	ka := a.find(netAddr)
+	addr := NetAddressV2Key(netAddr)
+	ka := a.addrIndex[addr]
 	if ka != nil {
 		// TODO: only update addresses periodically.
 		// Update the last seen time and services.
@@ -267,7 +278,7 @@ func (a *AddrManager) expireNew(bucket int) {
 	}
 
 	if oldest != nil {
-		key := NetAddressKey(oldest.na)
+		key := NetAddressV2Key(oldest.na)
 		log.Tracef("expiring oldest address %v", key)
 
 		delete(a.addrNew[bucket], key)
@@ -296,13 +307,13 @@ func (a *AddrManager) pickTried(bucket int) *list.Element {
 	return oldestElem
 }
 
-func (a *AddrManager) getNewBucket(netAddr, srcAddr *wire.NetAddress) int {
+func (a *AddrManager) getNewBucket(netAddr *wire.NetAddressV2, srcAddr *wire.NetAddress) int {
 	// bitcoind:
 	// doublesha256(key + sourcegroup + int64(doublesha256(key + group + sourcegroup))%bucket_per_source_group) % num_new_buckets
 
 	data1 := []byte{}
 	data1 = append(data1, a.key[:]...)
-	data1 = append(data1, []byte(GroupKey(netAddr))...)
+	data1 = append(data1, []byte(GroupKeyV2(netAddr))...)
 	data1 = append(data1, []byte(GroupKey(srcAddr))...)
 	hash1 := chainhash.DoubleHashB(data1)
 	hash64 := binary.LittleEndian.Uint64(hash1)
@@ -318,12 +329,12 @@ func (a *AddrManager) getNewBucket(netAddr, srcAddr *wire.NetAddress) int {
 	return int(binary.LittleEndian.Uint64(hash2) % newBucketCount)
 }
 
-func (a *AddrManager) getTriedBucket(netAddr *wire.NetAddress) int {
+func (a *AddrManager) getTriedBucket(netAddr *wire.NetAddressV2) int {
 	// bitcoind hashes this as:
 	// doublesha256(key + group + truncate_to_64bits(doublesha256(key)) % buckets_per_group) % num_buckets
 	data1 := []byte{}
 	data1 = append(data1, a.key[:]...)
-	data1 = append(data1, []byte(NetAddressKey(netAddr))...)
+	data1 = append(data1, []byte(NetAddressV2Key(netAddr))...)
 	hash1 := chainhash.DoubleHashB(data1)
 	hash64 := binary.LittleEndian.Uint64(hash1)
 	hash64 %= triedBucketsPerGroup
@@ -331,7 +342,7 @@ func (a *AddrManager) getTriedBucket(netAddr *wire.NetAddress) int {
 	binary.LittleEndian.PutUint64(hashbuf[:], hash64)
 	data2 := []byte{}
 	data2 = append(data2, a.key[:]...)
-	data2 = append(data2, GroupKey(netAddr)...)
+	data2 = append(data2, GroupKeyV2(netAddr)...)
 	data2 = append(data2, hashbuf[:]...)
 
 	hash2 := chainhash.DoubleHashB(data2)
@@ -384,6 +395,9 @@ func (a *AddrManager) savePeers() {
 			ska.Services = v.na.Services
 			ska.SrcServices = v.srcAddr.Services
 		}
+		if _, ok := v.na.ToLegacy(); !ok {
+			ska.Network = v.na.NetworkID
+		}
 		// Tried and refs are implicit in the rest of the structure
 		// and will be worked out from context on unserialisation.
 		sam.Addresses[i] = ska
@@ -402,7 +416,7 @@ func (a *AddrManager) savePeers() {
 		j := 0
 		for e := a.addrTried[i].Front(); e != nil; e = e.Next() {
 			ka := e.Value.(*KnownAddress)
-			sam.TriedBuckets[i][j] = NetAddressKey(ka.na)
+			sam.TriedBuckets[i][j] = NetAddressV2Key(ka.na)
 			j++
 		}
 	}
@@ -479,7 +493,16 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		if sam.Version == 1 {
 			v.Services = wire.SFNodeNetwork
 		}
-		ka.na, err = a.DeserializeNetAddress(v.Addr, v.Services)
+		if v.Network != 0 {
+			ka.na, err = deserializeNetAddressV2(v.Addr, v.Network,
+				v.Services)
+		} else {
+			var na *wire.NetAddress
+			na, err = a.DeserializeNetAddress(v.Addr, v.Services)
+			if err == nil {
+				ka.na = wire.NewNetAddressV2FromLegacy(na)
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("failed to deserialize netaddress "+
 				"%s: %v", v.Addr, err)
@@ -500,7 +523,7 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		ka.attempts = v.Attempts
 		ka.lastattempt = time.Unix(v.LastAttempt, 0)
 		ka.lastsuccess = time.Unix(v.LastSuccess, 0)
-		a.addrIndex[NetAddressKey(ka.na)] = ka
+		a.addrIndex[NetAddressV2Key(ka.na)] = ka
 	}
 
 	for i := range sam.NewBuckets {
@@ -564,6 +587,56 @@ func (a *AddrManager) DeserializeNetAddress(addr string,
 	return a.HostToNetAddress(host, uint16(port), services)
 }
 
+// deserializeNetAddressV2 converts the given address string of an address of
+// the given network, which can't be represented by a wire.NetAddress, to a
+// *wire.NetAddressV2.
+func deserializeNetAddressV2(addr string, networkID wire.NetworkID,
+	services wire.ServiceFlag) (*wire.NetAddressV2, error) {
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	switch networkID {
+	case wire.NetTorV3:
+		// The onion address encodes the public key followed by a
+		// checksum and version, which are recomputed below.
+		b, err = addrEncoding.DecodeString(strings.ToUpper(
+			strings.TrimSuffix(host, ".onion")))
+		if err == nil && len(b) > torV3PubKeySize {
+			b = b[:torV3PubKeySize]
+		}
+
+	case wire.NetI2P:
+		b, err = addrEncoding.DecodeString(strings.ToUpper(
+			strings.TrimSuffix(host, ".b32.i2p")))
+
+	case wire.NetCJDNS:
+		b = net.ParseIP(host).To16()
+
+	default:
+		return nil, fmt.Errorf("unsupported network %v", networkID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The address must round trip to catch invalid encodings, sizes and
+	// Tor v3 checksums.
+	na := wire.NewNetAddressV2(time.Now(), services, networkID, b,
+		uint16(port))
+	if na.String() != addr {
+		return nil, fmt.Errorf("invalid %v address %s", networkID, addr)
+	}
+	return na, nil
+}
+
 // Start begins the core address handler which manages a pool of known
 // addresses, timeouts, and interval based writes.
 func (a *AddrManager) Start() {
@@ -603,6 +676,21 @@ func (a *AddrManager) AddAddresses(addrs []*wire.NetAddress, srcAddr *wire.NetAd
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
+	for _, na := range addrs {
+		a.updateAddress(wire.NewNetAddressV2FromLegacy(na), srcAddr)
+	}
+}
+
+// AddAddressesV2 adds new addresses such as the ones of an addrv2 message to
+// the address manager.  Unlike AddAddresses, it accepts addresses of networks
+// which can't be represented by a wire.NetAddress such as Tor v3 onion
+// services, I2P and CJDNS.  It enforces a max number of addresses and silently
+// ignores duplicate addresses and addresses of unknown networks.  It is safe
+// for concurrent access.
+func (a *AddrManager) AddAddressesV2(addrs []*wire.NetAddressV2, srcAddr *wire.NetAddress) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
 	for _, na := range addrs {
 		a.updateAddress(na, srcAddr)
 	}
@@ -615,7 +703,7 @@ func (a *AddrManager) AddAddress(addr, srcAddr *wire.NetAddress) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
-	a.updateAddress(addr, srcAddr)
+	a.updateAddress(wire.NewNetAddressV2FromLegacy(addr), srcAddr)
 }
 
 // AddAddressByIP adds an address where we are given an ip:port and not a
@@ -663,15 +751,33 @@ func (a *AddrManager) NeedMoreAddresses() bool {
 }
 
 // AddressCache returns the current address cache.  It must be treated as
-// read-only (but since it is a copy now, this is not as dangerous).
+// read-only (but since it is a copy now, this is not as dangerous).  Addresses
+// which can't be represented by a wire.NetAddress are not included.
 func (a *AddrManager) AddressCache() []*wire.NetAddress {
 	allAddr := a.getAddresses()
 
-	numAddresses := len(allAddr) * getAddrPercent / 100
-	if numAddresses > getAddrMax {
-		numAddresses = getAddrMax
+	numAddresses := addressCacheSize(len(allAddr))
+
+	// Fisher-Yates shuffle the array. We only need to do the first
+	// `numAddresses' since we are throwing the rest.
+	for i := 0; i < numAddresses; i++ {
+		// pick a number between current index and the end
+		j := rand.Intn(len(allAddr)-i) + i
+		allAddr[i], allAddr[j] = allAddr[j], allAddr[i]
 	}
 
+	// slice off the limit we are willing to share.
+	return allAddr[0:numAddresses]
+}
+
+// AddressCacheV2 returns the current address cache including the addresses of
+// all networks, such as to respond to a getaddr request of a peer which
+// signaled support for addrv2 messages.  It must be treated as read-only.
+func (a *AddrManager) AddressCacheV2() []*wire.NetAddressV2 {
+	allAddr := a.getAddressesV2()
+
+	numAddresses := addressCacheSize(len(allAddr))
+
 	// Fisher-Yates shuffle the array. We only need to do the first
 	// `numAddresses' since we are throwing the rest.
 	for i := 0; i < numAddresses; i++ {
@@ -684,8 +790,18 @@ func (a *AddrManager) AddressCache() []*wire.NetAddress {
 	return allAddr[0:numAddresses]
 }
 
+// addressCacheSize returns the number of addresses shared out of the given
+// number of known addresses.
+func addressCacheSize(numKnown int) int {
+	numAddresses := numKnown * getAddrPercent / 100
+	if numAddresses > getAddrMax {
+		numAddresses = getAddrMax
+	}
+	return numAddresses
+}
+
 // getAddresses returns all of the addresses currently found within the
-// manager's address cache.
+// manager's address cache which can be represented by a wire.NetAddress.
 func (a *AddrManager) getAddresses() []*wire.NetAddress {
 	a.mtx.RLock()
 	defer a.mtx.RUnlock()
@@ -696,6 +812,27 @@ func (a *AddrManager) getAddresses() []*wire.NetAddress {
 	}
 
 	addrs := make([]*wire.NetAddress, 0, addrIndexLen)
+	for _, v := range a.addrIndex {
+		if na, ok := v.na.ToLegacy(); ok {
+			addrs = append(addrs, na)
+		}
+	}
+
+	return addrs
+}
+
+// getAddressesV2 returns all of the addresses currently found within the
+// manager's address cache.
+func (a *AddrManager) getAddressesV2() []*wire.NetAddressV2 {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	addrIndexLen := len(a.addrIndex)
+	if addrIndexLen == 0 {
+		return nil
+	}
+
+	addrs := make([]*wire.NetAddressV2, 0, addrIndexLen)
 	for _, v := range a.addrIndex {
 		addrs = append(addrs, v.na)
 	}
@@ -771,10 +908,23 @@ func NetAddressKey(na *wire.NetAddress) string {
 	return net.JoinHostPort(ipString(na), port)
 }
 
+// NetAddressV2Key returns a string key for the given address.  It matches the
+// key returned by NetAddressKey for addresses which can be represented by a
+// wire.NetAddress, and is in the form of host:port for the hostname of the
+// address otherwise.
+func NetAddressV2Key(na *wire.NetAddressV2) string {
+	if legacy, ok := na.ToLegacy(); ok {
+		return NetAddressKey(legacy)
+	}
+	return na.String()
+}
+
 // GetAddress returns a single address that should be routable.  It picks a
 // random one from the possible addresses with preference given to ones that
-// have not been used recently and should not pick 'close' addresses
-// consecutively.
+// have not been used recently or were recently connected to successfully and
+// should not pick 'close' addresses consecutively.  The returned address may
+// belong to a network which can't be represented by a wire.NetAddress, in which
+// case its NetAddress method returns nil.
 func (a *AddrManager) GetAddress() *KnownAddress {
 	// Protect concurrent access.
 	a.mtx.Lock()
@@ -806,7 +956,7 @@ func (a *AddrManager) GetAddress() *KnownAddress {
 			randval := a.rand.Intn(large)
 			if float64(randval) < (factor * ka.chance() * float64(large)) {
 				log.Tracef("Selected %v from tried bucket",
-					NetAddressKey(ka.na))
+					NetAddressV2Key(ka.na))
 				return ka
 			}
 			factor *= 1.2
@@ -834,7 +984,7 @@ func (a *AddrManager) GetAddress() *KnownAddress {
 			randval := a.rand.Intn(large)
 			if float64(randval) < (factor * ka.chance() * float64(large)) {
 				log.Tracef("Selected %v from new bucket",
-					NetAddressKey(ka.na))
+					NetAddressV2Key(ka.na))
 				return ka
 			}
 			factor *= 1.2
@@ -975,7 +1125,7 @@ func (a *AddrManager) Good(addr *wire.NetAddress) {
 	// something back.
 	a.nNew++
 
-	rmkey := NetAddressKey(rmka.na)
+	rmkey := NetAddressV2Key(rmka.na)
 	log.Tracef("Replacing %s with %s in tried", rmkey, addrKey)
 
 	// We made sure there is space here just above.
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/dashpay/dashd-go/wire"
 )
@@ -192,3 +193,71 @@ func TestAddrManagerV1ToV2(t *testing.T) {
 	addrMgr.loadPeers()
 	assertAddrs(t, addrMgr, expectedAddrs)
 }
+
+// TestAddrManagerV2Serialization ensures that addresses of networks which can't
+// be represented by a wire.NetAddress are stored, shared and persisted.
+func TestAddrManagerV2Serialization(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	addrMgr := New(tempDir, nil)
+
+	key := make([]byte, torV3PubKeySize)
+	rand.Read(key)
+	cjdns := net.ParseIP("fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa")
+	legacy := randAddr(t)
+	legacy.IP = net.ParseIP("12.1.2.3")
+	addrs := []*wire.NetAddressV2{
+		wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			wire.NetTorV3, key, 9999),
+		wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			wire.NetI2P, key, 0),
+		wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			wire.NetCJDNS, cjdns, 9999),
+		wire.NewNetAddressV2FromLegacy(legacy),
+	}
+	addrMgr.AddAddressesV2(addrs, randAddr(t))
+
+	// Addresses of unknown networks and invalid addresses are ignored.
+	addrMgr.AddAddressesV2([]*wire.NetAddressV2{
+		wire.NewNetAddressV2(time.Now(), 0, wire.NetworkID(99), key, 1),
+		wire.NewNetAddressV2(time.Now(), 0, wire.NetTorV3, key[:8], 1),
+	}, randAddr(t))
+
+	assertAddrsV2 := func() {
+		t.Helper()
+
+		got := make(map[string]struct{})
+		for _, na := range addrMgr.getAddressesV2() {
+			got[NetAddressV2Key(na)] = struct{}{}
+		}
+		if len(got) != len(addrs) {
+			t.Fatalf("expected to find %d addresses, found %d",
+				len(addrs), len(got))
+		}
+		for _, na := range addrs {
+			if _, ok := got[NetAddressV2Key(na)]; !ok {
+				t.Fatalf("expected to find address %v", na)
+			}
+		}
+
+		// Only the legacy address can be shared with addr messages.
+		legacyAddrs := addrMgr.getAddresses()
+		if len(legacyAddrs) != 1 {
+			t.Fatalf("expected to find 1 legacy address, found %d",
+				len(legacyAddrs))
+		}
+		assertAddr(t, legacyAddrs[0], legacy)
+	}
+	assertAddrsV2()
+
+	addrMgr.savePeers()
+	addrMgr = New(tempDir, nil)
+	addrMgr.loadPeers()
+	assertAddrsV2()
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// ASMap maps IP address prefixes to the autonomous system (AS) which
+// announces them.  It is used to group addresses by the network operator that
+// controls them rather than by a fixed size prefix, so connections can be
+// spread across providers instead of clustering within a single one that
+// controls many unrelated prefixes.
+//
+// The zero value is not usable.  An ASMap is created with LoadASMap and is
+// safe for concurrent reads once loaded.
+type ASMap struct {
+	// prefixes houses the AS numbers keyed by the masked 16-byte form of
+	// each prefix for every distinct prefix length.
+	prefixes map[int]map[string]uint32
+
+	// lengths are the distinct prefix lengths, in bits of the 16-byte form,
+	// sorted from the longest to the shortest so lookups find the most
+	// specific prefix first.
+	lengths []int
+}
+
+// LoadASMap parses an AS map from the passed reader.  Each non-empty line that
+// is not a comment, which starts with '#', consists of an IPv4 or IPv6 prefix
+// in CIDR notation followed by the AS number announcing it, optionally
+// prefixed with "AS".  For example:
+//
+//	1.1.1.0/24 AS13335
+//	2001:db8::/32 64496
+//
+// When prefixes overlap, the most specific one wins.
+func LoadASMap(r io.Reader) (*ASMap, error) {
+	m := &ASMap{prefixes: make(map[int]map[string]uint32)}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected prefix and AS "+
+				"number, got %q", lineNum, line)
+		}
+		_, ipNet, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		asnStr := strings.TrimPrefix(strings.ToUpper(fields[1]), "AS")
+		asn, err := strconv.ParseUint(asnStr, 10, 32)
+		if err != nil || asn == 0 {
+			return nil, fmt.Errorf("line %d: invalid AS number %q",
+				lineNum, fields[1])
+		}
+
+		// Normalize the prefix to the 16-byte form so IPv4 and IPv6
+		// addresses are looked up in the same way.
+		ones, bits := ipNet.Mask.Size()
+		if bits == 8*net.IPv4len {
+			ones += 8 * (net.IPv6len - net.IPv4len)
+		}
+		mask := net.CIDRMask(ones, 8*net.IPv6len)
+		key := string(ipNet.IP.To16().Mask(mask))
+
+		entries, ok := m.prefixes[ones]
+		if !ok {
+			entries = make(map[string]uint32)
+			m.prefixes[ones] = entries
+			m.lengths = append(m.lengths, ones)
+		}
+		entries[key] = uint32(asn)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(m.lengths)))
+	return m, nil
+}
+
+// Len returns the number of prefixes in the AS map.
+func (m *ASMap) Len() int {
+	var n int
+	for _, entries := range m.prefixes {
+		n += len(entries)
+	}
+	return n
+}
+
+// Lookup returns the AS number announcing the passed IP address along with
+// whether or not a prefix containing the address exists in the map.
+func (m *ASMap) Lookup(ip net.IP) (uint32, bool) {
+	ip = ip.To16()
+	if ip == nil {
+		return 0, false
+	}
+	for _, ones := range m.lengths {
+		masked := ip.Mask(net.CIDRMask(ones, 8*net.IPv6len))
+		if asn, ok := m.prefixes[ones][string(masked)]; ok {
+			return asn, true
+		}
+	}
+	return 0, false
+}
+
+// mappedIP returns the IP address which should be used to determine the AS of
+// the passed address, which is the embedded IPv4 address for the various IPv6
+// tunnelling and translation schemes, or nil when the address does not map to
+// an AS at all, such as tor addresses.
+func mappedIP(na *wire.NetAddress) net.IP {
+	switch {
+	case IsIPv4(na):
+		return na.IP
+	case IsRFC6145(na) || IsRFC6052(na):
+		return net.IP(na.IP[12:16])
+	case IsRFC3964(na):
+		return net.IP(na.IP[2:6])
+	case IsRFC4380(na):
+		// Teredo tunnels have the last 4 bytes as the v4 address XOR
+		// 0xff.
+		ip := net.IP(make([]byte, 4))
+		for i, b := range na.IP[12:16] {
+			ip[i] = b ^ 0xff
+		}
+		return ip
+	case IsOnionCatTor(na):
+		return nil
+	}
+	return na.IP
+}
+
+// GroupKey returns a string representing the network group an address is part
+// of.  Routable addresses which are announced by an AS in the map are grouped
+// by that AS, while all other addresses fall back to the prefix based groups
+// returned by the package level GroupKey function.
+//
+// It is safe to call this method on a nil AS map, in which case it is
+// equivalent to the package level GroupKey function.
+func (m *ASMap) GroupKey(na *wire.NetAddress) string {
+	if m == nil || IsLocal(na) || !IsRoutable(na) {
+		return GroupKey(na)
+	}
+	ip := mappedIP(na)
+	if ip == nil {
+		return GroupKey(na)
+	}
+	if asn, ok := m.Lookup(ip); ok {
+		return fmt.Sprintf("as:%d", asn)
+	}
+	return GroupKey(na)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dashpay/dashd-go/addrmgr"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestASMap ensures AS maps are parsed as expected and addresses are grouped
+// by the AS announcing the most specific prefix containing them.
+func TestASMap(t *testing.T) {
+	const asMapText = `
+# Comments and blank lines are ignored.
+12.0.0.0/8 AS100
+12.1.0.0/16 as200
+12.1.2.0/24 300
+173.194.0.0/16 400
+2001:470::/32 500
+`
+	asMap, err := addrmgr.LoadASMap(strings.NewReader(asMapText))
+	if err != nil {
+		t.Fatalf("LoadASMap: unexpected error: %v", err)
+	}
+	if asMap.Len() != 5 {
+		t.Fatalf("Len: got %d, want 5", asMap.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		// Most specific prefixes take precedence.
+		{"12.1.2.3", "as:300"},
+		{"12.1.3.4", "as:200"},
+		{"12.2.3.4", "as:100"},
+		{"173.194.1.1", "as:400"},
+
+		// Embedded IPv4 addresses map to the AS of the IPv4 address.
+		{"2002:0c01:0203::", "as:300"},         // RFC 3964
+		{"2001:0:0:0:0:0:f3fe:fdfc", "as:300"}, // RFC 4380
+
+		{"2001:470:1f0e::1", "as:500"},
+
+		// Addresses not in the map fall back to the prefix groups.
+		{"173.195.1.1", "173.195.0.0"},
+		{"2600:1f18::1", "2600:1f18::"},
+		{"127.0.0.1", "local"},
+		{"10.0.0.1", "unroutable"},
+	}
+	for _, test := range tests {
+		na := wire.NewNetAddressIPPort(net.ParseIP(test.ip), 9999,
+			wire.SFNodeNetwork)
+		if key := asMap.GroupKey(na); key != test.want {
+			t.Errorf("GroupKey(%s): got %q, want %q", test.ip, key,
+				test.want)
+		}
+	}
+
+	// A nil AS map must behave like the package level GroupKey.
+	var nilMap *addrmgr.ASMap
+	na := wire.NewNetAddressIPPort(net.ParseIP("12.1.2.3"), 9999,
+		wire.SFNodeNetwork)
+	if key, want := nilMap.GroupKey(na), addrmgr.GroupKey(na); key != want {
+		t.Errorf("GroupKey on nil map: got %q, want %q", key, want)
+	}
+
+	// Ensure malformed maps are rejected.
+	invalid := []string{
+		"12.0.0.0/8",
+		"12.0.0.0 100",
+		"12.0.0.0/8 ASX",
+		"12.0.0.0/8 0",
+		"12.0.0.0/8 100 extra",
+	}
+	for _, text := range invalid {
+		if _, err := addrmgr.LoadASMap(strings.NewReader(text)); err == nil {
+			t.Errorf("LoadASMap(%q): expected error", text)
+		}
+	}
+}
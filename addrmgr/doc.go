@@ -30,10 +30,14 @@ drastically reduces the chances an attacker is able to coerce your peer into
 only connecting to nodes they control.
 
 The address manager also understands routability and Tor addresses and tries
-hard to only return routable addresses.  In addition, it uses the information
+hard to only return routable addresses.  Besides the addresses of addr messages,
+it stores the addresses of addrv2 messages (BIP0155), including the ones of
+networks which only addrv2 messages can represent such as Tor v3 onion
+services, I2P and CJDNS, and persists all of them to a JSON file in the data
+directory.  In addition, it uses the information
 provided by the caller about connected, known good, and attempted addresses to
 periodically purge peers which no longer appear to be good peers as well as
-bias the selection toward known good peers.  The general idea is to make a best
+bias the selection toward known good and recently connected peers.  The general idea is to make a best
 effort at only providing usable addresses.
 */
 package addrmgr
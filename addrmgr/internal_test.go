@@ -20,6 +20,6 @@ func TstKnownAddressChance(ka *KnownAddress) float64 {
 
 func TstNewKnownAddress(na *wire.NetAddress, attempts int,
 	lastattempt, lastsuccess time.Time, tried bool, refs int) *KnownAddress {
-	return &KnownAddress{na: na, attempts: attempts, lastattempt: lastattempt,
+	return &KnownAddress{na: wire.NewNetAddressV2FromLegacy(na), attempts: attempts, lastattempt: lastattempt,
 		lastsuccess: lastsuccess, tried: tried, refs: refs}
 }
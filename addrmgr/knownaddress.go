@@ -15,7 +15,7 @@ import (
 // to determine how viable an address is.
 type KnownAddress struct {
 	mtx         sync.RWMutex // na and lastattempt
-	na          *wire.NetAddress
+	na          *wire.NetAddressV2
 	srcAddr     *wire.NetAddress
 	attempts    int
 	lastattempt time.Time
@@ -25,8 +25,21 @@ type KnownAddress struct {
 }
 
 // NetAddress returns the underlying wire.NetAddress associated with the
-// known address.
+// known address.  It returns nil for addresses of networks which can't be
+// represented by a wire.NetAddress, such as Tor v3 onion services.
 func (ka *KnownAddress) NetAddress() *wire.NetAddress {
+	ka.mtx.RLock()
+	defer ka.mtx.RUnlock()
+	na, ok := ka.na.ToLegacy()
+	if !ok {
+		return nil
+	}
+	return na
+}
+
+// NetAddressV2 returns the underlying wire.NetAddressV2 associated with the
+// known address.
+func (ka *KnownAddress) NetAddressV2() *wire.NetAddressV2 {
 	ka.mtx.RLock()
 	defer ka.mtx.RUnlock()
 	return ka.na
@@ -51,7 +64,8 @@ func (ka *KnownAddress) Services() wire.ServiceFlag {
 
 // chance returns the selection probability for a known address.  The priority
 // depends upon how recently the address has been seen, how recently it was last
-// attempted and how often attempts to connect to it have failed.
+// attempted, how recently a connection to it succeeded and how often attempts
+// to connect to it have failed.
 func (ka *KnownAddress) chance() float64 {
 	now := time.Now()
 	lastAttempt := now.Sub(ka.lastattempt)
@@ -67,6 +81,12 @@ func (ka *KnownAddress) chance() float64 {
 		c *= 0.01
 	}
 
+	// Addresses which were connected to recently are likely to still be
+	// reachable, so they are preferred.
+	if now.Sub(ka.lastsuccess) < recentSuccessHours*time.Hour {
+		c *= recentSuccessFactor
+	}
+
 	// Failed attempts deprioritise.
 	for i := ka.attempts; i > 0; i-- {
 		c /= 1.5
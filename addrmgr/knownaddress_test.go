@@ -23,27 +23,37 @@ func TestChance(t *testing.T) {
 			//Test normal case
 			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
 				0, time.Now().Add(-30*time.Minute), time.Now(), false, 0),
-			1.0,
+			1.0 * 2,
 		}, {
 			//Test case in which lastseen < 0
 			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(20 * time.Second)},
 				0, time.Now().Add(-30*time.Minute), time.Now(), false, 0),
-			1.0,
+			1.0 * 2,
 		}, {
 			//Test case in which lastattempt < 0
 			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
 				0, time.Now().Add(30*time.Minute), time.Now(), false, 0),
-			1.0 * .01,
+			1.0 * .01 * 2,
 		}, {
 			//Test case in which lastattempt < ten minutes
 			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
 				0, time.Now().Add(-5*time.Minute), time.Now(), false, 0),
-			1.0 * .01,
+			1.0 * .01 * 2,
 		}, {
 			//Test case with several failed attempts.
 			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
 				2, time.Now().Add(-30*time.Minute), time.Now(), false, 0),
-			1 / 1.5 / 1.5,
+			2 / 1.5 / 1.5,
+		}, {
+			//Test case in which the last success isn't recent
+			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
+				0, time.Now().Add(-30*time.Minute), time.Now().Add(-25*time.Hour), false, 0),
+			1.0,
+		}, {
+			//Test case in which the address never succeeded
+			addrmgr.TstNewKnownAddress(&wire.NetAddress{Timestamp: now.Add(-35 * time.Second)},
+				1, time.Now().Add(-30*time.Minute), time.Time{}, false, 0),
+			1 / 1.5,
 		},
 	}
 
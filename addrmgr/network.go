@@ -5,6 +5,7 @@
 package addrmgr
 
 import (
+	"encoding/base32"
 	"fmt"
 	"net"
 
@@ -92,6 +93,18 @@ var (
 	heNet = ipNet("2001:470::", 32, 128)
 )
 
+const (
+	// torV3PubKeySize is the size of the public key of a Tor v3 onion
+	// service, which is its address within the network.
+	torV3PubKeySize = 32
+
+	// cjdnsPrefix is the first byte of all CJDNS addresses (fc00::/8).
+	cjdnsPrefix = 0xfc
+)
+
+// addrEncoding is the base32 encoding used by Tor and I2P addresses.
+var addrEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 // ipNet returns a net.IPNet struct given the passed IP address string, number
 // of one bits to include at the start of the mask, and the total number of bits
 // for the mask.
@@ -279,3 +292,47 @@ func GroupKey(na *wire.NetAddress) string {
 
 	return na.IP.Mask(net.CIDRMask(bits, 128)).String()
 }
+
+// IsRoutableV2 returns whether or not the passed address is routable.  The
+// addresses of overlay networks which can't be represented by a
+// wire.NetAddress, such as Tor v3 onion services, I2P and CJDNS, are routable
+// when they are valid addresses of their network.
+func IsRoutableV2(na *wire.NetAddressV2) bool {
+	if legacy, ok := na.ToLegacy(); ok {
+		return IsRoutable(legacy)
+	}
+
+	switch na.NetworkID {
+	case wire.NetTorV3, wire.NetI2P:
+		return len(na.Addr) == torV3PubKeySize
+	case wire.NetCJDNS:
+		return len(na.Addr) == net.IPv6len && na.Addr[0] == cjdnsPrefix
+	}
+	return false
+}
+
+// GroupKeyV2 returns a string representing the network group the passed
+// address is part of.  It matches GroupKey for addresses which can be
+// represented by a wire.NetAddress.  The addresses of other overlay networks
+// are grouped by their network and the /4 of the address, like Tor addresses,
+// and are "unroutable" when they are invalid.
+func GroupKeyV2(na *wire.NetAddressV2) string {
+	if legacy, ok := na.ToLegacy(); ok {
+		return GroupKey(legacy)
+	}
+	if !IsRoutableV2(na) {
+		return "unroutable"
+	}
+
+	var network string
+	switch na.NetworkID {
+	case wire.NetTorV3:
+		network = "torv3"
+	case wire.NetI2P:
+		network = "i2p"
+	case wire.NetCJDNS:
+		// The first byte is the same for all CJDNS addresses.
+		return fmt.Sprintf("cjdns:%d", na.Addr[1]&((1<<4)-1))
+	}
+	return fmt.Sprintf("%s:%d", network, na.Addr[0]&((1<<4)-1))
+}
@@ -7,6 +7,7 @@ package addrmgr_test
 import (
 	"net"
 	"testing"
+	"time"
 
 	"github.com/dashpay/dashd-go/addrmgr"
 	"github.com/dashpay/dashd-go/wire"
@@ -200,3 +201,56 @@ func TestGroupKey(t *testing.T) {
 		}
 	}
 }
+
+// TestGroupKeyV2 tests the IsRoutableV2 and GroupKeyV2 functions to ensure they
+// properly handle the addresses of all networks.
+func TestGroupKeyV2(t *testing.T) {
+	key := make([]byte, 32)
+	key[0] = 0x12
+	tests := []struct {
+		name      string
+		networkID wire.NetworkID
+		addr      []byte
+		routable  bool
+		expected  string
+	}{
+		{name: "ipv4", networkID: wire.NetIPv4,
+			addr: net.ParseIP("12.1.2.3").To4(), routable: true,
+			expected: "12.1.0.0"},
+		{name: "ipv4 unroutable", networkID: wire.NetIPv4,
+			addr: net.ParseIP("10.1.2.3").To4(), expected: "unroutable"},
+		{name: "ipv6", networkID: wire.NetIPv6,
+			addr: net.ParseIP("2602:100::1"), routable: true,
+			expected: "2602:100::"},
+		{name: "tor v2", networkID: wire.NetTorV2,
+			addr: key[:10], routable: true, expected: "tor:2"},
+		{name: "tor v3", networkID: wire.NetTorV3, addr: key,
+			routable: true, expected: "torv3:2"},
+		{name: "tor v3 invalid size", networkID: wire.NetTorV3,
+			addr: key[:16], expected: "unroutable"},
+		{name: "i2p", networkID: wire.NetI2P, addr: key,
+			routable: true, expected: "i2p:2"},
+		{name: "cjdns", networkID: wire.NetCJDNS,
+			addr:     net.ParseIP("fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa"),
+			routable: true, expected: "cjdns:2"},
+		{name: "cjdns invalid prefix", networkID: wire.NetCJDNS,
+			addr: net.ParseIP("2602:100::1"), expected: "unroutable"},
+		{name: "unknown network", networkID: 99, addr: key,
+			expected: "unroutable"},
+	}
+
+	for i, test := range tests {
+		na := wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			test.networkID, test.addr, 9999)
+		if routable := addrmgr.IsRoutableV2(na); routable != test.routable {
+			t.Errorf("TestGroupKeyV2 #%d (%s): unexpected routable "+
+				"- got %v, want %v", i, test.name, routable,
+				test.routable)
+		}
+		if key := addrmgr.GroupKeyV2(na); key != test.expected {
+			t.Errorf("TestGroupKeyV2 #%d (%s): unexpected group key "+
+				"- got '%s', want '%s'", i, test.name,
+				key, test.expected)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package blockchain
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dashpay/dashd-go/btcutil"
 	"github.com/dashpay/dashd-go/database"
@@ -39,7 +40,10 @@ func (b *BlockChain) maybeAcceptBlock(block *btcutil.Block, flags BehaviorFlags)
 
 	// The block must pass all of the validation rules which depend on the
 	// position of the block within the block chain.
+	contextStart := time.Now()
 	err := b.checkBlockContext(block, prevNode, flags)
+	b.timings.add(block.Hash(), blockHeight, stageContext,
+		time.Since(contextStart))
 	if err != nil {
 		return false, err
 	}
@@ -101,7 +101,12 @@ type BlockChain struct {
 	timeSource          MedianTimeSource
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
+	stateCommitters     []StateCommitter
 	hashCache           *txscript.HashCache
+	txLocator           TxLocator
+	lockStatus          LockStatusProvider
+	maxReorgDepth       int32
+	timings             *blockTimings
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -111,13 +116,48 @@ type BlockChain struct {
 	maxRetargetTimespan int64 // target timespan * adjustment factor
 	blocksPerRetarget   int32 // target timespan / target time per block
 
-	// chainLock protects concurrent access to the vast majority of the
-	// fields in this struct below this point.
+	// The chain state is split into components which are each protected by
+	// their own lock.  The locks must be acquired in the order they are
+	// listed when more than one is needed:
+	//
+	//   notificationsLock -> chainLock -> indexLock -> utxoLock ->
+	//   stateLock, orphanLock and the locks of the block index, the best
+	//   chain view and the threshold state caches
+	//
+	// The locks after utxoLock are never held while acquiring another
+	// lock.  The notification callbacks are invoked with notificationsLock
+	// held for reads, but none of the other locks, so the callbacks are
+	// free to query the chain.
+	//
+	// chainLock serializes the processing of blocks and protects the vast
+	// majority of the fields in this struct below this point.  It is held
+	// for the entire time a block is validated and connected, so only
+	// operations which must not run concurrently with block processing
+	// acquire it.
+	//
+	// indexLock protects the main chain of the block index, that is the
+	// tip of the best chain and the chain of blocks leading to it.  Callers
+	// which navigate the main chain, such as the block locators and the
+	// threshold states, acquire it for reads.
+	//
+	// utxoLock protects the utxo set and the spend journal the database
+	// maintains for the tip of the best chain.  Callers which fetch utxos
+	// or spent outputs acquire it for reads.
+	//
+	// Both indexLock and utxoLock are only held for writes while a block
+	// that has already been validated is committed to the database and
+	// becomes the new tip, or for the entire time the blocks of a validated
+	// reorganize are disconnected and connected.  This allows readers of
+	// either component to proceed without waiting for the validation of
+	// blocks and without observing a partially applied reorganize.
 	chainLock sync.RWMutex
+	indexLock sync.RWMutex
+	utxoLock  sync.RWMutex
 
 	// These fields are related to the memory block index.  They both have
-	// their own locks, however they are often also protected by the chain
-	// lock to help prevent logic races when blocks are being processed.
+	// their own locks, however changes to the tip of the best chain are
+	// also protected by the index lock to help prevent logic races when
+	// blocks are being processed.
 	//
 	// index houses the entire block index in memory.  The block index is
 	// a tree-shaped structure.
@@ -139,6 +179,26 @@ type BlockChain struct {
 	nextCheckpoint *chaincfg.Checkpoint
 	checkpointNode *blockNode
 
+	// bootstrapTip is the tip of the header chain bootstrapped via
+	// ProcessBootstrapHeaders.  Its nodes are not part of the block index
+	// since their blocks are not known yet.  It is protected by the chain
+	// lock.
+	bootstrapTip *blockNode
+
+	// stateCommitErr is the error of the last failed commit of the
+	// external state.  No further blocks are connected or disconnected
+	// once it is set, since the external state no longer corresponds to
+	// the chain tip until it is recovered on the next start.  It is
+	// protected by the chain lock.
+	stateCommitErr error
+
+	// reindexDone is closed once the reindex of the chain state performed in
+	// the background, if any, is complete, and reindexErr is the error it
+	// failed with.  No blocks are processed before then.  reindexErr must
+	// only be accessed once reindexDone is closed.
+	reindexDone chan struct{}
+	reindexErr  error
+
 	// The state is used as a fairly efficient way to cache information
 	// about the current best chain state that is returned to callers when
 	// requested.  It operates on the principle of MVCC such that any time a
@@ -178,7 +238,8 @@ type BlockChain struct {
 	unknownRulesWarned bool
 
 	// The notifications field stores a slice of callbacks to be executed on
-	// certain blockchain events.
+	// certain blockchain events.  It is protected by the notifications
+	// lock, which is held for reads while the callbacks are invoked.
 	notificationsLock sync.RWMutex
 	notifications     []NotificationCallback
 }
@@ -284,7 +345,10 @@ func (b *BlockChain) removeOrphanBlock(orphan *orphanBlock) {
 // blocks and will remove the oldest received orphan block if the limit is
 // exceeded.
 func (b *BlockChain) addOrphanBlock(block *btcutil.Block) {
-	// Remove expired orphan blocks.
+	// Remove expired orphan blocks while determining the oldest remaining
+	// one.  The oldest orphan is recalculated from scratch since the one
+	// found by a previous call may have since expired or been processed.
+	b.oldestOrphan = nil
 	for _, oBlock := range b.orphans {
 		if time.Now().After(oBlock.expiration) {
 			b.removeOrphanBlock(oBlock)
@@ -554,7 +618,14 @@ func (b *BlockChain) getReorganizeNodes(node *blockNode) (*list.List, *list.List
 // must happen prior to calling this function requires the same details, so
 // it would be inefficient to repeat it.
 //
-// This function MUST be called with the chain state lock held (for writes).
+// The index and utxo locks must be held so readers never observe a tip that is
+// inconsistent with the utxo set.  The caller is responsible for sending the
+// NTBlockConnected notification once the locks are released, which includes
+// the case of an error returned after the block became the new tip because the
+// external state failed to commit.
+//
+// This function MUST be called with the chain state lock, the index lock and
+// the utxo lock held (for writes).
 func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	view *UtxoViewpoint, stxos []SpentTxOut) error {
 
@@ -597,7 +668,14 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	state := newBestState(node, blockSize, blockWeight, numTxns,
 		curTotalTxns+numTxns, node.CalcPastMedianTime())
 
-	// Atomically insert info into the database.
+	// Stage the changes of the block to the external state so they can be
+	// committed once the database has been updated.
+	if err := b.prepareState(block, true); err != nil {
+		return err
+	}
+
+	// Atomically insert info into the database and make the block the new
+	// tip.
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -605,6 +683,18 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 			return err
 		}
 
+		// Record the intent to commit the external state so the
+		// commit can be completed after a crash.
+		if len(b.stateCommitters) > 0 {
+			err := dbPutStateIntent(dbTx, stateIntent{
+				hash:    node.hash,
+				connect: true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
 		// Add the block hash and height to the block index which tracks
 		// the main chain.
 		err = dbPutBlockIndex(dbTx, block.Hash(), node.height)
@@ -615,6 +705,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		// Update the utxo set using the state of the utxo view.  This
 		// entails removing all of the utxos spent and adding the new
 		// ones created by the block.
+		utxoStart := time.Now()
 		err = dbPutUtxoView(dbTx, view)
 		if err != nil {
 			return err
@@ -626,20 +717,26 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		if err != nil {
 			return err
 		}
+		b.timings.add(&node.hash, node.height, stageUtxo,
+			time.Since(utxoStart))
 
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
 		if b.indexManager != nil {
+			indexStart := time.Now()
 			err := b.indexManager.ConnectBlock(dbTx, block, stxos)
 			if err != nil {
 				return err
 			}
+			b.timings.add(&node.hash, node.height, stageIndex,
+				time.Since(indexStart))
 		}
 
 		return nil
 	})
 	if err != nil {
+		b.abortState()
 		return err
 	}
 
@@ -659,20 +756,21 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	b.stateSnapshot = state
 	b.stateLock.Unlock()
 
-	// Notify the caller that the block was connected to the main chain.
-	// The caller would typically want to react with actions such as
-	// updating wallets.
-	b.chainLock.Unlock()
-	b.sendNotification(NTBlockConnected, block)
-	b.chainLock.Lock()
-
-	return nil
+	// Commit the staged changes to the external state now that the
+	// database reflects the block.
+	return b.commitState()
 }
 
 // disconnectBlock handles disconnecting the passed node/block from the end of
 // the main (best) chain.
 //
-// This function MUST be called with the chain state lock held (for writes).
+// The caller is responsible for sending the NTBlockDisconnected notification
+// once the index and utxo locks are released, which includes the case of an
+// error returned after the parent became the new tip because the external
+// state failed to commit.
+//
+// This function MUST be called with the chain state lock, the index lock and
+// the utxo lock held (for writes).
 func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view *UtxoViewpoint) error {
 	// Make sure the node being disconnected is the end of the best chain.
 	if !node.hash.IsEqual(&b.bestChain.Tip().hash) {
@@ -710,6 +808,13 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 	state := newBestState(prevNode, blockSize, blockWeight, numTxns,
 		newTotalTxns, prevNode.CalcPastMedianTime())
 
+	// Stage the changes of the block to the external state so they can be
+	// committed once the database has been updated.
+	if err := b.prepareState(block, false); err != nil {
+		return err
+	}
+
+	// Atomically update the database and make the parent the new tip.
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -717,6 +822,18 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 			return err
 		}
 
+		// Record the intent to commit the external state so the
+		// commit can be completed after a crash.
+		if len(b.stateCommitters) > 0 {
+			err := dbPutStateIntent(dbTx, stateIntent{
+				hash:    node.hash,
+				connect: false,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
 		// Remove the block hash and height from the block index which
 		// tracks the main chain.
 		err = dbRemoveBlockIndex(dbTx, block.Hash(), node.height)
@@ -759,6 +876,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 		return nil
 	})
 	if err != nil {
+		b.abortState()
 		return err
 	}
 
@@ -778,14 +896,28 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 	b.stateSnapshot = state
 	b.stateLock.Unlock()
 
-	// Notify the caller that the block was disconnected from the main
-	// chain.  The caller would typically want to react with actions such as
-	// updating wallets.
+	// Commit the staged changes to the external state now that the
+	// database reflects the block.
+	return b.commitState()
+}
+
+// sendChainNotifications sends the passed notifications in order without
+// holding the chain state lock, so the callbacks are free to query the chain.
+// The caller would typically want to react with actions such as updating
+// wallets.
+//
+// This function MUST be called with the chain state lock held (for writes) and
+// without holding the index and utxo locks.
+func (b *BlockChain) sendChainNotifications(notifications []Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+
 	b.chainLock.Unlock()
-	b.sendNotification(NTBlockDisconnected, block)
+	for _, n := range notifications {
+		b.sendNotification(n.Type, n.Data)
+	}
 	b.chainLock.Lock()
-
-	return nil
 }
 
 // countSpentOutputs returns the number of utxos the passed block spends.
@@ -988,6 +1120,51 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	view = NewUtxoViewpoint()
 	view.SetBestHash(&b.bestChain.Tip().hash)
 
+	// Hold the index and utxo locks while the blocks are disconnected and
+	// connected so readers never observe the chain in the middle of the
+	// reorganize, where the tip has been rolled back but the new branch is
+	// not attached yet.  The notifications are sent once the locks are
+	// released, including for the blocks that were processed before any
+	// failure.
+	var notifications []Notification
+	b.indexLock.Lock()
+	b.utxoLock.Lock()
+	err := b.applyReorganize(detachNodes, attachNodes, detachBlocks,
+		detachSpentTxOuts, attachBlocks, view, &notifications)
+	b.utxoLock.Unlock()
+	b.indexLock.Unlock()
+	b.sendChainNotifications(notifications)
+	if err != nil {
+		return err
+	}
+
+	// Log the point where the chain forked and old and new best chain
+	// heads.
+	if forkNode != nil {
+		log.Infof("REORGANIZE: Chain forks at %v (height %v)", forkNode.hash,
+			forkNode.height)
+	}
+	log.Infof("REORGANIZE: Old best chain head was %v (height %v)",
+		&oldBest.hash, oldBest.height)
+	log.Infof("REORGANIZE: New best chain head is %v (height %v)",
+		newBest.hash, newBest.height)
+
+	return nil
+}
+
+// applyReorganize disconnects the passed detach nodes and connects the passed
+// attach nodes with the blocks and spend journal entries previously loaded by
+// reorganizeChain.  The notifications for the blocks which were disconnected
+// and connected are appended to the passed slice, even when an error is
+// returned.
+//
+// This function MUST be called with the chain state lock, the index lock and
+// the utxo lock held (for writes).
+func (b *BlockChain) applyReorganize(detachNodes, attachNodes *list.List,
+	detachBlocks []*btcutil.Block, detachSpentTxOuts [][]SpentTxOut,
+	attachBlocks []*btcutil.Block, view *UtxoViewpoint,
+	notifications *[]Notification) error {
+
 	// Disconnect blocks from the main chain.
 	for i, e := 0, detachNodes.Front(); e != nil; i, e = i+1, e.Next() {
 		n := e.Value.(*blockNode)
@@ -1008,8 +1185,16 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 			return err
 		}
 
-		// Update the database and chain state.
+		// Update the database and chain state.  The block is also
+		// disconnected when only the commit of the external state
+		// failed afterwards, which the notification must reflect.
 		err = b.disconnectBlock(n, block, view)
+		if b.bestChain.Tip() != n {
+			*notifications = append(*notifications, Notification{
+				Type: NTBlockDisconnected,
+				Data: block,
+			})
+		}
 		if err != nil {
 			return err
 		}
@@ -1037,24 +1222,21 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 			return err
 		}
 
-		// Update the database and chain state.
+		// Update the database and chain state.  The block is also
+		// connected when only the commit of the external state failed
+		// afterwards, which the notification must reflect.
 		err = b.connectBlock(n, block, view, stxos)
+		if b.bestChain.Tip() == n {
+			*notifications = append(*notifications, Notification{
+				Type: NTBlockConnected,
+				Data: block,
+			})
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	// Log the point where the chain forked and old and new best chain
-	// heads.
-	if forkNode != nil {
-		log.Infof("REORGANIZE: Chain forks at %v (height %v)", forkNode.hash,
-			forkNode.height)
-	}
-	log.Infof("REORGANIZE: Old best chain head was %v (height %v)",
-		&oldBest.hash, oldBest.height)
-	log.Infof("REORGANIZE: New best chain head is %v (height %v)",
-		newBest.hash, newBest.height)
-
 	return nil
 }
 
@@ -1121,6 +1303,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
+			utxoStart := time.Now()
 			err := view.fetchInputUtxos(b.db, block)
 			if err != nil {
 				return false, err
@@ -1129,10 +1312,27 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 			if err != nil {
 				return false, err
 			}
+			b.timings.add(&node.hash, node.height, stageUtxo,
+				time.Since(utxoStart))
 		}
 
 		// Connect the block to the main chain.
+		b.indexLock.Lock()
+		b.utxoLock.Lock()
 		err := b.connectBlock(node, block, view, stxos)
+		connected := b.bestChain.Tip() == node
+		b.utxoLock.Unlock()
+		b.indexLock.Unlock()
+
+		// Notify the caller that the block was connected to the main
+		// chain.  That is also the case when only the commit of the
+		// external state failed afterwards.
+		if connected {
+			b.sendChainNotifications([]Notification{{
+				Type: NTBlockConnected,
+				Data: block,
+			}})
+		}
 		if err != nil {
 			// If we got hit with a rule error, then we'll mark
 			// that status of the block as invalid and flush the
@@ -1188,6 +1388,9 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 	// blocks that form the (now) old fork from the main chain, and attach
 	// the blocks that form the new chain to the main chain starting at the
 	// common ancenstor (the point where the chain forked).
+	if err := b.checkReorgDepth(node); err != nil {
+		return false, err
+	}
 	detachNodes, attachNodes := b.getReorganizeNodes(node)
 
 	// Reorganize the chain.
@@ -1205,13 +1408,144 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 	return err == nil, err
 }
 
+// checkReorgDepth ensures making the passed side chain node the tip of the main
+// chain does not disconnect more blocks than the configured maximum
+// reorganization depth, unless the best ChainLock is for one of the blocks the
+// reorganization connects.  The limit is only enforced when the chain was
+// configured with a LockStatusProvider, since a ChainLock is the only way to
+// move past a rejected reorganization.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) checkReorgDepth(node *blockNode) error {
+	if b.maxReorgDepth <= 0 || b.lockStatus == nil {
+		return nil
+	}
+	fork := b.bestChain.FindFork(node)
+	if fork == nil {
+		return nil
+	}
+	depth := b.bestChain.Tip().height - fork.height
+	if depth <= b.maxReorgDepth {
+		return nil
+	}
+
+	// A ChainLock on the new chain takes precedence over the limit since
+	// the network has reached consensus on it.
+	lockedHash := b.lockStatus.BestChainLock()
+	if lockedHash != nil {
+		lockedNode := b.index.LookupNode(lockedHash)
+		if lockedNode != nil && lockedNode.height > fork.height &&
+			node.Ancestor(lockedNode.height) == lockedNode {
+
+			log.Infof("Allowing reorganize of depth %d beyond the "+
+				"max of %d to chainlocked block %v", depth,
+				b.maxReorgDepth, lockedNode.hash)
+			return nil
+		}
+	}
+
+	str := fmt.Sprintf("reorganize to block %v would disconnect %d "+
+		"blocks beyond the fork at height %d, which exceeds the max "+
+		"reorganize depth of %d", node.hash, depth, fork.height,
+		b.maxReorgDepth)
+	return ruleError(ErrReorgTooDeep, str)
+}
+
+// bestLockedChainTip returns the block with the most cumulative work that
+// contains the passed chainlocked block, including the block itself, and is
+// eligible to become the tip of the main chain since it and all of its
+// ancestors back to the chainlocked block are stored and not known to be
+// invalid.  Nil is returned when the chainlocked block itself is not eligible.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) bestLockedChainTip(lockedNode *blockNode) *blockNode {
+	b.index.RLock()
+	defer b.index.RUnlock()
+
+	eligible := func(n *blockNode) bool {
+		return n.status.HaveData() && !n.status.KnownInvalid()
+	}
+	if !eligible(lockedNode) {
+		return nil
+	}
+
+	best := lockedNode
+	for _, node := range b.index.index {
+		if node.height <= lockedNode.height ||
+			node.workSum.Cmp(best.workSum) <= 0 ||
+			node.Ancestor(lockedNode.height) != lockedNode {
+
+			continue
+		}
+
+		n := node
+		for n != lockedNode && eligible(n) {
+			n = n.parent
+		}
+		if n == lockedNode {
+			best = node
+		}
+	}
+	return best
+}
+
+// ChainLockUpdated reconsiders the best chain once the best ChainLock of the
+// LockStatusProvider the chain was configured with has changed.  Side chains
+// with more work than the main chain are rejected with ErrReorgTooDeep when the
+// reorganize would exceed the max reorganize depth, so when the new ChainLock
+// is for a block of such a side chain, the side chain with the most work that
+// contains the chainlocked block is made the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainLockUpdated() error {
+	if b.lockStatus == nil || b.maxReorgDepth <= 0 {
+		return nil
+	}
+	lockedHash := b.lockStatus.BestChainLock()
+	if lockedHash == nil {
+		return nil
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	lockedNode := b.index.LookupNode(lockedHash)
+	if lockedNode == nil || b.bestChain.Contains(lockedNode) {
+		return nil
+	}
+	node := b.bestLockedChainTip(lockedNode)
+	if node == nil || node.workSum.Cmp(b.bestChain.Tip().workSum) <= 0 {
+		return nil
+	}
+	if err := b.checkReorgDepth(node); err != nil {
+		return err
+	}
+	detachNodes, attachNodes := b.getReorganizeNodes(node)
+
+	// Reorganize the chain.
+	log.Infof("REORGANIZE: ChainLock of block %v is causing a reorganize.",
+		lockedNode.hash)
+	err := b.reorganizeChain(detachNodes, attachNodes)
+
+	// Either getReorganizeNodes or reorganizeChain could have made unsaved
+	// changes to the block index, so flush regardless of whether there was
+	// an error.
+	if writeErr := b.index.flushToDB(); writeErr != nil {
+		log.Warnf("Error flushing block index changes to disk: %v",
+			writeErr)
+	}
+
+	return err
+}
+
 // isCurrent returns whether or not the chain believes it is current.  Several
 // factors are used to guess, but the key factors that allow the chain to
 // believe it is current are:
 //   - Latest block height is after the latest checkpoint (if enabled)
 //   - Latest block has a timestamp newer than 24 hours ago
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) isCurrent() bool {
 	// Not current if the latest main (best) chain height is before the
 	// latest known good checkpoint (when checkpoints are enabled).
@@ -1235,10 +1569,16 @@ func (b *BlockChain) isCurrent() bool {
 //   - Latest block height is after the latest checkpoint (if enabled)
 //   - Latest block has a timestamp newer than 24 hours ago
 //
+// The chain is never current while the chain state is being reindexed.
+//
 // This function is safe for concurrent access.
 func (b *BlockChain) IsCurrent() bool {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	if b.isReindexing() {
+		return false
+	}
+
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
 
 	return b.isCurrent()
 }
@@ -1286,10 +1626,10 @@ func (b *BlockChain) MainChainHasBlock(hash *chainhash.Hash) bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) BlockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	node := b.index.LookupNode(hash)
 	locator := b.bestChain.blockLocator(node)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return locator
 }
 
@@ -1298,9 +1638,9 @@ func (b *BlockChain) BlockLocatorFromHash(hash *chainhash.Hash) BlockLocator {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) LatestBlockLocator() (BlockLocator, error) {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	locator := b.bestChain.BlockLocator(nil)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return locator, nil
 }
 
@@ -1477,7 +1817,8 @@ func (b *BlockChain) IntervalBlockHashes(endHash *chainhash.Hash, interval int,
 // This is primarily a helper function for the locateBlocks and locateHeaders
 // functions.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) locateInventory(locator BlockLocator, hashStop *chainhash.Hash, maxEntries uint32) (*blockNode, uint32) {
 	// There are no block locators so a specific block is being requested
 	// as identified by the stop hash.
@@ -1531,7 +1872,8 @@ func (b *BlockChain) locateInventory(locator BlockLocator, hashStop *chainhash.H
 //
 // See the comment on the exported function for more details on special cases.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) locateBlocks(locator BlockLocator, hashStop *chainhash.Hash, maxHashes uint32) []chainhash.Hash {
 	// Find the node after the first known block in the locator and the
 	// total number of nodes after it needed while respecting the stop hash
@@ -1564,9 +1906,9 @@ func (b *BlockChain) locateBlocks(locator BlockLocator, hashStop *chainhash.Hash
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) LocateBlocks(locator BlockLocator, hashStop *chainhash.Hash, maxHashes uint32) []chainhash.Hash {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	hashes := b.locateBlocks(locator, hashStop, maxHashes)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return hashes
 }
 
@@ -1576,7 +1918,8 @@ func (b *BlockChain) LocateBlocks(locator BlockLocator, hashStop *chainhash.Hash
 //
 // See the comment on the exported function for more details on special cases.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) locateHeaders(locator BlockLocator, hashStop *chainhash.Hash, maxHeaders uint32) []wire.BlockHeader {
 	// Find the node after the first known block in the locator and the
 	// total number of nodes after it needed while respecting the stop hash
@@ -1609,9 +1952,9 @@ func (b *BlockChain) locateHeaders(locator BlockLocator, hashStop *chainhash.Has
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) LocateHeaders(locator BlockLocator, hashStop *chainhash.Hash) []wire.BlockHeader {
-	b.chainLock.RLock()
+	b.indexLock.RLock()
 	headers := b.locateHeaders(locator, hashStop, wire.MaxBlockHeadersPerMsg)
-	b.chainLock.RUnlock()
+	b.indexLock.RUnlock()
 	return headers
 }
 
@@ -1692,6 +2035,14 @@ type Config struct {
 	// index manager.
 	IndexManager IndexManager
 
+	// StateCommitters defines the state stored outside of the chain
+	// database, such as the deterministic masternode list, which is kept
+	// at the same block as the chain state when connecting and
+	// disconnecting blocks.  See StateCommitter for details.
+	//
+	// This field can be nil if the caller does not keep such state.
+	StateCommitters []StateCommitter
+
 	// HashCache defines a transaction hash mid-state cache to use when
 	// validating transactions. This cache has the potential to greatly
 	// speed up transaction validation as re-using the pre-calculated
@@ -1701,6 +2052,54 @@ type Config struct {
 	// This field can be nil if the caller is not interested in using a
 	// signature cache.
 	HashCache *txscript.HashCache
+
+	// TxLocator defines the means to locate transactions within the main
+	// chain, which is required to determine their finality status.
+	//
+	// This field can be nil if the caller does not wish to query the
+	// finality of transactions.
+	TxLocator TxLocator
+
+	// LockStatus defines the source of the InstantSend and ChainLock state
+	// used when determining the finality status of transactions.
+	//
+	// This field can be nil, in which case transactions are never
+	// considered locked.
+	LockStatus LockStatusProvider
+
+	// SlowBlockThreshold defines the total validation time at which a
+	// warning with the per-stage timing breakdown of the block is logged.
+	// The slowest blocks are available via SlowestBlocks regardless.
+	//
+	// This field can be zero to disable the warnings.
+	SlowBlockThreshold time.Duration
+
+	// MaxReorgDepth defines the maximum number of blocks a reorganization
+	// may disconnect from the main chain unless the new best chain contains
+	// the block of the best ChainLock provided by LockStatus.  Deeper
+	// reorganizations are rejected with ErrReorgTooDeep and the blocks of
+	// the new chain remain on a side chain until ChainLockUpdated is invoked
+	// for a ChainLock on it.  This is a policy rather than a consensus rule
+	// which protects services that credit deposits after a number of
+	// confirmations against deep reorganizations.
+	//
+	// The limit is ignored when LockStatus is nil, since the chain would
+	// otherwise be unable to follow the network past a rejected
+	// reorganization.
+	//
+	// This field can be zero to allow reorganizations of any depth.
+	MaxReorgDepth int32
+
+	// Reindex specifies that the utxo set, the spend journal and the main
+	// chain block index are rebuilt from the stored blocks on start up,
+	// along with the optional indexes when the index manager implements
+	// IndexRebuilder.  A reindex that was interrupted is always resumed
+	// regardless of this field.
+	//
+	// The reindex is performed in the background after New returns and no
+	// blocks are processed until it is complete, which is signaled by the
+	// channel returned by ReindexDone.
+	Reindex bool
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1716,6 +2115,22 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, AssertError("blockchain.New timesource is nil")
 	}
 
+	// Ensure devnet parameters identify the devnet so that it is protected
+	// against the blocks and messages of other devnets.
+	if name := config.ChainParams.DevNetName; name != "" {
+		if config.ChainParams.DevNetGenesisHash == nil {
+			return nil, AssertError("blockchain.New devnet genesis " +
+				"hash is nil")
+		}
+		if config.ChainParams.Net != chaincfg.DevNetMagic(name) {
+			return nil, AssertError(fmt.Sprintf("blockchain.New "+
+				"devnet magic %#08x does not match the magic "+
+				"%#08x derived from devnet name %q",
+				uint32(config.ChainParams.Net),
+				uint32(chaincfg.DevNetMagic(name)), name))
+		}
+	}
+
 	// Generate a checkpoint by height map from the provided checkpoints
 	// and assert the provided checkpoints are sorted by height as required.
 	var checkpointsByHeight map[int32]*chaincfg.Checkpoint
@@ -1751,6 +2166,10 @@ func New(config *Config) (*BlockChain, error) {
 		blocksPerRetarget:   int32(targetTimespan / targetTimePerBlock),
 		index:               newBlockIndex(config.DB, params),
 		hashCache:           config.HashCache,
+		txLocator:           config.TxLocator,
+		lockStatus:          config.LockStatus,
+		maxReorgDepth:       config.MaxReorgDepth,
+		timings:             newBlockTimings(config.SlowBlockThreshold),
 		bestChain:           newChainView(nil),
 		orphans:             make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
@@ -1784,24 +2203,82 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Rebuild the chain state from the stored blocks in the background
+	// when requested or when a previous reindex was interrupted.  The rest
+	// of the initialization depends on the rebuilt chain state, so it is
+	// only completed once the reindex is.
+	reindex, err := b.pendingReindex(config.Reindex)
+	if err != nil {
+		return nil, err
+	}
+	b.reindexDone = make(chan struct{})
+	if reindex != nil {
+		go func() {
+			err := b.reindex(reindex, config.Interrupt)
+			if err == nil {
+				err = b.finishInit(config)
+			}
+			if err != nil && err != errInterruptRequested {
+				log.Errorf("Unable to reindex the chain state: %v", err)
+			}
+			b.reindexErr = err
+			close(b.reindexDone)
+		}()
+		return &b, nil
+	}
+
+	if err := b.finishInit(config); err != nil {
+		return nil, err
+	}
+	close(b.reindexDone)
+	return &b, nil
+}
+
+// finishInit completes the initialization of the chain once the chain state is
+// complete, which involves recovering the external state, initializing the
+// optional indexes and the threshold state caches, and connecting the devnet
+// genesis block when needed.
+func (b *BlockChain) finishInit(config *Config) error {
+	// Complete the commit of the external state which was interrupted by
+	// a crash, if any, and catch up state which is behind the chain.  The
+	// state committers are only attached after a reindex since it rebuilds
+	// the chain state up to the block they are already at.
+	b.chainLock.Lock()
+	b.stateCommitters = config.StateCommitters
+	err := b.recoverState(config.Interrupt)
+	b.chainLock.Unlock()
+	if err != nil {
+		return err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
-		err := config.IndexManager.Init(&b, config.Interrupt)
+		err := config.IndexManager.Init(b, config.Interrupt)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	// Initialize rule change threshold state caches.
 	if err := b.initThresholdCaches(); err != nil {
-		return nil, err
+		return err
+	}
+
+	// Connect the devnet genesis block when it is known and the chain only
+	// contains the genesis block shared by all devnets.
+	devNetGenesis := b.chainParams.DevNetGenesisBlock
+	if devNetGenesis != nil && b.bestChain.Tip().height == 0 {
+		_, _, err := b.processBlock(btcutil.NewBlock(devNetGenesis), BFNone)
+		if err != nil {
+			return err
+		}
 	}
 
 	bestNode := b.bestChain.Tip()
 	log.Infof("Chain state (height %d, hash %v, totaltx %d, work %v)",
-		bestNode.height, bestNode.hash, b.stateSnapshot.TotalTxns,
+		bestNode.height, bestNode.hash, b.BestSnapshot().TotalTxns,
 		bestNode.workSum)
 
-	return &b, nil
+	return nil
 }
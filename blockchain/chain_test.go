@@ -36,8 +36,8 @@ func TestHaveBlock(t *testing.T) {
 	}
 
 	// Create a new database and chain instance to run tests against.
-	chain, teardownFunc, err := chainSetup("haveblock",
-		&chaincfg.MainNetParams)
+	params := bitcoinGenesisParams(blocks[0])
+	chain, teardownFunc, err := chainSetup("haveblock", params)
 	if err != nil {
 		t.Errorf("Failed to setup chain instance: %v", err)
 		return
@@ -49,7 +49,7 @@ func TestHaveBlock(t *testing.T) {
 	chain.TstSetCoinbaseMaturity(1)
 
 	for i := 1; i < len(blocks); i++ {
-		_, isOrphan, err := chain.ProcessBlock(blocks[i], BFNone)
+		_, isOrphan, err := chain.ProcessBlock(blocks[i], BFNoPoWCheck)
 		if err != nil {
 			t.Errorf("ProcessBlock fail on block %v: %v\n", i, err)
 			return
@@ -63,7 +63,7 @@ func TestHaveBlock(t *testing.T) {
 
 	// Insert an orphan block.
 	_, isOrphan, err := chain.ProcessBlock(btcutil.NewBlock(&Block100000),
-		BFNone)
+		BFNoPoWCheck)
 	if err != nil {
 		t.Errorf("Unable to process block: %v", err)
 		return
@@ -79,7 +79,7 @@ func TestHaveBlock(t *testing.T) {
 		want bool
 	}{
 		// Genesis block should be present (in the main chain).
-		{hash: chaincfg.MainNetParams.GenesisHash.String(), want: true},
+		{hash: params.GenesisHash.String(), want: true},
 
 		// Block 3a should be present (on a side chain).
 		{hash: "00000000474284d20067a4d33f6a02284e6ef70764a3a26d6a5b9df52ef663dd", want: true},
@@ -964,3 +964,239 @@ func TestIntervalBlockHashes(t *testing.T) {
 		}
 	}
 }
+
+// TestOrphanBlockPoolLimit ensures the orphan block pool never grows beyond
+// its limit, even when the oldest orphan it previously tracked has since been
+// removed from the pool.
+func TestOrphanBlockPoolLimit(t *testing.T) {
+	chain := newFakeChain(&chaincfg.MainNetParams)
+	chain.orphans = make(map[chainhash.Hash]*orphanBlock)
+	chain.prevOrphans = make(map[chainhash.Hash][]*orphanBlock)
+
+	// makeOrphan returns a unique block that builds on an unknown parent.
+	var nonce uint32
+	makeOrphan := func() *btcutil.Block {
+		nonce++
+		return btcutil.NewBlock(&wire.MsgBlock{
+			Header: wire.BlockHeader{
+				PrevBlock: chainhash.Hash{0x01},
+				Nonce:     nonce,
+			},
+		})
+	}
+
+	// Add two orphans so the first is tracked as the oldest and then make
+	// it expire so it is removed when the next orphan is added.
+	first := makeOrphan()
+	chain.addOrphanBlock(first)
+	chain.addOrphanBlock(makeOrphan())
+	chain.orphans[*first.Hash()].expiration = time.Now().Add(-time.Second)
+	chain.addOrphanBlock(makeOrphan())
+	if chain.IsKnownOrphan(first.Hash()) {
+		t.Fatal("expired orphan was not removed")
+	}
+
+	// Fill the pool well beyond its limit.
+	for i := 0; i < maxOrphanBlocks*2; i++ {
+		chain.addOrphanBlock(makeOrphan())
+		if len(chain.orphans) > maxOrphanBlocks {
+			t.Fatalf("orphan pool has %d blocks, more than the "+
+				"limit of %d", len(chain.orphans), maxOrphanBlocks)
+		}
+	}
+
+	// The orphans must all still be indexed by their parent.
+	numIndexed := len(chain.prevOrphans[chainhash.Hash{0x01}])
+	if numIndexed != len(chain.orphans) {
+		t.Fatalf("%d orphans indexed by parent, want %d", numIndexed,
+			len(chain.orphans))
+	}
+}
+
+// TestReadsDuringBlockProcessing ensures queries of the chain tip and the utxo
+// set do not wait for the chain lock, which is held for the entire time blocks
+// are processed.
+func TestReadsDuringBlockProcessing(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("readsduringprocessing",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Simulate a block being processed.
+	chain.chainLock.Lock()
+	defer chain.chainLock.Unlock()
+
+	done := make(chan error)
+	go func() {
+		genesisHash := chain.chainParams.GenesisHash
+		chain.IsCurrent()
+		chain.LatestBlockLocator()
+		chain.LocateHeaders(nil, genesisHash)
+		if _, err := chain.FetchUtxoEntry(wire.OutPoint{}); err != nil {
+			done <- err
+			return
+		}
+		if _, err := chain.CalcNextRequiredDifficulty(time.Now()); err != nil {
+			done <- err
+			return
+		}
+		if _, err := chain.CalcNextBlockVersion(); err != nil {
+			done <- err
+			return
+		}
+		_, err := chain.DeploymentStatus(chaincfg.DeploymentTestDummy)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reads blocked by the chain lock")
+	}
+}
+
+// TestCheckReorgDepth ensures reorganizations deeper than the configured
+// maximum are rejected unless the new chain is chainlocked.
+func TestCheckReorgDepth(t *testing.T) {
+	t.Parallel()
+
+	// Create a main chain of 10 blocks on top of the genesis block and a
+	// side chain of 5 blocks forking from the 6th block along with another
+	// one of 8 blocks forking from the 3rd block.
+	chain := newFakeChain(&chaincfg.MainNetParams)
+	tip := chain.bestChain.Tip()
+	nodes := make([]*blockNode, 0, 10)
+	for i := 0; i < 10; i++ {
+		tip = newFakeNode(tip, 1, 0, time.Unix(tip.timestamp+1, 0))
+		chain.index.AddNode(tip)
+		nodes = append(nodes, tip)
+	}
+	chain.bestChain.SetTip(tip)
+	sideChain := func(fork *blockNode, n int) []*blockNode {
+		side := make([]*blockNode, 0, n)
+		for node := fork; len(side) < n; {
+			node = newFakeNode(node, 2, 0, time.Unix(node.timestamp+1, 0))
+			chain.index.AddNode(node)
+			side = append(side, node)
+		}
+		return side
+	}
+	shallow := sideChain(nodes[5], 5)
+	deep := sideChain(nodes[2], 8)
+	shallowTip, deepTip := shallow[len(shallow)-1], deep[len(deep)-1]
+
+	isReorgTooDeep := func(err error) bool {
+		rerr, ok := err.(RuleError)
+		return ok && rerr.ErrorCode == ErrReorgTooDeep
+	}
+
+	// Any reorganization is allowed without a max depth.
+	for _, node := range []*blockNode{shallowTip, deepTip} {
+		if err := chain.checkReorgDepth(node); err != nil {
+			t.Fatalf("checkReorgDepth: unexpected error: %v", err)
+		}
+	}
+
+	// The max depth is not enforced without a source of ChainLocks which
+	// could allow a deeper reorganization.
+	chain.maxReorgDepth = 5
+	for _, node := range []*blockNode{shallowTip, deepTip} {
+		if err := chain.checkReorgDepth(node); err != nil {
+			t.Fatalf("checkReorgDepth: unexpected error: %v", err)
+		}
+	}
+
+	// Only the shallow reorganization of 4 blocks is allowed with a max
+	// depth of 5 once there is a source of ChainLocks.
+	lockStatus := &fakeLockStatus{}
+	chain.lockStatus = lockStatus
+	if err := chain.checkReorgDepth(shallowTip); err != nil {
+		t.Fatalf("checkReorgDepth: unexpected error: %v", err)
+	}
+	err := chain.checkReorgDepth(deepTip)
+	if !isReorgTooDeep(err) {
+		t.Fatalf("checkReorgDepth: got error %v, want %v", err,
+			ErrReorgTooDeep)
+	}
+
+	// A ChainLock on the main chain or below the fork doesn't allow the
+	// deep reorganization, while one on the new chain does.
+	tests := []struct {
+		name    string
+		locked  *blockNode
+		allowed bool
+	}{
+		{"main chain tip", nodes[9], false},
+		{"fork point", nodes[2], false},
+		{"side chain", deep[3], true},
+		{"side chain tip", deepTip, true},
+		{"other side chain", shallowTip, false},
+	}
+	for _, test := range tests {
+		lockStatus.chainLock = &test.locked.hash
+		err := chain.checkReorgDepth(deepTip)
+		if test.allowed && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !test.allowed && !isReorgTooDeep(err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				ErrReorgTooDeep)
+		}
+	}
+}
+
+// TestBestLockedChainTip ensures the tip of the chain with the most work which
+// contains a chainlocked block is selected among the eligible blocks.
+func TestBestLockedChainTip(t *testing.T) {
+	t.Parallel()
+
+	// Create a main chain of 5 blocks on top of the genesis block, a side
+	// chain of 6 blocks forking from the 2nd block and a branch of 2
+	// blocks forking from the 3rd block of the side chain.
+	chain := newFakeChain(&chaincfg.MainNetParams)
+	bits := chaincfg.MainNetParams.PowLimitBits
+	addChain := func(fork *blockNode, n int, version int32) []*blockNode {
+		nodes := make([]*blockNode, 0, n)
+		for node := fork; len(nodes) < n; {
+			node = newFakeNode(node, version, bits,
+				time.Unix(node.timestamp+1, 0))
+			node.status = statusDataStored
+			chain.index.AddNode(node)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	main := addChain(chain.bestChain.Tip(), 5, 1)
+	chain.bestChain.SetTip(main[len(main)-1])
+	side := addChain(main[1], 6, 2)
+	branch := addChain(side[2], 2, 3)
+
+	if got := chain.bestLockedChainTip(side[2]); got != side[5] {
+		t.Fatalf("bestLockedChainTip: got %v, want %v", got.hash,
+			side[5].hash)
+	}
+
+	// The branch has the most work once the side chain is invalid above
+	// the chainlocked block.
+	chain.index.SetStatusFlags(side[4], statusValidateFailed)
+	if got := chain.bestLockedChainTip(side[2]); got != branch[1] {
+		t.Fatalf("bestLockedChainTip: got %v, want %v", got.hash,
+			branch[1].hash)
+	}
+
+	// Blocks which are not stored are not eligible, nor are their
+	// descendants.
+	chain.index.UnsetStatusFlags(branch[0], statusDataStored)
+	if got := chain.bestLockedChainTip(side[2]); got != side[3] {
+		t.Fatalf("bestLockedChainTip: got %v, want %v", got.hash,
+			side[3].hash)
+	}
+	chain.index.UnsetStatusFlags(side[2], statusDataStored)
+	if got := chain.bestLockedChainTip(side[2]); got != nil {
+		t.Fatalf("bestLockedChainTip: got %v, want nil", got.hash)
+	}
+}
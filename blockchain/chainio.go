@@ -260,8 +260,8 @@ type SpentTxOut struct {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) FetchSpendJournal(targetBlock *btcutil.Block) ([]SpentTxOut, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 
 	var spendEntries []SpentTxOut
 	err := b.db.View(func(dbTx database.Tx) error {
@@ -55,9 +55,9 @@ func (b *BlockChain) LatestCheckpoint() *chaincfg.Checkpoint {
 	return &b.checkpoints[len(b.checkpoints)-1]
 }
 
-// verifyCheckpoint returns whether the passed block height and hash combination
-// match the checkpoint data.  It also returns true if there is no checkpoint
-// data for the passed block height.
+// verifyCheckpoint returns whether the passed block height and X11 hash
+// combination match the checkpoint data.  It also returns true if there is no
+// checkpoint data for the passed block height.
 func (b *BlockChain) verifyCheckpoint(height int32, hash *chainhash.Hash) bool {
 	if !b.HasCheckpoints() {
 		return true
@@ -78,6 +78,24 @@ func (b *BlockChain) verifyCheckpoint(height int32, hash *chainhash.Hash) bool {
 	return true
 }
 
+// lookupCheckpointNode returns the main chain block node at the height of the
+// passed checkpoint when its X11 hash matches the checkpoint hash.  Checkpoint
+// hashes are the X11 hashes Dash identifies blocks by, so they can't be looked
+// up in the block index directly.
+//
+// This function MUST be called with the chain lock held (for reads).
+func (b *BlockChain) lookupCheckpointNode(checkpoint *chaincfg.Checkpoint) *blockNode {
+	node := b.bestChain.NodeByHeight(checkpoint.Height)
+	if node == nil {
+		return nil
+	}
+	header := node.Header()
+	if header.PowHash() != *checkpoint.Hash {
+		return nil
+	}
+	return node
+}
+
 // findPreviousCheckpoint finds the most recent checkpoint that is already
 // available in the downloaded portion of the block chain and returns the
 // associated block node.  It returns nil if a checkpoint can't be found (this
@@ -98,8 +116,8 @@ func (b *BlockChain) findPreviousCheckpoint() (*blockNode, error) {
 		// Loop backwards through the available checkpoints to find one
 		// that is already available.
 		for i := numCheckpoints - 1; i >= 0; i-- {
-			node := b.index.LookupNode(checkpoints[i].Hash)
-			if node == nil || !b.bestChain.Contains(node) {
+			node := b.lookupCheckpointNode(&checkpoints[i])
+			if node == nil {
 				continue
 			}
 
@@ -143,7 +161,7 @@ func (b *BlockChain) findPreviousCheckpoint() (*blockNode, error) {
 	// this lookup fails something is very wrong since the chain has already
 	// passed the checkpoint which was verified as accurate before inserting
 	// it.
-	checkpointNode := b.index.LookupNode(b.nextCheckpoint.Hash)
+	checkpointNode := b.lookupCheckpointNode(b.nextCheckpoint)
 	if checkpointNode == nil {
 		return nil, AssertError(fmt.Sprintf("findPreviousCheckpoint "+
 			"failed lookup of known good block node %s",
@@ -198,8 +216,8 @@ func isNonstandardTransaction(tx *btcutil.Tx) bool {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) IsCheckpointCandidate(block *btcutil.Block) (bool, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
 
 	// A checkpoint must be in the main chain.
 	node := b.index.LookupNode(block.Hash())
@@ -115,6 +115,16 @@ func loadBlocks(filename string) (blocks []*btcutil.Block, err error) {
 	return
 }
 
+// bitcoinGenesisParams returns a copy of the main network parameters with the
+// passed genesis block, which is the Bitcoin genesis block the blocks of the
+// testdata files build on instead of the Dash genesis block.
+func bitcoinGenesisParams(genesis *btcutil.Block) *chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.GenesisBlock = genesis.MsgBlock()
+	params.GenesisHash = genesis.Hash()
+	return &params
+}
+
 // chainSetup is used to create a new db and chain instance with the genesis
 // block already inserted.  In addition to the new chain instance, it returns
 // a teardown function the caller should invoke when done testing to clean up.
@@ -357,6 +367,8 @@ func newFakeChain(params *chaincfg.Params) *BlockChain {
 	targetTimespan := int64(params.TargetTimespan / time.Second)
 	targetTimePerBlock := int64(params.TargetTimePerBlock / time.Second)
 	adjustmentFactor := params.RetargetAdjustmentFactor
+	reindexDone := make(chan struct{})
+	close(reindexDone)
 	b := &BlockChain{
 		chainParams:         params,
 		timeSource:          NewMedianTime(),
@@ -367,6 +379,7 @@ func newFakeChain(params *chaincfg.Params) *BlockChain {
 		bestChain:           newChainView(node),
 		warningCaches:       newThresholdCaches(vbNumBits),
 		deploymentCaches:    newThresholdCaches(chaincfg.DefinedDeployments),
+		reindexDone:         reindexDone,
 	}
 
 	for _, deployment := range params.Deployments {
@@ -220,6 +220,17 @@ const (
 	// current chain tip. This is not a block validation rule, but is required
 	// for block proposals submitted via getblocktemplate RPC.
 	ErrPrevBlockNotBest
+
+	// ErrBadDevNetGenesis indicates that the block at height one of a
+	// devnet is not the devnet genesis block, which usually means the
+	// block belongs to a different devnet.
+	ErrBadDevNetGenesis
+
+	// ErrReorgTooDeep indicates that a side chain with more work than the
+	// main chain was not made the main chain because the reorganization
+	// would disconnect more blocks than the configured maximum and the
+	// new chain is not chainlocked.  This is not a block validation rule.
+	ErrReorgTooDeep
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -267,6 +278,8 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrPreviousBlockUnknown:      "ErrPreviousBlockUnknown",
 	ErrInvalidAncestorBlock:      "ErrInvalidAncestorBlock",
 	ErrPrevBlockNotBest:          "ErrPrevBlockNotBest",
+	ErrBadDevNetGenesis:          "ErrBadDevNetGenesis",
+	ErrReorgTooDeep:              "ErrReorgTooDeep",
 }
 
 // String returns the ErrorCode as a human-readable name.
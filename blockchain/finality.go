@@ -0,0 +1,184 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+)
+
+// TxLocator provides the location of transactions within the blocks of the
+// main chain.  It is typically satisfied by the transaction index.
+type TxLocator interface {
+	// TxBlockRegion returns the block region for the provided transaction
+	// hash.  Both the region and the error must be nil when the
+	// transaction is not known.
+	TxBlockRegion(hash *chainhash.Hash) (*database.BlockRegion, error)
+}
+
+// LockStatusProvider provides the InstantSend and ChainLock state the network
+// has reached consensus on via the masternode quorums.
+type LockStatusProvider interface {
+	// IsInstantSendLocked returns whether or not a valid InstantSend lock
+	// has been received for the provided transaction hash.
+	IsInstantSendLocked(txHash *chainhash.Hash) bool
+
+	// BestChainLock returns the hash of the most recent block a valid
+	// ChainLock has been received for, or nil when there is none.  The
+	// block and all of its ancestors are considered chainlocked.
+	BestChainLock() *chainhash.Hash
+}
+
+// FinalityRules houses the conditions under which a transaction is considered
+// final, that is, safe to credit.  A transaction is final when any one of the
+// enabled conditions is met.
+type FinalityRules struct {
+	// MinConfirmations is the number of confirmations after which a
+	// transaction is final regardless of any locks.  A value of zero
+	// disables finality through confirmations.
+	MinConfirmations int32
+
+	// InstantSend specifies whether or not InstantSend locked transactions
+	// are final, whether or not they have been mined.
+	InstantSend bool
+
+	// ChainLocks specifies whether or not transactions in a chainlocked
+	// block are final.
+	ChainLocks bool
+}
+
+// DefaultFinalityRules are the finality rules commonly used to credit
+// deposits, where a transaction is final once it is InstantSend locked,
+// chainlocked or has six confirmations.
+var DefaultFinalityRules = FinalityRules{
+	MinConfirmations: 6,
+	InstantSend:      true,
+	ChainLocks:       true,
+}
+
+// TxFinality describes the finality status of a transaction as returned by
+// FinalityStatus.
+type TxFinality struct {
+	// BlockHash is the hash of the main chain block the transaction is
+	// included in, or nil when it has not been mined in the main chain.
+	BlockHash *chainhash.Hash
+
+	// Confirmations is the number of main chain blocks including and built
+	// on top of the block which contains the transaction.
+	Confirmations int32
+
+	// InstantSendLocked indicates the transaction is InstantSend locked.
+	InstantSendLocked bool
+
+	// ChainLocked indicates the block containing the transaction is
+	// chainlocked.
+	ChainLocked bool
+
+	// Final indicates the transaction satisfies the finality rules it was
+	// evaluated against.
+	Final bool
+}
+
+// FinalityStatus returns the finality status of the transaction with the
+// provided hash according to the passed rules, or DefaultFinalityRules when
+// they are nil.
+//
+// Transactions which are not found in the main chain are reported with zero
+// confirmations, since they may still be in the memory pool, and can only be
+// final through an InstantSend lock.  It is up to the caller to determine
+// whether or not such a transaction actually exists.
+//
+// An error is returned when the chain was not configured with a TxLocator.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FinalityStatus(txHash *chainhash.Hash, rules *FinalityRules) (*TxFinality, error) {
+	if b.txLocator == nil {
+		return nil, fmt.Errorf("unable to determine finality of "+
+			"transaction %v: no transaction locator", txHash)
+	}
+	if rules == nil {
+		rules = &DefaultFinalityRules
+	}
+
+	region, err := b.txLocator.TxBlockRegion(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var status TxFinality
+	if b.lockStatus != nil {
+		status.InstantSendLocked = b.lockStatus.IsInstantSendLocked(txHash)
+	}
+
+	// Determine the number of confirmations and whether or not the block
+	// is chainlocked when the transaction is in the main chain.  The index
+	// might briefly refer to a block that is being disconnected, so the
+	// block is also required to be part of the main chain.
+	if region != nil {
+		b.indexLock.RLock()
+		tip := b.bestChain.Tip()
+		node := b.index.LookupNode(region.Hash)
+		if node != nil && b.bestChain.Contains(node) {
+			status.BlockHash = &node.hash
+			status.Confirmations = tip.height - node.height + 1
+
+			if b.lockStatus != nil {
+				status.ChainLocked = b.isChainLocked(node)
+			}
+		}
+		b.indexLock.RUnlock()
+	}
+
+	switch {
+	case rules.InstantSend && status.InstantSendLocked:
+		status.Final = true
+	case rules.ChainLocks && status.ChainLocked:
+		status.Final = true
+	case rules.MinConfirmations > 0 &&
+		status.Confirmations >= rules.MinConfirmations:
+		status.Final = true
+	}
+
+	return &status, nil
+}
+
+// IsChainLocked returns whether or not the main chain block with the passed
+// hash is covered by the best ChainLock of the LockStatusProvider the chain was
+// configured with.  False is returned for blocks which are not in the main
+// chain and when the chain was not configured with a LockStatusProvider.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsChainLocked(hash *chainhash.Hash) bool {
+	if b.lockStatus == nil {
+		return false
+	}
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
+	node := b.index.LookupNode(hash)
+	if node == nil || !b.bestChain.Contains(node) {
+		return false
+	}
+	return b.isChainLocked(node)
+}
+
+// isChainLocked returns whether or not the passed main chain node is covered
+// by the best ChainLock, which is the case when it is the chainlocked block or
+// one of its ancestors.
+//
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
+func (b *BlockChain) isChainLocked(node *blockNode) bool {
+	lockedHash := b.lockStatus.BestChainLock()
+	if lockedHash == nil {
+		return false
+	}
+	lockedNode := b.index.LookupNode(lockedHash)
+	if lockedNode == nil || !b.bestChain.Contains(lockedNode) {
+		return false
+	}
+	return lockedNode.height >= node.height
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+)
+
+// fakeTxLocator provides a TxLocator backed by a map of transaction hashes to
+// the hashes of the blocks containing them.
+type fakeTxLocator map[chainhash.Hash]*chainhash.Hash
+
+func (l fakeTxLocator) TxBlockRegion(hash *chainhash.Hash) (*database.BlockRegion, error) {
+	blockHash, ok := l[*hash]
+	if !ok {
+		return nil, nil
+	}
+	return &database.BlockRegion{Hash: blockHash}, nil
+}
+
+// fakeLockStatus provides a LockStatusProvider with a fixed set of InstantSend
+// locked transactions and best ChainLock.
+type fakeLockStatus struct {
+	isLocked  map[chainhash.Hash]bool
+	chainLock *chainhash.Hash
+}
+
+func (s *fakeLockStatus) IsInstantSendLocked(txHash *chainhash.Hash) bool {
+	return s.isLocked[*txHash]
+}
+
+func (s *fakeLockStatus) BestChainLock() *chainhash.Hash {
+	return s.chainLock
+}
+
+// TestFinalityStatus ensures the finality status of transactions is reported
+// as expected for the various combinations of confirmations and locks.
+func TestFinalityStatus(t *testing.T) {
+	t.Parallel()
+
+	// Create a chain of 10 blocks on top of the genesis block along with
+	// a side chain block forking from the 5th block.
+	chain := newFakeChain(&chaincfg.MainNetParams)
+	tip := chain.bestChain.Tip()
+	nodes := make([]*blockNode, 0, 10)
+	for i := 0; i < 10; i++ {
+		tip = newFakeNode(tip, 1, 0, time.Unix(tip.timestamp+1, 0))
+		chain.index.AddNode(tip)
+		nodes = append(nodes, tip)
+	}
+	chain.bestChain.SetTip(tip)
+	sideNode := newFakeNode(nodes[4], 2, 0, time.Unix(0, 0))
+	chain.index.AddNode(sideNode)
+
+	// Ensure an error is returned when there is no way to locate
+	// transactions.
+	var txHash chainhash.Hash
+	if _, err := chain.FinalityStatus(&txHash, nil); err == nil {
+		t.Fatal("FinalityStatus: expected error without tx locator")
+	}
+
+	txInBlock := func(i int) chainhash.Hash { return chainhash.Hash{byte(i + 1)} }
+	locator := fakeTxLocator{}
+	for i, node := range nodes {
+		locator[txInBlock(i)] = &node.hash
+	}
+	sideTx := chainhash.Hash{0xff, 0x01}
+	locator[sideTx] = &sideNode.hash
+	mempoolTx := chainhash.Hash{0xff, 0x02}
+	lockedTx := chainhash.Hash{0xff, 0x03}
+
+	lockStatus := &fakeLockStatus{
+		isLocked: map[chainhash.Hash]bool{lockedTx: true},
+	}
+	chain.txLocator = locator
+	chain.lockStatus = lockStatus
+
+	tests := []struct {
+		name      string
+		txHash    chainhash.Hash
+		chainLock *blockNode
+		rules     *FinalityRules
+		want      TxFinality
+	}{{
+		name:   "six confirmations",
+		txHash: txInBlock(4),
+		want: TxFinality{
+			BlockHash:     &nodes[4].hash,
+			Confirmations: 6,
+			Final:         true,
+		},
+	}, {
+		name:   "five confirmations",
+		txHash: txInBlock(5),
+		want: TxFinality{
+			BlockHash:     &nodes[5].hash,
+			Confirmations: 5,
+		},
+	}, {
+		name:      "chainlocked ancestor",
+		txHash:    txInBlock(7),
+		chainLock: nodes[8],
+		want: TxFinality{
+			BlockHash:     &nodes[7].hash,
+			Confirmations: 3,
+			ChainLocked:   true,
+			Final:         true,
+		},
+	}, {
+		name:      "after chainlocked block",
+		txHash:    txInBlock(9),
+		chainLock: nodes[8],
+		want: TxFinality{
+			BlockHash:     &nodes[9].hash,
+			Confirmations: 1,
+		},
+	}, {
+		name:      "chainlocks disabled",
+		txHash:    txInBlock(7),
+		chainLock: nodes[8],
+		rules:     &FinalityRules{MinConfirmations: 6},
+		want: TxFinality{
+			BlockHash:     &nodes[7].hash,
+			Confirmations: 3,
+			ChainLocked:   true,
+		},
+	}, {
+		name:      "side chain",
+		txHash:    sideTx,
+		chainLock: nodes[8],
+		want:      TxFinality{},
+	}, {
+		name:   "unmined",
+		txHash: mempoolTx,
+		want:   TxFinality{},
+	}, {
+		name:   "instantsend locked",
+		txHash: lockedTx,
+		want: TxFinality{
+			InstantSendLocked: true,
+			Final:             true,
+		},
+	}, {
+		name:   "instantsend disabled",
+		txHash: lockedTx,
+		rules:  &FinalityRules{ChainLocks: true},
+		want: TxFinality{
+			InstantSendLocked: true,
+		},
+	}}
+
+	for _, test := range tests {
+		lockStatus.chainLock = nil
+		if test.chainLock != nil {
+			lockStatus.chainLock = &test.chainLock.hash
+		}
+
+		status, err := chain.FinalityStatus(&test.txHash, test.rules)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		gotHash, wantHash := status.BlockHash, test.want.BlockHash
+		if (gotHash == nil) != (wantHash == nil) ||
+			(gotHash != nil && *gotHash != *wantHash) {
+
+			t.Errorf("%s: mismatched block hash - got %v, want %v",
+				test.name, gotHash, wantHash)
+			continue
+		}
+		status.BlockHash, test.want.BlockHash = nil, nil
+		if *status != test.want {
+			t.Errorf("%s: mismatched status - got %+v, want %+v",
+				test.name, *status, test.want)
+		}
+	}
+}
+
+// TestIsChainLocked ensures main chain blocks are reported as chainlocked when
+// they are covered by the best ChainLock.
+func TestIsChainLocked(t *testing.T) {
+	t.Parallel()
+
+	chain := newFakeChain(&chaincfg.MainNetParams)
+	tip := chain.bestChain.Tip()
+	nodes := make([]*blockNode, 0, 5)
+	for i := 0; i < 5; i++ {
+		tip = newFakeNode(tip, 1, 0, time.Unix(tip.timestamp+1, 0))
+		chain.index.AddNode(tip)
+		nodes = append(nodes, tip)
+	}
+	chain.bestChain.SetTip(tip)
+	sideNode := newFakeNode(nodes[1], 2, 0, time.Unix(0, 0))
+	chain.index.AddNode(sideNode)
+
+	// Nothing is chainlocked without a lock status provider.
+	if chain.IsChainLocked(&nodes[0].hash) {
+		t.Fatal("IsChainLocked: block locked without lock status")
+	}
+
+	chain.lockStatus = &fakeLockStatus{chainLock: &nodes[3].hash}
+	tests := []struct {
+		name   string
+		hash   chainhash.Hash
+		locked bool
+	}{
+		{"ancestor", nodes[0].hash, true},
+		{"chainlocked block", nodes[3].hash, true},
+		{"after chainlocked block", nodes[4].hash, false},
+		{"side chain", sideNode.hash, false},
+		{"unknown", chainhash.Hash{0x01}, false},
+	}
+	for _, test := range tests {
+		if got := chain.IsChainLocked(&test.hash); got != test.locked {
+			t.Errorf("%s: got %v, want %v", test.name, got,
+				test.locked)
+		}
+	}
+}
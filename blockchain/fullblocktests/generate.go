@@ -313,8 +313,8 @@ func calcMerkleRoot(txns []*wire.MsgTx) chainhash.Hash {
 	return *merkles[len(merkles)-1]
 }
 
-// solveBlock attempts to find a nonce which makes the passed block header hash
-// to a value less than the target difficulty.  When a successful solution is
+// solveBlock attempts to find a nonce which makes the X11 hash of the passed
+// block header a value less than the target difficulty.  When a successful solution is
 // found true is returned and the nonce field of the passed header is updated
 // with the solution.  False is returned if no solution exists.
 //
@@ -342,7 +342,7 @@ func solveBlock(header *wire.BlockHeader) bool {
 				return
 			default:
 				hdr.Nonce = i
-				hash := hdr.BlockHash()
+				hash := hdr.PowHash()
 				if blockchain.HashToBig(&hash).Cmp(
 					targetDifficulty) <= 0 {
 
@@ -1441,11 +1441,11 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	{
 		origHash := b46.BlockHash()
 		for {
-			// Keep incrementing the nonce until the hash treated as
-			// a uint256 is higher than the limit.
+			// Keep incrementing the nonce until the X11 hash
+			// treated as a uint256 is higher than the limit.
 			b46.Header.Nonce++
-			blockHash := b46.BlockHash()
-			hashNum := blockchain.HashToBig(&blockHash)
+			powHash := b46.Header.PowHash()
+			hashNum := blockchain.HashToBig(&powHash)
 			if hashNum.Cmp(g.params.PowLimit) >= 0 {
 				break
 			}
@@ -0,0 +1,439 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcec/v2/ecdsa"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// HeaderBundleVersion is the current version of the serialized header
+	// bundle format.
+	HeaderBundleVersion = 1
+
+	// MaxHeaderBundleHeaders is the maximum number of block headers a
+	// header bundle may contain.  It bounds the memory used by a bundle
+	// from an untrusted source before its signature is verified.  Bundles
+	// downloaded for a network are bounded more tightly by
+	// HeaderBundleLimit.
+	MaxHeaderBundleHeaders = 4000000
+
+	// maxHeaderBundleSigLen is the maximum length of the DER encoded
+	// signature of a header bundle.
+	maxHeaderBundleSigLen = 72
+)
+
+// maxHeaderBundleSize returns the maximum size of a serialized header bundle
+// with up to the passed number of headers.
+func maxHeaderBundleSize(maxHeaders uint64) int64 {
+	return 8 + wire.MaxVarIntPayload +
+		int64(maxHeaders)*wire.MaxBlockHeaderPayload +
+		wire.MaxVarIntPayload + maxHeaderBundleSigLen
+}
+
+// HeaderBundleLimit returns the maximum number of headers a header bundle for
+// the passed network may reasonably contain at the passed time.  It is the
+// number of blocks expected to have been found since the genesis block at the
+// target block time plus a margin of a tenth for blocks which were found
+// faster, capped to MaxHeaderBundleHeaders.
+func HeaderBundleLimit(params *chaincfg.Params, now time.Time) uint64 {
+	elapsed := now.Sub(params.GenesisBlock.Header.Timestamp)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	expected := uint64(elapsed / params.TargetTimePerBlock)
+	limit := expected + expected/10 + wire.MaxBlockHeadersPerMsg
+	if limit > MaxHeaderBundleHeaders {
+		limit = MaxHeaderBundleHeaders
+	}
+	return limit
+}
+
+// HeaderBundle is a list of consecutive block headers signed by a trusted
+// publisher.  It allows the header chain to be bootstrapped from a source
+// other than the peer-to-peer network, such as an HTTPS server, before any
+// peers are connected.
+//
+// The serialized form is the version and network as little endian uint32s,
+// followed by the variable length list of headers and the variable length DER
+// encoded signature.  The signature commits to everything before it.
+type HeaderBundle struct {
+	Net       wire.BitcoinNet
+	Headers   []wire.BlockHeader
+	Signature []byte
+}
+
+// serializeUnsigned writes the version, network and headers of the bundle,
+// which are covered by its signature, to w.
+func (hb *HeaderBundle) serializeUnsigned(w io.Writer) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[:4], HeaderBundleVersion)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(hb.Net))
+	if _, err := w.Write(buf[:]); err != nil {
+		return err
+	}
+
+	err := wire.WriteVarInt(w, 0, uint64(len(hb.Headers)))
+	if err != nil {
+		return err
+	}
+	for i := range hb.Headers {
+		if err := hb.Headers[i].Serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SigHash returns the hash the signature of the bundle commits to.
+func (hb *HeaderBundle) SigHash() chainhash.Hash {
+	var buf bytes.Buffer
+	buf.Grow(8 + wire.MaxVarIntPayload +
+		len(hb.Headers)*wire.MaxBlockHeaderPayload)
+	_ = hb.serializeUnsigned(&buf)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// Serialize encodes the bundle to w.
+func (hb *HeaderBundle) Serialize(w io.Writer) error {
+	if err := hb.serializeUnsigned(w); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, hb.Signature)
+}
+
+// Deserialize decodes a bundle from r into the receiver.
+func (hb *HeaderBundle) Deserialize(r io.Reader) error {
+	return hb.deserialize(r, MaxHeaderBundleHeaders)
+}
+
+// deserialize decodes a bundle with up to the passed number of headers from r
+// into the receiver.
+func (hb *HeaderBundle) deserialize(r io.Reader, maxHeaders uint64) error {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return err
+	}
+	version := binary.LittleEndian.Uint32(buf[:4])
+	if version != HeaderBundleVersion {
+		return fmt.Errorf("unsupported header bundle version %d",
+			version)
+	}
+	hb.Net = wire.BitcoinNet(binary.LittleEndian.Uint32(buf[4:]))
+
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	if count > maxHeaders {
+		return fmt.Errorf("too many headers in bundle [count %d, "+
+			"max %d]", count, maxHeaders)
+	}
+
+	// Grow the headers as they are read rather than trusting the count
+	// to avoid allocating the max up front for a short bundle.
+	capacity := count
+	if capacity > wire.MaxBlockHeadersPerMsg {
+		capacity = wire.MaxBlockHeadersPerMsg
+	}
+	hb.Headers = make([]wire.BlockHeader, 0, capacity)
+	for i := uint64(0); i < count; i++ {
+		var header wire.BlockHeader
+		if err := header.Deserialize(r); err != nil {
+			return err
+		}
+		hb.Headers = append(hb.Headers, header)
+	}
+
+	hb.Signature, err = wire.ReadVarBytes(r, 0, maxHeaderBundleSigLen,
+		"header bundle signature")
+	return err
+}
+
+// Sign signs the bundle with the passed private key of the publisher.
+func (hb *HeaderBundle) Sign(key *btcec.PrivateKey) {
+	sigHash := hb.SigHash()
+	hb.Signature = ecdsa.Sign(key, sigHash[:]).Serialize()
+}
+
+// Verify returns an error unless the bundle is for the passed network and is
+// signed by the passed public key of the publisher.
+func (hb *HeaderBundle) Verify(net wire.BitcoinNet, pubKey *btcec.PublicKey) error {
+	if hb.Net != net {
+		return fmt.Errorf("header bundle is for network %v instead of %v",
+			hb.Net, net)
+	}
+
+	sig, err := ecdsa.ParseDERSignature(hb.Signature)
+	if err != nil {
+		return fmt.Errorf("malformed header bundle signature: %v", err)
+	}
+	sigHash := hb.SigHash()
+	if !sig.Verify(sigHash[:], pubKey) {
+		return errors.New("invalid header bundle signature")
+	}
+	return nil
+}
+
+// HeaderSource provides signed header bundles used to bootstrap the header
+// chain.
+type HeaderSource interface {
+	// FetchHeaderBundle returns a header bundle which has been verified
+	// to be signed by the trusted publisher of the source.
+	FetchHeaderBundle(ctx context.Context) (*HeaderBundle, error)
+}
+
+// HTTPSHeaderSource is a HeaderSource which downloads header bundles over
+// HTTPS.  The URLs are tried in order until a valid bundle is downloaded, so
+// the later ones serve as fallbacks.
+type HTTPSHeaderSource struct {
+	// URLs are the HTTPS URLs of the header bundle.
+	URLs []string
+
+	// PubKey is the public key of the publisher the bundle must be signed
+	// by.
+	PubKey *btcec.PublicKey
+
+	// Net is the network the bundle must be for.
+	Net wire.BitcoinNet
+
+	// Client is the HTTP client used for the downloads.  This field can
+	// be omitted in which case http.DefaultClient is used.
+	Client *http.Client
+
+	// MaxHeaders is the maximum number of headers the bundle may contain,
+	// which bounds the size of the downloads.  This field can be omitted
+	// in which case MaxHeaderBundleHeaders is used.
+	MaxHeaders uint64
+}
+
+// Ensure HTTPSHeaderSource implements the HeaderSource interface.
+var _ HeaderSource = (*HTTPSHeaderSource)(nil)
+
+// fetch downloads and verifies the header bundle at the passed URL.
+func (s *HTTPSHeaderSource) fetch(ctx context.Context, bundleURL string) (*HeaderBundle, error) {
+	u, err := url.Parse(bundleURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, errors.New("header bundles must be fetched over " +
+			"https")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		bundleURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %q", resp.Status)
+	}
+
+	maxHeaders := s.MaxHeaders
+	if maxHeaders == 0 || maxHeaders > MaxHeaderBundleHeaders {
+		maxHeaders = MaxHeaderBundleHeaders
+	}
+	var bundle HeaderBundle
+	body := io.LimitReader(resp.Body, maxHeaderBundleSize(maxHeaders))
+	if err := bundle.deserialize(body, maxHeaders); err != nil {
+		return nil, fmt.Errorf("malformed header bundle: %v", err)
+	}
+	if err := bundle.Verify(s.Net, s.PubKey); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// FetchHeaderBundle downloads a header bundle from the first URL which serves
+// a valid one.  An error describing the failure of each URL is returned when
+// none of them does.
+//
+// This is part of the HeaderSource interface implementation.
+func (s *HTTPSHeaderSource) FetchHeaderBundle(ctx context.Context) (*HeaderBundle, error) {
+	if len(s.URLs) == 0 {
+		return nil, errors.New("no header bundle urls")
+	}
+
+	var errs []error
+	for _, bundleURL := range s.URLs {
+		bundle, err := s.fetch(ctx, bundleURL)
+		if err == nil {
+			return bundle, nil
+		}
+		log.Debugf("Unable to fetch header bundle from %s: %v",
+			bundleURL, err)
+		errs = append(errs, fmt.Errorf("%s: %v", bundleURL, err))
+
+		// There is no point in trying the fallbacks once the context
+		// is done.
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("unable to fetch header bundle: %v", errs)
+}
+
+// ProcessBootstrapHeaders validates the passed consecutive block headers and
+// uses them as the bootstrapped header chain when it has more work than both
+// the best chain and the current bootstrapped header chain.  The first header
+// must either be a known block or build on one.  Headers of known blocks are
+// skipped, while the others are fully validated except for their blocks.
+//
+// The number of headers beyond the known blocks is returned, which is zero
+// when the headers were not used.  ErrReindexing is returned while the chain
+// state is being reindexed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessBootstrapHeaders(headers []wire.BlockHeader) (int, error) {
+	if b.isReindexing() {
+		return 0, ErrReindexing
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	var prevNode *blockNode
+	var numNew int
+	for i := range headers {
+		header := &headers[i]
+		hash := header.BlockHash()
+		if prevNode != nil && header.PrevBlock != prevNode.hash {
+			str := fmt.Sprintf("bootstrap header %v does not "+
+				"connect to the previous header %v", hash,
+				prevNode.hash)
+			return 0, ruleError(ErrPreviousBlockUnknown, str)
+		}
+
+		// Skip the headers of blocks that are already known.
+		if numNew == 0 {
+			if node := b.index.LookupNode(&hash); node != nil {
+				prevNode = node
+				continue
+			}
+		}
+		if prevNode == nil {
+			prevNode = b.index.LookupNode(&header.PrevBlock)
+			if prevNode == nil {
+				str := fmt.Sprintf("previous block %v of "+
+					"bootstrap header %v is not known",
+					header.PrevBlock, hash)
+				return 0, ruleError(ErrPreviousBlockUnknown, str)
+			}
+		}
+		if numNew == 0 && b.index.NodeStatus(prevNode).KnownInvalid() {
+			str := fmt.Sprintf("bootstrap header %v builds on an "+
+				"invalid block", hash)
+			return 0, ruleError(ErrInvalidAncestorBlock, str)
+		}
+
+		err := checkBlockHeaderSanity(header, b.chainParams.PowLimit,
+			b.timeSource, BFNone)
+		if err != nil {
+			return 0, err
+		}
+		err = b.checkBlockHeaderContext(header, prevNode, BFNone)
+		if err != nil {
+			return 0, err
+		}
+
+		// The nodes of the bootstrapped headers are not added to the
+		// block index since their blocks are not known.
+		prevNode = newBlockNode(header, prevNode)
+		numNew++
+	}
+
+	// Only keep the headers when they lead to a chain with more work.
+	if numNew == 0 || prevNode.workSum.Cmp(b.bestChain.Tip().workSum) <= 0 ||
+		(b.bootstrapTip != nil &&
+			prevNode.workSum.Cmp(b.bootstrapTip.workSum) <= 0) {
+
+		return 0, nil
+	}
+	b.bootstrapTip = prevNode
+
+	log.Infof("Bootstrapped %d block headers up to height %d (hash %v)",
+		numNew, prevNode.height, prevNode.hash)
+	return numNew, nil
+}
+
+// BootstrapHeaders fetches a header bundle from the passed source and
+// processes its headers with ProcessBootstrapHeaders.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BootstrapHeaders(ctx context.Context, source HeaderSource) (int, error) {
+	bundle, err := source.FetchHeaderBundle(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return b.ProcessBootstrapHeaders(bundle.Headers)
+}
+
+// BootstrapHeaderHashes returns the hashes of the bootstrapped headers after
+// the block with the from hash up to and including the one with the stop
+// hash.  It returns nil when either of them isn't part of the bootstrapped
+// header chain, which callers use to fall back to downloading the headers
+// from peers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BootstrapHeaderHashes(from, stop *chainhash.Hash) []chainhash.Hash {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	tip := b.bootstrapTip
+	if tip == nil {
+		return nil
+	}
+
+	// Release the bootstrapped headers once the best chain caught up with
+	// them since they are of no further use.
+	if tip.workSum.Cmp(b.bestChain.Tip().workSum) <= 0 {
+		b.bootstrapTip = nil
+		return nil
+	}
+
+	stopNode := tip
+	for stopNode != nil && stopNode.hash != *stop {
+		stopNode = stopNode.parent
+	}
+	if stopNode == nil {
+		return nil
+	}
+	var hashes []chainhash.Hash
+	for node := stopNode; node != nil; node = node.parent {
+		if node.hash == *from {
+			// Reverse the hashes into ascending order.
+			for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+				hashes[i], hashes[j] = hashes[j], hashes[i]
+			}
+			return hashes
+		}
+		hashes = append(hashes, node.hash)
+	}
+	return nil
+}
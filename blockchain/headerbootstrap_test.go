@@ -0,0 +1,240 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// bootstrapTestHeaders returns the passed number of solved headers building
+// on the passed parent.
+func bootstrapTestHeaders(parent *blockNode, count int) []wire.BlockHeader {
+	headers := make([]wire.BlockHeader, 0, count)
+	prevHash := parent.hash
+	timestamp := time.Unix(parent.timestamp, 0)
+	for i := 0; i < count; i++ {
+		timestamp = timestamp.Add(time.Minute)
+		header := wire.BlockHeader{
+			Version:   4,
+			PrevBlock: prevHash,
+			Timestamp: timestamp,
+			Bits:      parent.bits,
+		}
+		target := CompactToBig(header.Bits)
+		for {
+			hash := header.PowHash()
+			if HashToBig(&hash).Cmp(target) <= 0 {
+				break
+			}
+			header.Nonce++
+		}
+		headers = append(headers, header)
+		prevHash = header.BlockHash()
+	}
+	return headers
+}
+
+// TestHeaderBundle ensures header bundles round trip through their serialized
+// form and that only bundles signed by the publisher for the expected network
+// verify.
+func TestHeaderBundle(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain := newFakeChain(params)
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+
+	bundle := &HeaderBundle{
+		Net:     params.Net,
+		Headers: bootstrapTestHeaders(chain.bestChain.Tip(), 3),
+	}
+	bundle.Sign(key)
+
+	var buf bytes.Buffer
+	if err := bundle.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var decoded HeaderBundle
+	if err := decoded.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, bundle) {
+		t.Fatalf("mismatched bundle - got %v, want %v", decoded, bundle)
+	}
+	if err := decoded.Verify(params.Net, key.PubKey()); err != nil {
+		t.Fatalf("Verify: unexpected error: %v", err)
+	}
+
+	// The bundle must be for the expected network.
+	if err := decoded.Verify(wire.MainNet, key.PubKey()); err == nil {
+		t.Fatal("Verify: accepted bundle for the wrong network")
+	}
+
+	// The bundle must be signed by the publisher.
+	otherKey, _ := btcec.NewPrivateKey()
+	if err := decoded.Verify(params.Net, otherKey.PubKey()); err == nil {
+		t.Fatal("Verify: accepted bundle of another publisher")
+	}
+
+	// The signature must cover the headers.
+	decoded.Headers[1].Nonce++
+	if err := decoded.Verify(params.Net, key.PubKey()); err == nil {
+		t.Fatal("Verify: accepted modified bundle")
+	}
+}
+
+// TestProcessBootstrapHeaders ensures bootstrapped headers are validated and
+// that the hashes between the best chain and a later header are provided.
+func TestProcessBootstrapHeaders(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain := newFakeChain(params)
+	genesis := chain.bestChain.Tip()
+	headers := bootstrapTestHeaders(genesis, 5)
+
+	// Headers which don't connect are rejected.
+	disconnected := []wire.BlockHeader{headers[0], headers[2]}
+	_, err := chain.ProcessBootstrapHeaders(disconnected)
+	if rerr, ok := err.(RuleError); !ok ||
+		rerr.ErrorCode != ErrPreviousBlockUnknown {
+
+		t.Fatalf("ProcessBootstrapHeaders: unexpected error: %v", err)
+	}
+
+	// Headers with an invalid proof of work are rejected.
+	invalid := append([]wire.BlockHeader(nil), headers...)
+	invalid[4].Bits = 0x1d00ffff
+	if _, err := chain.ProcessBootstrapHeaders(invalid); err == nil {
+		t.Fatal("ProcessBootstrapHeaders: accepted invalid header")
+	}
+
+	// Including the genesis block, which is known, is allowed.
+	withGenesis := append([]wire.BlockHeader{params.GenesisBlock.Header},
+		headers...)
+	n, err := chain.ProcessBootstrapHeaders(withGenesis)
+	if err != nil || n != 5 {
+		t.Fatalf("ProcessBootstrapHeaders: got %d, %v, want 5", n, err)
+	}
+
+	// A shorter header chain doesn't replace the current one.
+	n, err = chain.ProcessBootstrapHeaders(headers[:3])
+	if err != nil || n != 0 {
+		t.Fatalf("ProcessBootstrapHeaders: got %d, %v, want 0", n, err)
+	}
+
+	from := genesis.hash
+	stop := headers[3].BlockHash()
+	want := []chainhash.Hash{headers[0].BlockHash(),
+		headers[1].BlockHash(), headers[2].BlockHash(), stop}
+	got := chain.BootstrapHeaderHashes(&from, &stop)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BootstrapHeaderHashes: got %v, want %v", got, want)
+	}
+	unknown := chainhash.Hash{0x01}
+	if got := chain.BootstrapHeaderHashes(&from, &unknown); got != nil {
+		t.Fatalf("BootstrapHeaderHashes: unexpected hashes %v", got)
+	}
+	if got := chain.BootstrapHeaderHashes(&unknown, &stop); got != nil {
+		t.Fatalf("BootstrapHeaderHashes: unexpected hashes %v", got)
+	}
+}
+
+// TestHTTPSHeaderSource ensures header bundles are downloaded over HTTPS and
+// that the fallback URLs are tried when a URL fails.
+func TestHTTPSHeaderSource(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain := newFakeChain(params)
+	key, _ := btcec.NewPrivateKey()
+	bundle := &HeaderBundle{
+		Net:     params.Net,
+		Headers: bootstrapTestHeaders(chain.bestChain.Tip(), 2),
+	}
+	bundle.Sign(key)
+	var buf bytes.Buffer
+	if err := bundle.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/headers" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(buf.Bytes())
+		}))
+	defer server.Close()
+
+	source := &HTTPSHeaderSource{
+		URLs: []string{
+			"http://127.0.0.1/headers",
+			server.URL + "/missing",
+			server.URL + "/headers",
+		},
+		PubKey: key.PubKey(),
+		Net:    params.Net,
+		Client: server.Client(),
+	}
+	n, err := chain.BootstrapHeaders(context.Background(), source)
+	if err != nil || n != 2 {
+		t.Fatalf("BootstrapHeaders: got %d, %v, want 2", n, err)
+	}
+
+	// Bundles with more headers than the configured maximum are rejected.
+	source.MaxHeaders = 1
+	if _, err := source.FetchHeaderBundle(context.Background()); err == nil {
+		t.Fatal("FetchHeaderBundle: accepted bundle with too many headers")
+	}
+	source.MaxHeaders = 0
+
+	// Bundles of another publisher are rejected.
+	otherKey, _ := btcec.NewPrivateKey()
+	source.PubKey = otherKey.PubKey()
+	if _, err := source.FetchHeaderBundle(context.Background()); err == nil {
+		t.Fatal("FetchHeaderBundle: accepted bundle of another publisher")
+	}
+}
+
+// TestHeaderBundleLimit ensures the number of headers of a bundle is limited
+// to the blocks expected since the genesis block plus a margin.
+func TestHeaderBundleLimit(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	genesisTime := params.GenesisBlock.Header.Timestamp
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want uint64
+	}{{
+		name: "before genesis",
+		now:  genesisTime.Add(-time.Hour),
+		want: wire.MaxBlockHeadersPerMsg,
+	}, {
+		name: "1000 blocks after genesis",
+		now:  genesisTime.Add(1000 * params.TargetTimePerBlock),
+		want: 1100 + wire.MaxBlockHeadersPerMsg,
+	}, {
+		name: "far future",
+		now:  genesisTime.AddDate(100, 0, 0),
+		want: MaxHeaderBundleHeaders,
+	}}
+	for _, test := range tests {
+		got := HeaderBundleLimit(params, test.now)
+		if got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
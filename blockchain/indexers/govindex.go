@@ -0,0 +1,774 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// govIndexName is the human-readable name for the index.
+	govIndexName = "governance index"
+
+	// govObjectHeaderSize is the size of the serialized governance objects
+	// without their data.
+	govObjectHeaderSize = 4 + 8 + chainhash.HashSize
+
+	// govVoteKeySize is the size of the keys of the vote buckets.
+	govVoteKeySize = chainhash.HashSize + 36 + 1 + 8
+
+	// govPayoutKeySize is the size of the keys of the payout bucket.
+	govPayoutKeySize = 4 + 4
+)
+
+var (
+	// govIndexKey is the key of the governance index and the parent db
+	// bucket used to house it.
+	govIndexKey = []byte("govidx")
+
+	// govObjectsBucketName is the name of the db bucket used to house the
+	// governance objects by hash.
+	govObjectsBucketName = []byte("objects")
+
+	// govTriggersBucketName is the name of the db bucket used to house the
+	// triggers by the height of the superblock they are for.
+	govTriggersBucketName = []byte("triggers")
+
+	// govVotesBucketName is the name of the db bucket used to house the
+	// votes by the object voted on.
+	govVotesBucketName = []byte("votes")
+
+	// govMNVotesBucketName is the name of the db bucket used to house the
+	// votes by the masternode that cast them.
+	govMNVotesBucketName = []byte("mnvotes")
+
+	// govPayoutsBucketName is the name of the db bucket used to house the
+	// superblock payouts by their position in the chain.
+	govPayoutsBucketName = []byte("payouts")
+)
+
+// -----------------------------------------------------------------------------
+// The governance index consists of the governance objects and votes passed to
+// it, which are relayed over the network rather than included in blocks, and
+// of the payouts of the superblocks in the main chain.
+//
+// All buckets are nested in the index bucket.  The numeric fields of keys are
+// serialized big endian so the entries are ordered by them, while the fields of
+// values are serialized little endian like the rest of the indexes.
+//
+// The serialized format for keys and values in the objects bucket is:
+//
+//   <hash> = <type><time><collateral hash><data>
+//
+//   Field             Type              Size
+//   hash              chainhash.Hash    32 bytes
+//   type              int32             4 bytes
+//   time              int64             8 bytes
+//   collateral hash   chainhash.Hash    32 bytes
+//   data              []byte            variable
+//
+// The triggers bucket contains an empty value for every trigger, keyed by the
+// height of the superblock it is for:
+//
+//   <height><hash> = <>
+//
+// The votes and mnvotes buckets contain the outcome of every vote, keyed by the
+// object and by the masternode respectively:
+//
+//   votes:   <object hash><masternode outpoint><signal><time> = <outcome>
+//   mnvotes: <masternode outpoint><object hash><signal><time> = <outcome>
+//
+//   Field                 Type              Size
+//   object hash           chainhash.Hash    32 bytes
+//   masternode outpoint   wire.OutPoint     36 bytes
+//   signal                uint8             1 byte
+//   time                  int64             8 bytes
+//   outcome               uint8             1 byte
+//
+// The payouts bucket contains every payment of a superblock to a proposal,
+// keyed by the height of the superblock and the index of the output:
+//
+//   <height><index> = <proposal hash><amount><script>
+//
+//   Field             Type              Size
+//   height            uint32            4 bytes
+//   index             uint32            4 bytes
+//   proposal hash     chainhash.Hash    32 bytes
+//   amount            int64             8 bytes
+//   script            []byte            variable
+// -----------------------------------------------------------------------------
+
+// GovObjectType identifies the type of a governance object.
+type GovObjectType int32
+
+// These constants define the types of governance objects.
+const (
+	GovObjectProposal GovObjectType = 1
+	GovObjectTrigger  GovObjectType = 2
+)
+
+// GovObject describes a governance object as stored in the governance index.
+type GovObject struct {
+	// Hash identifies the object.
+	Hash chainhash.Hash
+
+	// Type is the type of the object.
+	Type GovObjectType
+
+	// Time is the creation time of the object as a unix timestamp.
+	Time int64
+
+	// CollateralHash is the hash of the transaction which burned the fee
+	// for the object.  It is zero for triggers.
+	CollateralHash chainhash.Hash
+
+	// Data is the JSON encoded content of the object.
+	Data []byte
+}
+
+// GovVoteSignal identifies what a governance vote is about.
+type GovVoteSignal uint8
+
+// These constants define the signals of governance votes.
+const (
+	GovVoteSignalFunding  GovVoteSignal = 1
+	GovVoteSignalValid    GovVoteSignal = 2
+	GovVoteSignalDelete   GovVoteSignal = 3
+	GovVoteSignalEndorsed GovVoteSignal = 4
+)
+
+// GovVoteOutcome is the outcome of a governance vote.
+type GovVoteOutcome uint8
+
+// These constants define the outcomes of governance votes.
+const (
+	GovVoteOutcomeNone    GovVoteOutcome = 0
+	GovVoteOutcomeYes     GovVoteOutcome = 1
+	GovVoteOutcomeNo      GovVoteOutcome = 2
+	GovVoteOutcomeAbstain GovVoteOutcome = 3
+)
+
+// GovVote describes a vote of a masternode on a governance object as stored in
+// the governance index.
+type GovVote struct {
+	// ObjectHash is the hash of the object voted on.
+	ObjectHash chainhash.Hash
+
+	// Masternode is the collateral outpoint of the masternode which cast
+	// the vote.
+	Masternode wire.OutPoint
+
+	// Signal and Outcome are what the vote is about and its outcome.
+	Signal  GovVoteSignal
+	Outcome GovVoteOutcome
+
+	// Time is the time the vote was cast as a unix timestamp.
+	Time int64
+}
+
+// SuperblockPayout describes a payment of a superblock in the main chain to a
+// proposal as stored in the governance index.
+type SuperblockPayout struct {
+	// Height is the height of the superblock.
+	Height int32
+
+	// Index is the index of the output of the coinbase transaction of the
+	// superblock which makes the payment.
+	Index uint32
+
+	// ProposalHash is the hash of the proposal paid.
+	ProposalHash chainhash.Hash
+
+	// PkScript and Amount are the script and amount of the output.
+	PkScript []byte
+	Amount   btcutil.Amount
+}
+
+// govTrigger is the content of a trigger, which lists the payments of the
+// superblock at a height.  The payments are separated by '|' characters.
+type govTrigger struct {
+	EventBlockHeight int32  `json:"event_block_height"`
+	PaymentAddresses string `json:"payment_addresses"`
+	PaymentAmounts   string `json:"payment_amounts"`
+	ProposalHashes   string `json:"proposal_hashes"`
+}
+
+// govPayment is a single payment listed by a trigger.
+type govPayment struct {
+	proposalHash chainhash.Hash
+	pkScript     []byte
+	amount       btcutil.Amount
+}
+
+// parseGovTrigger parses the passed trigger data into the height of the
+// superblock it is for and the payments it lists.
+func parseGovTrigger(data []byte, params *chaincfg.Params) (int32, []govPayment, error) {
+	var trigger govTrigger
+	if err := json.Unmarshal(data, &trigger); err != nil {
+		return 0, nil, err
+	}
+
+	addrs := strings.Split(trigger.PaymentAddresses, "|")
+	amounts := strings.Split(trigger.PaymentAmounts, "|")
+	hashes := strings.Split(trigger.ProposalHashes, "|")
+	if len(addrs) != len(amounts) || len(addrs) != len(hashes) {
+		return 0, nil, fmt.Errorf("trigger lists %d addresses, %d "+
+			"amounts and %d proposals", len(addrs), len(amounts),
+			len(hashes))
+	}
+
+	payments := make([]govPayment, len(addrs))
+	for i := range addrs {
+		addr, err := btcutil.DecodeAddress(addrs[i], params)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !addr.IsForNet(params) {
+			return 0, nil, fmt.Errorf("payment address %s is not "+
+				"for %s", addrs[i], params.Name)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return 0, nil, err
+		}
+		value, err := strconv.ParseFloat(amounts[i], 64)
+		if err != nil {
+			return 0, nil, err
+		}
+		amount, err := btcutil.NewAmount(value)
+		if err != nil {
+			return 0, nil, err
+		}
+		proposalHash, err := chainhash.NewHashFromStr(hashes[i])
+		if err != nil {
+			return 0, nil, err
+		}
+
+		payments[i] = govPayment{
+			proposalHash: *proposalHash,
+			pkScript:     pkScript,
+			amount:       amount,
+		}
+	}
+
+	return trigger.EventBlockHeight, payments, nil
+}
+
+// serializeGovObject returns the serialization of the passed object for the
+// objects bucket.
+func serializeGovObject(obj *GovObject) []byte {
+	serialized := make([]byte, govObjectHeaderSize+len(obj.Data))
+	byteOrder.PutUint32(serialized[0:4], uint32(obj.Type))
+	byteOrder.PutUint64(serialized[4:12], uint64(obj.Time))
+	copy(serialized[12:44], obj.CollateralHash[:])
+	copy(serialized[44:], obj.Data)
+	return serialized
+}
+
+// deserializeGovObject deserializes the passed serialized object of the
+// objects bucket.
+func deserializeGovObject(hash *chainhash.Hash, serialized []byte) (*GovObject, error) {
+	if len(serialized) < govObjectHeaderSize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt governance object entry",
+		}
+	}
+
+	obj := &GovObject{
+		Hash: *hash,
+		Type: GovObjectType(byteOrder.Uint32(serialized[0:4])),
+		Time: int64(byteOrder.Uint64(serialized[4:12])),
+		Data: make([]byte, len(serialized)-govObjectHeaderSize),
+	}
+	copy(obj.CollateralHash[:], serialized[12:44])
+	copy(obj.Data, serialized[44:])
+	return obj, nil
+}
+
+// putOutPoint serializes the passed outpoint into the first 36 bytes of the
+// passed slice such that outpoints are ordered by hash and then index.
+func putOutPoint(target []byte, op *wire.OutPoint) {
+	copy(target[0:32], op.Hash[:])
+	binary.BigEndian.PutUint32(target[32:36], op.Index)
+}
+
+// govVoteKeys returns the keys of the votes and mnvotes buckets for the passed
+// vote.
+func govVoteKeys(vote *GovVote) ([]byte, []byte) {
+	key := make([]byte, govVoteKeySize)
+	copy(key[0:32], vote.ObjectHash[:])
+	putOutPoint(key[32:68], &vote.Masternode)
+	key[68] = byte(vote.Signal)
+	binary.BigEndian.PutUint64(key[69:77], uint64(vote.Time))
+
+	mnKey := make([]byte, govVoteKeySize)
+	putOutPoint(mnKey[0:36], &vote.Masternode)
+	copy(mnKey[36:68], vote.ObjectHash[:])
+	copy(mnKey[68:77], key[68:77])
+	return key, mnKey
+}
+
+// deserializeGovVote deserializes the passed key and value of the votes bucket,
+// or of the mnvotes bucket when byMasternode is set, into a vote.
+func deserializeGovVote(key, value []byte, byMasternode bool) (GovVote, error) {
+	if len(key) != govVoteKeySize || len(value) != 1 {
+		return GovVote{}, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt governance vote entry",
+		}
+	}
+
+	objStart, mnStart := 0, 32
+	if byMasternode {
+		objStart, mnStart = 36, 0
+	}
+
+	var vote GovVote
+	copy(vote.ObjectHash[:], key[objStart:objStart+32])
+	copy(vote.Masternode.Hash[:], key[mnStart:mnStart+32])
+	vote.Masternode.Index = binary.BigEndian.Uint32(key[mnStart+32 : mnStart+36])
+	vote.Signal = GovVoteSignal(key[68])
+	vote.Time = int64(binary.BigEndian.Uint64(key[69:77]))
+	vote.Outcome = GovVoteOutcome(value[0])
+	return vote, nil
+}
+
+// govPayoutKey returns the key of the payouts bucket for the payout made by the
+// output with the passed index of the superblock at the passed height.
+func govPayoutKey(height int32, index uint32) []byte {
+	key := make([]byte, govPayoutKeySize)
+	binary.BigEndian.PutUint32(key[0:4], uint32(height))
+	binary.BigEndian.PutUint32(key[4:8], index)
+	return key
+}
+
+// deserializeSuperblockPayout deserializes the passed key and value of the
+// payouts bucket into a payout.
+func deserializeSuperblockPayout(key, value []byte) (SuperblockPayout, error) {
+	if len(key) != govPayoutKeySize || len(value) < chainhash.HashSize+8 {
+		return SuperblockPayout{}, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt superblock payout entry",
+		}
+	}
+
+	payout := SuperblockPayout{
+		Height:   int32(binary.BigEndian.Uint32(key[0:4])),
+		Index:    binary.BigEndian.Uint32(key[4:8]),
+		Amount:   btcutil.Amount(byteOrder.Uint64(value[32:40])),
+		PkScript: make([]byte, len(value)-40),
+	}
+	copy(payout.ProposalHash[:], value[0:32])
+	copy(payout.PkScript, value[40:])
+	return payout, nil
+}
+
+// govBucket returns the nested bucket of the governance index with the passed
+// name.
+func govBucket(dbTx database.Tx, name []byte) database.Bucket {
+	return dbTx.Metadata().Bucket(govIndexKey).Bucket(name)
+}
+
+// forEachWithPrefix invokes the passed function with the key and value of every
+// entry of the passed bucket whose key starts with the passed prefix, in order.
+func forEachWithPrefix(bucket database.Bucket, prefix []byte,
+	fn func(k, v []byte) error) error {
+
+	cursor := bucket.Cursor()
+	ok := cursor.First()
+	if len(prefix) > 0 {
+		ok = cursor.Seek(prefix)
+	}
+	for ; ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			break
+		}
+		if err := fn(key, cursor.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GovIndex implements an index of the governance objects and votes passed to
+// it and of the superblock payouts in the main chain, which allows the history
+// of proposals and the treasury to be analyzed without replaying the governance
+// messages relayed by the network.
+//
+// The payouts of a superblock are identified with the trigger for it, so they
+// are only indexed when the trigger has been added with AddObject before the
+// superblock is connected.
+type GovIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the GovIndex type implements the Indexer interface.
+var _ Indexer = (*GovIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing
+// to initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) Key() []byte {
+	return govIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) Name() string {
+	return govIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the buckets for the governance
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) Create(dbTx database.Tx) error {
+	bucket, err := dbTx.Metadata().CreateBucket(govIndexKey)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range [][]byte{govObjectsBucketName,
+		govTriggersBucketName, govVotesBucketName, govMNVotesBucketName,
+		govPayoutsBucketName} {
+
+		if _, err := bucket.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSuperblock returns whether or not the block at the passed height is a
+// superblock.
+func (idx *GovIndex) isSuperblock(height int32) bool {
+	params := idx.chainParams
+	return params.SuperblockCycle > 0 &&
+		height >= params.SuperblockStartHeight &&
+		(height-params.SuperblockStartHeight)%params.SuperblockCycle == 0
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every payment
+// to a proposal when the passed block is a superblock.
+//
+// The coinbase transaction of a superblock may only make the payments of one of
+// the triggers for its height, so the payments of the first trigger whose
+// payments are all made by the coinbase transaction are indexed.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	height := block.Height()
+	if !idx.isSuperblock(height) || len(block.Transactions()) == 0 {
+		return nil
+	}
+
+	var triggers []chainhash.Hash
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(height))
+	triggersBucket := govBucket(dbTx, govTriggersBucketName)
+	err := forEachWithPrefix(triggersBucket, prefix[:], func(k, _ []byte) error {
+		var hash chainhash.Hash
+		copy(hash[:], k[4:])
+		triggers = append(triggers, hash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	coinbase := block.Transactions()[0].MsgTx()
+	objectsBucket := govBucket(dbTx, govObjectsBucketName)
+	for i := range triggers {
+		obj, err := deserializeGovObject(&triggers[i],
+			objectsBucket.Get(triggers[i][:]))
+		if err != nil {
+			return err
+		}
+		_, payments, err := parseGovTrigger(obj.Data, idx.chainParams)
+		if err != nil {
+			return err
+		}
+
+		indexes, ok := matchPayments(coinbase, payments)
+		if !ok {
+			continue
+		}
+
+		payoutsBucket := govBucket(dbTx, govPayoutsBucketName)
+		for j, payment := range payments {
+			value := make([]byte, 40+len(payment.pkScript))
+			copy(value[0:32], payment.proposalHash[:])
+			byteOrder.PutUint64(value[32:40], uint64(payment.amount))
+			copy(value[40:], payment.pkScript)
+			key := govPayoutKey(height, indexes[j])
+			if err := payoutsBucket.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// matchPayments returns the indexes of the outputs of the passed coinbase
+// transaction which make the passed payments and whether or not all of them
+// are made.
+func matchPayments(coinbase *wire.MsgTx, payments []govPayment) ([]uint32, bool) {
+	used := make([]bool, len(coinbase.TxOut))
+	indexes := make([]uint32, len(payments))
+	for i, payment := range payments {
+		found := false
+		for j, txOut := range coinbase.TxOut {
+			if used[j] || txOut.Value != int64(payment.amount) ||
+				!bytes.Equal(txOut.PkScript, payment.pkScript) {
+
+				continue
+			}
+			used[j] = true
+			indexes[i] = uint32(j)
+			found = true
+			break
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return indexes, true
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the payouts of the
+// passed block.
+//
+// This is part of the Indexer interface.
+func (idx *GovIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	if !idx.isSuperblock(block.Height()) {
+		return nil
+	}
+
+	var keys [][]byte
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(block.Height()))
+	payoutsBucket := govBucket(dbTx, govPayoutsBucketName)
+	err := forEachWithPrefix(payoutsBucket, prefix[:], func(k, _ []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := payoutsBucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddObject adds the passed governance object to the index, replacing the
+// object with the same hash if any.  The data of triggers must list the
+// payments of a superblock or an error is returned.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) AddObject(obj *GovObject) error {
+	var eventHeight int32
+	if obj.Type == GovObjectTrigger {
+		var err error
+		eventHeight, _, err = parseGovTrigger(obj.Data, idx.chainParams)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %v: %v", obj.Hash, err)
+		}
+	}
+
+	return idx.db.Update(func(dbTx database.Tx) error {
+		err := govBucket(dbTx, govObjectsBucketName).Put(obj.Hash[:],
+			serializeGovObject(obj))
+		if err != nil || obj.Type != GovObjectTrigger {
+			return err
+		}
+
+		key := make([]byte, 4+chainhash.HashSize)
+		binary.BigEndian.PutUint32(key[0:4], uint32(eventHeight))
+		copy(key[4:], obj.Hash[:])
+		return govBucket(dbTx, govTriggersBucketName).Put(key, nil)
+	})
+}
+
+// AddVote adds the passed governance vote to the index.  All votes are kept,
+// including the ones later superseded by another vote of the same masternode,
+// so the index records how the outcome of a vote evolved.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) AddVote(vote *GovVote) error {
+	key, mnKey := govVoteKeys(vote)
+	value := []byte{byte(vote.Outcome)}
+	return idx.db.Update(func(dbTx database.Tx) error {
+		err := govBucket(dbTx, govVotesBucketName).Put(key, value)
+		if err != nil {
+			return err
+		}
+		return govBucket(dbTx, govMNVotesBucketName).Put(mnKey, value)
+	})
+}
+
+// Object returns the governance object with the passed hash, or nil when it is
+// not in the index.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) Object(hash *chainhash.Hash) (*GovObject, error) {
+	var obj *GovObject
+	err := idx.db.View(func(dbTx database.Tx) error {
+		serialized := govBucket(dbTx, govObjectsBucketName).Get(hash[:])
+		if serialized == nil {
+			return nil
+		}
+
+		var err error
+		obj, err = deserializeGovObject(hash, serialized)
+		return err
+	})
+	return obj, err
+}
+
+// fetchVotes returns the votes of the passed bucket whose keys start with the
+// passed prefix.
+func (idx *GovIndex) fetchVotes(bucketName, prefix []byte) ([]GovVote, error) {
+	byMasternode := bytes.Equal(bucketName, govMNVotesBucketName)
+	var votes []GovVote
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := govBucket(dbTx, bucketName)
+		return forEachWithPrefix(bucket, prefix, func(k, v []byte) error {
+			vote, err := deserializeGovVote(k, v, byMasternode)
+			if err != nil {
+				return err
+			}
+			votes = append(votes, vote)
+			return nil
+		})
+	})
+	return votes, err
+}
+
+// Votes returns all votes on the governance object with the passed hash,
+// ordered by masternode, signal and time.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) Votes(objectHash *chainhash.Hash) ([]GovVote, error) {
+	return idx.fetchVotes(govVotesBucketName, objectHash[:])
+}
+
+// MasternodeVotes returns all votes cast by the masternode with the passed
+// collateral outpoint, ordered by object, signal and time.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) MasternodeVotes(masternode *wire.OutPoint) ([]GovVote, error) {
+	var prefix [36]byte
+	putOutPoint(prefix[:], masternode)
+	return idx.fetchVotes(govMNVotesBucketName, prefix[:])
+}
+
+// fetchPayouts returns the payouts whose keys start with the passed prefix for
+// which the passed filter function returns true.
+func (idx *GovIndex) fetchPayouts(prefix []byte,
+	filter func(*SuperblockPayout) bool) ([]SuperblockPayout, error) {
+
+	var payouts []SuperblockPayout
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := govBucket(dbTx, govPayoutsBucketName)
+		return forEachWithPrefix(bucket, prefix, func(k, v []byte) error {
+			payout, err := deserializeSuperblockPayout(k, v)
+			if err != nil {
+				return err
+			}
+			if filter(&payout) {
+				payouts = append(payouts, payout)
+			}
+			return nil
+		})
+	})
+	return payouts, err
+}
+
+// SuperblockHeight returns the height of the superblock of the passed funding
+// cycle, where cycle zero is the first superblock.
+func (idx *GovIndex) SuperblockHeight(cycle int32) int32 {
+	return idx.chainParams.SuperblockStartHeight +
+		cycle*idx.chainParams.SuperblockCycle
+}
+
+// CyclePayouts returns the payouts of the superblock of the passed funding
+// cycle, ordered by output index.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) CyclePayouts(cycle int32) ([]SuperblockPayout, error) {
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(idx.SuperblockHeight(cycle)))
+	return idx.fetchPayouts(prefix[:], func(*SuperblockPayout) bool {
+		return true
+	})
+}
+
+// ProposalPayouts returns the payouts to the proposal with the passed hash
+// ordered by their position in the chain.
+//
+// This function is safe for concurrent access.
+func (idx *GovIndex) ProposalPayouts(proposalHash *chainhash.Hash) ([]SuperblockPayout, error) {
+	// There is only a handful of payouts per superblock, so they are all
+	// scanned rather than maintaining another bucket.
+	return idx.fetchPayouts(nil, func(payout *SuperblockPayout) bool {
+		return payout.ProposalHash == *proposalHash
+	})
+}
+
+// NewGovIndex returns a new instance of an indexer that is used to keep the
+// governance objects and votes passed to it along with the superblock payouts
+// in the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewGovIndex(db database.DB, chainParams *chaincfg.Params) *GovIndex {
+	return &GovIndex{db: db, chainParams: chainParams}
+}
+
+// DropGovIndex drops the governance index from the provided database if it
+// exists.
+func DropGovIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, govIndexKey, govIndexName, interrupt)
+}
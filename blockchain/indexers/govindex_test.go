@@ -0,0 +1,185 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+	_ "github.com/dashpay/dashd-go/database/ffldb"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestGovIndex ensures governance objects, votes and superblock payouts are
+// indexed and queried as expected.
+func TestGovIndex(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "govindex")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, wire.MainNet)
+	if err != nil {
+		t.Fatalf("unable to create database: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	params := &chaincfg.RegressionNetParams
+	idx := NewGovIndex(db, params)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("unable to create index: %v", err)
+	}
+
+	// Create a proposal and a trigger paying it and another proposal in the
+	// superblock of the second funding cycle.
+	proposal := &GovObject{
+		Hash:           chainhash.Hash{0x01},
+		Type:           GovObjectProposal,
+		Time:           1700000000,
+		CollateralHash: chainhash.Hash{0x02},
+		Data:           []byte(`{"name":"test"}`),
+	}
+	otherProposalHash := chainhash.Hash{0x03}
+	var pkScripts [2][]byte
+	var addrs [2]string
+	for i := range addrs {
+		addr, err := btcutil.NewAddressPubKeyHash(append(make([]byte, 19),
+			byte(i)), params)
+		if err != nil {
+			t.Fatalf("unable to create address: %v", err)
+		}
+		addrs[i] = addr.EncodeAddress()
+		pkScripts[i], _ = txscript.PayToAddrScript(addr)
+	}
+	height := idx.SuperblockHeight(1)
+	trigger := &GovObject{
+		Hash: chainhash.Hash{0x04},
+		Type: GovObjectTrigger,
+		Time: 1700000001,
+		Data: []byte(fmt.Sprintf(`{"event_block_height":%d,`+
+			`"payment_addresses":"%s|%s","payment_amounts":"1.5|2",`+
+			`"proposal_hashes":"%s|%s","type":2}`, height, addrs[0],
+			addrs[1], proposal.Hash, otherProposalHash)),
+	}
+	for _, obj := range []*GovObject{proposal, trigger} {
+		if err := idx.AddObject(obj); err != nil {
+			t.Fatalf("AddObject: unexpected error: %v", err)
+		}
+	}
+	badTrigger := *trigger
+	badTrigger.Data = []byte(`{"payment_addresses":"a|b",` +
+		`"payment_amounts":"1","proposal_hashes":""}`)
+	if err := idx.AddObject(&badTrigger); err == nil {
+		t.Fatal("AddObject: added trigger with mismatched payments")
+	}
+
+	obj, err := idx.Object(&proposal.Hash)
+	if err != nil {
+		t.Fatalf("Object: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(obj, proposal) {
+		t.Fatalf("Object: got %+v, want %+v", obj, proposal)
+	}
+	obj, err = idx.Object(&chainhash.Hash{0xff})
+	if err != nil || obj != nil {
+		t.Fatalf("Object: got %v, %v for unknown object", obj, err)
+	}
+
+	// Add votes of two masternodes, one of which changed its vote.
+	mn1 := wire.OutPoint{Hash: chainhash.Hash{0x10}, Index: 1}
+	mn2 := wire.OutPoint{Hash: chainhash.Hash{0x10}, Index: 2}
+	votes := []GovVote{
+		{proposal.Hash, mn1, GovVoteSignalFunding, GovVoteOutcomeNo, 100},
+		{proposal.Hash, mn1, GovVoteSignalFunding, GovVoteOutcomeYes, 200},
+		{proposal.Hash, mn2, GovVoteSignalFunding, GovVoteOutcomeAbstain, 150},
+		{trigger.Hash, mn1, GovVoteSignalFunding, GovVoteOutcomeYes, 300},
+	}
+	for i := range votes {
+		if err := idx.AddVote(&votes[i]); err != nil {
+			t.Fatalf("AddVote: unexpected error: %v", err)
+		}
+	}
+	gotVotes, err := idx.Votes(&proposal.Hash)
+	if err != nil {
+		t.Fatalf("Votes: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotVotes, votes[:3]) {
+		t.Fatalf("Votes: got %v, want %v", gotVotes, votes[:3])
+	}
+	gotVotes, err = idx.MasternodeVotes(&mn1)
+	if err != nil {
+		t.Fatalf("MasternodeVotes: unexpected error: %v", err)
+	}
+	want := []GovVote{votes[0], votes[1], votes[3]}
+	if !reflect.DeepEqual(gotVotes, want) {
+		t.Fatalf("MasternodeVotes: got %v, want %v", gotVotes, want)
+	}
+
+	// Connect the superblock, which pays the proposals after the miner,
+	// along with a block which isn't a superblock but makes the same
+	// payments.
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxOut(wire.NewTxOut(100, []byte{txscript.OP_TRUE}))
+	coinbase.AddTxOut(wire.NewTxOut(150000000, pkScripts[0]))
+	coinbase.AddTxOut(wire.NewTxOut(200000000, pkScripts[1]))
+	for _, h := range []int32{height, height + 1} {
+		block := btcutil.NewBlock(&wire.MsgBlock{
+			Transactions: []*wire.MsgTx{coinbase},
+		})
+		block.SetHeight(h)
+		err = db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, block, nil)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock: unexpected error: %v", err)
+		}
+	}
+
+	wantPayouts := []SuperblockPayout{
+		{height, 1, proposal.Hash, pkScripts[0], 150000000},
+		{height, 2, otherProposalHash, pkScripts[1], 200000000},
+	}
+	payouts, err := idx.CyclePayouts(1)
+	if err != nil {
+		t.Fatalf("CyclePayouts: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(payouts, wantPayouts) {
+		t.Fatalf("CyclePayouts: got %v, want %v", payouts, wantPayouts)
+	}
+	payouts, err = idx.ProposalPayouts(&otherProposalHash)
+	if err != nil {
+		t.Fatalf("ProposalPayouts: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(payouts, wantPayouts[1:]) {
+		t.Fatalf("ProposalPayouts: got %v, want %v", payouts,
+			wantPayouts[1:])
+	}
+
+	// Disconnecting the superblock must remove its payouts.
+	block := btcutil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{coinbase},
+	})
+	block.SetHeight(height)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block, nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: unexpected error: %v", err)
+	}
+	payouts, err = idx.CyclePayouts(1)
+	if err != nil || len(payouts) != 0 {
+		t.Fatalf("CyclePayouts: got %v, %v after disconnect", payouts,
+			err)
+	}
+}
@@ -137,6 +137,9 @@ type Manager struct {
 // Ensure the Manager type implements the blockchain.IndexManager interface.
 var _ blockchain.IndexManager = (*Manager)(nil)
 
+// Ensure the Manager type implements the blockchain.IndexRebuilder interface.
+var _ blockchain.IndexRebuilder = (*Manager)(nil)
+
 // indexDropKey returns the key for an index which indicates it is in the
 // process of being dropped.
 func indexDropKey(idxKey []byte) []byte {
@@ -533,6 +536,32 @@ func (m *Manager) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
 	return nil
 }
 
+// MarkIndexesForRebuild marks all enabled indexes that exist to be dropped
+// when the manager is initialized, after which they are recreated and caught
+// up to the best chain tip from scratch.
+//
+// This is part of the blockchain.IndexRebuilder interface.
+func (m *Manager) MarkIndexesForRebuild(dbTx database.Tx) error {
+	indexesBucket := dbTx.Metadata().Bucket(indexTipsBucketName)
+	if indexesBucket == nil {
+		return nil
+	}
+
+	for _, indexer := range m.enabledIndexes {
+		idxKey := indexer.Key()
+		if indexesBucket.Get(idxKey) == nil {
+			continue
+		}
+
+		err := indexesBucket.Put(indexDropKey(idxKey), idxKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // NewManager returns a new index manager with the provided indexes enabled.
 //
 // The manager returned satisfies the blockchain.IndexManager interface and thus
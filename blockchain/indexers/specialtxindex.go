@@ -0,0 +1,320 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// specialTxIndexName is the human-readable name for the index.
+	specialTxIndexName = "special transaction index"
+
+	// specialTxKeySize is the size of the keys of the special transaction
+	// index.
+	specialTxKeySize = 2 + 4 + 4
+)
+
+var (
+	// specialTxIndexKey is the key of the special transaction index and the
+	// db bucket used to house it.
+	specialTxIndexKey = []byte("specialtxbytypeidx")
+)
+
+// -----------------------------------------------------------------------------
+// The special transaction index consists of an entry for every DIP0002 special
+// transaction in the main chain, which allows the special transactions of a
+// given type within a range of blocks to be found without loading the blocks.
+//
+// The fields of the keys are serialized big endian, unlike the rest of the
+// indexes, so the entries are ordered by type, then by block height and then by
+// position within the block, which allows ranges to be iterated with a cursor.
+//
+// The serialized format for keys and values in the index bucket is:
+//
+//   <type><height><index> = <hash>
+//
+//   Field           Type              Size
+//   type            uint16            2 bytes
+//   height          uint32            4 bytes
+//   index           uint32            4 bytes
+//   hash            chainhash.Hash    32 bytes
+//   -----
+//   Total: 42 bytes
+// -----------------------------------------------------------------------------
+
+// SpecialTxEntry describes a special transaction in the main chain as stored
+// in the special transaction index.
+type SpecialTxEntry struct {
+	// Type is the DIP0002 type of the transaction.
+	Type wire.TxType
+
+	// Height is the height of the block that contains the transaction.
+	Height int32
+
+	// Index is the position of the transaction within its block.
+	Index uint32
+
+	// Hash is the hash of the transaction.
+	Hash chainhash.Hash
+}
+
+// specialTxKey returns the key of the special transaction index entry for the
+// transaction with the passed type at the passed position in the chain.
+func specialTxKey(txType wire.TxType, height int32, index uint32) []byte {
+	key := make([]byte, specialTxKeySize)
+	binary.BigEndian.PutUint16(key[0:2], uint16(txType))
+	binary.BigEndian.PutUint32(key[2:6], uint32(height))
+	binary.BigEndian.PutUint32(key[6:10], index)
+	return key
+}
+
+// deserializeSpecialTxEntry deserializes the passed special transaction index
+// key and value into an entry.
+func deserializeSpecialTxEntry(key, value []byte) (SpecialTxEntry, error) {
+	if len(key) != specialTxKeySize || len(value) != chainhash.HashSize {
+		return SpecialTxEntry{}, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt special transaction index entry",
+		}
+	}
+
+	entry := SpecialTxEntry{
+		Type:   wire.TxType(binary.BigEndian.Uint16(key[0:2])),
+		Height: int32(binary.BigEndian.Uint32(key[2:6])),
+		Index:  binary.BigEndian.Uint32(key[6:10]),
+	}
+	copy(entry.Hash[:], value)
+	return entry, nil
+}
+
+// dbPutSpecialTxEntries adds an entry for every special transaction in the
+// passed block to the index.
+func dbPutSpecialTxEntries(dbTx database.Tx, block *btcutil.Block) error {
+	bucket := dbTx.Metadata().Bucket(specialTxIndexKey)
+	for i, tx := range block.Transactions() {
+		msgTx := tx.MsgTx()
+		if !msgTx.IsSpecial() {
+			continue
+		}
+
+		key := specialTxKey(msgTx.TxType(), block.Height(), uint32(i))
+		if err := bucket.Put(key, tx.Hash()[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dbRemoveSpecialTxEntries removes the entries for every special transaction in
+// the passed block from the index.
+func dbRemoveSpecialTxEntries(dbTx database.Tx, block *btcutil.Block) error {
+	bucket := dbTx.Metadata().Bucket(specialTxIndexKey)
+	for i, tx := range block.Transactions() {
+		msgTx := tx.MsgTx()
+		if !msgTx.IsSpecial() {
+			continue
+		}
+
+		key := specialTxKey(msgTx.TxType(), block.Height(), uint32(i))
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dbFetchSpecialTxTypes returns the types of all special transactions in the
+// index ordered from the lowest to the highest.
+func dbFetchSpecialTxTypes(dbTx database.Tx) []wire.TxType {
+	var txTypes []wire.TxType
+	cursor := dbTx.Metadata().Bucket(specialTxIndexKey).Cursor()
+	var seek [2]byte
+	for ok := cursor.Seek(seek[:]); ok; ok = cursor.Seek(seek[:]) {
+		key := cursor.Key()
+		if len(key) < 2 {
+			break
+		}
+		txType := binary.BigEndian.Uint16(key[0:2])
+		txTypes = append(txTypes, wire.TxType(txType))
+		if txType == ^uint16(0) {
+			break
+		}
+
+		// Skip to the next type.
+		binary.BigEndian.PutUint16(seek[:], txType+1)
+	}
+
+	return txTypes
+}
+
+// dbFetchSpecialTxEntries returns up to the passed limit of entries for the
+// special transactions of the passed type within the passed range of block
+// heights, inclusive, ordered by their position in the chain.
+func dbFetchSpecialTxEntries(dbTx database.Tx, txType wire.TxType, startHeight,
+	endHeight int32, limit int) ([]SpecialTxEntry, error) {
+
+	var entries []SpecialTxEntry
+	cursor := dbTx.Metadata().Bucket(specialTxIndexKey).Cursor()
+	endKey := specialTxKey(txType, endHeight, ^uint32(0))
+	ok := cursor.Seek(specialTxKey(txType, startHeight, 0))
+	for ; ok && len(entries) < limit; ok = cursor.Next() {
+		key := cursor.Key()
+		if bytes.Compare(key, endKey) > 0 {
+			break
+		}
+
+		entry, err := deserializeSpecialTxEntry(key, cursor.Value())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// SpecialTxIndex implements an index of the DIP0002 special transactions in
+// the main chain by type and position in the chain.
+type SpecialTxIndex struct {
+	db database.DB
+}
+
+// Ensure the SpecialTxIndex type implements the Indexer interface.
+var _ Indexer = (*SpecialTxIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing
+// to initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) Key() []byte {
+	return specialTxIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) Name() string {
+	return specialTxIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the special
+// transaction index.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(specialTxIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every special
+// transaction in the passed block.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	return dbPutSpecialTxEntries(dbTx, block)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entry for every
+// special transaction in the passed block.
+//
+// This is part of the Indexer interface.
+func (idx *SpecialTxIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	return dbRemoveSpecialTxEntries(dbTx, block)
+}
+
+// SpecialTxs returns the special transactions of the passed types within the
+// passed range of block heights, inclusive, ordered by their position in the
+// chain.  All types are included when no types are passed.  The first skip
+// transactions are skipped and at most count transactions are returned.
+//
+// This function is safe for concurrent access.
+func (idx *SpecialTxIndex) SpecialTxs(txTypes []wire.TxType, startHeight,
+	endHeight int32, skip, count int) ([]SpecialTxEntry, error) {
+
+	if count <= 0 || skip < 0 || startHeight > endHeight {
+		return nil, nil
+	}
+
+	var entries []SpecialTxEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		if len(txTypes) == 0 {
+			txTypes = dbFetchSpecialTxTypes(dbTx)
+		}
+
+		// The entries returned are among the first skip+count entries
+		// of each type, so there is no need to fetch any more of them
+		// before merging them in chain order.
+		for _, txType := range txTypes {
+			typeEntries, err := dbFetchSpecialTxEntries(dbTx, txType,
+				startHeight, endHeight, skip+count)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, typeEntries...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Height != entries[j].Height {
+			return entries[i].Height < entries[j].Height
+		}
+		return entries[i].Index < entries[j].Index
+	})
+	if skip >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[skip:]
+	if len(entries) > count {
+		entries = entries[:count]
+	}
+	return entries, nil
+}
+
+// NewSpecialTxIndex returns a new instance of an indexer that is used to create
+// a mapping of the DIP0002 special transactions in the main chain by their type
+// and position in the chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewSpecialTxIndex(db database.DB) *SpecialTxIndex {
+	return &SpecialTxIndex{db: db}
+}
+
+// DropSpecialTxIndex drops the special transaction index from the provided
+// database if it exists.
+func DropSpecialTxIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, specialTxIndexKey, specialTxIndexName, interrupt)
+}
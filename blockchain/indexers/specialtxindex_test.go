@@ -0,0 +1,162 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/database"
+	_ "github.com/dashpay/dashd-go/database/ffldb"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// specialTx returns a transaction of the passed DIP0002 type with a payload
+// that makes its hash unique.
+func specialTx(txType wire.TxType, nonce byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	if txType != wire.TxTypeNormal {
+		tx.Version = int32(uint32(txType)<<16 | wire.SpecialTxVersion)
+		tx.ExtraPayload = []byte{nonce}
+	}
+	tx.AddTxOut(&wire.TxOut{Value: int64(nonce)})
+	return tx
+}
+
+// TestSpecialTxIndex ensures special transactions are indexed by type and
+// position in the chain and that they are queried and removed as expected.
+func TestSpecialTxIndex(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "specialtxindex")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, wire.MainNet)
+	if err != nil {
+		t.Fatalf("unable to create database: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	idx := NewSpecialTxIndex(db)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("unable to create index: %v", err)
+	}
+
+	// Create blocks at heights 1 through 3 with a coinbase special
+	// transaction each, along with normal transactions and registrations
+	// of masternodes.
+	txs := [][]*wire.MsgTx{
+		{specialTx(wire.TxTypeCoinbase, 1), specialTx(wire.TxTypeNormal, 2),
+			specialTx(wire.TxTypeProRegister, 3)},
+		{specialTx(wire.TxTypeCoinbase, 4)},
+		{specialTx(wire.TxTypeCoinbase, 5), specialTx(wire.TxTypeProRegister, 6),
+			specialTx(wire.TxTypeProRegister, 7)},
+	}
+	blocks := make([]*btcutil.Block, 0, len(txs))
+	for i, blockTxs := range txs {
+		block := btcutil.NewBlock(&wire.MsgBlock{Transactions: blockTxs})
+		block.SetHeight(int32(i + 1))
+		blocks = append(blocks, block)
+		err := db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, block, nil)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock: unexpected error: %v", err)
+		}
+	}
+
+	// entry returns the index entry expected for the transaction at the
+	// passed position.
+	entry := func(height int32, index uint32) SpecialTxEntry {
+		tx := txs[height-1][index]
+		return SpecialTxEntry{
+			Type:   tx.TxType(),
+			Height: height,
+			Index:  index,
+			Hash:   tx.TxHash(),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		txTypes     []wire.TxType
+		startHeight int32
+		endHeight   int32
+		skip        int
+		count       int
+		want        []SpecialTxEntry
+	}{{
+		name:        "all types",
+		startHeight: 1,
+		endHeight:   3,
+		count:       10,
+		want: []SpecialTxEntry{entry(1, 0), entry(1, 2), entry(2, 0),
+			entry(3, 0), entry(3, 1), entry(3, 2)},
+	}, {
+		name:        "all types with skip and count",
+		startHeight: 1,
+		endHeight:   3,
+		skip:        2,
+		count:       3,
+		want:        []SpecialTxEntry{entry(2, 0), entry(3, 0), entry(3, 1)},
+	}, {
+		name:        "single type",
+		txTypes:     []wire.TxType{wire.TxTypeProRegister},
+		startHeight: 1,
+		endHeight:   3,
+		count:       10,
+		want:        []SpecialTxEntry{entry(1, 2), entry(3, 1), entry(3, 2)},
+	}, {
+		name:        "height range",
+		txTypes:     []wire.TxType{wire.TxTypeCoinbase},
+		startHeight: 2,
+		endHeight:   2,
+		count:       10,
+		want:        []SpecialTxEntry{entry(2, 0)},
+	}, {
+		name:        "type without transactions",
+		txTypes:     []wire.TxType{wire.TxTypeQuorumCommitment},
+		startHeight: 1,
+		endHeight:   3,
+		count:       10,
+	}, {
+		name:        "skip past end",
+		startHeight: 1,
+		endHeight:   3,
+		skip:        6,
+		count:       10,
+	}}
+	for _, test := range tests {
+		got, err := idx.SpecialTxs(test.txTypes, test.startHeight,
+			test.endHeight, test.skip, test.count)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+
+	// Disconnecting the last block removes its transactions.
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, blocks[2], nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: unexpected error: %v", err)
+	}
+	got, err := idx.SpecialTxs(nil, 1, 3, 0, 10)
+	if err != nil {
+		t.Fatalf("SpecialTxs: unexpected error: %v", err)
+	}
+	want := []SpecialTxEntry{entry(1, 0), entry(1, 2), entry(2, 0)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SpecialTxs after disconnect: got %+v, want %+v", got,
+			want)
+	}
+}
@@ -6,8 +6,6 @@ package blockchain
 
 import (
 	"testing"
-
-	"github.com/dashpay/dashd-go/chaincfg"
 )
 
 // TestNotifications ensures that notification callbacks are fired on events.
@@ -19,7 +17,7 @@ func TestNotifications(t *testing.T) {
 
 	// Create a new database and chain instance to run tests against.
 	chain, teardownFunc, err := chainSetup("notifications",
-		&chaincfg.MainNetParams)
+		bitcoinGenesisParams(blocks[0]))
 	if err != nil {
 		t.Fatalf("Failed to setup chain instance: %v", err)
 	}
@@ -39,7 +37,7 @@ func TestNotifications(t *testing.T) {
 		chain.Subscribe(callback)
 	}
 
-	_, _, err = chain.ProcessBlock(blocks[1], BFNone)
+	_, _, err = chain.ProcessBlock(blocks[1], BFNoPoWCheck)
 	if err != nil {
 		t.Fatalf("ProcessBlock fail on block 1: %v\n", err)
 	}
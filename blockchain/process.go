@@ -138,11 +138,30 @@ func (b *BlockChain) processOrphans(hash *chainhash.Hash, flags BehaviorFlags) e
 // whether or not the block is on the main chain and the second indicates
 // whether or not the block is an orphan.
 //
+// ErrReindexing is returned while the chain state is being reindexed.
+//
 // This function is safe for concurrent access.
 func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bool, bool, error) {
+	if b.isReindexing() {
+		return false, false, ErrReindexing
+	}
+	return b.processBlock(block, flags)
+}
+
+// processBlock processes the passed block without ensuring the chain state is
+// not being reindexed.  See the exported version, ProcessBlock for further
+// details.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) processBlock(block *btcutil.Block, flags BehaviorFlags) (bool, bool, error) {
 	b.chainLock.Lock()
 	defer b.chainLock.Unlock()
 
+	// Collect the validation timing of this block and of any blocks
+	// connected as a result of processing it.
+	b.timings.begin()
+	defer b.timings.end()
+
 	fastAdd := flags&BFFastAdd == BFFastAdd
 
 	blockHash := block.Hash()
@@ -165,7 +184,9 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	}
 
 	// Perform preliminary sanity checks on the block and its transactions.
+	sanityStart := time.Now()
 	err = checkBlockSanity(block, b.chainParams.PowLimit, b.timeSource, flags)
+	b.timings.add(blockHash, -1, stageSanity, time.Since(sanityStart))
 	if err != nil {
 		return false, false, err
 	}
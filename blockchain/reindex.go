@@ -0,0 +1,415 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+)
+
+const (
+	// reindexPhaseWipe is the phase of a reindex during which the chain
+	// state buckets are being emptied.
+	reindexPhaseWipe = 0
+
+	// reindexPhaseReplay is the phase of a reindex during which the stored
+	// blocks are being connected to rebuild the chain state.
+	reindexPhaseReplay = 1
+
+	// reindexMaxDeletions is the maximum number of keys deleted from the
+	// chain state buckets in a single database transaction in order to
+	// keep memory usage to reasonable levels.
+	reindexMaxDeletions = 1000000
+
+	// reindexLogInterval is the minimum amount of time between reindex
+	// progress messages.
+	reindexLogInterval = 10 * time.Second
+)
+
+var (
+	// reindexStateKeyName is the name of the db key used to store the
+	// state of a reindex in progress.  It only exists while a reindex is
+	// in progress so it can be resumed after an interruption.
+	reindexStateKeyName = []byte("reindexstate")
+
+	// ErrReindexing is returned by the functions which process blocks while
+	// the chain state is being reindexed.
+	ErrReindexing = errors.New("the chain state is being reindexed")
+)
+
+// IndexRebuilder is an optional interface an IndexManager can implement to
+// have its indexes rebuilt when the chain state is reindexed.
+type IndexRebuilder interface {
+	// MarkIndexesForRebuild marks all indexes managed by the index manager
+	// to be dropped and rebuilt from the main chain when it is initialized.
+	// It is invoked within the database transaction that resets the chain
+	// state so the marks are only persisted along with the reset.
+	MarkIndexesForRebuild(database.Tx) error
+}
+
+// reindexState houses the state of a reindex in progress.
+//
+// The serialized format is:
+//
+//	<target hash><phase>
+//
+//	Field        Type             Size
+//	target hash  chainhash.Hash   chainhash.HashSize
+//	phase        byte             1
+type reindexState struct {
+	// target is the hash of the chain tip when the reindex started, which
+	// the chain state is rebuilt up to.
+	target chainhash.Hash
+
+	// phase is the phase the reindex is in.
+	phase byte
+}
+
+// serializeReindexState returns the serialization of the passed reindex state.
+func serializeReindexState(state reindexState) []byte {
+	serialized := make([]byte, chainhash.HashSize+1)
+	copy(serialized, state.target[:])
+	serialized[chainhash.HashSize] = state.phase
+	return serialized
+}
+
+// deserializeReindexState deserializes the passed serialized reindex state.
+func deserializeReindexState(serialized []byte) (reindexState, error) {
+	if len(serialized) != chainhash.HashSize+1 {
+		return reindexState{}, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt reindex state",
+		}
+	}
+
+	var state reindexState
+	copy(state.target[:], serialized)
+	state.phase = serialized[chainhash.HashSize]
+	return state, nil
+}
+
+// dbFetchReindexState returns the state of the reindex in progress or nil when
+// there is none.
+func dbFetchReindexState(dbTx database.Tx) (*reindexState, error) {
+	serialized := dbTx.Metadata().Get(reindexStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+
+	state, err := deserializeReindexState(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// dbPutReindexState stores the passed reindex state.
+func dbPutReindexState(dbTx database.Tx, state reindexState) error {
+	return dbTx.Metadata().Put(reindexStateKeyName,
+		serializeReindexState(state))
+}
+
+// pendingReindex returns the state of the reindex which must be performed
+// before the chain is used, or nil when there is none.  A reindex is pending
+// when requested or when a previous reindex was interrupted, in which case the
+// reindex state has to be stored first.
+func (b *BlockChain) pendingReindex(requested bool) (*reindexState, error) {
+	var state *reindexState
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchReindexState(dbTx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case state != nil:
+		log.Infof("Resuming reindex of the chain state to block %v",
+			state.target)
+
+	case requested:
+		state = &reindexState{
+			target: b.bestChain.Tip().hash,
+			phase:  reindexPhaseWipe,
+		}
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return dbPutReindexState(dbTx, *state)
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Reindexing the chain state to block %v (height %d)",
+			state.target, b.bestChain.Tip().height)
+	}
+
+	return state, nil
+}
+
+// reindex rebuilds the chain state, which consists of the utxo set, the spend
+// journal and the main chain block index, from the stored blocks according to
+// the passed reindex state.  The optional indexes are marked to be rebuilt as
+// well when the index manager supports it.
+//
+// The reindex is resumable.  Emptying the chain state buckets happens in
+// batches and connecting each block atomically updates the best chain state,
+// so an interrupted reindex continues from the last block connected on the
+// next start.
+//
+// The stored blocks were fully validated when they were first connected, so
+// they are connected without validating them again, the same way blocks are
+// connected when they are already known to be valid.
+func (b *BlockChain) reindex(state *reindexState, interrupt <-chan struct{}) error {
+	target := b.index.LookupNode(&state.target)
+	if target == nil {
+		return AssertError(fmt.Sprintf("reindex: cannot find reindex "+
+			"target %s in block index", state.target))
+	}
+
+	if state.phase == reindexPhaseWipe {
+		if err := b.wipeChainState(interrupt); err != nil {
+			return err
+		}
+		if err := b.resetChainState(state.target); err != nil {
+			return err
+		}
+	}
+
+	if err := b.replayBlocks(target, interrupt); err != nil {
+		return err
+	}
+
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbTx.Metadata().Delete(reindexStateKeyName)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Reindex of the chain state complete (height %d, hash %v)",
+		target.height, target.hash)
+	return nil
+}
+
+// isReindexing returns whether or not the chain state is being reindexed, in
+// which case no blocks may be processed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) isReindexing() bool {
+	select {
+	case <-b.reindexDone:
+		return false
+	default:
+		return true
+	}
+}
+
+// ReindexDone returns a channel that is closed once the reindex of the chain
+// state, which is performed in the background when requested or when a
+// previous reindex was interrupted, is complete.  The returned channel is
+// already closed when no reindex was performed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReindexDone() <-chan struct{} {
+	return b.reindexDone
+}
+
+// ReindexErr returns the error the reindex of the chain state failed with, if
+// any.  It must only be called once the channel returned by ReindexDone is
+// closed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReindexErr() error {
+	return b.reindexErr
+}
+
+// wipeChainState empties the buckets that house the chain state.  Since the
+// buckets can be massive, the keys are deleted in multiple database
+// transactions.
+func (b *BlockChain) wipeChainState(interrupt <-chan struct{}) error {
+	bucketNames := [][]byte{
+		utxoSetBucketName,
+		spendJournalBucketName,
+		hashIndexBucketName,
+		heightIndexBucketName,
+	}
+	for _, bucketName := range bucketNames {
+		var totalDeleted int
+		for numDeleted := reindexMaxDeletions; numDeleted == reindexMaxDeletions; {
+			numDeleted = 0
+			err := b.db.Update(func(dbTx database.Tx) error {
+				bucket := dbTx.Metadata().Bucket(bucketName)
+				cursor := bucket.Cursor()
+				for ok := cursor.First(); ok &&
+					numDeleted < reindexMaxDeletions; ok = cursor.Next() {
+
+					if err := cursor.Delete(); err != nil {
+						return err
+					}
+					numDeleted++
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if numDeleted > 0 {
+				totalDeleted += numDeleted
+				log.Infof("Deleted %d keys (%d total) from %s",
+					numDeleted, totalDeleted, bucketName)
+			}
+
+			if interruptRequested(interrupt) {
+				return errInterruptRequested
+			}
+		}
+	}
+
+	return nil
+}
+
+// resetChainState sets the best chain state to the genesis block after the
+// chain state buckets have been emptied, marks the optional indexes for rebuild
+// and moves the reindex to the replay phase.
+func (b *BlockChain) resetChainState(target chainhash.Hash) error {
+	genesis := b.bestChain.Genesis()
+	genesisBlock := btcutil.NewBlock(b.chainParams.GenesisBlock)
+	genesisBlock.SetHeight(0)
+	numTxns := uint64(len(genesisBlock.MsgBlock().Transactions))
+	blockSize := uint64(genesisBlock.MsgBlock().SerializeSize())
+	blockWeight := uint64(GetBlockWeight(genesisBlock))
+	state := newBestState(genesis, blockSize, blockWeight, numTxns,
+		numTxns, time.Unix(genesis.timestamp, 0))
+
+	err := b.db.Update(func(dbTx database.Tx) error {
+		err := dbPutBlockIndex(dbTx, &genesis.hash, genesis.height)
+		if err != nil {
+			return err
+		}
+
+		err = dbPutBestState(dbTx, state, genesis.workSum)
+		if err != nil {
+			return err
+		}
+
+		if rebuilder, ok := b.indexManager.(IndexRebuilder); ok {
+			if err := rebuilder.MarkIndexesForRebuild(dbTx); err != nil {
+				return err
+			}
+		}
+
+		return dbPutReindexState(dbTx, reindexState{
+			target: target,
+			phase:  reindexPhaseReplay,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	b.indexLock.Lock()
+	b.utxoLock.Lock()
+	b.bestChain.SetTip(genesis)
+	b.stateLock.Lock()
+	b.stateSnapshot = state
+	b.stateLock.Unlock()
+	b.utxoLock.Unlock()
+	b.indexLock.Unlock()
+
+	return nil
+}
+
+// replayBlocks connects the stored blocks from the current best chain tip up to
+// the passed target, which must be a descendant of it.  The chain lock is only
+// held while each block is connected, so the notifications are sent without
+// it.
+func (b *BlockChain) replayBlocks(target *blockNode, interrupt <-chan struct{}) error {
+	tip := b.bestChain.Tip()
+	if target.Ancestor(tip.height) != tip {
+		return AssertError(fmt.Sprintf("replayBlocks: reindex target %v "+
+			"does not descend from chain tip %v", target.hash, tip.hash))
+	}
+
+	// The optional indexes are rebuilt by the index manager once the chain
+	// state is rebuilt, so they must not be updated while replaying.
+	b.chainLock.Lock()
+	indexManager := b.indexManager
+	b.indexManager = nil
+	b.chainLock.Unlock()
+	defer func() {
+		b.chainLock.Lock()
+		b.indexManager = indexManager
+		b.chainLock.Unlock()
+	}()
+
+	lastLog := time.Now()
+	for height := tip.height + 1; height <= target.height; height++ {
+		node := target.Ancestor(height)
+		block, err := b.replayBlock(node)
+		if err != nil {
+			return err
+		}
+		b.sendNotification(NTBlockConnected, block)
+
+		if now := time.Now(); now.Sub(lastLog) >= reindexLogInterval ||
+			height == target.height {
+
+			log.Infof("Reindexed block %v (height %d of %d)",
+				node.hash, height, target.height)
+			lastLog = now
+		}
+
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+	}
+
+	return nil
+}
+
+// replayBlock connects the stored block of the passed node, whose parent must
+// be the current best chain tip, and returns it.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) replayBlock(node *blockNode) (*btcutil.Block, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	var block *btcutil.Block
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		block, err = dbFetchBlockByNode(dbTx, node)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := NewUtxoViewpoint()
+	view.SetBestHash(&node.parent.hash)
+	stxos := make([]SpentTxOut, 0, countSpentOutputs(block))
+	if err := view.fetchInputUtxos(b.db, block); err != nil {
+		return nil, err
+	}
+	if err := view.connectTransactions(block, &stxos); err != nil {
+		return nil, err
+	}
+
+	b.indexLock.Lock()
+	defer b.indexLock.Unlock()
+	b.utxoLock.Lock()
+	defer b.utxoLock.Unlock()
+	if err := b.connectBlock(node, block, view, stxos); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
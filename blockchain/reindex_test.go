@@ -0,0 +1,190 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/database"
+)
+
+// dumpChainState returns the contents of the buckets that house the chain
+// state keyed by bucket name.
+func dumpChainState(t *testing.T, db database.DB) map[string]map[string]string {
+	t.Helper()
+
+	dump := make(map[string]map[string]string)
+	err := db.View(func(dbTx database.Tx) error {
+		for _, bucketName := range [][]byte{utxoSetBucketName,
+			spendJournalBucketName, hashIndexBucketName,
+			heightIndexBucketName} {
+
+			entries := make(map[string]string)
+			bucket := dbTx.Metadata().Bucket(bucketName)
+			err := bucket.ForEach(func(k, v []byte) error {
+				entries[string(k)] = string(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			dump[string(bucketName)] = entries
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to dump chain state: %v", err)
+	}
+	return dump
+}
+
+// TestReindex ensures reindexing rebuilds the chain state from the stored
+// blocks and that an interrupted reindex is resumed.
+func TestReindex(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	chain, teardownFunc, err := chainSetup("reindex",
+		bitcoinGenesisParams(blocks[0]))
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Since we're not dealing with the real block chain, set the coinbase
+	// maturity to 1.
+	chain.TstSetCoinbaseMaturity(1)
+
+	for i := 1; i < len(blocks); i++ {
+		_, _, err := chain.ProcessBlock(blocks[i], BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+	}
+
+	// Blocks must be refused while the chain state is being reindexed.
+	reindexDone := chain.reindexDone
+	chain.reindexDone = make(chan struct{})
+	if _, _, err := chain.ProcessBlock(blocks[1], BFNoPoWCheck); err != ErrReindexing {
+		t.Fatalf("unexpected error while reindexing: %v", err)
+	}
+	if chain.IsCurrent() {
+		t.Fatalf("chain is current while reindexing")
+	}
+	chain.reindexDone = reindexDone
+
+	wantSnapshot := chain.BestSnapshot()
+	wantState := dumpChainState(t, chain.db)
+	if len(wantState[string(utxoSetBucketName)]) == 0 {
+		t.Fatalf("empty utxo set after processing blocks")
+	}
+
+	// Corrupt the utxo set by removing an entry.
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(utxoSetBucketName).Cursor()
+		cursor.First()
+		return cursor.Delete()
+	})
+	if err != nil {
+		t.Fatalf("unable to corrupt utxo set: %v", err)
+	}
+
+	// newChain creates a chain from the database and waits for the reindex
+	// performed in the background, if any, to complete.
+	newChain := func(reindex bool, interrupt <-chan struct{}) (*BlockChain, error) {
+		b, err := New(&Config{
+			DB:          chain.db,
+			Interrupt:   interrupt,
+			ChainParams: chain.chainParams,
+			TimeSource:  NewMedianTime(),
+			Reindex:     reindex,
+		})
+		if err != nil {
+			return nil, err
+		}
+		<-b.ReindexDone()
+		return b, b.ReindexErr()
+	}
+
+	// Interrupt the reindex right after it starts and ensure its state is
+	// retained.
+	interrupt := make(chan struct{})
+	close(interrupt)
+	if _, err := newChain(true, interrupt); err != errInterruptRequested {
+		t.Fatalf("unexpected error for interrupted reindex: %v", err)
+	}
+	var state *reindexState
+	err = chain.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchReindexState(dbTx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to fetch reindex state: %v", err)
+	}
+	if state == nil || state.target != wantSnapshot.Hash {
+		t.Fatalf("unexpected reindex state %+v, want target %v", state,
+			wantSnapshot.Hash)
+	}
+
+	// Starting again without requesting a reindex must resume it.
+	reindexed, err := newChain(false, nil)
+	if err != nil {
+		t.Fatalf("unable to resume reindex: %v", err)
+	}
+	snapshot := reindexed.BestSnapshot()
+	if snapshot.Hash != wantSnapshot.Hash ||
+		snapshot.Height != wantSnapshot.Height ||
+		snapshot.TotalTxns != wantSnapshot.TotalTxns {
+
+		t.Fatalf("unexpected best state after reindex - got %+v, "+
+			"want %+v", snapshot, wantSnapshot)
+	}
+	if got := dumpChainState(t, chain.db); !reflect.DeepEqual(got, wantState) {
+		t.Fatalf("chain state not rebuilt by reindex")
+	}
+	err = chain.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchReindexState(dbTx)
+		return err
+	})
+	if err != nil || state != nil {
+		t.Fatalf("reindex state not removed: %+v, %v", state, err)
+	}
+
+	// A requested reindex of an intact chain state leaves it unchanged.
+	if _, err := newChain(true, nil); err != nil {
+		t.Fatalf("unable to reindex: %v", err)
+	}
+	if got := dumpChainState(t, chain.db); !reflect.DeepEqual(got, wantState) {
+		t.Fatalf("chain state changed by reindex")
+	}
+}
+
+// TestReindexStateSerialization ensures the reindex state round trips through
+// its serialization and that corrupt state is detected.
+func TestReindexStateSerialization(t *testing.T) {
+	state := reindexState{phase: reindexPhaseReplay}
+	state.target[0] = 0x01
+	got, err := deserializeReindexState(serializeReindexState(state))
+	if err != nil || got != state {
+		t.Fatalf("round trip failed - got %+v (%v), want %+v", got, err,
+			state)
+	}
+
+	if _, err := deserializeReindexState([]byte{0x01}); !isDbCorruption(err) {
+		t.Fatalf("unexpected error for corrupt state: %v", err)
+	}
+}
+
+// isDbCorruption returns whether the passed error is a database corruption
+// error.
+func isDbCorruption(err error) bool {
+	dbErr, ok := err.(database.Error)
+	return ok && dbErr.ErrorCode == database.ErrCorruption
+}
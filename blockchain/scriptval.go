@@ -192,26 +192,25 @@ func ValidateTransactionScripts(tx *btcutil.Tx, utxoView *UtxoViewpoint,
 	flags txscript.ScriptFlags, sigCache *txscript.SigCache,
 	hashCache *txscript.HashCache) error {
 
-	// First determine if segwit is active according to the scriptFlags. If
-	// it isn't then we don't need to interact with the HashCache.
-	segwitActive := flags&txscript.ScriptVerifyWitness == txscript.ScriptVerifyWitness
-
-	// If the hashcache doesn't yet has the sighash midstate for this
-	// transaction, then we'll compute them now so we can re-use them
-	// amongst all worker validation goroutines.
-	if segwitActive && tx.MsgTx().HasWitness() &&
-		!hashCache.ContainsHashes(tx.Hash()) {
-		hashCache.AddSigHashes(tx.MsgTx())
-	}
-
+	// The same pointer to the transaction's sighash midstate will be
+	// re-used amongst all validation goroutines. By pre-computing the
+	// sighash here instead of during validation, we ensure the sighashes
+	// are only computed once.  Transactions with a single input gain
+	// nothing from the midstate, so it is only computed when there are
+	// multiple inputs to validate.
 	var cachedHashes *txscript.TxSigHashes
-	if segwitActive && tx.MsgTx().HasWitness() {
-		// The same pointer to the transaction's sighash midstate will
-		// be re-used amongst all validation goroutines. By
-		// pre-computing the sighash here instead of during validation,
-		// we ensure the sighashes
-		// are only computed once.
-		cachedHashes, _ = hashCache.GetSigHashes(tx.Hash())
+	if len(tx.MsgTx().TxIn) > 1 {
+		// If the hashcache doesn't yet has the sighash midstate for
+		// this transaction, then we'll compute them now so we can
+		// re-use them amongst all worker validation goroutines.
+		if hashCache != nil {
+			if !hashCache.ContainsHashes(tx.Hash()) {
+				hashCache.AddSigHashes(tx.MsgTx())
+			}
+			cachedHashes, _ = hashCache.GetSigHashes(tx.Hash())
+		} else {
+			cachedHashes = txscript.NewTxSigHashes(tx.MsgTx())
+		}
 	}
 
 	// Collect all of the transaction inputs and required information for
@@ -244,10 +243,6 @@ func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache,
 	hashCache *txscript.HashCache) error {
 
-	// First determine if segwit is active according to the scriptFlags. If
-	// it isn't then we don't need to interact with the HashCache.
-	segwitActive := scriptFlags&txscript.ScriptVerifyWitness == txscript.ScriptVerifyWitness
-
 	// Collect all of the transaction inputs and required information for
 	// validation for all transactions in the block into a single slice.
 	numInputs := 0
@@ -260,17 +255,18 @@ func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 
 		// If the HashCache is present, and it doesn't yet contain the
 		// partial sighashes for this transaction, then we add the
-		// sighashes for the transaction. This allows us to take
-		// advantage of the potential speed savings due to the new
-		// digest algorithm (BIP0143).
-		if segwitActive && tx.HasWitness() && hashCache != nil &&
+		// sighashes for the transaction. This allows every input to
+		// share a single serialization of the transaction when
+		// calculating its signature hash.
+		multiInput := len(tx.MsgTx().TxIn) > 1
+		if multiInput && hashCache != nil &&
 			!hashCache.ContainsHashes(hash) {
 
 			hashCache.AddSigHashes(tx.MsgTx())
 		}
 
 		var cachedHashes *txscript.TxSigHashes
-		if segwitActive && tx.HasWitness() {
+		if multiInput {
 			if hashCache != nil {
 				cachedHashes, _ = hashCache.GetSigHashes(hash)
 			} else {
@@ -307,9 +303,9 @@ func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 	// If the HashCache is present, once we have validated the block, we no
 	// longer need the cached hashes for these transactions, so we purge
 	// them from the cache.
-	if segwitActive && hashCache != nil {
+	if hashCache != nil {
 		for _, tx := range block.Transactions() {
-			if tx.MsgTx().HasWitness() {
+			if len(tx.MsgTx().TxIn) > 1 {
 				hashCache.PurgeSigHashes(tx.Hash())
 			}
 		}
@@ -0,0 +1,302 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+)
+
+var (
+	// stateIntentKeyName is the name of the db key used to store the intent
+	// to commit the external state for the block last connected or
+	// disconnected.  It only exists while the external state may lag behind
+	// the chain state so the commit can be completed after a crash.
+	stateIntentKeyName = []byte("stateintent")
+)
+
+// StateCommitter defines an interface for state which is derived from the main
+// chain but stored outside of the chain database, such as the deterministic
+// masternode list.  Changes to the state are committed along with the chain
+// state in two phases so a crash while connecting or disconnecting a block
+// can't leave the state and the chain state at different blocks:
+//
+//   - Prepare is invoked before the chain state is updated.  Errors abort the
+//     update of the chain state and the changes of all state committers
+//   - Commit is invoked once the chain state has been updated along with an
+//     intent record identifying the block
+//   - The intent record is removed once all state committers have committed
+//
+// When the chain is created and an intent record exists, the changes of the
+// block it identifies are prepared and committed again for the state
+// committers which did not commit them.  State which is further behind the
+// chain tip, such as newly created state at the genesis block, is caught up by
+// preparing and committing the main chain blocks after it.
+type StateCommitter interface {
+	// Name returns the human-readable name of the state.
+	Name() string
+
+	// Tip returns the hash of the block the committed state corresponds
+	// to.
+	Tip() (*chainhash.Hash, error)
+
+	// Prepare stages the changes the passed block makes to the state when
+	// it is connected to or disconnected from the end of the main chain.
+	// The staged changes must not be visible until they are committed.
+	Prepare(block *btcutil.Block, connect bool) error
+
+	// Commit durably applies the staged changes.
+	Commit() error
+
+	// Abort discards the staged changes.
+	Abort()
+}
+
+// stateIntent houses the block whose changes are being committed to the
+// external state.
+//
+// The serialized format is:
+//
+//	<block hash><connect>
+//
+//	Field        Type             Size
+//	block hash   chainhash.Hash   chainhash.HashSize
+//	connect      byte             1
+type stateIntent struct {
+	// hash is the hash of the block being connected or disconnected.
+	hash chainhash.Hash
+
+	// connect is whether the block is being connected.
+	connect bool
+}
+
+// serializeStateIntent returns the serialization of the passed state intent.
+func serializeStateIntent(intent stateIntent) []byte {
+	serialized := make([]byte, chainhash.HashSize+1)
+	copy(serialized, intent.hash[:])
+	if intent.connect {
+		serialized[chainhash.HashSize] = 1
+	}
+	return serialized
+}
+
+// deserializeStateIntent deserializes the passed serialized state intent.
+func deserializeStateIntent(serialized []byte) (stateIntent, error) {
+	if len(serialized) != chainhash.HashSize+1 {
+		return stateIntent{}, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt state intent",
+		}
+	}
+
+	var intent stateIntent
+	copy(intent.hash[:], serialized)
+	intent.connect = serialized[chainhash.HashSize] != 0
+	return intent, nil
+}
+
+// dbFetchStateIntent returns the pending state intent or nil when there is
+// none.
+func dbFetchStateIntent(dbTx database.Tx) (*stateIntent, error) {
+	serialized := dbTx.Metadata().Get(stateIntentKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+
+	intent, err := deserializeStateIntent(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// dbPutStateIntent stores the passed state intent.
+func dbPutStateIntent(dbTx database.Tx, intent stateIntent) error {
+	return dbTx.Metadata().Put(stateIntentKeyName,
+		serializeStateIntent(intent))
+}
+
+// prepareState runs the first phase of committing the changes the passed block
+// makes to the external state.  The changes of all state committers are
+// aborted when any of them fails to prepare.  The error of a previously failed
+// commit is returned until the state is recovered on the next start.
+func (b *BlockChain) prepareState(block *btcutil.Block, connect bool) error {
+	if b.stateCommitErr != nil {
+		return b.stateCommitErr
+	}
+	for i, committer := range b.stateCommitters {
+		if err := committer.Prepare(block, connect); err != nil {
+			for _, prepared := range b.stateCommitters[:i] {
+				prepared.Abort()
+			}
+			return fmt.Errorf("failed to prepare %s: %v",
+				committer.Name(), err)
+		}
+	}
+	return nil
+}
+
+// abortState discards the changes staged by prepareState.
+func (b *BlockChain) abortState() {
+	for _, committer := range b.stateCommitters {
+		committer.Abort()
+	}
+}
+
+// commitState runs the second phase of committing the changes of a block to
+// the external state once the chain state and the state intent were updated.
+// The state intent is removed once all state committers have committed.
+//
+// When a state committer fails to commit, the changes of the remaining state
+// committers are aborted and the state intent is kept so the commit is
+// completed when the chain is created the next time.  The error is returned
+// and prevents any further blocks from being connected or disconnected until
+// then, since the external state no longer corresponds to the chain tip.
+func (b *BlockChain) commitState() error {
+	for i, committer := range b.stateCommitters {
+		if err := committer.Commit(); err != nil {
+			for _, pending := range b.stateCommitters[i+1:] {
+				pending.Abort()
+			}
+			b.stateCommitErr = fmt.Errorf("failed to commit %s, it "+
+				"will be recovered on the next start: %v",
+				committer.Name(), err)
+			return b.stateCommitErr
+		}
+	}
+
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbTx.Metadata().Delete(stateIntentKeyName)
+	})
+	if err != nil {
+		// The state of all committers corresponds to the chain tip, so
+		// the intent is harmless and is removed on the next start.
+		log.Errorf("Failed to remove state intent: %v", err)
+	}
+	return nil
+}
+
+// recoverState ensures the state of all state committers corresponds to the
+// current chain tip.  State which is behind the tip on the main chain, such as
+// state which was just created or state which failed to commit the block of the
+// pending state intent, is caught up by connecting the blocks after it, while
+// state which is still at the block the intent disconnects is recovered by
+// disconnecting that block.  An error is returned for any other state.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) recoverState(interrupt <-chan struct{}) error {
+	if len(b.stateCommitters) == 0 {
+		return nil
+	}
+
+	var intent *stateIntent
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		intent, err = dbFetchStateIntent(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	tip := b.bestChain.Tip()
+	for _, committer := range b.stateCommitters {
+		stateTip, err := committer.Tip()
+		if err != nil {
+			return err
+		}
+		if *stateTip == tip.hash {
+			continue
+		}
+
+		node := b.index.LookupNode(stateTip)
+		switch {
+		case node != nil && b.bestChain.Contains(node):
+			err := b.catchUpState(committer, node, interrupt)
+			if err != nil {
+				return err
+			}
+
+		case node != nil && node.parent == tip && intent != nil &&
+			!intent.connect && intent.hash == node.hash:
+
+			log.Infof("Recovering %s to block %v", committer.Name(),
+				&tip.hash)
+			err := b.commitStateBlock(committer, node, false)
+			if err != nil {
+				return fmt.Errorf("failed to recover %s: %v",
+					committer.Name(), err)
+			}
+
+		default:
+			return fmt.Errorf("%s is at block %v instead of the chain "+
+				"tip %v", committer.Name(), stateTip, &tip.hash)
+		}
+	}
+
+	if intent == nil {
+		return nil
+	}
+	return b.db.Update(func(dbTx database.Tx) error {
+		return dbTx.Metadata().Delete(stateIntentKeyName)
+	})
+}
+
+// catchUpState connects the main chain blocks after the passed node, which the
+// state of the passed state committer is at, up to the chain tip to the state.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) catchUpState(committer StateCommitter, node *blockNode, interrupt <-chan struct{}) error {
+	tip := b.bestChain.Tip()
+	log.Infof("Catching up %s from height %d to height %d",
+		committer.Name(), node.height, tip.height)
+
+	lastLog := time.Now()
+	for height := node.height + 1; height <= tip.height; height++ {
+		n := b.bestChain.NodeByHeight(height)
+		if err := b.commitStateBlock(committer, n, true); err != nil {
+			return fmt.Errorf("failed to catch up %s: %v",
+				committer.Name(), err)
+		}
+
+		if now := time.Now(); now.Sub(lastLog) >= reindexLogInterval ||
+			height == tip.height {
+
+			log.Infof("Caught up %s to height %d of %d",
+				committer.Name(), height, tip.height)
+			lastLog = now
+		}
+
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+	}
+	return nil
+}
+
+// commitStateBlock prepares and commits the changes the block of the passed
+// node makes to the state of the passed state committer when it is connected
+// or disconnected.
+func (b *BlockChain) commitStateBlock(committer StateCommitter, node *blockNode, connect bool) error {
+	var block *btcutil.Block
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		block, err = dbFetchBlockByNode(dbTx, node)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := committer.Prepare(block, connect); err != nil {
+		committer.Abort()
+		return err
+	}
+	return committer.Commit()
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/database"
+)
+
+// fakeStateCommitter is a StateCommitter which tracks the block its state is
+// at and can be configured to fail either phase.
+type fakeStateCommitter struct {
+	tip         chainhash.Hash
+	staged      *chainhash.Hash
+	aborts      int
+	failPrepare bool
+	failCommit  bool
+}
+
+func (c *fakeStateCommitter) Name() string {
+	return "fake state"
+}
+
+func (c *fakeStateCommitter) Tip() (*chainhash.Hash, error) {
+	tip := c.tip
+	return &tip, nil
+}
+
+func (c *fakeStateCommitter) Prepare(block *btcutil.Block, connect bool) error {
+	if c.failPrepare {
+		return errors.New("prepare failed")
+	}
+	prevHash := block.MsgBlock().Header.PrevBlock
+	if connect && prevHash != c.tip {
+		return fmt.Errorf("connected block %v does not extend %v",
+			block.Hash(), c.tip)
+	}
+	if !connect && *block.Hash() != c.tip {
+		return fmt.Errorf("disconnected block %v is not %v",
+			block.Hash(), c.tip)
+	}
+	if connect {
+		c.staged = block.Hash()
+	} else {
+		c.staged = &prevHash
+	}
+	return nil
+}
+
+func (c *fakeStateCommitter) Commit() error {
+	if c.failCommit {
+		return errors.New("commit failed")
+	}
+	c.tip = *c.staged
+	c.staged = nil
+	return nil
+}
+
+func (c *fakeStateCommitter) Abort() {
+	c.staged = nil
+	c.aborts++
+}
+
+// fetchStateIntent returns the pending state intent of the passed chain.
+func fetchStateIntent(t *testing.T, chain *BlockChain) *stateIntent {
+	t.Helper()
+
+	var intent *stateIntent
+	err := chain.db.View(func(dbTx database.Tx) error {
+		var err error
+		intent, err = dbFetchStateIntent(dbTx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to fetch state intent: %v", err)
+	}
+	return intent
+}
+
+// TestStateCommit ensures the external state is committed along with the chain
+// state, that failures to prepare it abort connecting blocks, that failures to
+// commit it halt block processing and that interrupted commits are recovered
+// when the chain is created along with state which is further behind.
+func TestStateCommit(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	chain, teardownFunc, err := chainSetup("statecommit",
+		bitcoinGenesisParams(blocks[0]))
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Since we're not dealing with the real block chain, set the coinbase
+	// maturity to 1.
+	chain.TstSetCoinbaseMaturity(1)
+
+	committer := &fakeStateCommitter{tip: *blocks[0].Hash()}
+	chain.stateCommitters = []StateCommitter{committer}
+	for i := 1; i < 3; i++ {
+		if _, _, err := chain.ProcessBlock(blocks[i], BFNoPoWCheck); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+	}
+	if committer.tip != *blocks[2].Hash() {
+		t.Fatalf("state at block %v, want %v", committer.tip,
+			blocks[2].Hash())
+	}
+	if intent := fetchStateIntent(t, chain); intent != nil {
+		t.Fatalf("state intent %+v not removed", intent)
+	}
+
+	// A failed commit is returned, aborts the changes of the remaining
+	// committers and leaves the state behind the chain along with the
+	// intent to commit it.  The block is connected regardless, so its
+	// notification is sent.
+	var connected []*btcutil.Block
+	chain.Subscribe(func(n *Notification) {
+		if n.Type == NTBlockConnected {
+			connected = append(connected, n.Data.(*btcutil.Block))
+		}
+	})
+	pending := &fakeStateCommitter{tip: *blocks[2].Hash()}
+	chain.stateCommitters = []StateCommitter{committer, pending}
+	committer.failCommit = true
+	if _, _, err := chain.ProcessBlock(blocks[3], BFNoPoWCheck); err == nil {
+		t.Fatal("ProcessBlock: failed commit not returned")
+	}
+	if len(connected) != 1 || connected[0] != blocks[3] {
+		t.Fatalf("got %d connected notifications after failed commit, "+
+			"want block %v", len(connected), blocks[3].Hash())
+	}
+	if hash := chain.BestSnapshot().Hash; hash != *blocks[3].Hash() {
+		t.Fatalf("chain tip %v after failed commit, want %v", hash,
+			blocks[3].Hash())
+	}
+	if pending.aborts != 1 || pending.tip != *blocks[2].Hash() {
+		t.Fatalf("changes of pending state at block %v not aborted",
+			pending.tip)
+	}
+	intent := fetchStateIntent(t, chain)
+	want := stateIntent{hash: *blocks[3].Hash(), connect: true}
+	if intent == nil || *intent != want {
+		t.Fatalf("got state intent %+v, want %+v", intent, want)
+	}
+
+	// No further changes are prepared until the state is recovered.
+	committer.failCommit = false
+	if err := chain.prepareState(blocks[4], true); err == nil {
+		t.Fatal("prepareState: changes prepared after failed commit")
+	}
+
+	newChain := func(committers ...StateCommitter) (*BlockChain, error) {
+		return New(&Config{
+			DB:              chain.db,
+			ChainParams:     chain.chainParams,
+			TimeSource:      NewMedianTime(),
+			StateCommitters: committers,
+		})
+	}
+
+	// State which is neither at the tip nor at the block before the one
+	// of the intent can't be recovered.
+	if _, err := newChain(&fakeStateCommitter{}); err == nil {
+		t.Fatal("newChain: inconsistent state accepted")
+	}
+
+	// Creating the chain again completes the commit and catches up state
+	// which is further behind the chain.
+	behind := &fakeStateCommitter{tip: *blocks[0].Hash()}
+	recovered, err := newChain(committer, pending, behind)
+	if err != nil {
+		t.Fatalf("unable to recover state: %v", err)
+	}
+	if committer.tip != *blocks[3].Hash() || pending.tip != *blocks[3].Hash() {
+		t.Fatalf("state recovered to blocks %v and %v, want %v",
+			committer.tip, pending.tip, blocks[3].Hash())
+	}
+	if behind.tip != *blocks[3].Hash() {
+		t.Fatalf("state caught up to block %v, want %v", behind.tip,
+			blocks[3].Hash())
+	}
+	if intent := fetchStateIntent(t, recovered); intent != nil {
+		t.Fatalf("state intent %+v not removed", intent)
+	}
+	recovered.TstSetCoinbaseMaturity(1)
+
+	// A failure to prepare the state of any committer aborts connecting
+	// the block and discards the changes staged by the others.
+	failing := &fakeStateCommitter{tip: *blocks[3].Hash(), failPrepare: true}
+	recovered.stateCommitters = []StateCommitter{committer, failing}
+	if _, _, err := recovered.ProcessBlock(blocks[4], BFNoPoWCheck); err == nil {
+		t.Fatal("ProcessBlock: block connected despite failed prepare")
+	}
+	if hash := recovered.BestSnapshot().Hash; hash != *blocks[3].Hash() {
+		t.Fatalf("chain tip %v after failed prepare, want %v", hash,
+			blocks[3].Hash())
+	}
+	if committer.aborts != 1 || committer.tip != *blocks[3].Hash() {
+		t.Fatalf("changes of state at block %v not aborted",
+			committer.tip)
+	}
+}
+
+// TestStateIntentSerialization ensures the state intent round trips through
+// its serialization and that corrupt intents are detected.
+func TestStateIntentSerialization(t *testing.T) {
+	for _, connect := range []bool{false, true} {
+		intent := stateIntent{hash: chainhash.Hash{0x01}, connect: connect}
+		got, err := deserializeStateIntent(serializeStateIntent(intent))
+		if err != nil || got != intent {
+			t.Fatalf("round trip failed - got %+v (%v), want %+v",
+				got, err, intent)
+		}
+	}
+
+	_, err := deserializeStateIntent([]byte{0x01})
+	if !isDbCorruption(err) {
+		t.Fatalf("unexpected error for corrupt intent: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ package blockchain
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
@@ -106,21 +107,30 @@ type thresholdConditionChecker interface {
 
 // thresholdStateCache provides a type to cache the threshold states of each
 // threshold window for a set of IDs.
+//
+// It is safe for concurrent access.  Since the threshold state of a window is
+// fully determined by the blocks in it, concurrent callers which calculate the
+// state of the same window update the cache with the same value.
 type thresholdStateCache struct {
+	mtx     sync.RWMutex
 	entries map[chainhash.Hash]ThresholdState
 }
 
 // Lookup returns the threshold state associated with the given hash along with
 // a boolean that indicates whether or not it is valid.
 func (c *thresholdStateCache) Lookup(hash *chainhash.Hash) (ThresholdState, bool) {
+	c.mtx.RLock()
 	state, ok := c.entries[*hash]
+	c.mtx.RUnlock()
 	return state, ok
 }
 
 // Update updates the cache to contain the provided hash to threshold state
 // mapping.
 func (c *thresholdStateCache) Update(hash *chainhash.Hash, state ThresholdState) {
+	c.mtx.Lock()
 	c.entries[*hash] = state
+	c.mtx.Unlock()
 }
 
 // newThresholdCaches returns a new array of caches to be used when calculating
@@ -253,7 +263,8 @@ func thresholdStateTransition(state ThresholdState, prevNode *blockNode,
 // AFTER the given node and deployment ID.  The cache is used to ensure the
 // threshold states for previous windows are only calculated once.
 //
-// This function MUST be called with the chain state lock held (for writes).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) thresholdState(prevNode *blockNode, checker thresholdConditionChecker, cache *thresholdStateCache) (ThresholdState, error) {
 	// The threshold state for the window that contains the genesis block is
 	// defined by definition.
@@ -335,9 +346,9 @@ func (b *BlockChain) thresholdState(prevNode *blockNode, checker thresholdCondit
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) ThresholdState(deploymentID uint32) (ThresholdState, error) {
-	b.chainLock.Lock()
+	b.indexLock.RLock()
 	state, err := b.deploymentState(b.bestChain.Tip(), deploymentID)
-	b.chainLock.Unlock()
+	b.indexLock.RUnlock()
 
 	return state, err
 }
@@ -347,9 +358,9 @@ func (b *BlockChain) ThresholdState(deploymentID uint32) (ThresholdState, error)
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) IsDeploymentActive(deploymentID uint32) (bool, error) {
-	b.chainLock.Lock()
+	b.indexLock.RLock()
 	state, err := b.deploymentState(b.bestChain.Tip(), deploymentID)
-	b.chainLock.Unlock()
+	b.indexLock.RUnlock()
 	if err != nil {
 		return false, err
 	}
@@ -357,6 +368,186 @@ func (b *BlockChain) IsDeploymentActive(deploymentID uint32) (bool, error) {
 	return state == ThresholdActive, nil
 }
 
+// ThresholdStats houses the signalling statistics of a rule change deployment
+// for the confirmation window that contains a given block.
+type ThresholdStats struct {
+	// Period is the number of blocks in each confirmation window.
+	Period uint32
+
+	// Threshold is the number of blocks within a confirmation window that
+	// must signal for the rule change in order for it to lock in.
+	Threshold uint32
+
+	// Elapsed is the number of blocks of the current confirmation window
+	// that have already been connected.
+	Elapsed uint32
+
+	// Count is the number of the elapsed blocks that signalled for the rule
+	// change.
+	Count uint32
+
+	// Possible indicates whether or not the threshold can still be reached
+	// within the current confirmation window.
+	Possible bool
+}
+
+// DeploymentStatus describes the activation progress of a rule change
+// deployment for the block AFTER the end of the current best chain.  It is the
+// programmatic equivalent of the softforks section of the getblockchaininfo RPC
+// in Dash Core.
+type DeploymentStatus struct {
+	// State is the threshold state of the deployment.
+	State ThresholdState
+
+	// Since is the height of the first block to which the state applies.
+	Since int32
+
+	// Stats houses the signalling statistics of the current confirmation
+	// window.  It is only set while the deployment is in the
+	// ThresholdStarted state since signalling has no effect otherwise.
+	Stats *ThresholdStats
+}
+
+// thresholdStats returns the signalling statistics of the confirmation window
+// that contains the passed node as determined by the provided checker.
+//
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
+func thresholdStats(node *blockNode, checker thresholdConditionChecker) (*ThresholdStats, error) {
+	stats := &ThresholdStats{
+		Period:    checker.MinerConfirmationWindow(),
+		Threshold: checker.RuleChangeActivationThreshold(),
+	}
+	if node == nil {
+		return stats, nil
+	}
+
+	// Count the signalling blocks from the passed node back to the last
+	// block of the previous confirmation window.
+	period := int32(stats.Period)
+	endOfPrevPeriod := node.height - (node.height+1)%period
+	for countNode := node; countNode != nil &&
+		countNode.height > endOfPrevPeriod; countNode = countNode.parent {
+
+		condition, err := checker.Condition(countNode)
+		if err != nil {
+			return nil, err
+		}
+		if condition {
+			stats.Count++
+		}
+		stats.Elapsed++
+	}
+
+	// The threshold can no longer be reached once more blocks than the
+	// window allows for have not signalled.
+	stats.Possible = stats.Period-stats.Threshold >= stats.Elapsed-stats.Count
+
+	return stats, nil
+}
+
+// thresholdStateSince returns the height of the first block to which the
+// threshold state for the block AFTER the passed node applies.
+//
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
+func (b *BlockChain) thresholdStateSince(prevNode *blockNode,
+	checker thresholdConditionChecker, cache *thresholdStateCache) (int32, error) {
+
+	state, err := b.thresholdState(prevNode, checker, cache)
+	if err != nil {
+		return 0, err
+	}
+	if state == ThresholdDefined {
+		return 0, nil
+	}
+
+	// The state of a block is always the same as that of the first block
+	// of its confirmation window, so start from the last block of the
+	// previous window and walk backwards a window at a time for as long as
+	// the state remains the same.
+	confirmationWindow := int32(checker.MinerConfirmationWindow())
+	prevNode = prevNode.Ancestor(prevNode.height -
+		(prevNode.height+1)%confirmationWindow)
+	for {
+		prevPeriodNode := prevNode.RelativeAncestor(confirmationWindow)
+		if prevPeriodNode == nil {
+			break
+		}
+		prevState, err := b.thresholdState(prevPeriodNode, checker, cache)
+		if err != nil {
+			return 0, err
+		}
+		if prevState != state {
+			break
+		}
+		prevNode = prevPeriodNode
+	}
+
+	return prevNode.height + 1, nil
+}
+
+// ThresholdStats returns the signalling statistics of the given deployment ID
+// for the confirmation window containing the end of the current best chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ThresholdStats(deploymentID uint32) (*ThresholdStats, error) {
+	if deploymentID >= uint32(len(b.chainParams.Deployments)) {
+		return nil, DeploymentError(deploymentID)
+	}
+
+	deployment := &b.chainParams.Deployments[deploymentID]
+	checker := deploymentChecker{deployment: deployment, chain: b}
+
+	b.indexLock.RLock()
+	stats, err := thresholdStats(b.bestChain.Tip(), checker)
+	b.indexLock.RUnlock()
+
+	return stats, err
+}
+
+// DeploymentStatus returns the threshold state of the given deployment ID for
+// the block AFTER the end of the current best chain along with the height the
+// state applies since and, while the deployment is being voted on, the
+// signalling statistics of the current confirmation window.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) DeploymentStatus(deploymentID uint32) (*DeploymentStatus, error) {
+	if deploymentID >= uint32(len(b.chainParams.Deployments)) {
+		return nil, DeploymentError(deploymentID)
+	}
+
+	deployment := &b.chainParams.Deployments[deploymentID]
+	checker := deploymentChecker{deployment: deployment, chain: b}
+	cache := &b.deploymentCaches[deploymentID]
+
+	b.indexLock.RLock()
+	defer b.indexLock.RUnlock()
+
+	tip := b.bestChain.Tip()
+	state, err := b.thresholdState(tip, checker, cache)
+	if err != nil {
+		return nil, err
+	}
+	since, err := b.thresholdStateSince(tip, checker, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &DeploymentStatus{
+		State: state,
+		Since: since,
+	}
+	if state == ThresholdStarted {
+		status.Stats, err = thresholdStats(tip, checker)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
 // deploymentState returns the current rule change threshold for a given
 // deploymentID. The threshold is evaluated from the point of view of the block
 // node passed in as the first argument to this method.
@@ -366,7 +557,8 @@ func (b *BlockChain) IsDeploymentActive(deploymentID uint32) (bool, error) {
 // desired.  In other words, the returned deployment state is for the block
 // AFTER the passed node.
 //
-// This function MUST be called with the chain state lock held (for writes).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) deploymentState(prevNode *blockNode, deploymentID uint32) (ThresholdState, error) {
 	if deploymentID > uint32(len(b.chainParams.Deployments)) {
 		return ThresholdFailed, DeploymentError(deploymentID)
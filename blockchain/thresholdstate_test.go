@@ -6,7 +6,9 @@ package blockchain
 
 import (
 	"testing"
+	"time"
 
+	"github.com/dashpay/dashd-go/chaincfg"
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 )
 
@@ -316,3 +318,69 @@ func TestThresholdStateTransition(t *testing.T) {
 		}
 	}
 }
+
+// TestDeploymentStatus ensures the deployment status, including the height the
+// state applies since and the signalling statistics of the current window, are
+// reported as expected while a deployment is being voted on.
+func TestDeploymentStatus(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegressionNetParams
+	chain := newFakeChain(&params)
+	window := int32(params.MinerConfirmationWindow)
+	deployment := &params.Deployments[chaincfg.DeploymentTestDummy]
+	signalVersion := int32(vbTopBits | uint32(1)<<deployment.BitNumber)
+
+	// Create two full confirmation windows of blocks without signalling
+	// followed by a partial window in which every other block signals.
+	const extraBlocks = 11
+	tip := chain.bestChain.Tip()
+	for i := int32(0); i < 2*window+extraBlocks-1; i++ {
+		version := int32(vbTopBits)
+		if tip.height+1 >= 2*window && (tip.height+1)%2 == 0 {
+			version = signalVersion
+		}
+		tip = newFakeNode(tip, version, 0, time.Unix(tip.timestamp+1, 0))
+		chain.index.AddNode(tip)
+	}
+	chain.bestChain.SetTip(tip)
+
+	status, err := chain.DeploymentStatus(chaincfg.DeploymentTestDummy)
+	if err != nil {
+		t.Fatalf("DeploymentStatus: unexpected error: %v", err)
+	}
+	if status.State != ThresholdStarted {
+		t.Fatalf("unexpected state: got %v, want %v", status.State,
+			ThresholdStarted)
+	}
+	if status.Since != window {
+		t.Fatalf("unexpected since height: got %d, want %d",
+			status.Since, window)
+	}
+
+	wantStats := ThresholdStats{
+		Period:    params.MinerConfirmationWindow,
+		Threshold: params.RuleChangeActivationThreshold,
+		Elapsed:   extraBlocks,
+		Count:     extraBlocks/2 + 1,
+		Possible:  true,
+	}
+	if status.Stats == nil || *status.Stats != wantStats {
+		t.Fatalf("unexpected stats: got %+v, want %+v", status.Stats,
+			wantStats)
+	}
+
+	stats, err := chain.ThresholdStats(chaincfg.DeploymentTestDummy)
+	if err != nil {
+		t.Fatalf("ThresholdStats: unexpected error: %v", err)
+	}
+	if *stats != wantStats {
+		t.Fatalf("unexpected stats: got %+v, want %+v", stats, wantStats)
+	}
+
+	// Ensure invalid deployment IDs are rejected.
+	_, err = chain.DeploymentStatus(chaincfg.DefinedDeployments)
+	if _, ok := err.(DeploymentError); !ok {
+		t.Fatalf("unexpected error for invalid deployment: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// maxSlowBlocks is the number of blocks with the longest total validation time
+// that are retained for SlowestBlocks.
+const maxSlowBlocks = 10
+
+// validationStage identifies a stage of block validation that is timed.
+type validationStage int
+
+// These constants define the timed stages of block validation.
+const (
+	// stageSanity is the context-free checks of the header and block.
+	stageSanity validationStage = iota
+
+	// stageContext is the checks that depend on the position of the block
+	// in the chain, excluding script validation and utxo loading.
+	stageContext
+
+	// stageScripts is the execution of all input scripts.
+	stageScripts
+
+	// stageUtxo is loading, spending and storing the utxos of the block.
+	stageUtxo
+
+	// stageIndex is updating the optional indexes.
+	stageIndex
+)
+
+// BlockTiming houses the time spent in each stage of validating and connecting
+// a block.  Stages the block did not reach, such as connection for side chain
+// blocks, are zero.
+type BlockTiming struct {
+	Hash   chainhash.Hash
+	Height int32
+
+	// SanityChecks is the time spent on the context-free checks of the
+	// header and block contents.
+	SanityChecks time.Duration
+
+	// ContextChecks is the time spent on checks that depend on the
+	// position of the block in the chain, such as difficulty, sequence
+	// locks, signature operation limits and input values.
+	ContextChecks time.Duration
+
+	// ScriptValidation is the time spent executing input scripts.
+	ScriptValidation time.Duration
+
+	// UtxoUpdates is the time spent loading the referenced utxos, spending
+	// them and writing the resulting utxo set and spend journal.
+	UtxoUpdates time.Duration
+
+	// IndexUpdates is the time spent updating the optional indexes.
+	IndexUpdates time.Duration
+}
+
+// Total returns the total time spent validating and connecting the block.
+func (t *BlockTiming) Total() time.Duration {
+	return t.SanityChecks + t.ContextChecks + t.ScriptValidation +
+		t.UtxoUpdates + t.IndexUpdates
+}
+
+// String returns a summary of the timing breakdown.
+func (t *BlockTiming) String() string {
+	return fmt.Sprintf("total %v (sanity %v, context %v, scripts %v, "+
+		"utxo %v, index %v)", t.Total(), t.SanityChecks,
+		t.ContextChecks, t.ScriptValidation, t.UtxoUpdates,
+		t.IndexUpdates)
+}
+
+// add adds the passed duration to the field for the given stage.
+func (t *BlockTiming) add(stage validationStage, d time.Duration) {
+	switch stage {
+	case stageSanity:
+		t.SanityChecks += d
+	case stageContext:
+		t.ContextChecks += d
+	case stageScripts:
+		t.ScriptValidation += d
+	case stageUtxo:
+		t.UtxoUpdates += d
+	case stageIndex:
+		t.IndexUpdates += d
+	}
+}
+
+// blockTimings collects the validation timing of the blocks handled by a call
+// to ProcessBlock and retains the slowest of them.
+//
+// The pending timings are only accessed with the chain lock held while the
+// retained slowest blocks are protected by their own mutex so they can be
+// queried concurrently.  A nil blockTimings ignores all timings.
+type blockTimings struct {
+	// slowThreshold is the total validation time at which a warning with
+	// the timing breakdown is logged.  Zero disables the warning.
+	slowThreshold time.Duration
+
+	active  bool
+	pending map[chainhash.Hash]*BlockTiming
+
+	mtx     sync.Mutex
+	slowest []BlockTiming
+}
+
+// newBlockTimings returns a new block timing collector that warns about blocks
+// taking at least the passed threshold to validate.
+func newBlockTimings(slowThreshold time.Duration) *blockTimings {
+	return &blockTimings{
+		slowThreshold: slowThreshold,
+		pending:       make(map[chainhash.Hash]*BlockTiming),
+	}
+}
+
+// begin starts collecting timings.  Timings recorded outside of begin and end,
+// such as while checking block templates, are ignored.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (t *blockTimings) begin() {
+	if t == nil {
+		return
+	}
+	t.active = true
+}
+
+// add records the passed duration for the given stage of the block with the
+// passed hash.  A negative height indicates the height is not yet known.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (t *blockTimings) add(hash *chainhash.Hash, height int32,
+	stage validationStage, d time.Duration) {
+
+	if t == nil || !t.active {
+		return
+	}
+
+	timing, ok := t.pending[*hash]
+	if !ok {
+		timing = &BlockTiming{Hash: *hash, Height: -1}
+		t.pending[*hash] = timing
+	}
+	if height >= 0 {
+		timing.Height = height
+	}
+	timing.add(stage, d)
+}
+
+// end stops collecting timings, logs the blocks that exceeded the slow block
+// threshold and retains the slowest blocks.  Blocks whose height never became
+// known, such as orphans, are discarded.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (t *blockTimings) end() {
+	if t == nil {
+		return
+	}
+	t.active = false
+	if len(t.pending) == 0 {
+		return
+	}
+
+	t.mtx.Lock()
+	for hash, timing := range t.pending {
+		delete(t.pending, hash)
+		if timing.Height < 0 {
+			continue
+		}
+
+		if t.slowThreshold > 0 && timing.Total() >= t.slowThreshold {
+			log.Warnf("Slow block %v (height %d): %v", timing.Hash,
+				timing.Height, timing)
+		}
+		t.slowest = append(t.slowest, *timing)
+	}
+	sort.SliceStable(t.slowest, func(i, j int) bool {
+		return t.slowest[i].Total() > t.slowest[j].Total()
+	})
+	if len(t.slowest) > maxSlowBlocks {
+		t.slowest = t.slowest[:maxSlowBlocks]
+	}
+	t.mtx.Unlock()
+}
+
+// SlowestBlocks returns the validation timing breakdown of the blocks that took
+// the longest to process since the chain instance was created, ordered from
+// the slowest.  At most 10 blocks are retained.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SlowestBlocks() []BlockTiming {
+	t := b.timings
+	if t == nil {
+		return nil
+	}
+
+	t.mtx.Lock()
+	slowest := make([]BlockTiming, len(t.slowest))
+	copy(slowest, t.slowest)
+	t.mtx.Unlock()
+
+	return slowest
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// TestBlockTimings ensures the block timing collector attributes durations to
+// the right stages, only records while active, discards blocks with an unknown
+// height and retains the slowest blocks in descending order.
+func TestBlockTimings(t *testing.T) {
+	timings := newBlockTimings(0)
+	hash := func(b byte) *chainhash.Hash {
+		return &chainhash.Hash{b}
+	}
+
+	// Timings recorded outside of begin and end are ignored.
+	timings.add(hash(1), 1, stageSanity, time.Second)
+	timings.begin()
+	timings.add(hash(1), -1, stageSanity, 1)
+	timings.add(hash(1), 1, stageContext, 2)
+	timings.add(hash(1), -1, stageScripts, 4)
+	timings.add(hash(1), -1, stageUtxo, 8)
+	timings.add(hash(1), -1, stageIndex, 16)
+	timings.add(hash(2), -1, stageSanity, time.Hour)
+	timings.end()
+
+	want := BlockTiming{
+		Hash:             *hash(1),
+		Height:           1,
+		SanityChecks:     1,
+		ContextChecks:    2,
+		ScriptValidation: 4,
+		UtxoUpdates:      8,
+		IndexUpdates:     16,
+	}
+	got := timings.slowest
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("unexpected timings - got %+v, want [%+v]", got, want)
+	}
+	if total := got[0].Total(); total != 31 {
+		t.Fatalf("wrong total - got %v, want 31ns", total)
+	}
+
+	// Only the slowest blocks are retained, slowest first.
+	timings.begin()
+	for i := 0; i < maxSlowBlocks+5; i++ {
+		timings.add(hash(byte(i+10)), int32(i+10), stageScripts,
+			time.Duration(i+100))
+	}
+	timings.end()
+	if len(timings.slowest) != maxSlowBlocks {
+		t.Fatalf("wrong number of retained blocks - got %d, want %d",
+			len(timings.slowest), maxSlowBlocks)
+	}
+	for i := 1; i < len(timings.slowest); i++ {
+		if timings.slowest[i-1].Total() < timings.slowest[i].Total() {
+			t.Fatalf("retained blocks not ordered by total time")
+		}
+	}
+	if first := timings.slowest[0].Height; first != maxSlowBlocks+14 {
+		t.Fatalf("wrong slowest block - got height %d, want %d", first,
+			maxSlowBlocks+14)
+	}
+
+	// A nil collector ignores everything.
+	var nilTimings *blockTimings
+	nilTimings.begin()
+	nilTimings.add(hash(1), 1, stageSanity, 1)
+	nilTimings.end()
+}
+
+// TestSlowestBlocks ensures processing blocks records their validation timing.
+func TestSlowestBlocks(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	chain, teardownFunc, err := chainSetup("slowestblocks",
+		bitcoinGenesisParams(blocks[0]))
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Since we're not dealing with the real block chain, set the coinbase
+	// maturity to 1.
+	chain.TstSetCoinbaseMaturity(1)
+
+	if slowest := chain.SlowestBlocks(); len(slowest) != 0 {
+		t.Fatalf("unexpected timings before processing blocks: %v",
+			slowest)
+	}
+	for i := 1; i < len(blocks); i++ {
+		_, _, err := chain.ProcessBlock(blocks[i], BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+	}
+
+	slowest := chain.SlowestBlocks()
+	if len(slowest) != len(blocks)-1 {
+		t.Fatalf("wrong number of timings - got %d, want %d",
+			len(slowest), len(blocks)-1)
+	}
+	for _, timing := range slowest {
+		block := blocks[timing.Height]
+		if timing.Hash != *block.Hash() {
+			t.Fatalf("timing for height %d has hash %v, want %v",
+				timing.Height, timing.Hash, block.Hash())
+		}
+		if timing.SanityChecks <= 0 || timing.ContextChecks <= 0 {
+			t.Fatalf("missing stage timings for height %d: %v",
+				timing.Height, &timing)
+		}
+	}
+}
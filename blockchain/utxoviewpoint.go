@@ -159,6 +159,19 @@ func (view *UtxoViewpoint) LookupEntry(outpoint wire.OutPoint) *UtxoEntry {
 	return view.entries[outpoint]
 }
 
+// FetchPrevOutput returns the transaction output for the passed outpoint
+// according to the current state of the view, or nil when it does not exist in
+// the view or has been spent.
+//
+// This method allows the view to be used as a txscript.PrevOutputFetcher.
+func (view *UtxoViewpoint) FetchPrevOutput(outpoint wire.OutPoint) *wire.TxOut {
+	entry := view.entries[outpoint]
+	if entry == nil || entry.IsSpent() {
+		return nil
+	}
+	return wire.NewTxOut(entry.Amount(), entry.PkScript())
+}
+
 // addTxOut adds the specified output to the view if it is not provably
 // unspendable.  When the view already has an entry for the output, it will be
 // marked unspent.  All fields will be updated for existing entries since it's
@@ -624,9 +637,9 @@ func (b *BlockChain) FetchUtxoView(tx *btcutil.Tx) (*UtxoViewpoint, error) {
 	// Request the utxos from the point of view of the end of the main
 	// chain.
 	view := NewUtxoViewpoint()
-	b.chainLock.RLock()
+	b.utxoLock.RLock()
 	err := view.fetchUtxosMain(b.db, neededSet)
-	b.chainLock.RUnlock()
+	b.utxoLock.RUnlock()
 	return view, err
 }
 
@@ -641,8 +654,8 @@ func (b *BlockChain) FetchUtxoView(tx *btcutil.Tx) (*UtxoViewpoint, error) {
 // This function is safe for concurrent access however the returned entry (if
 // any) is NOT.
 func (b *BlockChain) FetchUtxoEntry(outpoint wire.OutPoint) (*UtxoEntry, error) {
-	b.chainLock.RLock()
-	defer b.chainLock.RUnlock()
+	b.utxoLock.RLock()
+	defer b.utxoLock.RUnlock()
 
 	var entry *UtxoEntry
 	err := b.db.View(func(dbTx database.Tx) error {
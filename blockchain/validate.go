@@ -323,8 +323,9 @@ func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags Behavio
 	// The block hash must be less than the claimed target unless the flag
 	// to avoid proof of work checks is set.
 	if flags&BFNoPoWCheck != BFNoPoWCheck {
-		// The block hash must be less than the claimed target.
-		hash := header.BlockHash()
+		// The X11 hash of the block must be less than the claimed
+		// target.
+		hash := header.PowHash()
 		hashNum := HashToBig(&hash)
 		if hashNum.Cmp(target) > 0 {
 			str := fmt.Sprintf("block hash of %064x is higher than "+
@@ -673,8 +674,24 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 	// block.
 	blockHeight := prevNode.height + 1
 
+	// Ensure the block at height one of a devnet is the devnet genesis
+	// block.  Devnets share the same genesis block, so this prevents the
+	// chain of a devnet from being extended with the blocks of another
+	// devnet it was accidentally connected to.
+	//
+	// Both the devnet genesis hash and the checkpoints are the X11 hashes
+	// Dash identifies blocks by.
+	blockHash := header.PowHash()
+	params := b.chainParams
+	if params.DevNetGenesisHash != nil && blockHeight == 1 &&
+		!blockHash.IsEqual(params.DevNetGenesisHash) {
+
+		str := fmt.Sprintf("block %v at height 1 is not the devnet "+
+			"genesis block %v", blockHash, params.DevNetGenesisHash)
+		return ruleError(ErrBadDevNetGenesis, str)
+	}
+
 	// Ensure chain matches up to predetermined checkpoints.
-	blockHash := header.BlockHash()
 	if !b.verifyCheckpoint(blockHeight, &blockHash) {
 		str := fmt.Sprintf("block at height %d does not match "+
 			"checkpoint hash", blockHeight)
@@ -699,7 +716,6 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 	// Reject outdated block versions once a majority of the network
 	// has upgraded.  These were originally voted on by BIP0034,
 	// BIP0065, and BIP0066.
-	params := b.chainParams
 	if header.Version < 2 && blockHeight >= params.BIP0034Height ||
 		header.Version < 3 && blockHeight >= params.BIP0066Height ||
 		header.Version < 4 && blockHeight >= params.BIP0065Height {
@@ -986,6 +1002,17 @@ func CheckTransactionInputs(tx *btcutil.Tx, txHeight int32, utxoView *UtxoViewpo
 //
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, view *UtxoViewpoint, stxos *[]SpentTxOut) error {
+	// Attribute the time spent to the validation stages.  Everything other
+	// than loading the utxos and running the scripts counts as context
+	// checks.
+	var utxoTime, scriptTime time.Duration
+	defer func(start time.Time) {
+		contextTime := time.Since(start) - utxoTime - scriptTime
+		b.timings.add(&node.hash, node.height, stageContext, contextTime)
+		b.timings.add(&node.hash, node.height, stageUtxo, utxoTime)
+		b.timings.add(&node.hash, node.height, stageScripts, scriptTime)
+	}(time.Now())
+
 	// If the side chain blocks end up in the database, a call to
 	// CheckBlockSanity should be done here in case a previous version
 	// allowed a block that is no longer valid.  However, since the
@@ -1035,7 +1062,9 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 	//
 	// These utxo entries are needed for verification of things such as
 	// transaction inputs, counting pay-to-script-hashes, and scripts.
+	utxoStart := time.Now()
 	err := view.fetchInputUtxos(b.db, block)
+	utxoTime = time.Since(utxoStart)
 	if err != nil {
 		return err
 	}
@@ -1223,8 +1252,10 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 	// expensive ECDSA signature check scripts.  Doing this last helps
 	// prevent CPU exhaustion attacks.
 	if runScripts {
+		scriptStart := time.Now()
 		err := checkBlockScripts(block, view, scriptFlags, b.sigCache,
 			b.hashCache)
+		scriptTime = time.Since(scriptStart)
 		if err != nil {
 			return err
 		}
@@ -1240,9 +1271,14 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 // CheckConnectBlockTemplate fully validates that connecting the passed block to
 // the main chain does not violate any consensus rules, aside from the proof of
 // work requirement. The block must connect to the current tip of the main chain.
+// ErrReindexing is returned while the chain state is being reindexed.
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CheckConnectBlockTemplate(block *btcutil.Block) error {
+	if b.isReindexing() {
+		return ErrReindexing
+	}
+
 	b.chainLock.Lock()
 	defer b.chainLock.Unlock()
 
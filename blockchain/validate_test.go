@@ -66,19 +66,6 @@ func TestSequenceLocksActive(t *testing.T) {
 // TestCheckConnectBlockTemplate tests the CheckConnectBlockTemplate function to
 // ensure it fails.
 func TestCheckConnectBlockTemplate(t *testing.T) {
-	// Create a new database and chain instance to run tests against.
-	chain, teardownFunc, err := chainSetup("checkconnectblocktemplate",
-		&chaincfg.MainNetParams)
-	if err != nil {
-		t.Errorf("Failed to setup chain instance: %v", err)
-		return
-	}
-	defer teardownFunc()
-
-	// Since we're not dealing with the real block chain, set the coinbase
-	// maturity to 1.
-	chain.TstSetCoinbaseMaturity(1)
-
 	// Load up blocks such that there is a side chain.
 	// (genesis block) -> 1 -> 2 -> 3 -> 4
 	//                          \-> 3a
@@ -96,8 +83,21 @@ func TestCheckConnectBlockTemplate(t *testing.T) {
 		blocks = append(blocks, blockTmp...)
 	}
 
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("checkconnectblocktemplate",
+		bitcoinGenesisParams(blocks[0]))
+	if err != nil {
+		t.Errorf("Failed to setup chain instance: %v", err)
+		return
+	}
+	defer teardownFunc()
+
+	// Since we're not dealing with the real block chain, set the coinbase
+	// maturity to 1.
+	chain.TstSetCoinbaseMaturity(1)
+
 	for i := 1; i <= 3; i++ {
-		isMainChain, _, err := chain.ProcessBlock(blocks[i], BFNone)
+		isMainChain, _, err := chain.ProcessBlock(blocks[i], BFNoPoWCheck)
 		if err != nil {
 			t.Fatalf("CheckConnectBlockTemplate: Received unexpected error "+
 				"processing block %d: %v", i, err)
@@ -154,7 +154,10 @@ func TestCheckBlockSanity(t *testing.T) {
 	powLimit := chaincfg.MainNetParams.PowLimit
 	block := btcutil.NewBlock(&Block100000)
 	timeSource := NewMedianTime()
-	err := CheckBlockSanity(block, powLimit, timeSource)
+
+	// The test block is a Bitcoin block, so it doesn't satisfy the X11
+	// proof of work.
+	err := checkBlockSanity(block, powLimit, timeSource, BFNoPoWCheck)
 	if err != nil {
 		t.Errorf("CheckBlockSanity: %v", err)
 	}
@@ -163,7 +166,7 @@ func TestCheckBlockSanity(t *testing.T) {
 	// second fails.
 	timestamp := block.MsgBlock().Header.Timestamp
 	block.MsgBlock().Header.Timestamp = timestamp.Add(time.Nanosecond)
-	err = CheckBlockSanity(block, powLimit, timeSource)
+	err = checkBlockSanity(block, powLimit, timeSource, BFNoPoWCheck)
 	if err == nil {
 		t.Errorf("CheckBlockSanity: error is nil when it shouldn't be")
 	}
@@ -485,3 +488,81 @@ var Block100000 = wire.MsgBlock{
 		},
 	},
 }
+
+// TestDevNetGenesis ensures the block at height one of a devnet must be the
+// devnet genesis block and that devnet parameters must use the magic derived
+// from the devnet name.
+func TestDevNetGenesis(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.DevNetName = "test"
+	params.Net = chaincfg.DevNetMagic(params.DevNetName)
+
+	genesisNode := newBlockNode(&params.GenesisBlock.Header, nil)
+	devNetGenesis := wire.BlockHeader{
+		Version:   4,
+		PrevBlock: *params.GenesisHash,
+		Timestamp: params.GenesisBlock.Header.Timestamp.Add(time.Second),
+		Bits:      params.PowLimitBits,
+	}
+	devNetGenesisHash := devNetGenesis.PowHash()
+	params.DevNetGenesisHash = &devNetGenesisHash
+	chain := newFakeChain(&params)
+
+	// The devnet genesis block and blocks built on top of it are accepted.
+	err := chain.checkBlockHeaderContext(&devNetGenesis, genesisNode,
+		BFFastAdd)
+	if err != nil {
+		t.Fatalf("checkBlockHeaderContext: unexpected error for devnet "+
+			"genesis block: %v", err)
+	}
+	devNetNode := newBlockNode(&devNetGenesis, genesisNode)
+	header := devNetGenesis
+	header.PrevBlock = devNetGenesis.BlockHash()
+	err = chain.checkBlockHeaderContext(&header, devNetNode, BFFastAdd)
+	if err != nil {
+		t.Fatalf("checkBlockHeaderContext: unexpected error for block "+
+			"at height 2: %v", err)
+	}
+
+	// The genesis block of another devnet is rejected.
+	otherGenesis := devNetGenesis
+	otherGenesis.Nonce++
+	err = chain.checkBlockHeaderContext(&otherGenesis, genesisNode,
+		BFFastAdd)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrBadDevNetGenesis {
+		t.Fatalf("checkBlockHeaderContext: got error %v, want %v", err,
+			ErrBadDevNetGenesis)
+	}
+
+	// Devnet parameters with a magic that is not derived from the devnet
+	// name are rejected.
+	params.Net = chaincfg.DevNetMagic("other")
+	_, teardownFunc, err := chainSetup("devnetmagic", &params)
+	if err == nil {
+		teardownFunc()
+		t.Fatal("chainSetup: expected error for devnet magic mismatch")
+	}
+}
+
+// TestDevNetGenesisConnect ensures the devnet genesis block of devnet
+// parameters created with NewDevNetParams is connected when the chain is
+// created.
+func TestDevNetGenesisConnect(t *testing.T) {
+	params, err := chaincfg.NewDevNetParams("connect")
+	if err != nil {
+		t.Fatalf("NewDevNetParams: unexpected error: %v", err)
+	}
+	chain, teardownFunc, err := chainSetup("devnetconnect", &params)
+	if err != nil {
+		t.Fatalf("chainSetup: unexpected error: %v", err)
+	}
+	defer teardownFunc()
+
+	best := chain.BestSnapshot()
+	devNetGenesisHash := params.DevNetGenesisBlock.BlockHash()
+	if best.Height != 1 || best.Hash != devNetGenesisHash {
+		t.Fatalf("best block: got %v at height %d, want devnet genesis "+
+			"block %v at height 1", best.Hash, best.Height,
+			devNetGenesisHash)
+	}
+}
@@ -256,7 +256,8 @@ func (c deploymentChecker) Condition(node *blockNode) (bool, error) {
 // exported version uses the current best chain as the previous block node
 // while this function accepts any block node.
 //
-// This function MUST be called with the chain state lock held (for writes).
+// This function MUST be called with either the chain state lock or the index
+// lock held (for reads).
 func (b *BlockChain) calcNextBlockVersion(prevNode *blockNode) (int32, error) {
 	// Set the appropriate bits for each actively defined rule deployment
 	// that is either in the process of being voted on, or locked in for the
@@ -283,9 +284,9 @@ func (b *BlockChain) calcNextBlockVersion(prevNode *blockNode) (int32, error) {
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CalcNextBlockVersion() (int32, error) {
-	b.chainLock.Lock()
+	b.indexLock.RLock()
 	version, err := b.calcNextBlockVersion(b.bestChain.Tip())
-	b.chainLock.Unlock()
+	b.indexLock.RUnlock()
 	return version, err
 }
 
@@ -18,6 +18,7 @@ import (
 	"github.com/dashpay/dashd-go/blockchain/indexers"
 	"github.com/dashpay/dashd-go/database"
 	"github.com/dashpay/dashd-go/limits"
+	"github.com/dashpay/dashd-go/mnlist"
 )
 
 const (
@@ -25,6 +26,10 @@ const (
 	// database type is appended to this value to form the full block
 	// database name.
 	blockDbNamePrefix = "blocks"
+
+	// mnListDbName is the name of the database which houses the
+	// masternode list.
+	mnListDbName = "mnlist"
 )
 
 var (
@@ -143,10 +148,30 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropSpecialTxIndex {
+		if err := indexers.DropSpecialTxIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Load the masternode list, which is committed along with the chain
+	// state.
+	mnList, err := loadMNList()
+	if err != nil {
+		btcdLog.Errorf("%v", err)
+		return err
+	}
+	defer func() {
+		btcdLog.Infof("Gracefully shutting down the masternode list...")
+		mnList.Close()
+	}()
 
 	// Create server and start it.
 	server, err := newServer(cfg.Listeners, cfg.AgentBlacklist,
-		cfg.AgentWhitelist, db, activeNetParams.Params, interrupt)
+		cfg.AgentWhitelist, db, mnList, activeNetParams.Params, interrupt)
 	if err != nil {
 		// TODO: this logging could do with some beautifying.
 		btcdLog.Errorf("Unable to start server on %v: %v",
@@ -296,6 +321,24 @@ func loadBlockDB() (database.DB, error) {
 	return db, nil
 }
 
+// loadMNList loads (or creates when needed) the masternode list, which is kept
+// in memory along with the block database when running with the memory
+// database.
+func loadMNList() (*mnlist.State, error) {
+	if cfg.DbType == "memdb" {
+		btcdLog.Infof("Creating masternode list in memory.")
+		return mnlist.Open("", activeNetParams.Params)
+	}
+
+	// The regression test database is removed on each run, so the
+	// masternode list must be as well.
+	dbPath := filepath.Join(cfg.DataDir, mnListDbName)
+	removeRegressionDB(dbPath)
+
+	btcdLog.Infof("Loading masternode list from '%s'", dbPath)
+	return mnlist.Open(dbPath, activeNetParams.Params)
+}
+
 func main() {
 	// Block and transaction processing can cause bursty allocations.  This
 	// limits the garbage collector from excessively overallocating during
@@ -59,6 +59,39 @@ func NewDebugLevelCmd(levelSpec string) *DebugLevelCmd {
 	}
 }
 
+// DumpPeerStateCmd defines the dumppeerstate JSON-RPC command.  This command
+// is not a standard Bitcoin command.  It is an extension for btcd.
+type DumpPeerStateCmd struct{}
+
+// NewDumpPeerStateCmd returns a new DumpPeerStateCmd which can be used to issue
+// a dumppeerstate JSON-RPC command.  This command is not a standard Bitcoin
+// command.  It is an extension for btcd.
+func NewDumpPeerStateCmd() *DumpPeerStateCmd {
+	return &DumpPeerStateCmd{}
+}
+
+// GetProfileCmd defines the getprofile JSON-RPC command.  This command is not a
+// standard Bitcoin command.  It is an extension for btcd.
+type GetProfileCmd struct {
+	Profile string `jsonrpcusage:"\"cpu|goroutine|heap|allocs|threadcreate|block|mutex\""`
+	Seconds *int64 `jsonrpcdefault:"30"`
+	Debug   *int   `jsonrpcdefault:"0"`
+}
+
+// NewGetProfileCmd returns a new GetProfileCmd which can be used to issue a
+// getprofile JSON-RPC command.  This command is not a standard Bitcoin command.
+// It is an extension for btcd.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetProfileCmd(profile string, seconds *int64, debug *int) *GetProfileCmd {
+	return &GetProfileCmd{
+		Profile: profile,
+		Seconds: seconds,
+		Debug:   debug,
+	}
+}
+
 // GenerateToAddressCmd defines the generatetoaddress JSON-RPC command.
 type GenerateToAddressCmd struct {
 	NumBlocks int64
@@ -128,6 +161,14 @@ func NewGetHeadersCmd(blockLocators []string, hashStop string) *GetHeadersCmd {
 	}
 }
 
+// DiscoverCmd defines the rpc.discover JSON-RPC command which returns an
+// OpenRPC document describing the methods supported by the server.
+type DiscoverCmd struct{}
+
+// NewDiscoverCmd returns a new instance which can be used to issue a JSON-RPC
+// rpc.discover command.
+func NewDiscoverCmd() *DiscoverCmd { return new(DiscoverCmd) }
+
 // VersionCmd defines the version JSON-RPC command.
 //
 // NOTE: This is a btcsuite extension ported from
@@ -146,11 +187,14 @@ func init() {
 	flags := UsageFlag(0)
 
 	MustRegisterCmd("debuglevel", (*DebugLevelCmd)(nil), flags)
+	MustRegisterCmd("dumppeerstate", (*DumpPeerStateCmd)(nil), flags)
+	MustRegisterCmd("rpc.discover", (*DiscoverCmd)(nil), flags)
 	MustRegisterCmd("node", (*NodeCmd)(nil), flags)
 	MustRegisterCmd("generate", (*GenerateCmd)(nil), flags)
 	MustRegisterCmd("generatetoaddress", (*GenerateToAddressCmd)(nil), flags)
 	MustRegisterCmd("getbestblock", (*GetBestBlockCmd)(nil), flags)
 	MustRegisterCmd("getcurrentnet", (*GetCurrentNetCmd)(nil), flags)
 	MustRegisterCmd("getheaders", (*GetHeadersCmd)(nil), flags)
+	MustRegisterCmd("getprofile", (*GetProfileCmd)(nil), flags)
 	MustRegisterCmd("version", (*VersionCmd)(nil), flags)
 }
@@ -43,6 +43,48 @@ func TestBtcdExtCmds(t *testing.T) {
 				LevelSpec: "trace",
 			},
 		},
+		{
+			name: "dumppeerstate",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("dumppeerstate")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDumpPeerStateCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"dumppeerstate","params":[],"id":1}`,
+			unmarshalled: &btcjson.DumpPeerStateCmd{},
+		},
+		{
+			name: "getprofile",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getprofile", "heap")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetProfileCmd("heap", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getprofile","params":["heap"],"id":1}`,
+			unmarshalled: &btcjson.GetProfileCmd{
+				Profile: "heap",
+				Seconds: btcjson.Int64(30),
+				Debug:   btcjson.Int(0),
+			},
+		},
+		{
+			name: "getprofile optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getprofile", "goroutine", 0, 2)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetProfileCmd("goroutine",
+					btcjson.Int64(0), btcjson.Int(2))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getprofile","params":["goroutine",0,2],"id":1}`,
+			unmarshalled: &btcjson.GetProfileCmd{
+				Profile: "goroutine",
+				Seconds: btcjson.Int64(0),
+				Debug:   btcjson.Int(2),
+			},
+		},
 		{
 			name: "node",
 			newCmd: func() (interface{}, error) {
@@ -194,6 +236,17 @@ func TestBtcdExtCmds(t *testing.T) {
 				HashStop: "000000000000000000ba33b33e1fad70b69e234fc24414dd47113bff38f523f7",
 			},
 		},
+		{
+			name: "rpc.discover",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("rpc.discover")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDiscoverCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"rpc.discover","params":[],"id":1}`,
+			unmarshalled: &btcjson.DiscoverCmd{},
+		},
 		{
 			name: "version",
 			newCmd: func() (interface{}, error) {
@@ -18,3 +18,35 @@ type VersionResult struct {
 	Prerelease    string `json:"prerelease"`
 	BuildMetadata string `json:"buildmetadata"`
 }
+
+// GetProfileResult models the data returned from the getprofile command.
+type GetProfileResult struct {
+	Profile  string `json:"profile"`
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// PeerStateResult models the data returned for each peer from the
+// dumppeerstate command.
+type PeerStateResult struct {
+	ID                 int32  `json:"id"`
+	Addr               string `json:"addr"`
+	Inbound            bool   `json:"inbound"`
+	Persistent         bool   `json:"persistent"`
+	Whitelisted        bool   `json:"whitelisted"`
+	SyncNode           bool   `json:"syncnode"`
+	Connected          bool   `json:"connected"`
+	Handshake          string `json:"handshake"`
+	ProtocolVersion    uint32 `json:"protocolversion"`
+	Services           string `json:"services"`
+	RelayTxes          bool   `json:"relaytxes"`
+	WantsHeaders       bool   `json:"wantsheaders"`
+	BlocksOnly         bool   `json:"blocksonly"`
+	LastBlock          int32  `json:"lastblock"`
+	LastAnnouncedBlock string `json:"lastannouncedblock,omitempty"`
+	LastPingNonce      uint64 `json:"lastpingnonce,omitempty"`
+	BanScore           int32  `json:"banscore"`
+	FeeFilter          int64  `json:"feefilter"`
+	BytesSent          uint64 `json:"bytessent"`
+	BytesRecv          uint64 `json:"bytesrecv"`
+}
@@ -122,6 +122,19 @@ func NewDeriveAddressesCmd(descriptor string, descriptorRange *DescriptorRange)
 	}
 }
 
+// DumpTxOutSetCmd defines the dumptxoutset JSON-RPC command.
+type DumpTxOutSetCmd struct {
+	Path string
+}
+
+// NewDumpTxOutSetCmd returns a new instance which can be used to issue a
+// dumptxoutset JSON-RPC command.
+func NewDumpTxOutSetCmd(path string) *DumpTxOutSetCmd {
+	return &DumpTxOutSetCmd{
+		Path: path,
+	}
+}
+
 // ChangeType defines the different output types to use for the change address
 // of a transaction built by the node.
 type ChangeType string
@@ -665,6 +678,39 @@ func NewGetRawTransactionCmd(txHash string, verbose *int) *GetRawTransactionCmd
 	}
 }
 
+// GetSpecialTxesCmd defines the getspecialtxes JSON-RPC command.
+//
+// The first five fields match Dash Core, where the command only returns the
+// special transactions of a single block.  NumBlocks extends the query to the
+// passed number of blocks starting at the given block, which requires the
+// special transaction index.
+type GetSpecialTxesCmd struct {
+	BlockHash string
+	Type      *int `jsonrpcdefault:"-1"`
+	Count     *int `jsonrpcdefault:"10"`
+	Skip      *int `jsonrpcdefault:"0"`
+	Verbosity *int `jsonrpcdefault:"0"`
+	NumBlocks *int `jsonrpcdefault:"1"`
+}
+
+// NewGetSpecialTxesCmd returns a new instance which can be used to issue a
+// getspecialtxes JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSpecialTxesCmd(blockHash string, txType, count, skip, verbosity,
+	numBlocks *int) *GetSpecialTxesCmd {
+
+	return &GetSpecialTxesCmd{
+		BlockHash: blockHash,
+		Type:      txType,
+		Count:     count,
+		Skip:      skip,
+		Verbosity: verbosity,
+		NumBlocks: numBlocks,
+	}
+}
+
 // GetTxOutCmd defines the gettxout JSON-RPC command.
 type GetTxOutCmd struct {
 	Txid           string
@@ -1051,6 +1097,7 @@ func init() {
 	MustRegisterCmd("decoderawtransaction", (*DecodeRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("decodescript", (*DecodeScriptCmd)(nil), flags)
 	MustRegisterCmd("deriveaddresses", (*DeriveAddressesCmd)(nil), flags)
+	MustRegisterCmd("dumptxoutset", (*DumpTxOutSetCmd)(nil), flags)
 	MustRegisterCmd("fundrawtransaction", (*FundRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("getaddednodeinfo", (*GetAddedNodeInfoCmd)(nil), flags)
 	MustRegisterCmd("getbestblockhash", (*GetBestBlockHashCmd)(nil), flags)
@@ -1082,6 +1129,7 @@ func init() {
 	MustRegisterCmd("getpeerinfo", (*GetPeerInfoCmd)(nil), flags)
 	MustRegisterCmd("getrawmempool", (*GetRawMempoolCmd)(nil), flags)
 	MustRegisterCmd("getrawtransaction", (*GetRawTransactionCmd)(nil), flags)
+	MustRegisterCmd("getspecialtxes", (*GetSpecialTxesCmd)(nil), flags)
 	MustRegisterCmd("gettxout", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCmd("gettxoutproof", (*GetTxOutProofCmd)(nil), flags)
 	MustRegisterCmd("gettxoutsetinfo", (*GetTxOutSetInfoCmd)(nil), flags)
@@ -890,6 +890,46 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: btcjson.Int(1),
 			},
 		},
+		{
+			name: "getspecialtxes",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getspecialtxes", "123")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSpecialTxesCmd("123", nil, nil,
+					nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getspecialtxes","params":["123"],"id":1}`,
+			unmarshalled: &btcjson.GetSpecialTxesCmd{
+				BlockHash: "123",
+				Type:      btcjson.Int(-1),
+				Count:     btcjson.Int(10),
+				Skip:      btcjson.Int(0),
+				Verbosity: btcjson.Int(0),
+				NumBlocks: btcjson.Int(1),
+			},
+		},
+		{
+			name: "getspecialtxes optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getspecialtxes", "123", 1, 5,
+					2, 2, 100)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSpecialTxesCmd("123",
+					btcjson.Int(1), btcjson.Int(5), btcjson.Int(2),
+					btcjson.Int(2), btcjson.Int(100))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getspecialtxes","params":["123",1,5,2,2,100],"id":1}`,
+			unmarshalled: &btcjson.GetSpecialTxesCmd{
+				BlockHash: "123",
+				Type:      btcjson.Int(1),
+				Count:     btcjson.Int(5),
+				Skip:      btcjson.Int(2),
+				Verbosity: btcjson.Int(2),
+				NumBlocks: btcjson.Int(100),
+			},
+		},
 		{
 			name: "gettxout",
 			newCmd: func() (interface{}, error) {
@@ -950,6 +990,19 @@ func TestChainSvrCmds(t *testing.T) {
 				BlockHash: btcjson.String("000000000000034a7dedef4a161fa058a2d67a173a90155f3a2fe6fc132e0ebf"),
 			},
 		},
+		{
+			name: "dumptxoutset",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("dumptxoutset", "utxo.dat")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDumpTxOutSetCmd("utxo.dat")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"dumptxoutset","params":["utxo.dat"],"id":1}`,
+			unmarshalled: &btcjson.DumpTxOutSetCmd{
+				Path: "utxo.dat",
+			},
+		},
 		{
 			name: "gettxoutsetinfo",
 			newCmd: func() (interface{}, error) {
@@ -8,6 +8,9 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 
@@ -182,6 +185,18 @@ type Bip9SoftForkDescription struct {
 	Timeout             int64  `json:"timeout"`
 	Since               int32  `json:"since"`
 	MinActivationHeight int32  `json:"min_activation_height"`
+
+	Statistics *Bip9SoftForkStatistics `json:"statistics,omitempty"`
+}
+
+// Bip9SoftForkStatistics describes the signalling progress of a BIP0009
+// version bits soft-fork within the current confirmation window.
+type Bip9SoftForkStatistics struct {
+	Period    uint32 `json:"period"`
+	Threshold uint32 `json:"threshold"`
+	Elapsed   uint32 `json:"elapsed"`
+	Count     uint32 `json:"count"`
+	Possible  bool   `json:"possible"`
 }
 
 // StartTime returns the starting time of the softfork as a Unix epoch.
@@ -387,27 +402,28 @@ type GetNodeAddressesResult struct {
 
 // GetPeerInfoResult models the data returned from the getpeerinfo command.
 type GetPeerInfoResult struct {
-	ID             int32   `json:"id"`
-	Addr           string  `json:"addr"`
-	AddrLocal      string  `json:"addrlocal,omitempty"`
-	Services       string  `json:"services"`
-	RelayTxes      bool    `json:"relaytxes"`
-	LastSend       int64   `json:"lastsend"`
-	LastRecv       int64   `json:"lastrecv"`
-	BytesSent      uint64  `json:"bytessent"`
-	BytesRecv      uint64  `json:"bytesrecv"`
-	ConnTime       int64   `json:"conntime"`
-	TimeOffset     int64   `json:"timeoffset"`
-	PingTime       float64 `json:"pingtime"`
-	PingWait       float64 `json:"pingwait,omitempty"`
-	Version        uint32  `json:"version"`
-	SubVer         string  `json:"subver"`
-	Inbound        bool    `json:"inbound"`
-	StartingHeight int32   `json:"startingheight"`
-	CurrentHeight  int32   `json:"currentheight,omitempty"`
-	BanScore       int32   `json:"banscore"`
-	FeeFilter      int64   `json:"feefilter"`
-	SyncNode       bool    `json:"syncnode"`
+	ID             int32    `json:"id"`
+	Addr           string   `json:"addr"`
+	AddrLocal      string   `json:"addrlocal,omitempty"`
+	Services       string   `json:"services"`
+	RelayTxes      bool     `json:"relaytxes"`
+	LastSend       int64    `json:"lastsend"`
+	LastRecv       int64    `json:"lastrecv"`
+	BytesSent      uint64   `json:"bytessent"`
+	BytesRecv      uint64   `json:"bytesrecv"`
+	ConnTime       int64    `json:"conntime"`
+	TimeOffset     int64    `json:"timeoffset"`
+	PingTime       float64  `json:"pingtime"`
+	PingWait       float64  `json:"pingwait,omitempty"`
+	Version        uint32   `json:"version"`
+	SubVer         string   `json:"subver"`
+	Inbound        bool     `json:"inbound"`
+	StartingHeight int32    `json:"startingheight"`
+	CurrentHeight  int32    `json:"currentheight,omitempty"`
+	BanScore       int32    `json:"banscore"`
+	FeeFilter      int64    `json:"feefilter"`
+	SyncNode       bool     `json:"syncnode"`
+	Permissions    []string `json:"permissions,omitempty"`
 }
 
 // GetRawMempoolVerboseResult models the data returned from the getrawmempool
@@ -444,6 +460,16 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// DumpTxOutSetResult models the data from the dumptxoutset command.
+type DumpTxOutSetResult struct {
+	CoinsWritten int64  `json:"coins_written"`
+	BaseHash     string `json:"base_hash"`
+	BaseHeight   int32  `json:"base_height"`
+	Path         string `json:"path"`
+	TxOutSetHash string `json:"txoutset_hash"`
+	NChainTx     int64  `json:"nchaintx"`
+}
+
 // GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
 type GetTxOutSetInfoResult struct {
 	Height         int64          `json:"height"`
@@ -464,9 +490,9 @@ func (g *GetTxOutSetInfoResult) UnmarshalJSON(data []byte) error {
 	// Step 2: Create an anonymous struct with raw replacements for the special
 	// fields.
 	aux := &struct {
-		BestBlock      string  `json:"bestblock"`
-		HashSerialized string  `json:"hash_serialized_2"`
-		TotalAmount    float64 `json:"total_amount"`
+		BestBlock      string     `json:"bestblock"`
+		HashSerialized string     `json:"hash_serialized_2"`
+		TotalAmount    JSONAmount `json:"total_amount"`
 		*Alias
 	}{
 		Alias: (*Alias)(g),
@@ -492,12 +518,7 @@ func (g *GetTxOutSetInfoResult) UnmarshalJSON(data []byte) error {
 
 	g.HashSerialized = *serializedHash
 
-	amount, err := btcutil.NewAmount(aux.TotalAmount)
-	if err != nil {
-		return err
-	}
-
-	g.TotalAmount = amount
+	g.TotalAmount = btcutil.Amount(aux.TotalAmount)
 
 	return nil
 }
@@ -772,20 +793,37 @@ type ValidateAddressChainResult struct {
 	WitnessProgram *string `json:"witness_program,omitempty"`
 }
 
-// EstimateSmartFeeResult models the data returned buy the chain server
-// estimatesmartfee command
+// EstimateSmartFeeResult models the data returned by the chain server
+// estimatesmartfee command.  FeeRate is expressed in DASH per kilobyte and is
+// omitted when the server has insufficient data for an estimate, in which case
+// Errors describes why.  Blocks is the number of blocks the estimate is valid
+// for, which may differ from the requested confirmation target.
 type EstimateSmartFeeResult struct {
 	FeeRate *float64 `json:"feerate,omitempty"`
 	Errors  []string `json:"errors,omitempty"`
 	Blocks  int64    `json:"blocks"`
 }
 
+// FeeRatePerKB returns the estimated fee rate as an amount per kilobyte.  An
+// error including the errors reported by the server is returned when no
+// estimate is available.
+func (r *EstimateSmartFeeResult) FeeRatePerKB() (btcutil.Amount, error) {
+	if r.FeeRate == nil {
+		if len(r.Errors) == 0 {
+			return 0, errors.New("no fee rate estimate available")
+		}
+		return 0, fmt.Errorf("no fee rate estimate available: %s",
+			strings.Join(r.Errors, "; "))
+	}
+	return btcutil.NewAmount(*r.FeeRate)
+}
+
 var _ json.Unmarshaler = &FundRawTransactionResult{}
 
 type rawFundRawTransactionResult struct {
-	Transaction    string  `json:"hex"`
-	Fee            float64 `json:"fee"`
-	ChangePosition int     `json:"changepos"`
+	Transaction    string     `json:"hex"`
+	Fee            JSONAmount `json:"fee"`
+	ChangePosition int        `json:"changepos"`
 }
 
 // FundRawTransactionResult is the result of the fundrawtransaction JSON-RPC call
@@ -816,13 +854,8 @@ func (f *FundRawTransactionResult) UnmarshalJSON(data []byte) error {
 		}
 	}
 
-	fee, err := btcutil.NewAmount(rawRes.Fee)
-	if err != nil {
-		return err
-	}
-
 	f.Transaction = &msgTx
-	f.Fee = fee
+	f.Fee = btcutil.Amount(rawRes.Fee)
 	f.ChangePosition = rawRes.ChangePosition
 	return nil
 }
@@ -7,6 +7,7 @@ package btcjson_test
 
 import (
 	"encoding/json"
+	"math"
 	"reflect"
 	"testing"
 
@@ -137,6 +138,34 @@ func TestGetTxOutSetInfoResult(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name:   "GetTxOutSetInfoResult - exact large amount",
+			result: `{"height":123,"bestblock":"000000000000005f94116250e2407310463c0a7cf950f1af9ebe935b1c0687ab","transactions":1,"txouts":1,"bogosize":1,"hash_serialized_2":"9a0a561203ff052182993bc5d0cb2c620880bfafdbd80331f65fd9546c3e5c3e","disk_size":1,"total_amount":92233720368.54775807}`,
+			want: btcjson.GetTxOutSetInfoResult{
+				Height: 123,
+				BestBlock: func() chainhash.Hash {
+					h, err := chainhash.NewHashFromStr("000000000000005f94116250e2407310463c0a7cf950f1af9ebe935b1c0687ab")
+					if err != nil {
+						panic(err)
+					}
+
+					return *h
+				}(),
+				Transactions: 1,
+				TxOuts:       1,
+				BogoSize:     1,
+				HashSerialized: func() chainhash.Hash {
+					h, err := chainhash.NewHashFromStr("9a0a561203ff052182993bc5d0cb2c620880bfafdbd80331f65fd9546c3e5c3e")
+					if err != nil {
+						panic(err)
+					}
+
+					return *h
+				}(),
+				DiskSize:    1,
+				TotalAmount: math.MaxInt64,
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -200,3 +229,55 @@ func TestChainSvrMiningInfoResults(t *testing.T) {
 		}
 	}
 }
+
+// TestEstimateSmartFeeResult ensures estimatesmartfee results unmarshal as
+// expected and that the fee rate is converted to an amount per kilobyte.
+func TestEstimateSmartFeeResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		result  string
+		want    btcutil.Amount
+		blocks  int64
+		wantErr bool
+	}{
+		{
+			name:   "estimate available",
+			result: `{"feerate":0.00001234,"blocks":2}`,
+			want:   1234,
+			blocks: 2,
+		},
+		{
+			name:    "insufficient data",
+			result:  `{"errors":["Insufficient data or no feerate found"],"blocks":0}`,
+			wantErr: true,
+		},
+		{
+			name:    "no estimate without errors",
+			result:  `{"blocks":0}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var res btcjson.EstimateSmartFeeResult
+		if err := json.Unmarshal([]byte(test.result), &res); err != nil {
+			t.Errorf("%s: unmarshal failed: %v", test.name, err)
+			continue
+		}
+		if res.Blocks != test.blocks {
+			t.Errorf("%s: wrong blocks - got %d, want %d", test.name,
+				res.Blocks, test.blocks)
+		}
+		got, err := res.FeeRatePerKB()
+		if test.wantErr != (err != nil) {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: wrong fee rate - got %v, want %v", test.name,
+				got, test.want)
+		}
+	}
+}
@@ -73,10 +73,60 @@ func NewMasternodeListCmd(mode, filter string) *MasternodelistCmd {
 	}
 }
 
+// GObjectSubCmd defines the sub command used in the gobject JSON-RPC command.
+type GObjectSubCmd string
+
+// GObject subcommands
+const (
+	GObjectList GObjectSubCmd = "list"
+	GObjectDiff GObjectSubCmd = "diff"
+)
+
+// GObjectCmd defines the gobject JSON-RPC command.
+type GObjectCmd struct {
+	SubCmd GObjectSubCmd `jsonrpcusage:"\"list|diff\""`
+	Signal *string       `json:",omitempty" jsonrpcusage:"\"valid|funding|delete|endorsed|all\""`
+	Type   *string       `json:",omitempty" jsonrpcusage:"\"proposals|triggers|all\""`
+}
+
+// NewGObjectListCmd returns a new instance which can be used to issue a
+// gobject list JSON-RPC command.  Empty signal and objType use the defaults
+// of the server, which are "valid" and "all".
+func NewGObjectListCmd(signal, objType string) *GObjectCmd {
+	return newGObjectCmd(GObjectList, signal, objType)
+}
+
+// NewGObjectDiffCmd returns a new instance which can be used to issue a
+// gobject diff JSON-RPC command, which lists the governance objects changed
+// since the previous gobject diff command.  Empty signal and objType use the
+// defaults of the server, which are "valid" and "all".
+func NewGObjectDiffCmd(signal, objType string) *GObjectCmd {
+	return newGObjectCmd(GObjectDiff, signal, objType)
+}
+
+func newGObjectCmd(sub GObjectSubCmd, signal, objType string) *GObjectCmd {
+	r := &GObjectCmd{
+		SubCmd: sub,
+	}
+	if signal == "" && objType == "" {
+		return r
+	}
+	if signal == "" {
+		signal = "valid"
+	}
+	r.Signal = &signal
+	if objType == "" {
+		return r
+	}
+	r.Type = &objType
+	return r
+}
+
 func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
 
 	MustRegisterCmd("masternode", (*MasternodeCmd)(nil), flags)
 	MustRegisterCmd("masternodelist", (*MasternodelistCmd)(nil), flags)
+	MustRegisterCmd("gobject", (*GObjectCmd)(nil), flags)
 }
@@ -47,6 +47,32 @@ func TestDashMasternodeCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"masternode","params":["count"],"id":1}`,
 			unmarshalled: &btcjson.MasternodeCmd{SubCmd: btcjson.MasternodeCount},
 		},
+		{
+			name: "gobject list",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("gobject", "list")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGObjectListCmd("", "")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"gobject","params":["list"],"id":1}`,
+			unmarshalled: &btcjson.GObjectCmd{SubCmd: btcjson.GObjectList},
+		},
+		{
+			name: "gobject diff",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("gobject", "diff", "valid", "proposals")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGObjectDiffCmd("", "proposals")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gobject","params":["diff","valid","proposals"],"id":1}`,
+			unmarshalled: &btcjson.GObjectCmd{
+				SubCmd: btcjson.GObjectDiff,
+				Signal: btcjson.String("valid"),
+				Type:   btcjson.String("proposals"),
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -55,3 +55,25 @@ type MasternodeResult struct {
 	Outpoint  string `json:"outpoint"`
 	Payee     string `json:"payee"`
 }
+
+// GObjectResult models a governance object returned by the gobject list and
+// gobject diff commands, which return the objects keyed by their hash.
+type GObjectResult struct {
+	DataHex           string `json:"DataHex"`
+	DataString        string `json:"DataString"`
+	Hash              string `json:"Hash"`
+	CollateralHash    string `json:"CollateralHash"`
+	ObjectType        int    `json:"ObjectType"`
+	CreationTime      int64  `json:"CreationTime"`
+	SigningMasternode string `json:"SigningMasternode,omitempty"`
+	AbsoluteYesCount  int    `json:"AbsoluteYesCount"`
+	YesCount          int    `json:"YesCount"`
+	NoCount           int    `json:"NoCount"`
+	AbstainCount      int    `json:"AbstainCount"`
+	BlockchainValid   bool   `json:"fBlockchainValidity"`
+	IsValidReason     string `json:"IsValidReason"`
+	CachedValid       bool   `json:"fCachedValid"`
+	CachedFunding     bool   `json:"fCachedFunding"`
+	CachedDelete      bool   `json:"fCachedDelete"`
+	CachedEndorsed    bool   `json:"fCachedEndorsed"`
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dashpay/dashd-go/btcutil"
+)
+
+// JSONAmount is an amount of duffs which is encoded in JSON as a number of
+// dash, which is how the RPC server encodes amounts.  Unlike decoding the
+// amount as a float64, it is decoded from the text of the JSON number, so no
+// precision is lost for any amount.
+type JSONAmount btcutil.Amount
+
+// Ensure JSONAmount implements the json.Marshaler and json.Unmarshaler
+// interfaces.
+var _ json.Marshaler = JSONAmount(0)
+var _ json.Unmarshaler = (*JSONAmount)(nil)
+
+// MarshalJSON encodes the amount as a JSON number of dash with all the digits
+// required to represent it exactly.
+//
+// This is part of the json.Marshaler interface.
+func (a JSONAmount) MarshalJSON() ([]byte, error) {
+	// The absolute value is computed as an unsigned integer so the most
+	// negative amount doesn't overflow.
+	abs := uint64(a)
+	sign := ""
+	if a < 0 {
+		abs = -abs
+		sign = "-"
+	}
+
+	const duffsPerDash = uint64(btcutil.SatoshiPerBitcoin)
+	s := sign + strconv.FormatUint(abs/duffsPerDash, 10)
+	if frac := abs % duffsPerDash; frac != 0 {
+		fracStr := strconv.FormatUint(frac+duffsPerDash, 10)[1:]
+		s += "." + strings.TrimRight(fracStr, "0")
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON decodes a JSON number of dash, or a string containing one,
+// into the amount.  An error is returned when the number is more precise than
+// a duff or does not fit in an amount.  The amount is left unchanged for JSON
+// null.
+//
+// This is part of the json.Unmarshaler interface.
+func (a *JSONAmount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	amount, err := parseJSONAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = JSONAmount(amount)
+	return nil
+}
+
+// parseJSONAmount parses the passed decimal number of dash, such as "1.5" or
+// "1e-8", into an amount without converting it to a floating point value
+// first.
+func parseJSONAmount(s string) (btcutil.Amount, error) {
+	invalid := fmt.Errorf("invalid amount %q", s)
+
+	// Split the number into its sign, digits and exponent.
+	num := s
+	negative := strings.HasPrefix(num, "-")
+	if negative {
+		num = num[1:]
+	}
+	exp := 0
+	if i := strings.IndexAny(num, "eE"); i >= 0 {
+		e, err := strconv.Atoi(num[i+1:])
+		if err != nil {
+			return 0, invalid
+		}
+		exp = e
+		num = num[:i]
+	}
+	intPart, fracPart := num, ""
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		intPart, fracPart = num[:i], num[i+1:]
+		if fracPart == "" {
+			return 0, invalid
+		}
+	}
+	if intPart == "" {
+		return 0, invalid
+	}
+	digits := intPart + fracPart
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, invalid
+		}
+	}
+
+	// The digits are the amount in units of 10^-(len(fracPart) - exp)
+	// dash, so they must be shifted to units of duffs.  Digits shifted
+	// out must be zeros.
+	shift := 8 - len(fracPart) + exp
+	digits = strings.TrimLeft(digits, "0")
+	if shift < 0 {
+		cut := len(digits) + shift
+		if cut < 0 {
+			cut = 0
+		}
+		if strings.TrimRight(digits[cut:], "0") != "" {
+			return 0, fmt.Errorf("amount %q is more precise than a "+
+				"duff", s)
+		}
+		digits = digits[:cut]
+		shift = 0
+	}
+	if digits == "" {
+		return 0, nil
+	}
+	if len(digits)+shift > 19 {
+		return 0, btcutil.ErrAmountOverflow
+	}
+	digits += strings.Repeat("0", shift)
+
+	if negative {
+		digits = "-" + digits
+	}
+	duffs, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, btcutil.ErrAmountOverflow
+	}
+	return btcutil.Amount(duffs), nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcjson"
+	"github.com/dashpay/dashd-go/btcutil"
+)
+
+// TestJSONAmountUnmarshal ensures JSON numbers of dash are decoded into exact
+// amounts and that invalid, too precise and too large numbers are rejected.
+func TestJSONAmountUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		json  string
+		valid bool
+		want  btcutil.Amount
+	}{
+		{json: `0`, valid: true, want: 0},
+		{json: `1`, valid: true, want: 1e8},
+		{json: `-1.5`, valid: true, want: -1.5e8},
+		{json: `0.00000001`, valid: true, want: 1},
+		{json: `1e-8`, valid: true, want: 1},
+		{json: `1E-08`, valid: true, want: 1},
+		{json: `1.10000000`, valid: true, want: 1.1e8},
+		{json: `0.1e1`, valid: true, want: 1e8},
+		{json: `"1.5"`, valid: true, want: 1.5e8},
+		{json: `21000000`, valid: true, want: btcutil.MaxSatoshi},
+		{json: `92233720368.54775807`, valid: true, want: math.MaxInt64},
+		{json: `-92233720368.54775808`, valid: true, want: math.MinInt64},
+
+		// Precision is never lost, unlike with float64 amounts.
+		{json: `20999999.99999999`, valid: true, want: btcutil.MaxSatoshi - 1},
+		{json: `12345678.12345678`, valid: true, want: 1234567812345678},
+
+		{json: `0.000000001`, valid: false},
+		{json: `1e-9`, valid: false},
+		{json: `92233720368.54775808`, valid: false},
+		{json: `1e300`, valid: false},
+		{json: `""`, valid: false},
+		{json: `"-"`, valid: false},
+		{json: `".5"`, valid: false},
+		{json: `"1."`, valid: false},
+		{json: `"+1"`, valid: false},
+		{json: `"1e"`, valid: false},
+		{json: `"0x10"`, valid: false},
+		{json: `"NaN"`, valid: false},
+	}
+
+	for _, test := range tests {
+		var a btcjson.JSONAmount
+		err := json.Unmarshal([]byte(test.json), &a)
+		if (err == nil) != test.valid {
+			t.Errorf("Unmarshal(%s): unexpected error %v", test.json, err)
+			continue
+		}
+		if btcutil.Amount(a) != test.want {
+			t.Errorf("Unmarshal(%s): got %d, want %d", test.json, a,
+				test.want)
+		}
+	}
+
+	// Null leaves the amount unchanged.
+	a := btcjson.JSONAmount(5)
+	if err := json.Unmarshal([]byte(`null`), &a); err != nil || a != 5 {
+		t.Errorf("Unmarshal(null): got %d, %v, want 5", a, err)
+	}
+}
+
+// TestJSONAmountMarshal ensures amounts are encoded as JSON numbers of dash
+// which decode back into the same amounts.
+func TestJSONAmountMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount btcutil.Amount
+		json   string
+	}{
+		{amount: 0, json: "0"},
+		{amount: 1, json: "0.00000001"},
+		{amount: 1e8, json: "1"},
+		{amount: -1.5e8, json: "-1.5"},
+		{amount: btcutil.MaxSatoshi - 1, json: "20999999.99999999"},
+		{amount: math.MinInt64, json: "-92233720368.54775808"},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(btcjson.JSONAmount(test.amount))
+		if err != nil || string(b) != test.json {
+			t.Errorf("Marshal(%d): got %s, %v, want %s", test.amount,
+				b, err, test.json)
+			continue
+		}
+
+		var a btcjson.JSONAmount
+		err = json.Unmarshal(b, &a)
+		if err != nil || btcutil.Amount(a) != test.amount {
+			t.Errorf("Unmarshal(%s): got %d, %v, want %d", b, a, err,
+				test.amount)
+		}
+	}
+}
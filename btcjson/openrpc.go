@@ -0,0 +1,281 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OpenRPCVersion is the version of the OpenRPC specification the documents
+// generated by GenerateOpenRPC conform to.
+const OpenRPCVersion = "1.2.6"
+
+// JSONSchema models the subset of JSON Schema used to describe the parameters
+// and results of RPC methods in an OpenRPC document.
+type JSONSchema struct {
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a parameter or the result of an RPC
+// method in an OpenRPC document.
+type OpenRPCContentDescriptor struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+// OpenRPCMethod describes an RPC method in an OpenRPC document.
+type OpenRPCMethod struct {
+	Name           string                      `json:"name"`
+	Summary        string                      `json:"summary,omitempty"`
+	ParamStructure string                      `json:"paramStructure"`
+	Params         []*OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor   `json:"result"`
+}
+
+// OpenRPCInfo holds the metadata about the API described by an OpenRPC
+// document.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCDocument is a machine-readable description of an RPC API as defined
+// by the OpenRPC specification.
+type OpenRPCDocument struct {
+	OpenRPC string           `json:"openrpc"`
+	Info    OpenRPCInfo      `json:"info"`
+	Methods []*OpenRPCMethod `json:"methods"`
+}
+
+// reflectTypeToJSONSchema returns a JSON schema describing the JSON encoding of
+// the provided Go type.  Field descriptions are looked up with the same keys
+// used when generating help.  The seen map tracks the struct types currently
+// being described in order to break recursion.
+func reflectTypeToJSONSchema(xT descLookupFunc, rt reflect.Type, descKey string, seen map[reflect.Type]struct{}) *JSONSchema {
+	// Indirect pointer if needed.
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	kind := rt.Kind()
+	if isNumeric(kind) {
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			return &JSONSchema{Type: "number"}
+		}
+		return &JSONSchema{Type: "integer"}
+	}
+
+	switch kind {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+
+	case reflect.Array, reflect.Slice:
+		return &JSONSchema{
+			Type:  "array",
+			Items: reflectTypeToJSONSchema(xT, rt.Elem(), descKey, seen),
+		}
+
+	case reflect.Map:
+		return &JSONSchema{
+			Type:                 "object",
+			Description:          xT(descKey + "--desc"),
+			AdditionalProperties: reflectTypeToJSONSchema(xT, rt.Elem(), descKey, seen),
+		}
+
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object"}
+		if _, ok := seen[rt]; ok {
+			return schema
+		}
+		seen[rt] = struct{}{}
+		defer delete(seen, rt)
+
+		typeName := strings.ToLower(rt.Name())
+		schema.Properties = make(map[string]*JSONSchema, rt.NumField())
+		for i := 0; i < rt.NumField(); i++ {
+			rtf := rt.Field(i)
+			if rtf.PkgPath != "" {
+				continue
+			}
+
+			// The property name is the json name when it's
+			// available, otherwise the field name as encoded by
+			// encoding/json.
+			fieldName, opts := rtf.Name, ""
+			if tag := rtf.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				parts := strings.SplitN(tag, ",", 2)
+				if parts[0] != "" {
+					fieldName = parts[0]
+				}
+				if len(parts) > 1 {
+					opts = parts[1]
+				}
+			}
+
+			fieldDescKey := typeName + "-" + strings.ToLower(fieldName)
+			fieldSchema := reflectTypeToJSONSchema(xT, rtf.Type,
+				fieldDescKey, seen)
+			if fieldSchema.Description == "" {
+				fieldSchema.Description = xT(fieldDescKey)
+			}
+			schema.Properties[fieldName] = fieldSchema
+
+			if rtf.Type.Kind() != reflect.Ptr &&
+				!strings.Contains(opts, "omitempty") {
+
+				schema.Required = append(schema.Required, fieldName)
+			}
+		}
+		return schema
+	}
+
+	// Any other types, such as interfaces, can hold any value.
+	return &JSONSchema{}
+}
+
+// methodSchema generates and returns the OpenRPC description of the provided
+// command and method info.  This is the main work horse for the exported
+// GenerateOpenRPC function.
+func methodSchema(xT descLookupFunc, rtp reflect.Type, defaults map[int]reflect.Value, method string, resultTypes []interface{}) *OpenRPCMethod {
+	m := &OpenRPCMethod{
+		Name:           method,
+		Summary:        xT(method + "--synopsis"),
+		ParamStructure: "by-position",
+		Params:         make([]*OpenRPCContentDescriptor, 0),
+	}
+
+	// Describe each argument in the command.  Several simplifying
+	// assumptions are made here because the RegisterCmd function has
+	// already rigorously enforced the layout.
+	rt := rtp.Elem()
+	seen := make(map[reflect.Type]struct{})
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		fieldName := strings.ToLower(rtf.Name)
+		descKey := method + "-" + fieldName
+		schema := reflectTypeToJSONSchema(xT, rtf.Type, descKey, seen)
+		if defVal, ok := defaults[i]; ok {
+			schema.Default = defVal.Elem().Interface()
+		}
+		m.Params = append(m.Params, &OpenRPCContentDescriptor{
+			Name:        fieldName,
+			Description: xT(descKey),
+			Required:    rtf.Type.Kind() != reflect.Ptr,
+			Schema:      schema,
+		})
+	}
+
+	// Describe each result type.  When there is more than one result
+	// type, the result is one of them depending on the condition which
+	// triggers it.
+	results := make([]*JSONSchema, 0, len(resultTypes))
+	for i, resultType := range resultTypes {
+		descKey := fmt.Sprintf("%s--result%d", method, i)
+		var schema *JSONSchema
+		if resultType == nil {
+			schema = &JSONSchema{Type: "null"}
+		} else {
+			rt := reflect.TypeOf(resultType).Elem()
+			schema = reflectTypeToJSONSchema(xT, rt, descKey, seen)
+			if schema.Description == "" {
+				schema.Description = xT(descKey)
+			}
+		}
+		if len(resultTypes) > 1 {
+			condKey := fmt.Sprintf("%s--condition%d", method, i)
+			schema.Title = xT(condKey)
+		}
+		results = append(results, schema)
+	}
+	m.Result = &OpenRPCContentDescriptor{Name: method + "result"}
+	switch len(results) {
+	case 0:
+		m.Result.Schema = &JSONSchema{Type: "null"}
+	case 1:
+		m.Result.Schema = results[0]
+	default:
+		m.Result.Schema = &JSONSchema{OneOf: results}
+	}
+
+	return m
+}
+
+// GenerateOpenRPC generates and returns an OpenRPC document describing the
+// provided methods, which must all be associated with registered types.  All
+// commands provided by this package are registered by default.
+//
+// The methodResultTypes map associates each method with its result types
+// which, along with the provided descriptions map, follow the same rules as
+// the result types and descriptions passed to GenerateHelp.  Unlike
+// GenerateHelp, descriptions missing from the map are left empty rather than
+// resulting in an error since they are not required for the document to be
+// machine-readable.
+//
+// The methods in the returned document are sorted by name so the output is
+// deterministic.
+func GenerateOpenRPC(info OpenRPCInfo, descs map[string]string, methodResultTypes map[string][]interface{}) (*OpenRPCDocument, error) {
+	xT := func(key string) string {
+		return descs[key]
+	}
+
+	methods := make([]string, 0, len(methodResultTypes))
+	for method := range methodResultTypes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	doc := &OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    info,
+		Methods: make([]*OpenRPCMethod, 0, len(methods)),
+	}
+	for _, method := range methods {
+		registerLock.RLock()
+		rtp, ok := methodToConcreteType[method]
+		info := methodToInfo[method]
+		registerLock.RUnlock()
+		if !ok {
+			str := fmt.Sprintf("%q is not registered", method)
+			return nil, makeError(ErrUnregisteredMethod, str)
+		}
+
+		resultTypes := methodResultTypes[method]
+		for i, resultType := range resultTypes {
+			if resultType == nil {
+				continue
+			}
+
+			rtp := reflect.TypeOf(resultType)
+			if rtp.Kind() != reflect.Ptr {
+				str := fmt.Sprintf("%s result #%d (%v) is not a "+
+					"pointer", method, i, rtp.Kind())
+				return nil, makeError(ErrInvalidType, str)
+			}
+		}
+
+		doc.Methods = append(doc.Methods, methodSchema(xT, rtp,
+			info.defaults, method, resultTypes))
+	}
+
+	return doc, nil
+}
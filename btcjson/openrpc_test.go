@@ -0,0 +1,120 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcjson"
+)
+
+// TestGenerateOpenRPC ensures the generated OpenRPC document describes the
+// parameters and results of the provided methods.
+func TestGenerateOpenRPC(t *testing.T) {
+	t.Parallel()
+
+	descs := map[string]string{
+		"getblockheader--synopsis":         "Returns a block header",
+		"getblockheader-hash":              "The hash of the block",
+		"getblockheader-verbose":           "Whether to return a JSON object",
+		"getblockheader--condition0":       "verbose=false",
+		"getblockheader--condition1":       "verbose=true",
+		"getblockheader--result0":          "The serialized block header",
+		"getblockheaderverboseresult-hash": "The hash of the block",
+	}
+	methodResultTypes := map[string][]interface{}{
+		"getblockheader": {(*string)(nil),
+			(*btcjson.GetBlockHeaderVerboseResult)(nil)},
+		"ping": nil,
+	}
+	info := btcjson.OpenRPCInfo{Title: "test", Version: "1.0.0"}
+	doc, err := btcjson.GenerateOpenRPC(info, descs, methodResultTypes)
+	if err != nil {
+		t.Fatalf("GenerateOpenRPC: unexpected error: %v", err)
+	}
+	if doc.OpenRPC != btcjson.OpenRPCVersion || doc.Info != info {
+		t.Fatalf("unexpected document header: %q, %+v", doc.OpenRPC,
+			doc.Info)
+	}
+	if len(doc.Methods) != 2 || doc.Methods[0].Name != "getblockheader" ||
+		doc.Methods[1].Name != "ping" {
+
+		t.Fatalf("unexpected methods: %+v", doc.Methods)
+	}
+
+	m := doc.Methods[0]
+	if m.Summary != "Returns a block header" || m.ParamStructure != "by-position" {
+		t.Errorf("unexpected method details: %+v", m)
+	}
+	wantParams := []*btcjson.OpenRPCContentDescriptor{{
+		Name:        "hash",
+		Description: "The hash of the block",
+		Required:    true,
+		Schema:      &btcjson.JSONSchema{Type: "string"},
+	}, {
+		Name:        "verbose",
+		Description: "Whether to return a JSON object",
+		Schema:      &btcjson.JSONSchema{Type: "boolean", Default: true},
+	}}
+	if !reflect.DeepEqual(m.Params, wantParams) {
+		t.Errorf("unexpected params - got %s, want %s",
+			mustMarshal(t, m.Params), mustMarshal(t, wantParams))
+	}
+
+	results := m.Result.Schema.OneOf
+	if len(results) != 2 {
+		t.Fatalf("unexpected number of results %d", len(results))
+	}
+	wantResult := &btcjson.JSONSchema{
+		Title:       "verbose=false",
+		Description: "The serialized block header",
+		Type:        "string",
+	}
+	if !reflect.DeepEqual(results[0], wantResult) {
+		t.Errorf("unexpected first result - got %s, want %s",
+			mustMarshal(t, results[0]), mustMarshal(t, wantResult))
+	}
+	verbose := results[1]
+	if verbose.Type != "object" || verbose.Title != "verbose=true" {
+		t.Errorf("unexpected second result: %s", mustMarshal(t, verbose))
+	}
+	hash := verbose.Properties["hash"]
+	if hash == nil || hash.Type != "string" ||
+		hash.Description != "The hash of the block" {
+
+		t.Errorf("unexpected hash property: %s", mustMarshal(t, hash))
+	}
+	if height := verbose.Properties["height"]; height == nil ||
+		height.Type != "integer" {
+
+		t.Errorf("unexpected height property: %s",
+			mustMarshal(t, height))
+	}
+
+	if got := doc.Methods[1].Result.Schema.Type; got != "null" {
+		t.Errorf("unexpected ping result type %q", got)
+	}
+
+	// Unregistered methods must be rejected.
+	_, err = btcjson.GenerateOpenRPC(info, descs, map[string][]interface{}{
+		"bogus": nil,
+	})
+	jerr, ok := err.(btcjson.Error)
+	if !ok || jerr.ErrorCode != btcjson.ErrUnregisteredMethod {
+		t.Errorf("unexpected error for unregistered method: %v", err)
+	}
+}
+
+// mustMarshal returns the JSON encoding of the provided value for use in test
+// failure messages.
+func mustMarshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unable to marshal %v: %v", v, err)
+	}
+	return string(b)
+}
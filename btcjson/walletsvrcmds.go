@@ -357,6 +357,15 @@ func NewUnloadWalletCmd(walletName *string) *UnloadWalletCmd {
 	return &UnloadWalletCmd{WalletName: walletName}
 }
 
+// ListWalletsCmd defines the listwallets JSON-RPC command.
+type ListWalletsCmd struct{}
+
+// NewListWalletsCmd returns a new instance which can be used to issue a
+// listwallets JSON-RPC command.
+func NewListWalletsCmd() *ListWalletsCmd {
+	return &ListWalletsCmd{}
+}
+
 // LoadWalletCmd defines the loadwallet JSON-RPC command
 type LoadWalletCmd struct {
 	WalletName string
@@ -1117,6 +1126,7 @@ func init() {
 	MustRegisterCmd("listsinceblock", (*ListSinceBlockCmd)(nil), flags)
 	MustRegisterCmd("listtransactions", (*ListTransactionsCmd)(nil), flags)
 	MustRegisterCmd("listunspent", (*ListUnspentCmd)(nil), flags)
+	MustRegisterCmd("listwallets", (*ListWalletsCmd)(nil), flags)
 	MustRegisterCmd("loadwallet", (*LoadWalletCmd)(nil), flags)
 	MustRegisterCmd("lockunspent", (*LockUnspentCmd)(nil), flags)
 	MustRegisterCmd("move", (*MoveCmd)(nil), flags)
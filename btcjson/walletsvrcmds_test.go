@@ -141,6 +141,17 @@ func TestWalletSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"backupwallet","params":["backup.dat"],"id":1}`,
 			unmarshalled: &btcjson.BackupWalletCmd{Destination: "backup.dat"},
 		},
+		{
+			name: "listwallets",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listwallets")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListWalletsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listwallets","params":[],"id":1}`,
+			unmarshalled: &btcjson.ListWalletsCmd{},
+		},
 		{
 			name: "loadwallet",
 			newCmd: func() (interface{}, error) {
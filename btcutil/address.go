@@ -142,7 +142,10 @@ type Address interface {
 // DecodeAddress decodes the string encoding of an address and returns
 // the Address if addr is a valid encoding for a known address type.
 //
-// The bitcoin network the address is associated with is extracted if possible.
+// The network the address is associated with is extracted if possible.  Dash
+// pay-to-pubkey-hash and pay-to-script-hash addresses only encode a version
+// byte, which is shared by testnet and regtest, so the passed defaultNet
+// determines which version bytes are accepted.
 // When the address does not encode the network, such as in the case of a raw
 // public key, the address will be associated with the passed defaultNet.
 func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
@@ -223,6 +226,79 @@ func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
 	}
 }
 
+// AddressValidity describes the result of validating a payment address with
+// ValidateAddress.
+type AddressValidity int
+
+// These constants define the possible results of validating an address.
+const (
+	// AddressValid indicates the address is a valid pay-to-pubkey-hash or
+	// pay-to-script-hash address for the network.
+	AddressValid AddressValidity = iota
+
+	// AddressInvalidFormat indicates the address is not base58 encoded or
+	// is too short to contain a version and checksum.
+	AddressInvalidFormat
+
+	// AddressChecksumMismatch indicates the address checksum is wrong,
+	// which is typically the result of a typo.
+	AddressChecksumMismatch
+
+	// AddressInvalidLength indicates the address does not encode a
+	// 20-byte hash.
+	AddressInvalidLength
+
+	// AddressWrongNetwork indicates the address is valid for a different
+	// network.
+	AddressWrongNetwork
+
+	// AddressUnknownType indicates the address version byte does not
+	// identify an address type of any known network.
+	AddressUnknownType
+)
+
+// addressValidityStrings is a map of address validity results back to their
+// constant names for pretty printing.
+var addressValidityStrings = map[AddressValidity]string{
+	AddressValid:            "AddressValid",
+	AddressInvalidFormat:    "AddressInvalidFormat",
+	AddressChecksumMismatch: "AddressChecksumMismatch",
+	AddressInvalidLength:    "AddressInvalidLength",
+	AddressWrongNetwork:     "AddressWrongNetwork",
+	AddressUnknownType:      "AddressUnknownType",
+}
+
+// String returns the AddressValidity as a human-readable name.
+func (v AddressValidity) String() string {
+	if s := addressValidityStrings[v]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown AddressValidity (%d)", int(v))
+}
+
+// ValidateAddress determines whether or not the passed string is a valid
+// pay-to-pubkey-hash or pay-to-script-hash address for the provided network.
+// Unlike DecodeAddress, the reason an address is invalid is reported in a
+// granular manner so user interfaces can give specific feedback, such as
+// distinguishing a mistyped address from one for another network.
+func ValidateAddress(addr string, net *chaincfg.Params) AddressValidity {
+	decoded, netID, err := base58.CheckDecode(addr)
+	switch {
+	case err == base58.ErrChecksum:
+		return AddressChecksumMismatch
+	case err != nil:
+		return AddressInvalidFormat
+	case len(decoded) != ripemd160.Size:
+		return AddressInvalidLength
+	case netID == net.PubKeyHashAddrID || netID == net.ScriptHashAddrID:
+		return AddressValid
+	case chaincfg.IsPubKeyHashAddrID(netID) ||
+		chaincfg.IsScriptHashAddrID(netID):
+		return AddressWrongNetwork
+	}
+	return AddressUnknownType
+}
+
 // decodeSegWitAddress parses a bech32 encoded segwit address string and
 // returns the witness version and witness program byte representation.
 func decodeSegWitAddress(address string) (byte, []byte, error) {
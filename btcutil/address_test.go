@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/btcutil/base58"
 	"github.com/dashpay/dashd-go/chaincfg"
 	"github.com/dashpay/dashd-go/wire"
 	"golang.org/x/crypto/ripemd160"
@@ -884,3 +885,161 @@ func TestAddresses(t *testing.T) {
 		}
 	}
 }
+
+// TestValidateAddress ensures ValidateAddress reports the expected reason for
+// addresses being invalid.
+func TestValidateAddress(t *testing.T) {
+	hash := make([]byte, ripemd160.Size)
+	for i := range hash {
+		hash[i] = byte(i)
+	}
+	mainP2PKH, err := btcutil.NewAddressPubKeyHash(hash, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	mainP2SH, err := btcutil.NewAddressScriptHashFromHash(hash,
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressScriptHashFromHash: %v", err)
+	}
+	testP2PKH, err := btcutil.NewAddressPubKeyHash(hash, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+
+	// Replace the final character to produce an address with a bad
+	// checksum.
+	badChecksum := mainP2PKH.EncodeAddress()
+	last := badChecksum[len(badChecksum)-1]
+	if last == 'a' {
+		last = 'b'
+	} else {
+		last = 'a'
+	}
+	badChecksum = badChecksum[:len(badChecksum)-1] + string(last)
+
+	tests := []struct {
+		name string
+		addr string
+		want btcutil.AddressValidity
+	}{
+		{"mainnet p2pkh", mainP2PKH.EncodeAddress(), btcutil.AddressValid},
+		{"mainnet p2sh", mainP2SH.EncodeAddress(), btcutil.AddressValid},
+		{"testnet p2pkh", testP2PKH.EncodeAddress(), btcutil.AddressWrongNetwork},
+		{"bad checksum", badChecksum, btcutil.AddressChecksumMismatch},
+		{"invalid characters", "X0OIl", btcutil.AddressInvalidFormat},
+		{"empty", "", btcutil.AddressInvalidFormat},
+		{"short hash", base58.CheckEncode(hash[:19],
+			chaincfg.MainNetParams.PubKeyHashAddrID), btcutil.AddressInvalidLength},
+		{"unknown version", base58.CheckEncode(hash, 0xff),
+			btcutil.AddressUnknownType},
+	}
+	for _, test := range tests {
+		got := btcutil.ValidateAddress(test.addr, &chaincfg.MainNetParams)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if s := btcutil.AddressValidity(0xff).String(); s !=
+		"Unknown AddressValidity (255)" {
+
+		t.Errorf("unexpected string for unknown validity: %s", s)
+	}
+}
+
+// TestAddressNetworks ensures pay-to-pubkey-hash and pay-to-script-hash
+// addresses use the Dash version bytes of each network and are only
+// considered valid for networks sharing them.
+func TestAddressNetworks(t *testing.T) {
+	hash := make([]byte, ripemd160.Size)
+	for i := range hash {
+		hash[i] = byte(i)
+	}
+
+	tests := []struct {
+		net        *chaincfg.Params
+		p2pkh      string
+		p2sh       string
+		forNetwork map[string]bool
+	}{
+		{
+			net:   &chaincfg.MainNetParams,
+			p2pkh: "X",
+			p2sh:  "7",
+			forNetwork: map[string]bool{
+				"main": true, "testnet": false, "regtest": false,
+			},
+		},
+		{
+			net:   &chaincfg.TestNet3Params,
+			p2pkh: "y",
+			p2sh:  "8",
+			forNetwork: map[string]bool{
+				"main": false, "testnet": true, "regtest": true,
+			},
+		},
+		{
+			net:   &chaincfg.RegressionNetParams,
+			p2pkh: "y",
+			p2sh:  "8",
+			forNetwork: map[string]bool{
+				"main": false, "testnet": true, "regtest": true,
+			},
+		},
+	}
+	nets := map[string]*chaincfg.Params{
+		"main":    &chaincfg.MainNetParams,
+		"testnet": &chaincfg.TestNet3Params,
+		"regtest": &chaincfg.RegressionNetParams,
+	}
+
+	for _, test := range tests {
+		p2pkh, err := btcutil.NewAddressPubKeyHash(hash, test.net)
+		if err != nil {
+			t.Fatalf("%s: NewAddressPubKeyHash: %v", test.net.Name, err)
+		}
+		p2sh, err := btcutil.NewAddressScriptHashFromHash(hash, test.net)
+		if err != nil {
+			t.Fatalf("%s: NewAddressScriptHashFromHash: %v",
+				test.net.Name, err)
+		}
+
+		for _, addr := range []struct {
+			addr   btcutil.Address
+			prefix string
+		}{{p2pkh, test.p2pkh}, {p2sh, test.p2sh}} {
+			encoded := addr.addr.EncodeAddress()
+			if !strings.HasPrefix(encoded, addr.prefix) {
+				t.Errorf("%s: address %s does not start with %q",
+					test.net.Name, encoded, addr.prefix)
+			}
+
+			decoded, err := btcutil.DecodeAddress(encoded, test.net)
+			if err != nil {
+				t.Errorf("%s: DecodeAddress(%s): %v", test.net.Name,
+					encoded, err)
+				continue
+			}
+			if !reflect.DeepEqual(decoded, addr.addr) {
+				t.Errorf("%s: DecodeAddress(%s) = %#v, want %#v",
+					test.net.Name, encoded, decoded, addr.addr)
+			}
+
+			for name, net := range nets {
+				want := test.forNetwork[name]
+				if got := decoded.IsForNet(net); got != want {
+					t.Errorf("%s: IsForNet(%s) of %s = %v, "+
+						"want %v", test.net.Name, name,
+						encoded, got, want)
+				}
+				_, err := btcutil.DecodeAddress(encoded, net)
+				if (err == nil) != want {
+					t.Errorf("%s: DecodeAddress(%s) for %s: "+
+						"unexpected error %v", test.net.Name,
+						encoded, name, err)
+				}
+			}
+		}
+	}
+}
@@ -6,51 +6,129 @@ package btcutil
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"strconv"
+	"strings"
 )
 
 // AmountUnit describes a method of converting an Amount to something
-// other than the base unit of a bitcoin.  The value of the AmountUnit
+// other than the base unit of a dash.  The value of the AmountUnit
 // is the exponent component of the decadic multiple to convert from
-// an amount in bitcoin to an amount counted in units.
+// an amount in dash to an amount counted in units.
 type AmountUnit int
 
-// These constants define various units used when describing a bitcoin
+// These constants define various units used when describing a dash
 // monetary amount.
 const (
-	AmountMegaBTC  AmountUnit = 6
-	AmountKiloBTC  AmountUnit = 3
-	AmountBTC      AmountUnit = 0
-	AmountMilliBTC AmountUnit = -3
-	AmountMicroBTC AmountUnit = -6
-	AmountSatoshi  AmountUnit = -8
+	AmountMegaDASH  AmountUnit = 6
+	AmountKiloDASH  AmountUnit = 3
+	AmountDASH      AmountUnit = 0
+	AmountMilliDASH AmountUnit = -3
+	AmountMicroDASH AmountUnit = -6
+	AmountDuff      AmountUnit = -8
+)
+
+// These constants are the bitcoin names of the units, which are kept for
+// compatibility.
+const (
+	// Deprecated: Use AmountMegaDASH.
+	AmountMegaBTC = AmountMegaDASH
+
+	// Deprecated: Use AmountKiloDASH.
+	AmountKiloBTC = AmountKiloDASH
+
+	// Deprecated: Use AmountDASH.
+	AmountBTC = AmountDASH
+
+	// Deprecated: Use AmountMilliDASH.
+	AmountMilliBTC = AmountMilliDASH
+
+	// Deprecated: Use AmountMicroDASH.
+	AmountMicroBTC = AmountMicroDASH
+
+	// Deprecated: Use AmountDuff.
+	AmountSatoshi = AmountDuff
 )
 
 // String returns the unit as a string.  For recognized units, the SI
-// prefix is used, or "Satoshi" for the base unit.  For all unrecognized
-// units, "1eN BTC" is returned, where N is the AmountUnit.
+// prefix is used, or "duffs" for the base unit.  For all unrecognized
+// units, "1eN DASH" is returned, where N is the AmountUnit.
 func (u AmountUnit) String() string {
 	switch u {
-	case AmountMegaBTC:
-		return "MBTC"
-	case AmountKiloBTC:
-		return "kBTC"
-	case AmountBTC:
-		return "BTC"
-	case AmountMilliBTC:
-		return "mBTC"
-	case AmountMicroBTC:
-		return "μBTC"
-	case AmountSatoshi:
-		return "Satoshi"
+	case AmountMegaDASH:
+		return "MDASH"
+	case AmountKiloDASH:
+		return "kDASH"
+	case AmountDASH:
+		return "DASH"
+	case AmountMilliDASH:
+		return "mDASH"
+	case AmountMicroDASH:
+		return "μDASH"
+	case AmountDuff:
+		return "duffs"
 	default:
-		return "1e" + strconv.FormatInt(int64(u), 10) + " BTC"
+		return "1e" + strconv.FormatInt(int64(u), 10) + " DASH"
 	}
 }
 
-// Amount represents the base bitcoin monetary unit (colloquially referred
-// to as a `Satoshi').  A single Amount is equal to 1e-8 of a bitcoin.
+// amountUnitNames maps the accepted names of units to the units.  The legacy
+// bitcoin names are accepted for compatibility.
+var amountUnitNames = map[string]AmountUnit{
+	"MDASH":   AmountMegaDASH,
+	"kDASH":   AmountKiloDASH,
+	"DASH":    AmountDASH,
+	"mDASH":   AmountMilliDASH,
+	"μDASH":   AmountMicroDASH,
+	"uDASH":   AmountMicroDASH,
+	"duffs":   AmountDuff,
+	"duff":    AmountDuff,
+	"MBTC":    AmountMegaDASH,
+	"kBTC":    AmountKiloDASH,
+	"BTC":     AmountDASH,
+	"mBTC":    AmountMilliDASH,
+	"μBTC":    AmountMicroDASH,
+	"uBTC":    AmountMicroDASH,
+	"Satoshi": AmountDuff,
+}
+
+// ParseAmountUnit returns the unit described by the passed string, which may
+// be any of the strings returned by AmountUnit.String, including the "1eN
+// DASH" form for unrecognized units.  Since the SI prefixes are case
+// sensitive, so is the unit name.  The singular "duff", "uDASH" as an ASCII
+// form of "μDASH", and the legacy bitcoin names such as "BTC" and "Satoshi"
+// are accepted as well.
+func ParseAmountUnit(s string) (AmountUnit, error) {
+	if u, ok := amountUnitNames[s]; ok {
+		return u, nil
+	}
+
+	// Parse the "1eN DASH" form used for unrecognized units.
+	if exp := strings.TrimSuffix(s, " DASH"); exp != s &&
+		strings.HasPrefix(exp, "1e") {
+
+		n, err := strconv.ParseInt(exp[2:], 10, 32)
+		if err == nil {
+			return AmountUnit(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown amount unit %q", s)
+}
+
+var (
+	// ErrAmountOverflow describes an error where the result of arithmetic
+	// on amounts does not fit in an Amount.
+	ErrAmountOverflow = errors.New("amount overflow")
+
+	// ErrInvalidMultiplier describes an error where an amount is multiplied
+	// by NaN or +-Infinity.
+	ErrInvalidMultiplier = errors.New("invalid amount multiplier")
+)
+
+// Amount represents the base dash monetary unit (colloquially referred
+// to as a `duff').  A single Amount is equal to 1e-8 of a dash.
 type Amount int64
 
 // round converts a floating point number, which may or may not be representable
@@ -88,29 +166,36 @@ func NewAmount(f float64) (Amount, error) {
 	return round(f * SatoshiPerBitcoin), nil
 }
 
-// ToUnit converts a monetary amount counted in bitcoin base units to a
-// floating point value representing an amount of bitcoin.
+// ToUnit converts a monetary amount counted in dash base units to a
+// floating point value representing an amount of dash.
 func (a Amount) ToUnit(u AmountUnit) float64 {
 	return float64(a) / math.Pow10(int(u+8))
 }
 
-// ToBTC is the equivalent of calling ToUnit with AmountBTC.
+// ToDASH is the equivalent of calling ToUnit with AmountDASH.
+func (a Amount) ToDASH() float64 {
+	return a.ToUnit(AmountDASH)
+}
+
+// ToBTC is the equivalent of calling ToUnit with AmountDASH.
+//
+// Deprecated: Use ToDASH.
 func (a Amount) ToBTC() float64 {
-	return a.ToUnit(AmountBTC)
+	return a.ToDASH()
 }
 
-// Format formats a monetary amount counted in bitcoin base units as a
+// Format formats a monetary amount counted in dash base units as a
 // string for a given unit.  The conversion will succeed for any unit,
 // however, known units will be formated with an appended label describing
-// the units with SI notation, or "Satoshi" for the base unit.
+// the units with SI notation, or "duffs" for the base unit.
 func (a Amount) Format(u AmountUnit) string {
 	units := " " + u.String()
 	return strconv.FormatFloat(a.ToUnit(u), 'f', -int(u+8), 64) + units
 }
 
-// String is the equivalent of calling Format with AmountBTC.
+// String is the equivalent of calling Format with AmountDASH.
 func (a Amount) String() string {
-	return a.Format(AmountBTC)
+	return a.Format(AmountDASH)
 }
 
 // MulF64 multiplies an Amount by a floating point value.  While this is not
@@ -120,3 +205,41 @@ func (a Amount) String() string {
 func (a Amount) MulF64(f float64) Amount {
 	return round(float64(a) * f)
 }
+
+// CheckedAdd returns the sum of two amounts.  Unlike the + operator, which
+// silently wraps around, it returns ErrAmountOverflow when the sum does not fit
+// in an Amount.
+func (a Amount) CheckedAdd(b Amount) (Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrAmountOverflow
+	}
+	return a + b, nil
+}
+
+// CheckedSub returns the difference of two amounts.  Unlike the - operator,
+// which silently wraps around, it returns ErrAmountOverflow when the
+// difference does not fit in an Amount.
+func (a Amount) CheckedSub(b Amount) (Amount, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrAmountOverflow
+	}
+	return a - b, nil
+}
+
+// CheckedMulF64 multiplies an Amount by a floating point value and rounds the
+// product the same way as MulF64.  Unlike MulF64, it returns
+// ErrInvalidMultiplier when f is NaN or +-Infinity and ErrAmountOverflow when
+// the product does not fit in an Amount.
+func (a Amount) CheckedMulF64(f float64) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrInvalidMultiplier
+	}
+
+	// Every float64 below 2^63 rounds to a value that fits, since they are
+	// spaced further than 0.5 apart near the bounds.
+	product := float64(a) * f
+	if product >= 1<<63 || product < -(1<<63) {
+		return 0, ErrAmountOverflow
+	}
+	return round(product), nil
+}
@@ -119,48 +119,48 @@ func TestAmountUnitConversions(t *testing.T) {
 		s         string
 	}{
 		{
-			name:      "MBTC",
+			name:      "MDASH",
 			amount:    MaxSatoshi,
-			unit:      AmountMegaBTC,
+			unit:      AmountMegaDASH,
 			converted: 21,
-			s:         "21 MBTC",
+			s:         "21 MDASH",
 		},
 		{
-			name:      "kBTC",
+			name:      "kDASH",
 			amount:    44433322211100,
-			unit:      AmountKiloBTC,
+			unit:      AmountKiloDASH,
 			converted: 444.33322211100,
-			s:         "444.333222111 kBTC",
+			s:         "444.333222111 kDASH",
 		},
 		{
-			name:      "BTC",
+			name:      "DASH",
 			amount:    44433322211100,
-			unit:      AmountBTC,
+			unit:      AmountDASH,
 			converted: 444333.22211100,
-			s:         "444333.222111 BTC",
+			s:         "444333.222111 DASH",
 		},
 		{
-			name:      "mBTC",
+			name:      "mDASH",
 			amount:    44433322211100,
-			unit:      AmountMilliBTC,
+			unit:      AmountMilliDASH,
 			converted: 444333222.11100,
-			s:         "444333222.111 mBTC",
+			s:         "444333222.111 mDASH",
 		},
 		{
 
-			name:      "μBTC",
+			name:      "μDASH",
 			amount:    44433322211100,
-			unit:      AmountMicroBTC,
+			unit:      AmountMicroDASH,
 			converted: 444333222111.00,
-			s:         "444333222111 μBTC",
+			s:         "444333222111 μDASH",
 		},
 		{
 
-			name:      "satoshi",
+			name:      "duffs",
 			amount:    44433322211100,
-			unit:      AmountSatoshi,
+			unit:      AmountDuff,
 			converted: 44433322211100,
-			s:         "44433322211100 Satoshi",
+			s:         "44433322211100 duffs",
 		},
 		{
 
@@ -168,7 +168,7 @@ func TestAmountUnitConversions(t *testing.T) {
 			amount:    44433322211100,
 			unit:      AmountUnit(-1),
 			converted: 4443332.2211100,
-			s:         "4443332.22111 1e-1 BTC",
+			s:         "4443332.22111 1e-1 DASH",
 		},
 	}
 
@@ -185,15 +185,22 @@ func TestAmountUnitConversions(t *testing.T) {
 			continue
 		}
 
-		// Verify that Amount.ToBTC works as advertised.
-		f1 := test.amount.ToUnit(AmountBTC)
-		f2 := test.amount.ToBTC()
-		if f1 != f2 {
-			t.Errorf("%v: ToBTC does not match ToUnit(AmountBTC): %v != %v", test.name, f1, f2)
+		// Verify that Amount.ToDASH and the deprecated Amount.ToBTC work
+		// as advertised.
+		f1 := test.amount.ToUnit(AmountDASH)
+		f2 := test.amount.ToDASH()
+		if f1 != f2 || f1 != test.amount.ToBTC() {
+			t.Errorf("%v: ToDASH does not match ToUnit(AmountDASH): %v != %v", test.name, f1, f2)
+		}
+
+		// Verify that the unit can be parsed back from its string.
+		u, err := ParseAmountUnit(test.unit.String())
+		if err != nil || u != test.unit {
+			t.Errorf("%v: ParseAmountUnit(%q) = %v, %v", test.name, test.unit.String(), u, err)
 		}
 
 		// Verify that Amount.String works as advertised.
-		s1 := test.amount.Format(AmountBTC)
+		s1 := test.amount.Format(AmountDASH)
 		s2 := test.amount.String()
 		if s1 != s2 {
 			t.Errorf("%v: String does not match Format(AmountBitcoin): %v != %v", test.name, s1, s2)
@@ -307,3 +314,103 @@ func TestAmountMulF64(t *testing.T) {
 		}
 	}
 }
+
+func TestAmountCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func() (Amount, error)
+		res  Amount
+		err  error
+	}{
+		{
+			name: "Add",
+			op:   func() (Amount, error) { return Amount(1e8).CheckedAdd(2e8) },
+			res:  3e8,
+		},
+		{
+			name: "Add negative",
+			op:   func() (Amount, error) { return Amount(1e8).CheckedAdd(-2e8) },
+			res:  -1e8,
+		},
+		{
+			name: "Add up to max",
+			op: func() (Amount, error) {
+				return Amount(math.MaxInt64 - 1).CheckedAdd(1)
+			},
+			res: math.MaxInt64,
+		},
+		{
+			name: "Add overflow",
+			op:   func() (Amount, error) { return Amount(math.MaxInt64).CheckedAdd(1) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "Add negative overflow",
+			op:   func() (Amount, error) { return Amount(math.MinInt64).CheckedAdd(-1) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "Sub",
+			op:   func() (Amount, error) { return Amount(1e8).CheckedSub(2e8) },
+			res:  -1e8,
+		},
+		{
+			name: "Sub down to min",
+			op: func() (Amount, error) {
+				return Amount(math.MinInt64 + 1).CheckedSub(1)
+			},
+			res: math.MinInt64,
+		},
+		{
+			name: "Sub overflow",
+			op:   func() (Amount, error) { return Amount(math.MinInt64).CheckedSub(1) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "Sub negative overflow",
+			op:   func() (Amount, error) { return Amount(0).CheckedSub(math.MinInt64) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "MulF64",
+			op:   func() (Amount, error) { return Amount(100).CheckedMulF64(2.0 / 3) },
+			res:  67,
+		},
+		{
+			name: "MulF64 overflow",
+			op:   func() (Amount, error) { return Amount(math.MaxInt64).CheckedMulF64(2) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "MulF64 negative overflow",
+			op:   func() (Amount, error) { return Amount(math.MaxInt64).CheckedMulF64(-2) },
+			err:  ErrAmountOverflow,
+		},
+		{
+			name: "MulF64 NaN",
+			op:   func() (Amount, error) { return Amount(1).CheckedMulF64(math.NaN()) },
+			err:  ErrInvalidMultiplier,
+		},
+		{
+			name: "MulF64 +Infinity",
+			op:   func() (Amount, error) { return Amount(1).CheckedMulF64(math.Inf(1)) },
+			err:  ErrInvalidMultiplier,
+		},
+		{
+			name: "MulF64 -Infinity",
+			op:   func() (Amount, error) { return Amount(0).CheckedMulF64(math.Inf(-1)) },
+			err:  ErrInvalidMultiplier,
+		},
+	}
+
+	for _, test := range tests {
+		a, err := test.op()
+		if err != test.err {
+			t.Errorf("%v: expected error %v got %v", test.name, test.err, err)
+			continue
+		}
+		if a != test.res {
+			t.Errorf("%v: expected %v got %v", test.name, test.res, a)
+		}
+	}
+}
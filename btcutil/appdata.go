@@ -29,6 +29,14 @@ func appDataDir(goos, appName string, roaming bool) string {
 	appNameUpper := string(unicode.ToUpper(rune(appName[0]))) + appName[1:]
 	appNameLower := string(unicode.ToLower(rune(appName[0]))) + appName[1:]
 
+	return appDataDirNamed(goos, appNameUpper, appNameLower, roaming)
+}
+
+// appDataDirNamed returns an operating system specific directory to be used
+// for storing application data for an application using the passed directory
+// names.  The upper name is used on Mac and Windows and the lower name on
+// POSIX style operating systems, where it is prefixed with a period, and Plan 9.
+func appDataDirNamed(goos, appNameUpper, appNameLower string, roaming bool) string {
 	// Get the OS specific home directory via the Go standard lib.
 	var homeDir string
 	usr, err := user.Current()
@@ -103,3 +111,35 @@ func appDataDir(goos, appName string, roaming bool) string {
 func AppDataDir(appName string, roaming bool) string {
 	return appDataDir(runtime.GOOS, appName, roaming)
 }
+
+// dashCoreDataDir returns the default data directory of Dash Core.  See
+// DashCoreDataDir for more details.  This unexported version takes an
+// operating system argument for the same reasons as appDataDir.
+func dashCoreDataDir(goos string, roaming bool) string {
+	return appDataDirNamed(goos, "DashCore", "dashcore", roaming)
+}
+
+// DashCoreDataDir returns the operating system specific default data directory
+// of Dash Core so tools can locate the data of an existing node, such as its
+// dash.conf and wallets.  Unlike AppDataDir, the directory name follows the
+// mixed case naming of Dash Core on Mac and Windows.
+//
+// The roaming parameter only applies to Windows where it specifies the roaming
+// application data profile (%APPDATA%) should be used instead of the local one
+// (%LOCALAPPDATA%).  Dash Core stores its data in the roaming profile, so
+// roaming should be true to locate the data of a node using the default
+// location.
+//
+// The returned directory is the base directory for mainnet.  Dash Core stores
+// the data for the other networks in subdirectories of it, such as testnet3,
+// regtest and devnet-<name>.
+//
+// Example results:
+//  dir := DashCoreDataDir(true)
+//   POSIX (Linux/BSD): ~/.dashcore
+//   Mac OS: $HOME/Library/Application Support/DashCore
+//   Windows: %APPDATA%\DashCore
+//   Plan 9: $home/dashcore
+func DashCoreDataDir(roaming bool) string {
+	return dashCoreDataDir(runtime.GOOS, roaming)
+}
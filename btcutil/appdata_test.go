@@ -131,3 +131,53 @@ func TestAppDataDir(t *testing.T) {
 		}
 	}
 }
+
+// TestDashCoreDataDir ensures the Dash Core data directory follows its naming
+// conventions for various operating systems.
+func TestDashCoreDataDir(t *testing.T) {
+	// When we're on Windows, set the expected local and roaming directories
+	// per the environment vars.  When we aren't on Windows, the function
+	// should return the current directory when forced to provide the
+	// Windows path since the environment variables won't exist.
+	winLocal := "."
+	winRoaming := "."
+	if runtime.GOOS == "windows" {
+		localAppData := os.Getenv("LOCALAPPDATA")
+		roamingAppData := os.Getenv("APPDATA")
+		if localAppData == "" {
+			localAppData = roamingAppData
+		}
+		winLocal = filepath.Join(localAppData, "DashCore")
+		winRoaming = filepath.Join(roamingAppData, "DashCore")
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		t.Fatalf("user.Current: %v", err)
+	}
+	homeDir := usr.HomeDir
+
+	tests := []struct {
+		goos    string
+		roaming bool
+		want    string
+	}{
+		{"windows", false, winLocal},
+		{"windows", true, winRoaming},
+		{"linux", false, filepath.Join(homeDir, ".dashcore")},
+		{"linux", true, filepath.Join(homeDir, ".dashcore")},
+		{"darwin", false, filepath.Join(homeDir, "Library",
+			"Application Support", "DashCore")},
+		{"freebsd", false, filepath.Join(homeDir, ".dashcore")},
+		{"plan9", false, filepath.Join(homeDir, "dashcore")},
+		{"unrecognized", false, filepath.Join(homeDir, ".dashcore")},
+	}
+
+	for i, test := range tests {
+		ret := btcutil.TstDashCoreDataDir(test.goos, test.roaming)
+		if ret != test.want {
+			t.Errorf("dashCoreDataDir #%d (%s) does not match - "+
+				"got %s, want %s", i, test.goos, ret, test.want)
+		}
+	}
+}
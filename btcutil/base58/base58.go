@@ -5,6 +5,7 @@
 package base58
 
 import (
+	"encoding/binary"
 	"math/big"
 )
 
@@ -26,8 +27,37 @@ var bigRadix = [...]*big.Int{
 
 var bigRadix10 = big.NewInt(58 * 58 * 58 * 58 * 58 * 58 * 58 * 58 * 58 * 58) // 58^10
 
+const (
+	// smallEncodeLimit is the maximum length of the byte slices encoded
+	// without big.Int arithmetic.  It covers the payloads of addresses,
+	// private keys and extended keys.
+	smallEncodeLimit = 90
+
+	// smallDecodeLimit is the maximum length of the strings decoded
+	// without big.Int arithmetic, which is the length of the encoding of
+	// smallEncodeLimit bytes.
+	smallDecodeLimit = 123
+
+	// radix5 is 58^5, the largest power of 58 that fits in a uint32.
+	radix5 = 58 * 58 * 58 * 58 * 58
+
+	// smallEncodeLimbs is the number of base 58^5 limbs needed to hold a
+	// number of smallEncodeLimit bytes, which needs log(256) / log(58) ~=
+	// 1.366 base58 digits per byte.
+	smallEncodeLimbs = smallEncodeLimit*1366/1000/5 + 2
+
+	// smallDecodeLimbs is the number of base 2^32 limbs needed to hold a
+	// number of smallDecodeLimit base58 digits, which needs log(58) /
+	// log(256) ~= 0.733 bytes per digit.
+	smallDecodeLimbs = smallDecodeLimit*733/1000/4 + 2
+)
+
 // Decode decodes a modified base58 string to a byte slice.
 func Decode(b string) []byte {
+	if len(b) <= smallDecodeLimit {
+		return decodeSmall(b)
+	}
+
 	answer := big.NewInt(0)
 	scratch := new(big.Int)
 
@@ -86,6 +116,10 @@ func Decode(b string) []byte {
 
 // Encode encodes a byte slice to a modified base58 string.
 func Encode(b []byte) string {
+	if len(b) <= smallEncodeLimit {
+		return encodeSmall(b)
+	}
+
 	x := new(big.Int)
 	x.SetBytes(b)
 
@@ -136,3 +170,131 @@ func Encode(b []byte) string {
 
 	return string(answer)
 }
+
+// decodeSmall decodes a modified base58 string of at most smallDecodeLimit
+// characters to a byte slice.  The conversion is done on fixed size arrays of
+// limbs rather than with big.Int arithmetic, which avoids allocations for short
+// strings.
+func decodeSmall(b string) []byte {
+	var numZeros int
+	for numZeros = 0; numZeros < len(b); numZeros++ {
+		if b[numZeros] != alphabetIdx0 {
+			break
+		}
+	}
+
+	// Convert up to 5 digits at a time into the little endian base 2^32
+	// limbs of the number.  The first group takes the remainder so the
+	// rest of the groups are full.
+	var limbs [smallDecodeLimbs]uint32
+	var numLimbs int
+	for t := b[numZeros:]; len(t) > 0; {
+		n := len(t) % 5
+		if n == 0 {
+			n = 5
+		}
+
+		total, mul := uint64(0), uint64(1)
+		for i := 0; i < n; i++ {
+			tmp := b58[t[i]]
+			if tmp == 255 {
+				return []byte("")
+			}
+			total = total*58 + uint64(tmp)
+			mul *= 58
+		}
+		t = t[n:]
+
+		// x = x*58^n + total
+		carry := total
+		for i := 0; i < numLimbs; i++ {
+			carry += uint64(limbs[i]) * mul
+			limbs[i] = uint32(carry)
+			carry >>= 32
+		}
+		for ; carry > 0; carry >>= 32 {
+			limbs[numLimbs] = uint32(carry)
+			numLimbs++
+		}
+	}
+
+	// Serialize the limbs big endian without the leading zero bytes.
+	var buf [smallDecodeLimbs * 4]byte
+	for i := 0; i < numLimbs; i++ {
+		binary.BigEndian.PutUint32(buf[len(buf)-4*(i+1):], limbs[i])
+	}
+	start := len(buf) - 4*numLimbs
+	for start < len(buf) && buf[start] == 0 {
+		start++
+	}
+
+	val := make([]byte, numZeros+len(buf)-start)
+	copy(val[numZeros:], buf[start:])
+	return val
+}
+
+// encodeSmall encodes a byte slice of at most smallEncodeLimit bytes to a
+// modified base58 string.  The conversion is done on fixed size arrays of
+// limbs rather than with big.Int arithmetic, which avoids allocations for
+// short slices.
+func encodeSmall(b []byte) string {
+	var numZeros int
+	for numZeros = 0; numZeros < len(b); numZeros++ {
+		if b[numZeros] != 0 {
+			break
+		}
+	}
+
+	// Convert up to 4 bytes at a time into the little endian base 58^5
+	// limbs of the number.  The first group takes the remainder so the
+	// rest of the groups are full.
+	var limbs [smallEncodeLimbs]uint32
+	var numLimbs int
+	for t := b[numZeros:]; len(t) > 0; {
+		n := len(t) % 4
+		if n == 0 {
+			n = 4
+		}
+
+		var total uint64
+		for _, v := range t[:n] {
+			total = total<<8 | uint64(v)
+		}
+		t = t[n:]
+
+		// x = x*256^n + total
+		carry := total
+		for i := 0; i < numLimbs; i++ {
+			carry += uint64(limbs[i]) << (8 * uint(n))
+			limbs[i] = uint32(carry % radix5)
+			carry /= radix5
+		}
+		for ; carry > 0; carry /= radix5 {
+			limbs[numLimbs] = uint32(carry % radix5)
+			numLimbs++
+		}
+	}
+
+	// Write the digits of the limbs from the end of the buffer, drop the
+	// leading zero digits of the most significant limb and then prepend
+	// a '1' for every leading zero byte.
+	var buf [smallEncodeLimit + smallEncodeLimbs*5]byte
+	pos := len(buf)
+	for i := 0; i < numLimbs; i++ {
+		m := limbs[i]
+		for j := 0; j < 5; j++ {
+			pos--
+			buf[pos] = alphabet[m%58]
+			m /= 58
+		}
+	}
+	for pos < len(buf) && buf[pos] == alphabetIdx0 {
+		pos++
+	}
+	for i := 0; i < numZeros; i++ {
+		pos--
+		buf[pos] = alphabetIdx0
+	}
+
+	return string(buf[pos:])
+}
@@ -7,6 +7,8 @@ package base58_test
 import (
 	"bytes"
 	"encoding/hex"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/dashpay/dashd-go/btcutil/base58"
@@ -99,3 +101,44 @@ func TestBase58(t *testing.T) {
 		}
 	}
 }
+
+// referenceEncode encodes a byte slice to a modified base58 string by repeated
+// division of a big.Int, which is the plain definition of the encoding.
+func referenceEncode(b []byte) string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	var digits []byte
+	x := new(big.Int).SetBytes(b)
+	radix, mod := big.NewInt(58), new(big.Int)
+	for x.Sign() > 0 {
+		x.DivMod(x, radix, mod)
+		digits = append([]byte{alphabet[mod.Int64()]}, digits...)
+	}
+	zeros := len(b) - len(bytes.TrimLeft(b, "\x00"))
+	return strings.Repeat("1", zeros) + string(digits)
+}
+
+// TestBase58Lengths ensures slices of all lengths around the limits of the
+// conversions that avoid big.Int arithmetic are encoded and decoded as
+// expected.
+func TestBase58Lengths(t *testing.T) {
+	for n := 0; n <= 200; n++ {
+		for _, fill := range []byte{0x00, 0x01, 0x7f, 0xff} {
+			b := bytes.Repeat([]byte{fill}, n)
+			if n > 2 {
+				// Include leading zeros.
+				b[0], b[1] = 0, 0
+			}
+
+			encoded := base58.Encode(b)
+			if want := referenceEncode(b); encoded != want {
+				t.Fatalf("Encode of %d bytes of %#x: got %s, want %s",
+					n, fill, encoded, want)
+			}
+			if res := base58.Decode(encoded); !bytes.Equal(res, b) {
+				t.Fatalf("Decode of %d bytes of %#x: got %x, want %x",
+					n, fill, res, b)
+			}
+		}
+	}
+}
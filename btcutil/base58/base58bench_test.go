@@ -16,6 +16,10 @@ var (
 	raw100k     = bytes.Repeat([]byte{0xff}, 100*1000)
 	encoded5k   = base58.Encode(raw5k)
 	encoded100k = base58.Encode(raw100k)
+
+	// raw21 is the version and hash of a pay-to-pubkey-hash address.
+	raw21     = bytes.Repeat([]byte{0x4c}, 21)
+	encoded21 = base58.CheckEncode(raw21[1:], raw21[0])
 )
 
 func BenchmarkBase58Encode_5K(b *testing.B) {
@@ -45,3 +49,17 @@ func BenchmarkBase58Decode_100K(b *testing.B) {
 		base58.Decode(encoded100k)
 	}
 }
+
+func BenchmarkBase58CheckEncode_Address(b *testing.B) {
+	b.SetBytes(int64(len(raw21)))
+	for i := 0; i < b.N; i++ {
+		base58.CheckEncode(raw21[1:], raw21[0])
+	}
+}
+
+func BenchmarkBase58CheckDecode_Address(b *testing.B) {
+	b.SetBytes(int64(len(encoded21)))
+	for i := 0; i < b.N; i++ {
+		base58.CheckDecode(encoded21)
+	}
+}
@@ -26,8 +26,14 @@ func checksum(input []byte) (cksum [4]byte) {
 
 // CheckEncode prepends a version byte and appends a four byte checksum.
 func CheckEncode(input []byte, version byte) string {
-	b := make([]byte, 0, 1+len(input)+4)
-	b = append(b, version)
+	return CheckEncodeVersion(input, []byte{version})
+}
+
+// CheckEncodeVersion prepends a version of any length and appends a four byte
+// checksum.  Multi-byte versions are used by formats such as extended keys.
+func CheckEncodeVersion(input []byte, version []byte) string {
+	b := make([]byte, 0, len(version)+len(input)+4)
+	b = append(b, version...)
 	b = append(b, input...)
 	cksum := checksum(b)
 	b = append(b, cksum[:]...)
@@ -36,17 +42,29 @@ func CheckEncode(input []byte, version byte) string {
 
 // CheckDecode decodes a string that was encoded with CheckEncode and verifies the checksum.
 func CheckDecode(input string) (result []byte, version byte, err error) {
+	result, versionBytes, err := CheckDecodeVersion(input, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, versionBytes[0], nil
+}
+
+// CheckDecodeVersion decodes a string that was encoded with CheckEncodeVersion
+// using a version of versionLen bytes and verifies the checksum.
+func CheckDecodeVersion(input string, versionLen int) (result, version []byte, err error) {
 	decoded := Decode(input)
-	if len(decoded) < 5 {
-		return nil, 0, ErrInvalidFormat
+	if versionLen < 0 || len(decoded) < versionLen+4 {
+		return nil, nil, ErrInvalidFormat
 	}
-	version = decoded[0]
 	var cksum [4]byte
 	copy(cksum[:], decoded[len(decoded)-4:])
 	if checksum(decoded[:len(decoded)-4]) != cksum {
-		return nil, 0, ErrChecksum
+		return nil, nil, ErrChecksum
 	}
-	payload := decoded[1 : len(decoded)-4]
-	result = append(result, payload...)
-	return
+
+	// The version and the payload share the decoded buffer, which is not
+	// referenced anywhere else.
+	version = decoded[:versionLen:versionLen]
+	result = decoded[versionLen : len(decoded)-4 : len(decoded)-4]
+	return result, version, nil
 }
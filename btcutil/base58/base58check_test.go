@@ -5,6 +5,7 @@
 package base58_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/dashpay/dashd-go/btcutil/base58"
@@ -67,3 +68,35 @@ func TestBase58Check(t *testing.T) {
 	}
 
 }
+
+func TestBase58CheckVersion(t *testing.T) {
+	// Extended private keys on mainnet use the four byte version 0488ade4.
+	version := []byte{0x04, 0x88, 0xad, 0xe4}
+	payload := bytes.Repeat([]byte{0x5a}, 74)
+	encoded := base58.CheckEncodeVersion(payload, version)
+	if encoded[:4] != "xprv" {
+		t.Errorf("CheckEncodeVersion: got %s, want xprv prefix", encoded)
+	}
+	res, resVersion, err := base58.CheckDecodeVersion(encoded, len(version))
+	switch {
+	case err != nil:
+		t.Errorf("CheckDecodeVersion failed with err: %v", err)
+
+	case !bytes.Equal(resVersion, version):
+		t.Errorf("CheckDecodeVersion: got version: %x want: %x", resVersion, version)
+
+	case !bytes.Equal(res, payload):
+		t.Errorf("CheckDecodeVersion: got: %x want: %x", res, payload)
+	}
+
+	// A single byte version matches CheckEncode.
+	if res := base58.CheckEncodeVersion([]byte("abc"), []byte{20}); res != "4QiVtDjUdeq" {
+		t.Errorf("CheckEncodeVersion: got %s, want 4QiVtDjUdeq", res)
+	}
+
+	// Strings too short for the version and the checksum are invalid.
+	_, _, err = base58.CheckDecodeVersion("3MNQE1X", 2)
+	if err != base58.ErrInvalidFormat {
+		t.Errorf("CheckDecodeVersion: got err %v, want ErrInvalidFormat", err)
+	}
+}
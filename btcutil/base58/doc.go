@@ -25,5 +25,7 @@ byte arrays into human-readable strings along with a version byte that can be
 used to differentiate the same payload.  For Bitcoin addresses, the extra
 version is used to differentiate the network of otherwise identical public keys
 which helps prevent using an address intended for one network on another.
+Formats such as extended keys use versions of several bytes, which are handled
+by CheckEncodeVersion and CheckDecodeVersion.
 */
 package base58
@@ -264,6 +264,24 @@ func (bf *Filter) maybeAddOutpoint(pkScript []byte, outHash *chainhash.Hash, out
 	}
 }
 
+// matchesPushedData returns true if the bloom filter matches any data pushed
+// by the passed script.  The script is tokenized in place, so no allocations
+// are made for the pushed data.  Scripts which fail to parse only match on the
+// data pushed prior to the parse failure, which is consistent with how the
+// reference implementation handles them.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) matchesPushedData(script []byte) bool {
+	const scriptVersion = 0
+	tokenizer := txscript.MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		if data := tokenizer.Data(); data != nil && bf.matches(data) {
+			return true
+		}
+	}
+	return false
+}
+
 // matchTxAndUpdate returns true if the bloom filter matches data within the
 // passed transaction, otherwise false is returned.  If the filter does match
 // the passed transaction, it will also update the filter depending on the bloom
@@ -284,20 +302,12 @@ func (bf *Filter) matchTxAndUpdate(tx *btcutil.Tx) bool {
 	// from the client and avoids some potential races that could otherwise
 	// occur.
 	for i, txOut := range tx.MsgTx().TxOut {
-		pushedData, err := txscript.PushedData(txOut.PkScript)
-		if err != nil {
+		if !bf.matchesPushedData(txOut.PkScript) {
 			continue
 		}
 
-		for _, data := range pushedData {
-			if !bf.matches(data) {
-				continue
-			}
-
-			matched = true
-			bf.maybeAddOutpoint(txOut.PkScript, tx.Hash(), uint32(i))
-			break
-		}
+		matched = true
+		bf.maybeAddOutpoint(txOut.PkScript, tx.Hash(), uint32(i))
 	}
 
 	// Nothing more to do if a match has already been made.
@@ -315,14 +325,8 @@ func (bf *Filter) matchTxAndUpdate(tx *btcutil.Tx) bool {
 			return true
 		}
 
-		pushedData, err := txscript.PushedData(txin.SignatureScript)
-		if err != nil {
-			continue
-		}
-		for _, data := range pushedData {
-			if bf.matches(data) {
-				return true
-			}
+		if bf.matchesPushedData(txin.SignatureScript) {
+			return true
 		}
 	}
 
@@ -0,0 +1,230 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chainexport_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/btcutil/chainexport"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// testBlock returns a block with a coinbase paying to a pay-to-pubkey-hash
+// address and a transaction with a multisig and a nonstandard output along
+// with the address of the coinbase and the public key of the multisig.
+func testBlock(t *testing.T, params *chaincfg.Params) (*btcutil.Block, string, string) {
+	t.Helper()
+
+	pkHashAddr, err := btcutil.NewAddressPubKeyHash(make([]byte, 20), params)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+	}
+	pkHashScript, err := txscript.PayToAddrScript(pkHashAddr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: unexpected error: %v", err)
+	}
+	pubKey, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029" +
+		"bfcdb2dce28d959f2815b16f81798")
+	pubKeyAddr, err := btcutil.NewAddressPubKey(pubKey, params)
+	if err != nil {
+		t.Fatalf("NewAddressPubKey: unexpected error: %v", err)
+	}
+	multiSigScript, err := txscript.MultiSigScript(
+		[]*btcutil.AddressPubKey{pubKeyAddr}, 1)
+	if err != nil {
+		t.Fatalf("MultiSigScript: unexpected error: %v", err)
+	}
+
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), []byte{0x51, 0x51}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(500000000, pkHashScript))
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 2),
+		[]byte{0x00}, nil))
+	tx.AddTxOut(wire.NewTxOut(100, multiSigScript))
+	tx.AddTxOut(wire.NewTxOut(200, []byte{0xff}))
+
+	msgBlock := wire.NewMsgBlock(wire.NewBlockHeader(1,
+		&chainhash.Hash{0x02}, &chainhash.Hash{0x03}, 0x1e0ffff0, 7))
+	msgBlock.Header.Timestamp = time.Unix(1700000000, 0)
+	msgBlock.AddTransaction(coinbase)
+	msgBlock.AddTransaction(tx)
+	block := btcutil.NewBlock(msgBlock)
+	block.SetHeight(100)
+
+	return block, pkHashAddr.EncodeAddress(), pubKeyAddr.EncodeAddress()
+}
+
+// TestFlattenBlock ensures blocks are flattened into the expected records in
+// the expected order and that errors of the callback stop the flattening.
+func TestFlattenBlock(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	block, pkHashAddr, pubKeyAddr := testBlock(t, params)
+	txs := block.Transactions()
+
+	var records []chainexport.Record
+	err := chainexport.FlattenBlock(block, params,
+		func(r chainexport.Record) error {
+			records = append(records, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("FlattenBlock: unexpected error: %v", err)
+	}
+
+	var tables []chainexport.Table
+	for _, r := range records {
+		tables = append(tables, r.Table())
+	}
+	wantTables := []chainexport.Table{chainexport.BlockTable,
+		chainexport.TxTable, chainexport.InputTable,
+		chainexport.OutputTable, chainexport.TxTable,
+		chainexport.InputTable, chainexport.OutputTable,
+		chainexport.OutputTable}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Fatalf("got tables %v, want %v", tables, wantTables)
+	}
+
+	blockRecord := records[0].(*chainexport.BlockRecord)
+	if blockRecord.Hash != block.Hash().String() ||
+		blockRecord.Height != 100 || blockRecord.NumTxs != 2 ||
+		blockRecord.Time != 1700000000 {
+
+		t.Errorf("unexpected block record %+v", blockRecord)
+	}
+
+	coinbase := records[1].(*chainexport.TxRecord)
+	if !coinbase.Coinbase || coinbase.TxIndex != 0 ||
+		coinbase.TxID != txs[0].Hash().String() ||
+		coinbase.OutputValue != 500000000 {
+
+		t.Errorf("unexpected coinbase record %+v", coinbase)
+	}
+	tx := records[4].(*chainexport.TxRecord)
+	if tx.Coinbase || tx.TxIndex != 1 || tx.OutputValue != 300 ||
+		tx.BlockHash != blockRecord.Hash {
+
+		t.Errorf("unexpected transaction record %+v", tx)
+	}
+
+	input := records[5].(*chainexport.InputRecord)
+	if input.PrevTxID != (chainhash.Hash{0x01}).String() ||
+		input.PrevIndex != 2 {
+
+		t.Errorf("unexpected input record %+v", input)
+	}
+
+	wantOutputs := []struct {
+		record chainexport.Record
+		class  txscript.ScriptClass
+		addrs  []string
+	}{
+		{records[3], txscript.PubKeyHashTy, []string{pkHashAddr}},
+		{records[6], txscript.MultiSigTy, []string{pubKeyAddr}},
+		{records[7], txscript.NonStandardTy, []string{}},
+	}
+	for i, want := range wantOutputs {
+		output := want.record.(*chainexport.OutputRecord)
+		if output.ScriptClass != want.class ||
+			!reflect.DeepEqual(output.Addresses, want.addrs) {
+
+			t.Errorf("output #%d: got class %v and addresses %v, "+
+				"want %v and %v", i, output.ScriptClass,
+				output.Addresses, want.class, want.addrs)
+		}
+	}
+
+	// Errors of the callback are returned as soon as they happen.
+	errStop := errors.New("stop")
+	var n int
+	err = chainexport.FlattenBlock(block, params,
+		func(r chainexport.Record) error {
+			n++
+			if r.Table() == chainexport.InputTable {
+				return errStop
+			}
+			return nil
+		})
+	if err != errStop || n != 3 {
+		t.Errorf("got error %v after %d records, want %v after 3", err,
+			n, errStop)
+	}
+}
+
+// TestCSVEncoder ensures the CSV encoder writes the header of each table once
+// followed by the records of the table, and that tables without a writer are
+// skipped.
+func TestCSVEncoder(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	block, pkHashAddr, _ := testBlock(t, params)
+
+	var txBuf, outputBuf bytes.Buffer
+	enc := chainexport.NewCSVEncoder(params, map[chainexport.Table]io.Writer{
+		chainexport.TxTable:     &txBuf,
+		chainexport.OutputTable: &outputBuf,
+	})
+	if err := enc.EncodeBlock(block); err != nil {
+		t.Fatalf("EncodeBlock: unexpected error: %v", err)
+	}
+	mempoolTx := btcutil.NewTx(block.MsgBlock().Transactions[1])
+	if err := enc.EncodeTx(mempoolTx); err != nil {
+		t.Fatalf("EncodeTx: unexpected error: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush: unexpected error: %v", err)
+	}
+
+	txRows, err := csv.NewReader(&txBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read transaction rows: %v", err)
+	}
+	if len(txRows) != 4 {
+		t.Fatalf("got %d transaction rows, want 4", len(txRows))
+	}
+	if !reflect.DeepEqual(txRows[0], chainexport.Header(chainexport.TxTable)) {
+		t.Errorf("got transaction header %v", txRows[0])
+	}
+	wantCoinbase := []string{block.Hash().String(), "100", "1700000000",
+		"0", block.Transactions()[0].Hash().String(), "1", "0", "0",
+		"87", "1", "1", "500000000", "true"}
+	if !reflect.DeepEqual(txRows[1], wantCoinbase) {
+		t.Errorf("got coinbase row %v, want %v", txRows[1], wantCoinbase)
+	}
+
+	// Transactions which aren't in a block have no block fields.
+	wantMempoolTx := []string{"", "", "", "", mempoolTx.Hash().String(),
+		"1", "0", "0"}
+	if !reflect.DeepEqual(txRows[3][:8], wantMempoolTx) {
+		t.Errorf("got mempool transaction row %v, want %v", txRows[3],
+			wantMempoolTx)
+	}
+
+	outputRows, err := csv.NewReader(&outputBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read output rows: %v", err)
+	}
+	if len(outputRows) != 6 {
+		t.Fatalf("got %d output rows, want 6", len(outputRows))
+	}
+	wantOutput := []string{block.Transactions()[0].Hash().String(), "0",
+		"500000000", "pubkeyhash", "1", pkHashAddr,
+		"76a914000000000000000000000000000000000000000088ac"}
+	if !reflect.DeepEqual(outputRows[1], wantOutput) {
+		t.Errorf("got output row %v, want %v", outputRows[1], wantOutput)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chainexport
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// CSVEncoder writes the flattened records of blocks and transactions as CSV,
+// with the records of each table written to a separate writer.  The header of
+// a table is written before its first record.  Records of tables without a
+// writer are discarded.
+//
+// The records are buffered, so Flush must be called once encoding is done.
+type CSVEncoder struct {
+	params      *chaincfg.Params
+	writers     [numTables]*csv.Writer
+	wroteHeader [numTables]bool
+}
+
+// NewCSVEncoder returns a new CSVEncoder which writes the records of each table
+// to the writer of the table in the passed map.  The addresses of outputs are
+// encoded for the passed network.
+func NewCSVEncoder(params *chaincfg.Params, writers map[Table]io.Writer) *CSVEncoder {
+	enc := &CSVEncoder{params: params}
+	for table, w := range writers {
+		if table >= 0 && table < numTables && w != nil {
+			enc.writers[table] = csv.NewWriter(w)
+		}
+	}
+	return enc
+}
+
+// encodeRecord writes the passed record to the writer of its table, preceded
+// by the header of the table when it is the first record of the table.
+func (enc *CSVEncoder) encodeRecord(record Record) error {
+	table := record.Table()
+	w := enc.writers[table]
+	if w == nil {
+		return nil
+	}
+	if !enc.wroteHeader[table] {
+		if err := w.Write(tableHeaders[table]); err != nil {
+			return err
+		}
+		enc.wroteHeader[table] = true
+	}
+	return w.Write(record.Fields())
+}
+
+// EncodeBlock writes the records of the passed block and its transactions.
+func (enc *CSVEncoder) EncodeBlock(block *btcutil.Block) error {
+	return FlattenBlock(block, enc.params, enc.encodeRecord)
+}
+
+// EncodeTx writes the records of the passed transaction, which is not part of
+// a block.
+func (enc *CSVEncoder) EncodeTx(tx *btcutil.Tx) error {
+	return FlattenTx(tx, enc.params, enc.encodeRecord)
+}
+
+// Flush writes any buffered records to the underlying writers and returns the
+// first error encountered by any of them.
+func (enc *CSVEncoder) Flush() error {
+	for _, w := range enc.writers {
+		if w == nil {
+			continue
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
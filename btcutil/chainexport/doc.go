@@ -0,0 +1,34 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package chainexport flattens blocks and transactions into tabular records for
+ingestion by analytics pipelines.
+
+Blocks and transactions are nested structures, which most data warehouses and
+dataframe libraries don't handle well.  This package flattens them into four
+tables, one row per block, transaction, input and output, which are joined by
+block hashes and transaction ids.  The public key scripts of outputs are
+resolved to their script class and the addresses they pay to.
+
+Records are produced as a stream through FlattenBlock and FlattenTx, so they
+can be written in any format without holding the records of a whole block in
+memory.  CSVEncoder writes them as CSV, one writer per table:
+
+	enc := chainexport.NewCSVEncoder(&chaincfg.MainNetParams,
+		map[chainexport.Table]io.Writer{
+			chainexport.TxTable:     txFile,
+			chainexport.OutputTable: outputFile,
+		})
+	for _, block := range blocks {
+		if err := enc.EncodeBlock(block); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+
+Columnar formats such as Parquet can be written from the same records with the
+encoder of choice, using Header for the column names.
+*/
+package chainexport
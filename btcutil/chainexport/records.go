@@ -0,0 +1,356 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chainexport
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// Table identifies the table a record belongs to.
+type Table int
+
+// These constants define the tables of the flattened records.
+const (
+	// BlockTable houses a record per block.
+	BlockTable Table = iota
+
+	// TxTable houses a record per transaction.
+	TxTable
+
+	// InputTable houses a record per transaction input.
+	InputTable
+
+	// OutputTable houses a record per transaction output.
+	OutputTable
+
+	// numTables is the number of tables.  It must be the last item.
+	numTables
+)
+
+// tableStrings is a map of tables back to their names for pretty printing.
+var tableStrings = map[Table]string{
+	BlockTable:  "blocks",
+	TxTable:     "transactions",
+	InputTable:  "inputs",
+	OutputTable: "outputs",
+}
+
+// String returns the name of the table.
+func (t Table) String() string {
+	if s, ok := tableStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown Table (%d)", int(t))
+}
+
+// tableHeaders houses the column names of each table, which are in the order
+// of the fields returned by the records of the table.
+var tableHeaders = map[Table][]string{
+	BlockTable: {"hash", "height", "version", "prev_block", "merkle_root",
+		"time", "bits", "nonce", "num_txs", "size"},
+	TxTable: {"block_hash", "block_height", "block_time", "tx_index",
+		"txid", "version", "type", "lock_time", "size", "num_inputs",
+		"num_outputs", "output_value", "coinbase"},
+	InputTable: {"txid", "index", "prev_txid", "prev_index", "sequence",
+		"signature_script"},
+	OutputTable: {"txid", "index", "value", "script_class",
+		"required_sigs", "addresses", "pk_script"},
+}
+
+// Header returns the column names of the passed table.
+func Header(table Table) []string {
+	header := make([]string, len(tableHeaders[table]))
+	copy(header, tableHeaders[table])
+	return header
+}
+
+// Record is a flattened block, transaction, input or output.
+type Record interface {
+	// Table returns the table the record belongs to.
+	Table() Table
+
+	// Fields returns the values of the record formatted as strings in the
+	// order of the columns returned by Header for its table.
+	Fields() []string
+}
+
+// BlockRecord is the flattened header of a block.
+type BlockRecord struct {
+	Hash       string
+	Height     int32 // btcutil.BlockHeightUnknown when unknown
+	Version    int32
+	PrevBlock  string
+	MerkleRoot string
+	Time       int64 // Seconds since 1 Jan 1970 UTC
+	Bits       uint32
+	Nonce      uint32
+	NumTxs     int
+	Size       int
+}
+
+// Table returns BlockTable.  This is part of the Record interface.
+func (r *BlockRecord) Table() Table {
+	return BlockTable
+}
+
+// Fields returns the values of the record.  This is part of the Record
+// interface.
+func (r *BlockRecord) Fields() []string {
+	return []string{
+		r.Hash,
+		strconv.FormatInt(int64(r.Height), 10),
+		strconv.FormatInt(int64(r.Version), 10),
+		r.PrevBlock,
+		r.MerkleRoot,
+		strconv.FormatInt(r.Time, 10),
+		fmt.Sprintf("%08x", r.Bits),
+		strconv.FormatUint(uint64(r.Nonce), 10),
+		strconv.Itoa(r.NumTxs),
+		strconv.Itoa(r.Size),
+	}
+}
+
+// TxRecord is a flattened transaction along with the block which contains it.
+// The block fields are empty for transactions which are not in a block.
+type TxRecord struct {
+	BlockHash   string
+	BlockHeight int32 // btcutil.BlockHeightUnknown when unknown
+	BlockTime   int64 // Seconds since 1 Jan 1970 UTC
+	TxIndex     int   // Index in the block, or -1 when not in a block
+	TxID        string
+	Version     uint16 // Version without the special transaction type
+	Type        uint16 // DIP0002 special transaction type
+	LockTime    uint32
+	Size        int
+	NumInputs   int
+	NumOutputs  int
+	OutputValue btcutil.Amount
+	Coinbase    bool
+}
+
+// Table returns TxTable.  This is part of the Record interface.
+func (r *TxRecord) Table() Table {
+	return TxTable
+}
+
+// Fields returns the values of the record.  This is part of the Record
+// interface.
+func (r *TxRecord) Fields() []string {
+	fields := []string{r.BlockHash, "", "", "", r.TxID,
+		strconv.FormatUint(uint64(r.Version), 10),
+		strconv.FormatUint(uint64(r.Type), 10),
+		strconv.FormatUint(uint64(r.LockTime), 10),
+		strconv.Itoa(r.Size),
+		strconv.Itoa(r.NumInputs),
+		strconv.Itoa(r.NumOutputs),
+		strconv.FormatInt(int64(r.OutputValue), 10),
+		strconv.FormatBool(r.Coinbase),
+	}
+	if r.BlockHash != "" {
+		fields[1] = strconv.FormatInt(int64(r.BlockHeight), 10)
+		fields[2] = strconv.FormatInt(r.BlockTime, 10)
+		fields[3] = strconv.Itoa(r.TxIndex)
+	}
+	return fields
+}
+
+// InputRecord is a flattened transaction input.
+type InputRecord struct {
+	TxID            string
+	Index           int
+	PrevTxID        string
+	PrevIndex       uint32
+	Sequence        uint32
+	SignatureScript []byte
+}
+
+// Table returns InputTable.  This is part of the Record interface.
+func (r *InputRecord) Table() Table {
+	return InputTable
+}
+
+// Fields returns the values of the record.  This is part of the Record
+// interface.
+func (r *InputRecord) Fields() []string {
+	return []string{
+		r.TxID,
+		strconv.Itoa(r.Index),
+		r.PrevTxID,
+		strconv.FormatUint(uint64(r.PrevIndex), 10),
+		strconv.FormatUint(uint64(r.Sequence), 10),
+		hex.EncodeToString(r.SignatureScript),
+	}
+}
+
+// OutputRecord is a flattened transaction output with its public key script
+// resolved to a script class and the addresses it pays to.
+type OutputRecord struct {
+	TxID         string
+	Index        int
+	Value        btcutil.Amount
+	ScriptClass  txscript.ScriptClass
+	RequiredSigs int
+	Addresses    []string
+	PkScript     []byte
+}
+
+// Table returns OutputTable.  This is part of the Record interface.
+func (r *OutputRecord) Table() Table {
+	return OutputTable
+}
+
+// Fields returns the values of the record.  The addresses are separated by
+// semicolons.  This is part of the Record interface.
+func (r *OutputRecord) Fields() []string {
+	return []string{
+		r.TxID,
+		strconv.Itoa(r.Index),
+		strconv.FormatInt(int64(r.Value), 10),
+		r.ScriptClass.String(),
+		strconv.Itoa(r.RequiredSigs),
+		strings.Join(r.Addresses, ";"),
+		hex.EncodeToString(r.PkScript),
+	}
+}
+
+// flattenTx passes the records of the passed transaction to fn, starting with
+// the transaction followed by its inputs and outputs.  The transaction record
+// is expected to have its block fields set.
+func flattenTx(tx *btcutil.Tx, txRecord *TxRecord, params *chaincfg.Params,
+	fn func(Record) error) error {
+
+	msgTx := tx.MsgTx()
+	txID := tx.Hash().String()
+
+	var outputValue btcutil.Amount
+	for _, txOut := range msgTx.TxOut {
+		outputValue += btcutil.Amount(txOut.Value)
+	}
+	txRecord.TxID = txID
+	txRecord.Version = msgTx.TxVersion()
+	txRecord.Type = uint16(msgTx.TxType())
+	txRecord.LockTime = msgTx.LockTime
+	txRecord.Size = msgTx.SerializeSize()
+	txRecord.NumInputs = len(msgTx.TxIn)
+	txRecord.NumOutputs = len(msgTx.TxOut)
+	txRecord.OutputValue = outputValue
+	txRecord.Coinbase = isCoinBase(tx)
+	if err := fn(txRecord); err != nil {
+		return err
+	}
+
+	for i, txIn := range msgTx.TxIn {
+		err := fn(&InputRecord{
+			TxID:            txID,
+			Index:           i,
+			PrevTxID:        txIn.PreviousOutPoint.Hash.String(),
+			PrevIndex:       txIn.PreviousOutPoint.Index,
+			Sequence:        txIn.Sequence,
+			SignatureScript: txIn.SignatureScript,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, txOut := range msgTx.TxOut {
+		// Scripts which can't be parsed are nonstandard and pay to no
+		// address, which is what the returned class already reflects.
+		class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+			txOut.PkScript, params)
+		addresses := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			addresses = append(addresses, addr.EncodeAddress())
+		}
+		err := fn(&OutputRecord{
+			TxID:         txID,
+			Index:        i,
+			Value:        btcutil.Amount(txOut.Value),
+			ScriptClass:  class,
+			RequiredSigs: reqSigs,
+			Addresses:    addresses,
+			PkScript:     txOut.PkScript,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isCoinBase returns whether or not the passed transaction is a coinbase,
+// which is the only transaction with a single input that spends the null
+// outpoint.
+func isCoinBase(tx *btcutil.Tx) bool {
+	msgTx := tx.MsgTx()
+	if len(msgTx.TxIn) != 1 {
+		return false
+	}
+	prevOut := &msgTx.TxIn[0].PreviousOutPoint
+	return prevOut.Index == wire.MaxPrevOutIndex &&
+		prevOut.Hash == chainhash.Hash{}
+}
+
+// FlattenTx passes the records of the passed transaction, which is not part of
+// a block such as a transaction of the mempool, to fn.  The transaction record
+// comes first and is followed by the records of its inputs and outputs.  It
+// stops at and returns the first error returned by fn.
+func FlattenTx(tx *btcutil.Tx, params *chaincfg.Params, fn func(Record) error) error {
+	txRecord := &TxRecord{
+		BlockHeight: btcutil.BlockHeightUnknown,
+		TxIndex:     -1,
+	}
+	return flattenTx(tx, txRecord, params, fn)
+}
+
+// FlattenBlock passes the records of the passed block and its transactions to
+// fn.  The block record comes first and is followed by the records of each
+// transaction in the order of FlattenTx.  It stops at and returns the first
+// error returned by fn.
+func FlattenBlock(block *btcutil.Block, params *chaincfg.Params, fn func(Record) error) error {
+	msgBlock := block.MsgBlock()
+	header := &msgBlock.Header
+	blockHash := block.Hash().String()
+	blockTime := header.Timestamp.Unix()
+	err := fn(&BlockRecord{
+		Hash:       blockHash,
+		Height:     block.Height(),
+		Version:    header.Version,
+		PrevBlock:  header.PrevBlock.String(),
+		MerkleRoot: header.MerkleRoot.String(),
+		Time:       blockTime,
+		Bits:       header.Bits,
+		Nonce:      header.Nonce,
+		NumTxs:     len(msgBlock.Transactions),
+		Size:       msgBlock.SerializeSize(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range block.Transactions() {
+		txRecord := &TxRecord{
+			BlockHash:   blockHash,
+			BlockHeight: block.Height(),
+			BlockTime:   blockTime,
+			TxIndex:     i,
+		}
+		if err := flattenTx(tx, txRecord, params, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package coinjoin
+
+import (
+	"github.com/dashpay/dashd-go/btcutil"
+)
+
+// standardDenominations houses the amounts of the standard CoinJoin
+// denominations ordered from the largest to the smallest.  Each denomination is
+// a power of ten of DASH plus a hundred thousandth of that amount, which makes
+// them easily distinguishable from ordinary payments.
+var standardDenominations = []btcutil.Amount{
+	(10 * btcutil.SatoshiPerBitcoin) + 10000, // 10.0001 DASH
+	(1 * btcutil.SatoshiPerBitcoin) + 1000,   // 1.00001 DASH
+	(btcutil.SatoshiPerBitcoin / 10) + 100,   // 0.100001 DASH
+	(btcutil.SatoshiPerBitcoin / 100) + 10,   // 0.0100001 DASH
+	(btcutil.SatoshiPerBitcoin / 1000) + 1,   // 0.00100001 DASH
+}
+
+// StandardDenominations returns the amounts of the standard CoinJoin
+// denominations ordered from the largest to the smallest.
+func StandardDenominations() []btcutil.Amount {
+	denoms := make([]btcutil.Amount, len(standardDenominations))
+	copy(denoms, standardDenominations)
+	return denoms
+}
+
+// SmallestDenomination returns the smallest standard CoinJoin denomination.
+func SmallestDenomination() btcutil.Amount {
+	return standardDenominations[len(standardDenominations)-1]
+}
+
+// IsDenominatedAmount returns whether or not the passed amount is exactly one
+// of the standard CoinJoin denominations.
+func IsDenominatedAmount(amount btcutil.Amount) bool {
+	return AmountToDenomination(amount) != 0
+}
+
+// AmountToDenomination returns the bit flag identifying the standard CoinJoin
+// denomination of the passed amount as used on the wire by the mixing
+// protocol, or 0 when the amount is not denominated.  The largest denomination
+// is identified by the least significant bit.
+func AmountToDenomination(amount btcutil.Amount) uint32 {
+	for i, denom := range standardDenominations {
+		if amount == denom {
+			return 1 << uint(i)
+		}
+	}
+
+	return 0
+}
+
+// DenominationToAmount returns the amount of the standard CoinJoin
+// denomination identified by the passed bit flag along with whether or not the
+// flag identifies exactly one known denomination.
+func DenominationToAmount(denom uint32) (btcutil.Amount, bool) {
+	for i, amount := range standardDenominations {
+		if denom == 1<<uint(i) {
+			return amount, true
+		}
+	}
+
+	return 0, false
+}
+
+// DenominateAmount breaks the passed amount down into standard CoinJoin
+// denominations.  The denominations are chosen greedily, using as many of the
+// largest denomination as possible before moving on to the next smaller one,
+// which results in the fewest outputs.  The denominations are returned ordered
+// from the largest to the smallest along with the remaining amount that is too
+// small to be denominated.  Non-positive amounts result in no denominations.
+//
+// The result is deterministic, so it is suitable for previewing how a balance
+// is split by mixing, however, note that mixing clients may prefer creating
+// several outputs of the smaller denominations to improve their chances of
+// taking part in mixing sessions.
+func DenominateAmount(amount btcutil.Amount) ([]btcutil.Amount, btcutil.Amount) {
+	if amount <= 0 {
+		return nil, amount
+	}
+
+	var denoms []btcutil.Amount
+	remaining := amount
+	for _, denom := range standardDenominations {
+		for remaining >= denom {
+			denoms = append(denoms, denom)
+			remaining -= denom
+		}
+	}
+
+	return denoms, remaining
+}
+
+// CollateralAmount returns the minimum amount of a CoinJoin collateral input,
+// which is a tenth of the smallest denomination.
+func CollateralAmount() btcutil.Amount {
+	return SmallestDenomination() / 10
+}
+
+// MaxCollateralAmount returns the maximum amount of a CoinJoin collateral
+// input.  Collateral may be reused for up to four mixing sessions, so the
+// largest collateral covers four charges.
+func MaxCollateralAmount() btcutil.Amount {
+	return CollateralAmount() * 4
+}
+
+// IsCollateralAmount returns whether or not the passed amount is in the range
+// of amounts accepted for CoinJoin collateral inputs.
+func IsCollateralAmount(amount btcutil.Amount) bool {
+	return amount >= CollateralAmount() && amount <= MaxCollateralAmount()
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package coinjoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected. It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+var (
+	// p2pkhScript is a pay-to-pubkey-hash script.
+	p2pkhScript = hexToBytes("76a914" + "0102030405060708090a0b0c0d0e0f1011121314" +
+		"88ac")
+
+	// p2shScript is a pay-to-script-hash script.
+	p2shScript = hexToBytes("a914" + "0102030405060708090a0b0c0d0e0f1011121314" +
+		"87")
+)
+
+// TestDenominations ensures the denomination amounts and their wire flags are
+// recognized and converted as expected.
+func TestDenominations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount btcutil.Amount
+		denom  uint32
+	}{
+		{1000010000, 1 << 0},
+		{100001000, 1 << 1},
+		{10000100, 1 << 2},
+		{1000010, 1 << 3},
+		{100001, 1 << 4},
+		{100000, 0},
+		{1000010001, 0},
+		{0, 0},
+	}
+
+	for i, test := range tests {
+		denom := AmountToDenomination(test.amount)
+		if denom != test.denom {
+			t.Errorf("AmountToDenomination #%d: got %d, want %d", i,
+				denom, test.denom)
+			continue
+		}
+		if IsDenominatedAmount(test.amount) != (test.denom != 0) {
+			t.Errorf("IsDenominatedAmount #%d: unexpected result", i)
+			continue
+		}
+		if test.denom == 0 {
+			continue
+		}
+
+		amount, ok := DenominationToAmount(test.denom)
+		if !ok || amount != test.amount {
+			t.Errorf("DenominationToAmount #%d: got %v (%v), want %v",
+				i, amount, ok, test.amount)
+		}
+	}
+
+	if _, ok := DenominationToAmount(1<<0 | 1<<1); ok {
+		t.Errorf("DenominationToAmount: accepted combined flags")
+	}
+	if SmallestDenomination() != 100001 {
+		t.Errorf("SmallestDenomination: got %v", SmallestDenomination())
+	}
+
+	// Ensure modifying the returned denominations does not affect the
+	// package state.
+	denoms := StandardDenominations()
+	denoms[0] = 0
+	if !IsDenominatedAmount(1000010000) {
+		t.Errorf("StandardDenominations: internal state modified")
+	}
+}
+
+// TestDenominateAmount ensures amounts are broken down greedily into the
+// standard denominations.
+func TestDenominateAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount    btcutil.Amount
+		denoms    []btcutil.Amount
+		remaining btcutil.Amount
+	}{
+		{0, nil, 0},
+		{-1, nil, -1},
+		{100000, nil, 100000},
+		{100001, []btcutil.Amount{100001}, 0},
+		{1000010000, []btcutil.Amount{1000010000}, 0},
+		{
+			amount: 2*1000010000 + 100001000 + 3*100001 + 99,
+			denoms: []btcutil.Amount{
+				1000010000, 1000010000, 100001000, 100001,
+				100001, 100001,
+			},
+			remaining: 99,
+		},
+		{
+			// Just short of 1.00001 DASH, which takes nine of each
+			// smaller denomination.
+			amount: 100001000 - 1,
+			denoms: []btcutil.Amount{
+				10000100, 10000100, 10000100, 10000100,
+				10000100, 10000100, 10000100, 10000100,
+				10000100, 1000010, 1000010, 1000010, 1000010,
+				1000010, 1000010, 1000010, 1000010, 1000010,
+				100001, 100001, 100001, 100001, 100001, 100001,
+				100001, 100001, 100001,
+			},
+			remaining: 100001000 - 1 - 9*(10000100+1000010+100001),
+		},
+	}
+
+	for i, test := range tests {
+		denoms, remaining := DenominateAmount(test.amount)
+		if !reflect.DeepEqual(denoms, test.denoms) ||
+			remaining != test.remaining {
+
+			t.Errorf("DenominateAmount #%d (%v): got %v and %v, want "+
+				"%v and %v", i, test.amount, denoms, remaining,
+				test.denoms, test.remaining)
+			continue
+		}
+
+		var sum btcutil.Amount
+		for _, denom := range denoms {
+			if !IsDenominatedAmount(denom) {
+				t.Errorf("DenominateAmount #%d: %v is not "+
+					"denominated", i, denom)
+			}
+			sum += denom
+		}
+		if sum+remaining != test.amount {
+			t.Errorf("DenominateAmount #%d: denominations do not add "+
+				"up to the amount", i)
+		}
+	}
+}
+
+// TestCollateral ensures the collateral amount range is recognized.
+func TestCollateral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount btcutil.Amount
+		valid  bool
+	}{
+		{9999, false},
+		{10000, true},
+		{25000, true},
+		{40000, true},
+		{40001, false},
+	}
+
+	for i, test := range tests {
+		if IsCollateralAmount(test.amount) != test.valid {
+			t.Errorf("IsCollateralAmount #%d (%v): want %v", i,
+				test.amount, test.valid)
+		}
+	}
+}
+
+// TestClassifyOutput ensures outputs are classified according to both their
+// amounts and script shapes.
+func TestClassifyOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		txOut *wire.TxOut
+		class OutputClass
+		p2sh  bool
+	}{
+		{
+			name:  "denominated p2pkh",
+			txOut: wire.NewTxOut(100001000, p2pkhScript),
+			class: OutputDenominated,
+		},
+		{
+			name:  "denominated p2sh",
+			txOut: wire.NewTxOut(100001000, p2shScript),
+			class: OutputOther,
+			p2sh:  true,
+		},
+		{
+			name:  "collateral p2pkh",
+			txOut: wire.NewTxOut(20000, p2pkhScript),
+			class: OutputCollateral,
+		},
+		{
+			name:  "ordinary payment",
+			txOut: wire.NewTxOut(123456789, p2pkhScript),
+			class: OutputOther,
+		},
+	}
+
+	for _, test := range tests {
+		class := ClassifyOutput(test.txOut)
+		if class != test.class {
+			t.Errorf("%s: got %v, want %v", test.name, class,
+				test.class)
+		}
+		if IsP2SHOutput(test.txOut) != test.p2sh {
+			t.Errorf("%s: unexpected p2sh result", test.name)
+		}
+	}
+}
+
+// TestIsP2SHWrappedInput ensures signature scripts redeeming
+// pay-to-script-hash outputs are detected.
+func TestIsP2SHWrappedInput(t *testing.T) {
+	t.Parallel()
+
+	sig := bytes.Repeat([]byte{0x30}, 71)
+	pubKey := append([]byte{0x02}, bytes.Repeat([]byte{0x01}, 32)...)
+
+	// 1-of-1 multisig redeem script.
+	redeemScript := append([]byte{0x51, 0x21}, pubKey...)
+	redeemScript = append(redeemScript, 0x51, 0xae)
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		want      bool
+	}{
+		{
+			name: "p2pkh redemption",
+			sigScript: append(append([]byte{byte(len(sig))}, sig...),
+				append([]byte{byte(len(pubKey))}, pubKey...)...),
+			want: false,
+		},
+		{
+			name: "p2sh multisig redemption",
+			sigScript: append(append([]byte{0x00, byte(len(sig))}, sig...),
+				append([]byte{byte(len(redeemScript))}, redeemScript...)...),
+			want: true,
+		},
+		{
+			name:      "not push only",
+			sigScript: []byte{0x76, 0x01, 0x51},
+			want:      false,
+		},
+		{
+			name:      "empty",
+			sigScript: nil,
+			want:      false,
+		},
+	}
+
+	for _, test := range tests {
+		if got := IsP2SHWrappedInput(test.sigScript); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestIsMixingTx ensures transactions are only recognized as mixing
+// transactions when all of their outputs share the same denomination.
+func TestIsMixingTx(t *testing.T) {
+	t.Parallel()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := 0; i < 3; i++ {
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: uint32(i)}, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(1000010, p2pkhScript))
+	}
+	if !IsMixingTx(tx) {
+		t.Fatalf("IsMixingTx: mixing transaction not recognized")
+	}
+
+	tx.TxOut[1].Value = 10000100
+	if IsMixingTx(tx) {
+		t.Fatalf("IsMixingTx: mixed denominations recognized")
+	}
+
+	tx.TxOut[1].Value = 1000010
+	tx.AddTxOut(wire.NewTxOut(1000010, p2pkhScript))
+	if IsMixingTx(tx) {
+		t.Fatalf("IsMixingTx: mismatched input and output counts " +
+			"recognized")
+	}
+}
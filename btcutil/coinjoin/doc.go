@@ -0,0 +1,20 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package coinjoin provides helpers for recognizing the outputs and inputs that
+take part in Dash CoinJoin mixing.
+
+CoinJoin mixing only ever produces outputs of a small set of standard
+denominations which are paid to pay-to-pubkey-hash scripts, and participants
+pay for misbehaving with collateral inputs of a narrow range of amounts.  This
+makes it possible to classify wallet UTXOs and the outputs of arbitrary
+transactions purely from their amounts and script shapes.
+
+The heuristics in this package are exactly that: heuristics.  An output with a
+denominated amount paying to a pay-to-pubkey-hash script is not necessarily the
+product of a mixing session, so callers performing analytics should treat the
+results as indicators rather than proofs.
+*/
+package coinjoin
@@ -0,0 +1,124 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package coinjoin
+
+import (
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// OutputClass identifies the role an output plays with regards to CoinJoin
+// mixing as determined by the heuristics in this package.
+type OutputClass byte
+
+// These constants are used to identify the CoinJoin role of an output.
+const (
+	// OutputOther identifies an output that plays no recognized role in
+	// CoinJoin mixing.
+	OutputOther OutputClass = iota
+
+	// OutputDenominated identifies an output paying a standard CoinJoin
+	// denomination to a pay-to-pubkey-hash script.
+	OutputDenominated
+
+	// OutputCollateral identifies an output paying a collateral amount to a
+	// pay-to-pubkey-hash script.
+	OutputCollateral
+)
+
+// outputClassStrings is a map of OutputClass values back to their constant
+// names for pretty printing.
+var outputClassStrings = map[OutputClass]string{
+	OutputOther:       "OutputOther",
+	OutputDenominated: "OutputDenominated",
+	OutputCollateral:  "OutputCollateral",
+}
+
+// String returns the OutputClass as a human-readable name.
+func (c OutputClass) String() string {
+	if s := outputClassStrings[c]; s != "" {
+		return s
+	}
+	return "Unknown OutputClass"
+}
+
+// IsDenominatedOutput returns whether or not the passed output has the shape
+// of a CoinJoin mixing output, which always pays a standard denomination to a
+// pay-to-pubkey-hash script.
+func IsDenominatedOutput(txOut *wire.TxOut) bool {
+	return IsDenominatedAmount(btcutil.Amount(txOut.Value)) &&
+		txscript.IsPayToPubKeyHash(txOut.PkScript)
+}
+
+// IsCollateralOutput returns whether or not the passed output has the shape of
+// a CoinJoin collateral output, which pays a collateral amount to a
+// pay-to-pubkey-hash script.
+func IsCollateralOutput(txOut *wire.TxOut) bool {
+	return IsCollateralAmount(btcutil.Amount(txOut.Value)) &&
+		txscript.IsPayToPubKeyHash(txOut.PkScript)
+}
+
+// ClassifyOutput returns the CoinJoin role of the passed output.
+func ClassifyOutput(txOut *wire.TxOut) OutputClass {
+	switch {
+	case IsDenominatedOutput(txOut):
+		return OutputDenominated
+	case IsCollateralOutput(txOut):
+		return OutputCollateral
+	}
+
+	return OutputOther
+}
+
+// IsP2SHOutput returns whether or not the passed output pays to a
+// pay-to-script-hash script and is therefore redeemed by a script wrapped
+// within the signature script of the spending input.
+func IsP2SHOutput(txOut *wire.TxOut) bool {
+	return txscript.IsPayToScriptHash(txOut.PkScript)
+}
+
+// IsP2SHWrappedInput returns whether or not the passed signature script has
+// the shape of one redeeming a pay-to-script-hash output.  That is, it only
+// pushes data and the final push is itself a recognized standard script.
+//
+// The referenced output is not required, which makes this useful when the
+// previous outputs of a transaction are not available, however it also means
+// a signature script which merely happens to push data that parses as a
+// standard script is indistinguishable from a real redemption.
+func IsP2SHWrappedInput(sigScript []byte) bool {
+	if !txscript.IsPushOnlyScript(sigScript) {
+		return false
+	}
+
+	const scriptVersion = 0
+	var redeemScript []byte
+	tokenizer := txscript.MakeScriptTokenizer(scriptVersion, sigScript)
+	for tokenizer.Next() {
+		redeemScript = tokenizer.Data()
+	}
+	if tokenizer.Err() != nil || len(redeemScript) == 0 {
+		return false
+	}
+
+	return txscript.GetScriptClass(redeemScript) != txscript.NonStandardTy
+}
+
+// IsMixingTx returns whether or not the passed transaction has the shape of a
+// CoinJoin mixing transaction, which has the same number of inputs and outputs
+// and only denominated outputs all of the same denomination.
+func IsMixingTx(tx *wire.MsgTx) bool {
+	if len(tx.TxIn) == 0 || len(tx.TxIn) != len(tx.TxOut) {
+		return false
+	}
+
+	for _, txOut := range tx.TxOut {
+		if !IsDenominatedOutput(txOut) || txOut.Value != tx.TxOut[0].Value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -42,5 +42,11 @@ To decode/encode an address:
 		return
 	}
 	fmt.Println(addr.EncodeAddress())
+
+Message Signing Overview
+
+SignMessage and VerifyMessage create and verify signatures of arbitrary
+messages by the key of a pay-to-pubkey-hash address.  They are compatible with
+the signmessage and verifymessage RPCs of Dash Core.
 */
 package btcutil
@@ -10,17 +10,17 @@ import (
 func ExampleAmount() {
 
 	a := btcutil.Amount(0)
-	fmt.Println("Zero Satoshi:", a)
+	fmt.Println("Zero duffs:", a)
 
 	a = btcutil.Amount(1e8)
-	fmt.Println("100,000,000 Satoshis:", a)
+	fmt.Println("100,000,000 duffs:", a)
 
 	a = btcutil.Amount(1e5)
-	fmt.Println("100,000 Satoshis:", a)
+	fmt.Println("100,000 duffs:", a)
 	// Output:
-	// Zero Satoshi: 0 BTC
-	// 100,000,000 Satoshis: 1 BTC
-	// 100,000 Satoshis: 0.001 BTC
+	// Zero duffs: 0 DASH
+	// 100,000,000 duffs: 1 DASH
+	// 100,000 duffs: 0.001 DASH
 }
 
 func ExampleNewAmount() {
@@ -52,25 +52,41 @@ func ExampleNewAmount() {
 	}
 	fmt.Println(amountNaN) //Output 4
 
-	// Output: 1 BTC
-	// 0.01234567 BTC
-	// 0 BTC
+	// Output: 1 DASH
+	// 0.01234567 DASH
+	// 0 DASH
 	// invalid bitcoin amount
 }
 
 func ExampleAmount_unitConversions() {
 	amount := btcutil.Amount(44433322211100)
 
-	fmt.Println("Satoshi to kBTC:", amount.Format(btcutil.AmountKiloBTC))
-	fmt.Println("Satoshi to BTC:", amount)
-	fmt.Println("Satoshi to MilliBTC:", amount.Format(btcutil.AmountMilliBTC))
-	fmt.Println("Satoshi to MicroBTC:", amount.Format(btcutil.AmountMicroBTC))
-	fmt.Println("Satoshi to Satoshi:", amount.Format(btcutil.AmountSatoshi))
+	fmt.Println("Duffs to kDASH:", amount.Format(btcutil.AmountKiloDASH))
+	fmt.Println("Duffs to DASH:", amount)
+	fmt.Println("Duffs to MilliDASH:", amount.Format(btcutil.AmountMilliDASH))
+	fmt.Println("Duffs to MicroDASH:", amount.Format(btcutil.AmountMicroDASH))
+	fmt.Println("Duffs to duffs:", amount.Format(btcutil.AmountDuff))
 
 	// Output:
-	// Satoshi to kBTC: 444.333222111 kBTC
-	// Satoshi to BTC: 444333.222111 BTC
-	// Satoshi to MilliBTC: 444333222.111 mBTC
-	// Satoshi to MicroBTC: 444333222111 μBTC
-	// Satoshi to Satoshi: 44433322211100 Satoshi
+	// Duffs to kDASH: 444.333222111 kDASH
+	// Duffs to DASH: 444333.222111 DASH
+	// Duffs to MilliDASH: 444333222.111 mDASH
+	// Duffs to MicroDASH: 444333222111 μDASH
+	// Duffs to duffs: 44433322211100 duffs
+}
+
+func ExampleParseAmountUnit() {
+	unit, err := btcutil.ParseAmountUnit("mDASH")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(btcutil.Amount(123456789).Format(unit))
+
+	_, err = btcutil.ParseAmountUnit("MDash")
+	fmt.Println(err)
+
+	// Output:
+	// 1234.56789 mDASH
+	// unknown amount unit "MDash"
 }
@@ -36,6 +36,14 @@ package provides the NewMaster function to create it from a cryptographically
 random seed.  The GenerateSeed function is provided as a convenient way to
 create a random seed for use with the NewMaster function.
 
+Mnemonics
+
+Wallets usually present the seed to users as a BIP0039 mnemonic, a sentence of
+12 to 24 English words.  The GenerateEntropy and NewMnemonic functions create a
+new mnemonic, ValidateMnemonic detects mistyped words through the checksum the
+mnemonic encodes, and NewMasterFromMnemonic creates the master node from a
+mnemonic and an optional passphrase.
+
 Deriving Children
 
 Once you have created a tree root (or have deserialized an extended key as
@@ -46,6 +54,11 @@ the HardenedKeyStart constant + the hardened key number as the index to the
 Derive function.  This provides the ability to cascade the keys into a tree and
 hence generate the hierarchical deterministic key chains.
 
+Keys deeper in the tree can be derived in one step with the DerivePath function,
+which accepts derivation paths such as "m/44'/5'/0'/0/0", the first receiving
+address of the first account of a BIP0044 Dash wallet.  The ParsePath and
+FormatPath functions convert between such paths and child indexes.
+
 Normal vs Hardened Derived Extended Keys
 
 A private extended key can be used to derive both hardened and non-hardened
@@ -253,8 +253,12 @@ func (k *ExtendedKey) Derive(i uint32) (*ExtendedKey, error) {
 	//
 	// For normal children:
 	//   serP(parentPubKey) || ser32(i)
+	//
+	// The data holds the parent private key for hardened children, so it
+	// is wiped once the child is derived.
 	keyLen := 33
-	data := make([]byte, keyLen+4)
+	data := btcutil.NewSecretBytes(keyLen + 4)
+	defer data.Zero()
 	if isChildHardened {
 		// Case #1.
 		// When the child is a hardened child, the key is known to be a
@@ -282,8 +286,9 @@ func (k *ExtendedKey) Derive(i uint32) (*ExtendedKey, error) {
 	// Split "I" into two 32-byte sequences Il and Ir where:
 	//   Il = intermediate key used to derive the child
 	//   Ir = child chain code
-	il := ilr[:len(ilr)/2]
+	il := btcutil.SecretBytes(ilr[:len(ilr)/2])
 	childChainCode := ilr[len(ilr)/2:]
+	defer il.Zero()
 
 	// Both derived public or private keys rely on treating the left 32-byte
 	// sequence calculated above (Il) as a 256-bit integer that must be
@@ -292,6 +297,7 @@ func (k *ExtendedKey) Derive(i uint32) (*ExtendedKey, error) {
 	// a child extended key can't be created for this index and the caller
 	// should simply increment to the next index.
 	var ilNum btcec.ModNScalar
+	defer ilNum.Zero()
 	if overflow := ilNum.SetByteSlice(il); overflow {
 		return nil, ErrInvalidChild
 	}
@@ -313,6 +319,7 @@ func (k *ExtendedKey) Derive(i uint32) (*ExtendedKey, error) {
 		//
 		// childKey = parse256(Il) + parenKey
 		var keyNum btcec.ModNScalar
+		defer keyNum.Zero()
 		if overflow := keyNum.SetByteSlice(k.key); overflow {
 			return nil, ErrInvalidChild
 		}
@@ -400,8 +407,12 @@ func (k *ExtendedKey) DeriveNonStandard(i uint32) (*ExtendedKey, error) {
 		return nil, ErrDeriveHardFromPublic
 	}
 
+	//
+	// The data holds the parent private key for hardened children, so it
+	// is wiped once the child is derived.
 	keyLen := 33
-	data := make([]byte, keyLen+4)
+	data := btcutil.NewSecretBytes(keyLen + 4)
+	defer data.Zero()
 	if isChildHardened {
 		copy(data[1:], k.key)
 	} else {
@@ -413,8 +424,9 @@ func (k *ExtendedKey) DeriveNonStandard(i uint32) (*ExtendedKey, error) {
 	_, _ = hmac512.Write(data)
 	ilr := hmac512.Sum(nil)
 
-	il := ilr[:len(ilr)/2]
+	il := btcutil.SecretBytes(ilr[:len(ilr)/2])
 	childChainCode := ilr[len(ilr)/2:]
+	defer il.Zero()
 
 	ilNum := new(big.Int).SetBytes(il)
 	if ilNum.Cmp(btcec.S256().N) >= 0 || ilNum.Sign() == 0 {
@@ -581,7 +593,13 @@ func (k *ExtendedKey) String() string {
 	// The serialized format is:
 	//   version (4) || depth (1) || parent fingerprint (4)) ||
 	//   child num (4) || chain code (32) || key data (33) || checksum (4)
-	serializedBytes := make([]byte, 0, serializedKeyLen+4)
+	//
+	// The serialization holds the private key of extended private keys, so
+	// it is wiped once encoded.  Note the returned string is a copy of the
+	// key that can't be wiped.
+	buf := btcutil.NewSecretBytes(serializedKeyLen + 4)
+	defer buf.Zero()
+	serializedBytes := buf[:0]
 	serializedBytes = append(serializedBytes, k.version...)
 	serializedBytes = append(serializedBytes, k.depth)
 	serializedBytes = append(serializedBytes, k.parentFP...)
@@ -616,24 +634,15 @@ func (k *ExtendedKey) SetNet(net *chaincfg.Params) {
 	}
 }
 
-// zero sets all bytes in the passed slice to zero.  This is used to
-// explicitly clear private key material from memory.
-func zero(b []byte) {
-	lenb := len(b)
-	for i := 0; i < lenb; i++ {
-		b[i] = 0
-	}
-}
-
 // Zero manually clears all fields and bytes in the extended key.  This can be
 // used to explicitly clear key material from memory for enhanced security
 // against memory scraping.  This function only clears this particular key and
 // not any children that have already been derived.
 func (k *ExtendedKey) Zero() {
-	zero(k.key)
-	zero(k.pubKey)
-	zero(k.chainCode)
-	zero(k.parentFP)
+	btcutil.SecretBytes(k.key).Zero()
+	btcutil.SecretBytes(k.pubKey).Zero()
+	btcutil.SecretBytes(k.chainCode).Zero()
+	btcutil.SecretBytes(k.parentFP).Zero()
 	k.version = nil
 	k.key = nil
 	k.depth = 0
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+// References:
+//   [BIP39]: BIP0039 - Mnemonic code for generating deterministic keys
+//   https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// MinEntropyBits is the minimum number of bits of entropy encoded by a
+	// mnemonic, which results in 12 words.
+	MinEntropyBits = 128
+
+	// MaxEntropyBits is the maximum number of bits of entropy encoded by a
+	// mnemonic, which results in 24 words.
+	MaxEntropyBits = 256
+
+	// mnemonicSeedIterations is the number of PBKDF2 iterations used to
+	// derive a seed from a mnemonic.
+	mnemonicSeedIterations = 2048
+
+	// mnemonicSeedLen is the length in bytes of the seeds derived from a
+	// mnemonic.
+	mnemonicSeedLen = 64 // 512 bits
+)
+
+var (
+	// ErrInvalidEntropyLen describes an error in which the provided entropy
+	// or entropy length is not a multiple of 32 bits in the allowed range.
+	ErrInvalidEntropyLen = fmt.Errorf("entropy length must be a multiple "+
+		"of 32 bits between %d and %d bits", MinEntropyBits,
+		MaxEntropyBits)
+
+	// ErrInvalidMnemonic describes an error in which a mnemonic does not
+	// have a valid number of words or contains a word that is not in the
+	// word list.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrMnemonicChecksum describes an error in which the checksum encoded
+	// by the last word of a mnemonic does not match the calculated value,
+	// which usually means a word was mistyped or the words were reordered.
+	ErrMnemonicChecksum = errors.New("bad mnemonic checksum")
+)
+
+// englishWordIndex maps the words of the English word list to their position.
+var englishWordIndex = func() map[string]uint16 {
+	index := make(map[string]uint16, len(englishWordList))
+	for i, word := range englishWordList {
+		index[word] = uint16(i)
+	}
+	return index
+}()
+
+// GenerateEntropy returns a cryptographically secure random entropy of the
+// passed number of bits, which must be a multiple of 32 between MinEntropyBits
+// and MaxEntropyBits, for use with NewMnemonic.
+func GenerateEntropy(bits int) ([]byte, error) {
+	if bits < MinEntropyBits || bits > MaxEntropyBits || bits%32 != 0 {
+		return nil, ErrInvalidEntropyLen
+	}
+
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// NewMnemonic returns the mnemonic that encodes the passed entropy as a
+// sentence of English words separated by single spaces, as defined by [BIP39].
+// Each word encodes 11 bits of the entropy followed by a checksum of one bit
+// for every 32 bits of entropy.
+func NewMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits < MinEntropyBits || bits > MaxEntropyBits || bits%32 != 0 {
+		return "", ErrInvalidEntropyLen
+	}
+
+	// The checksum is the first bits of the SHA-256 of the entropy, which
+	// fit in a single byte.
+	hash := sha256.Sum256(entropy)
+	data := btcutil.NewSecretBytes(len(entropy) + 1)
+	defer data.Zero()
+	copy(data, entropy)
+	data[len(entropy)] = hash[0]
+
+	words := make([]string, (bits+bits/32)/11)
+	for i := range words {
+		var index int
+		for j := 0; j < 11; j++ {
+			bit := i*11 + j
+			index = index<<1 | int(data[bit/8]>>(7-bit%8)&1)
+		}
+		words[i] = englishWordList[index]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy returns the entropy encoded by the passed mnemonic after
+// verifying its checksum.  The words may be separated by any whitespace.
+//
+// ErrInvalidMnemonic is returned when the mnemonic does not consist of 12, 15,
+// 18, 21 or 24 words of the English word list, and ErrMnemonicChecksum when its
+// checksum does not match.  The errors don't include the words, so they are
+// safe to log.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	numWords := len(words)
+	if numWords < MinEntropyBits*33/32/11 ||
+		numWords > MaxEntropyBits*33/32/11 || numWords%3 != 0 {
+
+		return nil, fmt.Errorf("%w: %d words", ErrInvalidMnemonic,
+			numWords)
+	}
+
+	// Concatenate the 11 bits encoded by each word.
+	data := btcutil.NewSecretBytes((numWords*11 + 7) / 8)
+	defer data.Zero()
+	for i, word := range words {
+		index, ok := englishWordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word at position %d",
+				ErrInvalidMnemonic, i+1)
+		}
+		for j := 0; j < 11; j++ {
+			if index>>(10-j)&1 == 1 {
+				bit := i*11 + j
+				data[bit/8] |= 1 << (7 - bit%8)
+			}
+		}
+	}
+
+	// The entropy is followed by one bit of checksum for every 32 bits.
+	bits := numWords * 11 * 32 / 33
+	checksumBits := bits / 32
+	entropy := make([]byte, bits/8)
+	copy(entropy, data)
+	hash := sha256.Sum256(entropy)
+	if data[bits/8]>>(8-checksumBits) != hash[0]>>(8-checksumBits) {
+		btcutil.SecretBytes(entropy).Zero()
+		return nil, ErrMnemonicChecksum
+	}
+
+	return entropy, nil
+}
+
+// ValidateMnemonic returns an error when the passed mnemonic is not a valid
+// [BIP39] mnemonic.  See MnemonicToEntropy for the errors that are returned.
+func ValidateMnemonic(mnemonic string) error {
+	entropy, err := MnemonicToEntropy(mnemonic)
+	if err != nil {
+		return err
+	}
+	btcutil.SecretBytes(entropy).Zero()
+	return nil
+}
+
+// NewSeedFromMnemonic returns the 512-bit seed derived from the passed mnemonic
+// and passphrase, which may be empty, as defined by [BIP39].  The mnemonic is
+// validated first, so mistyped mnemonics are not silently turned into the
+// seed of an unrelated wallet.
+//
+// [BIP39] requires the passphrase to be normalized to Unicode NFKD form, which
+// is left to the caller.  The passphrase is used as is, which only matters for
+// passphrases with characters outside of ASCII.
+func NewSeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	// The words are joined by single spaces so the seed doesn't depend on
+	// the whitespace used to separate them.
+	sentence := []byte(strings.Join(strings.Fields(mnemonic), " "))
+	defer btcutil.SecretBytes(sentence).Zero()
+	salt := []byte("mnemonic" + passphrase)
+	defer btcutil.SecretBytes(salt).Zero()
+
+	return pbkdf2.Key(sentence, salt, mnemonicSeedIterations,
+		mnemonicSeedLen, sha512.New), nil
+}
+
+// NewMasterFromMnemonic returns the master node for the seed derived from the
+// passed mnemonic and passphrase.  See NewSeedFromMnemonic and NewMaster for
+// more details.
+func NewMasterFromMnemonic(mnemonic, passphrase string,
+	net *chaincfg.Params) (*ExtendedKey, error) {
+
+	seed, err := NewSeedFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer btcutil.SecretBytes(seed).Zero()
+
+	return NewMaster(seed, net)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestMnemonic ensures mnemonics and seeds are created from entropy as
+// expected using the test vectors of [BIP39], which all use the passphrase
+// "TREZOR".
+func TestMnemonic(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "80808080808080808080808080808080",
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+
+	for i, test := range tests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Errorf("#%d NewMnemonic: unexpected error: %v", i, err)
+			continue
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("#%d NewMnemonic: got %q, want %q", i, mnemonic,
+				test.mnemonic)
+			continue
+		}
+
+		gotEntropy, err := MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Errorf("#%d MnemonicToEntropy: unexpected error: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(gotEntropy, entropy) {
+			t.Errorf("#%d MnemonicToEntropy: got %x, want %x", i,
+				gotEntropy, entropy)
+			continue
+		}
+
+		if test.seed == "" {
+			continue
+		}
+		seed, err := NewSeedFromMnemonic(mnemonic, "TREZOR")
+		if err != nil {
+			t.Errorf("#%d NewSeedFromMnemonic: unexpected error: %v", i, err)
+			continue
+		}
+		if got := hex.EncodeToString(seed); got != test.seed {
+			t.Errorf("#%d NewSeedFromMnemonic: got %s, want %s", i, got,
+				test.seed)
+		}
+	}
+
+	// The master node is derived from the seed, regardless of the
+	// whitespace separating the words.
+	mnemonic := strings.Repeat("abandon\t ", 11) + "about\n"
+	master, err := NewMasterFromMnemonic(mnemonic, "TREZOR",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMasterFromMnemonic: unexpected error: %v", err)
+	}
+	want := "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF"
+	if got := master.String(); got != want {
+		t.Fatalf("NewMasterFromMnemonic: got %s, want %s", got, want)
+	}
+}
+
+// TestMnemonicErrors ensures invalid entropy and mnemonics are rejected with
+// the expected errors.
+func TestMnemonicErrors(t *testing.T) {
+	for _, bits := range []int{0, 96, 120, 160 + 8, 288} {
+		if _, err := GenerateEntropy(bits); err != ErrInvalidEntropyLen {
+			t.Errorf("GenerateEntropy(%d): got error %v, want %v", bits,
+				err, ErrInvalidEntropyLen)
+		}
+		if _, err := NewMnemonic(make([]byte, bits/8)); err != ErrInvalidEntropyLen {
+			t.Errorf("NewMnemonic(%d bits): got error %v, want %v", bits,
+				err, ErrInvalidEntropyLen)
+		}
+	}
+
+	tests := []struct {
+		mnemonic string
+		err      error
+	}{
+		{"", ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 11), ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 13), ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 27), ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 11) + "bitcoin", ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 11) + "About", ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 12), ErrMnemonicChecksum},
+		{"legal winner thank year wave sausage worth useful legal winner yellow thank", ErrMnemonicChecksum},
+		{strings.Repeat("zoo ", 23) + "zoo", ErrMnemonicChecksum},
+	}
+	for i, test := range tests {
+		if err := ValidateMnemonic(test.mnemonic); !errors.Is(err, test.err) {
+			t.Errorf("#%d ValidateMnemonic: got error %v, want %v", i, err,
+				test.err)
+		}
+		if _, err := NewSeedFromMnemonic(test.mnemonic, ""); !errors.Is(err, test.err) {
+			t.Errorf("#%d NewSeedFromMnemonic: got error %v, want %v", i,
+				err, test.err)
+		}
+	}
+
+	// Generated entropy round trips through its mnemonic.
+	for bits := MinEntropyBits; bits <= MaxEntropyBits; bits += 32 {
+		entropy, err := GenerateEntropy(bits)
+		if err != nil {
+			t.Fatalf("GenerateEntropy(%d): unexpected error: %v", bits, err)
+		}
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("NewMnemonic: unexpected error: %v", err)
+		}
+		if n := len(strings.Fields(mnemonic)); n != bits*33/32/11 {
+			t.Fatalf("NewMnemonic: got %d words for %d bits", n, bits)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Fatalf("ValidateMnemonic(%q): unexpected error: %v",
+				mnemonic, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath describes an error in which a derivation path is not of the
+// form "m/44'/5'/0'/0/0".
+var ErrInvalidPath = errors.New("invalid derivation path")
+
+// ParsePath parses a derivation path such as "m/44'/5'/0'/0/0" into the child
+// indexes to pass to Derive, from the first to the last.  The leading "m" of the
+// master node is optional and hardened indexes are marked with a trailing "'",
+// "h" or "H".  Each index, excluding the mark, must be less than
+// HardenedKeyStart.  The path "m" is the master node itself and results in no
+// indexes.
+func ParsePath(path string) ([]uint32, error) {
+	elems := strings.Split(path, "/")
+	if elems[0] == "m" || elems[0] == "M" {
+		elems = elems[1:]
+	}
+
+	indexes := make([]uint32, 0, len(elems))
+	for _, elem := range elems {
+		hardened := false
+		if n := len(elem) - 1; n > 0 && (elem[n] == '\'' ||
+			elem[n] == 'h' || elem[n] == 'H') {
+
+			elem = elem[:n]
+			hardened = true
+		}
+
+		// Reject signs, which ParseUint does not, and indexes that
+		// are already in the hardened range.
+		if elem == "" || elem[0] < '0' || elem[0] > '9' {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPath, path)
+		}
+		index, err := strconv.ParseUint(elem, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPath, path)
+		}
+
+		if hardened {
+			index += HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
+
+// FormatPath returns the derivation path for the passed child indexes in the
+// form parsed by ParsePath, with hardened indexes marked with a trailing "'".
+func FormatPath(indexes []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range indexes {
+		b.WriteByte('/')
+		if index >= HardenedKeyStart {
+			b.WriteString(strconv.FormatUint(uint64(index-HardenedKeyStart), 10))
+			b.WriteByte('\'')
+			continue
+		}
+		b.WriteString(strconv.FormatUint(uint64(index), 10))
+	}
+	return b.String()
+}
+
+// DerivePath returns the extended key at the passed derivation path relative
+// to the extended key, which is usually the master node, such as
+// "m/44'/5'/0'/0/0" for the first receiving address of the first account of a
+// BIP0044 Dash wallet.  See ParsePath for the accepted forms of the path.
+//
+// The same errors as Derive may be returned for any of the children along the
+// path, including ErrDeriveHardFromPublic when the path contains a hardened
+// index and the extended key is public.
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	child := k
+	for _, index := range indexes {
+		child, err = child.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
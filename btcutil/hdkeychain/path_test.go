@@ -0,0 +1,116 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestParsePath ensures derivation paths are parsed and formatted as expected.
+func TestParsePath(t *testing.T) {
+	hkStart := uint32(HardenedKeyStart)
+
+	tests := []struct {
+		path    string
+		indexes []uint32
+		format  string
+	}{
+		{"m", []uint32{}, "m"},
+		{"m/0", []uint32{0}, "m/0"},
+		{"m/44'/5'/0'/0/0", []uint32{hkStart + 44, hkStart + 5, hkStart, 0, 0},
+			"m/44'/5'/0'/0/0"},
+		{"m/0H/1/2h/2/1000000000", []uint32{hkStart, 1, hkStart + 2, 2,
+			1000000000}, "m/0'/1/2'/2/1000000000"},
+		{"M/2147483647'", []uint32{^uint32(0)}, "m/2147483647'"},
+		{"1/2", []uint32{1, 2}, "m/1/2"},
+	}
+	for _, test := range tests {
+		indexes, err := ParsePath(test.path)
+		if err != nil {
+			t.Errorf("ParsePath(%q): unexpected error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(indexes, test.indexes) {
+			t.Errorf("ParsePath(%q): got %v, want %v", test.path, indexes,
+				test.indexes)
+			continue
+		}
+		if format := FormatPath(indexes); format != test.format {
+			t.Errorf("FormatPath(%v): got %q, want %q", indexes, format,
+				test.format)
+		}
+	}
+
+	invalid := []string{"", "m/", "m//0", "m/0/", "m/-1", "m/+1", "m/'",
+		"m/2147483648", "m/2147483648'", "m/4294967296", "m/1x", "m/0''",
+		"x/0", "m/m/0"}
+	for _, path := range invalid {
+		if _, err := ParsePath(path); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("ParsePath(%q): got error %v, want %v", path, err,
+				ErrInvalidPath)
+		}
+	}
+}
+
+// TestDerivePath ensures extended keys are derived along derivation paths as
+// expected.
+func TestDerivePath(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+
+	// Test vector 1 of [BIP32].
+	child, err := master.DerivePath("m/0'/1/2'/2/1000000000")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	want := "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"
+	if got := child.String(); got != want {
+		t.Fatalf("DerivePath: got %s, want %s", got, want)
+	}
+
+	// The master node itself is returned for the path "m".
+	if child, err := master.DerivePath("m"); err != nil || child != master {
+		t.Fatalf("DerivePath: unexpected result %v, %v", child, err)
+	}
+
+	// Hardened indexes can't be derived from a public extended key.
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	if _, err := pub.DerivePath("m/44'/5'"); err != ErrDeriveHardFromPublic {
+		t.Fatalf("DerivePath: got error %v, want %v", err,
+			ErrDeriveHardFromPublic)
+	}
+	if _, err := pub.DerivePath("m/x"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("DerivePath: got error %v, want %v", err, ErrInvalidPath)
+	}
+
+	// Extended keys of the Dash test network serialize with the tprv and
+	// tpub versions.
+	master.SetNet(&chaincfg.TestNet3Params)
+	account, err := master.DerivePath("m/44'/1'/0'")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	accountPub, err := account.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	if s := account.String(); s[:4] != "tprv" {
+		t.Fatalf("String: got %s, want tprv prefix", s)
+	}
+	if s := accountPub.String(); s[:4] != "tpub" {
+		t.Fatalf("String: got %s, want tpub prefix", s)
+	}
+}
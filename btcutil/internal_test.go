@@ -15,6 +15,7 @@ import (
 	"github.com/dashpay/dashd-go/btcec/v2"
 	"github.com/dashpay/dashd-go/btcutil/base58"
 	"github.com/dashpay/dashd-go/btcutil/bech32"
+	"github.com/dashpay/dashd-go/chaincfg"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -31,6 +32,20 @@ func TstAppDataDir(goos, appName string, roaming bool) string {
 	return appDataDir(goos, appName, roaming)
 }
 
+// TstDashCoreDataDir makes the internal dashCoreDataDir function available to
+// the test package.
+func TstDashCoreDataDir(goos string, roaming bool) string {
+	return dashCoreDataDir(goos, roaming)
+}
+
+// TstBlockSubsidy makes the internal blockSubsidy function available to the
+// test package.
+func TstBlockSubsidy(prevHeight int32, prevBits uint32,
+	params *chaincfg.Params) (Amount, Amount) {
+
+	return blockSubsidy(prevHeight, prevBits, params)
+}
+
 // TstAddressPubKeyHash makes an AddressPubKeyHash, setting the
 // unexported fields with the parameters hash and netID.
 func TstAddressPubKeyHash(hash [ripemd160.Size]byte,
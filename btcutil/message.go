@@ -0,0 +1,84 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+
+	"github.com/dashpay/dashd-go/btcec/v2/ecdsa"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// MessageSignatureHeader is the text prepended to messages before they are
+// signed to signify that a signed message follows and to prevent inadvertently
+// signing a transaction.  Dash Core kept the header of its predecessor.
+const MessageSignatureHeader = "DarkCoin Signed Message:\n"
+
+// ErrMessageAddressType describes an error where a message signature is
+// verified against an address which is not a pay-to-pubkey-hash address.  The
+// signatures only commit to public keys, so other addresses can't be verified.
+var ErrMessageAddressType = errors.New("address is not a pay-to-pubkey-hash " +
+	"address")
+
+// MessageHash returns the hash that is signed for the passed message, which is
+// the double SHA-256 of the MessageSignatureHeader and the message serialized
+// as variable length strings.
+func MessageHash(message string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, MessageSignatureHeader)
+	_ = wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// SignMessage signs the passed message with the private key of the WIF and
+// returns the base64 encoded compact signature, which is compatible with the
+// signmessage and verifymessage RPCs of Dash Core.
+func SignMessage(wif *WIF, message string) (string, error) {
+	sig, err := ecdsa.SignCompact(wif.PrivKey, MessageHash(message),
+		wif.CompressPubKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyMessage returns whether or not the passed base64 encoded signature is a
+// valid signature of the message by the key of the passed pay-to-pubkey-hash
+// address, such as created by SignMessage or the signmessage RPC of Dash Core.
+//
+// ErrMessageAddressType is returned for other addresses and an error is
+// returned when the signature is not valid base64.  Signatures from which no
+// public key can be recovered are reported as invalid rather than as errors,
+// like Dash Core does.
+func VerifyMessage(addr Address, signature, message string) (bool, error) {
+	pkHashAddr, ok := addr.(*AddressPubKeyHash)
+	if !ok {
+		return false, ErrMessageAddressType
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, err
+	}
+
+	pubKey, wasCompressed, err := ecdsa.RecoverCompact(sig,
+		MessageHash(message))
+	if err != nil {
+		return false, nil
+	}
+
+	// The signature records whether the address was derived from the
+	// compressed or the uncompressed public key.
+	var serializedPubKey []byte
+	if wasCompressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	} else {
+		serializedPubKey = pubKey.SerializeUncompressed()
+	}
+	return bytes.Equal(Hash160(serializedPubKey), pkHashAddr.ScriptAddress()), nil
+}
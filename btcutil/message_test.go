@@ -0,0 +1,95 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	. "github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestSignVerifyMessage ensures messages are hashed with the Dash message
+// header and that signatures verify against the address of the signing key.
+func TestSignVerifyMessage(t *testing.T) {
+	const message = "Hello, Dash!"
+	want, _ := hex.DecodeString("28b668d9eda4d866797688984bb5236c9a979d9bc5f5bb6d6ba13b238896a0e9")
+	if hash := MessageHash(message); !bytes.Equal(hash, want) {
+		t.Fatalf("MessageHash: got %x, want %x", hash, want)
+	}
+
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	otherKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	net := &chaincfg.MainNetParams
+
+	for _, compress := range []bool{true, false} {
+		wif, err := NewWIF(privKey, net, compress)
+		if err != nil {
+			t.Fatalf("NewWIF: unexpected error: %v", err)
+		}
+		addr, err := NewAddressPubKeyHash(Hash160(wif.SerializePubKey()), net)
+		if err != nil {
+			t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+		}
+
+		sig, err := SignMessage(wif, message)
+		if err != nil {
+			t.Fatalf("SignMessage: unexpected error: %v", err)
+		}
+		valid, err := VerifyMessage(addr, sig, message)
+		if err != nil || !valid {
+			t.Fatalf("VerifyMessage (compress %v): got %v, %v, want "+
+				"valid", compress, valid, err)
+		}
+
+		// The signature must not verify for a different message.
+		valid, err = VerifyMessage(addr, sig, message+"!")
+		if err != nil || valid {
+			t.Fatalf("VerifyMessage: modified message verified: %v, %v",
+				valid, err)
+		}
+
+		// The signature must not verify for the address of the same key
+		// with the other compression.
+		otherWIF, _ := NewWIF(privKey, net, !compress)
+		otherAddr, _ := NewAddressPubKeyHash(
+			Hash160(otherWIF.SerializePubKey()), net)
+		valid, err = VerifyMessage(otherAddr, sig, message)
+		if err != nil || valid {
+			t.Fatalf("VerifyMessage: other compression verified: %v, %v",
+				valid, err)
+		}
+	}
+
+	wif, _ := NewWIF(privKey, net, true)
+	addr, _ := NewAddressPubKeyHash(Hash160(wif.SerializePubKey()), net)
+
+	// Signatures of other keys and garbage are invalid rather than errors.
+	otherWIF, _ := NewWIF(otherKey, net, true)
+	otherSig, _ := SignMessage(otherWIF, message)
+	garbage := base64.StdEncoding.EncodeToString(make([]byte, 65))
+	for _, sig := range []string{otherSig, garbage, ""} {
+		valid, err := VerifyMessage(addr, sig, message)
+		if err != nil || valid {
+			t.Fatalf("VerifyMessage(%q): got %v, %v, want invalid", sig,
+				valid, err)
+		}
+	}
+
+	// Malformed signatures and other address types are errors.
+	if _, err := VerifyMessage(addr, "not base64!", message); err == nil {
+		t.Fatal("VerifyMessage: expected error for malformed signature")
+	}
+	scriptAddr, _ := NewAddressScriptHash([]byte{0x51}, net)
+	sig, _ := SignMessage(wif, message)
+	if _, err := VerifyMessage(scriptAddr, sig, message); err != ErrMessageAddressType {
+		t.Fatalf("VerifyMessage: got error %v, want %v", err,
+			ErrMessageAddressType)
+	}
+}
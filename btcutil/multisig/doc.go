@@ -0,0 +1,22 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package multisig provides helpers for deriving multi-signature addresses
+according to BIP 67.
+
+# Overview
+
+Multi-signature redeem scripts commit to the order of their public keys, so
+cosigners that combine the same keys in a different order end up with
+different scripts and therefore different pay-to-script-hash addresses.  BIP 67
+removes that ambiguity by requiring compressed public keys sorted
+lexicographically by their serialized form.
+
+The functions in this package sort the keys of all cosigners, build the
+resulting bare multi-signature redeem script and derive its pay-to-script-hash
+address, so every cosigner independently arrives at the same address regardless
+of the order in which the keys were exchanged.
+*/
+package multisig
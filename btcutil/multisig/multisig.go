@@ -0,0 +1,95 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Provides functions for building multi-signature redeem scripts and addresses
+// according to BIP 67
+// (https://github.com/bitcoin/bips/blob/master/bip-0067.mediawiki)
+
+package multisig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/txscript"
+)
+
+// ErrUncompressedPubKey is returned when a public key is not in the compressed
+// format required by BIP 67.
+var ErrUncompressedPubKey = errors.New("BIP 67 requires compressed public keys")
+
+// checkCompressed returns ErrUncompressedPubKey, wrapped with the index of the
+// offending key, if any of the passed public keys is not compressed.
+func checkCompressed(pubKeys []*btcutil.AddressPubKey) error {
+	for i, pubKey := range pubKeys {
+		if pubKey.Format() != btcutil.PKFCompressed {
+			return fmt.Errorf("public key %d: %w", i,
+				ErrUncompressedPubKey)
+		}
+	}
+	return nil
+}
+
+// SortPubKeys returns a copy of the passed public keys sorted according to
+// BIP 67, which is lexicographically by their compressed serialization.  The
+// passed slice is not modified.
+func SortPubKeys(pubKeys []*btcutil.AddressPubKey) ([]*btcutil.AddressPubKey, error) {
+	if err := checkCompressed(pubKeys); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*btcutil.AddressPubKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.Sort(sortablePubKeys(sorted))
+	return sorted, nil
+}
+
+// IsSorted returns whether the passed public keys are compressed and sorted
+// according to BIP 67.
+func IsSorted(pubKeys []*btcutil.AddressPubKey) bool {
+	return checkCompressed(pubKeys) == nil &&
+		sort.IsSorted(sortablePubKeys(pubKeys))
+}
+
+// RedeemScript returns the bare multi-signature script requiring nRequired of
+// the passed public keys, which are first sorted according to BIP 67.  The
+// errors returned by txscript.MultiSigScript apply for invalid key counts.
+func RedeemScript(nRequired int, pubKeys []*btcutil.AddressPubKey) ([]byte, error) {
+	sorted, err := SortPubKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.MultiSigScript(sorted, nRequired)
+}
+
+// Address returns the pay-to-script-hash address for the network described by
+// the passed parameters along with the redeem script requiring nRequired of
+// the passed public keys, which are first sorted according to BIP 67.
+func Address(nRequired int, pubKeys []*btcutil.AddressPubKey,
+	params *chaincfg.Params) (*btcutil.AddressScriptHash, []byte, error) {
+
+	redeemScript, err := RedeemScript(nRequired, pubKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	addr, err := btcutil.NewAddressScriptHash(redeemScript, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return addr, redeemScript, nil
+}
+
+// sortablePubKeys implements sort.Interface to sort public keys by their
+// serialized form.
+type sortablePubKeys []*btcutil.AddressPubKey
+
+func (s sortablePubKeys) Len() int      { return len(s) }
+func (s sortablePubKeys) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortablePubKeys) Less(i, j int) bool {
+	return bytes.Compare(s[i].ScriptAddress(), s[j].ScriptAddress()) < 0
+}
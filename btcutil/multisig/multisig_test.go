@@ -0,0 +1,168 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package multisig_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/btcutil/multisig"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// parsePubKeys decodes the passed hex-encoded public keys into addresses.
+func parsePubKeys(t *testing.T, hexKeys []string) []*btcutil.AddressPubKey {
+	t.Helper()
+	pubKeys := make([]*btcutil.AddressPubKey, 0, len(hexKeys))
+	for _, hexKey := range hexKeys {
+		serialized, err := hex.DecodeString(hexKey)
+		if err != nil {
+			t.Fatalf("unable to decode key %s: %v", hexKey, err)
+		}
+		pubKey, err := btcutil.NewAddressPubKey(serialized,
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("unable to parse key %s: %v", hexKey, err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys
+}
+
+// TestBIP67 ensures the redeem script and address are derived according to
+// the test vectors in BIP 67 and that they don't depend on the order of the
+// passed keys.
+func TestBIP67(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		nRequired int
+		keys      []string
+		sorted    []string
+		script    string
+	}{
+		{
+			name:      "unsorted 2-of-2",
+			nRequired: 2,
+			keys: []string{
+				"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+				"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+			},
+			sorted: []string{
+				"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+				"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+			},
+			script: "522102fe6f0a5a297eb38c391581c4413e084773ea23954d93" +
+				"f7753db7dc0adc188b2f2102ff12471208c14bd580709cb2358d" +
+				"98975247d8765f92bc25eab3b2763ed605f852ae",
+		},
+	}
+
+	for _, test := range tests {
+		pubKeys := parsePubKeys(t, test.keys)
+		wantSorted := parsePubKeys(t, test.sorted)
+
+		sorted, err := multisig.SortPubKeys(pubKeys)
+		if err != nil {
+			t.Fatalf("%s: SortPubKeys: unexpected error: %v", test.name,
+				err)
+		}
+		for i := range sorted {
+			if !bytes.Equal(sorted[i].ScriptAddress(),
+				wantSorted[i].ScriptAddress()) {
+
+				t.Fatalf("%s: key %d - got %s, want %s", test.name,
+					i, sorted[i], wantSorted[i])
+			}
+		}
+		if !multisig.IsSorted(sorted) {
+			t.Fatalf("%s: sorted keys not reported as sorted",
+				test.name)
+		}
+		if multisig.IsSorted(pubKeys) {
+			t.Fatalf("%s: unsorted keys reported as sorted",
+				test.name)
+		}
+
+		// The passed keys must not be modified.
+		if !bytes.Equal(pubKeys[0].ScriptAddress(),
+			parsePubKeys(t, test.keys[:1])[0].ScriptAddress()) {
+
+			t.Fatalf("%s: SortPubKeys modified its input", test.name)
+		}
+
+		addr, script, err := multisig.Address(test.nRequired, pubKeys,
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: Address: unexpected error: %v", test.name, err)
+		}
+		if got := hex.EncodeToString(script); got != test.script {
+			t.Fatalf("%s: wrong redeem script - got %s, want %s",
+				test.name, got, test.script)
+		}
+		wantAddr, err := btcutil.NewAddressScriptHash(script,
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: NewAddressScriptHash: %v", test.name, err)
+		}
+		if addr.EncodeAddress() != wantAddr.EncodeAddress() {
+			t.Fatalf("%s: wrong address - got %s, want %s", test.name,
+				addr, wantAddr)
+		}
+
+		// Cosigners combining the keys in the already sorted order
+		// must derive the same address.
+		addr2, _, err := multisig.Address(test.nRequired, wantSorted,
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: Address: unexpected error: %v", test.name, err)
+		}
+		if addr2.EncodeAddress() != addr.EncodeAddress() {
+			t.Fatalf("%s: address depends on key order - got %s, "+
+				"want %s", test.name, addr2, addr)
+		}
+	}
+}
+
+// TestUncompressedPubKey ensures uncompressed public keys are rejected.
+func TestUncompressedPubKey(t *testing.T) {
+	t.Parallel()
+
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{1}, 32))
+	uncompressed, err := btcutil.NewAddressPubKey(
+		privKey.PubKey().SerializeUncompressed(), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressPubKey: unexpected error: %v", err)
+	}
+	compressed, err := btcutil.NewAddressPubKey(
+		privKey.PubKey().SerializeCompressed(), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressPubKey: unexpected error: %v", err)
+	}
+	pubKeys := []*btcutil.AddressPubKey{compressed, uncompressed}
+
+	_, err = multisig.SortPubKeys(pubKeys)
+	if !errors.Is(err, multisig.ErrUncompressedPubKey) {
+		t.Fatalf("SortPubKeys: unexpected error: %v", err)
+	}
+	_, _, err = multisig.Address(1, pubKeys, &chaincfg.MainNetParams)
+	if !errors.Is(err, multisig.ErrUncompressedPubKey) {
+		t.Fatalf("Address: unexpected error: %v", err)
+	}
+	if multisig.IsSorted(pubKeys) {
+		t.Fatal("uncompressed keys reported as sorted")
+	}
+
+	// Invalid key counts are still reported.
+	_, err = multisig.RedeemScript(2, pubKeys[:1])
+	if err == nil {
+		t.Fatal("RedeemScript: expected error for too many required sigs")
+	}
+}
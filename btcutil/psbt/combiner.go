@@ -0,0 +1,163 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"errors"
+)
+
+// ErrCombineMismatch indicates that the packets passed to Combine are not for
+// the same unsigned transaction.
+var ErrCombineMismatch = errors.New("Cannot combine PSBTs for different " +
+	"unsigned transactions")
+
+// Combine merges the key-value pairs of the passed packets, which must all be
+// for the same unsigned transaction including its DIP0002 extra payload, into
+// a new packet.  Referencing the PSBT BIP, this function serves the role of the
+// Combiner.
+//
+// Signatures, BIP32 derivations and unknowns are merged by their key.  All
+// other fields are taken from the first packet that has them set.  The
+// returned packet may share the values of these fields with the passed
+// packets.
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, ErrInvalidPsbtFormat
+	}
+
+	first := packets[0]
+	txHash := first.UnsignedTx.TxHash()
+	for _, p := range packets {
+		if p.UnsignedTx.TxHash() != txHash {
+			return nil, ErrCombineMismatch
+		}
+		if len(p.Inputs) != len(p.UnsignedTx.TxIn) ||
+			len(p.Outputs) != len(p.UnsignedTx.TxOut) {
+
+			return nil, ErrInvalidPsbtFormat
+		}
+	}
+
+	combined := &Packet{
+		UnsignedTx: first.UnsignedTx.Copy(),
+		Inputs:     make([]PInput, len(first.Inputs)),
+		Outputs:    make([]POutput, len(first.Outputs)),
+	}
+	for _, p := range packets {
+		for i := range p.Inputs {
+			combineInput(&combined.Inputs[i], &p.Inputs[i])
+		}
+		for i := range p.Outputs {
+			combineOutput(&combined.Outputs[i], &p.Outputs[i])
+		}
+		for _, u := range p.Unknowns {
+			if !hasUnknown(combined.Unknowns, u.Key) {
+				combined.Unknowns = append(combined.Unknowns, u)
+			}
+		}
+	}
+
+	return combined, nil
+}
+
+// combineInput merges the fields of the src input into the dst input.
+func combineInput(dst, src *PInput) {
+	if dst.NonWitnessUtxo == nil {
+		dst.NonWitnessUtxo = src.NonWitnessUtxo
+	}
+	if dst.WitnessUtxo == nil {
+		dst.WitnessUtxo = src.WitnessUtxo
+	}
+	if dst.SighashType == 0 {
+		dst.SighashType = src.SighashType
+	}
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	if dst.FinalScriptSig == nil {
+		dst.FinalScriptSig = src.FinalScriptSig
+	}
+	if dst.FinalScriptWitness == nil {
+		dst.FinalScriptWitness = src.FinalScriptWitness
+	}
+
+	for _, sig := range src.PartialSigs {
+		if !hasPartialSig(dst.PartialSigs, sig.PubKey) {
+			dst.PartialSigs = append(dst.PartialSigs, sig)
+		}
+	}
+	dst.Bip32Derivation = combineBip32Derivations(dst.Bip32Derivation,
+		src.Bip32Derivation)
+	for _, u := range src.Unknowns {
+		if !hasUnknownPtr(dst.Unknowns, u.Key) {
+			dst.Unknowns = append(dst.Unknowns, u)
+		}
+	}
+}
+
+// combineOutput merges the fields of the src output into the dst output.
+func combineOutput(dst, src *POutput) {
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	dst.Bip32Derivation = combineBip32Derivations(dst.Bip32Derivation,
+		src.Bip32Derivation)
+}
+
+// combineBip32Derivations returns dst with the derivations of src for public
+// keys it does not have yet appended.
+func combineBip32Derivations(dst, src []*Bip32Derivation) []*Bip32Derivation {
+	for _, d := range src {
+		found := false
+		for _, x := range dst {
+			if string(x.PubKey) == string(d.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+// hasPartialSig returns whether or not the passed signatures contain one for
+// the passed public key.
+func hasPartialSig(sigs []*PartialSig, pubKey []byte) bool {
+	for _, sig := range sigs {
+		if string(sig.PubKey) == string(pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnknown returns whether or not the passed unknowns contain the passed key.
+func hasUnknown(unknowns []Unknown, key []byte) bool {
+	for _, u := range unknowns {
+		if string(u.Key) == string(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnknownPtr returns whether or not the passed unknowns contain the passed
+// key.
+func hasUnknownPtr(unknowns []*Unknown, key []byte) bool {
+	for _, u := range unknowns {
+		if string(u.Key) == string(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -61,3 +61,25 @@ func New(inputs []*wire.OutPoint,
 		Unknowns:   nil,
 	}, nil
 }
+
+// NewSpecial returns a new PSBT packet for a DIP0002 special transaction of
+// the passed type carrying the passed extra payload, such as a masternode
+// registration.  The transaction version is set to wire.SpecialTxVersion and
+// the remaining parameters are the same as for New.  The extra payload is part
+// of the unsigned transaction and therefore committed to by all signatures.
+func NewSpecial(inputs []*wire.OutPoint, outputs []*wire.TxOut,
+	txType wire.TxType, payload []byte, nLockTime uint32,
+	nSequences []uint32) (*Packet, error) {
+
+	if txType == wire.TxTypeNormal {
+		return nil, ErrInvalidPsbtFormat
+	}
+
+	version := int32(txType)<<16 | wire.SpecialTxVersion
+	p, err := New(inputs, outputs, version, nLockTime, nSequences)
+	if err != nil {
+		return nil, err
+	}
+	p.UnsignedTx.ExtraPayload = payload
+	return p, nil
+}
@@ -5,6 +5,9 @@
 // Package psbt is an implementation of Partially Signed Bitcoin
 // Transactions (PSBT). The format is defined in BIP 174:
 // https://github.com/bitcoin/bips/blob/master/bip-0174.mediawiki
+//
+// The unsigned transaction may be a DIP0002 special transaction, whose extra
+// payload is serialized along with it and committed to by the signatures.
 package psbt
 
 import (
@@ -11,7 +11,9 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/dashpay/dashd-go/btcec/v2"
 	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 	"github.com/dashpay/dashd-go/txscript"
 	"github.com/dashpay/dashd-go/wire"
@@ -1446,3 +1448,150 @@ func TestWitnessForNonWitnessUtxo(t *testing.T) {
 		t.Fatalf("unable to extract funding TX: %v", err)
 	}
 }
+
+// TestSpecialTxCombine ensures PSBTs for DIP0002 special transactions can be
+// created, signed by different parties, combined, finalized and extracted
+// with the extra payload committed to by the signatures.
+func TestSpecialTxCombine(t *testing.T) {
+	// Create a previous transaction paying to two different keys.
+	key1, pub1 := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	key2, pub2 := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(&wire.TxIn{})
+	var pkScripts [][]byte
+	for _, pub := range []*btcec.PublicKey{pub1, pub2} {
+		addr, err := btcutil.NewAddressPubKeyHash(
+			btcutil.Hash160(pub.SerializeCompressed()),
+			&chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("unable to create address: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("unable to create script: %v", err)
+		}
+		pkScripts = append(pkScripts, pkScript)
+		prevTx.AddTxOut(wire.NewTxOut(btcutil.SatoshiPerBitcoin, pkScript))
+	}
+	prevHash := prevTx.TxHash()
+
+	payload := []byte{0x02, 0x00, 0xaa, 0xbb}
+	packet, err := NewSpecial(
+		[]*wire.OutPoint{{Hash: prevHash, Index: 0}, {Hash: prevHash, Index: 1}},
+		[]*wire.TxOut{wire.NewTxOut(btcutil.SatoshiPerBitcoin, pkScripts[0])},
+		wire.TxTypeProUpdateService, payload, 0,
+		[]uint32{wire.MaxTxInSequenceNum, wire.MaxTxInSequenceNum},
+	)
+	if err != nil {
+		t.Fatalf("unable to create special PSBT: %v", err)
+	}
+	if _, err := NewSpecial(nil, nil, wire.TxTypeNormal, nil, 0, nil); err != ErrInvalidPsbtFormat {
+		t.Fatalf("NewSpecial: got error %v, want %v", err,
+			ErrInvalidPsbtFormat)
+	}
+
+	// The extra payload must survive serialization.
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		t.Fatalf("unable to serialize PSBT: %v", err)
+	}
+	raw := buf.Bytes()
+	parse := func() *Packet {
+		p, err := NewFromRawBytes(bytes.NewReader(raw), false)
+		if err != nil {
+			t.Fatalf("unable to parse PSBT: %v", err)
+		}
+		if p.UnsignedTx.TxType() != wire.TxTypeProUpdateService ||
+			!bytes.Equal(p.UnsignedTx.ExtraPayload, payload) {
+
+			t.Fatalf("special transaction not preserved: %v",
+				spew.Sdump(p.UnsignedTx))
+		}
+		return p
+	}
+
+	// Each party adds the UTXOs and signs its own input.
+	keys := []*btcec.PrivateKey{key1, key2}
+	packets := make([]*Packet, len(keys))
+	for i, key := range keys {
+		p := parse()
+		u, err := NewUpdater(p)
+		if err != nil {
+			t.Fatalf("unable to create updater: %v", err)
+		}
+		if err := u.AddInNonWitnessUtxo(prevTx, i); err != nil {
+			t.Fatalf("unable to add UTXO: %v", err)
+		}
+		pubKey := key.PubKey().SerializeCompressed()
+		path := []uint32{0x8000002c, 0x80000005, 0x80000000, 0, uint32(i)}
+		if err := u.AddInBip32Derivation(0x01020304, path, pubKey, i); err != nil {
+			t.Fatalf("unable to add derivation: %v", err)
+		}
+		sig, err := txscript.RawTxInSignature(p.UnsignedTx, i,
+			pkScripts[i], txscript.SigHashAll, key)
+		if err != nil {
+			t.Fatalf("unable to sign: %v", err)
+		}
+		res, err := u.Sign(i, sig, pubKey, nil, nil)
+		if err != nil || res != SignSuccesful {
+			t.Fatalf("unable to add signature: %v, %v", res, err)
+		}
+		packets[i] = p
+	}
+
+	// Packets for a different extra payload can't be combined.
+	other := parse()
+	other.UnsignedTx.ExtraPayload = []byte{0x02, 0x00}
+	if _, err := Combine(packets[0], other); err != ErrCombineMismatch {
+		t.Fatalf("Combine: got error %v, want %v", err, ErrCombineMismatch)
+	}
+
+	combined, err := Combine(packets...)
+	if err != nil {
+		t.Fatalf("unable to combine PSBTs: %v", err)
+	}
+	for i := range combined.Inputs {
+		in := &combined.Inputs[i]
+		if in.NonWitnessUtxo == nil || len(in.PartialSigs) != 1 ||
+			len(in.Bip32Derivation) != 1 {
+
+			t.Fatalf("input %d not combined: %v", i, spew.Sdump(in))
+		}
+	}
+	if !combined.IsComplete() {
+		if err := MaybeFinalizeAll(combined); err != nil {
+			t.Fatalf("unable to finalize PSBT: %v", err)
+		}
+	}
+	tx, err := Extract(combined)
+	if err != nil {
+		t.Fatalf("unable to extract transaction: %v", err)
+	}
+	if !bytes.Equal(tx.ExtraPayload, payload) {
+		t.Fatalf("extra payload not extracted: %x", tx.ExtraPayload)
+	}
+
+	// The signatures commit to the extra payload.
+	verify := func(tx *wire.MsgTx) error {
+		for i := range tx.TxIn {
+			vm, err := txscript.NewEngine(pkScripts[i], tx, i,
+				txscript.StandardVerifyFlags, nil, nil,
+				btcutil.SatoshiPerBitcoin)
+			if err != nil {
+				return err
+			}
+			if err := vm.Execute(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := verify(tx); err != nil {
+		t.Fatalf("extracted transaction does not verify: %v", err)
+	}
+	tx.ExtraPayload[2] ^= 0xff
+	if err := verify(tx); err == nil {
+		t.Fatal("transaction with modified payload verifies")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"crypto/subtle"
+)
+
+// SecretBytes is a byte slice holding secret material, such as a serialized
+// private key, a seed or a chain code, that can be explicitly wiped from
+// memory once it is no longer needed.
+//
+// Go provides no guarantee that secret material never leaves copies behind.
+// The garbage collector may move the backing array of a slice, growing a slice
+// with append copies it, and converting it to a string always copies it into
+// memory that can't be wiped.  In order to keep the number of copies to a
+// minimum, SecretBytes is a slice type rather than a wrapper struct, so it can
+// be converted from and to the slices returned by other packages without
+// allocating, and its methods never allocate or copy the secret.  Callers
+// should preallocate the required capacity with NewSecretBytes instead of
+// appending to a secret and wipe it with Zero as soon as it is not needed.
+//
+// The String and GoString methods redact the secret so it is not leaked when
+// formatted, such as when logging a value that holds one.
+type SecretBytes []byte
+
+// NewSecretBytes returns a zeroed SecretBytes of the passed length.  This is
+// the only function related to SecretBytes that allocates.
+func NewSecretBytes(length int) SecretBytes {
+	return make(SecretBytes, length)
+}
+
+// Zero sets all bytes of the secret to zero.  Only the bytes within the length
+// of the secret are cleared since the backing array may be shared with other
+// data, so a secret that was resliced must be wiped through the original slice.
+func (s SecretBytes) Zero() {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+// IsZero returns whether all bytes of the secret are zero, which is the case
+// after it has been wiped with Zero.
+func (s SecretBytes) IsZero() bool {
+	var acc byte
+	for _, b := range s {
+		acc |= b
+	}
+	return acc == 0
+}
+
+// Equal returns whether the secret is equal to the passed secret in constant
+// time, so comparing secrets does not leak their contents through timing.
+func (s SecretBytes) Equal(other SecretBytes) bool {
+	return subtle.ConstantTimeCompare(s, other) == 1
+}
+
+// String returns a redacted representation of the secret.
+//
+// This is part of the fmt.Stringer interface.
+func (s SecretBytes) String() string {
+	return "[redacted]"
+}
+
+// GoString returns a redacted representation of the secret.
+//
+// This is part of the fmt.GoStringer interface.
+func (s SecretBytes) GoString() string {
+	return "btcutil.SecretBytes([redacted])"
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	. "github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestSecretBytes ensures secrets are wiped, compared and redacted when
+// formatted as expected.
+func TestSecretBytes(t *testing.T) {
+	secret := NewSecretBytes(32)
+	if len(secret) != 32 || !secret.IsZero() {
+		t.Fatalf("NewSecretBytes: unexpected secret %x", []byte(secret))
+	}
+	for i := range secret {
+		secret[i] = byte(i + 1)
+	}
+	if secret.IsZero() {
+		t.Fatal("IsZero: secret reported as zero")
+	}
+
+	other := NewSecretBytes(32)
+	copy(other, secret)
+	if !secret.Equal(other) {
+		t.Fatal("Equal: equal secrets reported as different")
+	}
+	other[31] ^= 0x01
+	if secret.Equal(other) || secret.Equal(secret[:31]) {
+		t.Fatal("Equal: different secrets reported as equal")
+	}
+
+	// Formatting the secret must not leak it.
+	for _, format := range []string{"%v", "%s", "%x", "%#v", "%+v"} {
+		formatted := fmt.Sprintf(format, secret)
+		if formatted == fmt.Sprintf(format, []byte(secret)) {
+			t.Fatalf("Sprintf(%q): secret leaked: %s", format,
+				formatted)
+		}
+	}
+
+	// Wiping a resliced secret must only clear the bytes within its
+	// length.
+	secret[:16].Zero()
+	if !secret[:16].IsZero() || secret[16:].IsZero() {
+		t.Fatalf("Zero: unexpected secret after wiping the first half %x",
+			[]byte(secret))
+	}
+	secret.Zero()
+	if !secret.IsZero() {
+		t.Fatalf("Zero: secret not wiped %x", []byte(secret))
+	}
+}
+
+// TestWIFZero ensures the private key of a WIF structure is cleared by Zero.
+func TestWIFZero(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	wif, err := NewWIF(privKey, &chaincfg.MainNetParams, true)
+	if err != nil {
+		t.Fatalf("NewWIF: unexpected error: %v", err)
+	}
+
+	wif.Zero()
+	if wif.PrivKey != nil {
+		t.Fatal("Zero: private key not removed")
+	}
+	if !privKey.Key.IsZero() {
+		t.Fatal("Zero: private key not cleared")
+	}
+}
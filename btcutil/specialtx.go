@@ -0,0 +1,328 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// specialTxPayloadNames maps the special transaction types to the names Dash
+// Core uses for their payloads in decoded transactions.
+var specialTxPayloadNames = map[wire.TxType]string{
+	wire.TxTypeProRegister:        "proRegTx",
+	wire.TxTypeProUpdateService:   "proUpServTx",
+	wire.TxTypeProUpdateRegistrar: "proUpRegTx",
+	wire.TxTypeProUpdateRevoke:    "proUpRevTx",
+	wire.TxTypeCoinbase:           "cbTx",
+	wire.TxTypeQuorumCommitment:   "qcTx",
+}
+
+// SpecialTxPayload wraps the typed payload of a DIP0002 special transaction to
+// provide its human-readable serialization.  The JSON encoding uses the same
+// field names and formatting as the payloads of the transactions decoded by the
+// RPC server of Dash Core, so user interfaces such as block explorers can
+// render them directly.  Keys and scripts are rendered as addresses of the
+// network of the payload.
+type SpecialTxPayload struct {
+	Payload wire.SpecialTxPayload
+	params  *chaincfg.Params
+}
+
+// NewSpecialTxPayload returns a SpecialTxPayload for the passed payload which
+// renders addresses for the passed network.
+func NewSpecialTxPayload(payload wire.SpecialTxPayload, params *chaincfg.Params) *SpecialTxPayload {
+	return &SpecialTxPayload{
+		Payload: payload,
+		params:  params,
+	}
+}
+
+// SpecialTxPayload decodes the extra payload of the transaction and returns it
+// wrapped for the passed network.  An error is returned when the transaction
+// is not a special transaction with a known type and a valid payload.
+func (t *Tx) SpecialTxPayload(params *chaincfg.Params) (*SpecialTxPayload, error) {
+	payload, err := t.msgTx.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return NewSpecialTxPayload(payload, params), nil
+}
+
+// Name returns the name Dash Core uses for the payload in decoded transactions,
+// such as "proRegTx".
+func (p *SpecialTxPayload) Name() string {
+	if name, ok := specialTxPayloadNames[p.Payload.TxType()]; ok {
+		return name
+	}
+	return "extraPayload"
+}
+
+// String returns the JSON encoding of the payload.
+func (p *SpecialTxPayload) String() string {
+	b, err := p.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("<invalid %v payload: %v>", p.Payload.TxType(),
+			err)
+	}
+	return string(b)
+}
+
+// serviceString returns the service address of a masternode formatted as Dash
+// Core does.
+func serviceString(ip net.IP, port uint16) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+}
+
+// payoutAddress returns the address the passed pay-to-pubkey-hash or
+// pay-to-script-hash script pays to, or an empty string for other scripts.
+func payoutAddress(script []byte, params *chaincfg.Params) string {
+	var addr Address
+	var err error
+	switch {
+	case len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 &&
+		script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac:
+
+		addr, err = NewAddressPubKeyHash(script[3:23], params)
+
+	case len(script) == 23 && script[0] == 0xa9 && script[1] == 0x14 &&
+		script[22] == 0x87:
+
+		addr, err = NewAddressScriptHashFromHash(script[2:22], params)
+
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return addr.EncodeAddress()
+}
+
+// keyIDAddress returns the pay-to-pubkey-hash address of the passed key ID.
+func keyIDAddress(keyID [20]byte, params *chaincfg.Params) (string, error) {
+	addr, err := NewAddressPubKeyHash(keyID[:], params)
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// bitsString returns the hex encoding of the passed bits packed least
+// significant bit first, which is how Dash Core renders the bitsets of quorum
+// commitments.
+func bitsString(bits []bool) string {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			packed[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return hex.EncodeToString(packed)
+}
+
+// countBits returns the number of set bits.
+func countBits(bits []bool) int {
+	var n int
+	for _, bit := range bits {
+		if bit {
+			n++
+		}
+	}
+	return n
+}
+
+// platformNodeIDString returns the platform node ID of an Evo masternode in
+// the reversed byte order Dash Core displays 160-bit hashes in.
+func platformNodeIDString(id [20]byte) string {
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// platformJSON houses the platform fields of Evo masternodes.
+type platformJSON struct {
+	PlatformNodeID   string `json:"platformNodeID"`
+	PlatformP2PPort  uint16 `json:"platformP2PPort"`
+	PlatformHTTPPort uint16 `json:"platformHTTPPort"`
+}
+
+// newPlatformJSON returns the platform fields of the payload of a masternode of
+// the passed type, or nil for regular masternodes.
+func newPlatformJSON(mnType wire.MasternodeType, version uint16, platform *wire.PlatformFields) *platformJSON {
+	if mnType != wire.MasternodeTypeEvo || version < wire.ProTxBasicBLSVersion {
+		return nil
+	}
+	return &platformJSON{
+		PlatformNodeID:   platformNodeIDString(platform.PlatformNodeID),
+		PlatformP2PPort:  platform.PlatformP2PPort,
+		PlatformHTTPPort: platform.PlatformHTTPPort,
+	}
+}
+
+// MarshalJSON returns the human-readable JSON encoding of the payload.  This
+// is part of the json.Marshaler interface implementation.
+func (p *SpecialTxPayload) MarshalJSON() ([]byte, error) {
+	switch payload := p.Payload.(type) {
+	case *wire.ProRegTx:
+		ownerAddress, err := keyIDAddress(payload.KeyIDOwner, p.params)
+		if err != nil {
+			return nil, err
+		}
+		votingAddress, err := keyIDAddress(payload.KeyIDVoting, p.params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&struct {
+			Version         uint16  `json:"version"`
+			Type            uint16  `json:"type"`
+			CollateralHash  string  `json:"collateralHash"`
+			CollateralIndex uint32  `json:"collateralIndex"`
+			Service         string  `json:"service"`
+			OwnerAddress    string  `json:"ownerAddress"`
+			VotingAddress   string  `json:"votingAddress"`
+			PayoutAddress   string  `json:"payoutAddress,omitempty"`
+			PubKeyOperator  string  `json:"pubKeyOperator"`
+			OperatorReward  float64 `json:"operatorReward"`
+			*platformJSON
+			InputsHash string `json:"inputsHash"`
+		}{
+			Version:         payload.Version,
+			Type:            uint16(payload.Type),
+			CollateralHash:  payload.CollateralOutpoint.Hash.String(),
+			CollateralIndex: payload.CollateralOutpoint.Index,
+			Service:         serviceString(payload.IP, payload.Port),
+			OwnerAddress:    ownerAddress,
+			VotingAddress:   votingAddress,
+			PayoutAddress:   payoutAddress(payload.ScriptPayout, p.params),
+			PubKeyOperator:  hex.EncodeToString(payload.PubKeyOperator[:]),
+			OperatorReward:  float64(payload.OperatorReward) / 100,
+			platformJSON: newPlatformJSON(payload.Type, payload.Version,
+				&payload.Platform),
+			InputsHash: payload.InputsHash.String(),
+		})
+
+	case *wire.ProUpServTx:
+		return json.Marshal(&struct {
+			Version               uint16 `json:"version"`
+			Type                  uint16 `json:"type"`
+			ProTxHash             string `json:"proTxHash"`
+			Service               string `json:"service"`
+			OperatorPayoutAddress string `json:"operatorPayoutAddress,omitempty"`
+			*platformJSON
+			InputsHash string `json:"inputsHash"`
+		}{
+			Version:   payload.Version,
+			Type:      uint16(payload.Type),
+			ProTxHash: payload.ProTxHash.String(),
+			Service:   serviceString(payload.IP, payload.Port),
+			OperatorPayoutAddress: payoutAddress(
+				payload.ScriptOperatorPayout, p.params),
+			platformJSON: newPlatformJSON(payload.Type, payload.Version,
+				&payload.Platform),
+			InputsHash: payload.InputsHash.String(),
+		})
+
+	case *wire.ProUpRegTx:
+		votingAddress, err := keyIDAddress(payload.KeyIDVoting, p.params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&struct {
+			Version        uint16 `json:"version"`
+			ProTxHash      string `json:"proTxHash"`
+			VotingAddress  string `json:"votingAddress"`
+			PayoutAddress  string `json:"payoutAddress,omitempty"`
+			PubKeyOperator string `json:"pubKeyOperator"`
+			InputsHash     string `json:"inputsHash"`
+		}{
+			Version:        payload.Version,
+			ProTxHash:      payload.ProTxHash.String(),
+			VotingAddress:  votingAddress,
+			PayoutAddress:  payoutAddress(payload.ScriptPayout, p.params),
+			PubKeyOperator: hex.EncodeToString(payload.PubKeyOperator[:]),
+			InputsHash:     payload.InputsHash.String(),
+		})
+
+	case *wire.ProUpRevTx:
+		return json.Marshal(&struct {
+			Version    uint16 `json:"version"`
+			ProTxHash  string `json:"proTxHash"`
+			Reason     uint16 `json:"reason"`
+			InputsHash string `json:"inputsHash"`
+		}{
+			Version:    payload.Version,
+			ProTxHash:  payload.ProTxHash.String(),
+			Reason:     payload.Reason,
+			InputsHash: payload.InputsHash.String(),
+		})
+
+	case *wire.CbTx:
+		var merkleRootQuorums string
+		if payload.Version >= 2 {
+			merkleRootQuorums = payload.MerkleRootQuorums.String()
+		}
+		return json.Marshal(&struct {
+			Version           uint16 `json:"version"`
+			Height            int32  `json:"height"`
+			MerkleRootMNList  string `json:"merkleRootMNList"`
+			MerkleRootQuorums string `json:"merkleRootQuorums,omitempty"`
+		}{
+			Version:           payload.Version,
+			Height:            payload.Height,
+			MerkleRootMNList:  payload.MerkleRootMNList.String(),
+			MerkleRootQuorums: merkleRootQuorums,
+		})
+
+	case *wire.QcTx:
+		qc := &payload.Commitment
+		type commitmentJSON struct {
+			Version           uint16 `json:"version"`
+			LLMQType          uint8  `json:"llmqType"`
+			QuorumHash        string `json:"quorumHash"`
+			QuorumIndex       int16  `json:"quorumIndex"`
+			SignersCount      int    `json:"signersCount"`
+			Signers           string `json:"signers"`
+			ValidMembersCount int    `json:"validMembersCount"`
+			ValidMembers      string `json:"validMembers"`
+			QuorumPublicKey   string `json:"quorumPublicKey"`
+			QuorumVvecHash    string `json:"quorumVvecHash"`
+			QuorumSig         string `json:"quorumSig"`
+			MembersSig        string `json:"membersSig"`
+		}
+		return json.Marshal(&struct {
+			Version    uint16         `json:"version"`
+			Height     uint32         `json:"height"`
+			Commitment commitmentJSON `json:"commitment"`
+		}{
+			Version: payload.Version,
+			Height:  payload.Height,
+			Commitment: commitmentJSON{
+				Version:           qc.Version,
+				LLMQType:          uint8(qc.LLMQType),
+				QuorumHash:        qc.QuorumHash.String(),
+				QuorumIndex:       qc.QuorumIndex,
+				SignersCount:      countBits(qc.Signers),
+				Signers:           bitsString(qc.Signers),
+				ValidMembersCount: countBits(qc.ValidMembers),
+				ValidMembers:      bitsString(qc.ValidMembers),
+				QuorumPublicKey:   hex.EncodeToString(qc.QuorumPubKey[:]),
+				QuorumVvecHash:    qc.QuorumVvecHash.String(),
+				QuorumSig:         hex.EncodeToString(qc.QuorumSig[:]),
+				MembersSig:        hex.EncodeToString(qc.MembersSig[:]),
+			},
+		})
+	}
+
+	return nil, fmt.Errorf("no human-readable serialization for %v "+
+		"payloads", p.Payload.TxType())
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestSpecialTxPayloadJSON tests the human-readable serialization of special
+// transaction payloads.
+func TestSpecialTxPayloadJSON(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	keyID := [20]byte{0x01, 0x02, 0x03}
+	keyAddr, err := btcutil.NewAddressPubKeyHash(keyID[:], params)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	scriptAddr, err := btcutil.NewAddressScriptHashFromHash(keyID[:], params)
+	if err != nil {
+		t.Fatalf("NewAddressScriptHashFromHash: %v", err)
+	}
+	p2pkh := append(append([]byte{0x76, 0xa9, 0x14}, keyID[:]...), 0x88, 0xac)
+	p2sh := append(append([]byte{0xa9, 0x14}, keyID[:]...), 0x87)
+	hash := chainhash.Hash{0x01}
+	zeros48 := strings.Repeat("00", wire.BLSPublicKeySize)
+	zeros96 := strings.Repeat("00", wire.BLSSignatureSize)
+
+	tests := []struct {
+		name    string
+		payload wire.SpecialTxPayload
+		want    string
+	}{
+		{
+			name: "proRegTx",
+			payload: &wire.ProRegTx{
+				Version: wire.ProTxBasicBLSVersion,
+				Type:    wire.MasternodeTypeEvo,
+				CollateralOutpoint: wire.OutPoint{
+					Hash:  hash,
+					Index: 1,
+				},
+				IP:             net.ParseIP("1.2.3.4"),
+				Port:           9999,
+				KeyIDOwner:     keyID,
+				KeyIDVoting:    keyID,
+				OperatorReward: 1050,
+				ScriptPayout:   p2pkh,
+				Platform: wire.PlatformFields{
+					PlatformNodeID:   [20]byte{0xaa, 19: 0xbb},
+					PlatformP2PPort:  26656,
+					PlatformHTTPPort: 443,
+				},
+			},
+			want: `{"version":2,"type":1,"collateralHash":"` +
+				hash.String() + `","collateralIndex":1,` +
+				`"service":"1.2.3.4:9999","ownerAddress":"` +
+				keyAddr.EncodeAddress() + `","votingAddress":"` +
+				keyAddr.EncodeAddress() + `","payoutAddress":"` +
+				keyAddr.EncodeAddress() + `","pubKeyOperator":"` +
+				zeros48 + `","operatorReward":10.5,` +
+				`"platformNodeID":"bb` + strings.Repeat("00", 18) +
+				`aa","platformP2PPort":26656,"platformHTTPPort":443,` +
+				`"inputsHash":"` + chainhash.Hash{}.String() + `"}`,
+		},
+		{
+			name: "proUpServTx",
+			payload: &wire.ProUpServTx{
+				Version:              1,
+				ProTxHash:            hash,
+				IP:                   net.ParseIP("2001:db8::1"),
+				Port:                 19999,
+				ScriptOperatorPayout: p2sh,
+			},
+			want: `{"version":1,"type":0,"proTxHash":"` + hash.String() +
+				`","service":"[2001:db8::1]:19999",` +
+				`"operatorPayoutAddress":"` +
+				scriptAddr.EncodeAddress() + `","inputsHash":"` +
+				chainhash.Hash{}.String() + `"}`,
+		},
+		{
+			name: "proUpRegTx",
+			payload: &wire.ProUpRegTx{
+				Version:     1,
+				ProTxHash:   hash,
+				KeyIDVoting: keyID,
+				// Scripts of other types have no payout address.
+				ScriptPayout: []byte{0x51},
+			},
+			want: `{"version":1,"proTxHash":"` + hash.String() +
+				`","votingAddress":"` + keyAddr.EncodeAddress() +
+				`","pubKeyOperator":"` + zeros48 + `","inputsHash":"` +
+				chainhash.Hash{}.String() + `"}`,
+		},
+		{
+			name: "proUpRevTx",
+			payload: &wire.ProUpRevTx{
+				Version:   1,
+				ProTxHash: hash,
+				Reason:    3,
+			},
+			want: `{"version":1,"proTxHash":"` + hash.String() +
+				`","reason":3,"inputsHash":"` +
+				chainhash.Hash{}.String() + `"}`,
+		},
+		{
+			name: "cbTx",
+			payload: &wire.CbTx{
+				Version:           1,
+				Height:            100,
+				MerkleRootMNList:  hash,
+				MerkleRootQuorums: hash,
+			},
+			want: `{"version":1,"height":100,"merkleRootMNList":"` +
+				hash.String() + `"}`,
+		},
+		{
+			name: "qcTx",
+			payload: &wire.QcTx{
+				Version: 1,
+				Height:  200,
+				Commitment: wire.QuorumCommitment{
+					Version:    1,
+					LLMQType:   4,
+					QuorumHash: hash,
+					Signers: []bool{true, false, true, true,
+						false, false, false, false, true},
+					ValidMembers: []bool{true, true, true, true,
+						true, true, true, true, true},
+				},
+			},
+			want: `{"version":1,"height":200,"commitment":{"version":1,` +
+				`"llmqType":4,"quorumHash":"` + hash.String() +
+				`","quorumIndex":0,"signersCount":4,` +
+				`"signers":"0d01","validMembersCount":9,` +
+				`"validMembers":"ff01","quorumPublicKey":"` + zeros48 +
+				`","quorumVvecHash":"` + chainhash.Hash{}.String() +
+				`","quorumSig":"` + zeros96 + `","membersSig":"` +
+				zeros96 + `"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		p := btcutil.NewSpecialTxPayload(test.payload, params)
+		if p.Name() != test.name {
+			t.Errorf("%s: unexpected name %q", test.name, p.Name())
+		}
+		got, err := json.Marshal(p)
+		if err != nil {
+			t.Errorf("%s: MarshalJSON error %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, []byte(test.want)) {
+			t.Errorf("%s: MarshalJSON\n got: %s\nwant: %s",
+				test.name, got, test.want)
+			continue
+		}
+		if p.String() != test.want {
+			t.Errorf("%s: String\n got: %s\nwant: %s", test.name,
+				p.String(), test.want)
+		}
+	}
+}
+
+// TestTxSpecialTxPayload ensures the payload of special transactions can be
+// obtained from a Tx.
+func TestTxSpecialTxPayload(t *testing.T) {
+	cbTx := &wire.CbTx{Version: 2, Height: 1}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	if err := msgTx.SetPayload(cbTx); err != nil {
+		t.Fatalf("SetPayload: %v", err)
+	}
+
+	p, err := btcutil.NewTx(msgTx).SpecialTxPayload(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("SpecialTxPayload: %v", err)
+	}
+	if p.Name() != "cbTx" {
+		t.Errorf("unexpected payload name %q", p.Name())
+	}
+	if !strings.Contains(p.String(), `"merkleRootQuorums"`) {
+		t.Errorf("version 2 cbTx without merkleRootQuorums: %s", p)
+	}
+
+	_, err = btcutil.NewTx(wire.NewMsgTx(wire.TxVersion)).
+		SpecialTxPayload(&chaincfg.MainNetParams)
+	if err == nil {
+		t.Errorf("SpecialTxPayload of a regular transaction succeeded")
+	}
+}
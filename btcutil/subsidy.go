@@ -0,0 +1,195 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"math"
+
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// Heights of the previous block at which the formula of the base subsidy
+// changes, matching the eras of dashd.
+const (
+	// buggyDiffHeight is the last height of a mainnet block whose
+	// difficulty was calculated incorrectly for the subsidy.
+	buggyDiffHeight = 4500
+
+	// cpuEraHeight is the height after which the subsidy follows the
+	// formula of the CPU mining era.
+	cpuEraHeight = 5465
+
+	// gpuEraHeight is the height after which the subsidy follows the
+	// formula of the GPU mining era, unless the difficulty stayed low.
+	gpuEraHeight = 17000
+
+	// lowDiffEraHeight is the height after which the subsidy follows the
+	// formula of the GPU mining era regardless of the difficulty.
+	lowDiffEraHeight = 24000
+)
+
+// subsidyDifficulty returns the difficulty of the passed compact target bits
+// as dashd calculates it for the subsidy, which differs from the difficulty of
+// the getblock RPC.
+func subsidyDifficulty(bits uint32) float64 {
+	shift := int(bits>>24) & 0xff
+	diff := float64(0x0000ffff) / float64(bits&0x00ffffff)
+	for ; shift < 29; shift++ {
+		diff *= 256
+	}
+	for ; shift > 29; shift-- {
+		diff /= 256
+	}
+	return diff
+}
+
+// blockSubsidy returns the subsidy of the block following the block with the
+// passed height and compact target bits, split into the part paid by its
+// coinbase and the part set aside for the superblocks.  It is the equivalent
+// of GetBlockSubsidyInner of dashd.
+func blockSubsidy(prevHeight int32, prevBits uint32,
+	params *chaincfg.Params) (Amount, Amount) {
+
+	var diff float64
+	if prevHeight <= buggyDiffHeight && params.Net == wire.MainNet {
+		diff = float64(0x0000ffff) / float64(prevBits&0x00ffffff)
+	} else {
+		diff = subsidyDifficulty(prevBits)
+	}
+
+	// The base subsidy in whole dash depends on the difficulty and is
+	// truncated before it is clamped.
+	var base, minBase, maxBase int64
+	switch {
+	case prevHeight < cpuEraHeight:
+		base = int64(1111 / math.Pow(diff+1, 2))
+		minBase, maxBase = 1, 500
+	case prevHeight < gpuEraHeight ||
+		(diff <= 75 && prevHeight < lowDiffEraHeight):
+		base = int64(11111 / math.Pow((diff+51)/6, 2))
+		minBase, maxBase = 25, 500
+	default:
+		base = int64(2222222 / math.Pow((diff+2600)/9, 2))
+		minBase, maxBase = 5, 25
+	}
+	if base > maxBase {
+		base = maxBase
+	} else if base < minBase {
+		base = minBase
+	}
+
+	// The subsidy declines by a fourteenth, about 7.14%, every reduction
+	// interval, which is about a year, until a fourteenth of it rounds
+	// down to zero.
+	subsidy := Amount(base * SatoshiPerBitcoin)
+	interval := params.SubsidyReductionInterval
+	if interval > 0 {
+		for i := interval; i <= prevHeight && subsidy >= 14; i += interval {
+			subsidy -= subsidy / 14
+		}
+	}
+
+	var superblockPart Amount
+	if prevHeight > params.BudgetPaymentsStartHeight {
+		superblockPart = subsidy / 10
+	}
+	return subsidy - superblockPart, superblockPart
+}
+
+// nominalSubsidyBits returns the compact target bits BlockSubsidy assumes for
+// the previous block, which are those dashd uses to calculate the superblock
+// budgets.  The subsidy is lowest for the highest difficulty, which is used on
+// networks with a high difficulty, and highest for the lowest difficulty,
+// which is used on the networks that allow blocks of the minimum difficulty.
+func nominalSubsidyBits(params *chaincfg.Params) uint32 {
+	if params.ReduceMinDifficulty {
+		return params.PowLimitBits
+	}
+	return 1
+}
+
+// BlockSubsidyBits returns the subsidy paid by the coinbase of the block at
+// the passed height given the compact target bits of its previous block.  It
+// excludes the part of the subsidy set aside for the superblocks and is the
+// equivalent of GetBlockSubsidy of dashd.
+func BlockSubsidyBits(height int32, prevBits uint32, params *chaincfg.Params) Amount {
+	subsidy, _ := blockSubsidy(height-1, prevBits, params)
+	return subsidy
+}
+
+// BlockSubsidy returns the subsidy paid by the coinbase of the block at the
+// passed height, excluding the part of the subsidy set aside for the
+// superblocks.
+//
+// The subsidy of the early blocks depended on their difficulty, which
+// BlockSubsidy assumes to be the highest possible on mainnet and the lowest
+// possible on the test networks.  It is exact for all mainnet blocks since
+// the difficulty exceeded the point where the subsidy no longer depends on it,
+// well before height 100000.  Use BlockSubsidyBits for the exact subsidy of
+// any block.
+func BlockSubsidy(height int32, params *chaincfg.Params) Amount {
+	return BlockSubsidyBits(height, nominalSubsidyBits(params), params)
+}
+
+// SuperblockBudget returns the maximum amount the superblock at the passed
+// height may pay to the proposals funded by the governance system, which is
+// the part of the subsidy set aside for the superblocks by each block of a
+// superblock cycle.  Zero is returned when the height is not a superblock
+// height.  It is the equivalent of CSuperblock::GetPaymentsLimit of dashd.
+func SuperblockBudget(height int32, params *chaincfg.Params) Amount {
+	if params.SuperblockCycle <= 0 || height < params.SuperblockStartHeight ||
+		(height-params.SuperblockStartHeight)%params.SuperblockCycle != 0 {
+
+		return 0
+	}
+
+	_, superblockPart := blockSubsidy(height-1, nominalSubsidyBits(params),
+		params)
+	return superblockPart * Amount(params.SuperblockCycle)
+}
+
+// nextSubsidyChange returns the lowest previous block height greater than the
+// passed one at which the subsidy for fixed target bits may change.
+func nextSubsidyChange(prevHeight int32, params *chaincfg.Params) int32 {
+	next := int32(math.MaxInt32)
+	changes := []int32{buggyDiffHeight + 1, cpuEraHeight, gpuEraHeight,
+		lowDiffEraHeight, params.BudgetPaymentsStartHeight + 1}
+	if interval := params.SubsidyReductionInterval; interval > 0 {
+		changes = append(changes, (prevHeight/interval+1)*interval)
+	}
+	for _, h := range changes {
+		if h > prevHeight && h < next {
+			next = h
+		}
+	}
+	return next
+}
+
+// CumulativeSubsidy returns the total subsidy of the blocks from height one up
+// to and including the passed height, including the parts set aside for the
+// superblocks, with the subsidy of each block calculated like BlockSubsidy.
+// The subsidy of the genesis block can't be spent and is excluded.
+//
+// This is the supply emitted by the blocks when all superblock budgets are
+// paid in full.  Budgets that are not allocated to proposals are never
+// created, so the actual supply may be lower.
+func CumulativeSubsidy(height int32, params *chaincfg.Params) Amount {
+	bits := nominalSubsidyBits(params)
+
+	// The subsidy only changes at a few heights, so sum it over the spans
+	// of blocks with the same subsidy.
+	var total Amount
+	for prevHeight := int32(0); prevHeight < height; {
+		next := nextSubsidyChange(prevHeight, params)
+		if next > height {
+			next = height
+		}
+		subsidy, superblockPart := blockSubsidy(prevHeight, bits, params)
+		total += (subsidy + superblockPart) * Amount(next-prevHeight)
+		prevHeight = next
+	}
+	return total
+}
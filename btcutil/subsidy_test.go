@@ -0,0 +1,156 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"testing"
+
+	. "github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestBlockSubsidyBits ensures the subsidy of mainnet blocks matches the
+// fixtures of the subsidy tests of dashd and that the part of the subsidy set
+// aside for the superblocks is excluded.
+func TestBlockSubsidyBits(t *testing.T) {
+	tests := []struct {
+		height   int32
+		prevBits uint32
+		want     Amount
+	}{
+		{height: 4250, prevBits: 0x1c4a47c4, want: 50000000000},
+		{height: 4502, prevBits: 0x1c4a47c4, want: 5600000000},
+		{height: 5465, prevBits: 0x1c29ec00, want: 2100000000},
+		{height: 5466, prevBits: 0x1c29ec00, want: 12200000000},
+		{height: 17589, prevBits: 0x1c08ba34, want: 6100000000},
+		{height: 100000, prevBits: 0x1b10cf42, want: 500000000},
+		{height: 210240, prevBits: 0x1b11548e, want: 500000000},
+		{height: 210241, prevBits: 0x1b10d50b, want: 464285715},
+
+		// A tenth of the subsidy is set aside for the superblocks once
+		// the budget payments started.
+		{height: 328008, prevBits: 0x1b0a4d9c, want: 464285715},
+		{height: 328010, prevBits: 0x1b0a4d9c, want: 417857144},
+	}
+
+	params := &chaincfg.MainNetParams
+	for _, test := range tests {
+		got := BlockSubsidyBits(test.height, test.prevBits, params)
+		if got != test.want {
+			t.Errorf("BlockSubsidyBits(%d, %08x): got %d, want %d",
+				test.height, test.prevBits, got, test.want)
+		}
+	}
+}
+
+// TestBlockSubsidy ensures the subsidy and superblock budgets follow the
+// yearly reduction of the subsidy.
+func TestBlockSubsidy(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *chaincfg.Params
+		height int32
+		want   Amount
+		budget Amount
+	}{
+		{
+			name:   "mainnet first reduction",
+			params: &chaincfg.MainNetParams,
+			height: 210241,
+			want:   464285715,
+		},
+		{
+			name:   "mainnet first superblock",
+			params: &chaincfg.MainNetParams,
+			height: 614820,
+			want:   388010205,
+			budget: 43112245 * 16616,
+		},
+		{
+			name:   "mainnet fourth reduction",
+			params: &chaincfg.MainNetParams,
+			height: 1000000,
+			want:   334559821,
+		},
+		{
+			name:   "testnet superblock at the lowest difficulty",
+			params: &chaincfg.TestNet3Params,
+			height: 4200,
+			want:   45000000000,
+			budget: 5000000000 * 24,
+		},
+		{
+			name:   "regtest after many reductions",
+			params: &chaincfg.RegressionNetParams,
+			height: 1000000,
+			want:   12,
+			budget: 1 * 10,
+		},
+	}
+
+	for _, test := range tests {
+		got := BlockSubsidy(test.height, test.params)
+		if got != test.want {
+			t.Errorf("%s: got subsidy %d, want %d", test.name, got,
+				test.want)
+		}
+		budget := SuperblockBudget(test.height, test.params)
+		if budget != test.budget {
+			t.Errorf("%s: got budget %d, want %d", test.name, budget,
+				test.budget)
+		}
+	}
+
+	// There's no budget between superblocks.
+	params := &chaincfg.MainNetParams
+	if budget := SuperblockBudget(614821, params); budget != 0 {
+		t.Errorf("got budget %d for a block which isn't a superblock",
+			budget)
+	}
+}
+
+// TestCumulativeSubsidy ensures the cumulative subsidy is the sum of the
+// subsidies of the blocks, including the superblock budgets.
+func TestCumulativeSubsidy(t *testing.T) {
+	for _, params := range []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.RegressionNetParams,
+	} {
+		bits := params.PowLimitBits
+		if !params.ReduceMinDifficulty {
+			bits = 1
+		}
+		var want Amount
+		for height := int32(1); height <= 30000; height++ {
+			subsidy, superblockPart := TstBlockSubsidy(height-1, bits,
+				params)
+			if subsidy != BlockSubsidy(height, params) {
+				t.Fatalf("%s: got subsidy %d at height %d, want %d",
+					params.Name, BlockSubsidy(height, params),
+					height, subsidy)
+			}
+			want += subsidy + superblockPart
+			if height%997 != 0 {
+				continue
+			}
+			if got := CumulativeSubsidy(height, params); got != want {
+				t.Errorf("%s: got cumulative subsidy %d at height "+
+					"%d, want %d", params.Name, got, height, want)
+				break
+			}
+		}
+	}
+
+	// At the highest difficulty, the mainnet supply converges to about
+	// 14.93 million dash, most of which are 14 years worth of blocks with
+	// a subsidy of 5 dash.  The actual supply is higher since the subsidy
+	// of the early blocks was up to 500 dash.
+	params := &chaincfg.MainNetParams
+	got := CumulativeSubsidy(40*params.SubsidyReductionInterval, params)
+	if got < 14.1e6*SatoshiPerBitcoin || got >= 14.93e6*SatoshiPerBitcoin {
+		t.Errorf("got cumulative subsidy %v after 40 years", got)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package testvectors generates deterministic test fixtures which allow
+implementations in other languages to check they remain byte-compatible with
+this one.
+
+Every value in a fixture set is derived from a caller provided seed by hashing
+the seed together with a label identifying the kind of value and its index, so
+the same seed always produces exactly the same keys, addresses, scripts and
+special transaction payloads.  The derivation is simple enough to be repeated
+by the consumers of the fixtures should they wish to generate the inputs
+themselves:
+
+	SHA256(seed || label || uint32le(index))
+
+The generated fixtures are plain structs with JSON tags so they can be written
+with encoding/json directly or with the WriteJSON convenience method, which
+produces stable, indented output suitable for checking into a repository.
+
+NOTE: The keys generated by this package are trivially derivable from the seed
+and must never be used to secure real funds.
+*/
+package testvectors
@@ -0,0 +1,350 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package testvectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/txscript"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// labelKey is the derivation label of the pay-to-pubkey-hash keys.
+	labelKey = "key"
+
+	// labelMultiSig is the derivation label of the keys of the multisig
+	// redeem scripts.
+	labelMultiSig = "multisig"
+
+	// labelSpecialTx is the derivation label of the data within the
+	// special transaction payloads.
+	labelSpecialTx = "specialtx"
+
+	// multiSigKeys and multiSigRequired are the total and the required
+	// number of signatures of the generated multisig redeem scripts.
+	multiSigKeys     = 3
+	multiSigRequired = 2
+
+	// qcTxVersion is the version of the generated quorum commitment special
+	// transaction payloads.
+	qcTxVersion = 1
+)
+
+// KeyVector describes a private key along with its encodings and the
+// pay-to-pubkey-hash address and script paying to it.
+type KeyVector struct {
+	Index       uint32 `json:"index"`
+	PrivKey     string `json:"privkey"`
+	WIF         string `json:"wif"`
+	PubKey      string `json:"pubkey"`
+	PubKeyHash  string `json:"pubkeyhash"`
+	Address     string `json:"address"`
+	PkScript    string `json:"pkscript"`
+	PkScriptAsm string `json:"pkscript_asm"`
+}
+
+// ScriptVector describes a multisig redeem script along with the
+// pay-to-script-hash address and script paying to it.
+type ScriptVector struct {
+	Index           uint32   `json:"index"`
+	PubKeys         []string `json:"pubkeys"`
+	Required        int      `json:"required"`
+	RedeemScript    string   `json:"redeemscript"`
+	RedeemScriptAsm string   `json:"redeemscript_asm"`
+	Address         string   `json:"address"`
+	PkScript        string   `json:"pkscript"`
+	PkScriptAsm     string   `json:"pkscript_asm"`
+}
+
+// SpecialTxVector describes a DIP0002 special transaction along with its
+// extra payload.
+type SpecialTxVector struct {
+	Index   uint32 `json:"index"`
+	Type    string `json:"type"`
+	Version int32  `json:"version"`
+	Payload string `json:"payload"`
+	Tx      string `json:"tx"`
+	TxID    string `json:"txid"`
+}
+
+// Fixtures is a full set of test vectors generated from a single seed.
+type Fixtures struct {
+	Seed       string            `json:"seed"`
+	Network    string            `json:"network"`
+	Keys       []KeyVector       `json:"keys"`
+	Scripts    []ScriptVector    `json:"scripts"`
+	SpecialTxs []SpecialTxVector `json:"specialtxs"`
+}
+
+// Derive returns the deterministic 32 bytes for the passed seed, label and
+// index as described in the package documentation.
+func Derive(seed []byte, label string, index uint32) [sha256.Size]byte {
+	var idx [4]byte
+	binary.LittleEndian.PutUint32(idx[:], index)
+
+	h := sha256.New()
+	h.Write(seed)
+	h.Write([]byte(label))
+	h.Write(idx[:])
+
+	var out [sha256.Size]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// deriveKey returns the private key derived from the passed seed, label and
+// index.  The derived bytes are reduced modulo the group order.
+func deriveKey(seed []byte, label string, index uint32) *btcec.PrivateKey {
+	keyBytes := Derive(seed, label, index)
+	privKey, _ := btcec.PrivKeyFromBytes(keyBytes[:])
+	return privKey
+}
+
+// checkCount returns an error when the passed number of vectors to generate
+// is negative.
+func checkCount(count int) error {
+	if count < 0 {
+		return fmt.Errorf("invalid vector count %d", count)
+	}
+	return nil
+}
+
+// disasm returns the disassembly of the passed script, which is always valid
+// since it was generated by this package.
+func disasm(script []byte) string {
+	asm, _ := txscript.DisasmString(script)
+	return asm
+}
+
+// GenerateKeys returns count key vectors derived from the passed seed for the
+// passed network.
+func GenerateKeys(seed []byte, count int, params *chaincfg.Params) ([]KeyVector, error) {
+	if err := checkCount(count); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]KeyVector, 0, count)
+	for i := uint32(0); i < uint32(count); i++ {
+		privKey := deriveKey(seed, labelKey, i)
+		wif, err := btcutil.NewWIF(privKey, params, true)
+		if err != nil {
+			return nil, err
+		}
+		pubKey := privKey.PubKey().SerializeCompressed()
+		pkHash := btcutil.Hash160(pubKey)
+		addr, err := btcutil.NewAddressPubKeyHash(pkHash, params)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		vectors = append(vectors, KeyVector{
+			Index:       i,
+			PrivKey:     hex.EncodeToString(privKey.Serialize()),
+			WIF:         wif.String(),
+			PubKey:      hex.EncodeToString(pubKey),
+			PubKeyHash:  hex.EncodeToString(pkHash),
+			Address:     addr.EncodeAddress(),
+			PkScript:    hex.EncodeToString(pkScript),
+			PkScriptAsm: disasm(pkScript),
+		})
+	}
+
+	return vectors, nil
+}
+
+// GenerateScripts returns count 2-of-3 multisig script vectors derived from
+// the passed seed for the passed network.  The keys of the redeem script at
+// index i are derived with the indices 3i through 3i+2.
+func GenerateScripts(seed []byte, count int, params *chaincfg.Params) ([]ScriptVector, error) {
+	if err := checkCount(count); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]ScriptVector, 0, count)
+	for i := uint32(0); i < uint32(count); i++ {
+		pubKeys := make([]*btcutil.AddressPubKey, 0, multiSigKeys)
+		pubKeyStrs := make([]string, 0, multiSigKeys)
+		for j := uint32(0); j < multiSigKeys; j++ {
+			privKey := deriveKey(seed, labelMultiSig, i*multiSigKeys+j)
+			serialized := privKey.PubKey().SerializeCompressed()
+			pubKey, err := btcutil.NewAddressPubKey(serialized, params)
+			if err != nil {
+				return nil, err
+			}
+			pubKeys = append(pubKeys, pubKey)
+			pubKeyStrs = append(pubKeyStrs, hex.EncodeToString(serialized))
+		}
+
+		redeemScript, err := txscript.MultiSigScript(pubKeys,
+			multiSigRequired)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := btcutil.NewAddressScriptHash(redeemScript, params)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		vectors = append(vectors, ScriptVector{
+			Index:           i,
+			PubKeys:         pubKeyStrs,
+			Required:        multiSigRequired,
+			RedeemScript:    hex.EncodeToString(redeemScript),
+			RedeemScriptAsm: disasm(redeemScript),
+			Address:         addr.EncodeAddress(),
+			PkScript:        hex.EncodeToString(pkScript),
+			PkScriptAsm:     disasm(pkScript),
+		})
+	}
+
+	return vectors, nil
+}
+
+// specialTxVector wraps the passed payload in a special transaction of the
+// passed type spending a derived outpoint and returns its vector.
+func specialTxVector(index uint32, txType wire.TxType, payload []byte,
+	prevHash chainhash.Hash) (SpecialTxVector, error) {
+
+	tx := wire.NewMsgTx(int32(uint32(txType)<<16 | wire.SpecialTxVersion))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, index), nil,
+		nil))
+	tx.ExtraPayload = payload
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return SpecialTxVector{}, err
+	}
+
+	return SpecialTxVector{
+		Index:   index,
+		Type:    txType.String(),
+		Version: tx.Version,
+		Payload: hex.EncodeToString(payload),
+		Tx:      hex.EncodeToString(buf.Bytes()),
+		TxID:    tx.TxHash().String(),
+	}, nil
+}
+
+// GenerateSpecialTxs returns count pairs of special transaction vectors derived
+// from the passed seed.  Each pair consists of a DIP0004 coinbase payload and a
+// quorum commitment payload, both of which use data derived with the index of
+// the pair.
+func GenerateSpecialTxs(seed []byte, count int) ([]SpecialTxVector, error) {
+	if err := checkCount(count); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]SpecialTxVector, 0, count*2)
+	for i := uint32(0); i < uint32(count); i++ {
+		data := Derive(seed, labelSpecialTx, i)
+		prevHash := chainhash.DoubleHashH(data[:])
+		height := int32(binary.LittleEndian.Uint32(data[:4]) >> 8)
+
+		cbTx := wire.CbTx{
+			Version:           2,
+			Height:            height,
+			MerkleRootMNList:  chainhash.HashH(data[:]),
+			MerkleRootQuorums: chainhash.DoubleHashH(prevHash[:]),
+		}
+		var payload bytes.Buffer
+		if err := cbTx.Serialize(&payload); err != nil {
+			return nil, err
+		}
+		vector, err := specialTxVector(i, wire.TxTypeCoinbase,
+			payload.Bytes(), prevHash)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+
+		qc := wire.QuorumCommitment{
+			Version:        wire.QuorumCommitmentIndexedVersion,
+			LLMQType:       wire.LLMQType(data[4]),
+			QuorumHash:     prevHash,
+			QuorumIndex:    int16(data[5] & 0x03),
+			Signers:        make([]bool, 10),
+			ValidMembers:   make([]bool, 10),
+			QuorumVvecHash: chainhash.HashH(prevHash[:]),
+		}
+		for j := range qc.Signers {
+			qc.Signers[j] = data[6+j]&1 == 1
+			qc.ValidMembers[j] = data[16+j]&1 == 1
+		}
+		copy(qc.QuorumPubKey[:], bytes.Repeat(data[:], 2))
+		copy(qc.QuorumSig[:], bytes.Repeat(data[:], 3))
+		copy(qc.MembersSig[:], bytes.Repeat(prevHash[:], 3))
+
+		// The payload of a quorum commitment special transaction is
+		// prefixed by its version and the height of the block it is
+		// mined in.
+		payload.Reset()
+		var prefix [6]byte
+		binary.LittleEndian.PutUint16(prefix[:2], qcTxVersion)
+		binary.LittleEndian.PutUint32(prefix[2:], uint32(height))
+		payload.Write(prefix[:])
+		if err := qc.Serialize(&payload); err != nil {
+			return nil, err
+		}
+		vector, err = specialTxVector(i, wire.TxTypeQuorumCommitment,
+			payload.Bytes(), prevHash)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+
+	return vectors, nil
+}
+
+// Generate returns a full set of fixtures containing count vectors of each
+// kind derived from the passed seed for the passed network.
+func Generate(seed []byte, count int, params *chaincfg.Params) (*Fixtures, error) {
+	keys, err := GenerateKeys(seed, count, params)
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := GenerateScripts(seed, count, params)
+	if err != nil {
+		return nil, err
+	}
+	specialTxs, err := GenerateSpecialTxs(seed, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Fixtures{
+		Seed:       hex.EncodeToString(seed),
+		Network:    params.Name,
+		Keys:       keys,
+		Scripts:    scripts,
+		SpecialTxs: specialTxs,
+	}, nil
+}
+
+// WriteJSON writes the fixtures to w as indented JSON followed by a newline.
+func (f *Fixtures) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package testvectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestGenerateDeterministic ensures the same seed always generates the same
+// fixtures while different seeds generate different ones.
+func TestGenerateDeterministic(t *testing.T) {
+	t.Parallel()
+
+	generate := func(seed string) []byte {
+		f, err := Generate([]byte(seed), 3, &chaincfg.TestNet3Params)
+		if err != nil {
+			t.Fatalf("Generate: unexpected error: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := f.WriteJSON(&buf); err != nil {
+			t.Fatalf("WriteJSON: unexpected error: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first, second := generate("seed"), generate("seed")
+	if !bytes.Equal(first, second) {
+		t.Fatal("fixtures generated from the same seed differ")
+	}
+	if bytes.Equal(first, generate("other seed")) {
+		t.Fatal("fixtures generated from different seeds are equal")
+	}
+
+	if _, err := Generate(nil, -1, &chaincfg.MainNetParams); err == nil {
+		t.Fatal("Generate: did not receive expected error for negative " +
+			"count")
+	}
+}
+
+// TestGenerateConsistent ensures the generated vectors decode back into the
+// values they describe.
+func TestGenerateConsistent(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	f, err := Generate([]byte("seed"), 5, params)
+	if err != nil {
+		t.Fatalf("Generate: unexpected error: %v", err)
+	}
+	if len(f.Keys) != 5 || len(f.Scripts) != 5 || len(f.SpecialTxs) != 10 {
+		t.Fatalf("unexpected number of vectors - got %d keys, %d "+
+			"scripts, %d special txs", len(f.Keys), len(f.Scripts),
+			len(f.SpecialTxs))
+	}
+
+	for _, v := range f.Keys {
+		wif, err := btcutil.DecodeWIF(v.WIF)
+		if err != nil {
+			t.Fatalf("key %d: DecodeWIF: unexpected error: %v",
+				v.Index, err)
+		}
+		if got := hex.EncodeToString(wif.PrivKey.Serialize()); got != v.PrivKey {
+			t.Errorf("key %d: WIF private key %s, want %s", v.Index,
+				got, v.PrivKey)
+		}
+		if got := hex.EncodeToString(wif.SerializePubKey()); got != v.PubKey {
+			t.Errorf("key %d: WIF public key %s, want %s", v.Index,
+				got, v.PubKey)
+		}
+		addr, err := btcutil.DecodeAddress(v.Address, params)
+		if err != nil {
+			t.Fatalf("key %d: DecodeAddress: unexpected error: %v",
+				v.Index, err)
+		}
+		if got := hex.EncodeToString(addr.ScriptAddress()); got != v.PubKeyHash {
+			t.Errorf("key %d: address hash %s, want %s", v.Index,
+				got, v.PubKeyHash)
+		}
+	}
+
+	for _, v := range f.Scripts {
+		redeemScript, _ := hex.DecodeString(v.RedeemScript)
+		addr, err := btcutil.DecodeAddress(v.Address, params)
+		if err != nil {
+			t.Fatalf("script %d: DecodeAddress: unexpected error: %v",
+				v.Index, err)
+		}
+		if !bytes.Equal(addr.ScriptAddress(), btcutil.Hash160(redeemScript)) {
+			t.Errorf("script %d: address does not commit to the "+
+				"redeem script", v.Index)
+		}
+	}
+
+	for i, v := range f.SpecialTxs {
+		serialized, _ := hex.DecodeString(v.Tx)
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(serialized)); err != nil {
+			t.Fatalf("special tx %d: Deserialize: unexpected error: "+
+				"%v", i, err)
+		}
+		if tx.TxType().String() != v.Type {
+			t.Errorf("special tx %d: type %v, want %s", i,
+				tx.TxType(), v.Type)
+		}
+		if hex.EncodeToString(tx.ExtraPayload) != v.Payload {
+			t.Errorf("special tx %d: unexpected payload", i)
+		}
+		if tx.TxHash().String() != v.TxID {
+			t.Errorf("special tx %d: txid %v, want %s", i,
+				tx.TxHash(), v.TxID)
+		}
+		if tx.TxType() == wire.TxTypeCoinbase {
+			if _, err := tx.CbTxPayload(); err != nil {
+				t.Errorf("special tx %d: CbTxPayload: unexpected "+
+					"error: %v", i, err)
+			}
+		}
+	}
+}
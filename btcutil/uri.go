@@ -0,0 +1,91 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URIScheme is the scheme of payment request URIs as defined by BIP0021.
+const URIScheme = "dash"
+
+// PaymentRequest describes a request for payment to an address, optionally
+// for a specific amount, which is encoded as a URI by EncodeURI.
+type PaymentRequest struct {
+	// Address is the address to pay to.  It is required.
+	Address Address
+
+	// Amount is the amount requested.  It is omitted from the URI when
+	// zero.
+	Amount Amount
+
+	// Label is a name associated with the recipient.
+	Label string
+
+	// Message is a message describing the payment to the payer.
+	Message string
+
+	// InstantSend requests the payment be sent via InstantSend.
+	InstantSend bool
+}
+
+// uriEscape percent-encodes the passed string for use as a URI query
+// parameter value.  Unlike url.QueryEscape, spaces are encoded as %20 rather
+// than '+', since BIP0021 does not define the latter and a number of wallets
+// show it literally.
+func uriEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// formatURIAmount formats the passed amount as a decimal number of coins
+// without any trailing zeros or exponent, as required by BIP0021.  The integer
+// amount is formatted directly to avoid any floating point rounding.
+func formatURIAmount(a Amount) string {
+	whole := strconv.FormatInt(int64(a/SatoshiPerBitcoin), 10)
+	frac := int64(a % SatoshiPerBitcoin)
+	if frac == 0 {
+		return whole
+	}
+	fracStr := strconv.FormatInt(frac+SatoshiPerBitcoin, 10)[1:]
+	return whole + "." + strings.TrimRight(fracStr, "0")
+}
+
+// EncodeURI returns the BIP0021 payment request URI for the request, such as
+// "dash:XsV4GHVKGTjQFvwB7c6mYsGV3Mxf7iser6?amount=1.5&label=Shop".
+//
+// The result is suitable for use as the payload of a QR code.  The scheme is
+// lowercase, the address retains its case since base58 encoding is case
+// sensitive, and parameter values are percent-encoded using %20 for spaces.
+func (r *PaymentRequest) EncodeURI() (string, error) {
+	if r.Address == nil {
+		return "", errors.New("payment request has no address")
+	}
+	if r.Amount < 0 || r.Amount > MaxSatoshi {
+		return "", errors.New("payment request amount is out of range")
+	}
+
+	var params []string
+	if r.Amount != 0 {
+		params = append(params, "amount="+formatURIAmount(r.Amount))
+	}
+	if r.Label != "" {
+		params = append(params, "label="+uriEscape(r.Label))
+	}
+	if r.Message != "" {
+		params = append(params, "message="+uriEscape(r.Message))
+	}
+	if r.InstantSend {
+		params = append(params, "IS=1")
+	}
+
+	uri := URIScheme + ":" + r.Address.EncodeAddress()
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri, nil
+}
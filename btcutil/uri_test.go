@@ -0,0 +1,75 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil_test
+
+import (
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestEncodeURI ensures payment requests are encoded to the expected URIs.
+func TestEncodeURI(t *testing.T) {
+	const addrStr = "Xxk85srgGaZtuLhZLy54kq1jcJNJveoc3X"
+	addr, err := btcutil.DecodeAddress(addrStr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  btcutil.PaymentRequest
+		want string
+	}{{
+		name: "address only",
+		req:  btcutil.PaymentRequest{Address: addr},
+		want: "dash:" + addrStr,
+	}, {
+		name: "whole amount",
+		req:  btcutil.PaymentRequest{Address: addr, Amount: 2e8},
+		want: "dash:" + addrStr + "?amount=2",
+	}, {
+		name: "fractional amount",
+		req:  btcutil.PaymentRequest{Address: addr, Amount: 150000010},
+		want: "dash:" + addrStr + "?amount=1.5000001",
+	}, {
+		name: "smallest amount",
+		req:  btcutil.PaymentRequest{Address: addr, Amount: 1},
+		want: "dash:" + addrStr + "?amount=0.00000001",
+	}, {
+		name: "all parameters",
+		req: btcutil.PaymentRequest{
+			Address:     addr,
+			Amount:      1e7,
+			Label:       "Corner Shop",
+			Message:     "Order #12 & tip",
+			InstantSend: true,
+		},
+		want: "dash:" + addrStr + "?amount=0.1&label=Corner%20Shop" +
+			"&message=Order%20%2312%20%26%20tip&IS=1",
+	}}
+	for _, test := range tests {
+		got, err := test.req.EncodeURI()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	invalid := []btcutil.PaymentRequest{
+		{},
+		{Address: addr, Amount: -1},
+		{Address: addr, Amount: btcutil.MaxSatoshi + 1},
+	}
+	for i, req := range invalid {
+		if _, err := req.EncodeURI(); err == nil {
+			t.Errorf("invalid request #%d: expected error", i)
+		}
+	}
+}
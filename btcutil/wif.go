@@ -40,8 +40,8 @@ type WIF struct {
 	// uncompressed (65-byte) one.
 	CompressPubKey bool
 
-	// netID is the bitcoin network identifier byte used when
-	// WIF encoding the private key.
+	// netID is the network identifier byte used when WIF encoding the
+	// private key.
 	netID byte
 }
 
@@ -56,8 +56,22 @@ func NewWIF(privKey *btcec.PrivateKey, net *chaincfg.Params, compress bool) (*WI
 	return &WIF{privKey, compress, net.PrivateKeyID}, nil
 }
 
+// EncodePrivateKey returns the Wallet Import Format string encoding of the
+// passed private key for the passed network.  The compress argument specifies
+// whether the address controlled by the key is created from the compressed
+// serialization of its public key, which is the case for all keys generated by
+// Dash Core.  The result can be imported with the importprivkey RPC of Dash
+// Core and matches the output of its dumpprivkey RPC.
+func EncodePrivateKey(privKey *btcec.PrivateKey, net *chaincfg.Params, compress bool) (string, error) {
+	wif, err := NewWIF(privKey, net, compress)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
 // IsForNet returns whether or not the decoded WIF structure is associated
-// with the passed bitcoin network.
+// with the passed network.
 func (w *WIF) IsForNet(net *chaincfg.Params) bool {
 	return w.netID == net.PrivateKeyID
 }
@@ -68,8 +82,9 @@ func (w *WIF) IsForNet(net *chaincfg.Params) bool {
 // The WIF string must be a base58-encoded string of the following byte
 // sequence:
 //
-//   - 1 byte to identify the network, must be 0x80 for mainnet or 0xef for
-//     either testnet3 or the regression test network
+//   - 1 byte to identify the network, which is the PrivateKeyID of the
+//     network parameters: 0xcc for mainnet, or 0xef for testnet, the
+//     regression test network and devnets
 //   - 32 bytes of a binary-encoded, big-endian, zero-padded private key
 //   - Optional 1 byte (equal to 0x01) if the address being imported or exported
 //     was created by taking the RIPEMD160 after SHA256 hash of a serialized
@@ -77,6 +92,11 @@ func (w *WIF) IsForNet(net *chaincfg.Params) bool {
 //   - 4 bytes of checksum, must equal the first four bytes of the double SHA256
 //     of every byte before the checksum in this sequence
 //
+// Keys exported by Dash Core with dumpprivkey start with X (compressed) or 7
+// (uncompressed) for mainnet and c (compressed) or 9 (uncompressed) for the
+// test networks.  Use IsForNet to ensure the decoded key is for the expected
+// network.
+//
 // If the base58-decoded byte sequence does not match this, DecodeWIF will
 // return a non-nil error.  ErrMalformedPrivateKey is returned when the WIF
 // is of an impossible length or the expected compressed pubkey magic number
@@ -115,9 +135,12 @@ func DecodeWIF(wif string) (*WIF, error) {
 		return nil, ErrChecksumMismatch
 	}
 
+	// The private key is copied when it is parsed, so wipe the decoded
+	// bytes to avoid leaving a copy of it in memory.
 	netID := decoded[0]
 	privKeyBytes := decoded[1 : 1+btcec.PrivKeyBytesLen]
 	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+	SecretBytes(decoded).Zero()
 	return &WIF{privKey, compress, netID}, nil
 }
 
@@ -134,9 +157,16 @@ func (w *WIF) String() string {
 		encodeLen++
 	}
 
-	a := make([]byte, 0, encodeLen)
+	// The serialized private key and the buffer it is copied into are
+	// wiped once encoded.  Note the returned string is a copy of the
+	// private key that can't be wiped.
+	privKeyBytes := SecretBytes(w.PrivKey.Serialize())
+	buf := NewSecretBytes(encodeLen)
+	defer buf.Zero()
+	a := buf[:0]
 	a = append(a, w.netID)
-	a = append(a, w.PrivKey.Serialize()...)
+	a = append(a, privKeyBytes...)
+	privKeyBytes.Zero()
 	if w.CompressPubKey {
 		a = append(a, compressMagic)
 	}
@@ -145,6 +175,16 @@ func (w *WIF) String() string {
 	return base58.Encode(a)
 }
 
+// Zero clears the private key of the WIF structure.  This can be used to
+// explicitly clear key material from memory once the key is no longer needed.
+// The WIF structure must not be used after calling Zero.
+func (w *WIF) Zero() {
+	if w.PrivKey != nil {
+		w.PrivKey.Zero()
+	}
+	w.PrivKey = nil
+}
+
 // SerializePubKey serializes the associated public key of the imported or
 // exported private key in either a compressed or uncompressed format.  The
 // serialization format chosen depends on the value of w.CompressPubKey.
@@ -158,3 +158,83 @@ func TestEncodeDecodeWIF(t *testing.T) {
 		}
 	})
 }
+
+// TestEncodePrivateKey ensures private keys round trip through their Wallet
+// Import Format encoding using the Dash network identifier bytes.
+func TestEncodePrivateKey(t *testing.T) {
+	privateKey := []byte{
+		0xf1, 0xdf, 0x40, 0x0b, 0x33, 0x56, 0x60, 0x44,
+		0x14, 0xb0, 0x75, 0x59, 0x63, 0x48, 0xca, 0x09,
+		0x85, 0x62, 0x7e, 0xb2, 0xf3, 0xb3, 0x83, 0x32,
+		0xc6, 0x51, 0x08, 0x20, 0xa2, 0x14, 0x3d, 0xc8}
+	priv, _ := btcec.PrivKeyFromBytes(privateKey)
+
+	tests := []struct {
+		name     string
+		net      *chaincfg.Params
+		otherNet *chaincfg.Params
+		compress bool
+		wif      string
+	}{
+		{
+			name:     "mainnet compressed",
+			net:      &chaincfg.MainNetParams,
+			otherNet: &chaincfg.TestNet3Params,
+			compress: true,
+			wif:      "XKPoN4hV5wjG4cPw9daWp5ppPs1kZ9v1qnp4bqeRb79zKQJAq1fm",
+		},
+		{
+			name:     "mainnet uncompressed",
+			net:      &chaincfg.MainNetParams,
+			otherNet: &chaincfg.TestNet3Params,
+			compress: false,
+			wif:      "7sPPHZ7127xo1NAGtoMHGeoBHWTTBTVKmawchyLMXPEpRbrhbGN",
+		},
+		{
+			name:     "testnet compressed",
+			net:      &chaincfg.TestNet3Params,
+			otherNet: &chaincfg.MainNetParams,
+			compress: true,
+		},
+		{
+			name:     "regtest uncompressed",
+			net:      &chaincfg.RegressionNetParams,
+			otherNet: &chaincfg.MainNetParams,
+			compress: false,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := EncodePrivateKey(priv, test.net, test.compress)
+		if err != nil {
+			t.Fatalf("%s: EncodePrivateKey: unexpected error: %v",
+				test.name, err)
+		}
+		if test.wif != "" && got != test.wif {
+			t.Fatalf("%s: EncodePrivateKey: got %s, want %s",
+				test.name, got, test.wif)
+		}
+
+		wif, err := DecodeWIF(got)
+		if err != nil {
+			t.Fatalf("%s: DecodeWIF: unexpected error: %v", test.name,
+				err)
+		}
+		if !bytes.Equal(wif.PrivKey.Serialize(), privateKey) {
+			t.Fatalf("%s: DecodeWIF: wrong private key %x", test.name,
+				wif.PrivKey.Serialize())
+		}
+		if wif.CompressPubKey != test.compress {
+			t.Fatalf("%s: DecodeWIF: got compressed %v, want %v",
+				test.name, wif.CompressPubKey, test.compress)
+		}
+		if !wif.IsForNet(test.net) || wif.IsForNet(test.otherNet) {
+			t.Fatalf("%s: DecodeWIF: decoded key for wrong network",
+				test.name)
+		}
+	}
+
+	if _, err := EncodePrivateKey(priv, nil, true); err == nil {
+		t.Fatal("EncodePrivateKey: expected error for missing network")
+	}
+}
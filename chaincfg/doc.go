@@ -58,4 +58,9 @@
 // non-standard network.  As a general rule of thumb, all network parameters
 // should be unique to the network, but parameter collisions can still occur
 // (unfortunately, this is the case with regtest and testnet3 sharing magics).
+//
+// Dash devnets are such non-standard networks.  Their parameters are a copy of
+// DevNetParams with the devnet name, the magic bytes returned by DevNetMagic
+// for that name and the hash of the devnet genesis block set, which Register
+// verifies.
 package chaincfg
@@ -5,6 +5,8 @@
 package chaincfg
 
 import (
+	"errors"
+	"math/big"
 	"time"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
@@ -12,8 +14,55 @@ import (
 )
 
 // genesisCoinbaseTx is the coinbase transaction for the genesis blocks for
-// the main network, regression test network, and test network (version 3).
+// the main network and test network (version 3).
 var genesisCoinbaseTx = wire.MsgTx{
+	Version: 1,
+	TxIn: []*wire.TxIn{
+		{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 0xffffffff,
+			},
+			SignatureScript: []byte{
+				0x04, 0xff, 0xff, 0x00, 0x1d, 0x01, 0x04, 0x4c, /* |.......L| */
+				0x59, 0x57, 0x69, 0x72, 0x65, 0x64, 0x20, 0x30, /* |YWired 0| */
+				0x39, 0x2f, 0x4a, 0x61, 0x6e, 0x2f, 0x32, 0x30, /* |9/Jan/20| */
+				0x31, 0x34, 0x20, 0x54, 0x68, 0x65, 0x20, 0x47, /* |14 The G| */
+				0x72, 0x61, 0x6e, 0x64, 0x20, 0x45, 0x78, 0x70, /* |rand Exp| */
+				0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x20, /* |eriment | */
+				0x47, 0x6f, 0x65, 0x73, 0x20, 0x4c, 0x69, 0x76, /* |Goes Liv| */
+				0x65, 0x3a, 0x20, 0x4f, 0x76, 0x65, 0x72, 0x73, /* |e: Overs| */
+				0x74, 0x6f, 0x63, 0x6b, 0x2e, 0x63, 0x6f, 0x6d, /* |tock.com| */
+				0x20, 0x49, 0x73, 0x20, 0x4e, 0x6f, 0x77, 0x20, /* | Is Now | */
+				0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6e, /* |Acceptin| */
+				0x67, 0x20, 0x42, 0x69, 0x74, 0x63, 0x6f, 0x69, /* |g Bitcoi| */
+				0x6e, 0x73, /* |ns| */
+			},
+			Sequence: 0xffffffff,
+		},
+	},
+	TxOut: []*wire.TxOut{
+		{
+			Value: 0x12a05f200,
+			PkScript: []byte{
+				0x41, 0x04, 0x01, 0x84, 0x71, 0x0f, 0xa6, 0x89, /* |A...q...| */
+				0xad, 0x50, 0x23, 0x69, 0x0c, 0x80, 0xf3, 0xa4, /* |.P#i....| */
+				0x9c, 0x8f, 0x13, 0xf8, 0xd4, 0x5b, 0x8c, 0x85, /* |.....[..| */
+				0x7f, 0xbc, 0xbc, 0x8b, 0xc4, 0xa8, 0xe4, 0xd3, /* |........| */
+				0xeb, 0x4b, 0x10, 0xf4, 0xd4, 0x60, 0x4f, 0xa0, /* |.K...`O.| */
+				0x8d, 0xce, 0x60, 0x1a, 0xaf, 0x0f, 0x47, 0x02, /* |..`...G.| */
+				0x16, 0xfe, 0x1b, 0x51, 0x85, 0x0b, 0x4a, 0xcf, /* |...Q..J.| */
+				0x21, 0xb1, 0x79, 0xc4, 0x50, 0x70, 0xac, 0x7b, /* |!.y.Pp.{| */
+				0x03, 0xa9, 0xac, /* |...| */
+			},
+		},
+	},
+	LockTime: 0,
+}
+
+// bitcoinGenesisCoinbaseTx is the coinbase transaction of the Bitcoin genesis
+// blocks the regression test, simulation test and signet networks still use.
+var bitcoinGenesisCoinbaseTx = wire.MsgTx{
 	Version: 1,
 	TxIn: []*wire.TxIn{
 		{
@@ -56,21 +105,22 @@ var genesisCoinbaseTx = wire.MsgTx{
 }
 
 // genesisHash is the hash of the first block in the block chain for the main
-// network (genesis block).
+// network (genesis block).  Its X11 hash, which Dash identifies it by, is
+// 00000ffd590b1485b3caadc19b22e6379c733355108f107a430458cdf3407ab6.
 var genesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
-	0x6f, 0xe2, 0x8c, 0x0a, 0xb6, 0xf1, 0xb3, 0x72,
-	0xc1, 0xa6, 0xa2, 0x46, 0xae, 0x63, 0xf7, 0x4f,
-	0x93, 0x1e, 0x83, 0x65, 0xe1, 0x5a, 0x08, 0x9c,
-	0x68, 0xd6, 0x19, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xa4, 0xb0, 0x9e, 0xc6, 0x04, 0x78, 0xce, 0x10,
+	0xb2, 0xbd, 0xa1, 0x54, 0xb3, 0x49, 0xe5, 0x22,
+	0x2e, 0x3b, 0x9a, 0x5f, 0xa8, 0xfd, 0xd9, 0x70,
+	0x0f, 0xdd, 0x6e, 0xb0, 0x44, 0xc4, 0x9f, 0x08,
 })
 
 // genesisMerkleRoot is the hash of the first transaction in the genesis block
 // for the main network.
 var genesisMerkleRoot = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
-	0x3b, 0xa3, 0xed, 0xfd, 0x7a, 0x7b, 0x12, 0xb2,
-	0x7a, 0xc7, 0x2c, 0x3e, 0x67, 0x76, 0x8f, 0x61,
-	0x7f, 0xc8, 0x1b, 0xc3, 0x88, 0x8a, 0x51, 0x32,
-	0x3a, 0x9f, 0xb8, 0xaa, 0x4b, 0x1e, 0x5e, 0x4a,
+	0xc7, 0x62, 0xa6, 0x56, 0x7f, 0x3c, 0xc0, 0x92,
+	0xf0, 0x68, 0x4b, 0xb6, 0x2b, 0x7e, 0x00, 0xa8,
+	0x48, 0x90, 0xb9, 0x90, 0xf0, 0x7c, 0xc7, 0x1a,
+	0x6b, 0xb5, 0x8d, 0x64, 0xb9, 0x8e, 0x02, 0xe0,
 })
 
 // genesisBlock defines the genesis block of the block chain which serves as the
@@ -79,14 +129,23 @@ var genesisBlock = wire.MsgBlock{
 	Header: wire.BlockHeader{
 		Version:    1,
 		PrevBlock:  chainhash.Hash{},         // 0000000000000000000000000000000000000000000000000000000000000000
-		MerkleRoot: genesisMerkleRoot,        // 4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b
-		Timestamp:  time.Unix(0x495fab29, 0), // 2009-01-03 18:15:05 +0000 UTC
-		Bits:       0x1d00ffff,               // 486604799 [00000000ffff0000000000000000000000000000000000000000000000000000]
-		Nonce:      0x7c2bac1d,               // 2083236893
+		MerkleRoot: genesisMerkleRoot,        // e0028eb9648db56b1ac77cf090b99048a8007e2bb64b68f092c03c7f56a662c7
+		Timestamp:  time.Unix(1390095618, 0), // 2014-01-19 01:40:18 +0000 UTC
+		Bits:       0x1e0ffff0,               // 504365040 [00000ffff0000000000000000000000000000000000000000000000000000000]
+		Nonce:      28917698,
 	},
 	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
 }
 
+// bitcoinGenesisMerkleRoot is the hash of the first transaction in the Bitcoin
+// genesis blocks.
+var bitcoinGenesisMerkleRoot = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
+	0x3b, 0xa3, 0xed, 0xfd, 0x7a, 0x7b, 0x12, 0xb2,
+	0x7a, 0xc7, 0x2c, 0x3e, 0x67, 0x76, 0x8f, 0x61,
+	0x7f, 0xc8, 0x1b, 0xc3, 0x88, 0x8a, 0x51, 0x32,
+	0x3a, 0x9f, 0xb8, 0xaa, 0x4b, 0x1e, 0x5e, 0x4a,
+})
+
 // regTestGenesisHash is the hash of the first block in the block chain for the
 // regression test network (genesis block).
 var regTestGenesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
@@ -97,9 +156,9 @@ var regTestGenesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet
 })
 
 // regTestGenesisMerkleRoot is the hash of the first transaction in the genesis
-// block for the regression test network.  It is the same as the merkle root for
-// the main network.
-var regTestGenesisMerkleRoot = genesisMerkleRoot
+// block for the regression test network.  It is the same as the merkle root of
+// the Bitcoin genesis blocks.
+var regTestGenesisMerkleRoot = bitcoinGenesisMerkleRoot
 
 // regTestGenesisBlock defines the genesis block of the block chain which serves
 // as the public transaction ledger for the regression test network.
@@ -112,16 +171,17 @@ var regTestGenesisBlock = wire.MsgBlock{
 		Bits:       0x207fffff,               // 545259519 [7fffff0000000000000000000000000000000000000000000000000000000000]
 		Nonce:      2,
 	},
-	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
+	Transactions: []*wire.MsgTx{&bitcoinGenesisCoinbaseTx},
 }
 
 // testNet3GenesisHash is the hash of the first block in the block chain for the
-// test network (version 3).
+// test network (version 3).  Its X11 hash, which Dash identifies it by, is
+// 00000bafbc94add76cb75e2ec92894837288a481e5c005f6563d91623bf8bc2c.
 var testNet3GenesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
-	0x43, 0x49, 0x7f, 0xd7, 0xf8, 0x26, 0x95, 0x71,
-	0x08, 0xf4, 0xa3, 0x0f, 0xd9, 0xce, 0xc3, 0xae,
-	0xba, 0x79, 0x97, 0x20, 0x84, 0xe9, 0x0e, 0xad,
-	0x01, 0xea, 0x33, 0x09, 0x00, 0x00, 0x00, 0x00,
+	0x7b, 0x45, 0xfd, 0xf9, 0x62, 0x8f, 0x71, 0xdb,
+	0xb6, 0xdc, 0x25, 0x88, 0xe9, 0xd4, 0xa9, 0xdd,
+	0x3b, 0x54, 0xdf, 0x27, 0xfb, 0xc2, 0x7c, 0x6e,
+	0x73, 0x72, 0x4c, 0xa5, 0x75, 0x56, 0x97, 0x12,
 })
 
 // testNet3GenesisMerkleRoot is the hash of the first transaction in the genesis
@@ -135,10 +195,10 @@ var testNet3GenesisBlock = wire.MsgBlock{
 	Header: wire.BlockHeader{
 		Version:    1,
 		PrevBlock:  chainhash.Hash{},          // 0000000000000000000000000000000000000000000000000000000000000000
-		MerkleRoot: testNet3GenesisMerkleRoot, // 4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b
-		Timestamp:  time.Unix(1296688602, 0),  // 2011-02-02 23:16:42 +0000 UTC
-		Bits:       0x1d00ffff,                // 486604799 [00000000ffff0000000000000000000000000000000000000000000000000000]
-		Nonce:      0x18aea41a,                // 414098458
+		MerkleRoot: testNet3GenesisMerkleRoot, // e0028eb9648db56b1ac77cf090b99048a8007e2bb64b68f092c03c7f56a662c7
+		Timestamp:  time.Unix(1390666206, 0),  // 2014-01-25 16:10:06 +0000 UTC
+		Bits:       0x1e0ffff0,                // 504365040 [00000ffff0000000000000000000000000000000000000000000000000000000]
+		Nonce:      3861367235,
 	},
 	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
 }
@@ -153,9 +213,9 @@ var simNetGenesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet
 })
 
 // simNetGenesisMerkleRoot is the hash of the first transaction in the genesis
-// block for the simulation test network.  It is the same as the merkle root for
-// the main network.
-var simNetGenesisMerkleRoot = genesisMerkleRoot
+// block for the simulation test network.  It is the same as the merkle root of
+// the Bitcoin genesis blocks.
+var simNetGenesisMerkleRoot = bitcoinGenesisMerkleRoot
 
 // simNetGenesisBlock defines the genesis block of the block chain which serves
 // as the public transaction ledger for the simulation test network.
@@ -168,7 +228,7 @@ var simNetGenesisBlock = wire.MsgBlock{
 		Bits:       0x207fffff,               // 545259519 [7fffff0000000000000000000000000000000000000000000000000000000000]
 		Nonce:      2,
 	},
-	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
+	Transactions: []*wire.MsgTx{&bitcoinGenesisCoinbaseTx},
 }
 
 // sigNetGenesisHash is the hash of the first block in the block chain for the
@@ -181,9 +241,9 @@ var sigNetGenesisHash = chainhash.Hash{
 }
 
 // sigNetGenesisMerkleRoot is the hash of the first transaction in the genesis
-// block for the signet test network. It is the same as the merkle root for
-// the main network.
-var sigNetGenesisMerkleRoot = genesisMerkleRoot
+// block for the signet test network. It is the same as the merkle root of
+// the Bitcoin genesis blocks.
+var sigNetGenesisMerkleRoot = bitcoinGenesisMerkleRoot
 
 // sigNetGenesisBlock defines the genesis block of the block chain which serves
 // as the public transaction ledger for the signet test network.
@@ -196,5 +256,119 @@ var sigNetGenesisBlock = wire.MsgBlock{
 		Bits:       0x1e0377ae,               // 503543726 [00000377ae000000000000000000000000000000000000000000000000000000]
 		Nonce:      52613770,
 	},
-	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
+	Transactions: []*wire.MsgTx{&bitcoinGenesisCoinbaseTx},
+}
+
+// MaxDevNetNameLen is the maximum length of devnet names.  The name is included
+// in the signature script of the coinbase of the devnet genesis block, which
+// is limited to 100 bytes.
+const MaxDevNetNameLen = 90
+
+// devNetGenesisReward is the amount paid by the coinbase of devnet genesis
+// blocks, which is 50 DASH.
+const devNetGenesisReward = 50 * 1e8
+
+// ErrInvalidDevNetName describes an error where a devnet name is empty or
+// longer than MaxDevNetNameLen.
+var ErrInvalidDevNetName = errors.New("devnet name must not be empty or " +
+	"longer than 90 bytes")
+
+// hashToBig converts a chainhash.Hash into a big.Int that can be used to
+// perform math comparisons.
+func hashToBig(hash *chainhash.Hash) *big.Int {
+	// A Hash is in little-endian, but the big package wants the bytes in
+	// big-endian, so reverse them.
+	buf := *hash
+	blen := len(buf)
+	for i := 0; i < blen/2; i++ {
+		buf[i], buf[blen-1-i] = buf[blen-1-i], buf[i]
+	}
+
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// compactToBig is a copy of the blockchain.CompactToBig function.  We copy it
+// here so we don't run into a circular dependency.
+func compactToBig(compact uint32) *big.Int {
+	// Extract the mantissa, sign bit, and exponent.
+	mantissa := compact & 0x007fffff
+	isNegative := compact&0x00800000 != 0
+	exponent := uint(compact >> 24)
+
+	// Since the base for the exponent is 256, the exponent can be treated
+	// as the number of bytes to represent the full 256-bit number.  So,
+	// treat the exponent as the number of bytes and shift the mantissa
+	// right or left accordingly.  This is equivalent to:
+	// N = mantissa * 256^(exponent-3)
+	var bn *big.Int
+	if exponent <= 3 {
+		mantissa >>= 8 * (3 - exponent)
+		bn = big.NewInt(int64(mantissa))
+	} else {
+		bn = big.NewInt(int64(mantissa))
+		bn.Lsh(bn, 8*(exponent-3))
+	}
+
+	// Make it negative if the sign bit is set.
+	if isNegative {
+		bn = bn.Neg(bn)
+	}
+
+	return bn
+}
+
+// devNetGenesisBlock returns the genesis block of the devnet with the passed
+// name, which is built on top of the passed genesis block shared by all
+// devnets.
+//
+// Like Dash Core, the coinbase of the block pushes the height and the full
+// devnet name, which is the name prefixed with "devnet-", and burns the reward.
+// The block is a second after the genesis block and has the lowest nonce for
+// which the X11 hash satisfies the proof of work of the difficulty of the
+// genesis block, so the block only depends on the name and the genesis block.
+// Should no nonce satisfy it, the timestamp is bumped by a second and the
+// nonces are searched again.
+func devNetGenesisBlock(name string, genesis *wire.MsgBlock) *wire.MsgBlock {
+	fullName := []byte("devnet-" + name)
+	sigScript := []byte{0x51} // OP_1
+	if len(fullName) <= 75 {
+		sigScript = append(sigScript, byte(len(fullName)))
+	} else {
+		sigScript = append(sigScript, 0x4c, byte(len(fullName))) // OP_PUSHDATA1
+	}
+	sigScript = append(sigScript, fullName...)
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  sigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(&wire.TxOut{
+		Value:    devNetGenesisReward,
+		PkScript: []byte{0x6a}, // OP_RETURN
+	})
+
+	block := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    4,
+			PrevBlock:  genesis.BlockHash(),
+			MerkleRoot: coinbase.TxHash(),
+			Timestamp:  genesis.Header.Timestamp.Add(time.Second),
+			Bits:       genesis.Header.Bits,
+		},
+		Transactions: []*wire.MsgTx{coinbase},
+	}
+
+	target := compactToBig(block.Header.Bits)
+	for {
+		hash := block.Header.PowHash()
+		if hashToBig(&hash).Cmp(target) <= 0 {
+			return block
+		}
+		block.Header.Nonce++
+		if block.Header.Nonce == 0 {
+			block.Header.Timestamp = block.Header.Timestamp.Add(time.Second)
+		}
+	}
 }
@@ -35,6 +35,15 @@ func TestGenesisBlock(t *testing.T) {
 			"appear valid - got %v, want %v", spew.Sdump(hash),
 			spew.Sdump(MainNetParams.GenesisHash))
 	}
+
+	// Check the X11 hash of the block against the hash Dash identifies it
+	// by.
+	powHash := MainNetParams.GenesisBlock.Header.PowHash()
+	wantPowHash := "00000ffd590b1485b3caadc19b22e6379c733355108f107a430458cdf3407ab6"
+	if powHash.String() != wantPowHash {
+		t.Fatalf("TestGenesisBlock: Genesis block X11 hash does not "+
+			"appear valid - got %v, want %v", powHash, wantPowHash)
+	}
 }
 
 // TestRegTestGenesisBlock tests the genesis block of the regression test
@@ -89,6 +98,16 @@ func TestTestNet3GenesisBlock(t *testing.T) {
 			"not appear valid - got %v, want %v", spew.Sdump(hash),
 			spew.Sdump(TestNet3Params.GenesisHash))
 	}
+
+	// Check the X11 hash of the block against the hash Dash identifies it
+	// by.
+	powHash := TestNet3Params.GenesisBlock.Header.PowHash()
+	wantPowHash := "00000bafbc94add76cb75e2ec92894837288a481e5c005f6563d91623bf8bc2c"
+	if powHash.String() != wantPowHash {
+		t.Fatalf("TestTestNet3GenesisBlock: Genesis block X11 hash "+
+			"does not appear valid - got %v, want %v", powHash,
+			wantPowHash)
+	}
 }
 
 // TestSimNetGenesisBlock tests the genesis block of the simulation test network
@@ -152,38 +171,41 @@ var genesisBlockBytes = []byte{
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
-	0x00, 0x00, 0x00, 0x00, 0x3b, 0xa3, 0xed, 0xfd, /* |....;...| */
-	0x7a, 0x7b, 0x12, 0xb2, 0x7a, 0xc7, 0x2c, 0x3e, /* |z{..z.,>| */
-	0x67, 0x76, 0x8f, 0x61, 0x7f, 0xc8, 0x1b, 0xc3, /* |gv.a....| */
-	0x88, 0x8a, 0x51, 0x32, 0x3a, 0x9f, 0xb8, 0xaa, /* |..Q2:...| */
-	0x4b, 0x1e, 0x5e, 0x4a, 0x29, 0xab, 0x5f, 0x49, /* |K.^J)._I| */
-	0xff, 0xff, 0x00, 0x1d, 0x1d, 0xac, 0x2b, 0x7c, /* |......+|| */
+	0x00, 0x00, 0x00, 0x00, 0xc7, 0x62, 0xa6, 0x56, /* |.....b.V| */
+	0x7f, 0x3c, 0xc0, 0x92, 0xf0, 0x68, 0x4b, 0xb6, /* |.<...hK.| */
+	0x2b, 0x7e, 0x00, 0xa8, 0x48, 0x90, 0xb9, 0x90, /* |+~..H...| */
+	0xf0, 0x7c, 0xc7, 0x1a, 0x6b, 0xb5, 0x8d, 0x64, /* |.|..k..d| */
+	0xb9, 0x8e, 0x02, 0xe0, 0x02, 0x2d, 0xdb, 0x52, /* |.....-.R| */
+	0xf0, 0xff, 0x0f, 0x1e, 0xc2, 0x3f, 0xb9, 0x01, /* |.....?..| */
 	0x01, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, /* |........| */
-	0xff, 0xff, 0x4d, 0x04, 0xff, 0xff, 0x00, 0x1d, /* |..M.....| */
-	0x01, 0x04, 0x45, 0x54, 0x68, 0x65, 0x20, 0x54, /* |..EThe T| */
-	0x69, 0x6d, 0x65, 0x73, 0x20, 0x30, 0x33, 0x2f, /* |imes 03/| */
-	0x4a, 0x61, 0x6e, 0x2f, 0x32, 0x30, 0x30, 0x39, /* |Jan/2009| */
-	0x20, 0x43, 0x68, 0x61, 0x6e, 0x63, 0x65, 0x6c, /* | Chancel| */
-	0x6c, 0x6f, 0x72, 0x20, 0x6f, 0x6e, 0x20, 0x62, /* |lor on b| */
-	0x72, 0x69, 0x6e, 0x6b, 0x20, 0x6f, 0x66, 0x20, /* |rink of | */
-	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x20, 0x62, /* |second b| */
-	0x61, 0x69, 0x6c, 0x6f, 0x75, 0x74, 0x20, 0x66, /* |ailout f| */
-	0x6f, 0x72, 0x20, 0x62, 0x61, 0x6e, 0x6b, 0x73, /* |or banks| */
-	0xff, 0xff, 0xff, 0xff, 0x01, 0x00, 0xf2, 0x05, /* |........| */
-	0x2a, 0x01, 0x00, 0x00, 0x00, 0x43, 0x41, 0x04, /* |*....CA.| */
-	0x67, 0x8a, 0xfd, 0xb0, 0xfe, 0x55, 0x48, 0x27, /* |g....UH'| */
-	0x19, 0x67, 0xf1, 0xa6, 0x71, 0x30, 0xb7, 0x10, /* |.g..q0..| */
-	0x5c, 0xd6, 0xa8, 0x28, 0xe0, 0x39, 0x09, 0xa6, /* |\..(.9..| */
-	0x79, 0x62, 0xe0, 0xea, 0x1f, 0x61, 0xde, 0xb6, /* |yb...a..| */
-	0x49, 0xf6, 0xbc, 0x3f, 0x4c, 0xef, 0x38, 0xc4, /* |I..?L.8.| */
-	0xf3, 0x55, 0x04, 0xe5, 0x1e, 0xc1, 0x12, 0xde, /* |.U......| */
-	0x5c, 0x38, 0x4d, 0xf7, 0xba, 0x0b, 0x8d, 0x57, /* |\8M....W| */
-	0x8a, 0x4c, 0x70, 0x2b, 0x6b, 0xf1, 0x1d, 0x5f, /* |.Lp+k.._|*/
-	0xac, 0x00, 0x00, 0x00, 0x00, /* |.....|    */
+	0xff, 0xff, 0x62, 0x04, 0xff, 0xff, 0x00, 0x1d, /* |..b.....| */
+	0x01, 0x04, 0x4c, 0x59, 0x57, 0x69, 0x72, 0x65, /* |..LYWire| */
+	0x64, 0x20, 0x30, 0x39, 0x2f, 0x4a, 0x61, 0x6e, /* |d 09/Jan| */
+	0x2f, 0x32, 0x30, 0x31, 0x34, 0x20, 0x54, 0x68, /* |/2014 Th| */
+	0x65, 0x20, 0x47, 0x72, 0x61, 0x6e, 0x64, 0x20, /* |e Grand | */
+	0x45, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, /* |Experime| */
+	0x6e, 0x74, 0x20, 0x47, 0x6f, 0x65, 0x73, 0x20, /* |nt Goes | */
+	0x4c, 0x69, 0x76, 0x65, 0x3a, 0x20, 0x4f, 0x76, /* |Live: Ov| */
+	0x65, 0x72, 0x73, 0x74, 0x6f, 0x63, 0x6b, 0x2e, /* |erstock.| */
+	0x63, 0x6f, 0x6d, 0x20, 0x49, 0x73, 0x20, 0x4e, /* |com Is N| */
+	0x6f, 0x77, 0x20, 0x41, 0x63, 0x63, 0x65, 0x70, /* |ow Accep| */
+	0x74, 0x69, 0x6e, 0x67, 0x20, 0x42, 0x69, 0x74, /* |ting Bit| */
+	0x63, 0x6f, 0x69, 0x6e, 0x73, 0xff, 0xff, 0xff, /* |coins...| */
+	0xff, 0x01, 0x00, 0xf2, 0x05, 0x2a, 0x01, 0x00, /* |.....*..| */
+	0x00, 0x00, 0x43, 0x41, 0x04, 0x01, 0x84, 0x71, /* |..CA...q| */
+	0x0f, 0xa6, 0x89, 0xad, 0x50, 0x23, 0x69, 0x0c, /* |....P#i.| */
+	0x80, 0xf3, 0xa4, 0x9c, 0x8f, 0x13, 0xf8, 0xd4, /* |........| */
+	0x5b, 0x8c, 0x85, 0x7f, 0xbc, 0xbc, 0x8b, 0xc4, /* |[.......| */
+	0xa8, 0xe4, 0xd3, 0xeb, 0x4b, 0x10, 0xf4, 0xd4, /* |....K...| */
+	0x60, 0x4f, 0xa0, 0x8d, 0xce, 0x60, 0x1a, 0xaf, /* |`O...`..| */
+	0x0f, 0x47, 0x02, 0x16, 0xfe, 0x1b, 0x51, 0x85, /* |.G....Q.| */
+	0x0b, 0x4a, 0xcf, 0x21, 0xb1, 0x79, 0xc4, 0x50, /* |.J.!.y.P| */
+	0x70, 0xac, 0x7b, 0x03, 0xa9, 0xac, 0x00, 0x00, /* |p.{.....| */
+	0x00, 0x00, /* |..| */
 }
 
 // regTestGenesisBlockBytes are the wire encoded bytes for the genesis block of
@@ -234,38 +256,41 @@ var testNet3GenesisBlockBytes = []byte{
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
-	0x00, 0x00, 0x00, 0x00, 0x3b, 0xa3, 0xed, 0xfd, /* |....;...| */
-	0x7a, 0x7b, 0x12, 0xb2, 0x7a, 0xc7, 0x2c, 0x3e, /* |z{..z.,>| */
-	0x67, 0x76, 0x8f, 0x61, 0x7f, 0xc8, 0x1b, 0xc3, /* |gv.a....| */
-	0x88, 0x8a, 0x51, 0x32, 0x3a, 0x9f, 0xb8, 0xaa, /* |..Q2:...| */
-	0x4b, 0x1e, 0x5e, 0x4a, 0xda, 0xe5, 0x49, 0x4d, /* |K.^J)._I| */
-	0xff, 0xff, 0x00, 0x1d, 0x1a, 0xa4, 0xae, 0x18, /* |......+|| */
+	0x00, 0x00, 0x00, 0x00, 0xc7, 0x62, 0xa6, 0x56, /* |.....b.V| */
+	0x7f, 0x3c, 0xc0, 0x92, 0xf0, 0x68, 0x4b, 0xb6, /* |.<...hK.| */
+	0x2b, 0x7e, 0x00, 0xa8, 0x48, 0x90, 0xb9, 0x90, /* |+~..H...| */
+	0xf0, 0x7c, 0xc7, 0x1a, 0x6b, 0xb5, 0x8d, 0x64, /* |.|..k..d| */
+	0xb9, 0x8e, 0x02, 0xe0, 0xde, 0xe1, 0xe3, 0x52, /* |.......R| */
+	0xf0, 0xff, 0x0f, 0x1e, 0xc3, 0xc9, 0x27, 0xe6, /* |......'.| */
 	0x01, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* |........| */
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, /* |........| */
-	0xff, 0xff, 0x4d, 0x04, 0xff, 0xff, 0x00, 0x1d, /* |..M.....| */
-	0x01, 0x04, 0x45, 0x54, 0x68, 0x65, 0x20, 0x54, /* |..EThe T| */
-	0x69, 0x6d, 0x65, 0x73, 0x20, 0x30, 0x33, 0x2f, /* |imes 03/| */
-	0x4a, 0x61, 0x6e, 0x2f, 0x32, 0x30, 0x30, 0x39, /* |Jan/2009| */
-	0x20, 0x43, 0x68, 0x61, 0x6e, 0x63, 0x65, 0x6c, /* | Chancel| */
-	0x6c, 0x6f, 0x72, 0x20, 0x6f, 0x6e, 0x20, 0x62, /* |lor on b| */
-	0x72, 0x69, 0x6e, 0x6b, 0x20, 0x6f, 0x66, 0x20, /* |rink of | */
-	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x20, 0x62, /* |second b| */
-	0x61, 0x69, 0x6c, 0x6f, 0x75, 0x74, 0x20, 0x66, /* |ailout f| */
-	0x6f, 0x72, 0x20, 0x62, 0x61, 0x6e, 0x6b, 0x73, /* |or banks| */
-	0xff, 0xff, 0xff, 0xff, 0x01, 0x00, 0xf2, 0x05, /* |........| */
-	0x2a, 0x01, 0x00, 0x00, 0x00, 0x43, 0x41, 0x04, /* |*....CA.| */
-	0x67, 0x8a, 0xfd, 0xb0, 0xfe, 0x55, 0x48, 0x27, /* |g....UH'| */
-	0x19, 0x67, 0xf1, 0xa6, 0x71, 0x30, 0xb7, 0x10, /* |.g..q0..| */
-	0x5c, 0xd6, 0xa8, 0x28, 0xe0, 0x39, 0x09, 0xa6, /* |\..(.9..| */
-	0x79, 0x62, 0xe0, 0xea, 0x1f, 0x61, 0xde, 0xb6, /* |yb...a..| */
-	0x49, 0xf6, 0xbc, 0x3f, 0x4c, 0xef, 0x38, 0xc4, /* |I..?L.8.| */
-	0xf3, 0x55, 0x04, 0xe5, 0x1e, 0xc1, 0x12, 0xde, /* |.U......| */
-	0x5c, 0x38, 0x4d, 0xf7, 0xba, 0x0b, 0x8d, 0x57, /* |\8M....W| */
-	0x8a, 0x4c, 0x70, 0x2b, 0x6b, 0xf1, 0x1d, 0x5f, /* |.Lp+k.._|*/
-	0xac, 0x00, 0x00, 0x00, 0x00, /* |.....|    */
+	0xff, 0xff, 0x62, 0x04, 0xff, 0xff, 0x00, 0x1d, /* |..b.....| */
+	0x01, 0x04, 0x4c, 0x59, 0x57, 0x69, 0x72, 0x65, /* |..LYWire| */
+	0x64, 0x20, 0x30, 0x39, 0x2f, 0x4a, 0x61, 0x6e, /* |d 09/Jan| */
+	0x2f, 0x32, 0x30, 0x31, 0x34, 0x20, 0x54, 0x68, /* |/2014 Th| */
+	0x65, 0x20, 0x47, 0x72, 0x61, 0x6e, 0x64, 0x20, /* |e Grand | */
+	0x45, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, /* |Experime| */
+	0x6e, 0x74, 0x20, 0x47, 0x6f, 0x65, 0x73, 0x20, /* |nt Goes | */
+	0x4c, 0x69, 0x76, 0x65, 0x3a, 0x20, 0x4f, 0x76, /* |Live: Ov| */
+	0x65, 0x72, 0x73, 0x74, 0x6f, 0x63, 0x6b, 0x2e, /* |erstock.| */
+	0x63, 0x6f, 0x6d, 0x20, 0x49, 0x73, 0x20, 0x4e, /* |com Is N| */
+	0x6f, 0x77, 0x20, 0x41, 0x63, 0x63, 0x65, 0x70, /* |ow Accep| */
+	0x74, 0x69, 0x6e, 0x67, 0x20, 0x42, 0x69, 0x74, /* |ting Bit| */
+	0x63, 0x6f, 0x69, 0x6e, 0x73, 0xff, 0xff, 0xff, /* |coins...| */
+	0xff, 0x01, 0x00, 0xf2, 0x05, 0x2a, 0x01, 0x00, /* |.....*..| */
+	0x00, 0x00, 0x43, 0x41, 0x04, 0x01, 0x84, 0x71, /* |..CA...q| */
+	0x0f, 0xa6, 0x89, 0xad, 0x50, 0x23, 0x69, 0x0c, /* |....P#i.| */
+	0x80, 0xf3, 0xa4, 0x9c, 0x8f, 0x13, 0xf8, 0xd4, /* |........| */
+	0x5b, 0x8c, 0x85, 0x7f, 0xbc, 0xbc, 0x8b, 0xc4, /* |[.......| */
+	0xa8, 0xe4, 0xd3, 0xeb, 0x4b, 0x10, 0xf4, 0xd4, /* |....K...| */
+	0x60, 0x4f, 0xa0, 0x8d, 0xce, 0x60, 0x1a, 0xaf, /* |`O...`..| */
+	0x0f, 0x47, 0x02, 0x16, 0xfe, 0x1b, 0x51, 0x85, /* |.G....Q.| */
+	0x0b, 0x4a, 0xcf, 0x21, 0xb1, 0x79, 0xc4, 0x50, /* |.J.!.y.P| */
+	0x70, 0xac, 0x7b, 0x03, 0xa9, 0xac, 0x00, 0x00, /* |p.{.....| */
+	0x00, 0x00, /* |..| */
 }
 
 // simNetGenesisBlockBytes are the wire encoded bytes for the genesis block of
@@ -23,18 +23,22 @@ var (
 	// the overhead of creating it multiple times.
 	bigOne = big.NewInt(1)
 
-	// mainPowLimit is the highest proof of work value a Bitcoin block can
-	// have for the main network.  It is the value 2^224 - 1.
-	mainPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 224), bigOne)
+	// mainPowLimit is the highest proof of work value a Dash block can
+	// have for the main network.  It is the value 2^236 - 1.
+	mainPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 236), bigOne)
 
 	// regressionPowLimit is the highest proof of work value a Bitcoin block
 	// can have for the regression test network.  It is the value 2^255 - 1.
 	regressionPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
 
-	// testNet3PowLimit is the highest proof of work value a Bitcoin block
+	// testNet3PowLimit is the highest proof of work value a Dash block
 	// can have for the test network (version 3).  It is the value
-	// 2^224 - 1.
-	testNet3PowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 224), bigOne)
+	// 2^236 - 1.
+	testNet3PowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 236), bigOne)
+
+	// devNetPowLimit is the highest proof of work value a Dash block can
+	// have for devnets.  It is the value 2^255 - 1.
+	devNetPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
 
 	// simNetPowLimit is the highest proof of work value a Bitcoin block
 	// can have for the simulation test network.  It is the value 2^255 - 1.
@@ -192,6 +196,24 @@ type Params struct {
 	// is reduced.
 	SubsidyReductionInterval int32
 
+	// SuperblockStartHeight is the height of the first superblock, which is
+	// the block that pays the proposals funded by the governance system,
+	// and SuperblockCycle is the number of blocks between superblocks.
+	// There are no superblocks when SuperblockCycle is zero.
+	SuperblockStartHeight int32
+	SuperblockCycle       int32
+
+	// BudgetPaymentsStartHeight is the height after which a tenth of the
+	// subsidy of every block is set aside for the superblocks rather than
+	// paid by its coinbase.
+	BudgetPaymentsStartHeight int32
+
+	// ChainLocksLLMQType and InstantSendLLMQType are the types of the long
+	// living masternode quorums which sign ChainLocks and InstantSend
+	// locks, respectively, on the network.
+	ChainLocksLLMQType  wire.LLMQType
+	InstantSendLLMQType wire.LLMQType
+
 	// TargetTimespan is the desired amount of time that should elapse
 	// before the block difficulty requirement is examined to determine how
 	// it should be changed in order to maintain the desired block
@@ -263,9 +285,26 @@ type Params struct {
 	// BIP44 coin type used in the hierarchical deterministic path for
 	// address generation.
 	HDCoinType uint32
+
+	// DevNetName is the name of the devnet defined by the parameters.  It
+	// is empty for all other networks.
+	//
+	// The magic bytes of a devnet must be derived from its name as
+	// returned by DevNetMagic.
+	DevNetName string
+
+	// DevNetGenesisHash is the X11 hash of the devnet genesis block, which
+	// is the block at height one that is built on top of the shared genesis
+	// block.  It must be set for devnets and is nil for all other networks.
+	DevNetGenesisHash *chainhash.Hash
+
+	// DevNetGenesisBlock is the devnet genesis block when it is known, such
+	// as for the parameters returned by NewDevNetParams.  It is connected
+	// when the chain is created.
+	DevNetGenesisBlock *wire.MsgBlock
 }
 
-// MainNetParams defines the network parameters for the main Bitcoin network.
+// MainNetParams defines the network parameters for the main Dash network.
 var MainNetParams = Params{
 	Name:        "main",
 	Net:         wire.MainNet,
@@ -279,46 +318,55 @@ var MainNetParams = Params{
 	GenesisBlock:             &genesisBlock,
 	GenesisHash:              &genesisHash,
 	PowLimit:                 mainPowLimit,
-	PowLimitBits:             0x1d00ffff,
-	BIP0034Height:            227931, // 000000000000024b89b42a942fe0d9fea3bb44ab7bd1b19115dd6a759c0808b8
-	BIP0065Height:            388381, // 000000000000000004c2b624ed5d7756c508d90fd0da2c7c679febfa6c4735f0
-	BIP0066Height:            363725, // 00000000000000000379eaa19dce8c9b722d46ae6a57c2f1a988119488b50931
+	PowLimitBits:             0x1e0fffff,
+	BIP0034Height:            951,
+	BIP0065Height:            619382,
+	BIP0066Height:            245817,
 	CoinbaseMaturity:         100,
-	SubsidyReductionInterval: 210000,
-	TargetTimespan:           time.Hour * 24 * 14, // 14 days
-	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
-	RetargetAdjustmentFactor: 4,                   // 25% less, 400% more
+	SubsidyReductionInterval: 210240,
+	TargetTimespan:           time.Hour * 24,    // 1 day
+	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
+	RetargetAdjustmentFactor: 4,                 // 25% less, 400% more
 	ReduceMinDifficulty:      false,
 	MinDiffReductionTime:     0,
 	GenerateSupported:        false,
 
+	// Governance superblocks
+	SuperblockStartHeight:     614820,
+	SuperblockCycle:           16616, // about 28.8 days
+	BudgetPaymentsStartHeight: 328008,
+	ChainLocksLLMQType:        2, // LLMQ_400_60
+	InstantSendLLMQType:       5, // LLMQ_60_75
+
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{
-		{11111, newHashFromStr("0000000069e244f73d78e8fd29ba2fd2ed618bd6fa2ee92559f542fdb26e7c1d")},
-		{33333, newHashFromStr("000000002dd5588a74784eaa7ab0507a18ad16a236e7b1ce69f00d7ddfb5d0a6")},
-		{74000, newHashFromStr("0000000000573993a3c9e41ce34471c079dcf5f52a0e824a81e7f953b8661a20")},
-		{105000, newHashFromStr("00000000000291ce28027faea320c8d2b054b2e0fe44a773f3eefb151d6bdc97")},
-		{134444, newHashFromStr("00000000000005b12ffd4cd315cd34ffd4a594f430ac814c91184a0d42d2b0fe")},
-		{168000, newHashFromStr("000000000000099e61ea72015e79632f216fe6cb33d7899acb35b75c8303b763")},
-		{193000, newHashFromStr("000000000000059f452a5f7340de6682a977387c17010ff6e6c3bd83ca8b1317")},
-		{210000, newHashFromStr("000000000000048b95347e83192f69cf0366076336c639f9b7228e9ba171342e")},
-		{216116, newHashFromStr("00000000000001b4f4b433e81ee46494af945cf96014816a4e2370f11b23df4e")},
-		{225430, newHashFromStr("00000000000001c108384350f74090433e7fcf79a606b8e797f065b130575932")},
-		{250000, newHashFromStr("000000000000003887df1f29024b06fc2200b55f8af8f35453d7be294df2d214")},
-		{267300, newHashFromStr("000000000000000a83fbd660e918f218bf37edd92b748ad940483c7c116179ac")},
-		{279000, newHashFromStr("0000000000000001ae8c72a0b0c301f67e3afca10e819efa9041e458e9bd7e40")},
-		{300255, newHashFromStr("0000000000000000162804527c6e9b9f0563a280525f9d08c12041def0a0f3b2")},
-		{319400, newHashFromStr("000000000000000021c6052e9becade189495d1c539aa37c58917305fd15f13b")},
-		{343185, newHashFromStr("0000000000000000072b8bf361d01a6ba7d445dd024203fafc78768ed4368554")},
-		{352940, newHashFromStr("000000000000000010755df42dba556bb72be6a32f3ce0b6941ce4430152c9ff")},
-		{382320, newHashFromStr("00000000000000000a8dc6ed5b133d0eb2fd6af56203e4159789b092defd8ab2")},
-		{400000, newHashFromStr("000000000000000004ec466ce4732fe6f1ed1cddc2ed4b328fff5224276e3f6f")},
-		{430000, newHashFromStr("000000000000000001868b2bb3a285f3cc6b33ea234eb70facf4dcdf22186b87")},
-		{460000, newHashFromStr("000000000000000000ef751bbce8e744ad303c47ece06c8d863e4d417efc258c")},
-		{490000, newHashFromStr("000000000000000000de069137b17b8d5a3dfbd5b145b2dcfb203f15d0c4de90")},
-		{520000, newHashFromStr("0000000000000000000d26984c0229c9f6962dc74db0a6d525f2f1640396f69c")},
-		{550000, newHashFromStr("000000000000000000223b7a2298fb1c6c75fb0efc28a4c56853ff4112ec6bc9")},
-		{560000, newHashFromStr("0000000000000000002c7b276daf6efb2b6aa68e2ce3be67ef925b3264ae7122")},
+		{1500, newHashFromStr("000000aaf0300f59f49bc3e970bad15c11f961fe2347accffff19d96ec9778e3")},
+		{4991, newHashFromStr("000000003b01809551952460744d5dbb8fcbd6cbae3c220267bf7fa43f837367")},
+		{9918, newHashFromStr("00000000213e229f332c0ffbe34defdaa9e74de87f2d8d1f01af8d121c3c170b")},
+		{16912, newHashFromStr("00000000075c0d10371d55a60634da70f197548dbbfa4123e12abfcbc5738af9")},
+		{23912, newHashFromStr("0000000000335eac6703f3b1732ec8b2f89c3ba3a7889e5767b090556bb9a276")},
+		{35457, newHashFromStr("0000000000b0ae211be59b048df14820475ad0dd53b9ff83b010f71a77342d9f")},
+		{45479, newHashFromStr("000000000063d411655d590590e16960f15ceea4257122ac430c6fbe39fbf02d")},
+		{55895, newHashFromStr("0000000000ae4c53a43639a4ca027282f69da9c67ba951768a20415b6439a2d7")},
+		{68899, newHashFromStr("0000000000194ab4d3d9eeb1f2f792f21bb39ff767cb547fe977640f969d77b7")},
+		{74619, newHashFromStr("000000000011d28f38f05d01650a502cc3f4d0e793fbc26e2a2ca71f07dc3842")},
+		{75095, newHashFromStr("0000000000193d12f6ad352a9996ee58ef8bdc4946818a5fec5ce99c11b87f0d")},
+		{88805, newHashFromStr("00000000001392f1652e9bf45cd8bc79dc60fe935277cd11538565b4a94fa85f")},
+		{107996, newHashFromStr("00000000000a23840ac16115407488267aa3da2b9bc843e301185b7d17e4dc40")},
+		{137993, newHashFromStr("00000000000cf69ce152b1bffdeddc59188d7a80879210d6e5c9503011929c3c")},
+		{167996, newHashFromStr("000000000009486020a80f7f2cc065342b0c2fb59af5e090cd813dba68ab0fed")},
+		{207992, newHashFromStr("00000000000d85c22be098f74576ef00b7aa00c05777e966aff68a270f1e01a5")},
+		{312645, newHashFromStr("0000000000059dcb71ad35a9e40526c44e7aae6c99169a9e7017b7d84b1c2daf")},
+		{407452, newHashFromStr("000000000003c6a87e73623b9d70af7cd908ae22fee466063e4ffc20be1d2dbc")},
+		{523412, newHashFromStr("000000000000e54f036576a10597e0e42cc22a5159ce572f999c33975e121d4d")},
+		{523930, newHashFromStr("0000000000000bccdb11c2b1cfb0ecab452abf267d89b7f46eaf2d54ce6e652c")},
+		{750000, newHashFromStr("00000000000000b4181bbbdddbae464ce11fede5d0292fb63fdede1e7c8ab21c")},
+		{888900, newHashFromStr("0000000000000026c29d576073ab51ebd1d3c938de02e9a44c7ee9e16f82db28")},
+		{967800, newHashFromStr("0000000000000024e26c7df7e46d673724d223cf4ca2b2adc21297cc095600f4")},
+		{1067570, newHashFromStr("000000000000001e09926bcf5fa4513d23e870a34f74e38200db99eb3f5b7a70")},
+		{1167570, newHashFromStr("000000000000000fb7b1e9b81700283dff0f7d87cf458e5edfdae00c669de661")},
+		{1364585, newHashFromStr("00000000000000022f355c52417fca9b73306958f7c0832b3a7bce006ca369ef")},
+		{1450000, newHashFromStr("00000000000000105cfae44a995332d8ec256850ea33a1f7b700474e3dad82bc")},
 	},
 
 	// Consensus rule change deployments.
@@ -351,10 +399,10 @@ var MainNetParams = Params{
 		DeploymentCSV: {
 			BitNumber: 0,
 			DeploymentStarter: NewMedianTimeDeploymentStarter(
-				time.Unix(1462060800, 0), // May 1st, 2016
+				time.Unix(1486252800, 0), // February 5th, 2017
 			),
 			DeploymentEnder: NewMedianTimeDeploymentEnder(
-				time.Unix(1493596800, 0), // May 1st, 2017
+				time.Unix(1517788800, 0), // February 5th, 2018
 			),
 		},
 		DeploymentSegwit: {
@@ -417,6 +465,13 @@ var RegressionNetParams = Params{
 	MinDiffReductionTime:     time.Minute * 20, // TargetTimePerBlock * 2
 	GenerateSupported:        true,
 
+	// Governance superblocks
+	SuperblockStartHeight:     1500,
+	SuperblockCycle:           10, // 25 minutes
+	BudgetPaymentsStartHeight: 1000,
+	ChainLocksLLMQType:        100, // LLMQ_TEST
+	InstantSendLLMQType:       103, // LLMQ_TEST_DIP0024
+
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: nil,
 
@@ -488,7 +543,7 @@ var RegressionNetParams = Params{
 	HDCoinType: 1,
 }
 
-// TestNet3Params defines the network parameters for the test Bitcoin network
+// TestNet3Params defines the network parameters for the test Dash network
 // (version 3).  Not to be confused with the regression test network, this
 // network is sometimes simply called "testnet".
 var TestNet3Params = Params{
@@ -503,19 +558,26 @@ var TestNet3Params = Params{
 	GenesisBlock:             &testNet3GenesisBlock,
 	GenesisHash:              &testNet3GenesisHash,
 	PowLimit:                 testNet3PowLimit,
-	PowLimitBits:             0x1d00ffff,
-	BIP0034Height:            21111,  // 0000000023b3a96d3484e5abb3755c413e7d41500f8e2a5c3f0dd01299cd8ef8
-	BIP0065Height:            581885, // 00000000007f6655f22f98e72ed80d8b06dc761d5da09df0fa1dc4be4f861eb6
-	BIP0066Height:            330776, // 000000002104c8c45e99a8853285a3b592602a3ccde2b832481da85e9e4ba182
+	PowLimitBits:             0x1e0fffff,
+	BIP0034Height:            76,
+	BIP0065Height:            2431,
+	BIP0066Height:            2075,
 	CoinbaseMaturity:         100,
-	SubsidyReductionInterval: 210000,
-	TargetTimespan:           time.Hour * 24 * 14, // 14 days
-	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
-	RetargetAdjustmentFactor: 4,                   // 25% less, 400% more
+	SubsidyReductionInterval: 210240,
+	TargetTimespan:           time.Hour * 24,    // 1 day
+	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
+	RetargetAdjustmentFactor: 4,                 // 25% less, 400% more
 	ReduceMinDifficulty:      true,
-	MinDiffReductionTime:     time.Minute * 20, // TargetTimePerBlock * 2
+	MinDiffReductionTime:     time.Minute * 5, // TargetTimePerBlock * 2
 	GenerateSupported:        false,
 
+	// Governance superblocks
+	SuperblockStartHeight:     4200,
+	SuperblockCycle:           24, // 1 hour
+	BudgetPaymentsStartHeight: 4100,
+	ChainLocksLLMQType:        1, // LLMQ_50_60
+	InstantSendLLMQType:       5, // LLMQ_60_75
+
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{
 		{261, newHashFromStr("00000c26026d0815a7e2ce4fa270775f61403c040647ff2c3091f99e894a4618")},
@@ -557,10 +619,10 @@ var TestNet3Params = Params{
 		DeploymentCSV: {
 			BitNumber: 0,
 			DeploymentStarter: NewMedianTimeDeploymentStarter(
-				time.Unix(1456790400, 0), // March 1st, 2016
+				time.Unix(1506556800, 0), // September 28th, 2017
 			),
 			DeploymentEnder: NewMedianTimeDeploymentEnder(
-				time.Unix(1493596800, 0), // May 1st, 2017
+				time.Unix(1538092800, 0), // September 28th, 2018
 			),
 		},
 		DeploymentSegwit: {
@@ -582,8 +644,8 @@ var TestNet3Params = Params{
 	Bech32HRPSegwit: "tb", // always tb for test net
 
 	// Address encoding magics
-	PubKeyHashAddrID:        0x8C, // starts with m or n
-	ScriptHashAddrID:        0x13, // starts with 2
+	PubKeyHashAddrID:        0x8C, // starts with y
+	ScriptHashAddrID:        0x13, // starts with 8
 	WitnessScriptHashAddrID: 0x28, // starts with T7n
 	PrivateKeyID:            0xEF, // starts with 9 (uncompressed) or c (compressed)
 	WitnessPubKeyHashAddrID: 0x03, // starts with QW
@@ -597,6 +659,112 @@ var TestNet3Params = Params{
 	HDCoinType: 1,
 }
 
+// DevNetParams defines the network parameters shared by all Dash devnets,
+// which are development networks that anyone can create.  Devnets are
+// identified by their name, so the parameters of a devnet are a copy of these
+// with DevNetName, Net and DevNetGenesisHash set, such as returned by
+// NewDevNetParams, which must be registered with Register before use.
+// DevNetParams itself can't be registered.
+var DevNetParams = Params{
+	Name:        "devnet",
+	DefaultPort: "19799",
+	DNSSeeds:    []DNSSeed{},
+
+	// Chain parameters
+	GenesisBlock:             &regTestGenesisBlock,
+	GenesisHash:              &regTestGenesisHash,
+	PowLimit:                 devNetPowLimit,
+	PowLimitBits:             0x207fffff,
+	BIP0034Height:            1,
+	BIP0065Height:            1,
+	BIP0066Height:            1,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 210240,
+	TargetTimespan:           time.Hour * 24,    // 1 day
+	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
+	RetargetAdjustmentFactor: 4,                 // 25% less, 400% more
+	ReduceMinDifficulty:      true,
+	MinDiffReductionTime:     time.Minute * 5, // TargetTimePerBlock * 2
+	GenerateSupported:        true,
+
+	// Governance superblocks
+	SuperblockStartHeight:     4200,
+	SuperblockCycle:           24, // 1 hour
+	BudgetPaymentsStartHeight: 4100,
+	ChainLocksLLMQType:        101, // LLMQ_DEVNET
+	InstantSendLLMQType:       105, // LLMQ_DEVNET_DIP0024
+
+	// Checkpoints ordered from oldest to newest.
+	Checkpoints: nil,
+
+	// Consensus rule change deployments.
+	//
+	// The miner confirmation window is defined as:
+	//   target proof of work timespan / target proof of work spacing
+	RuleChangeActivationThreshold: 1512, // 75% of MinerConfirmationWindow
+	MinerConfirmationWindow:       2016,
+	Deployments: [DefinedDeployments]ConsensusDeployment{
+		DeploymentTestDummy: {
+			BitNumber: 28,
+			DeploymentStarter: NewMedianTimeDeploymentStarter(
+				time.Time{}, // Always available for vote
+			),
+			DeploymentEnder: NewMedianTimeDeploymentEnder(
+				time.Time{}, // Never expires
+			),
+		},
+		DeploymentTestDummyMinActivation: {
+			BitNumber:                 22,
+			CustomActivationThreshold: 1815,    // Only needs 90% hash rate.
+			MinActivationHeight:       10_0000, // Can only activate after height 10k.
+			DeploymentStarter: NewMedianTimeDeploymentStarter(
+				time.Time{}, // Always available for vote
+			),
+			DeploymentEnder: NewMedianTimeDeploymentEnder(
+				time.Time{}, // Never expires
+			),
+		},
+		DeploymentCSV: {
+			BitNumber: 0,
+			DeploymentStarter: NewMedianTimeDeploymentStarter(
+				time.Time{}, // Always available for vote
+			),
+			DeploymentEnder: NewMedianTimeDeploymentEnder(
+				time.Time{}, // Never expires
+			),
+		},
+		DeploymentSegwit: {
+			BitNumber: 1,
+			DeploymentStarter: NewMedianTimeDeploymentStarter(
+				time.Time{}, // Always available for vote
+			),
+			DeploymentEnder: NewMedianTimeDeploymentEnder(
+				time.Time{}, // Never expires.
+			),
+		},
+	},
+
+	// Mempool parameters
+	RelayNonStdTxs: true,
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "tb", // always tb for test net
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x8C, // starts with y
+	ScriptHashAddrID: 0x13, // starts with 8
+	PrivateKeyID:     0xEF, // starts with 9 (uncompressed) or c (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
 // SimNetParams defines the network parameters for the simulation test Bitcoin
 // network.  This network is similar to the normal test network except it is
 // intended for private use within a group of individuals doing simulation
@@ -707,6 +875,39 @@ var SigNetParams = CustomSignetParams(
 	DefaultSignetChallenge, DefaultSignetDNSSeeds,
 )
 
+// DevNetMagic returns the magic bytes that identify the devnet with the passed
+// name.  They are defined as the first four bytes of the sha256d of the full
+// devnet name, which is the name prefixed with "devnet-", so peers of devnets
+// with different names reject each other's messages.
+func DevNetMagic(name string) wire.BitcoinNet {
+	hashDouble := chainhash.DoubleHashB([]byte("devnet-" + name))
+
+	// We use little endian encoding of the hash prefix to be in line with
+	// the other wire network identities.
+	return wire.BitcoinNet(binary.LittleEndian.Uint32(hashDouble[0:4]))
+}
+
+// NewDevNetParams returns the network parameters for the devnet with the passed
+// name.  The magic bytes and the devnet genesis block are derived from the name
+// deterministically, so nodes using the parameters for the same name form the
+// same devnet.  ErrInvalidDevNetName is returned when the name is empty or
+// longer than MaxDevNetNameLen.
+//
+// The parameters must be registered with Register before use.
+func NewDevNetParams(name string) (Params, error) {
+	if name == "" || len(name) > MaxDevNetNameLen {
+		return Params{}, ErrInvalidDevNetName
+	}
+
+	params := DevNetParams
+	params.DevNetName = name
+	params.Net = DevNetMagic(name)
+	params.DevNetGenesisBlock = devNetGenesisBlock(name, params.GenesisBlock)
+	devNetGenesisHash := params.DevNetGenesisBlock.Header.PowHash()
+	params.DevNetGenesisHash = &devNetGenesisHash
+	return params, nil
+}
+
 // CustomSignetParams creates network parameters for a custom signet network
 // from a challenge. The challenge is the binary compiled version of the block
 // challenge script.
@@ -825,6 +1026,11 @@ var (
 	// network or previously-registered into this package.
 	ErrDuplicateNet = errors.New("duplicate Bitcoin network")
 
+	// ErrInvalidDevNet describes an error where the parameters for a devnet
+	// could not be set due to a missing name or genesis hash, or magic
+	// bytes which are not derived from the name.
+	ErrInvalidDevNet = errors.New("invalid devnet parameters")
+
 	// ErrUnknownHDKeyID describes an error where the provided id which
 	// is intended to identify the network for a hierarchical deterministic
 	// private extended key is not registered.
@@ -851,13 +1057,22 @@ func (d DNSSeed) String() string {
 // Register registers the network parameters for a Bitcoin network.  This may
 // error with ErrDuplicateNet if the network is already registered (either
 // due to a previous Register call, or the network being one of the default
-// networks).
+// networks).  Devnets, which have DevNetName set, must have the magic bytes
+// returned by DevNetMagic for their name and a DevNetGenesisHash, or
+// ErrInvalidDevNet is returned.
 //
 // Network parameters should be registered into this package by a main package
 // as early as possible.  Then, library packages may lookup networks or network
 // parameters based on inputs and work regardless of the network being standard
 // or not.
 func Register(params *Params) error {
+	if params.DevNetName != "" || params.Name == DevNetParams.Name {
+		if params.DevNetName == "" || params.DevNetGenesisHash == nil ||
+			params.Net != DevNetMagic(params.DevNetName) {
+
+			return ErrInvalidDevNet
+		}
+	}
 	if _, ok := registeredNets[params.Net]; ok {
 		return ErrDuplicateNet
 	}
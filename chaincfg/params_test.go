@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -104,32 +105,91 @@ func TestSigNetPowLimit(t *testing.T) {
 	}
 }
 
-// compactToBig is a copy of the blockchain.CompactToBig function. We copy it
-// here so we don't run into a circular dependency just because of a test.
-func compactToBig(compact uint32) *big.Int {
-	// Extract the mantissa, sign bit, and exponent.
-	mantissa := compact & 0x007fffff
-	isNegative := compact&0x00800000 != 0
-	exponent := uint(compact >> 24)
-
-	// Since the base for the exponent is 256, the exponent can be treated
-	// as the number of bytes to represent the full 256-bit number.  So,
-	// treat the exponent as the number of bytes and shift the mantissa
-	// right or left accordingly.  This is equivalent to:
-	// N = mantissa * 256^(exponent-3)
-	var bn *big.Int
-	if exponent <= 3 {
-		mantissa >>= 8 * (3 - exponent)
-		bn = big.NewInt(int64(mantissa))
-	} else {
-		bn = big.NewInt(int64(mantissa))
-		bn.Lsh(bn, 8*(exponent-3))
-	}
-
-	// Make it negative if the sign bit is set.
-	if isNegative {
-		bn = bn.Neg(bn)
-	}
-
-	return bn
+// TestDevNetMagic ensures the magic bytes of devnets are derived from their
+// names deterministically and differ from the other networks.
+func TestDevNetMagic(t *testing.T) {
+	magic := DevNetMagic("test")
+	if got := DevNetMagic("test"); got != magic {
+		t.Fatalf("DevNetMagic: got %v, want %v", got, magic)
+	}
+	if DevNetMagic("other") == magic {
+		t.Fatal("DevNetMagic: devnets with different names share magic")
+	}
+	for _, params := range []*Params{&MainNetParams, &TestNet3Params,
+		&RegressionNetParams, &SimNetParams, &SigNetParams} {
+
+		if params.Net == magic {
+			t.Fatalf("DevNetMagic: magic matches %s network", params.Name)
+		}
+	}
+}
+
+// TestNewDevNetParams ensures the parameters of devnets are derived from their
+// names deterministically and that invalid names are rejected.
+func TestNewDevNetParams(t *testing.T) {
+	params, err := NewDevNetParams("test")
+	if err != nil {
+		t.Fatalf("NewDevNetParams: unexpected error: %v", err)
+	}
+	again, err := NewDevNetParams("test")
+	if err != nil {
+		t.Fatalf("NewDevNetParams: unexpected error: %v", err)
+	}
+	other, err := NewDevNetParams("other")
+	if err != nil {
+		t.Fatalf("NewDevNetParams: unexpected error: %v", err)
+	}
+
+	if params.Net != DevNetMagic("test") || params.DevNetName != "test" {
+		t.Fatalf("NewDevNetParams: got devnet %q with magic %v",
+			params.DevNetName, params.Net)
+	}
+	if *params.DevNetGenesisHash != *again.DevNetGenesisHash {
+		t.Fatal("NewDevNetParams: devnet genesis block is not " +
+			"deterministic")
+	}
+	if *params.DevNetGenesisHash == *other.DevNetGenesisHash {
+		t.Fatal("NewDevNetParams: devnets with different names share " +
+			"the devnet genesis block")
+	}
+
+	block := params.DevNetGenesisBlock
+	hash := block.Header.PowHash()
+	if hash != *params.DevNetGenesisHash {
+		t.Fatalf("NewDevNetParams: devnet genesis hash %v does not "+
+			"match block %v", params.DevNetGenesisHash, hash)
+	}
+	if block.Header.PrevBlock != *params.GenesisHash {
+		t.Fatalf("NewDevNetParams: devnet genesis block builds on %v, "+
+			"want %v", block.Header.PrevBlock, params.GenesisHash)
+	}
+	if hashToBig(&hash).Cmp(compactToBig(block.Header.Bits)) > 0 {
+		t.Fatal("NewDevNetParams: devnet genesis block does not " +
+			"satisfy its X11 proof of work")
+	}
+	sigScript := block.Transactions[0].TxIn[0].SignatureScript
+	if !bytes.HasSuffix(sigScript, []byte("devnet-test")) {
+		t.Fatalf("NewDevNetParams: coinbase script %x does not include "+
+			"the devnet name", sigScript)
+	}
+
+	for _, name := range []string{"", strings.Repeat("a", MaxDevNetNameLen+1)} {
+		if _, err := NewDevNetParams(name); err != ErrInvalidDevNetName {
+			t.Fatalf("NewDevNetParams(%q): got error %v, want %v",
+				name, err, ErrInvalidDevNetName)
+		}
+	}
+	long, err := NewDevNetParams(strings.Repeat("a", MaxDevNetNameLen))
+	if err != nil {
+		t.Fatalf("NewDevNetParams: unexpected error: %v", err)
+	}
+	sigScript = long.DevNetGenesisBlock.Transactions[0].TxIn[0].SignatureScript
+	if len(sigScript) > 100 {
+		t.Fatalf("NewDevNetParams: coinbase script of %d bytes is too "+
+			"long", len(sigScript))
+	}
+
+	if err := Register(&params); err != nil {
+		t.Fatalf("Register: unexpected error: %v", err)
+	}
 }
@@ -506,3 +506,39 @@ func TestRegister(t *testing.T) {
 		}
 	}
 }
+
+// TestRegisterDevNet ensures only devnets with a name, genesis hash and the
+// magic bytes derived from the name can be registered.
+func TestRegisterDevNet(t *testing.T) {
+	genesisHash := *RegressionNetParams.GenesisHash
+	devNet := func(name string) *Params {
+		params := DevNetParams
+		params.DevNetName = name
+		params.Net = DevNetMagic(name)
+		params.DevNetGenesisHash = &genesisHash
+		return &params
+	}
+
+	noGenesis := devNet("registertest-nogenesis")
+	noGenesis.DevNetGenesisHash = nil
+	badMagic := devNet("registertest-badmagic")
+	badMagic.Net = DevNetMagic("registertest-other")
+
+	tests := []struct {
+		name   string
+		params *Params
+		err    error
+	}{
+		{"template", &DevNetParams, ErrInvalidDevNet},
+		{"no genesis hash", noGenesis, ErrInvalidDevNet},
+		{"magic not from name", badMagic, ErrInvalidDevNet},
+		{"valid", devNet("registertest"), nil},
+		{"duplicate", devNet("registertest"), ErrDuplicateNet},
+	}
+	for _, test := range tests {
+		if err := Register(test.params); err != test.err {
+			t.Errorf("%s: unexpected error: got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
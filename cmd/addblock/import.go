@@ -340,6 +340,13 @@ func newBlockImporter(db database.DB, r io.ReadSeeker) (*blockImporter, error) {
 		return nil, err
 	}
 
+	// Wait for the resumed reindex of the chain state to complete, if any,
+	// since no blocks are processed until then.
+	<-chain.ReindexDone()
+	if err := chain.ReindexErr(); err != nil {
+		return nil, err
+	}
+
 	return &blockImporter{
 		db:           db,
 		r:            r,
@@ -159,6 +159,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "failed to initialize chain: %v\n", err)
 		return
 	}
+	<-chain.ReindexDone()
+	if err := chain.ReindexErr(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to reindex chain: %v\n", err)
+		return
+	}
 
 	// Get the latest block hash and height from the database and report
 	// status.
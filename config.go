@@ -9,10 +9,10 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -21,8 +21,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/btcsuite/go-socks/socks"
 	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcec/v2"
 	"github.com/dashpay/dashd-go/btcutil"
 	"github.com/dashpay/dashd-go/chaincfg"
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
@@ -31,6 +31,7 @@ import (
 	_ "github.com/dashpay/dashd-go/database/ffldb"
 	"github.com/dashpay/dashd-go/mempool"
 	"github.com/dashpay/dashd-go/peer"
+	"github.com/dashpay/dashd-go/txscript"
 	"github.com/dashpay/dashd-go/wire"
 	flags "github.com/jessevdk/go-flags"
 )
@@ -48,6 +49,7 @@ const (
 	defaultMaxRPCClients         = 10
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
+	defaultMaxRPCBatchSize       = 1000
 	defaultDbType                = "ffldb"
 	defaultFreeTxRelayLimit      = 15.0
 	defaultTrickleInterval       = peer.DefaultTrickleInterval
@@ -62,6 +64,7 @@ const (
 	defaultGenerate              = false
 	defaultMaxOrphanTransactions = 100
 	defaultMaxOrphanTxSize       = 100000
+	defaultMempoolSyncPeers      = 3
 	defaultSigCacheMaxSize       = 100000
 	sampleConfigFilename         = "sample-btcd.conf"
 	defaultTxIndex               = false
@@ -100,6 +103,7 @@ type config struct {
 	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	AgentBlacklist       []string      `long:"agentblacklist" description:"A comma separated list of user-agent substrings which will cause btcd to reject any peers whose user-agent contains any of the blacklisted substrings."`
 	AgentWhitelist       []string      `long:"agentwhitelist" description:"A comma separated list of user-agent substrings which will cause btcd to require all peers' user-agents to contain one of the whitelisted substrings. The blacklist is applied before the blacklist, and an empty whitelist will allow all agents that do not fail the blacklist."`
+	ASMap                string        `long:"asmap" description:"File mapping IP prefixes to autonomous system numbers, one '<prefix> <asn>' per line, used to spread outbound connections across network operators rather than address ranges"`
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
 	BlockMaxSize         uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
@@ -108,22 +112,32 @@ type config struct {
 	BlockMinWeight       uint32        `long:"blockminweight" description:"Mininum block weight to be used when creating a block"`
 	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	BlocksOnlyOutbound   bool          `long:"blocksonlyoutbound" description:"Only relay blocks and headers with outbound peers, which are asked not to announce transactions or InstantSend locks"`
+	BlocksOnlyConns      int           `long:"blocksonlyconns" description:"Number of additional outbound peers to maintain with which only blocks and headers are relayed"`
 	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
 	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
+	DataCarrierSize      int           `long:"datacarriersize" description:"Maximum number of bytes of data pushed by a relayed null data (OP_RETURN) output, excluding the opcodes counted by dashd (0-80)"`
 	DataDir              string        `short:"b" long:"datadir" description:"Directory to store data"`
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	DevNet               string        `long:"devnet" description:"Use the devnet with the given name"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
 	DropCfIndex          bool          `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
+	DropSpecialTxIndex   bool          `long:"dropspecialtxindex" description:"Deletes the special transaction index from the database on start up and then exits."`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
 	Generate             bool          `long:"generate" description:"Generate (mine) bitcoins using the CPU"`
 	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
+	HeadersBootstrapURLs []string      `long:"headersbootstrap" description:"Add an HTTPS URL of a signed block header bundle used to bootstrap the header chain while connecting to peers -- Later URLs are only used when the earlier ones fail"`
+	HeadersBootstrapKey  string        `long:"headersbootstrapkey" description:"Hex encoded public key the block header bundles must be signed by -- Required with headersbootstrap"`
 	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 8333, testnet: 18333)"`
 	LogDir               string        `long:"logdir" description:"Directory to log output."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
 	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	MaxReorgDepth        int32         `long:"maxreorgdepth" description:"Reject chain reorganizations which would disconnect more than this number of blocks unless the new chain is chainlocked -- 0 allows reorganizations of any depth"`
+	MempoolExpiry        time.Duration `long:"mempoolexpiry" description:"How long a transaction may stay in the memory pool without being mined before it is evicted.  Valid time units are {s, m, h}.  Zero disables expiration"`
+	MempoolSyncPeers     int           `long:"mempoolsyncpeers" description:"Number of peers whose mempool is requested once the chain is current after startup -- 0 disables the mempool sync"`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in BTC/kB to be considered a non-zero fee."`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
@@ -146,40 +160,56 @@ type config struct {
 	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
 	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
 	RegressionTest       bool          `long:"regtest" description:"Use the regression test network"`
+	Reindex              bool          `long:"reindex" description:"Rebuild the chain state and optional indexes from the stored blocks on start up.  An interrupted reindex is resumed on the next start"`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	RejectReplacement    bool          `long:"rejectreplacement" description:"Reject transactions that attempt to replace existing transactions within the mempool through the Replace-By-Fee (RBF) signaling policy."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
+	RPCCORSOrigins       []string      `long:"rpccorsorigin" description:"Add an origin whose browser-based clients may make cross-origin requests to the RPC server, or * for any origin (eg. http://localhost:3000)"`
 	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
 	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
 	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
 	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
 	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 8334, testnet: 18334)"`
+	RPCMaxBatchSize      int           `long:"rpcmaxbatchsize" description:"Max number of requests allowed in a single batched JSON-RPC request"`
 	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
 	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
 	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
+	RPCOriginRateLimit   int           `long:"rpcoriginratelimit" description:"Max number of RPC requests per second from each origin of browser-based clients -- 0 to disable"`
 	RPCQuirks            bool          `long:"rpcquirks" description:"Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE: Discouraged unless interoperability issues need to be worked around"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
+	RPCSerialBatch       bool          `long:"rpcserialbatch" description:"Process the entries of batched JSON-RPC requests one after the other instead of concurrently, so entries observe the effects of earlier entries of the same batch"`
+	RPCTrustedProxies    []string      `long:"rpctrustedproxy" description:"Add an IP network or IP of a reverse proxy whose X-Forwarded-For header identifies the RPC clients it forwards requests of (eg. 127.0.0.1 or 10.0.0.0/8)"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
 	SigNet               bool          `long:"signet" description:"Use the signet test network"`
 	SigNetChallenge      string        `long:"signetchallenge" description:"Connect to a custom signet network defined by this challenge instead of using the global default signet test network -- Can be specified multiple times"`
 	SigNetSeedNode       []string      `long:"signetseednode" description:"Specify a seed node for the signet network instead of using the global default signet network seed nodes"`
+	SpecialTxIndex       bool          `long:"specialtxindex" description:"Maintain an index of special transactions by type which makes ranges of blocks available via the getspecialtxes RPC"`
 	TestNet3             bool          `long:"testnet" description:"Use the test network"`
 	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
 	TrickleInterval      time.Duration `long:"trickleinterval" description:"Minimum time between attempts to send new inventory to a connected peer"`
+	Transport            string        `long:"transport" description:"Transport used for peer connections -- Transports other than tcp are experimental and only allowed on the regression test, simulation test and signet networks"`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
 	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
-	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
+	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP whose peers are granted permissions, optionally prefixed by a comma separated list of permissions and @ -- permissions are noban, relay, forcerelay, mempool and all, and default to noban (eg. 192.168.1.0/24, ::1 or noban,mempool@127.0.0.1)"`
 	lookup               func(string) ([]net.IP, error)
-	oniondial            func(string, string, time.Duration) (net.Conn, error)
-	dial                 func(string, string, time.Duration) (net.Conn, error)
+	dial                 connmgr.DialFunc
+	listen               func(string, string) (net.Listener, error)
 	addCheckpoints       []chaincfg.Checkpoint
 	miningAddrs          []btcutil.Address
+	headersBootstrapKey  *btcec.PublicKey
 	minRelayTxFee        btcutil.Amount
-	whitelists           []*net.IPNet
+	whitelists           []whitelist
+	rpcTrustedProxies    []*net.IPNet
+}
+
+// whitelist is an IP network whose peers are granted permissions.
+type whitelist struct {
+	ipnet       *net.IPNet
+	permissions peer.PermissionFlags
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -421,6 +451,8 @@ func loadConfig() (*config, []string, error) {
 		RPCMaxClients:        defaultMaxRPCClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		RPCMaxBatchSize:      defaultMaxRPCBatchSize,
+		DataCarrierSize:      txscript.MaxDataCarrierSize,
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
 		DbType:               defaultDbType,
@@ -429,12 +461,15 @@ func loadConfig() (*config, []string, error) {
 		MinRelayTxFee:        mempool.DefaultMinRelayTxFee.ToBTC(),
 		FreeTxRelayLimit:     defaultFreeTxRelayLimit,
 		TrickleInterval:      defaultTrickleInterval,
+		Transport:            peer.DefaultTransport,
 		BlockMinSize:         defaultBlockMinSize,
 		BlockMaxSize:         defaultBlockMaxSize,
 		BlockMinWeight:       defaultBlockMinWeight,
 		BlockMaxWeight:       defaultBlockMaxWeight,
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MempoolSyncPeers:     defaultMempoolSyncPeers,
+		MempoolExpiry:        mempool.DefaultExpiryTimeout,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		Generate:             defaultGenerate,
 		TxIndex:              defaultTxIndex,
@@ -481,7 +516,8 @@ func loadConfig() (*config, []string, error) {
 	// Load additional config from file.
 	var configFileError error
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
-	if !(preCfg.RegressionTest || preCfg.SimNet || preCfg.SigNet) ||
+	if !(preCfg.RegressionTest || preCfg.SimNet || preCfg.SigNet ||
+		preCfg.DevNet != "") ||
 		preCfg.ConfigFile != defaultConfigFile {
 
 		if _, err := os.Stat(preCfg.ConfigFile); os.IsNotExist(err) {
@@ -595,10 +631,29 @@ func loadConfig() (*config, []string, error) {
 		)
 		activeNetParams.Params = &chainParams
 	}
+	if cfg.DevNet != "" {
+		numNets++
+		activeNetParams = &devNetParams
+
+		// The devnet is defined by its name, which must be registered
+		// so addresses and keys of the devnet can be decoded.
+		chainParams, err := chaincfg.NewDevNetParams(cfg.DevNet)
+		if err == nil {
+			err = chaincfg.Register(&chainParams)
+		}
+		if err != nil {
+			str := "%s: Invalid devnet name %q: %v"
+			err := fmt.Errorf(str, funcName, cfg.DevNet, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		activeNetParams.Params = &chainParams
+	}
 	if numNets > 1 {
-		str := "%s: The testnet, regtest, segnet, signet and simnet " +
-			"params can't be used together -- choose one of the " +
-			"five"
+		str := "%s: The testnet, regtest, segnet, signet, simnet and " +
+			"devnet params can't be used together -- choose one " +
+			"of the six"
 		err := fmt.Errorf(str, funcName)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
@@ -649,6 +704,10 @@ func loadConfig() (*config, []string, error) {
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
 	cfg.LogDir = filepath.Join(cfg.LogDir, netName(activeNetParams))
 
+	if cfg.ASMap != "" {
+		cfg.ASMap = cleanAndExpandPath(cfg.ASMap)
+	}
+
 	// Special show command to list supported subsystems and exit.
 	if cfg.DebugLevel == "show" {
 		fmt.Println("Supported subsystems", supportedSubsystems())
@@ -701,9 +760,25 @@ func loadConfig() (*config, []string, error) {
 	// Validate any given whitelisted IP addresses and networks.
 	if len(cfg.Whitelists) > 0 {
 		var ip net.IP
-		cfg.whitelists = make([]*net.IPNet, 0, len(cfg.Whitelists))
+		cfg.whitelists = make([]whitelist, 0, len(cfg.Whitelists))
 
 		for _, addr := range cfg.Whitelists {
+			// The permissions granted to the peers precede the
+			// address and default to noban.
+			permissions := peer.PermissionNoBan
+			if i := strings.LastIndex(addr, "@"); i != -1 {
+				permissions, err = peer.ParsePermissionFlags(addr[:i])
+				if err != nil {
+					str := "%s: The whitelist value of '%s' " +
+						"is invalid: %v"
+					err = fmt.Errorf(str, funcName, addr, err)
+					fmt.Fprintln(os.Stderr, err)
+					fmt.Fprintln(os.Stderr, usageMessage)
+					return nil, nil, err
+				}
+				addr = addr[i+1:]
+			}
+
 			_, ipnet, err := net.ParseCIDR(addr)
 			if err != nil {
 				ip = net.ParseIP(addr)
@@ -726,7 +801,10 @@ func loadConfig() (*config, []string, error) {
 					Mask: net.CIDRMask(bits, bits),
 				}
 			}
-			cfg.whitelists = append(cfg.whitelists, ipnet)
+			cfg.whitelists = append(cfg.whitelists, whitelist{
+				ipnet:       ipnet,
+				permissions: permissions,
+			})
 		}
 	}
 
@@ -812,6 +890,92 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.RPCOriginRateLimit < 0 {
+		str := "%s: The rpcoriginratelimit option may not be less " +
+			"than 0 -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.RPCOriginRateLimit)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the origins allowed to make cross-origin RPC requests, which
+	// consist of a scheme and host such as http://localhost:3000.
+	for i, origin := range cfg.RPCCORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		origin = strings.TrimSuffix(origin, "/")
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+
+			str := "%s: The rpccorsorigin value of '%s' is invalid"
+			err := fmt.Errorf(str, funcName, cfg.RPCCORSOrigins[i])
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.RPCCORSOrigins[i] = origin
+	}
+
+	// Validate any given trusted reverse proxy IP addresses and networks.
+	for _, addr := range cfg.RPCTrustedProxies {
+		_, ipnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				str := "%s: The rpctrustedproxy value of '%s' " +
+					"is invalid"
+				err = fmt.Errorf(str, funcName, addr)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			ipnet = &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			}
+		}
+		cfg.rpcTrustedProxies = append(cfg.rpcTrustedProxies, ipnet)
+	}
+
+	if cfg.RPCMaxBatchSize < 1 {
+		str := "%s: The rpcmaxbatchsize option may not be less than 1 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.RPCMaxBatchSize)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Limit the data carrier size to the range of null data scripts which
+	// are recognized as such.
+	if cfg.DataCarrierSize < 0 ||
+		cfg.DataCarrierSize > txscript.MaxDataCarrierSize {
+
+		str := "%s: The datacarriersize option must be in the range " +
+			"0-%d -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, txscript.MaxDataCarrierSize,
+			cfg.DataCarrierSize)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.MempoolExpiry < 0 {
+		str := "%s: The mempoolexpiry option may not be negative " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.MempoolExpiry)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate the the minrelaytxfee.
 	cfg.minRelayTxFee, err = btcutil.NewAmount(cfg.MinRelayTxFee)
 	if err != nil {
@@ -848,6 +1012,33 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// The max reorganization depth may not be negative.
+	if cfg.MaxReorgDepth < 0 {
+		str := "%s: The maxreorgdepth option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.MaxReorgDepth)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The header bundles must be signed by the configured key.
+	if len(cfg.HeadersBootstrapURLs) > 0 {
+		keyBytes, err := hex.DecodeString(cfg.HeadersBootstrapKey)
+		if err == nil {
+			cfg.headersBootstrapKey, err = btcec.ParsePubKey(keyBytes)
+		}
+		if err != nil {
+			str := "%s: The headersbootstrapkey option must be a " +
+				"valid public key when headersbootstrap is " +
+				"specified: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Limit the max orphan count to a sane vlue.
 	if cfg.MaxOrphanTxs < 0 {
 		str := "%s: The maxorphantx option may not be less than 0 " +
@@ -858,6 +1049,24 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.BlocksOnlyConns < 0 {
+		str := "%s: The blocksonlyconns option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.BlocksOnlyConns)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.MempoolSyncPeers < 0 {
+		str := "%s: The mempoolsyncpeers option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.MempoolSyncPeers)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the block priority and minimum block sizes to max block size.
 	cfg.BlockPrioritySize = minUint32(cfg.BlockPrioritySize, cfg.BlockMaxSize)
 	cfg.BlockMinSize = minUint32(cfg.BlockMinSize, cfg.BlockMaxSize)
@@ -902,6 +1111,16 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --specialtxindex and --dropspecialtxindex do not mix.
+	if cfg.SpecialTxIndex && cfg.DropSpecialTxIndex {
+		err := fmt.Errorf("%s: the --specialtxindex and "+
+			"--dropspecialtxindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --addrindex and --dropaddrindex do not mix.
 	if cfg.AddrIndex && cfg.DropAddrIndex {
 		err := fmt.Errorf("%s: the --addrindex and --dropaddrindex "+
@@ -1032,13 +1251,49 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Ensure the peer transport is registered.  Experimental transports are
+	// only allowed on private test networks and can't be used through a
+	// proxy since proxies only relay TCP connections.
+	transport, err := peer.LookupTransport(cfg.Transport)
+	if err != nil {
+		str := "%s: %v -- supported transports: %v"
+		err := fmt.Errorf(str, funcName, err, peer.SupportedTransports())
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if transport.Experimental() {
+		if !(cfg.RegressionTest || cfg.SimNet || cfg.SigNet ||
+			cfg.DevNet != "") {
+
+			str := "%s: the experimental %s transport may only be " +
+				"used on the regression test, simulation test, " +
+				"signet and devnet networks"
+			err := fmt.Errorf(str, funcName, cfg.Transport)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		if cfg.Proxy != "" || cfg.OnionProxy != "" {
+			str := "%s: the experimental %s transport may not be " +
+				"used with a proxy"
+			err := fmt.Errorf(str, funcName, cfg.Transport)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		btcdLog.Warnf("Using the experimental %s transport for peer "+
+			"connections", cfg.Transport)
+	}
+
 	// Setup dial and DNS resolution (lookup) functions depending on the
-	// specified options.  The default is to use the standard
-	// net.DialTimeout function as well as the system DNS resolver.  When a
-	// proxy is specified, the dial function is set to the proxy specific
-	// dial function and the lookup is set to use tor (unless --noonion is
-	// specified in which case the system DNS resolver is used).
-	cfg.dial = net.DialTimeout
+	// specified options.  The default is to use the dial function of the
+	// peer transport, which is net.DialTimeout for tcp, as well as the
+	// system DNS resolver.  When a proxy is specified, the dial function is
+	// set to dial through the proxy and the lookup is set to use tor (unless
+	// --noonion is specified in which case the system DNS resolver is used).
+	dial := connmgr.DialFunc(transport.Dial)
+	cfg.listen = transport.Listen
 	cfg.lookup = net.LookupIP
 	if cfg.Proxy != "" {
 		_, _, err := net.SplitHostPort(cfg.Proxy)
@@ -1053,22 +1308,19 @@ func loadConfig() (*config, []string, error) {
 		// Tor isolation flag means proxy credentials will be overridden
 		// unless there is also an onion proxy configured in which case
 		// that one will be overridden.
-		torIsolation := false
-		if cfg.TorIsolation && cfg.OnionProxy == "" &&
-			(cfg.ProxyUser != "" || cfg.ProxyPass != "") {
-
-			torIsolation = true
+		torIsolation := cfg.TorIsolation && cfg.OnionProxy == ""
+		if torIsolation && (cfg.ProxyUser != "" || cfg.ProxyPass != "") {
 			fmt.Fprintln(os.Stderr, "Tor isolation set -- "+
 				"overriding specified proxy user credentials")
 		}
 
-		proxy := &socks.Proxy{
+		proxy := &connmgr.SOCKS5Proxy{
 			Addr:         cfg.Proxy,
 			Username:     cfg.ProxyUser,
 			Password:     cfg.ProxyPass,
 			TorIsolation: torIsolation,
 		}
-		cfg.dial = proxy.DialTimeout
+		dial = proxy.DialTimeout
 
 		// Treat the proxy as tor and perform DNS resolution through it
 		// unless the --noonion flag is set or there is an
@@ -1086,6 +1338,7 @@ func loadConfig() (*config, []string, error) {
 	// function is set to use the onion-specific proxy while leaving the
 	// normal dial function as selected above.  This allows .onion address
 	// traffic to be routed through a different proxy than normal traffic.
+	onionDial := dial
 	if cfg.OnionProxy != "" {
 		_, _, err := net.SplitHostPort(cfg.OnionProxy)
 		if err != nil {
@@ -1105,15 +1358,13 @@ func loadConfig() (*config, []string, error) {
 				"credentials ")
 		}
 
-		cfg.oniondial = func(network, addr string, timeout time.Duration) (net.Conn, error) {
-			proxy := &socks.Proxy{
-				Addr:         cfg.OnionProxy,
-				Username:     cfg.OnionProxyUser,
-				Password:     cfg.OnionProxyPass,
-				TorIsolation: cfg.TorIsolation,
-			}
-			return proxy.DialTimeout(network, addr, timeout)
+		proxy := &connmgr.SOCKS5Proxy{
+			Addr:         cfg.OnionProxy,
+			Username:     cfg.OnionProxyUser,
+			Password:     cfg.OnionProxyPass,
+			TorIsolation: cfg.TorIsolation,
 		}
+		onionDial = proxy.DialTimeout
 
 		// When configured in bridge mode (both --onion and --proxy are
 		// configured), it means that the proxy configured by --proxy is
@@ -1124,17 +1375,14 @@ func loadConfig() (*config, []string, error) {
 				return connmgr.TorLookupIP(host, cfg.OnionProxy)
 			}
 		}
-	} else {
-		cfg.oniondial = cfg.dial
 	}
 
-	// Specifying --noonion means the onion address dial function results in
-	// an error.
+	// Specifying --noonion means dialing .onion addresses results in an
+	// error.
 	if cfg.NoOnion {
-		cfg.oniondial = func(a, b string, t time.Duration) (net.Conn, error) {
-			return nil, errors.New("tor has been disabled")
-		}
+		onionDial = nil
 	}
+	cfg.dial = connmgr.OnionDialFunc(dial, onionDial)
 
 	// Warn about missing config file only after all other configuration is
 	// done.  This prevents the warning on help messages and invalid
@@ -1219,10 +1467,6 @@ func createDefaultConfigFile(destinationPath string) error {
 // one was specified, but will otherwise use the normal dial function (which
 // could itself use a proxy or not).
 func btcdDial(addr net.Addr) (net.Conn, error) {
-	if strings.Contains(addr.String(), ".onion:") {
-		return cfg.oniondial(addr.Network(), addr.String(),
-			defaultConnectTimeout)
-	}
 	return cfg.dial(addr.Network(), addr.String(), defaultConnectTimeout)
 }
 
@@ -1234,7 +1478,7 @@ func btcdDial(addr net.Addr) (net.Conn, error) {
 // Any attempt to resolve a tor address (.onion) will return an error since they
 // are not intended to be resolved outside of the tor proxy.
 func btcdLookup(host string) ([]net.IP, error) {
-	if strings.HasSuffix(host, ".onion") {
+	if connmgr.IsOnionHost(host) {
 		return nil, fmt.Errorf("attempt to resolve tor address %s", host)
 	}
 
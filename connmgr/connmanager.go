@@ -5,6 +5,7 @@
 package connmgr
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -19,7 +20,8 @@ import (
 const maxFailedAttempts = 25
 
 var (
-	//ErrDialNil is used to indicate that Dial cannot be nil in the configuration.
+	//ErrDialNil is used to indicate that Dial and DialContext cannot both be
+	//nil in the configuration.
 	ErrDialNil = errors.New("Config: Dial cannot be nil")
 
 	// maxRetryDuration is the max duration of time retrying of a persistent
@@ -52,6 +54,44 @@ const (
 	ConnDisconnected
 )
 
+// ConnGroup identifies a group of outbound connections.  The connection
+// manager maintains a separate target number of connections for each group.
+type ConnGroup uint8
+
+// These constants define the groups of outbound connections.
+const (
+	// GroupRelay is the group of regular outbound connections which relay
+	// blocks, transactions and addresses.  It is the default group.
+	GroupRelay ConnGroup = iota
+
+	// GroupMasternode is the group of connections to the masternodes of the
+	// quorums the local masternode is a member of.
+	GroupMasternode
+
+	// GroupBlocksOnly is the group of outbound connections with which only
+	// blocks and headers are relayed.
+	GroupBlocksOnly
+
+	// numConnGroups is the number of connection groups.  It must be the
+	// last constant.
+	numConnGroups
+)
+
+// Map of connection groups back to their constant names for pretty printing.
+var connGroupStrings = map[ConnGroup]string{
+	GroupRelay:      "GroupRelay",
+	GroupMasternode: "GroupMasternode",
+	GroupBlocksOnly: "GroupBlocksOnly",
+}
+
+// String returns the ConnGroup in human-readable form.
+func (g ConnGroup) String() string {
+	if s, ok := connGroupStrings[g]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ConnGroup (%d)", uint8(g))
+}
+
 // ConnReq is the connection request to a network address. If permanent, the
 // connection will be retried on disconnection.
 type ConnReq struct {
@@ -61,9 +101,14 @@ type ConnReq struct {
 	Addr      net.Addr
 	Permanent bool
 
+	// Group is the group of outbound connections the connection counts
+	// towards.  It defaults to GroupRelay.
+	Group ConnGroup
+
 	conn       net.Conn
 	state      ConnState
 	stateMtx   sync.RWMutex
+	cancelDial context.CancelFunc
 	retryCount uint32
 }
 
@@ -74,6 +119,33 @@ func (c *ConnReq) updateState(state ConnState) {
 	c.stateMtx.Unlock()
 }
 
+// setDialCancel records the function which cancels the dial of the connection
+// request.  It returns false without recording it when the connection request
+// was already canceled.
+func (c *ConnReq) setDialCancel(cancel context.CancelFunc) bool {
+	c.stateMtx.Lock()
+	defer c.stateMtx.Unlock()
+
+	if c.state == ConnCanceled {
+		return false
+	}
+	c.cancelDial = cancel
+	return true
+}
+
+// cancel marks the connection request as canceled and cancels its dial when
+// one is in progress.
+func (c *ConnReq) cancel() {
+	c.stateMtx.Lock()
+	c.state = ConnCanceled
+	cancelDial := c.cancelDial
+	c.stateMtx.Unlock()
+
+	if cancelDial != nil {
+		cancelDial()
+	}
+}
+
 // ID returns a unique identifier for the connection request.
 func (c *ConnReq) ID() uint64 {
 	return atomic.LoadUint64(&c.id)
@@ -121,9 +193,15 @@ type Config struct {
 	OnAccept func(net.Conn)
 
 	// TargetOutbound is the number of outbound network connections to
-	// maintain. Defaults to 8.
+	// maintain in GroupRelay. Defaults to 8.
 	TargetOutbound uint32
 
+	// TargetGroupOutbound is the number of outbound network connections to
+	// maintain in each group other than GroupRelay, whose target is
+	// TargetOutbound.  No connections are made automatically for groups
+	// which are not present.
+	TargetGroupOutbound map[ConnGroup]uint32
+
 	// RetryDuration is the duration to wait before retrying connection
 	// requests. Defaults to 5s.
 	RetryDuration time.Duration
@@ -140,8 +218,21 @@ type Config struct {
 	// to.  If nil, no new connections will be made automatically.
 	GetNewAddress func() (net.Addr, error)
 
-	// Dial connects to the address on the named network. It cannot be nil.
+	// GetNewGroupAddress is a way to get an address to make a network
+	// connection to for a group other than GroupRelay, such as the address
+	// of a quorum member for GroupMasternode.  If nil, GetNewAddress is
+	// used for all groups.
+	GetNewGroupAddress func(ConnGroup) (net.Addr, error)
+
+	// Dial connects to the address on the named network.  It cannot be nil
+	// unless DialContext is set.
 	Dial func(net.Addr) (net.Conn, error)
+
+	// DialContext connects to the address on the named network and
+	// abandons the attempt when the context is canceled.  When set, it is
+	// used instead of Dial and connection attempts are canceled once they
+	// are removed or the connection manager is stopped.
+	DialContext func(context.Context, net.Addr) (net.Conn, error)
 }
 
 // registerPending is used to register a pending connection attempt. By
@@ -180,16 +271,51 @@ type ConnManager struct {
 
 	cfg            Config
 	wg             sync.WaitGroup
-	failedAttempts uint64
+	failedAttempts [numConnGroups]uint64
 	requests       chan interface{}
 	quit           chan struct{}
+
+	// dialCtx is the parent context of all dials.  It is canceled by
+	// cancelDials when the connection manager is stopped.
+	dialCtx     context.Context
+	cancelDials context.CancelFunc
+}
+
+// targetOutbound returns the number of outbound connections to maintain in the
+// passed group.
+func (cm *ConnManager) targetOutbound(group ConnGroup) uint32 {
+	if group == GroupRelay {
+		return cm.cfg.TargetOutbound
+	}
+	return cm.cfg.TargetGroupOutbound[group]
+}
+
+// newAddressFunc returns the function used to get addresses for new connections
+// in the passed group, or nil when no new connections are made automatically.
+func (cm *ConnManager) newAddressFunc(group ConnGroup) func() (net.Addr, error) {
+	if group == GroupRelay || cm.cfg.GetNewGroupAddress == nil {
+		return cm.cfg.GetNewAddress
+	}
+	return func() (net.Addr, error) {
+		return cm.cfg.GetNewGroupAddress(group)
+	}
+}
+
+// dial connects to the passed address with DialContext when set, or Dial
+// otherwise.
+func (cm *ConnManager) dial(ctx context.Context, addr net.Addr) (net.Conn, error) {
+	if cm.cfg.DialContext != nil {
+		return cm.cfg.DialContext(ctx, addr)
+	}
+	return cm.cfg.Dial(addr)
 }
 
 // handleFailedConn handles a connection failed due to a disconnect or any
 // other failure. If permanent, it retries the connection after the configured
-// retry duration. Otherwise, if required, it makes a new connection request.
-// After maxFailedConnectionAttempts new connections will be retried after the
-// configured retry duration.
+// retry duration. Otherwise, if required, it makes a new connection request in
+// the same group.  After maxFailedConnectionAttempts in a group, new
+// connections in that group will be retried after the configured retry
+// duration.
 func (cm *ConnManager) handleFailedConn(c *ConnReq) {
 	if atomic.LoadInt32(&cm.stop) != 0 {
 		return
@@ -204,17 +330,18 @@ func (cm *ConnManager) handleFailedConn(c *ConnReq) {
 		time.AfterFunc(d, func() {
 			cm.Connect(c)
 		})
-	} else if cm.cfg.GetNewAddress != nil {
-		cm.failedAttempts++
-		if cm.failedAttempts >= maxFailedAttempts {
-			log.Debugf("Max failed connection attempts reached: [%d] "+
-				"-- retrying connection in: %v", maxFailedAttempts,
-				cm.cfg.RetryDuration)
+	} else if cm.newAddressFunc(c.Group) != nil {
+		group := c.Group
+		cm.failedAttempts[group]++
+		if cm.failedAttempts[group] >= maxFailedAttempts {
+			log.Debugf("Max failed connection attempts reached in "+
+				"%v: [%d] -- retrying connection in: %v", group,
+				maxFailedAttempts, cm.cfg.RetryDuration)
 			time.AfterFunc(cm.cfg.RetryDuration, func() {
-				cm.NewConnReq()
+				cm.NewGroupConnReq(group)
 			})
 		} else {
-			go cm.NewConnReq()
+			go cm.NewGroupConnReq(group)
 		}
 	}
 }
@@ -265,7 +392,7 @@ out:
 				conns[connReq.id] = connReq
 				log.Debugf("Connected to %v", connReq)
 				connReq.retryCount = 0
-				cm.failedAttempts = 0
+				cm.failedAttempts[connReq.Group] = 0
 
 				delete(pending, connReq.id)
 
@@ -286,8 +413,8 @@ out:
 					// Pending connection was found, remove
 					// it from pending map if we should
 					// ignore a later, successful
-					// connection.
-					connReq.updateState(ConnCanceled)
+					// connection, and abandon the dial.
+					connReq.cancel()
 					log.Debugf("Canceling: %v", connReq)
 					delete(pending, msg.id)
 					continue
@@ -317,12 +444,13 @@ out:
 				}
 
 				// Otherwise, we will attempt a reconnection if
-				// we do not have enough peers, or if this is a
-				// persistent peer. The connection request is
-				// re added to the pending map, so that
-				// subsequent processing of connections and
+				// we do not have enough peers in its group, or
+				// if this is a persistent peer. The connection
+				// request is re added to the pending map, so
+				// that subsequent processing of connections and
 				// failures do not ignore the request.
-				if uint32(len(conns)) < cm.cfg.TargetOutbound ||
+				if groupConnCount(conns, connReq.Group) <
+					cm.targetOutbound(connReq.Group) ||
 					connReq.Permanent {
 
 					connReq.updateState(ConnPending)
@@ -356,17 +484,35 @@ out:
 	log.Trace("Connection handler done")
 }
 
-// NewConnReq creates a new connection request and connects to the
+// groupConnCount returns the number of connections in the passed group.
+func groupConnCount(conns map[uint64]*ConnReq, group ConnGroup) uint32 {
+	var count uint32
+	for _, connReq := range conns {
+		if connReq.Group == group {
+			count++
+		}
+	}
+	return count
+}
+
+// NewConnReq creates a new connection request in GroupRelay and connects to the
 // corresponding address.
 func (cm *ConnManager) NewConnReq() {
+	cm.NewGroupConnReq(GroupRelay)
+}
+
+// NewGroupConnReq creates a new connection request in the passed group and
+// connects to the corresponding address.
+func (cm *ConnManager) NewGroupConnReq(group ConnGroup) {
 	if atomic.LoadInt32(&cm.stop) != 0 {
 		return
 	}
-	if cm.cfg.GetNewAddress == nil {
+	getNewAddress := cm.newAddressFunc(group)
+	if getNewAddress == nil {
 		return
 	}
 
-	c := &ConnReq{}
+	c := &ConnReq{Group: group}
 	atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
 
 	// Submit a request of a pending connection attempt to the connection
@@ -388,7 +534,7 @@ func (cm *ConnManager) NewConnReq() {
 		return
 	}
 
-	addr, err := cm.cfg.GetNewAddress()
+	addr, err := getNewAddress()
 	if err != nil {
 		select {
 		case cm.requests <- handleFailed{c, err}:
@@ -439,9 +585,19 @@ func (cm *ConnManager) Connect(c *ConnReq) {
 		}
 	}
 
+	// The dial is abandoned when the connection request is removed while
+	// it is in progress or the connection manager is stopped.
+	ctx, cancel := context.WithCancel(cm.dialCtx)
+	defer cancel()
+	if !c.setDialCancel(cancel) {
+		log.Debugf("Ignoring connect for canceled connreq=%v", c)
+		return
+	}
+
 	log.Debugf("Attempting to connect to %v", c)
 
-	conn, err := cm.cfg.Dial(c.Addr)
+	conn, err := cm.dial(ctx, c.Addr)
+	c.setDialCancel(nil)
 	if err != nil {
 		select {
 		case cm.requests <- handleFailed{c, err}:
@@ -529,6 +685,14 @@ func (cm *ConnManager) Start() {
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
 		go cm.NewConnReq()
 	}
+	for group, target := range cm.cfg.TargetGroupOutbound {
+		if group == GroupRelay {
+			continue
+		}
+		for i := uint32(0); i < target; i++ {
+			go cm.NewGroupConnReq(group)
+		}
+	}
 }
 
 // Wait blocks until the connection manager halts gracefully.
@@ -552,13 +716,14 @@ func (cm *ConnManager) Stop() {
 	}
 
 	close(cm.quit)
+	cm.cancelDials()
 	log.Trace("Connection manager stopped")
 }
 
 // New returns a new connection manager.
 // Use Start to start connecting to the network.
 func New(cfg *Config) (*ConnManager, error) {
-	if cfg.Dial == nil {
+	if cfg.Dial == nil && cfg.DialContext == nil {
 		return nil, ErrDialNil
 	}
 	// Default to sane values
@@ -573,5 +738,13 @@ func New(cfg *Config) (*ConnManager, error) {
 		requests: make(chan interface{}),
 		quit:     make(chan struct{}),
 	}
+	if cfg.TargetGroupOutbound != nil {
+		cm.cfg.TargetGroupOutbound = make(map[ConnGroup]uint32,
+			len(cfg.TargetGroupOutbound))
+		for group, target := range cfg.TargetGroupOutbound {
+			cm.cfg.TargetGroupOutbound[group] = target
+		}
+	}
+	cm.dialCtx, cm.cancelDials = context.WithCancel(context.Background())
 	return &cm, nil
 }
@@ -5,6 +5,7 @@
 package connmgr
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -216,6 +217,90 @@ func TestTargetOutbound(t *testing.T) {
 	cmgr.Stop()
 }
 
+// TestTargetGroupOutbound tests the target number of outbound connections in
+// each group and that disconnected connections are replaced in their group.
+func TestTargetGroupOutbound(t *testing.T) {
+	targets := map[ConnGroup]uint32{
+		GroupRelay:      3,
+		GroupMasternode: 2,
+		GroupBlocksOnly: 1,
+	}
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound: targets[GroupRelay],
+		TargetGroupOutbound: map[ConnGroup]uint32{
+			GroupMasternode: targets[GroupMasternode],
+			GroupBlocksOnly: targets[GroupBlocksOnly],
+		},
+		Dial: mockDialer,
+		GetNewAddress: func() (net.Addr, error) {
+			return &net.TCPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: 18555,
+			}, nil
+		},
+		GetNewGroupAddress: func(group ConnGroup) (net.Addr, error) {
+			ip := "127.0.0.1"
+			if group == GroupMasternode {
+				ip = "127.0.0.2"
+			}
+			return &net.TCPAddr{
+				IP:   net.ParseIP(ip),
+				Port: 18555,
+			}, nil
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+	defer cmgr.Stop()
+
+	var blocksOnly *ConnReq
+	counts := make(map[ConnGroup]uint32)
+	for i := 0; i < 6; i++ {
+		c := <-connected
+		counts[c.Group]++
+		wantIP := "127.0.0.1"
+		if c.Group == GroupMasternode {
+			wantIP = "127.0.0.2"
+		}
+		if ip := c.Addr.(*net.TCPAddr).IP.String(); ip != wantIP {
+			t.Fatalf("unexpected address for %v: got %v, want %v",
+				c.Group, ip, wantIP)
+		}
+		if c.Group == GroupBlocksOnly {
+			blocksOnly = c
+		}
+	}
+	select {
+	case c := <-connected:
+		t.Fatalf("target outbound: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond):
+	}
+	for group, target := range targets {
+		if counts[group] != target {
+			t.Fatalf("unexpected connections in %v: got %d, want %d",
+				group, counts[group], target)
+		}
+	}
+
+	// A disconnected connection is replaced in its group.
+	cmgr.Disconnect(blocksOnly.ID())
+	select {
+	case c := <-connected:
+		if c.Group != GroupBlocksOnly {
+			t.Fatalf("unexpected replacement group: got %v, want %v",
+				c.Group, GroupBlocksOnly)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("disconnected connection wasn't replaced")
+	}
+}
+
 // TestRetryPermanent tests that permanent connection requests are retried.
 //
 // We make a permanent connection request using Connect, disconnect it using
@@ -473,6 +558,64 @@ func TestRemovePendingConnection(t *testing.T) {
 	cmgr.Stop()
 }
 
+// TestCancelPendingDial tests that removing a pending connection request and
+// stopping the connection manager cancel dials in progress.
+func TestCancelPendingDial(t *testing.T) {
+	dialing := make(chan struct{})
+	canceled := make(chan struct{})
+	blockingDialer := func(ctx context.Context, addr net.Addr) (net.Conn, error) {
+		dialing <- struct{}{}
+		<-ctx.Done()
+		canceled <- struct{}{}
+		return nil, ctx.Err()
+	}
+	cmgr, err := New(&Config{
+		DialContext: blockingDialer,
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+
+	cr := &ConnReq{
+		Addr: &net.TCPAddr{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: 18555,
+		},
+		Permanent: true,
+	}
+	go cmgr.Connect(cr)
+	<-dialing
+
+	cmgr.Remove(cr.ID())
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("dial of removed connection request wasn't canceled")
+	}
+	if cr.State() != ConnCanceled {
+		t.Fatalf("request wasn't canceled, status is: %v", cr.State())
+	}
+
+	// Connecting a canceled request doesn't dial again.
+	cmgr.Connect(cr)
+	select {
+	case <-dialing:
+		t.Fatal("canceled connection request was dialed")
+	case <-time.After(time.Millisecond):
+	}
+
+	go cmgr.Connect(&ConnReq{Addr: cr.Addr})
+	<-dialing
+	cmgr.Stop()
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("dial wasn't canceled when stopping")
+	}
+	cmgr.Wait()
+}
+
 // TestCancelIgnoreDelayedConnection tests that a canceled connection request will
 // not execute the on connection callback, even if an outstanding retry
 // succeeds.
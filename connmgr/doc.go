@@ -10,5 +10,18 @@ Connection Manager Overview
 Connection Manager handles all the general connection concerns such as
 maintaining a set number of outbound connections, sourcing peers, banning,
 limiting max connections, tor lookup, etc.
+
+Outbound connections are split into groups, each with its own target number of
+connections: regular relay connections, connections to the members of
+masternode quorums, and connections with which only blocks are relayed.  Failed
+connections are retried with a backoff and connection attempts which are no
+longer wanted are canceled.
+
+Connections are dialed with a DialFunc, which may route them through a SOCKS5
+proxy such as Tor.  SOCKS5Proxy resolves hostnames, including .onion addresses,
+through the proxy and can isolate every connection on its own Tor circuit with
+random credentials, while OnionDialFunc routes .onion addresses to a dedicated
+onion proxy.  Together with TorLookupIP this allows all traffic to be routed
+through Tor.
 */
 package connmgr
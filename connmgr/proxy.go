@@ -0,0 +1,302 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone         = 0x00
+	socks5AuthUserPass     = 0x02
+	socks5AuthNoAcceptable = 0xff
+
+	socks5UserPassVersion = 0x01
+
+	socks5CmdConnect = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+
+	// torIsolationCredentialSize is the number of random bytes of the
+	// username and password used for Tor stream isolation.
+	torIsolationCredentialSize = 16
+)
+
+var (
+	// ErrTorDisabled indicates an attempt to dial a .onion address while
+	// connecting to Tor onion services is disabled.
+	ErrTorDisabled = errors.New("tor has been disabled")
+
+	// ErrProxyAuthFailed indicates the proxy rejected the username and
+	// password.
+	ErrProxyAuthFailed = errors.New("proxy authentication failed")
+)
+
+// DialFunc connects to the address on the named network within the timeout.
+// It has the signature of net.DialTimeout, which is the default, and
+// SOCKS5Proxy.DialTimeout and OnionDialFunc provide implementations which
+// route connections through proxies.
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// IsOnionHost returns whether the host is the address of a Tor onion service.
+// Onion addresses can't be resolved and must be dialed through Tor.
+func IsOnionHost(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), ".onion")
+}
+
+// OnionDialFunc returns a DialFunc which dials .onion addresses with onionDial
+// and all other addresses with dial.  This allows onion service traffic to be
+// routed through a Tor proxy which differs from the proxy used for other
+// connections, if any.  Dialing a .onion address returns ErrTorDisabled when
+// onionDial is nil.
+func OnionDialFunc(dial, onionDial DialFunc) DialFunc {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil || !IsOnionHost(host) {
+			return dial(network, address, timeout)
+		}
+		if onionDial == nil {
+			return nil, ErrTorDisabled
+		}
+		return onionDial(network, address, timeout)
+	}
+}
+
+// ProxiedAddr is the remote address of a connection through a proxy, which is
+// the address requested from the proxy rather than the address of the proxy
+// itself.
+type ProxiedAddr struct {
+	Net  string
+	Host string
+	Port int
+}
+
+// Network returns the network of the address.
+//
+// This is part of the net.Addr interface.
+func (a *ProxiedAddr) Network() string {
+	return a.Net
+}
+
+// String returns the host and port of the address.
+//
+// This is part of the net.Addr interface.
+func (a *ProxiedAddr) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// Ensure ProxiedAddr implements the net.Addr interface.
+var _ net.Addr = (*ProxiedAddr)(nil)
+
+// proxiedConn is a connection through a proxy which reports the requested
+// address as its remote address.
+type proxiedConn struct {
+	net.Conn
+	remoteAddr *ProxiedAddr
+}
+
+// RemoteAddr returns the address requested from the proxy.
+//
+// This is part of the net.Conn interface.
+func (c *proxiedConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// SOCKS5Proxy dials connections through a SOCKS5 proxy (RFC 1928) such as Tor.
+// Hostnames, including .onion addresses, are resolved by the proxy.
+type SOCKS5Proxy struct {
+	// Addr is the host and port of the proxy.
+	Addr string
+
+	// Username and Password are the credentials used to authenticate with
+	// the proxy (RFC 1929).  No authentication is used when both are empty.
+	Username string
+	Password string
+
+	// TorIsolation authenticates every connection with random credentials
+	// instead of Username and Password.  Tor isolates streams with
+	// different credentials on separate circuits, so connections can't be
+	// linked to each other by the exit relay or onion service.
+	TorIsolation bool
+
+	// Dial connects to the proxy.  It defaults to net.DialTimeout.
+	Dial DialFunc
+}
+
+// credentials returns the username and password to authenticate a new
+// connection with, or empty strings when no authentication is used.
+func (p *SOCKS5Proxy) credentials() (string, string, error) {
+	if !p.TorIsolation {
+		return p.Username, p.Password, nil
+	}
+
+	var b [2 * torIsolationCredentialSize]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b[:torIsolationCredentialSize]),
+		hex.EncodeToString(b[torIsolationCredentialSize:]), nil
+}
+
+// DialTimeout connects to the address on the named network through the proxy.
+// The timeout covers both connecting to the proxy and the SOCKS5 handshake.
+// The remote address of the returned connection is a ProxiedAddr.
+//
+// This has the signature of a DialFunc.
+func (p *SOCKS5Proxy) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %v", portStr, err)
+	}
+	if len(host) > 255 {
+		return nil, fmt.Errorf("host %q is too long", host)
+	}
+	username, password, err := p.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	dial := p.Dial
+	if dial == nil {
+		dial = net.DialTimeout
+	}
+	conn, err := dial("tcp", p.Addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout != 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	err = socks5Handshake(conn, username, password, host, uint16(port))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %v", p.Addr, err)
+	}
+	if timeout != 0 {
+		conn.SetDeadline(time.Time{})
+	}
+
+	remoteAddr := &ProxiedAddr{Net: network, Host: host, Port: int(port)}
+	return &proxiedConn{Conn: conn, remoteAddr: remoteAddr}, nil
+}
+
+// socks5Handshake negotiates the authentication method, authenticates when
+// credentials are given and requests a connection to the host and port.
+func socks5Handshake(rw io.ReadWriter, username, password, host string, port uint16) error {
+	// Offer username and password authentication only when there are
+	// credentials.
+	method := byte(socks5AuthNone)
+	if username != "" || password != "" {
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("proxy credentials are too long")
+		}
+		method = socks5AuthUserPass
+	}
+	if _, err := rw.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+	var reply [2]byte
+	if _, err := io.ReadFull(rw, reply[:]); err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return ErrTorInvalidProxyResponse
+	}
+	switch reply[1] {
+	case method:
+	case socks5AuthNoAcceptable:
+		return ErrTorUnrecognizedAuthMethod
+	default:
+		return ErrTorInvalidProxyResponse
+	}
+
+	if method == socks5AuthUserPass {
+		buf := make([]byte, 0, 3+len(username)+len(password))
+		buf = append(buf, socks5UserPassVersion, byte(len(username)))
+		buf = append(buf, username...)
+		buf = append(buf, byte(len(password)))
+		buf = append(buf, password...)
+		if _, err := rw.Write(buf); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(rw, reply[:]); err != nil {
+			return err
+		}
+		if reply[0] != socks5UserPassVersion {
+			return ErrTorInvalidProxyResponse
+		}
+		if reply[1] != 0x00 {
+			return ErrProxyAuthFailed
+		}
+	}
+
+	// Request the connection.  IP addresses are sent as such and all other
+	// hosts are sent as domain names to be resolved by the proxy.
+	buf := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		buf = append(buf, socks5AddrDomain, byte(len(host)))
+		buf = append(buf, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		buf = append(buf, socks5AddrIPv4)
+		buf = append(buf, ip4...)
+	} else {
+		buf = append(buf, socks5AddrIPv6)
+		buf = append(buf, ip.To16()...)
+	}
+	buf = append(buf, byte(port>>8), byte(port))
+	if _, err := rw.Write(buf); err != nil {
+		return err
+	}
+
+	// The reply consists of the version, the status, a reserved byte and
+	// the address the proxy bound, which isn't needed.
+	var header [4]byte
+	if _, err := io.ReadFull(rw, header[:]); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return ErrTorInvalidProxyResponse
+	}
+	if header[1] != torSucceeded {
+		if err, ok := torStatusErrors[header[1]]; ok {
+			return err
+		}
+		return ErrTorInvalidProxyResponse
+	}
+	var boundAddrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		boundAddrLen = net.IPv4len
+	case socks5AddrIPv6:
+		boundAddrLen = net.IPv6len
+	case socks5AddrDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(rw, l[:]); err != nil {
+			return err
+		}
+		boundAddrLen = int(l[0])
+	default:
+		return ErrTorInvalidAddressResponse
+	}
+	bound := make([]byte, boundAddrLen+2)
+	_, err := io.ReadFull(rw, bound)
+	return err
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// socks5Request is the connection request received by mockSOCKS5Proxy.
+type socks5Request struct {
+	username string
+	password string
+	host     string
+	port     int
+}
+
+// mockSOCKS5Proxy accepts a single SOCKS5 connection on the listener,
+// authenticating with the username and password when the client offers it,
+// and reports the request on the returned channel.  The connection is echoed
+// back after the handshake.
+func mockSOCKS5Proxy(t *testing.T, listener net.Listener) <-chan socks5Request {
+	requests := make(chan socks5Request, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		readBytes := func(n int) []byte {
+			b := make([]byte, n)
+			if _, err := io.ReadFull(conn, b); err != nil {
+				t.Errorf("mock proxy: read: %v", err)
+			}
+			return b
+		}
+
+		var req socks5Request
+		greeting := readBytes(2)
+		method := readBytes(int(greeting[1]))[0]
+		conn.Write([]byte{socks5Version, method})
+		if method == socks5AuthUserPass {
+			header := readBytes(2)
+			req.username = string(readBytes(int(header[1])))
+			req.password = string(readBytes(int(readBytes(1)[0])))
+			conn.Write([]byte{socks5UserPassVersion, 0x00})
+		}
+
+		header := readBytes(4)
+		switch header[3] {
+		case socks5AddrIPv4:
+			req.host = net.IP(readBytes(net.IPv4len)).String()
+		case socks5AddrIPv6:
+			req.host = net.IP(readBytes(net.IPv6len)).String()
+		case socks5AddrDomain:
+			req.host = string(readBytes(int(readBytes(1)[0])))
+		}
+		port := readBytes(2)
+		req.port = int(port[0])<<8 | int(port[1])
+		requests <- req
+
+		conn.Write([]byte{socks5Version, torSucceeded, 0x00,
+			socks5AddrIPv4, 127, 0, 0, 1, 0, 0})
+		io.Copy(conn, conn)
+	}()
+	return requests
+}
+
+// TestSOCKS5Proxy ensures connections are requested from a SOCKS5 proxy with
+// the expected address and credentials.
+func TestSOCKS5Proxy(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxy     SOCKS5Proxy
+		addr      string
+		wantHost  string
+		wantPort  int
+		wantCreds bool
+	}{{
+		name:     "ipv4 without authentication",
+		addr:     "1.2.3.4:9999",
+		wantHost: "1.2.3.4",
+		wantPort: 9999,
+	}, {
+		name:     "ipv6 without authentication",
+		addr:     "[2001:db8::1]:9999",
+		wantHost: "2001:db8::1",
+		wantPort: 9999,
+	}, {
+		name: "onion with authentication",
+		proxy: SOCKS5Proxy{
+			Username: "user",
+			Password: "pass",
+		},
+		addr:      "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion:9999",
+		wantHost:  "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion",
+		wantPort:  9999,
+		wantCreds: true,
+	}, {
+		name: "tor isolation",
+		proxy: SOCKS5Proxy{
+			TorIsolation: true,
+		},
+		addr:      "seed.example.com:19999",
+		wantHost:  "seed.example.com",
+		wantPort:  19999,
+		wantCreds: true,
+	}}
+
+	for _, test := range tests {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen: unexpected error: %v", err)
+		}
+		requests := mockSOCKS5Proxy(t, listener)
+
+		proxy := test.proxy
+		proxy.Addr = listener.Addr().String()
+		conn, err := proxy.DialTimeout("tcp", test.addr, time.Second)
+		if err != nil {
+			listener.Close()
+			t.Fatalf("%s: DialTimeout: unexpected error: %v", test.name,
+				err)
+		}
+		req := <-requests
+		if req.host != test.wantHost || req.port != test.wantPort {
+			t.Errorf("%s: requested %s:%d, want %s:%d", test.name,
+				req.host, req.port, test.wantHost, test.wantPort)
+		}
+		gotCreds := req.username != "" || req.password != ""
+		if gotCreds != test.wantCreds {
+			t.Errorf("%s: unexpected credentials %q:%q", test.name,
+				req.username, req.password)
+		}
+		if !proxy.TorIsolation && req.username != proxy.Username {
+			t.Errorf("%s: username %q, want %q", test.name,
+				req.username, proxy.Username)
+		}
+		if conn.RemoteAddr().String() != test.addr {
+			t.Errorf("%s: remote address %v, want %v", test.name,
+				conn.RemoteAddr(), test.addr)
+		}
+
+		// The connection is usable after the handshake.
+		conn.Write([]byte("ping"))
+		var b [4]byte
+		if _, err := io.ReadFull(conn, b[:]); err != nil ||
+			string(b[:]) != "ping" {
+
+			t.Errorf("%s: read %q, %v, want ping", test.name, b, err)
+		}
+		conn.Close()
+		listener.Close()
+	}
+
+	// Stream isolation uses different credentials for every connection.
+	proxy := &SOCKS5Proxy{TorIsolation: true}
+	user1, pass1, _ := proxy.credentials()
+	user2, pass2, _ := proxy.credentials()
+	if user1 == user2 || pass1 == pass2 {
+		t.Errorf("credentials: reused isolation credentials %q:%q",
+			user1, pass1)
+	}
+}
+
+// TestOnionDialFunc ensures .onion addresses are dialed with the onion dial
+// function and are rejected when onion services are disabled.
+func TestOnionDialFunc(t *testing.T) {
+	errDial := errors.New("dial")
+	errOnionDial := errors.New("onion dial")
+	dial := func(string, string, time.Duration) (net.Conn, error) {
+		return nil, errDial
+	}
+	onionDial := func(string, string, time.Duration) (net.Conn, error) {
+		return nil, errOnionDial
+	}
+
+	tests := []struct {
+		addr      string
+		onionDial DialFunc
+		want      error
+	}{
+		{"1.2.3.4:9999", onionDial, errDial},
+		{"example.com:9999", onionDial, errDial},
+		{"example.onion:9999", onionDial, errOnionDial},
+		{"EXAMPLE.ONION:9999", onionDial, errOnionDial},
+		{"example.onion:9999", nil, ErrTorDisabled},
+		{"1.2.3.4:9999", nil, errDial},
+	}
+	for i, test := range tests {
+		f := OnionDialFunc(dial, test.onionDial)
+		_, err := f("tcp", test.addr, time.Second)
+		if err != test.want {
+			t.Errorf("OnionDialFunc #%d (%s): got %v, want %v", i,
+				test.addr, err, test.want)
+		}
+	}
+}
@@ -27,6 +27,10 @@ Application Options:
       --addrindex             Maintain a full address-based transaction index
                               which makes the searchrawtransactions RPC
                               available
+      --asmap=                File mapping IP prefixes to autonomous system
+                              numbers, one '<prefix> <asn>' per line, used to
+                              spread outbound connections across network
+                              operators rather than address ranges
       --banduration=          How long to ban misbehaving peers.  Valid time
                               units are {s, m, h}.  Minimum 1 second (default:
                               24h0m0s)
@@ -44,12 +48,22 @@ Application Options:
                               transactions when creating a block (default:
                               50000)
       --blocksonly            Do not accept transactions from remote peers.
+      --blocksonlyoutbound    Only relay blocks and headers with outbound
+                              peers, which are asked not to announce
+                              transactions or InstantSend locks
+      --blocksonlyconns=      Number of additional outbound peers to maintain
+                              with which only blocks and headers are relayed
   -C, --configfile=           Path to configuration file
       --connect=              Connect only to the specified peers at startup
       --cpuprofile=           Write CPU profile to the specified file
+      --datacarriersize=      Maximum number of bytes of data pushed by a
+                              relayed null data (OP_RETURN) output, excluding
+                              the opcodes counted by dashd (0-80) (default:
+                              80)
   -b, --datadir=              Directory to store data
       --dbtype=               Database backend to use for the Block Chain
                               (default: ffldb)
+      --devnet=               Use the devnet with the given name
   -d, --debuglevel=           Logging level for all subsystems {trace, debug,
                               info, warn, error, critical} -- You may also
                               specify
@@ -62,6 +76,8 @@ Application Options:
       --dropcfindex           Deletes the index used for committed filtering
                               (CF) support from the database on start up and
                               then exits.
+      --dropspecialtxindex    Deletes the special transaction index from the
+                              database on start up and then exits.
       --droptxindex           Deletes the hash-based transaction index from the
                               database on start up and then exits.
       --externalip=           Add an ip to the list of local addresses we claim
@@ -70,6 +86,13 @@ Application Options:
       --limitfreerelay=       Limit relay of transactions with no transaction
                               fee to the given amount in thousands of bytes per
                               minute (default: 15)
+      --headersbootstrap=     Add an HTTPS URL of a signed block header bundle
+                              used to bootstrap the header chain while
+                              connecting to peers -- Later URLs are only used
+                              when the earlier ones fail
+      --headersbootstrapkey=  Hex encoded public key the block header bundles
+                              must be signed by -- Required with
+                              headersbootstrap
       --listen=               Add an interface/port to listen for connections
                               (default all interfaces port: 8333, testnet:
                               18333, signet: 38333)
@@ -78,6 +101,17 @@ Application Options:
                               memory (default: 100)
       --maxpeers=             Max number of inbound and outbound peers
                               (default: 125)
+      --maxreorgdepth=        Reject chain reorganizations which would disconnect
+                              more than this number of blocks unless the new
+                              chain is chainlocked -- 0 allows reorganizations
+                              of any depth
+      --mempoolexpiry=        How long a transaction may stay in the memory
+                              pool without being mined before it is evicted.
+                              Valid time units are {s, m, h}.  Zero disables
+                              expiration (default: 336h0m0s)
+      --mempoolsyncpeers=     Number of peers whose mempool is requested once
+                              the chain is current after startup -- 0
+                              disables the mempool sync (default: 3)
       --miningaddr=           Add the specified payment address to the list of
                               addresses to use for generated blocks -- At least
                               one address is required if the generate option is
@@ -114,35 +148,60 @@ Application Options:
       --proxypass=            Password for proxy server
       --proxyuser=            Username for proxy server
       --regtest               Use the regression test network
+      --reindex               Rebuild the chain state and optional indexes from
+                              the stored blocks on start up.  An interrupted
+                              reindex is resumed on the next start
       --rejectnonstd          Reject non-standard transactions regardless of
                               the default settings for the active network.
       --relaynonstd           Relay non-standard transactions regardless of the
                               default settings for the active network.
+      --rpccorsorigin=        Add an origin whose browser-based clients may
+                              make cross-origin requests to the RPC server, or
+                              * for any origin (eg. http://localhost:3000)
       --rpccert=              File containing the certificate file
       --rpckey=               File containing the certificate key
       --rpclimitpass=         Password for limited RPC connections
       --rpclimituser=         Username for limited RPC connections
       --rpclisten=            Add an interface/port to listen for RPC
                               connections (default port: 8334, testnet: 18334)
+      --rpcmaxbatchsize=      Max number of requests allowed in a single batched
+                              JSON-RPC request (default: 1000)
       --rpcmaxclients=        Max number of RPC clients for standard
                               connections (default: 10)
       --rpcmaxconcurrentreqs= Max number of concurrent RPC requests that may be
                               processed concurrently (default: 20)
       --rpcmaxwebsockets=     Max number of RPC websocket connections (default:
                               25)
+      --rpcoriginratelimit=   Max number of RPC requests per second from each
+                              origin of browser-based clients -- 0 to disable
       --rpcquirks             Mirror some JSON-RPC quirks of Bitcoin Core --
                               NOTE: Discouraged unless interoperability issues
                               need to be worked around
   -P, --rpcpass=              Password for RPC connections
+      --rpcserialbatch        Process the entries of batched JSON-RPC requests
+                              one after the other instead of concurrently, so
+                              entries observe the effects of earlier entries of
+                              the same batch
+      --rpctrustedproxy=      Add an IP network or IP of a reverse proxy whose
+                              X-Forwarded-For header identifies the RPC clients
+                              it forwards requests of (eg. 127.0.0.1 or
+                              10.0.0.0/8)
   -u, --rpcuser=              Username for RPC connections
       --sigcachemaxsize=      The maximum number of entries in the signature
                               verification cache (default: 100000)
       --simnet                Use the simulation test network
+      --specialtxindex        Maintain an index of special transactions by
+                              type which makes the getspecialtxes RPC
+                              available for ranges of blocks
       --testnet               Use the test network
       --torisolation          Enable Tor stream isolation by randomizing user
                               credentials for each connection.
       --trickleinterval=      Minimum time between attempts to send new
                               inventory to a connected peer (default: 10s)
+      --transport=            Transport used for peer connections --
+                              Transports other than tcp are experimental and
+                              only allowed on the regression test, simulation
+                              test and signet networks (default: tcp)
       --txindex               Maintain a full hash-based transaction index
                               which makes all transactions available via the
                               getrawtransaction RPC
@@ -150,8 +209,12 @@ Application Options:
                               for more information.
       --upnp                  Use UPnP to map our listening port outside of NAT
   -V, --version               Display version information and exit
-      --whitelist=            Add an IP network or IP that will not be banned.
-                              (eg. 192.168.1.0/24 or ::1)
+      --whitelist=            Add an IP network or IP whose peers are granted
+                              permissions, optionally prefixed by a comma
+                              separated list of permissions and @ --
+                              permissions are noban, relay, forcerelay, mempool
+                              and all, and default to noban (eg.
+                              192.168.1.0/24, ::1 or noban,mempool@127.0.0.1)
 
 Help Options:
   -h, --help           Show this help message
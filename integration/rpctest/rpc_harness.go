@@ -130,8 +130,13 @@ func New(activeNet *chaincfg.Params, handlers *rpcclient.NotificationHandlers,
 	case wire.SimNet:
 		extraArgs = append(extraArgs, "--simnet")
 	default:
-		return nil, fmt.Errorf("rpctest.New must be called with one " +
-			"of the supported chain networks")
+		// Devnets are selected by name, from which the node derives
+		// the same parameters as NewDevNetParams.
+		if activeNet.DevNetName == "" {
+			return nil, fmt.Errorf("rpctest.New must be called " +
+				"with one of the supported chain networks")
+		}
+		extraArgs = append(extraArgs, "--devnet="+activeNet.DevNetName)
 	}
 
 	testDir, err := baseDir()
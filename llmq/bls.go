@@ -0,0 +1,59 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// basicSchemeDST is the domain separation tag the message is hashed to the
+// curve with by the basic BLS signature scheme with signatures in G2.
+const basicSchemeDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"
+
+// BasicBLS implements BLSVerifier with the basic BLS signature scheme over the
+// BLS12-381 curve, which the network uses since the v19 hard fork.  Public keys
+// are compressed G1 points and signatures are compressed G2 points.
+//
+// Signatures of the legacy scheme used before the hard fork do not verify.
+type BasicBLS struct{}
+
+// Ensure BasicBLS implements the BLSVerifier interface.
+var _ BLSVerifier = BasicBLS{}
+
+// VerifyInsecure returns whether or not sig is a valid signature of the passed
+// hash by the passed public key, where the hash is signed directly without
+// prepending the public key.  Public keys and signatures which are not valid
+// points of the respective subgroups are rejected as is the public key of the
+// point at infinity.
+//
+// This is part of the BLSVerifier interface.
+func (BasicBLS) VerifyInsecure(pubKey *[wire.BLSPublicKeySize]byte,
+	hash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) bool {
+
+	var pk bls12381.G1Affine
+	if _, err := pk.SetBytes(pubKey[:]); err != nil || pk.IsInfinity() {
+		return false
+	}
+	var s bls12381.G2Affine
+	if _, err := s.SetBytes(sig[:]); err != nil {
+		return false
+	}
+	h, err := bls12381.HashToG2(hash[:], []byte(basicSchemeDST))
+	if err != nil {
+		return false
+	}
+
+	// The signature is valid when e(pk, H(hash)) == e(g1, sig), which is
+	// checked as e(pk, H(hash)) * e(-g1, sig) == 1.
+	_, _, g1, _ := bls12381.Generators()
+	var negG1 bls12381.G1Affine
+	negG1.Neg(&g1)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{pk, negG1},
+		[]bls12381.G2Affine{h, s})
+	return err == nil && ok
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// basicSign returns the public key of the passed secret key along with its
+// signature of the passed hash in the basic BLS scheme.
+func basicSign(t *testing.T, sk int64, hash *chainhash.Hash) ([wire.BLSPublicKeySize]byte, [wire.BLSSignatureSize]byte) {
+	_, _, g1, _ := bls12381.Generators()
+	var pk bls12381.G1Affine
+	pk.ScalarMultiplication(&g1, big.NewInt(sk))
+
+	h, err := bls12381.HashToG2(hash[:], []byte(basicSchemeDST))
+	if err != nil {
+		t.Fatalf("HashToG2: unexpected error: %v", err)
+	}
+	var sig bls12381.G2Affine
+	sig.ScalarMultiplication(&h, big.NewInt(sk))
+
+	return pk.Bytes(), sig.Bytes()
+}
+
+// TestBasicBLS ensures signatures of the basic BLS scheme only verify for the
+// signed hash and the public key of the signer.
+func TestBasicBLS(t *testing.T) {
+	t.Parallel()
+
+	hash := chainhash.Hash{0x01, 0x02, 0x03}
+	pubKey, sig := basicSign(t, 12345, &hash)
+	if !(BasicBLS{}).VerifyInsecure(&pubKey, &hash, &sig) {
+		t.Fatal("VerifyInsecure: valid signature does not verify")
+	}
+
+	otherHash := chainhash.Hash{0x04}
+	if (BasicBLS{}).VerifyInsecure(&pubKey, &otherHash, &sig) {
+		t.Fatal("VerifyInsecure: signature verifies for another hash")
+	}
+	otherPubKey, otherSig := basicSign(t, 54321, &hash)
+	if (BasicBLS{}).VerifyInsecure(&otherPubKey, &hash, &sig) {
+		t.Fatal("VerifyInsecure: signature verifies for another key")
+	}
+	if (BasicBLS{}).VerifyInsecure(&pubKey, &hash, &otherSig) {
+		t.Fatal("VerifyInsecure: signature of another key verifies")
+	}
+
+	// Malformed keys and signatures as well as the public key of the point
+	// at infinity along with the signature of the point at infinity must
+	// be rejected.
+	var infPubKey [wire.BLSPublicKeySize]byte
+	var infSig [wire.BLSSignatureSize]byte
+	infPubKey[0], infSig[0] = 0xc0, 0xc0
+	if (BasicBLS{}).VerifyInsecure(&infPubKey, &hash, &infSig) {
+		t.Fatal("VerifyInsecure: point at infinity verifies")
+	}
+	badPubKey, badSig := pubKey, sig
+	badPubKey[0] &^= 0x80
+	badSig[0] &^= 0x80
+	if (BasicBLS{}).VerifyInsecure(&badPubKey, &hash, &sig) {
+		t.Fatal("VerifyInsecure: uncompressed public key verifies")
+	}
+	if (BasicBLS{}).VerifyInsecure(&pubKey, &hash, &badSig) {
+		t.Fatal("VerifyInsecure: uncompressed signature verifies")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package llmq implements verification of the signatures long living masternode
+quorums (LLMQs) produce for ChainLocks and InstantSend locks.
+
+Masternodes participate in the quorums that create these signatures, however,
+any node can verify them given the public keys of the active quorums, which
+are part of the simplified masternode list obtained via mnlistdiff messages.
+This allows nodes which are not masternodes to enforce the locks.
+
+# Verification
+
+Verifying a recovered quorum signature involves deriving the request id from
+the signed object, deterministically selecting the quorum responsible for the
+request from the set of quorums which were active SignHeightOffset blocks below
+the height the request was signed at, and checking the BLS signature of that
+quorum over the sign hash.  ChainLocks are signed at the height of the locked
+block while InstantSend locks are signed at the height of the chain tip, or at
+the end of the rotation cycle identified by the cycle hash of deterministic
+locks once that cycle is over.
+
+Callers provide the active quorums as of each height via the QuorumSource
+interface, for which MNListQuorums helps to look up the quorums of a
+wire.SimplifiedMNList, and a BLS implementation via the BLSVerifier interface.
+BasicBLS implements the latter with the basic BLS scheme the network uses since
+the v19 hard fork.
+
+# Lock Tracking
+
+LockTracker builds on Verifier to keep track of the InstantSend locked
+transactions and the best ChainLock that have been successfully verified.  It
+satisfies the blockchain.LockStatusProvider interface so the chain can take
+the locks into account when reporting transaction finality.  The InstantSend
+locks of mined transactions are dropped once their blocks are chainlocked.
+
+# Member Liveness
+
+Masternodes which fail to participate in the DKG sessions of their quorums
+are penalized by the Proof of Service (PoSe) scoring of the network and
+eventually banned.  LivenessProber periodically probes the fellow members of
+the quorums of a masternode and records the results, which identify the
+members that are likely to be penalized.  Its callbacks allow operators to be
+alerted when members go down and when the PoSe penalty of their own masternode
+increases.
+*/
+package llmq
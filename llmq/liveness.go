@@ -0,0 +1,340 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// defaultProbeTimeout is the default time to wait for a connection to
+	// a quorum member.
+	defaultProbeTimeout = 5 * time.Second
+
+	// defaultProbeInterval is the default time between probes of the
+	// quorum members.
+	defaultProbeInterval = time.Minute
+
+	// defaultDownThreshold is the default number of consecutive failed
+	// probes after which a quorum member is considered down.
+	defaultDownThreshold = 3
+)
+
+// MemberStatus describes the liveness of a quorum member as observed by the
+// probes, which is the information used to decide which members to penalize
+// when they fail to participate in a DKG session.
+type MemberStatus struct {
+	// ProRegTxHash identifies the masternode.
+	ProRegTxHash chainhash.Hash
+
+	// Addr is the address the masternode is probed at.
+	Addr string
+
+	// Probes and Failures are the total number of probes and of failed
+	// probes.
+	Probes   uint32
+	Failures uint32
+
+	// ConsecutiveFailures is the number of probes that failed since the
+	// last successful one.
+	ConsecutiveFailures uint32
+
+	// LastSuccess and LastFailure are the times of the last successful and
+	// failed probes, or the zero time when there was none.
+	LastSuccess time.Time
+	LastFailure time.Time
+
+	// Latency is the time it took to connect on the last successful probe.
+	Latency time.Duration
+
+	// LastErr is the error of the last failed probe.
+	LastErr error
+}
+
+// Reachable returns whether or not the member has been probed and the last
+// probe succeeded.
+func (s *MemberStatus) Reachable() bool {
+	return s.Probes > 0 && s.ConsecutiveFailures == 0
+}
+
+// LivenessConfig is the configuration of a LivenessProber.
+type LivenessConfig struct {
+	// ProRegTxHash identifies the local masternode, which is not probed.
+	ProRegTxHash chainhash.Hash
+
+	// Dial connects to the passed address.  It defaults to
+	// net.DialTimeout.
+	Dial func(network, addr string, timeout time.Duration) (net.Conn, error)
+
+	// Timeout is the time to wait for each connection.  It defaults to 5
+	// seconds.
+	Timeout time.Duration
+
+	// Interval is the time between probes once started.  It defaults to
+	// one minute.
+	Interval time.Duration
+
+	// DownThreshold is the number of consecutive failed probes after which
+	// a member is considered down.  It defaults to 3.
+	DownThreshold uint32
+
+	// OnMemberDown, when set, is invoked once a member has failed
+	// DownThreshold consecutive probes.  It is invoked again only after
+	// the member has been reachable in between.
+	OnMemberDown func(status MemberStatus)
+
+	// OnPoSePenalty, when set, is invoked when the PoSe penalty of the
+	// local masternode passed to UpdatePoSePenalty increases, so operators
+	// can be alerted before the masternode is banned.
+	OnPoSePenalty func(oldPenalty, newPenalty int)
+}
+
+// LivenessProber periodically probes the connectivity of the fellow members
+// of the quorums the local masternode participates in and keeps track of the
+// results.
+//
+// A probe only checks that a TCP connection to the service address of the
+// member can be established.
+//
+// It is safe for concurrent access.
+type LivenessProber struct {
+	cfg LivenessConfig
+
+	start int32
+	stop  int32
+	quit  chan struct{}
+	wg    sync.WaitGroup
+
+	mtx         sync.Mutex
+	members     map[chainhash.Hash]*MemberStatus
+	posePenalty int
+}
+
+// NewLivenessProber returns a new liveness prober with the passed
+// configuration.  Use SetMembers to set the members to probe and Start to
+// begin probing them periodically.
+func NewLivenessProber(cfg *LivenessConfig) *LivenessProber {
+	p := LivenessProber{
+		cfg:     *cfg, // Copy so caller can't mutate
+		quit:    make(chan struct{}),
+		members: make(map[chainhash.Hash]*MemberStatus),
+	}
+	if p.cfg.Dial == nil {
+		p.cfg.Dial = net.DialTimeout
+	}
+	if p.cfg.Timeout <= 0 {
+		p.cfg.Timeout = defaultProbeTimeout
+	}
+	if p.cfg.Interval <= 0 {
+		p.cfg.Interval = defaultProbeInterval
+	}
+	if p.cfg.DownThreshold == 0 {
+		p.cfg.DownThreshold = defaultDownThreshold
+	}
+	return &p
+}
+
+// SetMembers replaces the members to probe with the passed masternodes, such
+// as the members of the quorums the local masternode participates in.  The
+// local masternode and masternodes which are not valid, which can't be quorum
+// members, are ignored.  The status of members which remain at the same
+// address is kept.
+func (p *LivenessProber) SetMembers(entries []*wire.SimplifiedMNListEntry) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	members := make(map[chainhash.Hash]*MemberStatus, len(entries))
+	for _, e := range entries {
+		if !e.IsValid || e.ProRegTxHash == p.cfg.ProRegTxHash {
+			continue
+		}
+
+		addr := net.JoinHostPort(e.IP.String(), strconv.Itoa(int(e.Port)))
+		status, ok := p.members[e.ProRegTxHash]
+		if !ok || status.Addr != addr {
+			status = &MemberStatus{
+				ProRegTxHash: e.ProRegTxHash,
+				Addr:         addr,
+			}
+		}
+		members[e.ProRegTxHash] = status
+	}
+	p.members = members
+}
+
+// probeResult is the outcome of probing a single member.
+type probeResult struct {
+	proRegTxHash chainhash.Hash
+	addr         string
+	time         time.Time
+	latency      time.Duration
+	err          error
+}
+
+// ProbeAll probes all members concurrently and returns once all probes have
+// completed.
+func (p *LivenessProber) ProbeAll() {
+	p.mtx.Lock()
+	results := make([]probeResult, 0, len(p.members))
+	for _, status := range p.members {
+		results = append(results, probeResult{
+			proRegTxHash: status.ProRegTxHash,
+			addr:         status.Addr,
+		})
+	}
+	p.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(len(results))
+	for i := range results {
+		go func(r *probeResult) {
+			defer wg.Done()
+			r.time = time.Now()
+			conn, err := p.cfg.Dial("tcp", r.addr, p.cfg.Timeout)
+			r.latency = time.Since(r.time)
+			if err != nil {
+				r.err = err
+				return
+			}
+			conn.Close()
+		}(&results[i])
+	}
+	wg.Wait()
+
+	// Record the results of members which were not replaced while they
+	// were being probed and collect the members which went down so the
+	// callback can be invoked without holding the lock.
+	var down []MemberStatus
+	p.mtx.Lock()
+	for i := range results {
+		r := &results[i]
+		status, ok := p.members[r.proRegTxHash]
+		if !ok || status.Addr != r.addr {
+			continue
+		}
+
+		status.Probes++
+		if r.err == nil {
+			status.ConsecutiveFailures = 0
+			status.LastSuccess = r.time
+			status.Latency = r.latency
+			continue
+		}
+		status.Failures++
+		status.ConsecutiveFailures++
+		status.LastFailure = r.time
+		status.LastErr = r.err
+		if status.ConsecutiveFailures == p.cfg.DownThreshold {
+			down = append(down, *status)
+		}
+	}
+	p.mtx.Unlock()
+
+	if p.cfg.OnMemberDown != nil {
+		for _, status := range down {
+			p.cfg.OnMemberDown(status)
+		}
+	}
+}
+
+// Members returns the status of all members ordered by their ProRegTxHash.
+func (p *LivenessProber) Members() []MemberStatus {
+	p.mtx.Lock()
+	members := make([]MemberStatus, 0, len(p.members))
+	for _, status := range p.members {
+		members = append(members, *status)
+	}
+	p.mtx.Unlock()
+
+	sort.Slice(members, func(i, j int) bool {
+		return bytes.Compare(members[i].ProRegTxHash[:],
+			members[j].ProRegTxHash[:]) < 0
+	})
+	return members
+}
+
+// Member returns the status of the member with the passed ProRegTxHash.
+func (p *LivenessProber) Member(proRegTxHash *chainhash.Hash) (MemberStatus, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	status, ok := p.members[*proRegTxHash]
+	if !ok {
+		return MemberStatus{}, false
+	}
+	return *status, true
+}
+
+// UpdatePoSePenalty records the current PoSe penalty of the local masternode,
+// such as reported by the protx info RPC, and invokes the OnPoSePenalty
+// callback when it increased.
+func (p *LivenessProber) UpdatePoSePenalty(penalty int) {
+	p.mtx.Lock()
+	oldPenalty := p.posePenalty
+	p.posePenalty = penalty
+	p.mtx.Unlock()
+
+	if penalty > oldPenalty && p.cfg.OnPoSePenalty != nil {
+		p.cfg.OnPoSePenalty(oldPenalty, penalty)
+	}
+}
+
+// PoSePenalty returns the last PoSe penalty of the local masternode passed to
+// UpdatePoSePenalty.
+func (p *LivenessProber) PoSePenalty() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.posePenalty
+}
+
+// probeHandler probes the members every interval until the prober is stopped.
+//
+// It must be run as a goroutine.
+func (p *LivenessProber) probeHandler() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.ProbeAll()
+
+		select {
+		case <-ticker.C:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Start begins probing the members periodically.
+func (p *LivenessProber) Start() {
+	// Already started?
+	if atomic.AddInt32(&p.start, 1) != 1 {
+		return
+	}
+
+	p.wg.Add(1)
+	go p.probeHandler()
+}
+
+// Stop stops probing the members and waits for the probes in progress to
+// complete.
+func (p *LivenessProber) Stop() {
+	if atomic.AddInt32(&p.stop, 1) != 1 {
+		return
+	}
+
+	close(p.quit)
+	p.wg.Wait()
+}
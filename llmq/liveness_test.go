@@ -0,0 +1,134 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestLivenessProber ensures quorum members are probed and their status and
+// the PoSe penalty of the local masternode are reported as expected.
+func TestLivenessProber(t *testing.T) {
+	var mtx sync.Mutex
+	up := map[string]bool{"10.0.0.1:9999": true, "10.0.0.2:9999": true}
+	dial := func(network, addr string, timeout time.Duration) (net.Conn, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if !up[addr] {
+			return nil, errors.New("connection refused")
+		}
+		c1, c2 := net.Pipe()
+		c2.Close()
+		return c1, nil
+	}
+
+	var down []MemberStatus
+	var penalties [][2]int
+	p := NewLivenessProber(&LivenessConfig{
+		ProRegTxHash:  chainhash.Hash{0},
+		Dial:          dial,
+		DownThreshold: 2,
+		OnMemberDown: func(status MemberStatus) {
+			down = append(down, status)
+		},
+		OnPoSePenalty: func(oldPenalty, newPenalty int) {
+			penalties = append(penalties, [2]int{oldPenalty, newPenalty})
+		},
+	})
+
+	entry := func(n byte, valid bool) *wire.SimplifiedMNListEntry {
+		return &wire.SimplifiedMNListEntry{
+			ProRegTxHash: chainhash.Hash{n},
+			IP:           net.IPv4(10, 0, 0, n),
+			Port:         9999,
+			IsValid:      valid,
+		}
+	}
+
+	// The local masternode and invalid masternodes are not probed.
+	p.SetMembers([]*wire.SimplifiedMNListEntry{entry(0, true),
+		entry(1, true), entry(2, true), entry(3, false)})
+	members := p.Members()
+	if len(members) != 2 || members[0].ProRegTxHash != (chainhash.Hash{1}) ||
+		members[1].ProRegTxHash != (chainhash.Hash{2}) {
+
+		t.Fatalf("Members: unexpected members %v", members)
+	}
+	if members[0].Reachable() {
+		t.Fatal("Reachable: member reachable before being probed")
+	}
+
+	p.ProbeAll()
+	for _, status := range p.Members() {
+		if !status.Reachable() || status.Probes != 1 ||
+			status.LastSuccess.IsZero() {
+
+			t.Fatalf("ProbeAll: unexpected status %+v", status)
+		}
+	}
+
+	// Members are reported down once they fail the threshold number of
+	// consecutive probes.
+	mtx.Lock()
+	up["10.0.0.2:9999"] = false
+	mtx.Unlock()
+	p.ProbeAll()
+	if len(down) != 0 {
+		t.Fatalf("OnMemberDown: unexpected call for %v", down)
+	}
+	p.ProbeAll()
+	p.ProbeAll()
+	if len(down) != 1 || down[0].ProRegTxHash != (chainhash.Hash{2}) {
+		t.Fatalf("OnMemberDown: got %v, want member 2 once", down)
+	}
+	status, ok := p.Member(&chainhash.Hash{2})
+	if !ok || status.Reachable() || status.Probes != 4 ||
+		status.Failures != 3 || status.ConsecutiveFailures != 3 ||
+		status.LastErr == nil {
+
+		t.Fatalf("Member: unexpected status %+v", status)
+	}
+
+	// The status of members at the same address is kept while members
+	// at a new address start over.
+	moved := entry(1, true)
+	moved.Port = 19999
+	p.SetMembers([]*wire.SimplifiedMNListEntry{moved, entry(2, true)})
+	if status, _ := p.Member(&chainhash.Hash{1}); status.Probes != 0 {
+		t.Fatalf("SetMembers: status kept for moved member: %+v", status)
+	}
+	if status, _ := p.Member(&chainhash.Hash{2}); status.Probes != 4 {
+		t.Fatalf("SetMembers: status lost for member: %+v", status)
+	}
+
+	// Only increases of the PoSe penalty are reported.
+	p.UpdatePoSePenalty(0)
+	p.UpdatePoSePenalty(66)
+	p.UpdatePoSePenalty(33)
+	p.UpdatePoSePenalty(99)
+	want := [][2]int{{0, 66}, {33, 99}}
+	if len(penalties) != len(want) || penalties[0] != want[0] ||
+		penalties[1] != want[1] {
+
+		t.Fatalf("OnPoSePenalty: got %v, want %v", penalties, want)
+	}
+	if p.PoSePenalty() != 99 {
+		t.Fatalf("PoSePenalty: got %d, want 99", p.PoSePenalty())
+	}
+
+	// Members are probed once started.
+	p.Start()
+	p.Stop()
+	if status, _ := p.Member(&chainhash.Hash{1}); status.Probes != 1 {
+		t.Fatalf("Start: member not probed: %+v", status)
+	}
+}
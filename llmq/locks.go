@@ -0,0 +1,183 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"sync"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// minedLocks houses the hashes of the InstantSend locked transactions of a
+// main chain block which has not been chainlocked yet.
+type minedLocks struct {
+	height   int32
+	txHashes []chainhash.Hash
+}
+
+// LockTracker keeps track of the InstantSend locks and the best ChainLock
+// which have been verified.  It implements the blockchain.LockStatusProvider
+// interface.
+//
+// The InstantSend locks of transactions are removed once a block which mines
+// them is chainlocked, since the transactions can't be reversed anymore.  The
+// tracker learns about mined transactions via BlockConnected and
+// BlockDisconnected.
+//
+// It is safe for concurrent access.
+type LockTracker struct {
+	verifier *Verifier
+
+	mtx        sync.RWMutex
+	isLocked   map[chainhash.Hash]struct{}
+	minedLocks map[chainhash.Hash]minedLocks
+	chainLock  *wire.MsgCLSig
+}
+
+// NewLockTracker returns a new lock tracker which verifies locks with the
+// passed verifier.
+func NewLockTracker(verifier *Verifier) *LockTracker {
+	return &LockTracker{
+		verifier:   verifier,
+		isLocked:   make(map[chainhash.Hash]struct{}),
+		minedLocks: make(map[chainhash.Hash]minedLocks),
+	}
+}
+
+// ProcessChainLock verifies the passed ChainLock and makes it the best
+// ChainLock when it is for a higher block than the current one, which removes
+// the InstantSend locks of the transactions mined up to that block.  An error
+// is returned when the signature is invalid.
+func (t *LockTracker) ProcessChainLock(height int32, blockHash *chainhash.Hash,
+	sig *[wire.BLSSignatureSize]byte) error {
+
+	// Avoid verifying ChainLocks which would not replace the current one.
+	t.mtx.RLock()
+	stale := t.chainLock != nil && height <= t.chainLock.Height
+	t.mtx.RUnlock()
+	if stale {
+		return nil
+	}
+
+	if err := t.verifier.VerifyChainLock(height, blockHash, sig); err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	if t.chainLock == nil || height > t.chainLock.Height {
+		t.chainLock = wire.NewMsgCLSig(height, blockHash, sig)
+		t.pruneMinedLocks()
+	}
+	t.mtx.Unlock()
+	return nil
+}
+
+// pruneMinedLocks removes the InstantSend locks of the transactions mined in
+// blocks up to the best ChainLock.
+//
+// This function MUST be called with the tracker lock held (for writes).
+func (t *LockTracker) pruneMinedLocks() {
+	if t.chainLock == nil {
+		return
+	}
+	for blockHash, mined := range t.minedLocks {
+		if mined.height > t.chainLock.Height {
+			continue
+		}
+		for i := range mined.txHashes {
+			delete(t.isLocked, mined.txHashes[i])
+		}
+		delete(t.minedLocks, blockHash)
+	}
+}
+
+// BlockConnected records the InstantSend locked transactions of the passed
+// block, which has been connected to the main chain, as mined.  Their locks are
+// removed once the block is chainlocked.
+func (t *LockTracker) BlockConnected(block *btcutil.Block) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var txHashes []chainhash.Hash
+	for _, tx := range block.Transactions() {
+		if _, ok := t.isLocked[*tx.Hash()]; ok {
+			txHashes = append(txHashes, *tx.Hash())
+		}
+	}
+	if len(txHashes) == 0 {
+		return
+	}
+	t.minedLocks[*block.Hash()] = minedLocks{
+		height:   block.Height(),
+		txHashes: txHashes,
+	}
+	t.pruneMinedLocks()
+}
+
+// BlockDisconnected forgets the InstantSend locked transactions of the passed
+// block, which has been disconnected from the main chain, as mined.  Their
+// locks remain until the transactions are mined again.
+func (t *LockTracker) BlockDisconnected(block *btcutil.Block) {
+	t.mtx.Lock()
+	delete(t.minedLocks, *block.Hash())
+	t.mtx.Unlock()
+}
+
+// ProcessCLSig verifies the ChainLock relayed by the passed clsig message and
+// makes it the best ChainLock when it is for a higher block than the current
+// one.  See ProcessChainLock for details.
+func (t *LockTracker) ProcessCLSig(msg *wire.MsgCLSig) error {
+	return t.ProcessChainLock(msg.Height, &msg.BlockHash, &msg.Sig)
+}
+
+// ProcessISLock verifies the InstantSend lock relayed by the passed islock or
+// isdlock message and records the transaction as locked.  An error is returned
+// when the signature is invalid.
+func (t *LockTracker) ProcessISLock(msg *wire.MsgISLock) error {
+	if err := t.verifier.VerifyInstantSendLock(msg); err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	t.isLocked[msg.TxHash] = struct{}{}
+	t.mtx.Unlock()
+	return nil
+}
+
+// IsInstantSendLocked returns whether or not a valid InstantSend lock has been
+// processed for the transaction with the passed hash.
+//
+// This is part of the blockchain.LockStatusProvider interface.
+func (t *LockTracker) IsInstantSendLocked(txHash *chainhash.Hash) bool {
+	t.mtx.RLock()
+	_, ok := t.isLocked[*txHash]
+	t.mtx.RUnlock()
+	return ok
+}
+
+// BestChainLock returns the hash of the block of the best valid ChainLock that
+// has been processed, or nil when there is none.
+//
+// This is part of the blockchain.LockStatusProvider interface.
+func (t *LockTracker) BestChainLock() *chainhash.Hash {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	if t.chainLock == nil {
+		return nil
+	}
+	return &t.chainLock.BlockHash
+}
+
+// BestCLSig returns the clsig message of the best valid ChainLock that has
+// been processed, or nil when there is none.  It allows the ChainLock to be
+// relayed to peers which announce a lower one.  The returned message must not
+// be modified.
+func (t *LockTracker) BestCLSig() *wire.MsgCLSig {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.chainLock
+}
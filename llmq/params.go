@@ -0,0 +1,61 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// SignHeightOffset is the number of blocks the quorums responsible for signing
+// a request lag behind the height the request is signed at.  It ensures all
+// members agree on the active quorums despite small differences in their view
+// of the chain tip.
+const SignHeightOffset = 8
+
+// Params defines the parameters of a type of long living masternode quorum
+// which determine the quorum responsible for signing a request.
+type Params struct {
+	// Type identifies the quorum type.
+	Type wire.LLMQType
+
+	// DKGInterval is the number of blocks between the DKG sessions which
+	// create new quorums of the type.  For rotating quorums, it is the
+	// length of a rotation cycle.
+	DKGInterval int32
+
+	// SigningActiveQuorumCount is the number of the most recent quorums of
+	// the type which actively sign requests.
+	SigningActiveQuorumCount int
+
+	// UseRotation indicates the quorums of the type are rotating quorums,
+	// which are selected by their quorum index.
+	UseRotation bool
+}
+
+// knownParams houses the parameters of the quorum types in use on the main and
+// test networks.
+var knownParams = map[wire.LLMQType]*Params{
+	1:   {Type: 1, DKGInterval: 24, SigningActiveQuorumCount: 24},                     // LLMQ_50_60
+	2:   {Type: 2, DKGInterval: 288, SigningActiveQuorumCount: 4},                     // LLMQ_400_60
+	3:   {Type: 3, DKGInterval: 576, SigningActiveQuorumCount: 4},                     // LLMQ_400_85
+	4:   {Type: 4, DKGInterval: 24, SigningActiveQuorumCount: 24},                     // LLMQ_100_67
+	5:   {Type: 5, DKGInterval: 288, SigningActiveQuorumCount: 32, UseRotation: true}, // LLMQ_60_75
+	6:   {Type: 6, DKGInterval: 24, SigningActiveQuorumCount: 24},                     // LLMQ_25_67
+	100: {Type: 100, DKGInterval: 24, SigningActiveQuorumCount: 2},                    // LLMQ_TEST
+	101: {Type: 101, DKGInterval: 24, SigningActiveQuorumCount: 4},                    // LLMQ_DEVNET
+	102: {Type: 102, DKGInterval: 24, SigningActiveQuorumCount: 2},                    // LLMQ_TEST_V17
+	103: {Type: 103, DKGInterval: 24, SigningActiveQuorumCount: 2, UseRotation: true}, // LLMQ_TEST_DIP0024
+	104: {Type: 104, DKGInterval: 24, SigningActiveQuorumCount: 2},                    // LLMQ_TEST_INSTANTSEND
+	105: {Type: 105, DKGInterval: 48, SigningActiveQuorumCount: 2, UseRotation: true}, // LLMQ_DEVNET_DIP0024
+	106: {Type: 106, DKGInterval: 24, SigningActiveQuorumCount: 2},                    // LLMQ_TEST_PLATFORM
+	107: {Type: 107, DKGInterval: 24, SigningActiveQuorumCount: 4},                    // LLMQ_DEVNET_PLATFORM
+}
+
+// LLMQParams returns the parameters of the passed quorum type along with
+// whether or not the type is known.
+func LLMQParams(llmqType wire.LLMQType) (*Params, bool) {
+	params, ok := knownParams[llmqType]
+	return params, ok
+}
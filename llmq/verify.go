@@ -0,0 +1,299 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// chainLockRequestIDPrefix is the prefix of the data hashed to derive
+	// the request id of a ChainLock.
+	chainLockRequestIDPrefix = "clsig"
+
+	// instantSendRequestIDPrefix is the prefix of the data hashed to
+	// derive the request id of an InstantSend lock.
+	instantSendRequestIDPrefix = "islock"
+)
+
+var (
+	// ErrNoQuorum is returned when there is no active quorum which is
+	// able to sign a request.
+	ErrNoQuorum = errors.New("no quorum available to sign the request")
+
+	// ErrInvalidSignature is returned when a recovered quorum signature
+	// does not verify.
+	ErrInvalidSignature = errors.New("invalid quorum signature")
+
+	// ErrUnknownLLMQType is returned when a signature is to be verified for
+	// a quorum type whose parameters are unknown.
+	ErrUnknownLLMQType = errors.New("unknown quorum type")
+)
+
+// QuorumsUnavailableError is returned when the quorums which are responsible
+// for signing a request are not known since the masternode list as of the main
+// chain block at Height has not been obtained yet.
+type QuorumsUnavailableError struct {
+	Height int32
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e QuorumsUnavailableError) Error() string {
+	return fmt.Sprintf("quorums as of height %d are unavailable", e.Height)
+}
+
+// BLSVerifier verifies BLS signatures.
+type BLSVerifier interface {
+	// VerifyInsecure returns whether or not sig is a valid signature of
+	// the passed hash by the passed public key, where the hash is signed
+	// directly without prepending the public key.
+	VerifyInsecure(pubKey *[wire.BLSPublicKeySize]byte,
+		hash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) bool
+}
+
+// QuorumSource provides the quorums which are actively signing along with the
+// heights of the main chain blocks they are selected by.
+type QuorumSource interface {
+	// ActiveQuorums returns the final commitments of the quorums of the
+	// passed type which were actively signing requests as of the main
+	// chain block at the passed height, along with whether or not they
+	// are known.
+	ActiveQuorums(llmqType wire.LLMQType, height int32) ([]*wire.QuorumCommitment, bool)
+
+	// BestHeight returns the height of the main chain tip.
+	BestHeight() int32
+
+	// BlockHeightByHash returns the height of the main chain block with
+	// the passed hash.  An error is returned when the block is not in the
+	// main chain.
+	BlockHeightByHash(hash *chainhash.Hash) (int32, error)
+}
+
+// MNListQuorums provides the active quorums of a simplified masternode list.
+// Implementations of QuorumSource use it to look up the quorums in the list as
+// of the requested block.
+type MNListQuorums struct {
+	List *wire.SimplifiedMNList
+}
+
+// ActiveQuorums returns the final commitments of the quorums of the passed
+// type in the masternode list.
+func (m MNListQuorums) ActiveQuorums(llmqType wire.LLMQType) []*wire.QuorumCommitment {
+	var quorums []*wire.QuorumCommitment
+	for _, qc := range m.List.Quorums() {
+		if qc.LLMQType == llmqType {
+			quorums = append(quorums, qc)
+		}
+	}
+	return quorums
+}
+
+// ChainLockRequestID returns the request id of the ChainLock for the block at
+// the passed height.
+func ChainLockRequestID(height int32) chainhash.Hash {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, chainLockRequestIDPrefix)
+	var heightBytes [4]byte
+	binary.LittleEndian.PutUint32(heightBytes[:], uint32(height))
+	buf.Write(heightBytes[:])
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// InstantSendRequestID returns the request id of the InstantSend lock of a
+// transaction spending the passed outpoints.
+func InstantSendRequestID(inputs []wire.OutPoint) chainhash.Hash {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, instantSendRequestIDPrefix)
+	_ = wire.WriteVarInt(&buf, 0, uint64(len(inputs)))
+	var index [4]byte
+	for i := range inputs {
+		buf.Write(inputs[i].Hash[:])
+		binary.LittleEndian.PutUint32(index[:], inputs[i].Index)
+		buf.Write(index[:])
+	}
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// SignHash returns the hash a quorum signs for the request with the passed id
+// and message hash.
+func SignHash(llmqType wire.LLMQType, quorumHash, requestID,
+	msgHash *chainhash.Hash) chainhash.Hash {
+
+	var buf [1 + chainhash.HashSize*3]byte
+	buf[0] = byte(llmqType)
+	copy(buf[1:], quorumHash[:])
+	copy(buf[1+chainhash.HashSize:], requestID[:])
+	copy(buf[1+chainhash.HashSize*2:], msgHash[:])
+	return chainhash.DoubleHashH(buf[:])
+}
+
+// hasQuorumIndexes returns whether or not the passed quorums carry the quorum
+// index rotating quorums are identified by, which is the case once rotation
+// has been activated.
+func hasQuorumIndexes(quorums []*wire.QuorumCommitment) bool {
+	for _, qc := range quorums {
+		if qc.Version != wire.QuorumCommitmentIndexedVersion &&
+			qc.Version != wire.QuorumCommitmentBasicBLSIndexedVersion {
+
+			return false
+		}
+	}
+	return true
+}
+
+// SelectQuorum returns the quorum responsible for signing the request with the
+// passed id from the passed quorums of the type described by params, which
+// must be the quorums that were actively signing as of SignHeightOffset blocks
+// before the height the request is signed at.
+//
+// For rotating quorums, the quorum whose index matches the log2 of the number
+// of signing active quorums bits of the request id is selected.  Otherwise,
+// each quorum is scored by the hash of the quorum type, its quorum hash and
+// the request id, and the quorum with the lowest score is selected.
+func SelectQuorum(quorums []*wire.QuorumCommitment, params *Params,
+	requestID *chainhash.Hash) (*wire.QuorumCommitment, error) {
+
+	if len(quorums) == 0 {
+		return nil, ErrNoQuorum
+	}
+
+	if params.UseRotation && hasQuorumIndexes(quorums) {
+		// Use the n bits of the last 64 bits of the request id just
+		// below the most significant one to select the quorum index.
+		n := uint(bits.Len(uint(params.SigningActiveQuorumCount))) - 1
+		b := binary.LittleEndian.Uint64(requestID[24:])
+		signer := ((uint64(1) << n) - 1) & (b >> (64 - n - 1))
+		for _, qc := range quorums {
+			if uint64(qc.QuorumIndex) == signer {
+				return qc, nil
+			}
+		}
+		return nil, ErrNoQuorum
+	}
+
+	type scoredQuorum struct {
+		score chainhash.Hash
+		qc    *wire.QuorumCommitment
+	}
+	scored := make([]scoredQuorum, 0, len(quorums))
+	var buf [1 + chainhash.HashSize*2]byte
+	buf[0] = byte(params.Type)
+	copy(buf[1+chainhash.HashSize:], requestID[:])
+	for _, qc := range quorums {
+		copy(buf[1:], qc.QuorumHash[:])
+		scored = append(scored, scoredQuorum{
+			score: chainhash.DoubleHashH(buf[:]),
+			qc:    qc,
+		})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		return bytes.Compare(scored[i].score[:], scored[j].score[:]) < 0
+	})
+	return scored[0].qc, nil
+}
+
+// Verifier verifies recovered quorum signatures of ChainLocks and InstantSend
+// locks against the active quorums.
+type Verifier struct {
+	// Quorums provides the active quorums.
+	Quorums QuorumSource
+
+	// BLS verifies the quorum signatures.
+	BLS BLSVerifier
+
+	// ChainLockType and InstantSendType are the quorum types which sign
+	// ChainLocks and InstantSend locks, respectively, on the network.
+	ChainLockType   wire.LLMQType
+	InstantSendType wire.LLMQType
+}
+
+// verify selects the quorum of the passed type responsible for the passed
+// request signed at the passed height and verifies its signature of the message
+// hash.
+func (v *Verifier) verify(llmqType wire.LLMQType, signHeight int32, requestID,
+	msgHash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) error {
+
+	params, ok := LLMQParams(llmqType)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownLLMQType, llmqType)
+	}
+
+	quorumHeight := signHeight - SignHeightOffset
+	if quorumHeight < 0 || quorumHeight > v.Quorums.BestHeight() {
+		return ErrNoQuorum
+	}
+	quorums, ok := v.Quorums.ActiveQuorums(llmqType, quorumHeight)
+	if !ok {
+		return QuorumsUnavailableError{Height: quorumHeight}
+	}
+	qc, err := SelectQuorum(quorums, params, requestID)
+	if err != nil {
+		return err
+	}
+
+	signHash := SignHash(llmqType, &qc.QuorumHash, requestID, msgHash)
+	if !v.BLS.VerifyInsecure(&qc.QuorumPubKey, &signHash, sig) {
+		return fmt.Errorf("%w: request %v signed by quorum %v",
+			ErrInvalidSignature, requestID, qc.QuorumHash)
+	}
+	return nil
+}
+
+// VerifyChainLock verifies the passed signature is a valid ChainLock of the
+// block with the passed hash and height.  ChainLocks are signed at the height
+// of the block they lock.
+func (v *Verifier) VerifyChainLock(height int32, blockHash *chainhash.Hash,
+	sig *[wire.BLSSignatureSize]byte) error {
+
+	requestID := ChainLockRequestID(height)
+	return v.verify(v.ChainLockType, height, &requestID, blockHash, sig)
+}
+
+// InstantSendSignHeight returns the height the passed InstantSend lock is
+// signed at.  Locks are signed at the height of the main chain tip, except for
+// deterministic locks of a rotation cycle which ended before the tip, which
+// are signed at the last height of the cycle they identify by its cycle hash.
+func (v *Verifier) InstantSendSignHeight(msg *wire.MsgISLock) (int32, error) {
+	tipHeight := v.Quorums.BestHeight()
+	if msg.Version == 0 {
+		return tipHeight, nil
+	}
+
+	params, ok := LLMQParams(v.InstantSendType)
+	if !ok {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownLLMQType,
+			v.InstantSendType)
+	}
+	cycleHeight, err := v.Quorums.BlockHeightByHash(&msg.CycleHash)
+	if err != nil {
+		return 0, fmt.Errorf("unknown cycle block %v: %w",
+			msg.CycleHash, err)
+	}
+	if cycleHeight+params.DKGInterval < tipHeight {
+		return cycleHeight + params.DKGInterval - 1, nil
+	}
+	return tipHeight, nil
+}
+
+// VerifyInstantSendLock verifies the passed InstantSend lock is validly signed
+// for the transaction and the inputs it spends.
+func (v *Verifier) VerifyInstantSendLock(msg *wire.MsgISLock) error {
+	signHeight, err := v.InstantSendSignHeight(msg)
+	if err != nil {
+		return err
+	}
+
+	requestID := InstantSendRequestID(msg.Inputs)
+	return v.verify(v.InstantSendType, signHeight, &requestID,
+		&msg.TxHash, &msg.Sig)
+}
@@ -0,0 +1,526 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package llmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// Ensure the lock tracker can provide the lock status to the chain.
+var _ blockchain.LockStatusProvider = (*LockTracker)(nil)
+
+// fakeBLS implements BLSVerifier with fake signatures consisting of the signed
+// hash followed by the public key.
+type fakeBLS struct{}
+
+func fakeSign(pubKey *[wire.BLSPublicKeySize]byte, hash *chainhash.Hash) [wire.BLSSignatureSize]byte {
+	var sig [wire.BLSSignatureSize]byte
+	copy(sig[:], hash[:])
+	copy(sig[chainhash.HashSize:], pubKey[:])
+	return sig
+}
+
+func (fakeBLS) VerifyInsecure(pubKey *[wire.BLSPublicKeySize]byte,
+	hash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) bool {
+
+	return fakeSign(pubKey, hash) == *sig
+}
+
+// fakeBlockHash returns the hash of the block at the passed height of the chain
+// of fakeQuorums.
+func fakeBlockHash(height int32) chainhash.Hash {
+	hash := chainhash.Hash{0xff}
+	binary.LittleEndian.PutUint32(hash[1:], uint32(height))
+	return hash
+}
+
+// fakeQuorums implements QuorumSource for a chain whose tip is at tipHeight and
+// whose blocks are identified by fakeBlockHash.  The quorums differ for every
+// height and are known up to knownHeight.
+type fakeQuorums struct {
+	tipHeight   int32
+	knownHeight int32
+}
+
+func (q fakeQuorums) ActiveQuorums(llmqType wire.LLMQType, height int32) ([]*wire.QuorumCommitment, bool) {
+	if height > q.knownHeight {
+		return nil, false
+	}
+	params, _ := LLMQParams(llmqType)
+	version := uint16(1)
+	if params.UseRotation {
+		version = wire.QuorumCommitmentIndexedVersion
+	}
+	return makeQuorums(llmqType, version, params.SigningActiveQuorumCount,
+		height), true
+}
+
+func (q fakeQuorums) BestHeight() int32 {
+	return q.tipHeight
+}
+
+func (q fakeQuorums) BlockHeightByHash(hash *chainhash.Hash) (int32, error) {
+	height := int32(binary.LittleEndian.Uint32(hash[1:]))
+	if height > q.tipHeight || *hash != fakeBlockHash(height) {
+		return 0, errors.New("block not found")
+	}
+	return height, nil
+}
+
+// makeQuorums returns the passed number of quorums of the passed type with
+// distinct quorum hashes and public keys, which also differ by the passed
+// height.
+func makeQuorums(llmqType wire.LLMQType, version uint16, num int, height int32) []*wire.QuorumCommitment {
+	quorums := make([]*wire.QuorumCommitment, 0, num)
+	for i := 0; i < num; i++ {
+		qc := &wire.QuorumCommitment{
+			Version:     version,
+			LLMQType:    llmqType,
+			QuorumHash:  chainhash.Hash{byte(llmqType), byte(i)},
+			QuorumIndex: int16(i),
+		}
+		binary.LittleEndian.PutUint32(qc.QuorumHash[2:], uint32(height))
+		qc.QuorumPubKey[0] = byte(llmqType)
+		qc.QuorumPubKey[1] = byte(i)
+		binary.LittleEndian.PutUint32(qc.QuorumPubKey[2:], uint32(height))
+		quorums = append(quorums, qc)
+	}
+	return quorums
+}
+
+// TestRequestIDs ensures the request ids are derived from the expected
+// serialization of the signed objects.
+func TestRequestIDs(t *testing.T) {
+	t.Parallel()
+
+	want := chainhash.DoubleHashH(append([]byte("\x05clsig"),
+		0x40, 0xe2, 0x01, 0x00))
+	if got := ChainLockRequestID(123456); got != want {
+		t.Errorf("ChainLockRequestID: got %v, want %v", got, want)
+	}
+
+	inputs := []wire.OutPoint{
+		{Hash: chainhash.Hash{0x01}, Index: 1},
+		{Hash: chainhash.Hash{0x02}, Index: 0x01020304},
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\x06islock\x02")
+	buf.Write(inputs[0].Hash[:])
+	buf.Write([]byte{0x01, 0x00, 0x00, 0x00})
+	buf.Write(inputs[1].Hash[:])
+	buf.Write([]byte{0x04, 0x03, 0x02, 0x01})
+	want = chainhash.DoubleHashH(buf.Bytes())
+	if got := InstantSendRequestID(inputs); got != want {
+		t.Errorf("InstantSendRequestID: got %v, want %v", got, want)
+	}
+
+	quorumHash, requestID, msgHash := chainhash.Hash{0x0a},
+		chainhash.Hash{0x0b}, chainhash.Hash{0x0c}
+	buf.Reset()
+	buf.WriteByte(1)
+	buf.Write(quorumHash[:])
+	buf.Write(requestID[:])
+	buf.Write(msgHash[:])
+	want = chainhash.DoubleHashH(buf.Bytes())
+	if got := SignHash(1, &quorumHash, &requestID, &msgHash); got != want {
+		t.Errorf("SignHash: got %v, want %v", got, want)
+	}
+}
+
+// TestSelectQuorum ensures the quorum responsible for a request is selected
+// as expected for both scored and rotating quorums.
+func TestSelectQuorum(t *testing.T) {
+	t.Parallel()
+
+	scoredParams := &Params{Type: 1, SigningActiveQuorumCount: 10}
+	requestID := chainhash.Hash{0x42}
+	if _, err := SelectQuorum(nil, scoredParams, &requestID); err != ErrNoQuorum {
+		t.Fatalf("SelectQuorum: unexpected error - got %v, want %v",
+			err, ErrNoQuorum)
+	}
+
+	// The quorum with the lowest score must be selected regardless of the
+	// order the quorums are provided in.
+	quorums := makeQuorums(1, 1, 10, 0)
+	var want *wire.QuorumCommitment
+	var bestScore chainhash.Hash
+	for _, qc := range quorums {
+		data := append([]byte{1}, qc.QuorumHash[:]...)
+		score := chainhash.DoubleHashH(append(data, requestID[:]...))
+		if want == nil || bytes.Compare(score[:], bestScore[:]) < 0 {
+			want, bestScore = qc, score
+		}
+	}
+	reversed := make([]*wire.QuorumCommitment, len(quorums))
+	for i, qc := range quorums {
+		reversed[len(quorums)-1-i] = qc
+	}
+	for _, list := range [][]*wire.QuorumCommitment{quorums, reversed} {
+		qc, err := SelectQuorum(list, scoredParams, &requestID)
+		if err != nil {
+			t.Fatalf("SelectQuorum: unexpected error: %v", err)
+		}
+		if qc != want {
+			t.Fatalf("SelectQuorum: selected quorum %v, want %v",
+				qc.QuorumHash, want.QuorumHash)
+		}
+	}
+
+	// Rotating quorums are selected by the log2 of the number of signing
+	// active quorums bits of the request id just below the most
+	// significant bit, even when fewer quorums are active.
+	rotatedParams := &Params{
+		Type:                     2,
+		SigningActiveQuorumCount: 4,
+		UseRotation:              true,
+	}
+	rotated := makeQuorums(2, wire.QuorumCommitmentIndexedVersion, 4, 0)
+	for wantIdx := uint64(0); wantIdx < 4; wantIdx++ {
+		var requestID chainhash.Hash
+		binary.LittleEndian.PutUint64(requestID[24:], wantIdx<<61)
+		for _, list := range [][]*wire.QuorumCommitment{rotated, rotated[wantIdx:]} {
+			qc, err := SelectQuorum(list, rotatedParams, &requestID)
+			if err != nil {
+				t.Fatalf("SelectQuorum: unexpected error: %v", err)
+			}
+			if uint64(qc.QuorumIndex) != wantIdx {
+				t.Fatalf("SelectQuorum: selected quorum index %d, "+
+					"want %d", qc.QuorumIndex, wantIdx)
+			}
+		}
+	}
+	if _, err := SelectQuorum(rotated[1:], rotatedParams, &chainhash.Hash{}); err != ErrNoQuorum {
+		t.Fatalf("SelectQuorum: unexpected error for missing quorum "+
+			"index - got %v, want %v", err, ErrNoQuorum)
+	}
+
+	// Quorums of a rotating type are scored until rotation is activated,
+	// which introduces the quorum index.
+	unindexed := makeQuorums(2, 1, 4, 0)
+	qc, err := SelectQuorum(unindexed, rotatedParams, &requestID)
+	if err != nil {
+		t.Fatalf("SelectQuorum: unexpected error: %v", err)
+	}
+	scored, _ := SelectQuorum(unindexed, &Params{Type: 2}, &requestID)
+	if qc != scored {
+		t.Fatalf("SelectQuorum: selected quorum %v, want %v",
+			qc.QuorumHash, scored.QuorumHash)
+	}
+}
+
+// TestLockTracker ensures locks are only tracked once their signatures have
+// been verified against the quorum responsible as of the height they were
+// signed at.
+func TestLockTracker(t *testing.T) {
+	t.Parallel()
+
+	// LLMQ_TEST signs ChainLocks and the rotating LLMQ_TEST_DIP0024 signs
+	// InstantSend locks.
+	const clType, isType = 100, 103
+	const tipHeight = 300
+	quorums := fakeQuorums{
+		tipHeight:   tipHeight,
+		knownHeight: tipHeight - SignHeightOffset,
+	}
+	verifier := &Verifier{
+		Quorums:         quorums,
+		BLS:             fakeBLS{},
+		ChainLockType:   clType,
+		InstantSendType: isType,
+	}
+	tracker := NewLockTracker(verifier)
+
+	// signLock signs the passed request with the quorum responsible as of
+	// the passed sign height.
+	signLock := func(llmqType wire.LLMQType, signHeight int32, requestID,
+		msgHash chainhash.Hash) [wire.BLSSignatureSize]byte {
+
+		active, _ := quorums.ActiveQuorums(llmqType,
+			signHeight-SignHeightOffset)
+		params, _ := LLMQParams(llmqType)
+		qc, err := SelectQuorum(active, params, &requestID)
+		if err != nil {
+			t.Fatalf("SelectQuorum: unexpected error: %v", err)
+		}
+		signHash := SignHash(llmqType, &qc.QuorumHash, &requestID,
+			&msgHash)
+		return fakeSign(&qc.QuorumPubKey, &signHash)
+	}
+
+	// A ChainLock signed by the wrong quorum type must be rejected.
+	blockHash := chainhash.Hash{0xbb}
+	sig := signLock(isType, 100, ChainLockRequestID(100), blockHash)
+	err := tracker.ProcessChainLock(100, &blockHash, &sig)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("ProcessChainLock: unexpected error - got %v, want %v",
+			err, ErrInvalidSignature)
+	}
+
+	// A ChainLock signed by the quorum responsible as of the tip rather
+	// than as of the height of the locked block must be rejected.
+	sig = signLock(clType, tipHeight, ChainLockRequestID(100), blockHash)
+	err = tracker.ProcessChainLock(100, &blockHash, &sig)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("ProcessChainLock: unexpected error - got %v, want %v",
+			err, ErrInvalidSignature)
+	}
+	if tracker.BestChainLock() != nil {
+		t.Fatal("BestChainLock: invalid ChainLock was accepted")
+	}
+
+	sig = signLock(clType, 100, ChainLockRequestID(100), blockHash)
+	if err := tracker.ProcessChainLock(100, &blockHash, &sig); err != nil {
+		t.Fatalf("ProcessChainLock: unexpected error: %v", err)
+	}
+	if got := tracker.BestChainLock(); got == nil || *got != blockHash {
+		t.Fatalf("BestChainLock: got %v, want %v", got, blockHash)
+	}
+
+	// ChainLocks for lower blocks must not replace the best one.
+	lowerHash := chainhash.Hash{0xaa}
+	sig = signLock(clType, 99, ChainLockRequestID(99), lowerHash)
+	if err := tracker.ProcessChainLock(99, &lowerHash, &sig); err != nil {
+		t.Fatalf("ProcessChainLock: unexpected error: %v", err)
+	}
+	if got := tracker.BestChainLock(); *got != blockHash {
+		t.Fatalf("BestChainLock: got %v, want %v", got, blockHash)
+	}
+	bestSig := signLock(clType, 100, ChainLockRequestID(100), blockHash)
+	want := wire.NewMsgCLSig(100, &blockHash, &bestSig)
+	if got := tracker.BestCLSig(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("BestCLSig: got %v, want %v", got, want)
+	}
+
+	// ChainLocks relayed by clsig messages replace the best one when they
+	// are for a higher block.
+	higherHash := chainhash.Hash{0xdd}
+	sig = signLock(clType, 101, ChainLockRequestID(101), higherHash)
+	clsig := wire.NewMsgCLSig(101, &higherHash, &sig)
+	if err := tracker.ProcessCLSig(clsig); err != nil {
+		t.Fatalf("ProcessCLSig: unexpected error: %v", err)
+	}
+	if got := tracker.BestCLSig(); !reflect.DeepEqual(got, clsig) {
+		t.Fatalf("BestCLSig: got %v, want %v", got, clsig)
+	}
+	if got := tracker.BestChainLock(); *got != higherHash {
+		t.Fatalf("BestChainLock: got %v, want %v", got, higherHash)
+	}
+
+	// ChainLocks which can't be verified until the quorums as of a later
+	// block are known must report the height of that block.
+	err = tracker.ProcessChainLock(tipHeight+1, &higherHash, &sig)
+	var unavailable QuorumsUnavailableError
+	if !errors.As(err, &unavailable) ||
+		unavailable.Height != tipHeight+1-SignHeightOffset {
+
+		t.Fatalf("ProcessChainLock: unexpected error - got %v, want "+
+			"quorums of height %d to be unavailable", err,
+			tipHeight+1-SignHeightOffset)
+	}
+	err = tracker.ProcessChainLock(tipHeight+SignHeightOffset+1,
+		&higherHash, &sig)
+	if err != ErrNoQuorum {
+		t.Fatalf("ProcessChainLock: unexpected error - got %v, want %v",
+			err, ErrNoQuorum)
+	}
+
+	// InstantSend locks must be signed for the spent inputs.
+	txHash := chainhash.Hash{0xcc}
+	inputs := []wire.OutPoint{{Hash: chainhash.Hash{0x01}, Index: 0}}
+	otherInputs := []wire.OutPoint{{Hash: chainhash.Hash{0x01}, Index: 1}}
+	islock := &wire.MsgISLock{
+		Inputs: otherInputs,
+		TxHash: txHash,
+		Sig: signLock(isType, tipHeight, InstantSendRequestID(inputs),
+			txHash),
+	}
+	err = tracker.ProcessISLock(islock)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("ProcessISLock: unexpected error - got %v, want %v",
+			err, ErrInvalidSignature)
+	}
+	if tracker.IsInstantSendLocked(&txHash) {
+		t.Fatal("IsInstantSendLocked: invalid lock was accepted")
+	}
+	islock.Inputs = inputs
+	if err := tracker.ProcessISLock(islock); err != nil {
+		t.Fatalf("ProcessISLock: unexpected error: %v", err)
+	}
+	if !tracker.IsInstantSendLocked(&txHash) {
+		t.Fatal("IsInstantSendLocked: valid lock was not tracked")
+	}
+
+	// Deterministic InstantSend locks are signed at the tip during the
+	// cycle identified by their cycle hash and at the last height of the
+	// cycle once it ended.
+	tests := []struct {
+		cycleHeight int32
+		signHeight  int32
+	}{
+		{cycleHeight: 288, signHeight: tipHeight},
+		{cycleHeight: 276, signHeight: tipHeight},
+		{cycleHeight: 264, signHeight: 287},
+	}
+	for _, test := range tests {
+		txHash := chainhash.Hash{0xcc, byte(test.cycleHeight)}
+		islock := &wire.MsgISLock{
+			Version:   1,
+			Inputs:    inputs,
+			TxHash:    txHash,
+			CycleHash: fakeBlockHash(test.cycleHeight),
+			Sig: signLock(isType, test.signHeight,
+				InstantSendRequestID(inputs), txHash),
+		}
+		signHeight, err := verifier.InstantSendSignHeight(islock)
+		if err != nil || signHeight != test.signHeight {
+			t.Fatalf("InstantSendSignHeight(cycle %d): got %d, %v, "+
+				"want %d", test.cycleHeight, signHeight, err,
+				test.signHeight)
+		}
+		if err := tracker.ProcessISLock(islock); err != nil {
+			t.Fatalf("ProcessISLock(cycle %d): unexpected error: %v",
+				test.cycleHeight, err)
+		}
+	}
+
+	// Deterministic InstantSend locks of unknown cycles are rejected.
+	islock = &wire.MsgISLock{
+		Version:   1,
+		Inputs:    inputs,
+		TxHash:    txHash,
+		CycleHash: fakeBlockHash(tipHeight + 1),
+	}
+	if err := tracker.ProcessISLock(islock); err == nil {
+		t.Fatal("ProcessISLock: lock of unknown cycle was accepted")
+	}
+
+	// Locks signed by quorums of unknown types are rejected.
+	unknown := NewLockTracker(&Verifier{
+		Quorums:         quorums,
+		BLS:             fakeBLS{},
+		ChainLockType:   250,
+		InstantSendType: 250,
+	})
+	err = unknown.ProcessChainLock(100, &blockHash, &sig)
+	if !errors.Is(err, ErrUnknownLLMQType) {
+		t.Fatalf("ProcessChainLock: unexpected error - got %v, want %v",
+			err, ErrUnknownLLMQType)
+	}
+}
+
+// TestLockTrackerPrune ensures the InstantSend locks of transactions are only
+// removed once a main chain block which mines them is chainlocked.
+func TestLockTrackerPrune(t *testing.T) {
+	t.Parallel()
+
+	const clType, isType = 100, 103
+	const tipHeight = 300
+	quorums := fakeQuorums{
+		tipHeight:   tipHeight,
+		knownHeight: tipHeight - SignHeightOffset,
+	}
+	tracker := NewLockTracker(&Verifier{
+		Quorums:         quorums,
+		BLS:             fakeBLS{},
+		ChainLockType:   clType,
+		InstantSendType: isType,
+	})
+
+	// sign returns the signature of the passed request by the quorum
+	// responsible as of the passed sign height.
+	sign := func(llmqType wire.LLMQType, signHeight int32, requestID,
+		msgHash chainhash.Hash) [wire.BLSSignatureSize]byte {
+
+		active, _ := quorums.ActiveQuorums(llmqType,
+			signHeight-SignHeightOffset)
+		params, _ := LLMQParams(llmqType)
+		qc, err := SelectQuorum(active, params, &requestID)
+		if err != nil {
+			t.Fatalf("SelectQuorum: unexpected error: %v", err)
+		}
+		signHash := SignHash(llmqType, &qc.QuorumHash, &requestID,
+			&msgHash)
+		return fakeSign(&qc.QuorumPubKey, &signHash)
+	}
+
+	// lockTx returns a distinct transaction which is InstantSend locked.
+	lockTx := func(lockTime uint32) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.LockTime = lockTime
+		txHash := tx.TxHash()
+		inputs := []wire.OutPoint{{Hash: txHash}}
+		err := tracker.ProcessISLock(&wire.MsgISLock{
+			Inputs: inputs,
+			TxHash: txHash,
+			Sig: sign(isType, tipHeight,
+				InstantSendRequestID(inputs), txHash),
+		})
+		if err != nil {
+			t.Fatalf("ProcessISLock: unexpected error: %v", err)
+		}
+		return tx
+	}
+
+	// mineBlock returns a block at the passed height mining the passed
+	// transaction.
+	mineBlock := func(height int32, tx *wire.MsgTx) *btcutil.Block {
+		msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+			Nonce: uint32(height),
+		})
+		msgBlock.AddTransaction(wire.NewMsgTx(wire.TxVersion))
+		msgBlock.AddTransaction(tx)
+		block := btcutil.NewBlock(msgBlock)
+		block.SetHeight(height)
+		return block
+	}
+
+	// chainLock processes a ChainLock of the block at the passed height.
+	chainLock := func(height int32) {
+		blockHash := chainhash.Hash{byte(height)}
+		sig := sign(clType, height, ChainLockRequestID(height), blockHash)
+		err := tracker.ProcessChainLock(height, &blockHash, &sig)
+		if err != nil {
+			t.Fatalf("ProcessChainLock: unexpected error: %v", err)
+		}
+	}
+
+	txA, txB := lockTx(1), lockTx(2)
+	hashA, hashB := txA.TxHash(), txB.TxHash()
+	tracker.BlockConnected(mineBlock(100, txA))
+	blockB := mineBlock(102, txB)
+	tracker.BlockConnected(blockB)
+
+	chainLock(101)
+	if tracker.IsInstantSendLocked(&hashA) {
+		t.Fatal("IsInstantSendLocked: lock of chainlocked tx is tracked")
+	}
+	if !tracker.IsInstantSendLocked(&hashB) {
+		t.Fatal("IsInstantSendLocked: lock of tx above the ChainLock " +
+			"was removed")
+	}
+
+	// Transactions of disconnected blocks remain locked.
+	tracker.BlockDisconnected(blockB)
+	chainLock(103)
+	if !tracker.IsInstantSendLocked(&hashB) {
+		t.Fatal("IsInstantSendLocked: lock of unmined tx was removed")
+	}
+
+	// Transactions mined at or below the best ChainLock are removed right
+	// away.
+	tracker.BlockConnected(mineBlock(103, txB))
+	if tracker.IsInstantSendLocked(&hashB) {
+		t.Fatal("IsInstantSendLocked: lock of chainlocked tx is tracked")
+	}
+}
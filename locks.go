@@ -0,0 +1,339 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/llmq"
+	"github.com/dashpay/dashd-go/peer"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// quorumHistoryDepth is the number of blocks below the most recent
+	// masternode list the active quorums are retained for.  It covers the
+	// heights the deterministic InstantSend locks of the previous rotation
+	// cycle are signed at.
+	quorumHistoryDepth = 576
+
+	// maxPendingISLocks is the maximum number of InstantSend locks of a
+	// peer which await the masternode list diff requested from it.
+	maxPendingISLocks = 100
+)
+
+// quorumSet houses the quorums which were active as of a main chain block.
+type quorumSet struct {
+	height  int32
+	quorums []*wire.QuorumCommitment
+}
+
+// quorumList houses the simplified masternode list as of the most recent main
+// chain block it was synced to via mnlistdiff messages along with the quorums
+// which were active as of the blocks below it.  It provides the quorums the
+// ChainLocks and InstantSend locks are verified against.
+//
+// It is safe for concurrent access.
+type quorumList struct {
+	// chain is the chain the blocks of the lists are looked up in.  It
+	// must be set before the list is used.
+	chain *blockchain.BlockChain
+
+	mtx        sync.RWMutex
+	list       *wire.SimplifiedMNList
+	listHeight int32
+	history    map[chainhash.Hash]quorumSet
+}
+
+// newQuorumList returns a new empty quorum list.
+func newQuorumList() *quorumList {
+	return &quorumList{history: make(map[chainhash.Hash]quorumSet)}
+}
+
+// ActiveQuorums returns the final commitments of the quorums of the passed type
+// which were active as of the main chain block at the passed height, along with
+// whether or not they are known.
+//
+// This is part of the llmq.QuorumSource interface.
+func (q *quorumList) ActiveQuorums(llmqType wire.LLMQType, height int32) ([]*wire.QuorumCommitment, bool) {
+	hash, err := q.chain.BlockHashByHeight(height)
+	if err != nil {
+		return nil, false
+	}
+
+	q.mtx.RLock()
+	set, ok := q.history[*hash]
+	q.mtx.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	var quorums []*wire.QuorumCommitment
+	for _, qc := range set.quorums {
+		if qc.LLMQType == llmqType {
+			quorums = append(quorums, qc)
+		}
+	}
+	return quorums, true
+}
+
+// BestHeight returns the height of the main chain tip.
+//
+// This is part of the llmq.QuorumSource interface.
+func (q *quorumList) BestHeight() int32 {
+	return q.chain.BestSnapshot().Height
+}
+
+// BlockHeightByHash returns the height of the main chain block with the passed
+// hash.
+//
+// This is part of the llmq.QuorumSource interface.
+func (q *quorumList) BlockHeightByHash(hash *chainhash.Hash) (int32, error) {
+	return q.chain.BlockHeightByHash(hash)
+}
+
+// diffBase returns the hash of the block to request the masternode list diff
+// leading to the main chain block at the passed height relative to.  It is the
+// block the most recent list is as of while it is a main chain block not above
+// the passed height and the zero hash, which requests the full list, otherwise.
+func (q *quorumList) diffBase(height int32) chainhash.Hash {
+	q.mtx.RLock()
+	list, listHeight := q.list, q.listHeight
+	q.mtx.RUnlock()
+	if list == nil || listHeight > height ||
+		!q.chain.MainChainHasBlock(&list.BlockHash) {
+
+		return chainhash.Hash{}
+	}
+	return list.BlockHash
+}
+
+// applyDiff applies the passed masternode list diff to the most recent list
+// and records the quorums of the resulting list.  The diff must lead to a main
+// chain block whose merkle root commits to the coinbase transaction of the
+// diff, which in turn commits to the resulting masternode list and quorums.
+// The resulting list only replaces the most recent one when it is as of a
+// higher block.
+func (q *quorumList) applyDiff(diff *wire.MsgMnListDiff) error {
+	height, err := q.chain.BlockHeightByHash(&diff.BlockHash)
+	if err != nil {
+		return fmt.Errorf("block %v is not in the main chain",
+			diff.BlockHash)
+	}
+	header, err := q.chain.HeaderByHash(&diff.BlockHash)
+	if err != nil {
+		return err
+	}
+	root, err := diff.CbTxMerkleRoot()
+	if err != nil {
+		return err
+	}
+	if root != header.MerkleRoot {
+		return fmt.Errorf("coinbase transaction is not committed to by "+
+			"block %v", diff.BlockHash)
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	// A diff based on the zero hash replaces the list.
+	var base *wire.SimplifiedMNList
+	if diff.BaseBlockHash != (chainhash.Hash{}) {
+		base = q.list
+	}
+	list, err := wire.ApplyMnListDiff(base, diff)
+	if err != nil {
+		return err
+	}
+	q.history[diff.BlockHash] = quorumSet{
+		height:  height,
+		quorums: list.Quorums(),
+	}
+	if q.list == nil || height > q.listHeight {
+		q.list, q.listHeight = list, height
+	}
+
+	// Forget the quorums of blocks too far below the most recent list.
+	for hash, set := range q.history {
+		if set.height < q.listHeight-quorumHistoryDepth {
+			delete(q.history, hash)
+		}
+	}
+	return nil
+}
+
+// handleLockNotification keeps the InstantSend locks tracked by the lock tracker
+// in sync with the transactions mined in the main chain, so the locks of mined
+// transactions are removed once their blocks are chainlocked.
+func (s *server) handleLockNotification(notification *blockchain.Notification) {
+	block, ok := notification.Data.(*btcutil.Block)
+	if !ok {
+		return
+	}
+
+	switch notification.Type {
+	case blockchain.NTBlockConnected:
+		s.lockTracker.BlockConnected(block)
+
+	case blockchain.NTBlockDisconnected:
+		s.lockTracker.BlockDisconnected(block)
+	}
+}
+
+// requestQuorums requests the masternode list diff leading to the main chain
+// block at the passed height from the peer unless it has been requested
+// already.  The diff is handled by OnMnListDiff.
+func (sp *serverPeer) requestQuorums(height int32) {
+	if sp.quorumsRequested && sp.quorumsHeight == height {
+		return
+	}
+	s := sp.server
+	hash, err := s.chain.BlockHashByHeight(height)
+	if err != nil {
+		return
+	}
+	base := s.quorums.diffBase(height)
+	sp.quorumsRequested, sp.quorumsHeight = true, height
+	sp.QueueMessage(wire.NewMsgGetMnListDiff(&base, hash), nil)
+}
+
+// requestLocks requests the ChainLocks and InstantSend locks announced by the
+// passed inventory from the peer.  They are handled by OnCLSig and OnISLock.
+func (sp *serverPeer) requestLocks(msg *wire.MsgInv) {
+	gdmsg := wire.NewMsgGetData()
+	for _, iv := range msg.InvList {
+		switch iv.Type {
+		case wire.InvTypeCLSig:
+		case wire.InvTypeISDLock:
+			if cfg.BlocksOnly {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := gdmsg.AddInvVect(iv); err != nil {
+			peerLog.Errorf("Failed to add inventory vector: %v", err)
+			break
+		}
+	}
+	if len(gdmsg.InvList) > 0 {
+		sp.QueueMessage(gdmsg, nil)
+	}
+}
+
+// OnCLSig is invoked when a peer receives a clsig Dash message.  The ChainLock
+// is verified against the quorums which were active SignHeightOffset blocks
+// below the locked block, so when the masternode list as of that block is not
+// known yet, it is requested from the peer first and the ChainLock is
+// processed once it arrives.
+func (sp *serverPeer) OnCLSig(_ *peer.Peer, msg *wire.MsgCLSig) {
+	sp.processCLSig(msg, true)
+}
+
+// OnMnListDiff is invoked when a peer receives a mnlistdiff Dash message.  The
+// diff is applied to the masternode list before processing the locks it was
+// requested for, if any.
+func (sp *serverPeer) OnMnListDiff(_ *peer.Peer, msg *wire.MsgMnListDiff) {
+	s := sp.server
+
+	// Diffs requested from several peers for the same block are only
+	// applied once, so failing to apply a diff is not an error of the
+	// peer.
+	if err := s.quorums.applyDiff(msg); err != nil {
+		peerLog.Debugf("Unable to apply mnlistdiff for block %v from "+
+			"%v: %v", msg.BlockHash, sp, err)
+	}
+	sp.quorumsRequested = false
+
+	if clsig := sp.pendingCLSig; clsig != nil {
+		sp.pendingCLSig = nil
+		sp.processCLSig(clsig, false)
+	}
+	islocks := sp.pendingISLocks
+	sp.pendingISLocks = nil
+	for _, islock := range islocks {
+		sp.processISLock(islock, false)
+	}
+}
+
+// processCLSig verifies the ChainLock relayed by the passed clsig message and
+// makes it the best ChainLock when it is for a higher block than the current
+// one.  The best chain is reconsidered when the best ChainLock changes, since
+// a reorganize to the chainlocked block might have been rejected for exceeding
+// the max reorganize depth.
+//
+// When the quorums responsible for the ChainLock are not known and retry is
+// set, the ChainLock is deferred until the masternode list diff requested from
+// the peer arrives.
+func (sp *serverPeer) processCLSig(msg *wire.MsgCLSig, retry bool) {
+	s := sp.server
+	prevLock := s.lockTracker.BestCLSig()
+	err := s.lockTracker.ProcessCLSig(msg)
+	var unavailable llmq.QuorumsUnavailableError
+	if retry && errors.As(err, &unavailable) {
+		sp.pendingCLSig = msg
+		sp.requestQuorums(unavailable.Height)
+		return
+	}
+	if err != nil {
+		peerLog.Debugf("Rejected ChainLock of block %v from %v: %v",
+			msg.BlockHash, sp, err)
+		return
+	}
+	if s.lockTracker.BestCLSig() == prevLock {
+		return
+	}
+
+	if err := s.chain.ChainLockUpdated(); err != nil {
+		srvrLog.Errorf("Unable to reorganize to chainlocked block %v: %v",
+			msg.BlockHash, err)
+	}
+}
+
+// OnISLock is invoked when a peer receives an islock or isdlock Dash message.
+// The InstantSend lock is verified against the quorums which were active
+// SignHeightOffset blocks below the height it was signed at.  Like ChainLocks,
+// it is processed once the masternode list as of that block arrives when it is
+// not known yet.
+func (sp *serverPeer) OnISLock(_ *peer.Peer, msg *wire.MsgISLock) {
+	sp.processISLock(msg, true)
+}
+
+// processISLock verifies the InstantSend lock relayed by the passed islock or
+// isdlock message, records the transaction as locked and removes the mempool
+// transactions which conflict with it.  When the quorums
+// responsible for the lock are not known and retry is set, the lock is
+// deferred until the masternode list diff requested from the peer arrives.
+func (sp *serverPeer) processISLock(msg *wire.MsgISLock, retry bool) {
+	s := sp.server
+	err := s.lockTracker.ProcessISLock(msg)
+	var unavailable llmq.QuorumsUnavailableError
+	if retry && errors.As(err, &unavailable) &&
+		len(sp.pendingISLocks) < maxPendingISLocks {
+
+		sp.pendingISLocks = append(sp.pendingISLocks, msg)
+		sp.requestQuorums(unavailable.Height)
+		return
+	}
+	if err != nil {
+		peerLog.Debugf("Rejected InstantSend lock of tx %v from %v: %v",
+			msg.TxHash, sp, err)
+		return
+	}
+
+	// The network agreed on the locked transaction, so the transactions
+	// in the mempool which conflict with it can never be mined.
+	removed := s.txMemPool.RemoveInstantSendConflicts(&msg.TxHash,
+		msg.Inputs)
+	for _, tx := range removed {
+		srvrLog.Debugf("Removed transaction %v from the mempool since "+
+			"it conflicts with InstantSend locked transaction %v",
+			tx.Hash(), msg.TxHash)
+	}
+}
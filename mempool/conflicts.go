@@ -0,0 +1,286 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TxCluster describes a cluster of unconfirmed transactions, which is a
+// connected component of the graph formed by the transactions in the pool and
+// the outputs they spend from each other.  Evicting any transaction of a
+// cluster also evicts its descendants, so the lock status of a cluster
+// determines which transactions it may lose to conflicting ones.
+type TxCluster struct {
+	// Txs are the hashes of the transactions in the cluster.
+	Txs []chainhash.Hash
+
+	// Fee is the total fee of the transactions in the cluster.
+	Fee int64
+
+	// Size is the total virtual size of the transactions in the cluster.
+	Size int64
+
+	// Locked are the hashes of the transactions in the cluster that are
+	// locked by InstantSend.
+	Locked []chainhash.Hash
+}
+
+// ConflictInfo describes the transactions in the pool a transaction conflicts
+// with.
+type ConflictInfo struct {
+	// Conflicts are the hashes of the transactions in the pool that spend
+	// any of the outputs spent by the transaction.
+	Conflicts []chainhash.Hash
+
+	// Evictions are the hashes of the transactions that would be removed
+	// from the pool if the transaction were accepted, which are the
+	// conflicts and all of their descendants.
+	Evictions []chainhash.Hash
+
+	// Clusters are the clusters containing the conflicts.
+	Clusters []*TxCluster
+
+	// Locked reports whether the transaction itself is locked by
+	// InstantSend.
+	Locked bool
+
+	// EvictionAllowed reports whether the lock status of the evictions and
+	// the replacement policy allow evicting them in favor of the
+	// transaction.  Fee requirements of replacements are not considered.
+	EvictionAllowed bool
+}
+
+// isInstantSendLocked returns whether the transaction with the passed hash is
+// locked by InstantSend according to the configured lock source.
+func (mp *TxPool) isInstantSendLocked(hash *chainhash.Hash) bool {
+	return mp.cfg.IsInstantSendLocked != nil &&
+		mp.cfg.IsInstantSendLocked(hash)
+}
+
+// sortHashes sorts the passed hashes by their bytes so results don't depend on
+// map iteration order.
+func sortHashes(hashes []chainhash.Hash) {
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+}
+
+// txCluster returns the cluster containing the transaction in the pool with
+// the passed hash.  The hashes of all transactions in the cluster are added to
+// the seen set.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txCluster(hash chainhash.Hash,
+	seen map[chainhash.Hash]struct{}) *TxCluster {
+
+	cluster := &TxCluster{}
+	queue := []chainhash.Hash{hash}
+	seen[hash] = struct{}{}
+	visit := func(hash chainhash.Hash) {
+		if _, ok := mp.pool[hash]; !ok {
+			return
+		}
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		queue = append(queue, hash)
+	}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		txD := mp.pool[hash]
+		cluster.Txs = append(cluster.Txs, hash)
+		cluster.Fee += txD.Fee
+		cluster.Size += GetTxVirtualSize(txD.Tx)
+		if mp.isInstantSendLocked(&hash) {
+			cluster.Locked = append(cluster.Locked, hash)
+		}
+
+		// Both the unconfirmed parents and the spenders of the outputs
+		// of the transaction belong to the same cluster.
+		msgTx := txD.Tx.MsgTx()
+		for _, txIn := range msgTx.TxIn {
+			visit(txIn.PreviousOutPoint.Hash)
+		}
+		op := wire.OutPoint{Hash: hash}
+		for i := range msgTx.TxOut {
+			op.Index = uint32(i)
+			if spender, ok := mp.outpoints[op]; ok {
+				visit(*spender.Hash())
+			}
+		}
+	}
+	sortHashes(cluster.Txs)
+	sortHashes(cluster.Locked)
+
+	return cluster
+}
+
+// checkConflicts is the internal function which implements the public
+// CheckConflicts, except that the clusters of the conflicts are not determined
+// since that requires walking the transaction graph beyond the evictions.  The
+// transactions that would be evicted are also returned keyed by their hash.
+// See the comment for CheckConflicts for more details.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkConflicts(tx *btcutil.Tx) (*ConflictInfo,
+	map[chainhash.Hash]*btcutil.Tx, error) {
+
+	info := &ConflictInfo{
+		Locked:          mp.isInstantSendLocked(tx.Hash()),
+		EvictionAllowed: true,
+	}
+
+	// Collect the direct conflicts along with the first one that doesn't
+	// permit being replaced.  Transactions locked by InstantSend replace
+	// their conflicts regardless of the replacement policy since the
+	// network already agreed on them.
+	var replaceErr error
+	conflicts := make(map[chainhash.Hash]struct{})
+	for _, txIn := range tx.MsgTx().TxIn {
+		conflict, ok := mp.outpoints[txIn.PreviousOutPoint]
+		if !ok {
+			continue
+		}
+		if _, ok := conflicts[*conflict.Hash()]; !ok {
+			conflicts[*conflict.Hash()] = struct{}{}
+			info.Conflicts = append(info.Conflicts, *conflict.Hash())
+		}
+
+		if replaceErr != nil || info.Locked {
+			continue
+		}
+		if mp.cfg.Policy.RejectReplacement ||
+			!mp.signalsReplacement(conflict, nil) {
+			str := fmt.Sprintf("output %v already spent by "+
+				"transaction %v in the memory pool",
+				txIn.PreviousOutPoint, conflict.Hash())
+			replaceErr = txRuleError(wire.RejectDuplicate, str)
+		}
+	}
+	if len(info.Conflicts) == 0 {
+		return info, nil, nil
+	}
+	sortHashes(info.Conflicts)
+
+	evictions := mp.txConflicts(tx)
+	for hash := range evictions {
+		info.Evictions = append(info.Evictions, hash)
+	}
+	sortHashes(info.Evictions)
+
+	// Transactions locked by InstantSend are never evicted, which also
+	// protects their ancestors since evicting those would evict them too.
+	for i := range info.Evictions {
+		hash := &info.Evictions[i]
+		if !mp.isInstantSendLocked(hash) {
+			continue
+		}
+		info.EvictionAllowed = false
+		str := fmt.Sprintf("transaction %v conflicts with InstantSend "+
+			"locked transaction %v", tx.Hash(), hash)
+		return info, evictions, txRuleError(wire.RejectDuplicate, str)
+	}
+	if replaceErr != nil {
+		info.EvictionAllowed = false
+		return info, evictions, replaceErr
+	}
+
+	return info, evictions, nil
+}
+
+// CheckConflicts returns the transactions in the pool the passed transaction
+// conflicts with, the transactions that would be evicted by accepting it, the
+// clusters they belong to and whether the eviction is allowed.
+//
+// Evicting a transaction locked by InstantSend, directly or by evicting one of
+// its ancestors, is never allowed.  Otherwise, a transaction locked by
+// InstantSend may evict its conflicts while other transactions may only
+// replace conflicts that signal replacement according to the RBF policy.  When
+// the eviction is not allowed, a RuleError describing the reason is returned
+// along with the conflict details.  Note that neither fee requirements of
+// replacements nor double spends against the main chain are checked.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckConflicts(tx *btcutil.Tx) (*ConflictInfo, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	info, _, err := mp.checkConflicts(tx)
+	seen := make(map[chainhash.Hash]struct{})
+	for _, hash := range info.Conflicts {
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		info.Clusters = append(info.Clusters, mp.txCluster(hash, seen))
+	}
+
+	return info, err
+}
+
+// TxCluster returns the cluster containing the transaction with the passed
+// hash, which must be in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) TxCluster(hash *chainhash.Hash) (*TxCluster, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	if _, ok := mp.pool[*hash]; !ok {
+		return nil, fmt.Errorf("transaction %v is not in the pool", hash)
+	}
+
+	return mp.txCluster(*hash, make(map[chainhash.Hash]struct{})), nil
+}
+
+// RemoveInstantSendConflicts removes the transactions in the pool which spend
+// any of the passed outputs spent by the InstantSend locked transaction with
+// the passed hash, along with all transactions which depend on them.  Orphans
+// spending the outputs are removed as well.  Since the network agreed on the
+// locked transaction, its conflicts can never be mined.  The removed
+// transactions are returned.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RemoveInstantSendConflicts(txHash *chainhash.Hash,
+	inputs []wire.OutPoint) []*btcutil.Tx {
+
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	// Collect the conflicts along with their descendants before removing
+	// them so each of them is reported once.
+	evictions := make(map[chainhash.Hash]*btcutil.Tx)
+	for _, input := range inputs {
+		conflict, ok := mp.outpoints[input]
+		if ok && !conflict.Hash().IsEqual(txHash) {
+			evictions[*conflict.Hash()] = conflict
+			for hash, tx := range mp.txDescendants(conflict, nil) {
+				evictions[hash] = tx
+			}
+		}
+
+		for _, orphan := range mp.orphansByPrev[input] {
+			if !orphan.Hash().IsEqual(txHash) {
+				mp.removeOrphan(orphan, true)
+			}
+		}
+	}
+
+	removed := make([]*btcutil.Tx, 0, len(evictions))
+	for _, tx := range evictions {
+		mp.removeTransaction(tx, true)
+		removed = append(removed, tx)
+	}
+	return removed
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// lockSet is a set of transactions locked by InstantSend used as the lock
+// source of the mempool in tests.
+type lockSet map[chainhash.Hash]struct{}
+
+// isLocked returns whether the transaction with the passed hash is locked.
+func (s lockSet) isLocked(hash *chainhash.Hash) bool {
+	_, ok := s[*hash]
+	return ok
+}
+
+// hashesOf returns the sorted hashes of the passed transactions.
+func hashesOf(txs ...*btcutil.Tx) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, *tx.Hash())
+	}
+	sortHashes(hashes)
+	return hashes
+}
+
+// TestTxCluster ensures the clusters of transactions in the pool are the
+// connected components of their spends.
+func TestTxCluster(t *testing.T) {
+	t.Parallel()
+
+	harness, _, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	ctx := &testContext{t, harness}
+	locks := make(lockSet)
+	harness.txPool.cfg.IsInstantSendLocked = locks.isLocked
+
+	// Create two transactions spending a parent transaction, a child
+	// spending both of them and an unrelated transaction.
+	coinbase := ctx.addCoinbaseTx(2)
+	outs := []spendableOutput{txOutToSpendableOut(coinbase, 0)}
+	parent := ctx.addSignedTx(outs, 2, 1000, false, false)
+	outs = []spendableOutput{txOutToSpendableOut(parent, 0)}
+	left := ctx.addSignedTx(outs, 1, 1000, false, false)
+	outs = []spendableOutput{txOutToSpendableOut(parent, 1)}
+	right := ctx.addSignedTx(outs, 1, 1000, false, false)
+	outs = []spendableOutput{
+		txOutToSpendableOut(left, 0), txOutToSpendableOut(right, 0),
+	}
+	child := ctx.addSignedTx(outs, 1, 1000, false, false)
+	outs = []spendableOutput{txOutToSpendableOut(coinbase, 1)}
+	unrelated := ctx.addSignedTx(outs, 1, 1000, false, false)
+	locks[*right.Hash()] = struct{}{}
+
+	members := []*btcutil.Tx{parent, left, right, child}
+	for _, tx := range members {
+		cluster, err := harness.txPool.TxCluster(tx.Hash())
+		if err != nil {
+			t.Fatalf("TxCluster(%v): %v", tx.Hash(), err)
+		}
+		if !reflect.DeepEqual(cluster.Txs, hashesOf(members...)) {
+			t.Fatalf("TxCluster(%v): wrong transactions - got %v",
+				tx.Hash(), cluster.Txs)
+		}
+		if cluster.Fee != 4000 {
+			t.Fatalf("TxCluster(%v): wrong fee - got %v, want 4000",
+				tx.Hash(), cluster.Fee)
+		}
+		if !reflect.DeepEqual(cluster.Locked, hashesOf(right)) {
+			t.Fatalf("TxCluster(%v): wrong locked transactions - "+
+				"got %v", tx.Hash(), cluster.Locked)
+		}
+	}
+
+	cluster, err := harness.txPool.TxCluster(unrelated.Hash())
+	if err != nil {
+		t.Fatalf("TxCluster(%v): %v", unrelated.Hash(), err)
+	}
+	if !reflect.DeepEqual(cluster.Txs, hashesOf(unrelated)) ||
+		len(cluster.Locked) != 0 {
+
+		t.Fatalf("TxCluster(%v): unexpected cluster %+v",
+			unrelated.Hash(), cluster)
+	}
+
+	if _, err := harness.txPool.TxCluster(coinbase.Hash()); err == nil {
+		t.Fatalf("TxCluster: expected error for transaction not in pool")
+	}
+}
+
+// TestCheckConflicts ensures conflicts are reported along with their evictions
+// and that evictions are only allowed when their lock status and the
+// replacement policy permit.
+func TestCheckConflicts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+
+		// signalsReplacement is whether the conflicting parent signals
+		// replacement.
+		signalsReplacement bool
+
+		// lockChild, lockTx are whether the child of the conflicting
+		// parent and the new transaction are locked by InstantSend.
+		lockChild bool
+		lockTx    bool
+
+		evictionAllowed bool
+	}{
+		{
+			name:               "replaceable conflict",
+			signalsReplacement: true,
+			evictionAllowed:    true,
+		},
+		{
+			name:            "non-replaceable conflict",
+			evictionAllowed: false,
+		},
+		{
+			name:               "locked descendant of conflict",
+			signalsReplacement: true,
+			lockChild:          true,
+			evictionAllowed:    false,
+		},
+		{
+			name:            "locked transaction",
+			lockTx:          true,
+			evictionAllowed: true,
+		},
+		{
+			name:            "locked transaction and descendant",
+			lockChild:       true,
+			lockTx:          true,
+			evictionAllowed: false,
+		},
+	}
+
+	for _, test := range testCases {
+		harness, _, err := newPoolHarness(&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("unable to create test pool: %v", err)
+		}
+		ctx := &testContext{t, harness}
+		locks := make(lockSet)
+		harness.txPool.cfg.IsInstantSendLocked = locks.isLocked
+
+		// Create a parent with a child and a transaction double
+		// spending the output spent by the parent.
+		coinbase := ctx.addCoinbaseTx(1)
+		coinbaseOut := txOutToSpendableOut(coinbase, 0)
+		outs := []spendableOutput{coinbaseOut}
+		parent := ctx.addSignedTx(outs, 1, 1000, test.signalsReplacement,
+			false)
+		outs = []spendableOutput{txOutToSpendableOut(parent, 0)}
+		child := ctx.addSignedTx(outs, 1, 1000, false, false)
+		outs = []spendableOutput{coinbaseOut}
+		tx, err := harness.CreateSignedTx(outs, 2, 5000, false)
+		if err != nil {
+			t.Fatalf("%s: unable to create transaction: %v",
+				test.name, err)
+		}
+		if test.lockChild {
+			locks[*child.Hash()] = struct{}{}
+		}
+		if test.lockTx {
+			locks[*tx.Hash()] = struct{}{}
+		}
+
+		info, err := harness.txPool.CheckConflicts(tx)
+		if (err == nil) != test.evictionAllowed ||
+			info.EvictionAllowed != test.evictionAllowed {
+
+			t.Fatalf("%s: unexpected eviction result - got %v (%v), "+
+				"want %v", test.name, info.EvictionAllowed, err,
+				test.evictionAllowed)
+		}
+		if code, _ := extractRejectCode(err); err != nil &&
+			code != wire.RejectDuplicate {
+
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if info.Locked != test.lockTx {
+			t.Fatalf("%s: wrong lock status - got %v, want %v",
+				test.name, info.Locked, test.lockTx)
+		}
+		if !reflect.DeepEqual(info.Conflicts, hashesOf(parent)) {
+			t.Fatalf("%s: wrong conflicts - got %v", test.name,
+				info.Conflicts)
+		}
+		if !reflect.DeepEqual(info.Evictions, hashesOf(parent, child)) {
+			t.Fatalf("%s: wrong evictions - got %v", test.name,
+				info.Evictions)
+		}
+		if len(info.Clusters) != 1 || !reflect.DeepEqual(
+			info.Clusters[0].Txs, hashesOf(parent, child)) {
+
+			t.Fatalf("%s: wrong clusters - got %+v", test.name,
+				info.Clusters)
+		}
+
+		// Processing the transaction must evict the conflicts exactly
+		// when the eviction is allowed.
+		_, err = harness.txPool.ProcessTransaction(tx, false, false, 0)
+		if (err == nil) != test.evictionAllowed {
+			t.Fatalf("%s: unexpected ProcessTransaction result: %v",
+				test.name, err)
+		}
+		testPoolMembership(ctx, tx, false, test.evictionAllowed)
+		testPoolMembership(ctx, parent, false, !test.evictionAllowed)
+		testPoolMembership(ctx, child, false, !test.evictionAllowed)
+	}
+}
+
+// TestRemoveInstantSendConflicts ensures the transactions spending the inputs
+// of an InstantSend locked transaction are removed from the pool along with
+// their descendants and orphans spending the inputs, while the locked
+// transaction and unrelated transactions remain.
+func TestRemoveInstantSendConflicts(t *testing.T) {
+	t.Parallel()
+
+	harness, _, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	ctx := &testContext{t, harness}
+
+	// Create a parent with a child, an unrelated transaction and an orphan
+	// spending an output of an unknown transaction.
+	coinbase := ctx.addCoinbaseTx(2)
+	coinbaseOut := txOutToSpendableOut(coinbase, 0)
+	parent := ctx.addSignedTx([]spendableOutput{coinbaseOut}, 1, 1000,
+		false, false)
+	outs := []spendableOutput{txOutToSpendableOut(parent, 0)}
+	child := ctx.addSignedTx(outs, 1, 1000, false, false)
+	outs = []spendableOutput{txOutToSpendableOut(coinbase, 1)}
+	unrelated := ctx.addSignedTx(outs, 1, 1000, false, false)
+	unknownOut := spendableOutput{
+		outPoint: wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		amount:   btcutil.Amount(5000000000),
+	}
+	orphan, err := harness.CreateSignedTx([]spendableOutput{unknownOut},
+		1, 1000, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	if _, err := harness.txPool.ProcessTransaction(orphan, true, false, 0); err != nil {
+		t.Fatalf("unable to process orphan: %v", err)
+	}
+	testPoolMembership(ctx, orphan, true, false)
+
+	// The locked transaction double spends the parent and the orphan.
+	locked, err := harness.CreateSignedTx(
+		[]spendableOutput{coinbaseOut, unknownOut}, 1, 2000, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	inputs := []wire.OutPoint{coinbaseOut.outPoint, unknownOut.outPoint}
+	removed := harness.txPool.RemoveInstantSendConflicts(locked.Hash(),
+		inputs)
+	if got := hashesOf(removed...); !reflect.DeepEqual(got,
+		hashesOf(parent, child)) {
+
+		t.Fatalf("RemoveInstantSendConflicts: removed %v, want %v",
+			got, hashesOf(parent, child))
+	}
+	testPoolMembership(ctx, parent, false, false)
+	testPoolMembership(ctx, child, false, false)
+	testPoolMembership(ctx, orphan, false, false)
+	testPoolMembership(ctx, unrelated, false, true)
+
+	// The locked transaction itself is not a conflict of its lock.
+	outs = []spendableOutput{txOutToSpendableOut(unrelated, 0)}
+	spender := ctx.addSignedTx(outs, 1, 1000, false, false)
+	removed = harness.txPool.RemoveInstantSendConflicts(spender.Hash(),
+		[]wire.OutPoint{outs[0].outPoint})
+	if len(removed) != 0 {
+		t.Fatalf("RemoveInstantSendConflicts: removed %v, want none",
+			hashesOf(removed...))
+	}
+	testPoolMembership(ctx, spender, false, true)
+}
@@ -39,6 +39,16 @@ const (
 	// scans of the orphan pool to evict expired transactions.
 	orphanExpireScanInterval = time.Minute * 5
 
+	// DefaultExpiryTimeout is the default amount of time a transaction is
+	// allowed to stay in the memory pool without being mined before it
+	// expires, which matches the relay policy of the reference
+	// implementation.
+	DefaultExpiryTimeout = time.Hour * 336
+
+	// txExpireScanInterval is the minimum amount of time in between scans
+	// of the memory pool to evict expired transactions.
+	txExpireScanInterval = time.Minute * 5
+
 	// MaxRBFSequence is the maximum sequence number an input can use to
 	// signal that the transaction spending it can be replaced using the
 	// Replace-By-Fee (RBF) policy.
@@ -89,6 +99,13 @@ type Config struct {
 	// into the mempool or not.
 	IsDeploymentActive func(deploymentID uint32) (bool, error)
 
+	// IsInstantSendLocked defines the function to use to determine whether
+	// a transaction is locked by InstantSend.  Locked transactions are
+	// never evicted in favor of conflicting ones, while a locked
+	// transaction evicts its conflicts regardless of whether they signal
+	// replacement.  This can be nil if InstantSend locks are not tracked.
+	IsInstantSendLocked func(*chainhash.Hash) bool
+
 	// SigCache defines a signature cache to use.
 	SigCache *txscript.SigCache
 
@@ -130,6 +147,19 @@ type Policy struct {
 	// that can be queued.
 	MaxOrphanTxs int
 
+	// MaxDataCarrierSize is the maximum number of bytes of data a standard
+	// null data (OP_RETURN) output may carry.  Outputs carrying more data
+	// are rejected as non-standard.  It can't be raised beyond
+	// txscript.MaxDataCarrierSize since larger null data scripts are not
+	// recognized as such.  A value of zero selects
+	// txscript.MaxDataCarrierSize, while a negative value only allows
+	// outputs without any data.
+	//
+	// Note that only the pushed data is counted, unlike the
+	// -datacarriersize option of dashd which limits the size of the whole
+	// script, including the OP_RETURN and push opcodes.
+	MaxDataCarrierSize int
+
 	// MaxOrphanTxSize is the maximum size allowed for orphan transactions.
 	// This helps prevent memory exhaustion attacks from sending a lot of
 	// of big orphans.
@@ -148,6 +178,25 @@ type Policy struct {
 	// transactions using the Replace-By-Fee (RBF) signaling policy into
 	// the mempool.
 	RejectReplacement bool
+
+	// ExpiryTimeout is the amount of time a transaction is allowed to stay
+	// in the memory pool without being mined.  Expired transactions are
+	// evicted along with any transactions which spend them.  A value of
+	// zero disables expiration.
+	ExpiryTimeout time.Duration
+}
+
+// dataCarrierSize returns the maximum number of bytes of data a standard null
+// data output may carry as configured by MaxDataCarrierSize, where zero
+// selects the default and a negative value allows no data.
+func (p *Policy) dataCarrierSize() int {
+	switch {
+	case p.MaxDataCarrierSize == 0:
+		return txscript.MaxDataCarrierSize
+	case p.MaxDataCarrierSize < 0:
+		return 0
+	}
+	return p.MaxDataCarrierSize
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -190,6 +239,11 @@ type TxPool struct {
 	// the scan will only run when an orphan is added to the pool as opposed
 	// to on an unconditional timer.
 	nextExpireScan time.Time
+
+	// nextTxExpireScan is the time after which the memory pool will be
+	// scanned in order to evict expired transactions.  Similar to the
+	// orphan scan, the scan only runs when a transaction is accepted.
+	nextTxExpireScan time.Time
 }
 
 // Ensure the TxPool type implements the mining.TxSource interface.
@@ -486,6 +540,12 @@ func (mp *TxPool) removeTransaction(tx *btcutil.Tx, removeRedeemers bool) {
 			mp.cfg.AddrIndex.RemoveUnconfirmedTx(txHash)
 		}
 
+		// The sighash midstate computed when validating the scripts of
+		// the transaction is no longer needed.
+		if mp.cfg.HashCache != nil {
+			mp.cfg.HashCache.PurgeSigHashes(txHash)
+		}
+
 		// Mark the referenced outpoints as unspent by the pool.
 		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
 			delete(mp.outpoints, txIn.PreviousOutPoint)
@@ -495,6 +555,43 @@ func (mp *TxPool) removeTransaction(tx *btcutil.Tx, removeRedeemers bool) {
 	}
 }
 
+// expireTransactions removes all transactions which have been in the pool
+// for longer than the configured expiry timeout as of the passed time, along
+// with any transactions that spend their outputs, and returns the number of
+// transactions removed.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) expireTransactions(now time.Time) int {
+	timeout := mp.cfg.Policy.ExpiryTimeout
+	if timeout <= 0 {
+		return 0
+	}
+
+	var expired []*btcutil.Tx
+	for _, txD := range mp.pool {
+		if now.Sub(txD.Added) > timeout {
+			expired = append(expired, txD.Tx)
+		}
+	}
+
+	origNumTxns := len(mp.pool)
+	for _, tx := range expired {
+		// The transaction might have already been removed as the
+		// redeemer of another expired transaction.
+		if _, exists := mp.pool[*tx.Hash()]; exists {
+			mp.removeTransaction(tx, true)
+		}
+	}
+
+	numExpired := origNumTxns - len(mp.pool)
+	if numExpired > 0 {
+		log.Debugf("Expired %d %s (remaining: %d)", numExpired,
+			pickNoun(numExpired, "transaction", "transactions"),
+			len(mp.pool))
+	}
+	return numExpired
+}
+
 // RemoveTransaction removes the passed transaction from the mempool. When the
 // removeRedeemers flag is set, any transactions that redeem outputs from the
 // removed transaction will also be removed recursively from the mempool, as
@@ -569,35 +666,22 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, tx *btcutil
 
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
-// If it does, we'll check whether each of those transactions are signaling for
-// replacement. If just one of them isn't, an error is returned. Otherwise, a
-// boolean is returned signaling that the transaction is a replacement. Note it
-// does not check for double spends against transactions already in the main
-// chain.
+// If it does, we'll check whether those transactions may be evicted in its
+// favor, which requires that none of them are locked by InstantSend and that
+// either the passed transaction is locked or each of them signals replacement.
+// If they can't, an error is returned. Otherwise, the transactions that would
+// be evicted are returned, which are only present when the transaction is a
+// replacement. Note it does not check for double spends against transactions
+// already in the main chain.
 //
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *TxPool) checkPoolDoubleSpend(tx *btcutil.Tx) (bool, error) {
-	var isReplacement bool
-	for _, txIn := range tx.MsgTx().TxIn {
-		conflict, ok := mp.outpoints[txIn.PreviousOutPoint]
-		if !ok {
-			continue
-		}
-
-		// Reject the transaction if we don't accept replacement
-		// transactions or if it doesn't signal replacement.
-		if mp.cfg.Policy.RejectReplacement ||
-			!mp.signalsReplacement(conflict, nil) {
-			str := fmt.Sprintf("output %v already spent by "+
-				"transaction %v in the memory pool",
-				txIn.PreviousOutPoint, conflict.Hash())
-			return false, txRuleError(wire.RejectDuplicate, str)
-		}
-
-		isReplacement = true
+func (mp *TxPool) checkPoolDoubleSpend(tx *btcutil.Tx) (map[chainhash.Hash]*btcutil.Tx, error) {
+	_, conflicts, err := mp.checkConflicts(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	return isReplacement, nil
+	return conflicts, nil
 }
 
 // signalsReplacement determines if a transaction is signaling that it can be
@@ -825,23 +909,42 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash) (*btcutil.Tx, error)
 	return nil, fmt.Errorf("transaction is not in the pool")
 }
 
+// FetchTxDesc returns the descriptor of the requested transaction from the
+// transaction pool.  This only fetches from the main transaction pool and does
+// not include orphans.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchTxDesc(txHash *chainhash.Hash) (*TxDesc, error) {
+	// Protect concurrent access.
+	mp.mtx.RLock()
+	txDesc, exists := mp.pool[*txHash]
+	mp.mtx.RUnlock()
+
+	if exists {
+		return txDesc, nil
+	}
+
+	return nil, fmt.Errorf("transaction is not in the pool")
+}
+
 // validateReplacement determines whether a transaction is deemed as a valid
-// replacement of all of its conflicts according to the RBF policy. If it is
-// valid, no error is returned. Otherwise, an error is returned indicating what
-// went wrong.
+// replacement of all of the passed conflicts, as returned by
+// checkPoolDoubleSpend, according to the RBF policy. If it is valid, no error
+// is returned. Otherwise, an error is returned indicating what went wrong.
 //
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
-	txFee int64) (map[chainhash.Hash]*btcutil.Tx, error) {
+func (mp *TxPool) validateReplacement(tx *btcutil.Tx, txFee int64,
+	conflicts map[chainhash.Hash]*btcutil.Tx) error {
 
 	// First, we'll make sure the set of conflicting transactions doesn't
-	// exceed the maximum allowed.
-	conflicts := mp.txConflicts(tx)
-	if len(conflicts) > MaxReplacementEvictions {
+	// exceed the maximum allowed.  Transactions locked by InstantSend were
+	// already agreed on by the network, so they aren't limited.
+	locked := mp.isInstantSendLocked(tx.Hash())
+	if !locked && len(conflicts) > MaxReplacementEvictions {
 		str := fmt.Sprintf("replacement transaction %v evicts more "+
 			"transactions than permitted: max is %v, evicts %v",
 			tx.Hash(), MaxReplacementEvictions, len(conflicts))
-		return nil, txRuleError(wire.RejectNonstandard, str)
+		return txRuleError(wire.RejectNonstandard, str)
 	}
 
 	// The set of conflicts (transactions we'll replace) and ancestors
@@ -853,7 +956,13 @@ func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
 		}
 		str := fmt.Sprintf("replacement transaction %v spends parent "+
 			"transaction %v", tx.Hash(), ancestorHash)
-		return nil, txRuleError(wire.RejectInvalid, str)
+		return txRuleError(wire.RejectInvalid, str)
+	}
+
+	// The fee requirements below don't apply to transactions locked by
+	// InstantSend either.
+	if locked {
+		return nil
 	}
 
 	// The replacement should have a higher fee rate than each of the
@@ -876,7 +985,7 @@ func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
 				"insufficient fee rate: needs more than %v, "+
 				"has %v", tx.Hash(), mp.pool[hash].FeePerKB,
 				txFeeRate)
-			return nil, txRuleError(wire.RejectInsufficientFee, str)
+			return txRuleError(wire.RejectInsufficientFee, str)
 		}
 
 		conflictsFee += mp.pool[hash].Fee
@@ -896,7 +1005,7 @@ func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
 		str := fmt.Sprintf("replacement transaction %v has an "+
 			"insufficient absolute fee: needs %v, has %v",
 			tx.Hash(), conflictsFee+minFee, txFee)
-		return nil, txRuleError(wire.RejectInsufficientFee, str)
+		return txRuleError(wire.RejectInsufficientFee, str)
 	}
 
 	// Finally, it should not spend any new unconfirmed outputs, other than
@@ -913,10 +1022,10 @@ func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
 		str := fmt.Sprintf("replacement transaction spends new "+
 			"unconfirmed input %v not found in conflicting "+
 			"transactions", txIn.PreviousOutPoint)
-		return nil, txRuleError(wire.RejectInvalid, str)
+		return txRuleError(wire.RejectInvalid, str)
 	}
 
-	return conflicts, nil
+	return nil
 }
 
 // maybeAcceptTransaction is the internal function which implements the public
@@ -991,7 +1100,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	if !mp.cfg.Policy.AcceptNonStd {
 		err = checkTransactionStandard(tx, nextBlockHeight,
 			medianTimePast, mp.cfg.Policy.MinRelayTxFee,
-			mp.cfg.Policy.MaxTxVersion)
+			mp.cfg.Policy.MaxTxVersion, mp.cfg.Policy.dataCarrierSize())
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -1015,7 +1124,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	// in-depth check that happens later after fetching the referenced
 	// transaction inputs from the main chain which examines the actual
 	// spend data and prevents double spends.
-	isReplacement, err := mp.checkPoolDoubleSpend(tx)
+	conflicts, err := mp.checkPoolDoubleSpend(tx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1196,9 +1305,8 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 
 	// If the transaction has any conflicts, and we've made it this far, then
 	// we're processing a potential replacement.
-	var conflicts map[chainhash.Hash]*btcutil.Tx
-	if isReplacement {
-		conflicts, err = mp.validateReplacement(tx, txFee)
+	if len(conflicts) > 0 {
+		err = mp.validateReplacement(tx, txFee, conflicts)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1235,6 +1343,12 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	log.Debugf("Accepted transaction %v (pool size: %v)", txHash,
 		len(mp.pool))
 
+	// Evict any transactions which have expired when it's time.
+	if now := time.Now(); now.After(mp.nextTxExpireScan) {
+		mp.expireTransactions(now)
+		mp.nextTxExpireScan = now.Add(txExpireScanInterval)
+	}
+
 	return nil, txD, nil
 }
 
@@ -931,6 +931,52 @@ func TestCheckSpend(t *testing.T) {
 	}
 }
 
+// TestExpireTransactions ensures transactions which have been in the pool for
+// longer than the expiry timeout are removed along with their descendants,
+// while newer transactions are kept.
+func TestExpireTransactions(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+
+	const txChainLength = 3
+	chainedTxns, err := harness.CreateTxChain(outputs[0], txChainLength)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := harness.txPool.ProcessTransaction(tx, true,
+			false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept "+
+				"tx: %v", err)
+		}
+	}
+
+	// Nothing should expire when expiry is disabled.
+	now := time.Now()
+	harness.txPool.cfg.Policy.ExpiryTimeout = 0
+	if n := harness.txPool.expireTransactions(now.Add(time.Hour)); n != 0 {
+		t.Fatalf("expired %d transactions with expiry disabled", n)
+	}
+
+	// Make the second transaction in the chain appear to have been added
+	// long ago.  It and its descendant should be expired while the first
+	// transaction remains.
+	harness.txPool.cfg.Policy.ExpiryTimeout = time.Hour
+	harness.txPool.pool[*chainedTxns[1].Hash()].Added = now.Add(-2 * time.Hour)
+	if n := harness.txPool.expireTransactions(now); n != 2 {
+		t.Fatalf("expired %d transactions, want 2", n)
+	}
+	testPoolMembership(tc, chainedTxns[0], false, true)
+	testPoolMembership(tc, chainedTxns[1], false, false)
+	testPoolMembership(tc, chainedTxns[2], false, false)
+}
+
 // TestSignalsReplacement tests that transactions properly signal they can be
 // replaced using RBF.
 func TestSignalsReplacement(t *testing.T) {
@@ -1206,8 +1252,9 @@ func TestCheckPoolDoubleSpend(t *testing.T) {
 
 			// Ensure that the mempool properly detected the double
 			// spend unless this is a replacement transaction.
-			isReplacement, err :=
+			conflicts, err :=
 				ctx.harness.txPool.checkPoolDoubleSpend(tx)
+			isReplacement := len(conflicts) > 0
 			if testCase.isReplacement && err != nil {
 				t.Fatalf("expected no error for replacement "+
 					"transaction, got: %v", err)
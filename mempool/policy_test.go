@@ -470,7 +470,8 @@ func TestCheckTransactionStandard(t *testing.T) {
 	for _, test := range tests {
 		// Ensure standardness is as expected.
 		err := checkTransactionStandard(btcutil.NewTx(&test.tx),
-			test.height, pastMedianTime, DefaultMinRelayTxFee, 1)
+			test.height, pastMedianTime, DefaultMinRelayTxFee, 1,
+			txscript.MaxDataCarrierSize)
 		if err == nil && test.isStandard {
 			// Test passes since function returned standard for a
 			// transaction which is intended to be standard.
@@ -510,3 +511,81 @@ func TestCheckTransactionStandard(t *testing.T) {
 		}
 	}
 }
+
+// TestCheckTransactionStandardDataCarrier ensures null data outputs are only
+// standard when the data they carry doesn't exceed the configured limit.
+func TestCheckTransactionStandardDataCarrier(t *testing.T) {
+	dummyTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		SignatureScript:  []byte{txscript.OP_0},
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	nullDataScript := func(dataLen int) []byte {
+		script, err := txscript.NullDataScript(bytes.Repeat([]byte{0xaa},
+			dataLen))
+		if err != nil {
+			t.Fatalf("NullDataScript: unexpected error: %v", err)
+		}
+		return script
+	}
+
+	tests := []struct {
+		name       string
+		pkScript   []byte
+		maxSize    int
+		isStandard bool
+	}{
+		{"no data with zero limit", []byte{txscript.OP_RETURN}, 0, true},
+		{"data with zero limit", nullDataScript(1), 0, false},
+		{"empty push with zero limit", nullDataScript(0), 0, true},
+		{"data at limit", nullDataScript(40), 40, true},
+		{"data above limit", nullDataScript(41), 40, false},
+		{"data at max carrier size", nullDataScript(80),
+			txscript.MaxDataCarrierSize, true},
+	}
+
+	for _, test := range tests {
+		tx := wire.MsgTx{
+			Version: 1,
+			TxIn:    []*wire.TxIn{&dummyTxIn},
+			TxOut:   []*wire.TxOut{{Value: 0, PkScript: test.pkScript}},
+		}
+		err := checkTransactionStandard(btcutil.NewTx(&tx), 300000,
+			time.Now(), DefaultMinRelayTxFee, 1, test.maxSize)
+		if isStandard := err == nil; isStandard != test.isStandard {
+			t.Errorf("%s: unexpected standardness - got %v, want "+
+				"%v (err: %v)", test.name, isStandard,
+				test.isStandard, err)
+			continue
+		}
+		if err != nil {
+			code, _ := extractRejectCode(err)
+			if code != wire.RejectNonstandard {
+				t.Errorf("%s: unexpected reject code - got %v, "+
+					"want %v", test.name, code,
+					wire.RejectNonstandard)
+			}
+		}
+	}
+}
+
+// TestPolicyDataCarrierSize ensures the zero value of the data carrier size
+// policy selects the default and that negative values allow no data.
+func TestPolicyDataCarrierSize(t *testing.T) {
+	tests := []struct {
+		maxSize int
+		want    int
+	}{
+		{0, txscript.MaxDataCarrierSize},
+		{-1, 0},
+		{1, 1},
+		{txscript.MaxDataCarrierSize, txscript.MaxDataCarrierSize},
+	}
+	for _, test := range tests {
+		policy := Policy{MaxDataCarrierSize: test.maxSize}
+		if got := policy.dataCarrierSize(); got != test.want {
+			t.Errorf("dataCarrierSize(%d): got %d, want %d",
+				test.maxSize, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package mnlist maintains the deterministic masternode list as of the tip of the
+main chain from the provider special transactions the blocks contain.
+
+The list tracks the registration of masternodes via ProRegTx transactions, the
+updates of their service and keys via ProUpServTx and ProUpRegTx transactions,
+their revocation via ProUpRevTx transactions and their removal once their
+collateral is spent.  Proof of service penalties are not tracked since they
+depend on the quorum commitments, so every masternode which was not revoked or
+had its operator key replaced is considered valid.
+
+# Committing With The Chain
+
+The list is stored in its own database outside of the chain database.  State
+implements the blockchain.StateCommitter interface so the changes of each block
+are committed along with the chain state, which ensures a crash while
+connecting or disconnecting a block can't leave the list and the chain at
+different blocks.  The changes of every connected block are recorded so the
+block can be disconnected again during a reorganize.
+*/
+package mnlist
@@ -0,0 +1,552 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mnlist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/btcsuite/goleveldb/leveldb"
+	"github.com/btcsuite/goleveldb/leveldb/storage"
+	"github.com/btcsuite/goleveldb/leveldb/util"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+const (
+	// outPointSize is the size of a serialized outpoint, which is the
+	// hash of the transaction followed by the output index.
+	outPointSize = chainhash.HashSize + 4
+
+	// smlEntrySize is the size of a serialized simplified masternode list
+	// entry.
+	smlEntrySize = chainhash.HashSize*2 + 16 + 2 + wire.BLSPublicKeySize +
+		20 + 1
+
+	// entrySize is the size of a serialized entry, which is the outpoint
+	// of the collateral followed by the simplified masternode list entry.
+	entrySize = outPointSize + smlEntrySize
+)
+
+var (
+	// tipKey is the key of the hash of the block the list is at.
+	tipKey = []byte("tip")
+
+	// entryKeyPrefix is the prefix of the keys of the entries, which are
+	// followed by the hash of the ProRegTx of the masternode.
+	entryKeyPrefix = []byte("e")
+
+	// collateralKeyPrefix is the prefix of the keys which map the
+	// collateral of a masternode to the hash of its ProRegTx.  They are
+	// followed by the serialized outpoint of the collateral.
+	collateralKeyPrefix = []byte("c")
+
+	// undoKeyPrefix is the prefix of the keys of the entries a block
+	// changed as they were before the block was connected.  They are
+	// followed by the hash of the block.
+	undoKeyPrefix = []byte("u")
+
+	// errNothingStaged is returned when committing without changes having
+	// been prepared.
+	errNothingStaged = errors.New("no masternode list changes are staged")
+)
+
+// Entry houses a masternode of the list.
+type Entry struct {
+	wire.SimplifiedMNListEntry
+
+	// Collateral is the outpoint of the collateral of the masternode,
+	// which removes it from the list once spent.
+	Collateral wire.OutPoint
+}
+
+// serializeEntry returns the serialization of the passed entry.
+func serializeEntry(entry *Entry) []byte {
+	var buf bytes.Buffer
+	buf.Grow(entrySize)
+	buf.Write(serializeOutPoint(&entry.Collateral))
+	_ = entry.SimplifiedMNListEntry.Serialize(&buf)
+	return buf.Bytes()
+}
+
+// deserializeEntry deserializes the passed serialized entry.
+func deserializeEntry(serialized []byte) (*Entry, error) {
+	if len(serialized) != entrySize {
+		return nil, errors.New("corrupt masternode list entry")
+	}
+
+	var entry Entry
+	copy(entry.Collateral.Hash[:], serialized)
+	entry.Collateral.Index = binary.LittleEndian.Uint32(
+		serialized[chainhash.HashSize:])
+	r := bytes.NewReader(serialized[outPointSize:])
+	if err := entry.SimplifiedMNListEntry.Deserialize(r); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// serializeOutPoint returns the serialization of the passed outpoint.
+func serializeOutPoint(op *wire.OutPoint) []byte {
+	serialized := make([]byte, outPointSize)
+	copy(serialized, op.Hash[:])
+	binary.LittleEndian.PutUint32(serialized[chainhash.HashSize:], op.Index)
+	return serialized
+}
+
+// undoRecord houses the entry of a masternode as it was before a block changed
+// it, which is nil when the block registered the masternode.
+type undoRecord struct {
+	proTxHash chainhash.Hash
+	entry     *Entry
+}
+
+// serializeUndo returns the serialization of the passed undo records.
+//
+// The serialized format is a sequence of records of the form:
+//
+//	<ProRegTx hash><has entry>[<entry>]
+//
+//	Field          Type             Size
+//	ProRegTx hash  chainhash.Hash   chainhash.HashSize
+//	has entry      byte             1
+//	entry          Entry            entrySize (only when has entry is 1)
+func serializeUndo(records []undoRecord) []byte {
+	var buf bytes.Buffer
+	for _, record := range records {
+		buf.Write(record.proTxHash[:])
+		if record.entry == nil {
+			buf.WriteByte(0)
+			continue
+		}
+		buf.WriteByte(1)
+		buf.Write(serializeEntry(record.entry))
+	}
+	return buf.Bytes()
+}
+
+// deserializeUndo deserializes the passed serialized undo records.
+func deserializeUndo(serialized []byte) ([]undoRecord, error) {
+	var records []undoRecord
+	for len(serialized) > 0 {
+		if len(serialized) < chainhash.HashSize+1 {
+			return nil, errors.New("corrupt masternode list undo record")
+		}
+
+		var record undoRecord
+		copy(record.proTxHash[:], serialized)
+		hasEntry := serialized[chainhash.HashSize] != 0
+		serialized = serialized[chainhash.HashSize+1:]
+		if hasEntry {
+			if len(serialized) < entrySize {
+				return nil, errors.New("corrupt masternode list " +
+					"undo record")
+			}
+			entry, err := deserializeEntry(serialized[:entrySize])
+			if err != nil {
+				return nil, err
+			}
+			record.entry = entry
+			serialized = serialized[entrySize:]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// dbKey returns the key made of the passed prefix and suffix.
+func dbKey(prefix, suffix []byte) []byte {
+	key := make([]byte, len(prefix)+len(suffix))
+	copy(key, prefix)
+	copy(key[len(prefix):], suffix)
+	return key
+}
+
+// stagedChanges houses the changes of a block which were prepared, but not
+// committed yet.
+type stagedChanges struct {
+	// block is the hash of the block being connected or disconnected and
+	// tip is the hash of the block the list is at afterwards.
+	block   chainhash.Hash
+	tip     chainhash.Hash
+	connect bool
+
+	// entries maps the hashes of the ProRegTxs of the masternodes the
+	// block changes to their new entries, which are nil for removed
+	// masternodes.
+	entries map[chainhash.Hash]*Entry
+
+	// collaterals maps the collaterals the block changes to the hashes of
+	// the ProRegTxs of their masternodes, which are nil for collaterals
+	// which no longer belong to a masternode.
+	collaterals map[wire.OutPoint]*chainhash.Hash
+
+	// undo houses the entries the block changes as they were before, in
+	// the order they were first changed.
+	undo []undoRecord
+}
+
+// State is the deterministic masternode list as of a block of the main chain.
+// It implements the blockchain.StateCommitter interface, so the changes of the
+// blocks are committed along with the chain state.
+//
+// Prepare, Commit and Abort must not be called concurrently, which the chain
+// ensures, while the other methods are safe for concurrent access.
+type State struct {
+	db      *leveldb.DB
+	genesis chainhash.Hash
+	staged  *stagedChanges
+}
+
+// Ensure State implements the blockchain.StateCommitter interface.
+var _ blockchain.StateCommitter = (*State)(nil)
+
+// Open opens the masternode list stored in the database at the passed path,
+// which is created at the genesis block of the passed network when it does not
+// exist.  The list is only kept in memory when the path is empty.
+func Open(path string, params *chaincfg.Params) (*State, error) {
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{db: db, genesis: *params.GenesisHash}, nil
+}
+
+// Close closes the database of the list.
+func (s *State) Close() error {
+	return s.db.Close()
+}
+
+// Entry returns the masternode registered by the ProRegTx with the passed hash
+// or nil when it is not in the list.
+func (s *State) Entry(proTxHash *chainhash.Hash) (*Entry, error) {
+	serialized, err := s.db.Get(dbKey(entryKeyPrefix, proTxHash[:]), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deserializeEntry(serialized)
+}
+
+// Entries returns all masternodes in the list ordered by the hash of their
+// ProRegTx.
+func (s *State) Entries() ([]*Entry, error) {
+	var entries []*Entry
+	iter := s.db.NewIterator(util.BytesPrefix(entryKeyPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		entry, err := deserializeEntry(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, iter.Error()
+}
+
+// Name returns the human-readable name of the state.
+//
+// This is part of the blockchain.StateCommitter interface.
+func (s *State) Name() string {
+	return "masternode list"
+}
+
+// Tip returns the hash of the block the committed list is at.
+//
+// This is part of the blockchain.StateCommitter interface.
+func (s *State) Tip() (*chainhash.Hash, error) {
+	serialized, err := s.db.Get(tipKey, nil)
+	if err == leveldb.ErrNotFound {
+		tip := s.genesis
+		return &tip, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(serialized) != chainhash.HashSize {
+		return nil, errors.New("corrupt masternode list tip")
+	}
+
+	var tip chainhash.Hash
+	copy(tip[:], serialized)
+	return &tip, nil
+}
+
+// Prepare stages the changes the passed block makes to the list when it is
+// connected to or disconnected from the end of the main chain.
+//
+// This is part of the blockchain.StateCommitter interface.
+func (s *State) Prepare(block *btcutil.Block, connect bool) error {
+	tip, err := s.Tip()
+	if err != nil {
+		return err
+	}
+
+	prevHash := block.MsgBlock().Header.PrevBlock
+	staged := &stagedChanges{
+		block:       *block.Hash(),
+		connect:     connect,
+		entries:     make(map[chainhash.Hash]*Entry),
+		collaterals: make(map[wire.OutPoint]*chainhash.Hash),
+	}
+	if connect {
+		if prevHash != *tip {
+			return fmt.Errorf("connected block %v does not extend "+
+				"block %v", block.Hash(), tip)
+		}
+		staged.tip = *block.Hash()
+
+		for _, tx := range block.Transactions() {
+			if err := s.connectTransaction(staged, tx); err != nil {
+				return err
+			}
+		}
+	} else {
+		if *block.Hash() != *tip {
+			return fmt.Errorf("disconnected block %v is not block %v",
+				block.Hash(), tip)
+		}
+		staged.tip = prevHash
+
+		// Restore the entries the block changed.  Blocks which did
+		// not change any entries have no undo records.
+		key := dbKey(undoKeyPrefix, block.Hash()[:])
+		serialized, err := s.db.Get(key, nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		records, err := deserializeUndo(serialized)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			err := s.setEntry(staged, &record.proTxHash, record.entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	s.staged = staged
+	return nil
+}
+
+// connectTransaction stages the changes the passed transaction makes to the
+// list.  The provider special transactions register and update masternodes,
+// while spending the collateral of a masternode removes it.
+func (s *State) connectTransaction(staged *stagedChanges, tx *btcutil.Tx) error {
+	msgTx := tx.MsgTx()
+	if !blockchain.IsCoinBase(tx) {
+		for _, txIn := range msgTx.TxIn {
+			proTxHash, err := s.collateralOwner(staged,
+				&txIn.PreviousOutPoint)
+			if err != nil {
+				return err
+			}
+			if proTxHash == nil {
+				continue
+			}
+			if err := s.setEntry(staged, proTxHash, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !msgTx.IsSpecial() {
+		return nil
+	}
+	payload, err := msgTx.Payload()
+	if err != nil {
+		// The block was accepted by the chain, so a payload which
+		// can't be decoded is of a type or version the list does not
+		// track.
+		return nil
+	}
+
+	if proRegTx, ok := payload.(*wire.ProRegTx); ok {
+		// The collateral is an output of the ProRegTx itself when the
+		// hash of its outpoint is zero.
+		entry := &Entry{Collateral: proRegTx.CollateralOutpoint}
+		if entry.Collateral.Hash == (chainhash.Hash{}) {
+			entry.Collateral.Hash = *tx.Hash()
+		}
+		entry.ProRegTxHash = *tx.Hash()
+		entry.IP = proRegTx.IP
+		entry.Port = proRegTx.Port
+		entry.PubKeyOperator = proRegTx.PubKeyOperator
+		entry.KeyIDVoting = proRegTx.KeyIDVoting
+		entry.IsValid = true
+		return s.setEntry(staged, tx.Hash(), entry)
+	}
+
+	var proTxHash *chainhash.Hash
+	switch p := payload.(type) {
+	case *wire.ProUpServTx:
+		proTxHash = &p.ProTxHash
+	case *wire.ProUpRegTx:
+		proTxHash = &p.ProTxHash
+	case *wire.ProUpRevTx:
+		proTxHash = &p.ProTxHash
+	default:
+		return nil
+	}
+	entry, err := s.fetchEntry(staged, proTxHash)
+	if err != nil || entry == nil {
+		return err
+	}
+
+	updated := *entry
+	switch p := payload.(type) {
+	case *wire.ProUpServTx:
+		// Updating the service revives a masternode whose operator
+		// key was replaced.
+		updated.IP = p.IP
+		updated.Port = p.Port
+		updated.IsValid = true
+
+	case *wire.ProUpRegTx:
+		// Replacing the operator key resets the service, so the
+		// masternode is invalid until the new operator updates it.
+		if p.PubKeyOperator != updated.PubKeyOperator {
+			updated.IP = net.IPv6zero
+			updated.Port = 0
+			updated.PubKeyOperator = p.PubKeyOperator
+			updated.IsValid = false
+		}
+		updated.KeyIDVoting = p.KeyIDVoting
+
+	case *wire.ProUpRevTx:
+		updated.IP = net.IPv6zero
+		updated.Port = 0
+		updated.PubKeyOperator = [wire.BLSPublicKeySize]byte{}
+		updated.IsValid = false
+	}
+	return s.setEntry(staged, proTxHash, &updated)
+}
+
+// fetchEntry returns the masternode registered by the ProRegTx with the passed
+// hash including the staged changes or nil when it is not in the list.
+func (s *State) fetchEntry(staged *stagedChanges, proTxHash *chainhash.Hash) (*Entry, error) {
+	if entry, ok := staged.entries[*proTxHash]; ok {
+		return entry, nil
+	}
+	return s.Entry(proTxHash)
+}
+
+// collateralOwner returns the hash of the ProRegTx of the masternode the
+// passed outpoint is the collateral of including the staged changes or nil
+// when it is not the collateral of any masternode.
+func (s *State) collateralOwner(staged *stagedChanges, op *wire.OutPoint) (*chainhash.Hash, error) {
+	if proTxHash, ok := staged.collaterals[*op]; ok {
+		return proTxHash, nil
+	}
+
+	key := dbKey(collateralKeyPrefix, serializeOutPoint(op))
+	serialized, err := s.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(serialized) != chainhash.HashSize {
+		return nil, errors.New("corrupt masternode list collateral")
+	}
+
+	var proTxHash chainhash.Hash
+	copy(proTxHash[:], serialized)
+	return &proTxHash, nil
+}
+
+// setEntry stages the passed entry as the new entry of the masternode
+// registered by the ProRegTx with the passed hash, where a nil entry removes
+// the masternode.
+func (s *State) setEntry(staged *stagedChanges, proTxHash *chainhash.Hash, entry *Entry) error {
+	prev, err := s.fetchEntry(staged, proTxHash)
+	if err != nil {
+		return err
+	}
+	if _, ok := staged.entries[*proTxHash]; !ok {
+		staged.undo = append(staged.undo, undoRecord{
+			proTxHash: *proTxHash,
+			entry:     prev,
+		})
+	}
+
+	if prev != nil {
+		staged.collaterals[prev.Collateral] = nil
+	}
+	if entry != nil {
+		owner := *proTxHash
+		staged.collaterals[entry.Collateral] = &owner
+	}
+	staged.entries[*proTxHash] = entry
+	return nil
+}
+
+// Commit applies the staged changes to the database.
+//
+// This is part of the blockchain.StateCommitter interface.
+func (s *State) Commit() error {
+	staged := s.staged
+	if staged == nil {
+		return errNothingStaged
+	}
+	s.staged = nil
+
+	batch := new(leveldb.Batch)
+	for op, proTxHash := range staged.collaterals {
+		key := dbKey(collateralKeyPrefix, serializeOutPoint(&op))
+		if proTxHash == nil {
+			batch.Delete(key)
+			continue
+		}
+		batch.Put(key, proTxHash[:])
+	}
+	for proTxHash, entry := range staged.entries {
+		key := dbKey(entryKeyPrefix, proTxHash[:])
+		if entry == nil {
+			batch.Delete(key)
+			continue
+		}
+		batch.Put(key, serializeEntry(entry))
+	}
+
+	// Record the entries the block changed so it can be disconnected
+	// again, and remove the record once it is.
+	undoKey := dbKey(undoKeyPrefix, staged.block[:])
+	switch {
+	case !staged.connect:
+		batch.Delete(undoKey)
+	case len(staged.undo) > 0:
+		batch.Put(undoKey, serializeUndo(staged.undo))
+	}
+	batch.Put(tipKey, staged.tip[:])
+
+	return s.db.Write(batch, nil)
+}
+
+// Abort discards the staged changes.
+//
+// This is part of the blockchain.StateCommitter interface.
+func (s *State) Abort() {
+	s.staged = nil
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mnlist
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// newSpecialTx returns a transaction spending the passed outpoint which carries
+// the passed payload.
+func newSpecialTx(t *testing.T, spend wire.OutPoint, payload wire.SpecialTxPayload) *wire.MsgTx {
+	t.Helper()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&spend, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000e8, nil))
+	tx.AddTxOut(wire.NewTxOut(1000e8, nil))
+	if payload != nil {
+		if err := tx.SetPayload(payload); err != nil {
+			t.Fatalf("SetPayload: unexpected error: %v", err)
+		}
+	}
+	return tx
+}
+
+// newBlock returns a block extending the block with the passed hash which
+// contains a coinbase followed by the passed transactions.
+func newBlock(prevHash *chainhash.Hash, height int32, txns ...*wire.MsgTx) *btcutil.Block {
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), []byte{byte(height)}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(500e8, nil))
+
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{PrevBlock: *prevHash})
+	msgBlock.AddTransaction(coinbase)
+	for _, tx := range txns {
+		msgBlock.AddTransaction(tx)
+	}
+	return btcutil.NewBlock(msgBlock)
+}
+
+// fetchEntries returns the entries of the passed state keyed by the hash of
+// their ProRegTx.
+func fetchEntries(t *testing.T, s *State) map[chainhash.Hash]Entry {
+	t.Helper()
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatalf("Entries: unexpected error: %v", err)
+	}
+	byHash := make(map[chainhash.Hash]Entry, len(entries))
+	for _, entry := range entries {
+		byHash[entry.ProRegTxHash] = *entry
+	}
+	return byHash
+}
+
+// apply prepares and commits the changes of the passed block.
+func apply(t *testing.T, s *State, block *btcutil.Block, connect bool) {
+	t.Helper()
+
+	if err := s.Prepare(block, connect); err != nil {
+		t.Fatalf("Prepare: unexpected error: %v", err)
+	}
+	if err := s.Commit(); err != nil {
+		t.Fatalf("Commit: unexpected error: %v", err)
+	}
+	tip, err := s.Tip()
+	if err != nil {
+		t.Fatalf("Tip: unexpected error: %v", err)
+	}
+	want := *block.Hash()
+	if !connect {
+		want = block.MsgBlock().Header.PrevBlock
+	}
+	if *tip != want {
+		t.Fatalf("list at block %v, want %v", tip, want)
+	}
+}
+
+// TestState ensures the provider special transactions of connected blocks are
+// applied to the list, that spending a collateral removes its masternode and
+// that disconnecting the blocks restores the previous lists.
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.RegressionNetParams
+	dbPath := filepath.Join(t.TempDir(), "mnlist")
+	s, err := Open(dbPath, params)
+	if err != nil {
+		t.Fatalf("Open: unexpected error: %v", err)
+	}
+	defer func() {
+		s.Close()
+	}()
+	if tip, err := s.Tip(); err != nil || *tip != *params.GenesisHash {
+		t.Fatalf("new list at block %v (%v), want genesis", tip, err)
+	}
+
+	// Register a masternode with the collateral in its ProRegTx and one
+	// with an external collateral.
+	extCollateral := wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 3}
+	regA := newSpecialTx(t, wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		&wire.ProRegTx{
+			Version:            1,
+			CollateralOutpoint: wire.OutPoint{Index: 1},
+			IP:                 net.ParseIP("10.0.0.1"),
+			Port:               9999,
+			PubKeyOperator:     [wire.BLSPublicKeySize]byte{0xa1},
+			KeyIDVoting:        [20]byte{0xa2},
+		})
+	regB := newSpecialTx(t, wire.OutPoint{Hash: chainhash.Hash{0x03}},
+		&wire.ProRegTx{
+			Version:            1,
+			CollateralOutpoint: extCollateral,
+			IP:                 net.ParseIP("10.0.0.2"),
+			Port:               9999,
+			PubKeyOperator:     [wire.BLSPublicKeySize]byte{0xb1},
+			KeyIDVoting:        [20]byte{0xb2},
+		})
+	hashA, hashB := regA.TxHash(), regB.TxHash()
+	block1 := newBlock(params.GenesisHash, 1, regA, regB)
+	apply(t, s, block1, true)
+	list1 := fetchEntries(t, s)
+	if len(list1) != 2 || !list1[hashA].IsValid || !list1[hashB].IsValid {
+		t.Fatalf("unexpected list after registrations: %+v", list1)
+	}
+	if list1[hashA].Collateral != *wire.NewOutPoint(&hashA, 1) ||
+		list1[hashB].Collateral != extCollateral {
+
+		t.Fatalf("unexpected collaterals %v and %v",
+			list1[hashA].Collateral, list1[hashB].Collateral)
+	}
+
+	// Update the service of the first masternode and replace the operator
+	// key of the second one, which invalidates it.
+	upServ := newSpecialTx(t, wire.OutPoint{Hash: chainhash.Hash{0x04}},
+		&wire.ProUpServTx{
+			Version:   1,
+			ProTxHash: hashA,
+			IP:        net.ParseIP("10.0.0.3"),
+			Port:      19999,
+		})
+	upReg := newSpecialTx(t, wire.OutPoint{Hash: chainhash.Hash{0x05}},
+		&wire.ProUpRegTx{
+			Version:        1,
+			ProTxHash:      hashB,
+			PubKeyOperator: [wire.BLSPublicKeySize]byte{0xb3},
+			KeyIDVoting:    [20]byte{0xb4},
+		})
+	block2 := newBlock(block1.Hash(), 2, upServ, upReg)
+	apply(t, s, block2, true)
+	list2 := fetchEntries(t, s)
+	if entry := list2[hashA]; entry.Port != 19999 ||
+		!entry.IP.Equal(net.ParseIP("10.0.0.3")) || !entry.IsValid {
+
+		t.Fatalf("service not updated: %+v", entry)
+	}
+	if entry := list2[hashB]; entry.IsValid || entry.Port != 0 ||
+		entry.PubKeyOperator[0] != 0xb3 || entry.KeyIDVoting[0] != 0xb4 {
+
+		t.Fatalf("operator key not replaced: %+v", entry)
+	}
+
+	// Spend the collateral of the first masternode and revoke the second
+	// one.
+	spend := newSpecialTx(t, *wire.NewOutPoint(&hashA, 1), nil)
+	upRev := newSpecialTx(t, wire.OutPoint{Hash: chainhash.Hash{0x06}},
+		&wire.ProUpRevTx{Version: 1, ProTxHash: hashB})
+	block3 := newBlock(block2.Hash(), 3, spend, upRev)
+	apply(t, s, block3, true)
+	list3 := fetchEntries(t, s)
+	if _, ok := list3[hashA]; ok || len(list3) != 1 {
+		t.Fatalf("masternode with spent collateral not removed: %+v",
+			list3)
+	}
+	if entry := list3[hashB]; entry.IsValid ||
+		entry.PubKeyOperator != [wire.BLSPublicKeySize]byte{} {
+
+		t.Fatalf("masternode not revoked: %+v", entry)
+	}
+
+	// Blocks which don't extend the list or aren't its tip can't be
+	// prepared, and aborted changes are discarded.
+	if err := s.Prepare(block2, true); err == nil {
+		t.Fatal("Prepare: connected block which does not extend the list")
+	}
+	if err := s.Prepare(block2, false); err == nil {
+		t.Fatal("Prepare: disconnected block which is not the tip")
+	}
+	if err := s.Prepare(newBlock(block3.Hash(), 4), true); err != nil {
+		t.Fatalf("Prepare: unexpected error: %v", err)
+	}
+	s.Abort()
+	if err := s.Commit(); err != errNothingStaged {
+		t.Fatalf("Commit: unexpected error after abort: %v", err)
+	}
+
+	// The list persists across reopening the database.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	s, err = Open(dbPath, params)
+	if err != nil {
+		t.Fatalf("Open: unexpected error: %v", err)
+	}
+	if got := fetchEntries(t, s); !reflect.DeepEqual(got, list3) {
+		t.Fatalf("reopened list %+v, want %+v", got, list3)
+	}
+
+	// Disconnecting the blocks restores the lists as of their parents.
+	apply(t, s, block3, false)
+	if got := fetchEntries(t, s); !reflect.DeepEqual(got, list2) {
+		t.Fatalf("list after disconnecting block 3 %+v, want %+v", got,
+			list2)
+	}
+	apply(t, s, block2, false)
+	if got := fetchEntries(t, s); !reflect.DeepEqual(got, list1) {
+		t.Fatalf("list after disconnecting block 2 %+v, want %+v", got,
+			list1)
+	}
+	apply(t, s, block1, false)
+	if got := fetchEntries(t, s); len(got) != 0 {
+		t.Fatalf("list after disconnecting block 1 %+v, want empty", got)
+	}
+}
@@ -9,5 +9,9 @@ download, keep the chain and unconfirmed transaction pool in sync, and announce
 new blocks connected to the chain. Currently the sync manager selects a single
 sync peer that it downloads all blocks from until it is up to date with the
 longest chain the sync peer is aware of.
+
+Once the chain is current after startup, the sync manager also requests the
+mempools of a few peers so a restarted node quickly learns about the unconfirmed
+transactions it missed while it was offline.
 */
 package netsync
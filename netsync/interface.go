@@ -38,4 +38,14 @@ type Config struct {
 	MaxPeers           int
 
 	FeeEstimator *mempool.FeeEstimator
+
+	// MempoolSyncPeers is the number of peers whose mempool is requested
+	// once the chain is current after startup.  Zero disables the mempool
+	// sync.
+	MempoolSyncPeers int
+
+	// MinRelayTxFee is the minimum fee rate in duffs per 1000 bytes of
+	// transactions accepted to the mempool.  It is sent in a feefilter
+	// message to the peers whose mempool is requested.
+	MinRelayTxFee btcutil.Amount
 }
@@ -152,6 +152,9 @@ type peerSyncState struct {
 	requestQueue    []*wire.InvVect
 	requestedTxns   map[chainhash.Hash]struct{}
 	requestedBlocks map[chainhash.Hash]struct{}
+
+	// mempoolRequested is set once the mempool of the peer was requested.
+	mempoolRequested bool
 }
 
 // limitAdd is a helper function for maps that require a maximum limit by
@@ -206,6 +209,12 @@ type SyncManager struct {
 
 	// An optional fee estimator.
 	feeEstimator *mempool.FeeEstimator
+
+	// The following fields are used for the startup mempool sync.
+	mempoolSyncPeers     int
+	mempoolSyncRequested int
+	lastMempoolSync      time.Time
+	minRelayTxFee        btcutil.Amount
 }
 
 // resetHeaderState sets the headers-first mode state to values appropriate for
@@ -357,19 +366,25 @@ func (sm *SyncManager) startSync() {
 		// and fully validate them.  Finally, regression test mode does
 		// not support the headers-first approach so do normal block
 		// downloads when in regression test mode.
+		//
+		// The headers up to the checkpoint are not downloaded when they
+		// have been bootstrapped already.
+		sm.syncPeer = bestPeer
 		if sm.nextCheckpoint != nil &&
 			best.Height < sm.nextCheckpoint.Height &&
 			sm.chainParams != &chaincfg.RegressionNetParams {
 
-			bestPeer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
 			sm.headersFirstMode = true
-			log.Infof("Downloading headers for blocks %d to "+
-				"%d from peer %s", best.Height+1,
-				sm.nextCheckpoint.Height, bestPeer.Addr())
+			if !sm.fetchBootstrapHeaderBlocks() {
+				bestPeer.PushGetHeadersMsg(locator,
+					sm.nextCheckpoint.Hash)
+				log.Infof("Downloading headers for blocks %d "+
+					"to %d from peer %s", best.Height+1,
+					sm.nextCheckpoint.Height, bestPeer.Addr())
+			}
 		} else {
 			bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 		}
-		sm.syncPeer = bestPeer
 
 		// Reset the last progress time now that we have a non-nil
 		// syncPeer to avoid instantly detecting it as stalled in the
@@ -440,6 +455,8 @@ func (sm *SyncManager) handleNewPeerMsg(peer *peerpkg.Peer) {
 	if isSyncCandidate && sm.syncPeer == nil {
 		sm.startSync()
 	}
+
+	sm.syncMempool()
 }
 
 // handleStallSample will switch to a new sync peer if the current one has
@@ -451,6 +468,10 @@ func (sm *SyncManager) handleStallSample() {
 		return
 	}
 
+	// The mempool sync waits for the chain to become current, so check
+	// whether it can proceed along with the stall samples.
+	sm.syncMempool()
+
 	// If we don't have an active sync peer, exit early.
 	if sm.syncPeer == nil {
 		return
@@ -603,6 +624,22 @@ func (sm *SyncManager) handleTxMsg(tmsg *txMsg) {
 	delete(sm.requestedTxns, *txHash)
 
 	if err != nil {
+		// Transactions of peers with the forcerelay permission are
+		// relayed again when they are already in the memory pool.
+		code, _ := mempool.ErrToRejectErr(err)
+		if code == wire.RejectDuplicate &&
+			peer.HasPermission(peerpkg.PermissionForceRelay) {
+
+			txDesc, err := sm.txMemPool.FetchTxDesc(txHash)
+			if err == nil {
+				log.Debugf("Force relaying transaction %v from %s",
+					txHash, peer)
+				iv := wire.NewInvVect(wire.InvTypeTx, txHash)
+				sm.peerNotifier.RelayInventory(iv, txDesc)
+				return
+			}
+		}
+
 		// Do not request this transaction again until a new block
 		// has been processed.
 		limitAdd(sm.rejectedTxns, *txHash, maxRejectedTxns)
@@ -690,7 +727,7 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 			firstNode := firstNodeEl.Value.(*headerNode)
 			if blockHash.IsEqual(firstNode.hash) {
 				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(sm.nextCheckpoint.Hash) {
+				if firstNode.height == sm.nextCheckpoint.Height {
 					isCheckpointBlock = true
 				} else {
 					sm.headerList.Remove(firstNodeEl)
@@ -829,6 +866,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	prevHash := sm.nextCheckpoint.Hash
 	sm.nextCheckpoint = sm.findNextHeaderCheckpoint(prevHeight)
 	if sm.nextCheckpoint != nil {
+		if sm.fetchBootstrapHeaderBlocks() {
+			return
+		}
 		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
 		err := peer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
 		if err != nil {
@@ -857,6 +897,45 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	}
 }
 
+// fetchBootstrapHeaderBlocks adds the bootstrapped headers which link the last
+// entry of the header list to the next checkpoint to the header list and
+// requests their blocks from the sync peer, which avoids downloading the
+// headers from it.  It returns false when the bootstrapped headers don't reach
+// the next checkpoint, in which case the header list is not modified.
+func (sm *SyncManager) fetchBootstrapHeaderBlocks() bool {
+	prevNodeEl := sm.headerList.Back()
+	if prevNodeEl == nil {
+		return false
+	}
+	prevNode := prevNodeEl.Value.(*headerNode)
+	hashes := sm.chain.BootstrapHeaderHashes(prevNode.hash,
+		sm.nextCheckpoint.Hash)
+	if len(hashes) == 0 {
+		return false
+	}
+
+	for i := range hashes {
+		node := headerNode{
+			height: prevNode.height + int32(i) + 1,
+			hash:   &hashes[i],
+		}
+		e := sm.headerList.PushBack(&node)
+		if sm.startHeader == nil {
+			sm.startHeader = e
+		}
+	}
+
+	// The first entry of the list is only used to link the headers, see
+	// handleHeadersMsg.
+	sm.headerList.Remove(sm.headerList.Front())
+	log.Infof("Using %d bootstrapped block headers up to checkpoint at "+
+		"height %d: Fetching blocks", len(hashes),
+		sm.nextCheckpoint.Height)
+	sm.progressLogger.SetLastLogTime(time.Now())
+	sm.fetchHeaderBlocks()
+	return true
+}
+
 // fetchHeaderBlocks creates and sends a request to the syncPeer for the next
 // list of blocks to be downloaded based on the current list of headers.
 func (sm *SyncManager) fetchHeaderBlocks() {
@@ -972,8 +1051,10 @@ func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 		}
 
 		// Verify the header at the next checkpoint height matches.
+		// Checkpoints use the X11 hashes Dash identifies blocks by.
 		if node.height == sm.nextCheckpoint.Height {
-			if node.hash.IsEqual(sm.nextCheckpoint.Hash) {
+			powHash := blockHeader.PowHash()
+			if powHash.IsEqual(sm.nextCheckpoint.Hash) {
 				receivedCheckpoint = true
 				log.Infof("Verified downloaded block "+
 					"header against checkpoint at height "+
@@ -1618,19 +1699,21 @@ func (sm *SyncManager) Pause() chan<- struct{} {
 // block, tx, and inv updates.
 func New(config *Config) (*SyncManager, error) {
 	sm := SyncManager{
-		peerNotifier:    config.PeerNotifier,
-		chain:           config.Chain,
-		txMemPool:       config.TxMemPool,
-		chainParams:     config.ChainParams,
-		rejectedTxns:    make(map[chainhash.Hash]struct{}),
-		requestedTxns:   make(map[chainhash.Hash]struct{}),
-		requestedBlocks: make(map[chainhash.Hash]struct{}),
-		peerStates:      make(map[*peerpkg.Peer]*peerSyncState),
-		progressLogger:  newBlockProgressLogger("Processed", log),
-		msgChan:         make(chan interface{}, config.MaxPeers*3),
-		headerList:      list.New(),
-		quit:            make(chan struct{}),
-		feeEstimator:    config.FeeEstimator,
+		peerNotifier:     config.PeerNotifier,
+		chain:            config.Chain,
+		txMemPool:        config.TxMemPool,
+		chainParams:      config.ChainParams,
+		rejectedTxns:     make(map[chainhash.Hash]struct{}),
+		requestedTxns:    make(map[chainhash.Hash]struct{}),
+		requestedBlocks:  make(map[chainhash.Hash]struct{}),
+		peerStates:       make(map[*peerpkg.Peer]*peerSyncState),
+		progressLogger:   newBlockProgressLogger("Processed", log),
+		msgChan:          make(chan interface{}, config.MaxPeers*3),
+		headerList:       list.New(),
+		quit:             make(chan struct{}),
+		feeEstimator:     config.FeeEstimator,
+		mempoolSyncPeers: config.MempoolSyncPeers,
+		minRelayTxFee:    config.MinRelayTxFee,
 	}
 
 	best := sm.chain.BestSnapshot()
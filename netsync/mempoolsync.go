@@ -0,0 +1,70 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"time"
+
+	peerpkg "github.com/dashpay/dashd-go/peer"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// mempoolSyncInterval is the minimum time between the mempool requests of the
+// startup mempool sync.  It spreads the transactions announced in response to
+// the requests over time instead of requesting them from all peers at once.
+const mempoolSyncInterval = 30 * time.Second
+
+// mempoolSyncCandidate returns whether the mempool of the peer may be
+// requested.  Peers must support the mempool message and advertise bloom
+// filtering, without which they refuse to serve mempool requests, and must
+// relay transactions to us.
+func (sm *SyncManager) mempoolSyncCandidate(peer *peerpkg.Peer) bool {
+	if !peer.Connected() || peer.BlocksOnly() {
+		return false
+	}
+	features := peer.Features()
+	return features.Mempool && features.BloomFilter
+}
+
+// syncMempool requests the mempool of the next candidate peer during the
+// startup mempool sync, which lets a restarted node learn about the unconfirmed
+// transactions it missed.  The mempools of up to Config.MempoolSyncPeers peers
+// are requested once the chain is current, one peer at a time with at least
+// mempoolSyncInterval between the requests.  The transactions announced in
+// response are requested, deduplicated and admitted to the mempool like any
+// other announced transactions.
+//
+// This function MUST be called from the blockHandler goroutine.
+func (sm *SyncManager) syncMempool() {
+	if sm.mempoolSyncRequested >= sm.mempoolSyncPeers || sm.headersFirstMode ||
+		!sm.current() {
+
+		return
+	}
+	if time.Since(sm.lastMempoolSync) < mempoolSyncInterval {
+		return
+	}
+
+	for peer, state := range sm.peerStates {
+		if state.mempoolRequested || !sm.mempoolSyncCandidate(peer) {
+			continue
+		}
+
+		// Ask the peer to not announce transactions which pay less than
+		// our minimum relay fee since they would be rejected anyways.
+		if sm.minRelayTxFee > 0 && peer.Features().FeeFilter {
+			feeFilter := wire.NewMsgFeeFilter(int64(sm.minRelayTxFee))
+			peer.QueueMessage(feeFilter, nil)
+		}
+		peer.QueueMessage(wire.NewMsgMemPool(), nil)
+
+		state.mempoolRequested = true
+		sm.mempoolSyncRequested++
+		sm.lastMempoolSync = time.Now()
+		log.Infof("Requesting mempool from peer %s (%d of %d)", peer,
+			sm.mempoolSyncRequested, sm.mempoolSyncPeers)
+		return
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/blockchain"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/database"
+	_ "github.com/dashpay/dashd-go/database/ffldb"
+	peerpkg "github.com/dashpay/dashd-go/peer"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// fixedTimeSource is a blockchain.MedianTimeSource which always reports the
+// same adjusted time.
+type fixedTimeSource struct {
+	now time.Time
+}
+
+func (s fixedTimeSource) AdjustedTime() time.Time         { return s.now }
+func (s fixedTimeSource) AddTimeSample(string, time.Time) {}
+func (s fixedTimeSource) Offset() time.Duration           { return 0 }
+
+// mempoolSyncTestChain returns a regression test chain which is current since
+// its time source reports the time of the genesis block.
+func mempoolSyncTestChain(t *testing.T) *blockchain.BlockChain {
+	params := chaincfg.RegressionNetParams
+	db, err := database.Create("ffldb", filepath.Join(t.TempDir(), "db"),
+		params.Net)
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: &params,
+		TimeSource: fixedTimeSource{
+			params.GenesisBlock.Header.Timestamp,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create chain: %v", err)
+	}
+	return chain
+}
+
+// mempoolSyncTestPeer returns an outbound peer which completed the version
+// handshake with a fake remote peer advertising the passed services.  The
+// commands of the messages the peer sends other than version and verack are
+// delivered on the returned channel.
+func mempoolSyncTestPeer(t *testing.T, services wire.ServiceFlag, blocksOnly bool) (*peerpkg.Peer, <-chan string) {
+	params := &chaincfg.RegressionNetParams
+	p, err := peerpkg.NewOutboundPeer(&peerpkg.Config{
+		ChainParams:    params,
+		AllowSelfConns: true,
+		BlocksOnly:     blocksOnly,
+	}, "10.0.0.2:19899")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected error: %v", err)
+	}
+
+	localConn, remoteConn := net.Pipe()
+	t.Cleanup(func() {
+		p.Disconnect()
+		remoteConn.Close()
+	})
+
+	// Answer the version message of the peer and forward the commands of
+	// the other messages it sends.
+	commands := make(chan string, 10)
+	go func() {
+		for {
+			_, msg, _, err := wire.ReadMessageN(remoteConn,
+				wire.ProtocolVersion, params.Net)
+			if err != nil {
+				return
+			}
+			switch msg.(type) {
+			case *wire.MsgVersion:
+				me := wire.NewNetAddressIPPort(net.ParseIP("10.0.0.2"),
+					19899, services)
+				you := wire.NewNetAddressIPPort(net.ParseIP("10.0.0.1"),
+					19899, 0)
+				version := wire.NewMsgVersion(me, you, 1, 0)
+				version.Services = services
+				wire.WriteMessage(remoteConn, version,
+					wire.ProtocolVersion, params.Net)
+				wire.WriteMessage(remoteConn, wire.NewMsgVerAck(),
+					wire.ProtocolVersion, params.Net)
+			case *wire.MsgVerAck:
+			default:
+				commands <- msg.Command()
+			}
+		}
+	}()
+
+	p.AssociateConnection(localConn)
+	deadline := time.Now().Add(time.Second)
+	for !p.VerAckReceived() {
+		if time.Now().After(deadline) {
+			t.Fatal("version handshake did not complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return p, commands
+}
+
+// TestSyncMempool ensures the startup mempool sync only requests the mempools
+// of candidate peers, one peer at a time at least mempoolSyncInterval apart,
+// and stops once the mempools of the configured number of peers were
+// requested.
+func TestSyncMempool(t *testing.T) {
+	const numRequested = 2
+
+	// The package logger is only set up by callers.
+	DisableLog()
+
+	sm := &SyncManager{
+		chain:            mempoolSyncTestChain(t),
+		peerStates:       make(map[*peerpkg.Peer]*peerSyncState),
+		mempoolSyncPeers: numRequested,
+	}
+	if !sm.current() {
+		t.Fatal("test chain is not current")
+	}
+
+	// Peers which don't advertise bloom filtering refuse to serve mempool
+	// requests and blocks only peers don't relay transactions.
+	bloom := wire.SFNodeNetwork | wire.SFNodeBloom
+	noBloom, _ := mempoolSyncTestPeer(t, wire.SFNodeNetwork, false)
+	blocksOnly, _ := mempoolSyncTestPeer(t, bloom, true)
+	candidates := make(map[*peerpkg.Peer]<-chan string)
+	for i := 0; i < numRequested+1; i++ {
+		p, commands := mempoolSyncTestPeer(t, bloom, false)
+		candidates[p] = commands
+	}
+	for _, p := range []*peerpkg.Peer{noBloom, blocksOnly} {
+		if sm.mempoolSyncCandidate(p) {
+			t.Fatalf("peer with services %v (blocks only %v) is a "+
+				"candidate", p.Services(), p.BlocksOnly())
+		}
+		sm.peerStates[p] = &peerSyncState{}
+	}
+	for p := range candidates {
+		if !sm.mempoolSyncCandidate(p) {
+			t.Fatalf("peer with services %v is not a candidate",
+				p.Services())
+		}
+		sm.peerStates[p] = &peerSyncState{}
+	}
+
+	// requested returns the peers whose mempool was requested.
+	requested := func() []*peerpkg.Peer {
+		var peers []*peerpkg.Peer
+		for p, state := range sm.peerStates {
+			if state.mempoolRequested {
+				peers = append(peers, p)
+			}
+		}
+		return peers
+	}
+
+	// No mempool is requested while in headers-first mode.
+	sm.headersFirstMode = true
+	sm.syncMempool()
+	if got := requested(); len(got) != 0 {
+		t.Fatalf("requested %d mempools in headers-first mode", len(got))
+	}
+	sm.headersFirstMode = false
+
+	for i := 1; i <= numRequested; i++ {
+		sm.syncMempool()
+		peers := requested()
+		if len(peers) != i || sm.mempoolSyncRequested != i {
+			t.Fatalf("requested %d mempools (count %d), want %d",
+				len(peers), sm.mempoolSyncRequested, i)
+		}
+
+		// Requesting again right away does nothing.
+		sm.syncMempool()
+		if got := requested(); len(got) != i {
+			t.Fatalf("requested %d mempools within the interval, "+
+				"want %d", len(got), i)
+		}
+
+		// Pretend the interval passed.
+		sm.lastMempoolSync = time.Now().Add(-mempoolSyncInterval)
+	}
+
+	// Only candidates are requested and they are sent a mempool message.
+	for _, p := range requested() {
+		commands, ok := candidates[p]
+		if !ok {
+			t.Fatalf("requested mempool of non-candidate peer with "+
+				"services %v", p.Services())
+		}
+		timeout := time.After(time.Second)
+		for sent := false; !sent; {
+			select {
+			case cmd := <-commands:
+				sent = cmd == wire.CmdMemPool
+			case <-timeout:
+				t.Fatal("peer did not send a mempool message")
+			}
+		}
+	}
+
+	// No more mempools are requested once the limit is reached.
+	sm.syncMempool()
+	if got := requested(); len(got) != numRequested {
+		t.Fatalf("requested %d mempools, want %d", len(got),
+			numRequested)
+	}
+}
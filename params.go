@@ -62,6 +62,14 @@ var sigNetParams = params{
 	rpcPort: "38332",
 }
 
+// devNetParams contains parameters specific to devnets.  The chain parameters
+// are replaced by the ones of the devnet selected by name when the devnet is
+// activated.
+var devNetParams = params{
+	Params:  &chaincfg.DevNetParams,
+	rpcPort: "19798",
+}
+
 // netName returns the name used when referring to a bitcoin network.  At the
 // time of writing, btcd currently places blocks for testnet version 3 in the
 // data and log directory "testnet", which does not match the Name field of the
@@ -72,9 +80,12 @@ var sigNetParams = params{
 // "testnet3" is planned for the future, at which point this function can be
 // removed and the network parameter's name used instead.
 func netName(chainParams *params) string {
-	switch chainParams.Net {
-	case wire.TestNet3:
+	switch {
+	case chainParams.Net == wire.TestNet3:
 		return "testnet"
+	case chainParams.DevNetName != "":
+		// Each devnet is a separate chain.
+		return "devnet-" + chainParams.DevNetName
 	default:
 		return chainParams.Name
 	}
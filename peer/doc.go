@@ -40,6 +40,13 @@ A quick overview of the major features peer provides are as follows:
     specify the related flag to signal support
   - Disconnects the peer when the protocol version is high enough
   - Does not invoke the related callbacks for older protocol versions
+  - Negotiated features, including support for the Dash LLMQ, chainlock and
+    InstantSend messages, and dropping of queued messages the remote peer does
+    not support at the negotiated protocol version
+  - Dash masternode authentication with mnauth messages after the handshake
+    using a caller provided BLS operator key and verifier
+  - Stall detection for requests which expect a response, including the Dash
+    masternode list and quorum rotation info requests
   - Snapshottable peer statistics such as the total number of bytes read and
     written, the remote address, user agent, and negotiated protocol version
   - Helper functions pushing addresses, getblocks, getheaders, and reject
@@ -66,6 +73,11 @@ This provides high flexibility for things such as connecting via proxies, acting
 as a proxy, creating bridge peers, choosing whether to listen for inbound peers,
 etc.
 
+Connections are typically established over TCP, however, the Transport
+interface allows other transports that provide a reliable ordered byte stream,
+such as an experimental QUIC transport for private test networks, to be
+registered with RegisterTransport and selected by name with LookupTransport.
+
 NewOutboundPeer and NewInboundPeer functions must be followed by calling Connect
 with a net.Conn instance to the peer.  This will start all async I/O goroutines
 and initiate the protocol negotiation process.  Once finished with the peer call
@@ -81,7 +93,7 @@ struct with the callbacks to be invoke specified and setting the Listeners field
 of the Config struct specified when creating a peer to it.
 
 For convenience, a callback hook for all of the currently supported bitcoin
-messages is exposed which receives the peer instance and the concrete message
+and Dash messages is exposed which receives the peer instance and the concrete message
 type.  In addition, a hook for OnRead is provided so even custom messages types
 for which this package does not directly provide a hook, as long as they
 implement the wire.Message interface, can be used.  Finally, the OnWrite hook
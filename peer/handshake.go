@@ -0,0 +1,178 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"fmt"
+
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// HandshakeState describes the progress of the version handshake with a remote
+// peer.
+type HandshakeState uint8
+
+// These constants define the states of the version handshake.  Inbound peers
+// move straight to HandshakeAwaitVersion while outbound peers do so once their
+// own version message has been sent.
+const (
+	// HandshakeInit indicates the handshake has not started.
+	HandshakeInit HandshakeState = iota
+
+	// HandshakeAwaitVersion indicates the version message of the remote
+	// peer is expected next.
+	HandshakeAwaitVersion
+
+	// HandshakeAwaitVerAck indicates the version message of the remote peer
+	// was accepted and its verack message is expected next.
+	HandshakeAwaitVerAck
+
+	// HandshakeComplete indicates both peers exchanged version and verack
+	// messages.
+	HandshakeComplete
+
+	// HandshakeFailed indicates the handshake failed or timed out and the
+	// peer was disconnected.
+	HandshakeFailed
+)
+
+// Map of HandshakeState values back to their constant names for pretty
+// printing.
+var handshakeStateStrings = map[HandshakeState]string{
+	HandshakeInit:         "HandshakeInit",
+	HandshakeAwaitVersion: "HandshakeAwaitVersion",
+	HandshakeAwaitVerAck:  "HandshakeAwaitVerAck",
+	HandshakeComplete:     "HandshakeComplete",
+	HandshakeFailed:       "HandshakeFailed",
+}
+
+// String returns the HandshakeState as a human-readable name.
+func (s HandshakeState) String() string {
+	if str := handshakeStateStrings[s]; str != "" {
+		return str
+	}
+	return fmt.Sprintf("Unknown HandshakeState (%d)", uint8(s))
+}
+
+// Features describes the capabilities negotiated with a remote peer during the
+// version handshake.  It allows upper layers to decide which messages may be
+// sent to the peer without repeating the protocol version comparisons.
+type Features struct {
+	// ProtocolVersion is the negotiated protocol version, which is the
+	// lower of the local and remote versions.
+	ProtocolVersion uint32
+
+	// Services are the services advertised by the remote peer.
+	Services wire.ServiceFlag
+
+	// RelayTx reports whether the remote peer wants transaction inventory
+	// to be announced to it.
+	RelayTx bool
+
+	// Pong reports whether the remote peer answers pings with pongs
+	// (BIP0031).
+	Pong bool
+
+	// Mempool reports whether the remote peer supports the mempool message
+	// (BIP0035).
+	Mempool bool
+
+	// BloomFilter reports whether the remote peer supports bloom filtering
+	// (BIP0037 and BIP0111).
+	BloomFilter bool
+
+	// Reject reports whether the remote peer understands reject messages.
+	Reject bool
+
+	// SendHeaders reports whether the remote peer supports the sendheaders
+	// message (BIP0130).
+	SendHeaders bool
+
+	// FeeFilter reports whether the remote peer supports the feefilter
+	// message (BIP0133).
+	FeeFilter bool
+
+	// CompactBlocks reports whether the remote peer supports the compact
+	// block messages (BIP0152).
+	CompactBlocks bool
+
+	// LLMQ reports whether the remote peer supports the Dash messages of
+	// long-living masternode quorums, which include mnauth, qsendrecsigs,
+	// clsig, islock and the DKG messages (DIP0006, DIP0007 and DIP0008).
+	LLMQ bool
+
+	// ISDLock reports whether the remote peer supports deterministic
+	// InstantSend locks with the isdlock message (DIP0022).
+	ISDLock bool
+
+	// AddrV2 reports whether the remote peer may negotiate the relay of
+	// addrv2 messages with the sendaddrv2 message (BIP0155).
+	AddrV2 bool
+}
+
+// messageProtocolVersions maps the commands of the messages which were not
+// added until a later protocol version to the first version supporting them.
+// Commands not in the map are supported by all protocol versions.
+var messageProtocolVersions = map[string]uint32{
+	wire.CmdPong:         wire.BIP0031Version + 1,
+	wire.CmdMemPool:      wire.BIP0035Version,
+	wire.CmdFilterAdd:    wire.BIP0037Version,
+	wire.CmdFilterClear:  wire.BIP0037Version,
+	wire.CmdFilterLoad:   wire.BIP0037Version,
+	wire.CmdMerkleBlock:  wire.BIP0037Version,
+	wire.CmdReject:       wire.RejectVersion,
+	wire.CmdSendHeaders:  wire.SendHeadersVersion,
+	wire.CmdFeeFilter:    wire.FeeFilterVersion,
+	wire.CmdSendCmpct:    wire.ShortIDsBlocksVersion,
+	wire.CmdCmpctBlock:   wire.ShortIDsBlocksVersion,
+	wire.CmdGetBlockTxn:  wire.ShortIDsBlocksVersion,
+	wire.CmdBlockTxn:     wire.ShortIDsBlocksVersion,
+	wire.CmdSendDsq:      wire.LLMQVersion,
+	wire.CmdQSendRecSigs: wire.LLMQVersion,
+	wire.CmdMNAuth:       wire.LLMQVersion,
+	wire.CmdCLSig:        wire.LLMQVersion,
+	wire.CmdISLock:       wire.LLMQVersion,
+	wire.CmdQFCommit:     wire.LLMQVersion,
+	wire.CmdQContrib:     wire.LLMQVersion,
+	wire.CmdQComplaint:   wire.LLMQVersion,
+	wire.CmdQJustify:     wire.LLMQVersion,
+	wire.CmdQPCommit:     wire.LLMQVersion,
+	wire.CmdISDLock:      wire.ISDLockVersion,
+	wire.CmdSendAddrV2:   wire.AddrV2Version,
+	wire.CmdAddrV2:       wire.AddrV2Version,
+}
+
+// SupportsMessage returns whether messages with the passed command may be sent
+// to the remote peer at the negotiated protocol version.
+func (f Features) SupportsMessage(command string) bool {
+	pver, ok := messageProtocolVersions[command]
+	return !ok || f.ProtocolVersion >= pver
+}
+
+// newFeatures returns the features negotiated at the passed protocol version
+// with a remote peer that sent the passed version message.
+func newFeatures(pver uint32, msg *wire.MsgVersion) Features {
+	// Peers before BIP0111 implicitly support bloom filters while later
+	// ones must advertise the service.
+	bloom := pver >= wire.BIP0037Version &&
+		(pver < wire.BIP0111Version ||
+			msg.Services&wire.SFNodeBloom == wire.SFNodeBloom)
+
+	return Features{
+		ProtocolVersion: pver,
+		Services:        msg.Services,
+		RelayTx:         !msg.DisableRelayTx,
+		Pong:            pver > wire.BIP0031Version,
+		Mempool:         pver >= wire.BIP0035Version,
+		BloomFilter:     bloom,
+		Reject:          pver >= wire.RejectVersion,
+		SendHeaders:     pver >= wire.SendHeadersVersion,
+		FeeFilter:       pver >= wire.FeeFilterVersion,
+		CompactBlocks:   pver >= wire.ShortIDsBlocksVersion,
+		LLMQ:            pver >= wire.LLMQVersion,
+		ISDLock:         pver >= wire.ISDLockVersion,
+		AddrV2:          pver >= wire.AddrV2Version,
+	}
+}
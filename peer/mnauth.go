@@ -0,0 +1,166 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// MNAuthSigner signs mnauth messages with the BLS operator key of the local
+// masternode.
+type MNAuthSigner interface {
+	// ProRegTxHash returns the hash of the ProRegTx which registered the
+	// local masternode.
+	ProRegTxHash() *chainhash.Hash
+
+	// OperatorPubKey returns the BLS public key of the operator key.
+	OperatorPubKey() *[wire.BLSPublicKeySize]byte
+
+	// Sign returns the BLS signature of the operator key over the passed
+	// hash.
+	Sign(hash *chainhash.Hash) (*[wire.BLSSignatureSize]byte, error)
+}
+
+// MNAuthVerifier verifies the mnauth messages of remote masternodes.  The BLS
+// verification matches llmq.BLSVerifier so the same implementation can be
+// used for both.
+type MNAuthVerifier interface {
+	// OperatorPubKey returns the BLS public key of the operator key of the
+	// valid masternode registered by the ProRegTx with the passed hash.  An
+	// error is returned when there is no such masternode.
+	OperatorPubKey(proRegTxHash *chainhash.Hash) (*[wire.BLSPublicKeySize]byte, error)
+
+	// VerifyInsecure returns whether sig is a valid signature of hash by
+	// pubKey.
+	VerifyInsecure(pubKey *[wire.BLSPublicKeySize]byte,
+		hash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) bool
+}
+
+// mnAuthSignHash returns the hash a masternode signs with its operator key to
+// authenticate with a mnauth message.  It commits to the operator key, the
+// challenge of the version message of the verifying peer, whether the
+// connection is inbound from the point of view of the verifying peer and, when
+// both peers support it, the protocol version advertised by the masternode.
+func mnAuthSignHash(pubKey *[wire.BLSPublicKeySize]byte,
+	challenge *chainhash.Hash, inbound bool, pver, remotePver uint32) chainhash.Hash {
+
+	var buf bytes.Buffer
+	buf.Grow(wire.BLSPublicKeySize + chainhash.HashSize + 5)
+	buf.Write(pubKey[:])
+	buf.Write(challenge[:])
+	if inbound {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	if pver >= wire.MNAuthNodeVersionVersion &&
+		remotePver >= wire.MNAuthNodeVersionVersion {
+
+		var v [4]byte
+		binary.LittleEndian.PutUint32(v[:], pver)
+		buf.Write(v[:])
+	}
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// localMNAuthMsg returns the mnauth message which authenticates the local
+// masternode to the remote peer, or nil when it is not sent because no
+// masternode signer is configured, the negotiated protocol version doesn't
+// support it, or the remote peer didn't send a challenge.
+func (p *Peer) localMNAuthMsg() (*wire.MsgMNAuth, error) {
+	signer := p.cfg.MNAuthSigner
+	if signer == nil || !p.Features().LLMQ {
+		return nil, nil
+	}
+
+	p.flagsMtx.Lock()
+	challenge := p.receivedMNAuthChallenge
+	remotePver := p.advertisedProtoVer
+	p.flagsMtx.Unlock()
+	if challenge == zeroHash {
+		return nil, nil
+	}
+
+	// The remote peer verifies the signature from its own point of view,
+	// so the direction of the connection is reversed.
+	signHash := mnAuthSignHash(signer.OperatorPubKey(), &challenge,
+		!p.inbound, p.cfg.ProtocolVersion, remotePver)
+	sig, err := signer.Sign(&signHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign mnauth: %v", err)
+	}
+	return wire.NewMsgMNAuth(signer.ProRegTxHash(), sig), nil
+}
+
+// writeLocalMNAuthMsg writes our mnauth message to the remote peer when the
+// local peer is a masternode.  See localMNAuthMsg for details.
+func (p *Peer) writeLocalMNAuthMsg() error {
+	msg, err := p.localMNAuthMsg()
+	if err != nil || msg == nil {
+		return err
+	}
+
+	return p.writeMessage(msg, wire.LatestEncoding)
+}
+
+// handleMNAuthMsg verifies a mnauth message received from the remote peer and
+// records the ProRegTx hash of the masternode it authenticated as.  Nothing is
+// verified when no masternode verifier is configured.  An error is returned
+// for a second mnauth message or an invalid signature.
+func (p *Peer) handleMNAuthMsg(msg *wire.MsgMNAuth) error {
+	verifier := p.cfg.MNAuthVerifier
+	if verifier == nil {
+		return nil
+	}
+
+	p.flagsMtx.Lock()
+	authenticated := p.verifiedProRegTxHash != nil
+	challenge := p.sentMNAuthChallenge
+	remotePver := p.advertisedProtoVer
+	p.flagsMtx.Unlock()
+	if authenticated {
+		return errors.New("duplicate mnauth message")
+	}
+
+	pubKey, err := verifier.OperatorPubKey(&msg.ProRegTxHash)
+	if err != nil {
+		return fmt.Errorf("unknown masternode %v: %v", msg.ProRegTxHash,
+			err)
+	}
+	signHash := mnAuthSignHash(pubKey, &challenge, p.inbound,
+		remotePver, p.cfg.ProtocolVersion)
+	if !verifier.VerifyInsecure(pubKey, &signHash, &msg.Sig) {
+		return fmt.Errorf("invalid mnauth signature for masternode %v",
+			msg.ProRegTxHash)
+	}
+
+	proRegTxHash := msg.ProRegTxHash
+	p.flagsMtx.Lock()
+	p.verifiedProRegTxHash = &proRegTxHash
+	p.flagsMtx.Unlock()
+
+	log.Debugf("Peer %v authenticated as masternode %v", p, proRegTxHash)
+	return nil
+}
+
+// VerifiedProRegTxHash returns the hash of the ProRegTx of the masternode the
+// remote peer authenticated as with a mnauth message, or nil when it hasn't
+// authenticated.  Callers may use it to prioritize masternode connections.
+// Peers are only authenticated when Config.MNAuthVerifier is set.
+//
+// This function is safe for concurrent access.
+func (p *Peer) VerifiedProRegTxHash() *chainhash.Hash {
+	p.flagsMtx.Lock()
+	proRegTxHash := p.verifiedProRegTxHash
+	p.flagsMtx.Unlock()
+
+	return proRegTxHash
+}
@@ -8,6 +8,7 @@ package peer
 import (
 	"bytes"
 	"container/list"
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"io"
@@ -28,13 +29,19 @@ import (
 )
 
 const (
-	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.FeeFilterVersion
+	// MaxProtocolVersion is the max protocol version the peer supports.  It
+	// includes the Dash LLMQ, deterministic InstantSend lock and addrv2
+	// messages.
+	MaxProtocolVersion = wire.AddrV2Version
 
 	// DefaultTrickleInterval is the min time between attempts to send an
 	// inv message to a peer.
 	DefaultTrickleInterval = 10 * time.Second
 
+	// DefaultHandshakeMsgTimeout is the default duration to wait for each
+	// message expected from the remote peer during the version handshake.
+	DefaultHandshakeMsgTimeout = 10 * time.Second
+
 	// MinAcceptableProtocolVersion is the lowest protocol version that a
 	// connected peer may support.
 	MinAcceptableProtocolVersion = wire.MultipleAddressVersion
@@ -102,6 +109,9 @@ type MessageListeners struct {
 	// OnAddr is invoked when a peer receives an addr bitcoin message.
 	OnAddr func(p *Peer, msg *wire.MsgAddr)
 
+	// OnAddrV2 is invoked when a peer receives an addrv2 bitcoin message.
+	OnAddrV2 func(p *Peer, msg *wire.MsgAddrV2)
+
 	// OnPing is invoked when a peer receives a ping bitcoin message.
 	OnPing func(p *Peer, msg *wire.MsgPing)
 
@@ -197,6 +207,77 @@ type MessageListeners struct {
 	// message.
 	OnSendHeaders func(p *Peer, msg *wire.MsgSendHeaders)
 
+	// OnSendCmpct is invoked when a peer receives a sendcmpct bitcoin
+	// message.
+	OnSendCmpct func(p *Peer, msg *wire.MsgSendCmpct)
+
+	// OnCmpctBlock is invoked when a peer receives a cmpctblock bitcoin
+	// message.
+	OnCmpctBlock func(p *Peer, msg *wire.MsgCmpctBlock)
+
+	// OnGetBlockTxn is invoked when a peer receives a getblocktxn bitcoin
+	// message.
+	OnGetBlockTxn func(p *Peer, msg *wire.MsgGetBlockTxn)
+
+	// OnBlockTxn is invoked when a peer receives a blocktxn bitcoin
+	// message.
+	OnBlockTxn func(p *Peer, msg *wire.MsgBlockTxn)
+
+	// OnGetMnListDiff is invoked when a peer receives a getmnlistd Dash
+	// message.
+	OnGetMnListDiff func(p *Peer, msg *wire.MsgGetMnListDiff)
+
+	// OnMnListDiff is invoked when a peer receives a mnlistdiff Dash
+	// message.
+	OnMnListDiff func(p *Peer, msg *wire.MsgMnListDiff)
+
+	// OnGetQRInfo is invoked when a peer receives a getqrinfo Dash message.
+	OnGetQRInfo func(p *Peer, msg *wire.MsgGetQRInfo)
+
+	// OnQRInfo is invoked when a peer receives a qrinfo Dash message.
+	OnQRInfo func(p *Peer, msg *wire.MsgQRInfo)
+
+	// OnGetSporks is invoked when a peer receives a getsporks Dash message.
+	OnGetSporks func(p *Peer, msg *wire.MsgGetSporks)
+
+	// OnSpork is invoked when a peer receives a spork Dash message.
+	OnSpork func(p *Peer, msg *wire.MsgSpork)
+
+	// OnSendDsq is invoked when a peer receives a senddsq Dash message.
+	OnSendDsq func(p *Peer, msg *wire.MsgSendDsq)
+
+	// OnQSendRecSigs is invoked when a peer receives a qsendrecsigs Dash
+	// message.
+	OnQSendRecSigs func(p *Peer, msg *wire.MsgQSendRecSigs)
+
+	// OnMNAuth is invoked when a peer receives a mnauth Dash message.  When
+	// Config.MNAuthVerifier is set, it is only invoked once the message was
+	// verified.
+	OnMNAuth func(p *Peer, msg *wire.MsgMNAuth)
+
+	// OnCLSig is invoked when a peer receives a clsig Dash message.
+	OnCLSig func(p *Peer, msg *wire.MsgCLSig)
+
+	// OnISLock is invoked when a peer receives an islock or isdlock Dash
+	// message.  It is not invoked in blocks-only mode.
+	OnISLock func(p *Peer, msg *wire.MsgISLock)
+
+	// OnQFCommit is invoked when a peer receives a qfcommit Dash message.
+	OnQFCommit func(p *Peer, msg *wire.MsgQFCommit)
+
+	// OnQContrib is invoked when a peer receives a qcontrib Dash message.
+	OnQContrib func(p *Peer, msg *wire.MsgQContrib)
+
+	// OnQComplaint is invoked when a peer receives a qcomplaint Dash
+	// message.
+	OnQComplaint func(p *Peer, msg *wire.MsgQComplaint)
+
+	// OnQJustify is invoked when a peer receives a qjustify Dash message.
+	OnQJustify func(p *Peer, msg *wire.MsgQJustify)
+
+	// OnQPCommit is invoked when a peer receives a qpcommit Dash message.
+	OnQPCommit func(p *Peer, msg *wire.MsgQPCommit)
+
 	// OnRead is invoked when a peer receives a bitcoin message.  It
 	// consists of the number of bytes read, the message, and whether or not
 	// an error in the read occurred.  Typically, callers will opt to use
@@ -260,10 +341,53 @@ type Config struct {
 	// peer.MaxProtocolVersion will be used.
 	ProtocolVersion uint32
 
+	// MinProtocolVersion specifies the lowest protocol version the peer is
+	// willing to downgrade to when the remote peer advertises an older
+	// version than ProtocolVersion.  Remote peers advertising a version
+	// below it are rejected as obsolete.  This field can be omitted in
+	// which case peer.MinAcceptableProtocolVersion will be used, and it is
+	// never allowed to be lower than that.
+	MinProtocolVersion uint32
+
+	// HandshakeMsgTimeout specifies the duration to wait for each message
+	// expected from the remote peer during the version handshake.  This
+	// field can be omitted in which case peer.DefaultHandshakeMsgTimeout
+	// will be used.
+	HandshakeMsgTimeout time.Duration
+
 	// DisableRelayTx specifies if the remote peer should be informed to
 	// not send inv messages for transactions.
 	DisableRelayTx bool
 
+	// BlocksOnly specifies if only blocks and headers are relayed with the
+	// remote peer, which reduces the bandwidth of nodes that have no need
+	// for unconfirmed transactions.  The remote peer is informed to not
+	// send inv messages for transactions like DisableRelayTx does, all
+	// inventory other than blocks, such as transactions and InstantSend
+	// locks, is neither announced to nor accepted from the remote peer, and
+	// transactions it sends anyway are ignored.
+	BlocksOnly bool
+
+	// Permissions specifies the permissions granted to the remote peer.
+	// They are not enforced by the peer itself, but are available to the
+	// message listeners with the Permissions and HasPermission methods.
+	Permissions PermissionFlags
+
+	// MNAuthSigner specifies the operator key of the local masternode.
+	// When set, the local peer authenticates as the masternode with a
+	// mnauth message after the version handshake with remote peers which
+	// support it.  This field can be omitted for peers that are not
+	// masternodes.
+	MNAuthSigner MNAuthSigner
+
+	// MNAuthVerifier specifies how the mnauth messages of remote
+	// masternodes are verified.  When set, remote peers with an invalid
+	// signature or which authenticate more than once are disconnected, and
+	// the masternode of those with a valid signature is available via
+	// VerifiedProRegTxHash.  When nil, mnauth messages are passed to the
+	// OnMNAuth listener without verification.
+	MNAuthVerifier MNAuthVerifier
+
 	// Listeners houses callback functions to be invoked on receiving peer
 	// messages.
 	Listeners MessageListeners
@@ -272,6 +396,12 @@ type Config struct {
 	// inventory to a peer.
 	TrickleInterval time.Duration
 
+	// MessageLimits specifies limits on the messages read from the remote
+	// peer which are stricter than those of the protocol.  Messages which
+	// exceed them are rejected and the peer is disconnected.  When nil,
+	// only the limits of the protocol are enforced.
+	MessageLimits *wire.MessageLimits
+
 	// AllowSelfConns is only used to allow the tests to bypass the self
 	// connection detecting and disconnect logic since they intentionally
 	// do so for testing purposes.
@@ -319,12 +449,17 @@ func newNetAddress(addr net.Addr, services wire.ServiceFlag) (*wire.NetAddress,
 
 	// For the most part, addr should be one of the two above cases, but
 	// to be safe, fall back to trying to parse the information from the
-	// address string as a last resort.
+	// address string as a last resort.  This is also the case for other
+	// proxied addresses, whose host may be a hostname such as a .onion
+	// address.
 	host, portStr, err := net.SplitHostPort(addr.String())
 	if err != nil {
 		return nil, err
 	}
 	ip := net.ParseIP(host)
+	if ip == nil {
+		ip = net.ParseIP("0.0.0.0")
+	}
 	port, err := strconv.ParseUint(portStr, 10, 16)
 	if err != nil {
 		return nil, err
@@ -453,8 +588,15 @@ type Peer struct {
 	advertisedProtoVer   uint32 // protocol version advertised by remote
 	protocolVersion      uint32 // negotiated protocol version
 	sendHeadersPreferred bool   // peer sent a sendheaders message
+	sendAddrV2           bool   // peer sent a sendaddrv2 message
 	verAckReceived       bool
 	witnessEnabled       bool
+	handshakeState       HandshakeState
+	features             Features
+
+	sentMNAuthChallenge     chainhash.Hash  // challenge in our version
+	receivedMNAuthChallenge chainhash.Hash  // challenge in their version
+	verifiedProRegTxHash    *chainhash.Hash // masternode peer authenticated as
 
 	wireEncoding wire.MessageEncoding
 
@@ -699,6 +841,31 @@ func (p *Peer) VerAckReceived() bool {
 	return verAckReceived
 }
 
+// HandshakeState returns the current state of the version handshake with the
+// peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) HandshakeState() HandshakeState {
+	p.flagsMtx.Lock()
+	state := p.handshakeState
+	p.flagsMtx.Unlock()
+
+	return state
+}
+
+// Features returns the capabilities negotiated with the peer during the version
+// handshake.  The returned value is only meaningful once the version of the
+// peer is known.
+//
+// This function is safe for concurrent access.
+func (p *Peer) Features() Features {
+	p.flagsMtx.Lock()
+	features := p.features
+	p.flagsMtx.Unlock()
+
+	return features
+}
+
 // ProtocolVersion returns the negotiated peer protocol version.
 //
 // This function is safe for concurrent access.
@@ -808,6 +975,19 @@ func (p *Peer) WantsHeaders() bool {
 	return sendHeadersPreferred
 }
 
+// WantsAddrV2 returns if the peer wants addrv2 messages instead of addr
+// messages (BIP0155).  Peers signal this with a sendaddrv2 message during the
+// version handshake.
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsAddrV2() bool {
+	p.flagsMtx.Lock()
+	sendAddrV2 := p.sendAddrV2
+	p.flagsMtx.Unlock()
+
+	return sendAddrV2
+}
+
 // IsWitnessEnabled returns true if the peer has signalled that it supports
 // segregated witness.
 //
@@ -820,6 +1000,30 @@ func (p *Peer) IsWitnessEnabled() bool {
 	return witnessEnabled
 }
 
+// BlocksOnly returns whether or not only blocks and headers are relayed with
+// the peer.  See Config.BlocksOnly for details.
+//
+// This function is safe for concurrent access.
+func (p *Peer) BlocksOnly() bool {
+	return p.cfg.BlocksOnly
+}
+
+// Permissions returns the permissions granted to the remote peer.  See
+// Config.Permissions for details.
+//
+// This function is safe for concurrent access.
+func (p *Peer) Permissions() PermissionFlags {
+	return p.cfg.Permissions
+}
+
+// HasPermission returns whether the passed permissions are all granted to the
+// remote peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) HasPermission(flags PermissionFlags) bool {
+	return p.cfg.Permissions&flags == flags
+}
+
 // PushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.  This function is useful over manually sending the message via
 // QueueMessage since it automatically limits the addresses to the maximum
@@ -856,6 +1060,39 @@ func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress) ([]*wire.NetAddress, er
 	return msg.AddrList, nil
 }
 
+// PushAddrV2Msg sends an addrv2 message to the connected peer using the
+// provided addresses.  It behaves like PushAddrMsg and is meant for peers which
+// signaled support for addrv2 messages as reported by WantsAddrV2.
+//
+// This function is safe for concurrent access.
+func (p *Peer) PushAddrV2Msg(addresses []*wire.NetAddressV2) ([]*wire.NetAddressV2, error) {
+	addressCount := len(addresses)
+
+	// Nothing to send.
+	if addressCount == 0 {
+		return nil, nil
+	}
+
+	msg := wire.NewMsgAddrV2()
+	msg.AddrList = make([]*wire.NetAddressV2, addressCount)
+	copy(msg.AddrList, addresses)
+
+	// Randomize the addresses sent if there are more than the maximum allowed.
+	if addressCount > wire.MaxAddrPerMsg {
+		// Shuffle the address list.
+		for i := 0; i < wire.MaxAddrPerMsg; i++ {
+			j := i + rand.Intn(addressCount-i)
+			msg.AddrList[i], msg.AddrList[j] = msg.AddrList[j], msg.AddrList[i]
+		}
+
+		// Truncate it to the maximum size.
+		msg.AddrList = msg.AddrList[:wire.MaxAddrPerMsg]
+	}
+
+	p.QueueMessage(msg, nil)
+	return msg.AddrList, nil
+}
+
 // PushGetBlocksMsg sends a getblocks message for the provided block locator
 // and stop hash.  It will ignore back-to-back duplicate requests.
 //
@@ -1018,8 +1255,9 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 
 // readMessage reads the next bitcoin message from the peer with logging.
 func (p *Peer) readMessage(encoding wire.MessageEncoding) (wire.Message, []byte, error) {
-	n, msg, buf, err := wire.ReadMessageWithEncodingN(p.conn,
-		p.ProtocolVersion(), p.cfg.ChainParams.Net, encoding)
+	n, msg, buf, err := wire.ReadMessageWithLimitsN(p.conn,
+		p.ProtocolVersion(), p.cfg.ChainParams.Net, encoding,
+		p.cfg.MessageLimits)
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
@@ -1177,6 +1415,23 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 		// headers.
 		deadline = time.Now().Add(stallResponseTimeout * 3)
 		pendingResponses[wire.CmdHeaders] = deadline
+
+	case wire.CmdGetBlockTxn:
+		// Expects a blocktxn message.
+		pendingResponses[wire.CmdBlockTxn] = deadline
+
+	case wire.CmdGetMnListDiff:
+		// Expects a mnlistdiff message.  Use a longer deadline since
+		// the remote peer may need to build the diff from the
+		// masternode list snapshots of many blocks.
+		deadline = time.Now().Add(stallResponseTimeout * 3)
+		pendingResponses[wire.CmdMnListDiff] = deadline
+
+	case wire.CmdGetQRInfo:
+		// Expects a qrinfo message.  Use a longer deadline for the same
+		// reason as getmnlistd since it carries several diffs.
+		deadline = time.Now().Add(stallResponseTimeout * 3)
+		pendingResponses[wire.CmdQRInfo] = deadline
 	}
 }
 
@@ -1376,11 +1631,16 @@ out:
 				// the message to be sent before disconnecting.
 				//
 				// NOTE: Ideally this would include the command in the header if
-				// at least that much of the message was valid, but that is not
-				// currently exposed by wire, so just used malformed for the
-				// command.
-				p.PushRejectMsg("malformed", wire.RejectMalformed, errMsg, nil,
-					true)
+				// at least that much of the message was valid, but that is only
+				// exposed by wire for messages which exceed the limits, so just
+				// use malformed for the command otherwise.
+				if reject, ok := err.(*wire.RejectReason); ok {
+					p.PushRejectMsg(reject.Cmd, reject.Code,
+						reject.Reason, nil, true)
+				} else {
+					p.PushRejectMsg("malformed", wire.RejectMalformed,
+						errMsg, nil, true)
+				}
 			}
 			break out
 		}
@@ -1414,6 +1674,18 @@ out:
 				p.cfg.Listeners.OnAddr(p, msg)
 			}
 
+		case *wire.MsgAddrV2:
+			if p.cfg.Listeners.OnAddrV2 != nil {
+				p.cfg.Listeners.OnAddrV2(p, msg)
+			}
+
+		case *wire.MsgSendAddrV2:
+			// Support for addrv2 messages must be signaled before the
+			// verack message.
+			p.PushRejectMsg(msg.Command(), wire.RejectInvalid,
+				"sendaddrv2 message after verack", nil, true)
+			break out
+
 		case *wire.MsgPing:
 			p.handlePingMsg(msg)
 			if p.cfg.Listeners.OnPing != nil {
@@ -1437,6 +1709,11 @@ out:
 			}
 
 		case *wire.MsgTx:
+			if p.cfg.BlocksOnly {
+				log.Debugf("Ignoring tx %v from %v in blocks-only "+
+					"mode", msg.TxHash(), p)
+				break
+			}
 			if p.cfg.Listeners.OnTx != nil {
 				p.cfg.Listeners.OnTx(p, msg)
 			}
@@ -1447,6 +1724,12 @@ out:
 			}
 
 		case *wire.MsgInv:
+			if p.cfg.BlocksOnly {
+				msg = p.filterBlocksOnlyInv(msg)
+				if len(msg.InvList) == 0 {
+					break
+				}
+			}
 			if p.cfg.Listeners.OnInv != nil {
 				p.cfg.Listeners.OnInv(p, msg)
 			}
@@ -1540,6 +1823,118 @@ out:
 				p.cfg.Listeners.OnSendHeaders(p, msg)
 			}
 
+		case *wire.MsgSendCmpct:
+			if p.cfg.Listeners.OnSendCmpct != nil {
+				p.cfg.Listeners.OnSendCmpct(p, msg)
+			}
+
+		case *wire.MsgCmpctBlock:
+			if p.cfg.Listeners.OnCmpctBlock != nil {
+				p.cfg.Listeners.OnCmpctBlock(p, msg)
+			}
+
+		case *wire.MsgGetBlockTxn:
+			if p.cfg.Listeners.OnGetBlockTxn != nil {
+				p.cfg.Listeners.OnGetBlockTxn(p, msg)
+			}
+
+		case *wire.MsgBlockTxn:
+			if p.cfg.Listeners.OnBlockTxn != nil {
+				p.cfg.Listeners.OnBlockTxn(p, msg)
+			}
+
+		case *wire.MsgGetMnListDiff:
+			if p.cfg.Listeners.OnGetMnListDiff != nil {
+				p.cfg.Listeners.OnGetMnListDiff(p, msg)
+			}
+
+		case *wire.MsgMnListDiff:
+			if p.cfg.Listeners.OnMnListDiff != nil {
+				p.cfg.Listeners.OnMnListDiff(p, msg)
+			}
+
+		case *wire.MsgGetQRInfo:
+			if p.cfg.Listeners.OnGetQRInfo != nil {
+				p.cfg.Listeners.OnGetQRInfo(p, msg)
+			}
+
+		case *wire.MsgQRInfo:
+			if p.cfg.Listeners.OnQRInfo != nil {
+				p.cfg.Listeners.OnQRInfo(p, msg)
+			}
+
+		case *wire.MsgGetSporks:
+			if p.cfg.Listeners.OnGetSporks != nil {
+				p.cfg.Listeners.OnGetSporks(p, msg)
+			}
+
+		case *wire.MsgSpork:
+			if p.cfg.Listeners.OnSpork != nil {
+				p.cfg.Listeners.OnSpork(p, msg)
+			}
+
+		case *wire.MsgSendDsq:
+			if p.cfg.Listeners.OnSendDsq != nil {
+				p.cfg.Listeners.OnSendDsq(p, msg)
+			}
+
+		case *wire.MsgQSendRecSigs:
+			if p.cfg.Listeners.OnQSendRecSigs != nil {
+				p.cfg.Listeners.OnQSendRecSigs(p, msg)
+			}
+
+		case *wire.MsgMNAuth:
+			if err := p.handleMNAuthMsg(msg); err != nil {
+				log.Debugf("Invalid mnauth from %v: %v", p, err)
+				p.PushRejectMsg(msg.Command(), wire.RejectInvalid,
+					err.Error(), nil, true)
+				break out
+			}
+			if p.cfg.Listeners.OnMNAuth != nil {
+				p.cfg.Listeners.OnMNAuth(p, msg)
+			}
+
+		case *wire.MsgCLSig:
+			if p.cfg.Listeners.OnCLSig != nil {
+				p.cfg.Listeners.OnCLSig(p, msg)
+			}
+
+		case *wire.MsgISLock:
+			if p.cfg.BlocksOnly {
+				log.Debugf("Ignoring %v for tx %v from %v in "+
+					"blocks-only mode", msg.Command(), msg.TxHash,
+					p)
+				break
+			}
+			if p.cfg.Listeners.OnISLock != nil {
+				p.cfg.Listeners.OnISLock(p, msg)
+			}
+
+		case *wire.MsgQFCommit:
+			if p.cfg.Listeners.OnQFCommit != nil {
+				p.cfg.Listeners.OnQFCommit(p, msg)
+			}
+
+		case *wire.MsgQContrib:
+			if p.cfg.Listeners.OnQContrib != nil {
+				p.cfg.Listeners.OnQContrib(p, msg)
+			}
+
+		case *wire.MsgQComplaint:
+			if p.cfg.Listeners.OnQComplaint != nil {
+				p.cfg.Listeners.OnQComplaint(p, msg)
+			}
+
+		case *wire.MsgQJustify:
+			if p.cfg.Listeners.OnQJustify != nil {
+				p.cfg.Listeners.OnQJustify(p, msg)
+			}
+
+		case *wire.MsgQPCommit:
+			if p.cfg.Listeners.OnQPCommit != nil {
+				p.cfg.Listeners.OnQPCommit(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %v "+
 				"from %v", rmsg.Command(), p)
@@ -1638,9 +2033,9 @@ out:
 				continue
 			}
 
-			// Create and send as many inv messages as needed to
-			// drain the inventory send queue.
-			invMsg := wire.NewMsgInvSizeHint(uint(invSendQueue.Len()))
+			// Drain the inventory send queue and send as many inv
+			// messages as needed to relay it.
+			invList := make([]*wire.InvVect, 0, invSendQueue.Len())
 			for e := invSendQueue.Front(); e != nil; e = invSendQueue.Front() {
 				iv := invSendQueue.Remove(e).(*wire.InvVect)
 
@@ -1650,19 +2045,14 @@ out:
 					continue
 				}
 
-				invMsg.AddInvVect(iv)
-				if len(invMsg.InvList) >= maxInvTrickleSize {
-					waiting = queuePacket(
-						outMsg{msg: invMsg},
-						pendingMsgs, waiting)
-					invMsg = wire.NewMsgInvSizeHint(uint(invSendQueue.Len()))
-				}
-
 				// Add the inventory that is being relayed to
 				// the known inventory for the peer.
+				invList = append(invList, iv)
 				p.AddKnownInventory(iv)
 			}
-			if len(invMsg.InvList) > 0 {
+			batches := wire.SplitInvVects(invList, maxInvTrickleSize)
+			for _, batch := range batches {
+				invMsg := &wire.MsgInv{InvList: batch}
 				waiting = queuePacket(outMsg{msg: invMsg},
 					pendingMsgs, waiting)
 			}
@@ -1841,15 +2231,69 @@ func (p *Peer) QueueMessageWithEncoding(msg wire.Message, doneChan chan<- struct
 		}
 		return
 	}
+
+	// Don't send messages the remote peer doesn't support at the
+	// negotiated protocol version since they would fail to encode and
+	// cause a disconnect.
+	if p.VersionKnown() && !p.Features().SupportsMessage(msg.Command()) {
+		log.Debugf("Not sending %v message unsupported by peer %v at "+
+			"protocol version %d", msg.Command(), p,
+			p.ProtocolVersion())
+		if doneChan != nil {
+			go func() {
+				doneChan <- struct{}{}
+			}()
+		}
+		return
+	}
 	p.outputQueue <- outMsg{msg: msg, encoding: encoding, doneChan: doneChan}
 }
 
+// isBlockInv returns whether or not the passed inventory vector describes a
+// block, which is the only inventory relayed in blocks-only mode.
+func isBlockInv(invVect *wire.InvVect) bool {
+	switch invVect.Type &^ wire.InvWitnessFlag {
+	case wire.InvTypeBlock, wire.InvTypeFilteredBlock:
+		return true
+	}
+	return false
+}
+
+// filterBlocksOnlyInv returns the passed inv message with all inventory other
+// than blocks removed.  The message is returned as is when it only contains
+// blocks.
+func (p *Peer) filterBlocksOnlyInv(msg *wire.MsgInv) *wire.MsgInv {
+	filtered := msg
+	for i, invVect := range msg.InvList {
+		if isBlockInv(invVect) {
+			if filtered != msg {
+				filtered.InvList = append(filtered.InvList, invVect)
+			}
+			continue
+		}
+
+		log.Tracef("Ignoring inventory %v from %v in blocks-only mode",
+			invVect, p)
+		if filtered == msg {
+			filtered = wire.NewMsgInvSizeHint(uint(len(msg.InvList)))
+			filtered.InvList = append(filtered.InvList,
+				msg.InvList[:i]...)
+		}
+	}
+	return filtered
+}
+
 // QueueInventory adds the passed inventory to the inventory send queue which
 // might not be sent right away, rather it is trickled to the peer in batches.
-// Inventory that the peer is already known to have is ignored.
+// Inventory that the peer is already known to have is ignored, as is all
+// inventory other than blocks in blocks-only mode.
 //
 // This function is safe for concurrent access.
 func (p *Peer) QueueInventory(invVect *wire.InvVect) {
+	if p.cfg.BlocksOnly && !isBlockInv(invVect) {
+		return
+	}
+
 	// Don't add the inventory to the send queue if the peer is already
 	// known to have it.
 	if p.knownInventory.Contains(invVect) {
@@ -1894,7 +2338,7 @@ func (p *Peer) Disconnect() {
 // acceptable then return an error.
 func (p *Peer) readRemoteVersionMsg() error {
 	// Read their version message.
-	remoteMsg, _, err := p.readMessage(wire.LatestEncoding)
+	remoteMsg, err := p.readHandshakeMsg(wire.CmdVersion)
 	if err != nil {
 		return err
 	}
@@ -1904,8 +2348,8 @@ func (p *Peer) readRemoteVersionMsg() error {
 	msg, ok := remoteMsg.(*wire.MsgVersion)
 	if !ok {
 		reason := "a version message must precede all others"
-		rejectMsg := wire.NewMsgReject(msg.Command(), wire.RejectMalformed,
-			reason)
+		rejectMsg := wire.NewMsgReject(remoteMsg.Command(),
+			wire.RejectMalformed, reason)
 		_ = p.writeMessage(rejectMsg, wire.LatestEncoding)
 		return errors.New(reason)
 	}
@@ -1922,9 +2366,16 @@ func (p *Peer) readRemoteVersionMsg() error {
 	p.protocolVersion = minUint32(p.protocolVersion, p.advertisedProtoVer)
 	p.versionKnown = true
 	p.services = msg.Services
+	p.features = newFeatures(p.protocolVersion, msg)
+	p.receivedMNAuthChallenge = msg.MNAuthChallenge
 	p.flagsMtx.Unlock()
-	log.Debugf("Negotiated protocol version %d for peer %s",
-		p.protocolVersion, p)
+	if p.protocolVersion < p.cfg.ProtocolVersion {
+		log.Debugf("Downgraded protocol version from %d to %d for "+
+			"peer %s", p.cfg.ProtocolVersion, p.protocolVersion, p)
+	} else {
+		log.Debugf("Negotiated protocol version %d for peer %s",
+			p.protocolVersion, p)
+	}
 
 	// Updating a bunch of stats including block based stats, and the
 	// peer's time offset.
@@ -1966,17 +2417,17 @@ func (p *Peer) readRemoteVersionMsg() error {
 	}
 
 	// Notify and disconnect clients that have a protocol version that is
-	// too old.
+	// too old to downgrade to.
 	//
 	// NOTE: If minAcceptableProtocolVersion is raised to be higher than
 	// wire.RejectVersion, this should send a reject packet before
 	// disconnecting.
-	if uint32(msg.ProtocolVersion) < MinAcceptableProtocolVersion {
+	if uint32(msg.ProtocolVersion) < p.cfg.MinProtocolVersion {
 		// Send a reject message indicating the protocol version is
 		// obsolete and wait for the message to be sent before
 		// disconnecting.
 		reason := fmt.Sprintf("protocol version must be %d or greater",
-			MinAcceptableProtocolVersion)
+			p.cfg.MinProtocolVersion)
 		rejectMsg := wire.NewMsgReject(msg.Command(), wire.RejectObsolete,
 			reason)
 		_ = p.writeMessage(rejectMsg, wire.LatestEncoding)
@@ -1992,19 +2443,36 @@ func (p *Peer) readRemoteVersionMsg() error {
 // connection.
 func (p *Peer) readRemoteVerAckMsg() error {
 	// Read the next message from the wire.
-	remoteMsg, _, err := p.readMessage(wire.LatestEncoding)
+	remoteMsg, err := p.readHandshakeMsg(wire.CmdVerAck)
 	if err != nil {
 		return err
 	}
 
+	// Peers signal support for addrv2 messages before their verack, which
+	// must follow it.
+	if _, ok := remoteMsg.(*wire.MsgSendAddrV2); ok {
+		p.flagsMtx.Lock()
+		p.sendAddrV2 = true
+		p.flagsMtx.Unlock()
+
+		remoteMsg, err = p.readHandshakeMsg(wire.CmdVerAck)
+		if err != nil {
+			return err
+		}
+	}
+
 	// It should be a verack message, otherwise send a reject message to the
-	// peer explaining why.
+	// peer explaining why.  A second version message is rejected as a
+	// duplicate rather than as malformed.
 	msg, ok := remoteMsg.(*wire.MsgVerAck)
 	if !ok {
+		code := wire.RejectMalformed
 		reason := "a verack message must follow version"
-		rejectMsg := wire.NewMsgReject(
-			msg.Command(), wire.RejectMalformed, reason,
-		)
+		if _, isVersion := remoteMsg.(*wire.MsgVersion); isVersion {
+			code = wire.RejectDuplicate
+			reason = "duplicate version message"
+		}
+		rejectMsg := wire.NewMsgReject(remoteMsg.Command(), code, reason)
 		_ = p.writeMessage(rejectMsg, wire.LatestEncoding)
 		return errors.New(reason)
 	}
@@ -2020,6 +2488,42 @@ func (p *Peer) readRemoteVerAckMsg() error {
 	return nil
 }
 
+// readHandshakeMsg reads the next message from the remote peer during the
+// version handshake.  An error is returned if no message arrives within the
+// configured handshake message timeout.  The expected command is only used to
+// describe the timeout.
+func (p *Peer) readHandshakeMsg(expected string) (wire.Message, error) {
+	deadline := time.Now().Add(p.cfg.HandshakeMsgTimeout)
+	if err := p.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	msg, _, err := p.readMessage(wire.LatestEncoding)
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil, fmt.Errorf("timeout waiting for %s message", expected)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear the deadline so it doesn't apply to the messages read once the
+	// handshake completes.
+	if err := p.conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// setHandshakeState updates the state of the version handshake.  A failed
+// handshake is final so the negotiation goroutine can't move it on after the
+// overall negotiation timeout expired.
+func (p *Peer) setHandshakeState(state HandshakeState) {
+	p.flagsMtx.Lock()
+	if p.handshakeState != HandshakeFailed {
+		p.handshakeState = state
+	}
+	p.flagsMtx.Unlock()
+}
+
 // localVersionMsg creates a version message that can be used to send to the
 // remote peer.
 func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
@@ -2077,11 +2581,34 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 	msg.ProtocolVersion = int32(p.cfg.ProtocolVersion)
 
 	// Advertise if inv messages for transactions are desired.
-	msg.DisableRelayTx = p.cfg.DisableRelayTx
+	msg.DisableRelayTx = p.cfg.DisableRelayTx || p.cfg.BlocksOnly
+
+	// Challenge remote masternodes to authenticate with a mnauth message.
+	// It must not be predictable so a signature can't be replayed.
+	if _, err := crand.Read(msg.MNAuthChallenge[:]); err != nil {
+		return nil, err
+	}
+	p.flagsMtx.Lock()
+	p.sentMNAuthChallenge = msg.MNAuthChallenge
+	p.flagsMtx.Unlock()
 
 	return msg, nil
 }
 
+// writeLocalVerAckMsg writes our verack message to the remote peer.  It is
+// preceded by a sendaddrv2 message when the negotiated protocol version
+// supports addrv2 messages.
+func (p *Peer) writeLocalVerAckMsg() error {
+	if p.ProtocolVersion() >= wire.AddrV2Version {
+		err := p.writeMessage(wire.NewMsgSendAddrV2(), wire.LatestEncoding)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.writeMessage(wire.NewMsgVerAck(), wire.LatestEncoding)
+}
+
 // writeLocalVersionMsg writes our version message to the remote peer.
 func (p *Peer) writeLocalVersionMsg() error {
 	localVerMsg, err := p.localVersionMsg()
@@ -2100,7 +2627,12 @@ func (p *Peer) writeLocalVersionMsg() error {
 //  2. We send our version.
 //  3. We send our verack.
 //  4. Remote peer sends their verack.
+//  5. We send our mnauth when we are a masternode.
+//
+// Either verack may be preceded by a sendaddrv2 message when the negotiated
+// protocol version supports addrv2 messages.
 func (p *Peer) negotiateInboundProtocol() error {
+	p.setHandshakeState(HandshakeAwaitVersion)
 	if err := p.readRemoteVersionMsg(); err != nil {
 		return err
 	}
@@ -2109,12 +2641,17 @@ func (p *Peer) negotiateInboundProtocol() error {
 		return err
 	}
 
-	err := p.writeMessage(wire.NewMsgVerAck(), wire.LatestEncoding)
-	if err != nil {
+	if err := p.writeLocalVerAckMsg(); err != nil {
 		return err
 	}
 
-	return p.readRemoteVerAckMsg()
+	p.setHandshakeState(HandshakeAwaitVerAck)
+	if err := p.readRemoteVerAckMsg(); err != nil {
+		return err
+	}
+
+	p.setHandshakeState(HandshakeComplete)
+	return p.writeLocalMNAuthMsg()
 }
 
 // negotiateOutboundProtocol performs the negotiation protocol for an outbound
@@ -2125,20 +2662,33 @@ func (p *Peer) negotiateInboundProtocol() error {
 //  2. Remote peer sends their version.
 //  3. Remote peer sends their verack.
 //  4. We send our verack.
+//  5. We send our mnauth when we are a masternode.
+//
+// Either verack may be preceded by a sendaddrv2 message when the negotiated
+// protocol version supports addrv2 messages.
 func (p *Peer) negotiateOutboundProtocol() error {
 	if err := p.writeLocalVersionMsg(); err != nil {
 		return err
 	}
 
+	p.setHandshakeState(HandshakeAwaitVersion)
 	if err := p.readRemoteVersionMsg(); err != nil {
 		return err
 	}
 
+	p.setHandshakeState(HandshakeAwaitVerAck)
 	if err := p.readRemoteVerAckMsg(); err != nil {
 		return err
 	}
 
-	return p.writeMessage(wire.NewMsgVerAck(), wire.LatestEncoding)
+	// The handshake is complete from our side once the verack is sent.  A
+	// failure to send it marks the handshake as failed.
+	p.setHandshakeState(HandshakeComplete)
+	if err := p.writeLocalVerAckMsg(); err != nil {
+		return err
+	}
+
+	return p.writeLocalMNAuthMsg()
 }
 
 // start begins processing input and output messages.
@@ -2158,10 +2708,12 @@ func (p *Peer) start() error {
 	select {
 	case err := <-negotiateErr:
 		if err != nil {
+			p.setHandshakeState(HandshakeFailed)
 			p.Disconnect()
 			return err
 		}
 	case <-time.After(negotiateTimeout):
+		p.setHandshakeState(HandshakeFailed)
 		p.Disconnect()
 		return errors.New("protocol negotiation timeout")
 	}
@@ -2241,6 +2793,17 @@ func newPeerBase(origCfg *Config, inbound bool) *Peer {
 		cfg.TrickleInterval = DefaultTrickleInterval
 	}
 
+	// Never downgrade below the minimum version supported by this package.
+	if cfg.MinProtocolVersion < MinAcceptableProtocolVersion {
+		cfg.MinProtocolVersion = MinAcceptableProtocolVersion
+	}
+
+	// Set the handshake message timeout if a non-positive value is
+	// specified.
+	if cfg.HandshakeMsgTimeout <= 0 {
+		cfg.HandshakeMsgTimeout = DefaultHandshakeMsgTimeout
+	}
+
 	p := Peer{
 		inbound:         inbound,
 		wireEncoding:    wire.BaseEncoding,
@@ -77,6 +77,19 @@ type addr struct {
 func (m addr) Network() string { return m.net }
 func (m addr) String() string  { return m.address }
 
+// deadlineConn is a mock connection with fake addresses backed by one end of
+// net.Pipe, which unlike conn honors deadlines.
+type deadlineConn struct {
+	net.Conn
+	laddr, raddr addr
+}
+
+// LocalAddr returns the local address for the connection.
+func (c *deadlineConn) LocalAddr() net.Addr { return &c.laddr }
+
+// RemoteAddr returns the remote address for the connection.
+func (c *deadlineConn) RemoteAddr() net.Addr { return &c.raddr }
+
 // pipe turns two mock connections into a full-duplex connection similar to
 // net.Pipe to allow pipe's with (fake) addresses.
 func pipe(c1, c2 *conn) (*conn, *conn) {
@@ -262,8 +275,8 @@ func TestPeerConnection(t *testing.T) {
 		wantLastPingNonce:   uint64(0),
 		wantLastPingMicros:  int64(0),
 		wantTimeOffset:      int64(0),
-		wantBytesSent:       167, // 143 version + 24 verack
-		wantBytesReceived:   167,
+		wantBytesSent:       199, // 175 version + 24 verack
+		wantBytesReceived:   167, // 143 version + 24 verack
 		wantWitnessEnabled:  false,
 	}
 	wantStats2 := peerStats{
@@ -278,7 +291,7 @@ func TestPeerConnection(t *testing.T) {
 		wantLastPingMicros:  int64(0),
 		wantTimeOffset:      int64(0),
 		wantBytesSent:       167, // 143 version + 24 verack
-		wantBytesReceived:   167,
+		wantBytesReceived:   199, // 175 version + 24 verack
 		wantWitnessEnabled:  true,
 	}
 
@@ -447,6 +460,66 @@ func TestPeerListeners(t *testing.T) {
 			OnSendHeaders: func(p *peer.Peer, msg *wire.MsgSendHeaders) {
 				ok <- msg
 			},
+			OnSendCmpct: func(p *peer.Peer, msg *wire.MsgSendCmpct) {
+				ok <- msg
+			},
+			OnCmpctBlock: func(p *peer.Peer, msg *wire.MsgCmpctBlock) {
+				ok <- msg
+			},
+			OnGetBlockTxn: func(p *peer.Peer, msg *wire.MsgGetBlockTxn) {
+				ok <- msg
+			},
+			OnBlockTxn: func(p *peer.Peer, msg *wire.MsgBlockTxn) {
+				ok <- msg
+			},
+			OnGetMnListDiff: func(p *peer.Peer, msg *wire.MsgGetMnListDiff) {
+				ok <- msg
+			},
+			OnMnListDiff: func(p *peer.Peer, msg *wire.MsgMnListDiff) {
+				ok <- msg
+			},
+			OnGetQRInfo: func(p *peer.Peer, msg *wire.MsgGetQRInfo) {
+				ok <- msg
+			},
+			OnQRInfo: func(p *peer.Peer, msg *wire.MsgQRInfo) {
+				ok <- msg
+			},
+			OnGetSporks: func(p *peer.Peer, msg *wire.MsgGetSporks) {
+				ok <- msg
+			},
+			OnSpork: func(p *peer.Peer, msg *wire.MsgSpork) {
+				ok <- msg
+			},
+			OnSendDsq: func(p *peer.Peer, msg *wire.MsgSendDsq) {
+				ok <- msg
+			},
+			OnQSendRecSigs: func(p *peer.Peer, msg *wire.MsgQSendRecSigs) {
+				ok <- msg
+			},
+			OnMNAuth: func(p *peer.Peer, msg *wire.MsgMNAuth) {
+				ok <- msg
+			},
+			OnCLSig: func(p *peer.Peer, msg *wire.MsgCLSig) {
+				ok <- msg
+			},
+			OnISLock: func(p *peer.Peer, msg *wire.MsgISLock) {
+				ok <- msg
+			},
+			OnQFCommit: func(p *peer.Peer, msg *wire.MsgQFCommit) {
+				ok <- msg
+			},
+			OnQContrib: func(p *peer.Peer, msg *wire.MsgQContrib) {
+				ok <- msg
+			},
+			OnQComplaint: func(p *peer.Peer, msg *wire.MsgQComplaint) {
+				ok <- msg
+			},
+			OnQJustify: func(p *peer.Peer, msg *wire.MsgQJustify) {
+				ok <- msg
+			},
+			OnQPCommit: func(p *peer.Peer, msg *wire.MsgQPCommit) {
+				ok <- msg
+			},
 		},
 		UserAgentName:     "peer",
 		UserAgentVersion:  "1.0",
@@ -597,6 +670,90 @@ func TestPeerListeners(t *testing.T) {
 			"OnSendHeaders",
 			wire.NewMsgSendHeaders(),
 		},
+		{
+			"OnSendCmpct",
+			wire.NewMsgSendCmpct(true, 1),
+		},
+		{
+			"OnCmpctBlock",
+			wire.NewMsgCmpctBlock(wire.NewMsgBlock(wire.NewBlockHeader(1,
+				&chainhash.Hash{}, &chainhash.Hash{}, 1, 1)), 1),
+		},
+		{
+			"OnGetBlockTxn",
+			wire.NewMsgGetBlockTxn(&chainhash.Hash{}, []uint32{0}),
+		},
+		{
+			"OnBlockTxn",
+			wire.NewMsgBlockTxn(&chainhash.Hash{}, nil),
+		},
+		{
+			"OnGetMnListDiff",
+			wire.NewMsgGetMnListDiff(&chainhash.Hash{}, &chainhash.Hash{}),
+		},
+		{
+			"OnMnListDiff",
+			wire.NewMsgMnListDiff(&chainhash.Hash{}, &chainhash.Hash{}),
+		},
+		{
+			"OnGetQRInfo",
+			wire.NewMsgGetQRInfo(nil, &chainhash.Hash{}, false),
+		},
+		{
+			"OnQRInfo",
+			&wire.MsgQRInfo{},
+		},
+		{
+			"OnGetSporks",
+			wire.NewMsgGetSporks(),
+		},
+		{
+			"OnSpork",
+			wire.NewMsgSpork(10001, 0, 1, []byte{0x01}),
+		},
+		{
+			"OnSendDsq",
+			wire.NewMsgSendDsq(true),
+		},
+		{
+			"OnQSendRecSigs",
+			wire.NewMsgQSendRecSigs(true),
+		},
+		{
+			"OnMNAuth",
+			wire.NewMsgMNAuth(&chainhash.Hash{},
+				&[wire.BLSSignatureSize]byte{}),
+		},
+		{
+			"OnCLSig",
+			wire.NewMsgCLSig(1, &chainhash.Hash{},
+				&[wire.BLSSignatureSize]byte{}),
+		},
+		{
+			"OnISLock",
+			wire.NewMsgISLock(nil, &chainhash.Hash{}, &chainhash.Hash{},
+				&[wire.BLSSignatureSize]byte{}),
+		},
+		{
+			"OnQFCommit",
+			wire.NewMsgQFCommit(&wire.QuorumCommitment{Version: 1}),
+		},
+		{
+			"OnQContrib",
+			wire.NewMsgQContrib(&wire.DKGSession{}),
+		},
+		{
+			"OnQComplaint",
+			wire.NewMsgQComplaint(&wire.DKGSession{}, 1),
+		},
+		{
+			"OnQJustify",
+			wire.NewMsgQJustify(&wire.DKGSession{}),
+		},
+		{
+			"OnQPCommit",
+			wire.NewMsgQPCommit(&wire.DKGSession{}, 1),
+		},
 	}
 	t.Logf("Running %d tests", len(tests))
 	for _, test := range tests {
@@ -989,3 +1146,624 @@ func TestUpdateLastBlockHeight(t *testing.T) {
 			remotePeerHeight+1)
 	}
 }
+
+// handshakeTestPeer creates an outbound peer using the passed config that is
+// connected to a fake remote end.  It returns the peer, the remote end of the
+// connection and a channel that receives every message the peer sends.
+func handshakeTestPeer(t *testing.T, cfg *peer.Config) (*peer.Peer, *conn, <-chan wire.Message) {
+	// The local end of the connection honors the read deadlines the peer
+	// uses to time out handshake messages.
+	localPipe, remotePipe := net.Pipe()
+	localConn := &deadlineConn{
+		Conn:  localPipe,
+		laddr: addr{"tcp", "10.0.0.1:9999"},
+		raddr: addr{"tcp", "10.0.0.2:9999"},
+	}
+	remoteConn := &conn{
+		Reader: remotePipe,
+		Writer: remotePipe,
+		Closer: remotePipe,
+		laddr:  "10.0.0.2:9999",
+		raddr:  "10.0.0.1:9999",
+	}
+	p, err := peer.NewOutboundPeer(cfg, "10.0.0.2:9999")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err - %v\n", err)
+	}
+	p.AssociateConnection(localConn)
+
+	outboundMessages := make(chan wire.Message, 10)
+	go func() {
+		for {
+			_, msg, _, err := wire.ReadMessageN(remoteConn,
+				wire.ProtocolVersion, cfg.ChainParams.Net)
+			if err != nil {
+				close(outboundMessages)
+				return
+			}
+			outboundMessages <- msg
+		}
+	}()
+
+	return p, remoteConn, outboundMessages
+}
+
+// handshakeTestVersion returns a version message from the fake remote end
+// advertising the passed protocol version and services.
+func handshakeTestVersion(pver uint32, services wire.ServiceFlag) *wire.MsgVersion {
+	remoteNA := wire.NewNetAddressIPPort(net.ParseIP("10.0.0.2"), 9999,
+		services)
+	localNA := wire.NewNetAddressIPPort(net.ParseIP("10.0.0.1"), 9999, 0)
+	msg := wire.NewMsgVersion(remoteNA, localNA, 0, 0)
+	msg.ProtocolVersion = int32(pver)
+	msg.Services = services
+	return msg
+}
+
+// expectHandshakeMsg waits for the next message sent by the peer and ensures
+// it has the passed command.
+func expectHandshakeMsg(t *testing.T, msgs <-chan wire.Message, command string) wire.Message {
+	t.Helper()
+	select {
+	case msg, ok := <-msgs:
+		if !ok {
+			t.Fatalf("connection closed while waiting for %s", command)
+		}
+		if msg.Command() != command {
+			t.Fatalf("expected %s message, got [%s]", command,
+				msg.Command())
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for %s message", command)
+	}
+	return nil
+}
+
+// waitForHandshakeDisconnect ensures the peer disconnects well before the
+// overall negotiation timeout and that the handshake is marked as failed.
+func waitForHandshakeDisconnect(t *testing.T, p *peer.Peer) {
+	t.Helper()
+	disconnected := make(chan struct{})
+	go func() {
+		p.WaitForDisconnect()
+		close(disconnected)
+	}()
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("peer did not disconnect")
+	}
+	if state := p.HandshakeState(); state != peer.HandshakeFailed {
+		t.Fatalf("wrong handshake state - got %v, want %v", state,
+			peer.HandshakeFailed)
+	}
+}
+
+// TestHandshakeMsgTimeout ensures a peer that doesn't send an expected
+// handshake message in time is disconnected without waiting for the overall
+// negotiation timeout.
+func TestHandshakeMsgTimeout(t *testing.T) {
+	cfg := &peer.Config{
+		ChainParams:         &chaincfg.MainNetParams,
+		AllowSelfConns:      true,
+		HandshakeMsgTimeout: 50 * time.Millisecond,
+	}
+
+	// Never answer the version message.
+	p, _, msgs := handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	waitForHandshakeDisconnect(t, p)
+	if p.VersionKnown() {
+		t.Fatal("version known without a version message")
+	}
+
+	// Answer the version message but never send a verack.
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	_, err := wire.WriteMessageN(remoteConn.Writer,
+		handshakeTestVersion(wire.ProtocolVersion, 0),
+		wire.ProtocolVersion, cfg.ChainParams.Net)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+	}
+	waitForHandshakeDisconnect(t, p)
+	if !p.VersionKnown() || p.VerAckReceived() {
+		t.Fatal("unexpected handshake progress")
+	}
+}
+
+// TestHandshakeDuplicateVersion ensures a second version message received in
+// place of the verack is rejected as a duplicate.
+func TestHandshakeDuplicateVersion(t *testing.T) {
+	cfg := &peer.Config{
+		ChainParams:    &chaincfg.MainNetParams,
+		AllowSelfConns: true,
+	}
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+
+	for i := 0; i < 2; i++ {
+		_, err := wire.WriteMessageN(remoteConn.Writer,
+			handshakeTestVersion(wire.ProtocolVersion, 0),
+			wire.ProtocolVersion, cfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+
+	msg := expectHandshakeMsg(t, msgs, wire.CmdReject)
+	reject := msg.(*wire.MsgReject)
+	if reject.Code != wire.RejectDuplicate || reject.Cmd != wire.CmdVersion {
+		t.Fatalf("unexpected reject - got %v %v", reject.Cmd, reject.Code)
+	}
+	waitForHandshakeDisconnect(t, p)
+}
+
+// TestHandshakeDowngrade ensures the protocol version is downgraded to the one
+// advertised by an older remote peer, that the negotiated features reflect it,
+// and that remote peers below the configured minimum version are rejected.
+func TestHandshakeDowngrade(t *testing.T) {
+	cfg := &peer.Config{
+		ChainParams:    &chaincfg.MainNetParams,
+		AllowSelfConns: true,
+	}
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	if state := p.HandshakeState(); state != peer.HandshakeAwaitVersion &&
+		state != peer.HandshakeInit {
+
+		t.Fatalf("unexpected handshake state %v", state)
+	}
+
+	remoteMsgs := []wire.Message{
+		handshakeTestVersion(wire.BIP0111Version, wire.SFNodeBloom),
+		wire.NewMsgVerAck(),
+	}
+	for _, msg := range remoteMsgs {
+		_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+			wire.BIP0111Version, cfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+
+	if state := p.HandshakeState(); state != peer.HandshakeComplete {
+		t.Fatalf("wrong handshake state - got %v, want %v", state,
+			peer.HandshakeComplete)
+	}
+	want := peer.Features{
+		ProtocolVersion: wire.BIP0111Version,
+		Services:        wire.SFNodeBloom,
+		RelayTx:         true,
+		Pong:            true,
+		Mempool:         true,
+		BloomFilter:     true,
+		Reject:          true,
+	}
+	if got := p.Features(); got != want {
+		t.Fatalf("wrong features - got %+v, want %+v", got, want)
+	}
+	p.Disconnect()
+
+	// Refuse to downgrade below the configured minimum version.
+	cfg.MinProtocolVersion = wire.SendHeadersVersion
+	p, remoteConn, msgs = handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	_, err := wire.WriteMessageN(remoteConn.Writer,
+		handshakeTestVersion(wire.BIP0111Version, wire.SFNodeBloom),
+		wire.BIP0111Version, cfg.ChainParams.Net)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+	}
+	msg := expectHandshakeMsg(t, msgs, wire.CmdReject)
+	if code := msg.(*wire.MsgReject).Code; code != wire.RejectObsolete {
+		t.Fatalf("wrong reject code - got %v, want %v", code,
+			wire.RejectObsolete)
+	}
+	waitForHandshakeDisconnect(t, p)
+}
+
+// TestHandshakeAddrV2 ensures support for addrv2 messages is signaled before
+// the verack when the negotiated protocol version supports them, that the
+// support of the remote peer is recorded, and that addrv2 messages are
+// delivered to the listener.
+func TestHandshakeAddrV2(t *testing.T) {
+	received := make(chan *wire.MsgAddrV2, 1)
+	cfg := &peer.Config{
+		ChainParams:     &chaincfg.MainNetParams,
+		AllowSelfConns:  true,
+		ProtocolVersion: wire.AddrV2Version,
+		Listeners: peer.MessageListeners{
+			OnAddrV2: func(p *peer.Peer, msg *wire.MsgAddrV2) {
+				received <- msg
+			},
+		},
+	}
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+
+	remoteMsgs := []wire.Message{
+		handshakeTestVersion(wire.AddrV2Version, 0),
+		wire.NewMsgSendAddrV2(),
+		wire.NewMsgVerAck(),
+	}
+	for _, msg := range remoteMsgs {
+		_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+			wire.AddrV2Version, cfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+	expectHandshakeMsg(t, msgs, wire.CmdSendAddrV2)
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+	if !p.WantsAddrV2() {
+		t.Fatal("WantsAddrV2: support for addrv2 not recorded")
+	}
+
+	addrV2 := wire.NewMsgAddrV2()
+	addrV2.AddAddress(wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+		wire.NetTorV3, make([]byte, 32), 9999))
+	_, err := wire.WriteMessageN(remoteConn.Writer, addrV2,
+		wire.AddrV2Version, cfg.ChainParams.Net)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+	}
+	select {
+	case msg := <-received:
+		if len(msg.AddrList) != 1 ||
+			msg.AddrList[0].String() != addrV2.AddrList[0].String() {
+
+			t.Fatalf("OnAddrV2: got %v, want %v", msg.AddrList,
+				addrV2.AddrList)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for addrv2 message")
+	}
+
+	// A sendaddrv2 message after the verack is rejected.
+	_, err = wire.WriteMessageN(remoteConn.Writer, wire.NewMsgSendAddrV2(),
+		wire.AddrV2Version, cfg.ChainParams.Net)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+	}
+	msg := expectHandshakeMsg(t, msgs, wire.CmdReject)
+	if cmd := msg.(*wire.MsgReject).Cmd; cmd != wire.CmdSendAddrV2 {
+		t.Fatalf("wrong reject command - got %v, want %v", cmd,
+			wire.CmdSendAddrV2)
+	}
+	p.WaitForDisconnect()
+
+	// Older remote peers are not sent a sendaddrv2 message.
+	p, remoteConn, msgs = handshakeTestPeer(t, cfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	remoteMsgs = []wire.Message{
+		handshakeTestVersion(wire.ISDLockVersion, 0),
+		wire.NewMsgVerAck(),
+	}
+	for _, msg := range remoteMsgs {
+		_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+			wire.ISDLockVersion, cfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+	if p.WantsAddrV2() {
+		t.Fatal("WantsAddrV2: unexpected support for addrv2")
+	}
+	p.Disconnect()
+}
+
+// TestHandshakeStateStringer tests the stringized output for the handshake
+// states.
+func TestHandshakeStateStringer(t *testing.T) {
+	tests := []struct {
+		in   peer.HandshakeState
+		want string
+	}{
+		{peer.HandshakeInit, "HandshakeInit"},
+		{peer.HandshakeAwaitVersion, "HandshakeAwaitVersion"},
+		{peer.HandshakeAwaitVerAck, "HandshakeAwaitVerAck"},
+		{peer.HandshakeComplete, "HandshakeComplete"},
+		{peer.HandshakeFailed, "HandshakeFailed"},
+		{0xff, "Unknown HandshakeState (255)"},
+	}
+	for i, test := range tests {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("String #%d - got %s, want %s", i, got, test.want)
+		}
+	}
+}
+
+// TestBlocksOnlyPeer ensures a peer in blocks-only mode asks the remote peer
+// to not relay transactions, that only block inventory is relayed in both
+// directions, and that InstantSend locks are ignored.
+func TestBlocksOnlyPeer(t *testing.T) {
+	received := make(chan wire.Message, 10)
+	cfg := &peer.Config{
+		ChainParams:     &chaincfg.MainNetParams,
+		AllowSelfConns:  true,
+		BlocksOnly:      true,
+		TrickleInterval: 10 * time.Millisecond,
+		Listeners: peer.MessageListeners{
+			OnTx: func(p *peer.Peer, msg *wire.MsgTx) {
+				received <- msg
+			},
+			OnInv: func(p *peer.Peer, msg *wire.MsgInv) {
+				received <- msg
+			},
+			OnISLock: func(p *peer.Peer, msg *wire.MsgISLock) {
+				received <- msg
+			},
+		},
+	}
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	defer p.Disconnect()
+	if !p.BlocksOnly() {
+		t.Fatal("BlocksOnly: peer not in blocks-only mode")
+	}
+
+	msg := expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	if !msg.(*wire.MsgVersion).DisableRelayTx {
+		t.Fatal("version message does not disable transaction relay")
+	}
+	writeMsgs := func(msgs ...wire.Message) {
+		t.Helper()
+		for _, msg := range msgs {
+			_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+				wire.ProtocolVersion, cfg.ChainParams.Net)
+			if err != nil {
+				t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+			}
+		}
+	}
+	writeMsgs(handshakeTestVersion(wire.ProtocolVersion, 0),
+		wire.NewMsgVerAck())
+	expectHandshakeMsg(t, msgs, wire.CmdSendAddrV2)
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+
+	// Only the block inventory must be announced to the remote peer.
+	txInv := wire.NewInvVect(wire.InvTypeTx, &chainhash.Hash{0x01})
+	blockInv := wire.NewInvVect(wire.InvTypeBlock, &chainhash.Hash{0x02})
+	p.QueueInventory(txInv)
+	p.QueueInventory(blockInv)
+	inv := expectHandshakeMsg(t, msgs, wire.CmdInv).(*wire.MsgInv)
+	if len(inv.InvList) != 1 || *inv.InvList[0] != *blockInv {
+		t.Fatalf("unexpected announced inventory %v", inv.InvList)
+	}
+
+	// Transactions, their locks and their inventory sent by the remote
+	// peer must be ignored, while the block inventory is still handled.
+	remoteInv := wire.NewMsgInv()
+	remoteInv.AddInvVect(txInv)
+	remoteInv.AddInvVect(blockInv)
+	isLock := wire.NewMsgISLock(nil, &chainhash.Hash{0x01}, &chainhash.Hash{},
+		&[wire.BLSSignatureSize]byte{})
+	writeMsgs(wire.NewMsgTx(1), isLock, remoteInv)
+	select {
+	case msg := <-received:
+		inv, ok := msg.(*wire.MsgInv)
+		if !ok {
+			t.Fatalf("unexpected %s message handled", msg.Command())
+		}
+		if len(inv.InvList) != 1 || *inv.InvList[0] != *blockInv {
+			t.Fatalf("unexpected handled inventory %v", inv.InvList)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for inv message")
+	}
+}
+
+// TestFeaturesSupportsMessage ensures messages are only reported as supported
+// by the protocol versions which added them.
+func TestFeaturesSupportsMessage(t *testing.T) {
+	tests := []struct {
+		pver    uint32
+		command string
+		want    bool
+	}{
+		{wire.MultipleAddressVersion, wire.CmdInv, true},
+		{wire.BIP0031Version, wire.CmdPong, false},
+		{wire.BIP0031Version + 1, wire.CmdPong, true},
+		{wire.BIP0111Version, wire.CmdSendHeaders, false},
+		{wire.SendHeadersVersion, wire.CmdSendHeaders, true},
+		{wire.FeeFilterVersion, wire.CmdSendCmpct, false},
+		{wire.ShortIDsBlocksVersion, wire.CmdCmpctBlock, true},
+		{wire.ShortIDsBlocksVersion, wire.CmdCLSig, false},
+		{wire.LLMQVersion, wire.CmdCLSig, true},
+		{wire.LLMQVersion, wire.CmdQFCommit, true},
+		{wire.LLMQVersion, wire.CmdISLock, true},
+		{wire.LLMQVersion, wire.CmdISDLock, false},
+		{wire.ISDLockVersion, wire.CmdISDLock, true},
+		{wire.ISDLockVersion, wire.CmdSendAddrV2, false},
+		{wire.AddrV2Version, wire.CmdAddrV2, true},
+	}
+	for i, test := range tests {
+		f := peer.Features{ProtocolVersion: test.pver}
+		if got := f.SupportsMessage(test.command); got != test.want {
+			t.Errorf("SupportsMessage #%d (%s at %d) - got %v, "+
+				"want %v", i, test.command, test.pver, got,
+				test.want)
+		}
+	}
+}
+
+// TestUnsupportedMessageNotSent ensures messages the remote peer doesn't
+// support at the negotiated protocol version are dropped instead of being
+// sent, and that the peer stays connected.
+func TestUnsupportedMessageNotSent(t *testing.T) {
+	cfg := &peer.Config{
+		ChainParams:    &chaincfg.MainNetParams,
+		AllowSelfConns: true,
+	}
+	p, remoteConn, msgs := handshakeTestPeer(t, cfg)
+	defer p.Disconnect()
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+
+	remoteMsgs := []wire.Message{
+		handshakeTestVersion(wire.ShortIDsBlocksVersion, 0),
+		wire.NewMsgVerAck(),
+	}
+	for _, msg := range remoteMsgs {
+		_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+			wire.ShortIDsBlocksVersion, cfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+
+	features := p.Features()
+	if !features.CompactBlocks || features.LLMQ || features.ISDLock ||
+		features.AddrV2 {
+
+		t.Fatalf("wrong Dash features - got %+v", features)
+	}
+
+	done := make(chan struct{}, 1)
+	p.QueueMessage(wire.NewMsgCLSig(1, &chainhash.Hash{},
+		&[wire.BLSSignatureSize]byte{}), done)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for dropped message")
+	}
+
+	// The next message sent must be the supported one.
+	p.QueueMessage(wire.NewMsgSendCmpct(false, 1), nil)
+	expectHandshakeMsg(t, msgs, wire.CmdSendCmpct)
+	if !p.Connected() {
+		t.Fatal("peer disconnected after unsupported message")
+	}
+}
+
+// mockMNAuthKey implements peer.MNAuthSigner and peer.MNAuthVerifier with a
+// fake signature scheme where the signature is the signed hash followed by the
+// public key.
+type mockMNAuthKey struct {
+	proRegTxHash chainhash.Hash
+	pubKey       [wire.BLSPublicKeySize]byte
+}
+
+func (k *mockMNAuthKey) ProRegTxHash() *chainhash.Hash {
+	return &k.proRegTxHash
+}
+
+func (k *mockMNAuthKey) OperatorPubKey() *[wire.BLSPublicKeySize]byte {
+	return &k.pubKey
+}
+
+func (k *mockMNAuthKey) Sign(hash *chainhash.Hash) (*[wire.BLSSignatureSize]byte, error) {
+	var sig [wire.BLSSignatureSize]byte
+	copy(sig[:], hash[:])
+	copy(sig[chainhash.HashSize:], k.pubKey[:])
+	return &sig, nil
+}
+
+// mockMNAuthVerifier knows the operator key of a single masternode.
+type mockMNAuthVerifier struct {
+	key *mockMNAuthKey
+}
+
+func (v mockMNAuthVerifier) OperatorPubKey(proRegTxHash *chainhash.Hash) (*[wire.BLSPublicKeySize]byte, error) {
+	if *proRegTxHash != v.key.proRegTxHash {
+		return nil, errors.New("unknown masternode")
+	}
+	return &v.key.pubKey, nil
+}
+
+func (v mockMNAuthVerifier) VerifyInsecure(pubKey *[wire.BLSPublicKeySize]byte,
+	hash *chainhash.Hash, sig *[wire.BLSSignatureSize]byte) bool {
+
+	want, _ := (&mockMNAuthKey{pubKey: *pubKey}).Sign(hash)
+	return *sig == *want
+}
+
+// TestMNAuth ensures a masternode peer authenticates with a mnauth message
+// after the version handshake, that the verifying peer exposes the ProRegTx
+// hash of the masternode, and that invalid mnauth messages are rejected.
+func TestMNAuth(t *testing.T) {
+	key := &mockMNAuthKey{
+		proRegTxHash: chainhash.Hash{0x01},
+		pubKey:       [wire.BLSPublicKeySize]byte{0x02},
+	}
+	received := make(chan *wire.MsgMNAuth, 1)
+	verifierCfg := &peer.Config{
+		ChainParams:    &chaincfg.MainNetParams,
+		AllowSelfConns: true,
+		MNAuthVerifier: mockMNAuthVerifier{key},
+		Listeners: peer.MessageListeners{
+			OnMNAuth: func(p *peer.Peer, msg *wire.MsgMNAuth) {
+				received <- msg
+			},
+		},
+	}
+	masternodeCfg := &peer.Config{
+		ChainParams:    &chaincfg.MainNetParams,
+		AllowSelfConns: true,
+		MNAuthSigner:   key,
+	}
+
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:9999"},
+		&conn{raddr: "10.0.0.2:9999"},
+	)
+	inPeer := peer.NewInboundPeer(verifierCfg)
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := peer.NewOutboundPeer(masternodeCfg, "10.0.0.2:9999")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err - %v\n", err)
+	}
+	outPeer.AssociateConnection(outConn)
+	defer inPeer.Disconnect()
+	defer outPeer.Disconnect()
+
+	select {
+	case msg := <-received:
+		if msg.ProRegTxHash != key.proRegTxHash {
+			t.Fatalf("OnMNAuth: got %v, want %v", msg.ProRegTxHash,
+				key.proRegTxHash)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for mnauth message")
+	}
+	got := inPeer.VerifiedProRegTxHash()
+	if got == nil || *got != key.proRegTxHash {
+		t.Fatalf("VerifiedProRegTxHash: got %v, want %v", got,
+			key.proRegTxHash)
+	}
+	if outPeer.VerifiedProRegTxHash() != nil {
+		t.Fatal("VerifiedProRegTxHash: unexpected masternode")
+	}
+
+	// A mnauth message which doesn't sign the challenge of the verifying
+	// peer is rejected.
+	p, remoteConn, msgs := handshakeTestPeer(t, verifierCfg)
+	expectHandshakeMsg(t, msgs, wire.CmdVersion)
+	sig, _ := key.Sign(&chainhash.Hash{})
+	remoteMsgs := []wire.Message{
+		handshakeTestVersion(wire.ProtocolVersion, 0),
+		wire.NewMsgVerAck(),
+		wire.NewMsgMNAuth(&key.proRegTxHash, sig),
+	}
+	for _, msg := range remoteMsgs {
+		_, err := wire.WriteMessageN(remoteConn.Writer, msg,
+			wire.ProtocolVersion, verifierCfg.ChainParams.Net)
+		if err != nil {
+			t.Fatalf("WriteMessageN: unexpected err - %v\n", err)
+		}
+	}
+	expectHandshakeMsg(t, msgs, wire.CmdSendAddrV2)
+	expectHandshakeMsg(t, msgs, wire.CmdVerAck)
+	msg := expectHandshakeMsg(t, msgs, wire.CmdReject)
+	if cmd := msg.(*wire.MsgReject).Cmd; cmd != wire.CmdMNAuth {
+		t.Fatalf("wrong reject command - got %v, want %v", cmd,
+			wire.CmdMNAuth)
+	}
+	p.WaitForDisconnect()
+	if p.VerifiedProRegTxHash() != nil {
+		t.Fatal("VerifiedProRegTxHash: unexpected masternode")
+	}
+}
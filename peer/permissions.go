@@ -0,0 +1,112 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PermissionFlags is a bitmask of the permissions granted to a remote peer,
+// such as a paired wallet backend which is trusted by the local node.  They are
+// set with Config.Permissions and are enforced by the caller, typically by the
+// handlers of the message listeners.
+type PermissionFlags uint32
+
+const (
+	// PermissionNoBan indicates the peer is neither banned nor disconnected
+	// for misbehavior, and that the rate limits which are enforced with ban
+	// scores don't apply to it.
+	PermissionNoBan PermissionFlags = 1 << iota
+
+	// PermissionRelay indicates transactions of the peer are accepted and
+	// relayed even when the local node only relays blocks.
+	PermissionRelay
+
+	// PermissionForceRelay indicates transactions of the peer are relayed
+	// even when they are already known.  It implies PermissionRelay.
+	PermissionForceRelay
+
+	// PermissionMempool indicates the peer may request the contents of the
+	// mempool with a mempool message.
+	PermissionMempool
+
+	// PermissionAll is the combination of all permissions.
+	PermissionAll = PermissionNoBan | PermissionRelay |
+		PermissionForceRelay | PermissionMempool
+)
+
+// Map of permission flags back to their names as used in configuration.
+var permissionFlagStrings = map[PermissionFlags]string{
+	PermissionNoBan:      "noban",
+	PermissionRelay:      "relay",
+	PermissionForceRelay: "forcerelay",
+	PermissionMempool:    "mempool",
+}
+
+// orderedPermissionFlags is the order in which permission flags are named.
+var orderedPermissionFlags = []PermissionFlags{
+	PermissionNoBan,
+	PermissionRelay,
+	PermissionForceRelay,
+	PermissionMempool,
+}
+
+// Names returns the names of the permissions which are set.  Unknown
+// permissions are named by their hex value.
+func (f PermissionFlags) Names() []string {
+	var names []string
+	for _, flag := range orderedPermissionFlags {
+		if f&flag == flag {
+			names = append(names, permissionFlagStrings[flag])
+			f -= flag
+		}
+	}
+	if f != 0 {
+		names = append(names, "0x"+strconv.FormatUint(uint64(f), 16))
+	}
+	return names
+}
+
+// String returns the PermissionFlags as a comma separated list of the names of
+// the permissions which are set, as accepted by ParsePermissionFlags.
+func (f PermissionFlags) String() string {
+	return strings.Join(f.Names(), ",")
+}
+
+// ParsePermissionFlags parses a comma separated list of permission names into
+// PermissionFlags.  Besides the names of the individual permissions, "all"
+// grants all of them.  Granting PermissionForceRelay grants PermissionRelay as
+// well.
+func ParsePermissionFlags(s string) (PermissionFlags, error) {
+	var flags PermissionFlags
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			flags |= PermissionAll
+			continue
+		}
+
+		var found bool
+		for flag, flagName := range permissionFlagStrings {
+			if name == flagName {
+				flags |= flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+	}
+	if flags&PermissionForceRelay != 0 {
+		flags |= PermissionRelay
+	}
+	return flags, nil
+}
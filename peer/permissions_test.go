@@ -0,0 +1,64 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer_test
+
+import (
+	"testing"
+
+	"github.com/dashpay/dashd-go/peer"
+)
+
+// TestParsePermissionFlags ensures permission flags are parsed from and
+// formatted to their names as expected.
+func TestParsePermissionFlags(t *testing.T) {
+	tests := []struct {
+		in    string
+		flags peer.PermissionFlags
+		str   string
+		valid bool
+	}{
+		{"", 0, "", true},
+		{"noban", peer.PermissionNoBan, "noban", true},
+		{"mempool, noban", peer.PermissionNoBan | peer.PermissionMempool,
+			"noban,mempool", true},
+		{"forcerelay", peer.PermissionRelay | peer.PermissionForceRelay,
+			"relay,forcerelay", true},
+		{"all", peer.PermissionAll, "noban,relay,forcerelay,mempool", true},
+		{"noban,bogus", 0, "", false},
+	}
+
+	for i, test := range tests {
+		flags, err := peer.ParsePermissionFlags(test.in)
+		if (err == nil) != test.valid {
+			t.Errorf("ParsePermissionFlags #%d (%q): unexpected "+
+				"error: %v", i, test.in, err)
+			continue
+		}
+		if flags != test.flags {
+			t.Errorf("ParsePermissionFlags #%d (%q): got %#x, want "+
+				"%#x", i, test.in, flags, test.flags)
+			continue
+		}
+		if str := flags.String(); str != test.str {
+			t.Errorf("String #%d: got %q, want %q", i, str, test.str)
+		}
+	}
+
+	// Unknown flags are formatted as hex.
+	if str := peer.PermissionFlags(0x101).String(); str != "noban,0x100" {
+		t.Errorf("String: got %q, want %q", str, "noban,0x100")
+	}
+
+	// Permissions are available from the peer.
+	p := peer.NewInboundPeer(&peer.Config{
+		Permissions: peer.PermissionMempool,
+	})
+	if !p.HasPermission(peer.PermissionMempool) ||
+		p.HasPermission(peer.PermissionMempool|peer.PermissionNoBan) {
+
+		t.Errorf("HasPermission: unexpected result for %v",
+			p.Permissions())
+	}
+}
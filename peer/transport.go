@@ -0,0 +1,126 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTransport is the name of the transport used for peer connections
+// unless another one is selected.
+const DefaultTransport = "tcp"
+
+// Transport abstracts the network transport peer connections are established
+// over.  A peer only requires its connection to be a reliable ordered byte
+// stream, so any transport that provides net.Conn and net.Listener
+// implementations, such as a stream multiplexed over QUIC, can be used in place
+// of TCP.
+//
+// Transports are registered with RegisterTransport so applications can select
+// them by name without the peer package depending on their implementation.
+type Transport interface {
+	// Name returns the name used to uniquely identify the transport.
+	Name() string
+
+	// Experimental returns whether the transport is experimental.
+	// Experimental transports are not expected to be supported by other
+	// nodes and should only be used on private test networks.
+	Experimental() bool
+
+	// Dial connects to the passed address on the named network, which is
+	// one of the networks accepted by net.Dial such as "tcp4", within the
+	// passed timeout.
+	Dial(network, address string, timeout time.Duration) (net.Conn, error)
+
+	// Listen listens for inbound connections at the passed address on the
+	// named network.
+	Listen(network, address string) (net.Listener, error)
+}
+
+// tcpTransport is the default transport which establishes peer connections
+// over TCP.
+type tcpTransport struct{}
+
+// Name returns the name of the TCP transport.
+//
+// This is part of the Transport interface.
+func (tcpTransport) Name() string {
+	return DefaultTransport
+}
+
+// Experimental returns false since TCP is the transport used by all nodes.
+//
+// This is part of the Transport interface.
+func (tcpTransport) Experimental() bool {
+	return false
+}
+
+// Dial connects to the passed address over TCP.
+//
+// This is part of the Transport interface.
+func (tcpTransport) Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout(network, address, timeout)
+}
+
+// Listen listens for inbound TCP connections at the passed address.
+//
+// This is part of the Transport interface.
+func (tcpTransport) Listen(network, address string) (net.Listener, error) {
+	return net.Listen(network, address)
+}
+
+var (
+	// transportsMtx protects transports.
+	transportsMtx sync.RWMutex
+
+	// transports holds all registered transports keyed by name.
+	transports = map[string]Transport{
+		DefaultTransport: tcpTransport{},
+	}
+)
+
+// RegisterTransport adds a transport to the available transports.  An error is
+// returned if a transport with the same name has already been registered.
+func RegisterTransport(transport Transport) error {
+	transportsMtx.Lock()
+	defer transportsMtx.Unlock()
+
+	name := transport.Name()
+	if _, exists := transports[name]; exists {
+		return fmt.Errorf("transport %q is already registered", name)
+	}
+	transports[name] = transport
+	return nil
+}
+
+// LookupTransport returns the registered transport with the passed name.  An
+// error is returned if no transport with the name has been registered.
+func LookupTransport(name string) (Transport, error) {
+	transportsMtx.RLock()
+	defer transportsMtx.RUnlock()
+
+	transport, exists := transports[name]
+	if !exists {
+		return nil, fmt.Errorf("transport %q is not registered", name)
+	}
+	return transport, nil
+}
+
+// SupportedTransports returns the sorted names of all registered transports.
+func SupportedTransports() []string {
+	transportsMtx.RLock()
+	defer transportsMtx.RUnlock()
+
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
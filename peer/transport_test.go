@@ -0,0 +1,83 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dashpay/dashd-go/peer"
+)
+
+// pipeTransport is an experimental transport used to test transport
+// registration.
+type pipeTransport struct{}
+
+func (pipeTransport) Name() string       { return "pipe" }
+func (pipeTransport) Experimental() bool { return true }
+func (pipeTransport) Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+func (pipeTransport) Listen(network, address string) (net.Listener, error) {
+	return nil, errors.New("not implemented")
+}
+
+// TestTransports ensures transports are registered and looked up as expected
+// and that the default TCP transport connects peers.
+func TestTransports(t *testing.T) {
+	tcp, err := peer.LookupTransport(peer.DefaultTransport)
+	if err != nil {
+		t.Fatalf("LookupTransport: unexpected error: %v", err)
+	}
+	if tcp.Name() != peer.DefaultTransport || tcp.Experimental() {
+		t.Fatalf("LookupTransport: unexpected default transport %q",
+			tcp.Name())
+	}
+
+	if _, err := peer.LookupTransport("pipe"); err == nil {
+		t.Fatal("LookupTransport: expected error for unknown transport")
+	}
+	if err := peer.RegisterTransport(pipeTransport{}); err != nil {
+		t.Fatalf("RegisterTransport: unexpected error: %v", err)
+	}
+	if err := peer.RegisterTransport(pipeTransport{}); err == nil {
+		t.Fatal("RegisterTransport: expected error for duplicate " +
+			"transport")
+	}
+	pipe, err := peer.LookupTransport("pipe")
+	if err != nil || !pipe.Experimental() {
+		t.Fatalf("LookupTransport: unexpected result %v, %v", pipe, err)
+	}
+	want := []string{"pipe", peer.DefaultTransport}
+	if got := peer.SupportedTransports(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SupportedTransports: got %v, want %v", got, want)
+	}
+
+	// Connect over the loopback interface using the TCP transport.
+	listener, err := tcp.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: unexpected error: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+		accepted <- err
+	}()
+	conn, err := tcp.Dial("tcp", listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("Dial: unexpected error: %v", err)
+	}
+	conn.Close()
+	if err := <-accepted; err != nil {
+		t.Fatalf("Accept: unexpected error: %v", err)
+	}
+}
@@ -61,6 +61,24 @@ func (p *rpcPeer) FeeFilter() int64 {
 	return atomic.LoadInt64(&(*serverPeer)(p).feeFilter)
 }
 
+// IsPersistent returns whether or not the peer is reconnected to when the
+// connection is lost.
+//
+// This function is safe for concurrent access and is part of the rpcserverPeer
+// interface implementation.
+func (p *rpcPeer) IsPersistent() bool {
+	return (*serverPeer)(p).persistent
+}
+
+// IsWhitelisted returns whether or not the peer is whitelisted, which is the
+// case when it was granted any permissions.
+//
+// This function is safe for concurrent access and is part of the rpcserverPeer
+// interface implementation.
+func (p *rpcPeer) IsWhitelisted() bool {
+	return (*serverPeer)(p).Permissions() != 0
+}
+
 // rpcConnManager provides a connection manager for use with the RPC server and
 // implements the rpcserverConnManager interface.
 type rpcConnManager struct {
@@ -848,7 +848,10 @@ func (c *Client) EstimateFeeAsync(numBlocks int64) FutureEstimateFeeResult {
 	return c.SendCmd(cmd)
 }
 
-// EstimateFee provides an estimated fee  in bitcoins per kilobyte.
+// EstimateFee provides an estimated fee in DASH per kilobyte.
+//
+// Deprecated: The estimatefee RPC is superseded by estimatesmartfee and is not
+// available on recent versions of Dash Core.  Use EstimateSmartFee instead.
 func (c *Client) EstimateFee(numBlocks int64) (float64, error) {
 	return c.EstimateFeeAsync(numBlocks).Receive()
 }
@@ -883,7 +886,17 @@ func (c *Client) EstimateSmartFeeAsync(confTarget int64, mode *btcjson.EstimateS
 	return c.SendCmd(cmd)
 }
 
-// EstimateSmartFee requests the server to estimate a fee level based on the given parameters.
+// EstimateSmartFee requests the server to estimate the fee rate, in DASH per
+// kilobyte, needed for a transaction to begin confirmation within confTarget
+// blocks.  The mode selects between btcjson.EstimateModeConservative, which
+// favours a higher estimate that is less likely to be insufficient, and
+// btcjson.EstimateModeEconomical, which reacts faster to short term drops in
+// fees.  A nil mode uses the server default, which is conservative for Dash
+// Core.
+//
+// The returned result includes the errors reported by the server when it lacks
+// the data for an estimate.  Use its FeeRatePerKB method to obtain the
+// estimate as an amount.
 func (c *Client) EstimateSmartFee(confTarget int64, mode *btcjson.EstimateSmartFeeMode) (*btcjson.EstimateSmartFeeResult, error) {
 	return c.EstimateSmartFeeAsync(confTarget, mode).Receive()
 }
@@ -1064,6 +1077,49 @@ func (c *Client) GetTxOutSetInfo() (*btcjson.GetTxOutSetInfoResult, error) {
 	return c.GetTxOutSetInfoAsync().Receive()
 }
 
+// FutureDumpTxOutSetResult is a future promise to deliver the result of a
+// DumpTxOutSetAsync RPC invocation (or an applicable error).
+type FutureDumpTxOutSetResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// results of DumpTxOutSetAsync RPC invocation.
+func (r FutureDumpTxOutSetResult) Receive() (*btcjson.DumpTxOutSetResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a dumptxoutset result object.
+	var dumpResult btcjson.DumpTxOutSetResult
+	err = json.Unmarshal(res, &dumpResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dumpResult, nil
+}
+
+// DumpTxOutSetAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See DumpTxOutSet for the blocking version and more details.
+func (c *Client) DumpTxOutSetAsync(path string) FutureDumpTxOutSetResult {
+	cmd := btcjson.NewDumpTxOutSetCmd(path)
+	return c.SendCmd(cmd)
+}
+
+// DumpTxOutSet instructs the server to write a snapshot of the unspent
+// transaction output set to the passed path on the server's file system.  Use
+// SaveSnapshot to store a copy of the snapshot obtained from elsewhere, such
+// as a download mirror, locally.
+//
+// NOTE: This command can take a considerable amount of time to complete on
+// mainnet since the entire unspent transaction output set is written out.
+func (c *Client) DumpTxOutSet(path string) (*btcjson.DumpTxOutSetResult, error) {
+	return c.DumpTxOutSetAsync(path).Receive()
+}
+
 // FutureRescanBlocksResult is a future promise to deliver the result of a
 // RescanBlocksAsync RPC invocation (or an applicable error).
 //
@@ -90,3 +90,26 @@ func TestUnmarshalGetBlockChainInfoResultSoftForks(t *testing.T) {
 		}
 	}
 }
+
+// TestEstimateSmartFeeReceive ensures the estimatesmartfee response is decoded
+// into a typed result.
+func TestEstimateSmartFeeReceive(t *testing.T) {
+	t.Parallel()
+
+	future := make(FutureEstimateSmartFeeResult, 1)
+	future <- &Response{result: []byte(`{"feerate":0.0001,"blocks":6}`)}
+	res, err := future.Receive()
+	if err != nil {
+		t.Fatalf("Receive: unexpected error: %v", err)
+	}
+	if res.Blocks != 6 {
+		t.Fatalf("wrong blocks - got %d, want 6", res.Blocks)
+	}
+	feeRate, err := res.FeeRatePerKB()
+	if err != nil {
+		t.Fatalf("FeeRatePerKB: unexpected error: %v", err)
+	}
+	if feeRate != 10000 {
+		t.Fatalf("wrong fee rate - got %d, want 10000", int64(feeRate))
+	}
+}
@@ -40,6 +40,14 @@ can be invoked without having to go through a connect/disconnect cycle for every
 call.  In addition, the websocket interface provides other nice features such as
 the ability to register for asynchronous notifications of various events.
 
+# Multiple Wallets
+
+A dashd server with several wallets loaded serves each of them on its own
+/wallet/<name> endpoint.  In HTTP POST mode, the Wallet field of ConnConfig
+routes all commands of a client to a wallet, and SendCmdToWallet routes a
+single command to any wallet.  The CreateWallet, LoadWallet, UnloadWallet and
+ListWallets functions manage the loaded wallets.
+
 # Synchronous vs Asynchronous API
 
 The client provides both a synchronous (blocking) and asynchronous API.
@@ -67,6 +67,12 @@ var (
 	ErrNotWebsocketClient = errors.New("client is not configured for " +
 		"websockets")
 
+	// ErrWalletNotHTTPPost is an error to describe the condition of routing
+	// a request to a wallet when the client has been configured to use
+	// websockets, which only reach the default endpoint.
+	ErrWalletNotHTTPPost = errors.New("requests can only be routed to a " +
+		"wallet in HTTP POST mode")
+
 	// ErrClientAlreadyConnected is an error to describe the condition where
 	// a new client connection cannot be established due to a websocket
 	// client having already connected to the RPC server.
@@ -100,6 +106,10 @@ type jsonRequest struct {
 	cmd            interface{}
 	marshalledJSON []byte
 	responseChan   chan *Response
+
+	// wallet is the name of the wallet the request is routed to.  The
+	// request is sent to the default endpoint when it is empty.
+	wallet string
 }
 
 // BackendVersion represents the version of the backend the client is currently
@@ -766,7 +776,7 @@ func (c *Client) handleSendPostMessage(jReq *jsonRequest) {
 	if !c.config.DisableTLS {
 		protocol = "https"
 	}
-	url := protocol + "://" + c.config.Host
+	url := protocol + "://" + c.config.Host + walletPath(jReq.wallet)
 
 	// Configure basic access authorization.
 	user, pass, err := c.config.getAuth()
@@ -960,11 +970,53 @@ func (c *Client) sendRequest(jReq *jsonRequest) {
 	c.sendMessage(jReq.marshalledJSON)
 }
 
+// walletPath returns the path of the endpoint of the passed wallet on the
+// RPC server, which is empty for the default endpoint.
+func walletPath(wallet string) string {
+	if wallet == "" {
+		return ""
+	}
+	return "/wallet/" + url.PathEscape(wallet)
+}
+
 // SendCmd sends the passed command to the associated server and returns a
 // response channel on which the reply will be delivered at some point in the
 // future.  It handles both websocket and HTTP POST mode depending on the
 // configuration of the client.
+//
+// In HTTP POST mode, the command is routed to the wallet configured by the
+// Wallet field of the connection configuration, if any.
 func (c *Client) SendCmd(cmd interface{}) chan *Response {
+	return c.sendCmd(cmd, c.config.Wallet)
+}
+
+// SendCmdToWallet sends the passed command to the endpoint of the passed
+// wallet on the associated server, such as /wallet/<name>, regardless of the
+// wallet configured for the client.  This allows a single client to use several
+// of the wallets loaded by a server in multiwallet mode, and an empty wallet
+// name routes the command to the default endpoint.
+//
+// Routing is only supported in HTTP POST mode.  Batched commands are all sent
+// to the wallet configured for the client, so routing a batched command to a
+// different wallet results in an error.
+func (c *Client) SendCmdToWallet(wallet string, cmd interface{}) chan *Response {
+	switch {
+	case wallet != "" && !c.config.HTTPPostMode:
+		return newFutureError(ErrWalletNotHTTPPost)
+
+	case c.batch && wallet != c.config.Wallet:
+		return newFutureError(fmt.Errorf("batched commands can't be "+
+			"routed to wallet %q instead of %q", wallet,
+			c.config.Wallet))
+	}
+
+	return c.sendCmd(cmd, wallet)
+}
+
+// sendCmd sends the passed command to the endpoint of the passed wallet on the
+// associated server and returns a response channel on which the reply will be
+// delivered at some point in the future.
+func (c *Client) sendCmd(cmd interface{}, wallet string) chan *Response {
 	rpcVersion := btcjson.RpcVersion1
 	if c.batch {
 		rpcVersion = btcjson.RpcVersion2
@@ -990,6 +1042,7 @@ func (c *Client) SendCmd(cmd interface{}) chan *Response {
 		cmd:            cmd,
 		marshalledJSON: marshalledJSON,
 		responseChan:   responseChan,
+		wallet:         wallet,
 	}
 
 	c.sendRequest(jReq)
@@ -1164,6 +1217,12 @@ type ConnConfig struct {
 	// typically "ws".
 	Endpoint string
 
+	// Wallet is the name of the wallet that commands are routed to when the
+	// RPC server has several wallets loaded.  Commands are sent to the
+	// /wallet/<name> endpoint of the server, or to the default endpoint
+	// when it is empty.  It requires HTTP POST mode.
+	Wallet string
+
 	// User is the username to use to authenticate to the RPC server.
 	User string
 
@@ -1389,6 +1448,11 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 // interested in receiving notifications and will be ignored if the
 // configuration is set to run in HTTP POST mode.
 func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error) {
+	// Commands can only be routed to a wallet in HTTP POST mode.
+	if config.Wallet != "" && !config.HTTPPostMode {
+		return nil, ErrWalletNotHTTPPost
+	}
+
 	// Either open a websocket connection or create an HTTP client depending
 	// on the HTTP POST mode.  Also, set the notification handlers to nil
 	// when running in HTTP POST mode.
@@ -1615,6 +1679,7 @@ func (c *Client) sendAsync() FutureGetBulkResult {
 		cmd:            nil,
 		marshalledJSON: marshalledRequest,
 		responseChan:   responseChan,
+		wallet:         c.config.Wallet,
 	}
 	c.sendPostRequest(&request)
 	return responseChan
@@ -300,3 +300,66 @@ func (c *Client) MasternodeListJSON(filter string) (map[string]btcjson.Masternod
 	}
 	return r.(map[string]btcjson.MasternodelistResultJSON), nil
 }
+
+// ----------------- gobject list / diff ---------------------
+
+// FutureGetGObjectsResult is a future promise to deliver the result of a
+// GObjectListAsync or GObjectDiffAsync RPC invocation (or an applicable error).
+type FutureGetGObjectsResult struct {
+	client   *Client
+	Response chan *Response
+}
+
+// Receive waits for the response promised by the future and returns the
+// governance objects keyed by their hash.
+func (r FutureGetGObjectsResult) Receive() (map[string]btcjson.GObjectResult, error) {
+	res, err := ReceiveFuture(r.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects map[string]btcjson.GObjectResult
+	err = json.Unmarshal(res, &objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// GObjectListAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+func (c *Client) GObjectListAsync(signal, objType string) FutureGetGObjectsResult {
+	cmd := btcjson.NewGObjectListCmd(signal, objType)
+
+	return FutureGetGObjectsResult{
+		client:   c,
+		Response: c.SendCmd(cmd),
+	}
+}
+
+// GObjectList returns the governance objects matching the signal and type.
+// Empty signal and objType use the defaults of the server.
+func (c *Client) GObjectList(signal, objType string) (map[string]btcjson.GObjectResult, error) {
+	return c.GObjectListAsync(signal, objType).Receive()
+}
+
+// GObjectDiffAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+func (c *Client) GObjectDiffAsync(signal, objType string) FutureGetGObjectsResult {
+	cmd := btcjson.NewGObjectDiffCmd(signal, objType)
+
+	return FutureGetGObjectsResult{
+		client:   c,
+		Response: c.SendCmd(cmd),
+	}
+}
+
+// GObjectDiff returns the governance objects matching the signal and type
+// which changed since the previous gobject diff command.  Empty signal and
+// objType use the defaults of the server.
+func (c *Client) GObjectDiff(signal, objType string) (map[string]btcjson.GObjectResult, error) {
+	return c.GObjectDiffAsync(signal, objType).Receive()
+}
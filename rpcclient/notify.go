@@ -731,15 +731,9 @@ func parseTxAcceptedNtfnParams(params []json.RawMessage) (*chainhash.Hash,
 		return nil, 0, err
 	}
 
-	// Unmarshal second parameter as a floating point number.
-	var famt float64
-	err = json.Unmarshal(params[1], &famt)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Bounds check amount.
-	amt, err := btcutil.NewAmount(famt)
+	// Unmarshal second parameter as an amount of dash.
+	var amt btcjson.JSONAmount
+	err = json.Unmarshal(params[1], &amt)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -750,7 +744,7 @@ func parseTxAcceptedNtfnParams(params []json.RawMessage) (*chainhash.Hash,
 		return nil, 0, err
 	}
 
-	return txHash, amt, nil
+	return txHash, btcutil.Amount(amt), nil
 }
 
 // parseTxAcceptedVerboseNtfnParams parses out details about a raw transaction
@@ -808,9 +802,9 @@ func parseAccountBalanceNtfnParams(params []json.RawMessage) (account string,
 		return "", 0, false, err
 	}
 
-	// Unmarshal second parameter as a floating point number.
-	var fbal float64
-	err = json.Unmarshal(params[1], &fbal)
+	// Unmarshal second parameter as an amount of dash.
+	var bal btcjson.JSONAmount
+	err = json.Unmarshal(params[1], &bal)
 	if err != nil {
 		return "", 0, false, err
 	}
@@ -821,13 +815,7 @@ func parseAccountBalanceNtfnParams(params []json.RawMessage) (account string,
 		return "", 0, false, err
 	}
 
-	// Bounds check amount.
-	bal, err := btcutil.NewAmount(fbal)
-	if err != nil {
-		return "", 0, false, err
-	}
-
-	return account, bal, confirmed, nil
+	return account, btcutil.Amount(bal), confirmed, nil
 }
 
 // parseWalletLockStateNtfnParams parses out the account name and locked
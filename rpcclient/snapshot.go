@@ -0,0 +1,107 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// snapshotBufferSize is the size of the buffer used when copying a snapshot
+// to disk.  It also determines how often the progress callback is invoked.
+const snapshotBufferSize = 1 << 20 // 1 MiB
+
+// SnapshotProgressFunc is the signature of the callback invoked by SaveSnapshot
+// as data is written.  It is passed the total number of bytes written so far.
+type SnapshotProgressFunc func(bytesWritten int64)
+
+// snapshotWriter wraps the file a snapshot is being written to in order to
+// hash the data and report the progress as it is written.
+type snapshotWriter struct {
+	file     *os.File
+	hasher   hash.Hash
+	written  int64
+	progress SnapshotProgressFunc
+}
+
+// Write writes the passed data to the underlying file and hasher and reports
+// the progress.
+//
+// This is part of the io.Writer interface.
+func (w *snapshotWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hasher.Write(p[:n])
+	w.written += int64(n)
+	if w.progress != nil && n > 0 {
+		w.progress(w.written)
+	}
+	return n, err
+}
+
+// SaveSnapshot streams a UTXO set snapshot, such as one produced by the
+// dumptxoutset RPC and served by a download mirror, from the passed reader to
+// the file at path and returns the hex-encoded SHA-256 digest of its contents.
+//
+// When expectedSHA256 is not empty, the digest of the data must match it, as
+// formatted by tools such as sha256sum, or an error is returned and nothing is
+// written to path.  The optional progress callback is invoked periodically with
+// the number of bytes written so far.
+//
+// The data is first written to a temporary file alongside path which is only
+// renamed to path once it has been completely written, synced and verified,
+// so an interrupted or corrupt download never leaves a partial snapshot at
+// path.
+func SaveSnapshot(r io.Reader, path string, expectedSHA256 string,
+	progress SnapshotProgressFunc) (string, error) {
+
+	tempPath := path + ".partial"
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return "", err
+	}
+	removeTemp := func() {
+		file.Close()
+		os.Remove(tempPath)
+	}
+
+	w := &snapshotWriter{
+		file:     file,
+		hasher:   sha256.New(),
+		progress: progress,
+	}
+	buf := make([]byte, snapshotBufferSize)
+	if _, err := io.CopyBuffer(w, r, buf); err != nil {
+		removeTemp()
+		return "", fmt.Errorf("unable to write snapshot: %v", err)
+	}
+
+	digest := hex.EncodeToString(w.hasher.Sum(nil))
+	if expectedSHA256 != "" && !strings.EqualFold(digest, expectedSHA256) {
+		removeTemp()
+		return "", fmt.Errorf("snapshot hash mismatch: got %s, want %s",
+			digest, expectedSHA256)
+	}
+
+	if err := file.Sync(); err != nil {
+		removeTemp()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,80 @@
+package rpcclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestSaveSnapshot ensures snapshots are written to disk with their progress
+// reported and are only kept when their hash matches.
+func TestSaveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The data is larger than the copy buffer so the progress is reported
+	// several times.  The digest is that of the data as computed by
+	// sha256sum.
+	data := bytes.Repeat([]byte{0xab}, snapshotBufferSize*2+100)
+	const digest = "1afdd128080c832657fcbf14f4b7d606b2534ce1fce260da44f3b4e4" +
+		"0bde84d8"
+
+	// Ensure a mismatched hash is rejected and leaves no files behind.
+	path := filepath.Join(dir, "utxo.dat")
+	badDigest := strings.Repeat("00", 32)
+	_, err = SaveSnapshot(bytes.NewReader(data), path, badDigest, nil)
+	if err == nil {
+		t.Fatal("SaveSnapshot: expected error for mismatched hash")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("SaveSnapshot: %d files left behind after failure",
+			len(files))
+	}
+
+	// Save the snapshot without verification while reading in small
+	// chunks to exercise the progress reporting.
+	var progress []int64
+	gotDigest, err := SaveSnapshot(iotest.HalfReader(bytes.NewReader(data)),
+		path, "", func(n int64) { progress = append(progress, n) })
+	if err != nil {
+		t.Fatalf("SaveSnapshot: unexpected error: %v", err)
+	}
+	if len(progress) < 2 || progress[len(progress)-1] != int64(len(data)) {
+		t.Fatalf("SaveSnapshot: unexpected progress %v", progress)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] <= progress[i-1] {
+			t.Fatalf("SaveSnapshot: progress is not increasing: %v",
+				progress)
+		}
+	}
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read snapshot: %v", err)
+	}
+	if !bytes.Equal(saved, data) {
+		t.Fatal("SaveSnapshot: saved data does not match")
+	}
+	if gotDigest != digest {
+		t.Fatalf("SaveSnapshot: mismatched digest - got %s, want %s",
+			gotDigest, digest)
+	}
+
+	// Saving again with the correct digest must succeed regardless of its
+	// case.
+	_, err = SaveSnapshot(bytes.NewReader(data), path,
+		strings.ToUpper(digest), nil)
+	if err != nil {
+		t.Fatalf("SaveSnapshot: unexpected error with matching hash: %v",
+			err)
+	}
+}
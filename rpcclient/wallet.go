@@ -6,7 +6,6 @@ package rpcclient
 
 import (
 	"encoding/json"
-	"strconv"
 
 	"github.com/dashpay/dashd-go/btcjson"
 	"github.com/dashpay/dashd-go/btcutil"
@@ -1576,7 +1575,7 @@ func (r FutureListAccountsResult) Receive() (map[string]btcutil.Amount, error) {
 	}
 
 	// Unmarshal result as a json object.
-	var accounts map[string]float64
+	var accounts map[string]btcjson.JSONAmount
 	err = json.Unmarshal(res, &accounts)
 	if err != nil {
 		return nil, err
@@ -1584,12 +1583,7 @@ func (r FutureListAccountsResult) Receive() (map[string]btcutil.Amount, error) {
 
 	accountsMap := make(map[string]btcutil.Amount)
 	for k, v := range accounts {
-		amount, err := btcutil.NewAmount(v)
-		if err != nil {
-			return nil, err
-		}
-
-		accountsMap[k] = amount
+		accountsMap[k] = btcutil.Amount(v)
 	}
 
 	return accountsMap, nil
@@ -1644,19 +1638,14 @@ func (r FutureGetBalanceResult) Receive() (btcutil.Amount, error) {
 		return 0, err
 	}
 
-	// Unmarshal result as a floating point number.
-	var balance float64
+	// Unmarshal result as an amount of dash.
+	var balance btcjson.JSONAmount
 	err = json.Unmarshal(res, &balance)
 	if err != nil {
 		return 0, err
 	}
 
-	amount, err := btcutil.NewAmount(balance)
-	if err != nil {
-		return 0, err
-	}
-
-	return amount, nil
+	return btcutil.Amount(balance), nil
 }
 
 // FutureGetBalanceParseResult is same as FutureGetBalanceResult except
@@ -1673,23 +1662,14 @@ func (r FutureGetBalanceParseResult) Receive() (btcutil.Amount, error) {
 		return 0, err
 	}
 
-	// Unmarshal result as a string
-	var balanceString string
-	err = json.Unmarshal(res, &balanceString)
-	if err != nil {
-		return 0, err
-	}
-
-	balance, err := strconv.ParseFloat(balanceString, 64)
-	if err != nil {
-		return 0, err
-	}
-	amount, err := btcutil.NewAmount(balance)
+	// Unmarshal result as a string containing an amount of dash.
+	var balance btcjson.JSONAmount
+	err = json.Unmarshal(res, &balance)
 	if err != nil {
 		return 0, err
 	}
 
-	return amount, nil
+	return btcutil.Amount(balance), nil
 }
 
 // GetBalanceAsync returns an instance of a type that can be used to get the
@@ -1784,19 +1764,14 @@ func (r FutureGetReceivedByAccountResult) Receive() (btcutil.Amount, error) {
 		return 0, err
 	}
 
-	// Unmarshal result as a floating point number.
-	var balance float64
+	// Unmarshal result as an amount of dash.
+	var balance btcjson.JSONAmount
 	err = json.Unmarshal(res, &balance)
 	if err != nil {
 		return 0, err
 	}
 
-	amount, err := btcutil.NewAmount(balance)
-	if err != nil {
-		return 0, err
-	}
-
-	return amount, nil
+	return btcutil.Amount(balance), nil
 }
 
 // GetReceivedByAccountAsync returns an instance of a type that can be used to
@@ -1849,19 +1824,14 @@ func (r FutureGetUnconfirmedBalanceResult) Receive() (btcutil.Amount, error) {
 		return 0, err
 	}
 
-	// Unmarshal result as a floating point number.
-	var balance float64
+	// Unmarshal result as an amount of dash.
+	var balance btcjson.JSONAmount
 	err = json.Unmarshal(res, &balance)
 	if err != nil {
 		return 0, err
 	}
 
-	amount, err := btcutil.NewAmount(balance)
-	if err != nil {
-		return 0, err
-	}
-
-	return amount, nil
+	return btcutil.Amount(balance), nil
 }
 
 // GetUnconfirmedBalanceAsync returns an instance of a type that can be used to
@@ -1893,19 +1863,14 @@ func (r FutureGetReceivedByAddressResult) Receive() (btcutil.Amount, error) {
 		return 0, err
 	}
 
-	// Unmarshal result as a floating point number.
-	var balance float64
+	// Unmarshal result as an amount of dash.
+	var balance btcjson.JSONAmount
 	err = json.Unmarshal(res, &balance)
 	if err != nil {
 		return 0, err
 	}
 
-	amount, err := btcutil.NewAmount(balance)
-	if err != nil {
-		return 0, err
-	}
-
-	return amount, nil
+	return btcutil.Amount(balance), nil
 }
 
 // GetReceivedByAddressAsync returns an instance of a type that can be used to
@@ -2827,6 +2792,42 @@ func (c *Client) LoadWallet(walletName string) (*btcjson.LoadWalletResult, error
 	return c.LoadWalletAsync(walletName).Receive()
 }
 
+// FutureListWalletsResult is a future promise to deliver the result of a
+// ListWalletsAsync RPC invocation (or an applicable error).
+type FutureListWalletsResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the names
+// of the loaded wallets.
+func (r FutureListWalletsResult) Receive() ([]string, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []string
+	err = json.Unmarshal(res, &wallets)
+	if err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// ListWalletsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ListWallets for the blocking version and more details.
+func (c *Client) ListWalletsAsync() FutureListWalletsResult {
+	return c.SendCmd(btcjson.NewListWalletsCmd())
+}
+
+// ListWallets returns the names of the wallets loaded by the server.  Each of
+// them can be used as the Wallet of a ConnConfig or with SendCmdToWallet to
+// route commands to it.
+func (c *Client) ListWallets() ([]string, error) {
+	return c.ListWalletsAsync().Receive()
+}
+
 // TODO(davec): Implement
 // encryptwallet (Won't be supported by btcwallet since it's always encrypted)
 // listaddressgroupings (NYI in btcwallet)
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcjson"
+)
+
+// expectPath returns a function that ensures requests are sent to the passed
+// URL path.
+func expectPath(expected string) func(r *http.Request) error {
+	return func(r *http.Request) error {
+		if r.URL.Path != expected {
+			return fmt.Errorf("unexpected path, expected: %q, actual: %q",
+				expected, r.URL.Path)
+		}
+		return nil
+	}
+}
+
+// TestWalletRouting ensures commands are routed to the wallet configured for
+// the client or passed with the command.
+func TestWalletRouting(t *testing.T) {
+	// Commands are sent to the default endpoint without a wallet.
+	client, err := New(connCfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+
+	wallets := []string{"", "alice", "bob smith"}
+	client.httpClient.Transport = mockRoundTripperFunc(
+		wallets,
+		expectPath(""),
+		expectBody(`{"jsonrpc":"1.0","method":"listwallets","params":[],"id":1}`),
+	)
+	got, err := client.ListWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, wallets) {
+		t.Fatalf("ListWallets: got %v, want %v", got, wallets)
+	}
+
+	// Commands are routed to the wallet passed with them, with its name
+	// escaped.
+	client.httpClient.Transport = mockRoundTripperFunc(
+		&btcjson.GetWalletInfoResult{WalletName: "bob smith"},
+		expectPath("/wallet/bob smith"),
+	)
+	_, err = ReceiveFuture(client.SendCmdToWallet("bob smith",
+		btcjson.NewGetWalletInfoCmd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Commands are routed to the wallet configured for the client.
+	cfg := *connCfg
+	cfg.Wallet = "alice"
+	walletClient, err := New(&cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer walletClient.Shutdown()
+
+	walletClient.httpClient.Transport = mockRoundTripperFunc(
+		nil,
+		expectPath("/wallet/alice"),
+		expectBody(`{"jsonrpc":"1.0","method":"backupwallet","params":["alice.bak"],"id":1}`),
+	)
+	if err := walletClient.BackupWallet("alice.bak"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty wallet name routes commands to the default endpoint.
+	walletClient.httpClient.Transport = mockRoundTripperFunc(
+		wallets,
+		expectPath(""),
+	)
+	_, err = ReceiveFuture(walletClient.SendCmdToWallet("",
+		btcjson.NewListWalletsCmd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wallets can only be used in HTTP POST mode.
+	cfg.HTTPPostMode = false
+	cfg.DisableConnectOnNew = true
+	if _, err := New(&cfg, nil); err != ErrWalletNotHTTPPost {
+		t.Fatalf("New: got error %v, want %v", err, ErrWalletNotHTTPPost)
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dashpay/dashd-go/btcjson"
+)
+
+// defaultWatchInterval is the poll interval of a ChangeWatcher when none is
+// configured.
+const defaultWatchInterval = 30 * time.Second
+
+// ChangeKind identifies how a watched governance object or masternode changed.
+type ChangeKind int
+
+// These constants define the kinds of changes reported by a ChangeWatcher.
+const (
+	// ChangeAdded indicates an item which was not known before.
+	ChangeAdded ChangeKind = iota
+
+	// ChangeUpdated indicates a known item whose fields changed.
+	ChangeUpdated
+
+	// ChangeRemoved indicates a known item which was removed.
+	ChangeRemoved
+)
+
+// changeKindStrings is a map of change kinds back to their names for pretty
+// printing.
+var changeKindStrings = map[ChangeKind]string{
+	ChangeAdded:   "added",
+	ChangeUpdated: "updated",
+	ChangeRemoved: "removed",
+}
+
+// String returns the ChangeKind in human-readable form.
+func (k ChangeKind) String() string {
+	if s, ok := changeKindStrings[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ChangeKind (%d)", int(k))
+}
+
+// GObjectChange describes a change of a governance object.
+type GObjectChange struct {
+	Kind ChangeKind
+	Hash string
+
+	// Object is the governance object after the change.  It is the last
+	// known state of the object for removed objects.
+	Object *btcjson.GObjectResult
+}
+
+// MasternodeChange describes a change of an entry of the masternode list.
+type MasternodeChange struct {
+	Kind         ChangeKind
+	ProRegTxHash string
+
+	// Height is the height of the block the change was observed at.
+	Height int
+
+	// Entry is the masternode list entry after the change.  It is nil for
+	// removed masternodes.
+	Entry *btcjson.ProTxDiffMN
+}
+
+// ChangeWatcherConfig describes the configuration of a ChangeWatcher.
+type ChangeWatcherConfig struct {
+	// PollInterval is the time between polls.  It defaults to 30 seconds
+	// when zero.
+	PollInterval time.Duration
+
+	// Signal and ObjectType filter the watched governance objects the
+	// same way as the gobject list command.  Empty values use the defaults
+	// of the server.
+	Signal     string
+	ObjectType string
+
+	// OnGObjectChange is invoked for every change of a governance object.
+	// Governance objects are not polled when it is nil.
+	OnGObjectChange func(*GObjectChange)
+
+	// OnMasternodeChange is invoked for every change of the masternode
+	// list.  The masternode list is not polled when it is nil.
+	OnMasternodeChange func(*MasternodeChange)
+
+	// OnError is invoked with the errors of polls started by Start.  The
+	// failed poll is retried after the poll interval.
+	OnError func(error)
+}
+
+// ChangeWatcher polls a dashd server for changes of the governance objects and
+// the masternode list and reports them as typed change events, since the
+// server offers no way to push them.
+//
+// Governance objects are fetched once with gobject list and then with gobject
+// diff, which only returns the objects changed since its previous invocation.
+// The masternode list is fetched with protx diff from the block height of the
+// previous poll to the current height.  Every object and masternode is
+// fingerprinted like an HTTP entity tag, so only actual changes are reported
+// and the first poll reports everything as added.  Governance objects marked
+// for deletion are reported as removed.
+//
+// Since gobject diff tracks its previous invocation on the server, other
+// clients invoking it against the same server cause changes to be missed.
+type ChangeWatcher struct {
+	client *Client
+	cfg    ChangeWatcherConfig
+
+	pollMtx      sync.Mutex
+	gobjectsInit bool
+	gobjects     map[string]string
+	mnHeight     int
+	mnRoot       string
+	masternodes  map[string]string
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewChangeWatcher returns a ChangeWatcher which polls the server of the
+// client according to the passed configuration.  It does not poll until
+// either Start or Poll is invoked.
+func (c *Client) NewChangeWatcher(cfg *ChangeWatcherConfig) *ChangeWatcher {
+	w := &ChangeWatcher{
+		client:      c,
+		cfg:         *cfg,
+		gobjects:    make(map[string]string),
+		masternodes: make(map[string]string),
+		quit:        make(chan struct{}),
+	}
+	if w.cfg.PollInterval == 0 {
+		w.cfg.PollInterval = defaultWatchInterval
+	}
+	return w
+}
+
+// etag returns a fingerprint of the JSON encoding of the passed value.
+func etag(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// pollGObjects reports the changes of the governance objects since the
+// previous poll.
+func (w *ChangeWatcher) pollGObjects() error {
+	var objects map[string]btcjson.GObjectResult
+	var err error
+	if !w.gobjectsInit {
+		objects, err = w.client.GObjectList(w.cfg.Signal,
+			w.cfg.ObjectType)
+	} else {
+		objects, err = w.client.GObjectDiff(w.cfg.Signal,
+			w.cfg.ObjectType)
+	}
+	if err != nil {
+		return err
+	}
+	w.gobjectsInit = true
+
+	for hash, object := range objects {
+		object := object
+		oldTag, known := w.gobjects[hash]
+		if object.CachedDelete {
+			if known {
+				delete(w.gobjects, hash)
+				w.cfg.OnGObjectChange(&GObjectChange{
+					Kind:   ChangeRemoved,
+					Hash:   hash,
+					Object: &object,
+				})
+			}
+			continue
+		}
+
+		tag, err := etag(&object)
+		if err != nil {
+			return err
+		}
+		if tag == oldTag {
+			continue
+		}
+		w.gobjects[hash] = tag
+
+		kind := ChangeAdded
+		if known {
+			kind = ChangeUpdated
+		}
+		w.cfg.OnGObjectChange(&GObjectChange{
+			Kind:   kind,
+			Hash:   hash,
+			Object: &object,
+		})
+	}
+
+	return nil
+}
+
+// pollMasternodes reports the changes of the masternode list since the
+// previous poll.
+func (w *ChangeWatcher) pollMasternodes() error {
+	height, err := w.client.GetBlockCount()
+	if err != nil {
+		return err
+	}
+	if int(height) == w.mnHeight {
+		return nil
+	}
+
+	// The masternode list is empty before the first block.
+	baseHeight := w.mnHeight
+	if baseHeight == 0 {
+		baseHeight = 1
+	}
+	diff, err := w.client.ProTxDiff(baseHeight, int(height))
+	if err != nil {
+		return err
+	}
+	w.mnHeight = int(height)
+	if diff.MerkleRootMNList == w.mnRoot {
+		return nil
+	}
+	w.mnRoot = diff.MerkleRootMNList
+
+	for _, hash := range diff.DeletedMNs {
+		if _, ok := w.masternodes[hash]; !ok {
+			continue
+		}
+		delete(w.masternodes, hash)
+		w.cfg.OnMasternodeChange(&MasternodeChange{
+			Kind:         ChangeRemoved,
+			ProRegTxHash: hash,
+			Height:       w.mnHeight,
+		})
+	}
+
+	for i := range diff.MnList {
+		entry := &diff.MnList[i]
+		tag, err := etag(entry)
+		if err != nil {
+			return err
+		}
+		oldTag, known := w.masternodes[entry.ProRegTxHash]
+		if tag == oldTag {
+			continue
+		}
+		w.masternodes[entry.ProRegTxHash] = tag
+
+		kind := ChangeAdded
+		if known {
+			kind = ChangeUpdated
+		}
+		w.cfg.OnMasternodeChange(&MasternodeChange{
+			Kind:         kind,
+			ProRegTxHash: entry.ProRegTxHash,
+			Height:       w.mnHeight,
+			Entry:        entry,
+		})
+	}
+
+	return nil
+}
+
+// Poll polls the server once and invokes the configured callbacks with the
+// changes since the previous poll.  It is invoked periodically after Start,
+// but may also be invoked directly by callers which schedule the polls
+// themselves.
+//
+// This function is safe for concurrent access.
+func (w *ChangeWatcher) Poll() error {
+	w.pollMtx.Lock()
+	defer w.pollMtx.Unlock()
+
+	if w.cfg.OnGObjectChange != nil {
+		if err := w.pollGObjects(); err != nil {
+			return fmt.Errorf("unable to poll governance objects: %v",
+				err)
+		}
+	}
+	if w.cfg.OnMasternodeChange != nil {
+		if err := w.pollMasternodes(); err != nil {
+			return fmt.Errorf("unable to poll masternode list: %v",
+				err)
+		}
+	}
+	return nil
+}
+
+// watchHandler polls the server every poll interval until the watcher is
+// stopped.
+//
+// This must be run as a goroutine.
+func (w *ChangeWatcher) watchHandler() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.Poll(); err != nil {
+			if w.cfg.OnError != nil {
+				w.cfg.OnError(err)
+			} else {
+				log.Warnf("Change watcher: %v", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-w.quit:
+			return
+		case <-w.client.shutdown:
+			return
+		}
+	}
+}
+
+// Start begins polling the server immediately and then every poll interval
+// until Stop is invoked or the client is shut down.
+func (w *ChangeWatcher) Start() {
+	w.startOnce.Do(func() {
+		w.wg.Add(1)
+		go w.watchHandler()
+	})
+}
+
+// Stop stops polling the server and waits for the poll in progress, if any,
+// to finish.
+func (w *ChangeWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+	})
+	w.wg.Wait()
+}
@@ -0,0 +1,169 @@
+package rpcclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcjson"
+)
+
+// routeRoundTripperFunc returns a round tripper which answers each request
+// with the result returned by the route for the method of the request.
+func routeRoundTripperFunc(routes map[string]func(params []interface{}) interface{}) roundTripperFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		data, err := readBody(r)
+		if err != nil {
+			return nil, err
+		}
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, err
+		}
+		route, ok := routes[req.Method]
+		if !ok {
+			return nil, fmt.Errorf("unexpected request %s", data)
+		}
+		raw, err := json.Marshal(route(req.Params))
+		if err != nil {
+			return nil, err
+		}
+		raw, err = json.Marshal(&rawResponse{Result: raw})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBuffer(raw)),
+		}, nil
+	}
+}
+
+// changeSummary is a comparable summary of a change event.
+type changeSummary struct {
+	Kind ChangeKind
+	Hash string
+}
+
+// sortedChanges returns the recorded changes sorted by hash and clears them.
+func sortedChanges(changes *[]changeSummary) []changeSummary {
+	got := *changes
+	*changes = nil
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].Hash < got[j].Hash
+	})
+	return got
+}
+
+func TestChangeWatcher(t *testing.T) {
+	client, err := New(connCfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+
+	var (
+		gobjects   map[string]btcjson.GObjectResult
+		blockCount int64
+		mnDiff     *btcjson.ProTxDiffResult
+		diffParams [][]interface{}
+	)
+	client.httpClient.Transport = routeRoundTripperFunc(
+		map[string]func([]interface{}) interface{}{
+			"gobject": func([]interface{}) interface{} {
+				return gobjects
+			},
+			"getblockcount": func([]interface{}) interface{} {
+				return blockCount
+			},
+			"protx": func(params []interface{}) interface{} {
+				diffParams = append(diffParams, params)
+				return mnDiff
+			},
+		})
+
+	var gobjectChanges, mnChanges []changeSummary
+	watcher := client.NewChangeWatcher(&ChangeWatcherConfig{
+		OnGObjectChange: func(c *GObjectChange) {
+			gobjectChanges = append(gobjectChanges,
+				changeSummary{c.Kind, c.Hash})
+		},
+		OnMasternodeChange: func(c *MasternodeChange) {
+			mnChanges = append(mnChanges,
+				changeSummary{c.Kind, c.ProRegTxHash})
+		},
+	})
+
+	poll := func(wantGObjects, wantMNs []changeSummary) {
+		t.Helper()
+
+		if err := watcher.Poll(); err != nil {
+			t.Fatalf("Poll: unexpected error: %v", err)
+		}
+		got := sortedChanges(&gobjectChanges)
+		if !reflect.DeepEqual(got, wantGObjects) {
+			t.Fatalf("got governance changes %v, want %v", got,
+				wantGObjects)
+		}
+		got = sortedChanges(&mnChanges)
+		if !reflect.DeepEqual(got, wantMNs) {
+			t.Fatalf("got masternode changes %v, want %v", got,
+				wantMNs)
+		}
+	}
+
+	// The first poll reports everything as added.
+	gobjects = map[string]btcjson.GObjectResult{
+		"a": {Hash: "a", YesCount: 1},
+		"b": {Hash: "b", YesCount: 2},
+	}
+	blockCount = 100
+	mnDiff = &btcjson.ProTxDiffResult{
+		MnList: []btcjson.ProTxDiffMN{
+			{ProRegTxHash: "m1", IsValid: true},
+			{ProRegTxHash: "m2", IsValid: true},
+		},
+		MerkleRootMNList: "r1",
+	}
+	poll([]changeSummary{{ChangeAdded, "a"}, {ChangeAdded, "b"}},
+		[]changeSummary{{ChangeAdded, "m1"}, {ChangeAdded, "m2"}})
+
+	// Unchanged objects and unknown deleted objects are not reported and
+	// the masternode list is not fetched without a new block.
+	gobjects = map[string]btcjson.GObjectResult{
+		"a": {Hash: "a", YesCount: 1},
+		"b": {Hash: "b", YesCount: 3},
+		"c": {Hash: "c", CachedDelete: true},
+	}
+	poll([]changeSummary{{ChangeUpdated, "b"}}, nil)
+
+	// Deleted objects and masternodes are reported as removed.
+	gobjects = map[string]btcjson.GObjectResult{
+		"a": {Hash: "a", YesCount: 1, CachedDelete: true},
+	}
+	blockCount = 101
+	mnDiff = &btcjson.ProTxDiffResult{
+		DeletedMNs:       []string{"m1"},
+		MnList:           []btcjson.ProTxDiffMN{{ProRegTxHash: "m2"}},
+		MerkleRootMNList: "r2",
+	}
+	poll([]changeSummary{{ChangeRemoved, "a"}},
+		[]changeSummary{{ChangeRemoved, "m1"}, {ChangeUpdated, "m2"}})
+
+	wantParams := [][]interface{}{
+		{"diff", float64(1), float64(100)},
+		{"diff", float64(100), float64(101)},
+	}
+	if !reflect.DeepEqual(diffParams, wantParams) {
+		t.Fatalf("got protx diff params %v, want %v", diffParams,
+			wantParams)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// rpcCORSMaxAge is the number of seconds browsers may cache the result
+	// of a CORS preflight request.
+	rpcCORSMaxAge = 600
+
+	// maxRPCOriginBuckets is the number of origins whose request rates are
+	// tracked before the buckets of idle origins are pruned.
+	maxRPCOriginBuckets = 1000
+)
+
+// rpcCORSAllowOrigin returns the value of the Access-Control-Allow-Origin
+// header for a request with the passed origin, or an empty string when
+// cross-origin requests from the origin aren't allowed by the rpccorsorigin
+// option.  Origins which are explicitly allowed are echoed back, while "*" is
+// returned for other origins when all origins are allowed.
+func rpcCORSAllowOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	var allowAny bool
+	for _, allowed := range cfg.RPCCORSOrigins {
+		if allowed == "*" {
+			allowAny = true
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	if allowAny {
+		return "*"
+	}
+	return ""
+}
+
+// handleRPCCORS sets the CORS headers of a response to an RPC request from a
+// browser-based client with an allowed origin.  True is returned when the
+// request was a preflight request, which has been responded to and must not be
+// handled further.  Preflight requests from origins which aren't allowed are
+// rejected.
+//
+// Credentials are only allowed for explicitly allowed origins, so browsers
+// never send the cookies or cached HTTP authentication of a user to the RPC
+// server on behalf of an arbitrary site.  Clients allowed by the wildcard must
+// set the Authorization header themselves.
+func handleRPCCORS(w http.ResponseWriter, r *http.Request) bool {
+	allowOrigin := rpcCORSAllowOrigin(r.Header.Get("Origin"))
+	if allowOrigin != "" {
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", allowOrigin)
+		if allowOrigin != "*" {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		h.Add("Vary", "Origin")
+	}
+
+	if r.Method != http.MethodOptions ||
+		r.Header.Get("Access-Control-Request-Method") == "" {
+
+		return false
+	}
+	if allowOrigin == "" {
+		http.Error(w, "403 Forbidden.", http.StatusForbidden)
+		return true
+	}
+	h := w.Header()
+	h.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	h.Set("Access-Control-Max-Age", strconv.Itoa(rpcCORSMaxAge))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// isRPCTrustedProxy returns whether the IP is the address of a reverse proxy
+// trusted by the rpctrustedproxy option.
+func isRPCTrustedProxy(ip net.IP) bool {
+	for _, ipnet := range cfg.rpcTrustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// rpcClientAddr returns the address of the client which made the RPC request.
+// This is the remote address of the connection unless the request was
+// forwarded by a trusted reverse proxy, in which case it is the last address
+// of the X-Forwarded-For header which isn't itself a trusted proxy.  Earlier
+// addresses of the header are ignored since they are provided by the client.
+func rpcClientAddr(r *http.Request) string {
+	if len(cfg.rpcTrustedProxies) == 0 {
+		return r.RemoteAddr
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isRPCTrustedProxy(ip) {
+		return r.RemoteAddr
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	clientAddr := r.RemoteAddr
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		clientAddr = ip.String()
+		if !isRPCTrustedProxy(ip) {
+			break
+		}
+	}
+	return clientAddr
+}
+
+// rpcOriginBucket is the token bucket of a single origin.
+type rpcOriginBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rpcOriginLimiter limits the rate of RPC requests from each browser origin
+// with a token bucket per origin, which allows bursts of up to a second worth
+// of requests.  The origin is taken from the Origin header, which is only
+// trustworthy when set by a browser, so the limiter is a throttle for
+// browser-based clients rather than a defense against abusive clients.
+//
+// The limiter is safe for concurrent access.
+type rpcOriginLimiter struct {
+	mtx     sync.Mutex
+	rate    float64
+	buckets map[string]*rpcOriginBucket
+}
+
+// newRPCOriginLimiter returns a limiter which allows the passed number of
+// requests per second from each origin.
+func newRPCOriginLimiter(rate int) *rpcOriginLimiter {
+	return &rpcOriginLimiter{
+		rate:    float64(rate),
+		buckets: make(map[string]*rpcOriginBucket),
+	}
+}
+
+// Allow returns whether a request from the origin is allowed at the passed
+// time and, if so, accounts for it.
+func (l *rpcOriginLimiter) Allow(origin string, now time.Time) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	bucket, ok := l.buckets[origin]
+	if !ok {
+		if len(l.buckets) >= maxRPCOriginBuckets {
+			l.prune(now)
+		}
+		bucket = &rpcOriginBucket{tokens: l.rate, last: now}
+		l.buckets[origin] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.rate {
+		bucket.tokens = l.rate
+	}
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune removes the buckets of origins which have been idle long enough for
+// their bucket to be full again, since they are equivalent to new buckets.
+//
+// This function MUST be called with the limiter lock held.
+func (l *rpcOriginLimiter) prune(now time.Time) {
+	for origin, bucket := range l.buckets {
+		idle := now.Sub(bucket.last).Seconds()
+		if bucket.tokens+idle*l.rate >= l.rate {
+			delete(l.buckets, origin)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRPCCORS ensures CORS headers are only set for allowed origins and that
+// preflight requests are answered.
+func TestRPCCORS(t *testing.T) {
+	defer func(oldCfg *config) { cfg = oldCfg }(cfg)
+	cfg = &config{RPCCORSOrigins: []string{"http://localhost:3000"}}
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		handled     bool
+		allowOrigin string
+		status      int
+	}{
+		{"same origin", http.MethodPost, "", false, false, "", 0},
+		{"allowed origin", http.MethodPost, "http://localhost:3000",
+			false, false, "http://localhost:3000", 0},
+		{"other origin", http.MethodPost, "http://example.com", false,
+			false, "", 0},
+		{"allowed preflight", http.MethodOptions, "http://localhost:3000",
+			true, true, "http://localhost:3000", http.StatusNoContent},
+		{"other preflight", http.MethodOptions, "http://example.com",
+			true, true, "", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		if test.preflight {
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		handled := handleRPCCORS(w, r)
+		if handled != test.handled {
+			t.Errorf("%s: handled %v, want %v", test.name, handled,
+				test.handled)
+		}
+		allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+		if allowOrigin != test.allowOrigin {
+			t.Errorf("%s: allowed origin %q, want %q", test.name,
+				allowOrigin, test.allowOrigin)
+		}
+		if test.handled && w.Code != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, w.Code,
+				test.status)
+		}
+	}
+
+	// Any origin is allowed with a wildcard, but without credentials, while
+	// explicitly allowed origins are still echoed back with credentials.
+	cfg.RPCCORSOrigins = []string{"*", "http://localhost:3000"}
+	wildcardTests := []struct {
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"http://example.com", "*", ""},
+		{"http://localhost:3000", "http://localhost:3000", "true"},
+	}
+	for _, test := range wildcardTests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Origin", test.origin)
+		w := httptest.NewRecorder()
+		handleRPCCORS(w, r)
+		h := w.Header()
+		if got := h.Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+			t.Errorf("wildcard %s: allowed origin %q, want %q",
+				test.origin, got, test.allowOrigin)
+		}
+		if got := h.Get("Access-Control-Allow-Credentials"); got != test.credentials {
+			t.Errorf("wildcard %s: allowed credentials %q, want %q",
+				test.origin, got, test.credentials)
+		}
+	}
+}
+
+// TestRPCClientAddr ensures the X-Forwarded-For header is only trusted for
+// requests forwarded by trusted proxies.
+func TestRPCClientAddr(t *testing.T) {
+	defer func(oldCfg *config) { cfg = oldCfg }(cfg)
+	_, ipnet, _ := net.ParseCIDR("10.0.0.0/8")
+	cfg = &config{rpcTrustedProxies: []*net.IPNet{ipnet}}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"1.2.3.4:1000", nil, "1.2.3.4:1000"},
+		{"1.2.3.4:1000", []string{"5.6.7.8"}, "1.2.3.4:1000"},
+		{"10.0.0.1:1000", nil, "10.0.0.1:1000"},
+		{"10.0.0.1:1000", []string{"5.6.7.8"}, "5.6.7.8"},
+		{"10.0.0.1:1000", []string{"9.9.9.9, 5.6.7.8, 10.0.0.2"},
+			"5.6.7.8"},
+		{"10.0.0.1:1000", []string{"9.9.9.9", "5.6.7.8"}, "5.6.7.8"},
+		{"10.0.0.1:1000", []string{"bogus, 10.0.0.2"}, "10.0.0.2"},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = test.remoteAddr
+		for _, header := range test.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		if got := rpcClientAddr(r); got != test.want {
+			t.Errorf("rpcClientAddr #%d: got %q, want %q", i, got,
+				test.want)
+		}
+	}
+}
+
+// TestRPCOriginLimiter ensures requests are limited per origin and that the
+// limit recovers over time.
+func TestRPCOriginLimiter(t *testing.T) {
+	l := newRPCOriginLimiter(2)
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < 2; i++ {
+		if !l.Allow("http://a", now) {
+			t.Fatalf("Allow #%d: request rejected", i)
+		}
+	}
+	if l.Allow("http://a", now) {
+		t.Fatal("Allow: request over the limit allowed")
+	}
+	if !l.Allow("http://b", now) {
+		t.Fatal("Allow: request of another origin rejected")
+	}
+	if !l.Allow("http://a", now.Add(500*time.Millisecond)) {
+		t.Fatal("Allow: request rejected after the limit recovered")
+	}
+
+	// Idle origins are pruned.
+	l.prune(now.Add(time.Hour))
+	if len(l.buckets) != 0 {
+		t.Fatalf("prune: %d buckets remain", len(l.buckets))
+	}
+}
@@ -16,11 +16,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"math/rand"
 	"net"
 	"net/http"
 	"os"
+	"runtime/pprof"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,7 +32,6 @@ import (
 	"github.com/btcsuite/websocket"
 	"github.com/dashpay/dashd-go/blockchain"
 	"github.com/dashpay/dashd-go/blockchain/indexers"
-	"github.com/dashpay/dashd-go/btcec/v2/ecdsa"
 	"github.com/dashpay/dashd-go/btcjson"
 	"github.com/dashpay/dashd-go/btcutil"
 	"github.com/dashpay/dashd-go/chaincfg"
@@ -58,6 +59,11 @@ const (
 	// is closed.
 	rpcAuthTimeoutSeconds = 10
 
+	// rpcIdleTimeout is the duration a kept alive connection to the RPC
+	// server is allowed to stay open without sending another request
+	// before it is closed.
+	rpcIdleTimeout = 2 * time.Minute
+
 	// uint256Size is the number of bytes needed to represent an unsigned
 	// 256-bit integer.
 	uint256Size = 32
@@ -135,6 +141,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"debuglevel":             handleDebugLevel,
 	"decoderawtransaction":   handleDecodeRawTransaction,
 	"decodescript":           handleDecodeScript,
+	"dumppeerstate":          handleDumpPeerState,
 	"estimatefee":            handleEstimateFee,
 	"generate":               handleGenerate,
 	"getaddednodeinfo":       handleGetAddedNodeInfo,
@@ -161,12 +168,15 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getnetworkhashps":       handleGetNetworkHashPS,
 	"getnodeaddresses":       handleGetNodeAddresses,
 	"getpeerinfo":            handleGetPeerInfo,
+	"getprofile":             handleGetProfile,
 	"getrawmempool":          handleGetRawMempool,
 	"getrawtransaction":      handleGetRawTransaction,
+	"getspecialtxes":         handleGetSpecialTxes,
 	"gettxout":               handleGetTxOut,
 	"help":                   handleHelp,
 	"node":                   handleNode,
 	"ping":                   handlePing,
+	"rpc.discover":           handleDiscover,
 	"searchrawtransactions":  handleSearchRawTransactions,
 	"sendrawtransaction":     handleSendRawTransaction,
 	"setgenerate":            handleSetGenerate,
@@ -276,7 +286,9 @@ var rpcLimited = map[string]struct{}{
 	"getnetworkhashps":      {},
 	"getrawmempool":         {},
 	"getrawtransaction":     {},
+	"getspecialtxes":        {},
 	"gettxout":              {},
+	"rpc.discover":          {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
@@ -639,6 +651,119 @@ func handleDebugLevel(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return "Done.", nil
 }
 
+// handleDumpPeerState implements the dumppeerstate command.
+func handleDumpPeerState(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	peers := s.cfg.ConnMgr.ConnectedPeers()
+	syncPeerID := s.cfg.SyncMgr.SyncPeerID()
+	states := make([]*btcjson.PeerStateResult, 0, len(peers))
+	for _, p := range peers {
+		pp := p.ToPeer()
+		features := pp.Features()
+		state := &btcjson.PeerStateResult{
+			ID:              pp.ID(),
+			Addr:            pp.Addr(),
+			Inbound:         pp.Inbound(),
+			Persistent:      p.IsPersistent(),
+			Whitelisted:     p.IsWhitelisted(),
+			SyncNode:        pp.ID() == syncPeerID,
+			Connected:       pp.Connected(),
+			Handshake:       pp.HandshakeState().String(),
+			ProtocolVersion: features.ProtocolVersion,
+			Services:        features.Services.String(),
+			RelayTxes:       !p.IsTxRelayDisabled(),
+			WantsHeaders:    pp.WantsHeaders(),
+			BlocksOnly:      pp.BlocksOnly(),
+			LastBlock:       pp.LastBlock(),
+			LastPingNonce:   pp.LastPingNonce(),
+			BanScore:        int32(p.BanScore()),
+			FeeFilter:       p.FeeFilter(),
+			BytesSent:       pp.BytesSent(),
+			BytesRecv:       pp.BytesReceived(),
+		}
+		if hash := pp.LastAnnouncedBlock(); hash != nil {
+			state.LastAnnouncedBlock = hash.String()
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// maxCPUProfileSeconds is the maximum duration of the CPU profiles captured by
+// the getprofile command.
+const maxCPUProfileSeconds = 300
+
+// handleGetProfile implements the getprofile command.
+func handleGetProfile(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetProfileCmd)
+
+	var buf bytes.Buffer
+	encoding := "base64"
+	if c.Profile == "cpu" {
+		seconds := *c.Seconds
+		if seconds <= 0 || seconds > maxCPUProfileSeconds {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("seconds must be between 1 "+
+					"and %d", maxCPUProfileSeconds),
+			}
+		}
+
+		// Only one CPU profile may be captured at a time, which
+		// includes the one requested by the --cpuprofile option.
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: err.Error(),
+			}
+		}
+		timer := time.NewTimer(time.Duration(seconds) * time.Second)
+		defer timer.Stop()
+		select {
+		// Stop profiling early when the client or the server quits so
+		// the profiler is available again.
+		case <-closeChan:
+			pprof.StopCPUProfile()
+			return nil, ErrClientQuit
+		case <-s.quit:
+			pprof.StopCPUProfile()
+			return nil, ErrClientQuit
+
+		case <-timer.C:
+		}
+		pprof.StopCPUProfile()
+	} else {
+		profile := pprof.Lookup(c.Profile)
+		if profile == nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("unknown profile %q",
+					c.Profile),
+			}
+		}
+
+		// Profiles with a non-zero debug level are formatted as text,
+		// such as the stacks of all goroutines for debug level 2.
+		debug := *c.Debug
+		if err := profile.WriteTo(&buf, debug); err != nil {
+			context := "Failed to write profile"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if debug != 0 {
+			encoding = "text"
+		}
+	}
+
+	data := buf.String()
+	if encoding == "base64" {
+		data = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+	return &btcjson.GetProfileResult{
+		Profile:  c.Profile,
+		Encoding: encoding,
+		Data:     data,
+	}, nil
+}
+
 // witnessToHex formats the passed witness stack as a slice of hex-encoded
 // strings to be used in a JSON response.
 func witnessToHex(witness wire.TxWitness) []string {
@@ -1138,6 +1263,7 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		Difficulty:    getDifficultyRatio(blockHeader.Bits, params),
 		NextHash:      nextHashString,
+		Chainlock:     s.cfg.Chain.IsChainLocked(hash),
 	}
 
 	if *c.Verbosity == 1 {
@@ -1269,7 +1395,7 @@ func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 
 		// Query the chain for the current status of the deployment as
 		// identified by its deployment ID.
-		deploymentStatus, err := chain.ThresholdState(uint32(deployment))
+		deploymentStatus, err := chain.DeploymentStatus(uint32(deployment))
 		if err != nil {
 			context := "Failed to obtain deployment status"
 			return nil, internalRPCError(err.Error(), context)
@@ -1278,12 +1404,25 @@ func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 		// Attempt to convert the current deployment status into a
 		// human readable string. If the status is unrecognized, then a
 		// non-nil error is returned.
-		statusString, err := softForkStatus(deploymentStatus)
+		statusString, err := softForkStatus(deploymentStatus.State)
 		if err != nil {
 			return nil, &btcjson.RPCError{
 				Code: btcjson.ErrRPCInternal.Code,
 				Message: fmt.Sprintf("unknown deployment status: %v",
-					deploymentStatus),
+					deploymentStatus.State),
+			}
+		}
+
+		// Include the signalling statistics of the current window
+		// while the deployment is being voted on.
+		var statistics *btcjson.Bip9SoftForkStatistics
+		if stats := deploymentStatus.Stats; stats != nil {
+			statistics = &btcjson.Bip9SoftForkStatistics{
+				Period:    stats.Period,
+				Threshold: stats.Threshold,
+				Elapsed:   stats.Elapsed,
+				Count:     stats.Count,
+				Possible:  stats.Possible,
 			}
 		}
 
@@ -1301,7 +1440,9 @@ func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 			Bit:                 deploymentDetails.BitNumber,
 			StartTime2:          startTime,
 			Timeout:             endTime,
+			Since:               deploymentStatus.Since,
 			MinActivationHeight: int32(deploymentDetails.MinActivationHeight),
+			Statistics:          statistics,
 		}
 	}
 
@@ -1392,6 +1533,7 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		Time:          blockHeader.Timestamp.Unix(),
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		Difficulty:    getDifficultyRatio(blockHeader.Bits, params),
+		Chainlock:     s.cfg.Chain.IsChainLocked(hash),
 	}
 	return blockHeaderReply, nil
 }
@@ -2555,6 +2697,7 @@ func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 			BanScore:       int32(p.BanScore()),
 			FeeFilter:      p.FeeFilter(),
 			SyncNode:       statsSnap.ID == syncPeerID,
+			Permissions:    p.ToPeer().Permissions().Names(),
 		}
 		if p.ToPeer().LastPingNonce() != 0 {
 			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
@@ -2705,6 +2848,183 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return *rawTxn, nil
 }
 
+// handleGetSpecialTxes implements the getspecialtxes command.
+func handleGetSpecialTxes(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetSpecialTxesCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	startHeight, err := s.cfg.Chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	txType := -1
+	if c.Type != nil {
+		txType = *c.Type
+	}
+	count := 10
+	if c.Count != nil {
+		count = *c.Count
+	}
+	skip := 0
+	if c.Skip != nil {
+		skip = *c.Skip
+	}
+	verbosity := 0
+	if c.Verbosity != nil {
+		verbosity = *c.Verbosity
+	}
+	numBlocks := 1
+	if c.NumBlocks != nil {
+		numBlocks = *c.NumBlocks
+	}
+	switch {
+	case txType < -1 || txType > math.MaxUint16:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid special transaction type %d", txType),
+		}
+	case count < 0 || skip < 0:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Count and skip must not be negative",
+		}
+	case verbosity < 0 || verbosity > 2:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Verbosity must be 0, 1 or 2",
+		}
+	case numBlocks < 1:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Number of blocks must be at least 1",
+		}
+	case numBlocks > 1 && s.cfg.SpecialTxIndex == nil:
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCNoTxInfo,
+			Message: "The special transaction index must be " +
+				"enabled to query more than one block " +
+				"(specify --specialtxindex)",
+		}
+	}
+
+	// Limit the range of blocks to the main chain.
+	chainHeight := s.cfg.Chain.BestSnapshot().Height
+	endHeight := chainHeight
+	if int64(startHeight)+int64(numBlocks)-1 < int64(chainHeight) {
+		endHeight = startHeight + int32(numBlocks) - 1
+	}
+
+	// Find the positions of the requested special transactions in the
+	// chain using the index when it is available and otherwise by loading
+	// the only block queried.
+	var entries []indexers.SpecialTxEntry
+	blocks := make(map[int32]*btcutil.Block)
+	if s.cfg.SpecialTxIndex != nil {
+		var txTypes []wire.TxType
+		if txType != -1 {
+			txTypes = []wire.TxType{wire.TxType(txType)}
+		}
+		entries, err = s.cfg.SpecialTxIndex.SpecialTxs(txTypes,
+			startHeight, endHeight, skip, count)
+		if err != nil {
+			context := "Failed to query special transaction index"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	} else {
+		block, err := s.cfg.Chain.BlockByHash(hash)
+		if err != nil {
+			context := "Failed to load block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		blocks[startHeight] = block
+
+		for i, tx := range block.Transactions() {
+			msgTx := tx.MsgTx()
+			if !msgTx.IsSpecial() || (txType != -1 &&
+				msgTx.TxType() != wire.TxType(txType)) {
+
+				continue
+			}
+			if len(entries) == skip+count {
+				break
+			}
+			entries = append(entries, indexers.SpecialTxEntry{
+				Type:   msgTx.TxType(),
+				Height: startHeight,
+				Index:  uint32(i),
+				Hash:   *tx.Hash(),
+			})
+		}
+		if skip >= len(entries) {
+			entries = nil
+		} else {
+			entries = entries[skip:]
+		}
+	}
+
+	// The transaction hashes are all that is needed for verbosity 0.
+	if verbosity == 0 {
+		txids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			txids = append(txids, entry.Hash.String())
+		}
+		return txids, nil
+	}
+
+	// Load the blocks containing the transactions otherwise.
+	txHexes := make([]string, 0, len(entries))
+	rawTxns := make([]btcjson.TxRawResult, 0, len(entries))
+	for _, entry := range entries {
+		block, ok := blocks[entry.Height]
+		if !ok {
+			block, err = s.cfg.Chain.BlockByHeight(entry.Height)
+			if err != nil {
+				context := "Failed to load block"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			blocks[entry.Height] = block
+		}
+		txns := block.Transactions()
+		if int(entry.Index) >= len(txns) ||
+			*txns[entry.Index].Hash() != entry.Hash {
+
+			context := "Special transaction index is inconsistent"
+			return nil, internalRPCError(entry.Hash.String(), context)
+		}
+		mtx := txns[entry.Index].MsgTx()
+
+		if verbosity == 1 {
+			mtxHex, err := messageToHex(mtx)
+			if err != nil {
+				return nil, err
+			}
+			txHexes = append(txHexes, mtxHex)
+			continue
+		}
+
+		header := block.MsgBlock().Header
+		rawTxn, err := createTxRawResult(s.cfg.ChainParams, mtx,
+			entry.Hash.String(), &header, block.Hash().String(),
+			entry.Height, chainHeight)
+		if err != nil {
+			return nil, err
+		}
+		rawTxns = append(rawTxns, *rawTxn)
+	}
+
+	if verbosity == 1 {
+		return txHexes, nil
+	}
+	return rawTxns, nil
+}
+
 // handleGetTxOut handles gettxout commands.
 func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.GetTxOutCmd)
@@ -2811,6 +3131,16 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	return txOutReply, nil
 }
 
+// handleDiscover implements the rpc.discover command.
+func handleDiscover(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	doc, err := s.helpCacher.rpcDiscover()
+	if err != nil {
+		context := "Failed to generate OpenRPC document"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	return doc, nil
+}
+
 // handleHelp implements the help command.
 func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.HelpCmd)
@@ -3486,10 +3816,6 @@ func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
-// Text used to signify that a signed message follows and to prevent
-// inadvertently signing a transaction.
-const messageSignatureHeader = "Bitcoin Signed Message:\n"
-
 // handleSignMessageWithPrivKey implements the signmessagewithprivkey command.
 func handleSignMessageWithPrivKey(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.SignMessageWithPrivKeyCmd)
@@ -3515,13 +3841,7 @@ func handleSignMessageWithPrivKey(s *rpcServer, cmd interface{}, closeChan <-cha
 		}
 	}
 
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, messageSignatureHeader)
-	wire.WriteVarString(&buf, 0, c.Message)
-	messageHash := chainhash.DoubleHashB(buf.Bytes())
-
-	sig, err := ecdsa.SignCompact(wif.PrivKey,
-		messageHash, wif.CompressPubKey)
+	sig, err := btcutil.SignMessage(wif, c.Message)
 	if err != nil {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCInvalidAddressOrKey,
@@ -3529,7 +3849,7 @@ func handleSignMessageWithPrivKey(s *rpcServer, cmd interface{}, closeChan <-cha
 		}
 	}
 
-	return base64.StdEncoding.EncodeToString(sig), nil
+	return sig, nil
 }
 
 // handleStop implements the stop command.
@@ -3692,53 +4012,23 @@ func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 		}
 	}
 
-	// Only P2PKH addresses are valid for signing.
-	if _, ok := addr.(*btcutil.AddressPubKeyHash); !ok {
+	// Only P2PKH addresses are valid for signing.  Errors recovering the
+	// public key from the signature mirror Dash Core by treating the
+	// signature as invalid.
+	valid, err := btcutil.VerifyMessage(addr, c.Signature, c.Message)
+	switch {
+	case err == btcutil.ErrMessageAddressType:
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCType,
 			Message: "Address is not a pay-to-pubkey-hash address",
 		}
-	}
-
-	// Decode base64 signature.
-	sig, err := base64.StdEncoding.DecodeString(c.Signature)
-	if err != nil {
+	case err != nil:
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCParse.Code,
 			Message: "Malformed base64 encoding: " + err.Error(),
 		}
 	}
-
-	// Validate the signature - this just shows that it was valid at all.
-	// we will compare it with the key next.
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, messageSignatureHeader)
-	wire.WriteVarString(&buf, 0, c.Message)
-	expectedMessageHash := chainhash.DoubleHashB(buf.Bytes())
-	pk, wasCompressed, err := ecdsa.RecoverCompact(sig,
-		expectedMessageHash)
-	if err != nil {
-		// Mirror Bitcoin Core behavior, which treats error in
-		// RecoverCompact as invalid signature.
-		return false, nil
-	}
-
-	// Reconstruct the pubkey hash.
-	var serializedPK []byte
-	if wasCompressed {
-		serializedPK = pk.SerializeCompressed()
-	} else {
-		serializedPK = pk.SerializeUncompressed()
-	}
-	address, err := btcutil.NewAddressPubKey(serializedPK, params)
-	if err != nil {
-		// Again mirror Bitcoin Core behavior, which treats error in public key
-		// reconstruction as invalid signature.
-		return false, nil
-	}
-
-	// Return boolean if addresses match.
-	return address.EncodeAddress() == c.Address, nil
+	return valid, nil
 }
 
 // handleVersion implements the version command.
@@ -3765,6 +4055,8 @@ type rpcServer struct {
 	limitauthsha           [sha256.Size]byte
 	ntfnMgr                *wsNotificationManager
 	numClients             int32
+	batchSem               semaphore
+	originLimiter          *rpcOriginLimiter
 	statusLines            map[int]string
 	statusLock             sync.RWMutex
 	wg                     sync.WaitGroup
@@ -3888,6 +4180,31 @@ func (s *rpcServer) limitConnections(w http.ResponseWriter, remoteAddr string) b
 	return false
 }
 
+// limitOrigin responds with a 429 too many requests and returns true if the
+// request is from a browser-based client whose origin exceeded the rate limit
+// set by the rpcoriginratelimit option.
+//
+// The limit is keyed on the Origin header, which other clients control and may
+// change or omit at will, so it only throttles the scripts of the sites a
+// browser runs.  The connections of other clients are limited by
+// limitConnections.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) limitOrigin(w http.ResponseWriter, r *http.Request, remoteAddr string) bool {
+	origin := r.Header.Get("Origin")
+	if s.originLimiter == nil || origin == "" {
+		return false
+	}
+	if !s.originLimiter.Allow(origin, time.Now()) {
+		rpcsLog.Debugf("RPC rate limit exceeded for origin %s - "+
+			"rejecting request from %s", origin, remoteAddr)
+		http.Error(w, "429 Too many requests.  Try again later.",
+			http.StatusTooManyRequests)
+		return true
+	}
+	return false
+}
+
 // incrementClients adds one to the number of connected RPC clients.  Note
 // this only applies to standard clients.  Websocket clients have their own
 // limits and are tracked separately.
@@ -3922,7 +4239,7 @@ func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error)
 	if len(authhdr) <= 0 {
 		if require {
 			rpcsLog.Warnf("RPC authentication failure from %s",
-				r.RemoteAddr)
+				rpcClientAddr(r))
 			return false, false, errors.New("auth failure")
 		}
 
@@ -3945,7 +4262,7 @@ func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error)
 	}
 
 	// Request's auth doesn't match either user
-	rpcsLog.Warnf("RPC authentication failure from %s", r.RemoteAddr)
+	rpcsLog.Warnf("RPC authentication failure from %s", rpcClientAddr(r))
 	return false, false, errors.New("auth failure")
 }
 
@@ -4098,6 +4415,83 @@ func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, close
 	return msg
 }
 
+// marshalledRPCError returns a marshalled JSON-RPC response for the passed
+// error code and message that is not associated with any request id.
+func marshalledRPCError(rpcVersion btcjson.RPCVersion, code btcjson.RPCErrorCode, message string) json.RawMessage {
+	jsonErr := &btcjson.RPCError{
+		Code:    code,
+		Message: message,
+	}
+	resp, err := btcjson.MarshalResponse(rpcVersion, nil, nil, jsonErr)
+	if err != nil {
+		rpcsLog.Errorf("Failed to create reply: %v", err)
+		return nil
+	}
+	return resp
+}
+
+// processBatch processes the entries of a batched JSON-RPC request and returns
+// the marshalled responses in the same order as the entries they belong to.
+// Entries without a response, such as notifications, are omitted.
+//
+// The entries are processed concurrently, with at most the configured number of
+// max concurrent requests being processed at once across all batches.  When the
+// rpcserialbatch option is set, they are processed one after the other instead,
+// so later entries observe the effects of earlier ones.
+func (s *rpcServer) processBatch(entries []json.RawMessage, isAdmin bool, closeChan <-chan struct{}) []json.RawMessage {
+	responses := make([]json.RawMessage, len(entries))
+
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		var req btcjson.Request
+		if err := json.Unmarshal(entry, &req); err != nil {
+			responses[i] = marshalledRPCError(btcjson.RpcVersion2,
+				btcjson.ErrRPCInvalidRequest.Code,
+				fmt.Sprintf("Invalid request: %v", err))
+			continue
+		}
+
+		if s.batchSem == nil {
+			responses[i] = s.processRequest(&req, isAdmin, closeChan)
+			continue
+		}
+
+		s.batchSem.acquire()
+		wg.Add(1)
+		go func(i int, req *btcjson.Request) {
+			defer func() {
+				s.batchSem.release()
+				wg.Done()
+			}()
+
+			responses[i] = s.processRequest(req, isAdmin, closeChan)
+		}(i, &req)
+	}
+	wg.Wait()
+
+	// Remove the entries that did not produce a response while retaining
+	// the order of the remaining ones.
+	results := responses[:0]
+	for _, resp := range responses {
+		if resp != nil {
+			results = append(results, resp)
+		}
+	}
+	return results
+}
+
+// requiresHijack returns whether or not any of the passed requests may block
+// for an extended period, such as a getblocktemplate long poll, and therefore
+// needs the connection to be hijacked so its read deadline can be cleared.
+func requiresHijack(requests []btcjson.Request) bool {
+	for i := range requests {
+		if requests[i].Method == "getblocktemplate" {
+			return true
+		}
+	}
+	return false
+}
+
 // jsonRPCRead handles reading and responding to RPC messages.
 func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin bool) {
 	if atomic.LoadInt32(&s.shutdown) != 0 {
@@ -4114,226 +4508,173 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 		return
 	}
 
-	// Unfortunately, the http server doesn't provide the ability to
-	// change the read deadline for the new connection and having one breaks
-	// long polling.  However, not having a read deadline on the initial
-	// connection would mean clients can connect and idle forever.  Thus,
-	// hijack the connecton from the HTTP server, clear the read deadline,
-	// and handle writing the response manually.
-	hj, ok := w.(http.Hijacker)
-	if !ok {
-		errMsg := "webserver doesn't support hijacking"
-		rpcsLog.Warnf(errMsg)
-		errCode := http.StatusInternalServerError
-		http.Error(w, strconv.Itoa(errCode)+" "+errMsg, errCode)
-		return
-	}
-	conn, buf, err := hj.Hijack()
-	if err != nil {
-		rpcsLog.Warnf("Failed to hijack HTTP connection: %v", err)
-		errCode := http.StatusInternalServerError
-		http.Error(w, strconv.Itoa(errCode)+" "+err.Error(), errCode)
-		return
-	}
-	defer conn.Close()
-	defer buf.Flush()
-	conn.SetReadDeadline(timeZeroVal)
-
-	// Attempt to parse the raw body into a JSON-RPC request.
-	// Setup a close notifier.  Since the connection is hijacked,
-	// the CloseNotifer on the ResponseWriter is not available.
-	closeChan := make(chan struct{}, 1)
-	go func() {
-		_, err = conn.Read(make([]byte, 1))
-		if err != nil {
-			close(closeChan)
+	// Attempt to parse the raw body into either a single JSON-RPC request
+	// or the entries of a batched request.  Any failures are recorded as
+	// the response to send back to the caller.
+	var (
+		single         btcjson.Request
+		entries        []json.RawMessage
+		requests       []btcjson.Request
+		errResp        json.RawMessage
+		batchedRequest = bytes.HasPrefix(body, batchedRequestPrefix)
+	)
+	if !batchedRequest {
+		if err := json.Unmarshal(body, &single); err != nil {
+			errResp = marshalledRPCError(btcjson.RpcVersion1,
+				btcjson.ErrRPCParse.Code,
+				fmt.Sprintf("Failed to parse request: %v", err))
+		} else {
+			requests = append(requests, single)
 		}
-	}()
-
-	var results []json.RawMessage
-	var batchSize int
-	var batchedRequest bool
-
-	// Determine request type
-	if bytes.HasPrefix(body, batchedRequestPrefix) {
-		batchedRequest = true
-	}
+	} else {
+		err := json.Unmarshal(body, &entries)
+		switch {
+		case err != nil:
+			errResp = marshalledRPCError(btcjson.RpcVersion2,
+				btcjson.ErrRPCParse.Code,
+				fmt.Sprintf("Failed to parse request: %v", err))
+
+		// Respond with an empty batch error if the batch size is zero.
+		case len(entries) == 0:
+			errResp = marshalledRPCError(btcjson.RpcVersion2,
+				btcjson.ErrRPCInvalidRequest.Code,
+				"Invalid request: empty batch")
+
+		case len(entries) > cfg.RPCMaxBatchSize:
+			errResp = marshalledRPCError(btcjson.RpcVersion2,
+				btcjson.ErrRPCInvalidRequest.Code,
+				fmt.Sprintf("Invalid request: batch size %d "+
+					"exceeds the maximum of %d", len(entries),
+					cfg.RPCMaxBatchSize))
 
-	// Process a single request
-	if !batchedRequest {
-		var req btcjson.Request
-		var resp json.RawMessage
-		err = json.Unmarshal(body, &req)
-		if err != nil {
-			jsonErr := &btcjson.RPCError{
-				Code: btcjson.ErrRPCParse.Code,
-				Message: fmt.Sprintf("Failed to parse request: %v",
-					err),
-			}
-			resp, err = btcjson.MarshalResponse(btcjson.RpcVersion1, nil, nil, jsonErr)
-			if err != nil {
-				rpcsLog.Errorf("Failed to create reply: %v", err)
+		default:
+			requests = make([]btcjson.Request, 0, len(entries))
+			for _, entry := range entries {
+				var req btcjson.Request
+				if json.Unmarshal(entry, &req) == nil {
+					requests = append(requests, req)
+				}
 			}
 		}
+	}
 
-		if err == nil {
-			// The JSON-RPC 1.0 spec defines that notifications must have their "id"
-			// set to null and states that notifications do not have a response.
-			//
-			// A JSON-RPC 2.0 notification is a request with "json-rpc":"2.0", and
-			// without an "id" member. The specification states that notifications
-			// must not be responded to. JSON-RPC 2.0 permits the null value as a
-			// valid request id, therefore such requests are not notifications.
-			//
-			// Bitcoin Core serves requests with "id":null or even an absent "id",
-			// and responds to such requests with "id":null in the response.
-			//
-			// Btcd does not respond to any request without and "id" or "id":null,
-			// regardless the indicated JSON-RPC protocol version unless RPC quirks
-			// are enabled. With RPC quirks enabled, such requests will be responded
-			// to if the reqeust does not indicate JSON-RPC version.
-			//
-			// RPC quirks can be enabled by the user to avoid compatibility issues
-			// with software relying on Core's behavior.
-			if req.ID == nil && !(cfg.RPCQuirks && req.Jsonrpc == "") {
-				return
+	// Requests are served directly via the response writer by default,
+	// which notifies of client disconnects through the request context.
+	closeChan := r.Context().Done()
+	var respWriter io.Writer
+	if !requiresHijack(requests) {
+		var respBuf bytes.Buffer
+		respWriter = &respBuf
+		defer func() {
+			w.Header().Set("Content-Length", strconv.Itoa(respBuf.Len()))
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(respBuf.Bytes()); err != nil {
+				rpcsLog.Errorf("Failed to write marshalled reply: %v",
+					err)
 			}
-			resp = s.processRequest(&req, isAdmin, closeChan)
+		}()
+	} else {
+		// Unfortunately, the http server doesn't provide the ability
+		// to change the read deadline for the new connection and
+		// having one breaks long polling.  However, not having a read
+		// deadline on the initial connection would mean clients can
+		// connect and idle forever.  Thus, hijack the connecton from
+		// the HTTP server, clear the read deadline, and handle writing
+		// the response manually.
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			errMsg := "webserver doesn't support hijacking"
+			rpcsLog.Warnf(errMsg)
+			errCode := http.StatusInternalServerError
+			http.Error(w, strconv.Itoa(errCode)+" "+errMsg, errCode)
+			return
 		}
-
-		if resp != nil {
-			results = append(results, resp)
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			rpcsLog.Warnf("Failed to hijack HTTP connection: %v", err)
+			errCode := http.StatusInternalServerError
+			http.Error(w, strconv.Itoa(errCode)+" "+err.Error(), errCode)
+			return
 		}
-	}
+		defer conn.Close()
+		defer buf.Flush()
+		conn.SetReadDeadline(timeZeroVal)
 
-	// Process a batched request
-	if batchedRequest {
-		var batchedRequests []interface{}
-		var resp json.RawMessage
-		err = json.Unmarshal(body, &batchedRequests)
-		if err != nil {
-			jsonErr := &btcjson.RPCError{
-				Code: btcjson.ErrRPCParse.Code,
-				Message: fmt.Sprintf("Failed to parse request: %v",
-					err),
-			}
-			resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
+		// Setup a close notifier.  Since the connection is hijacked,
+		// the CloseNotifer on the ResponseWriter is not available.
+		hijackedCloseChan := make(chan struct{}, 1)
+		go func() {
+			_, err := conn.Read(make([]byte, 1))
 			if err != nil {
-				rpcsLog.Errorf("Failed to create reply: %v", err)
+				close(hijackedCloseChan)
 			}
+		}()
+		closeChan = hijackedCloseChan
 
-			if resp != nil {
-				results = append(results, resp)
-			}
+		// The hijacked connection is closed once the response is
+		// written rather than kept alive for further requests.
+		w.Header().Set("Connection", "close")
+		err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
+		if err != nil {
+			rpcsLog.Error(err)
+			return
 		}
-
-		if err == nil {
-			// Response with an empty batch error if the batch size is zero
-			if len(batchedRequests) == 0 {
-				jsonErr := &btcjson.RPCError{
-					Code:    btcjson.ErrRPCInvalidRequest.Code,
-					Message: "Invalid request: empty batch",
-				}
-				resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
-				if err != nil {
-					rpcsLog.Errorf("Failed to marshal reply: %v", err)
-				}
-
-				if resp != nil {
-					results = append(results, resp)
-				}
-			}
-
-			// Process each batch entry individually
-			if len(batchedRequests) > 0 {
-				batchSize = len(batchedRequests)
-
-				for _, entry := range batchedRequests {
-					var reqBytes []byte
-					reqBytes, err = json.Marshal(entry)
-					if err != nil {
-						jsonErr := &btcjson.RPCError{
-							Code: btcjson.ErrRPCInvalidRequest.Code,
-							Message: fmt.Sprintf("Invalid request: %v",
-								err),
-						}
-						resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
-						if err != nil {
-							rpcsLog.Errorf("Failed to create reply: %v", err)
-						}
-
-						if resp != nil {
-							results = append(results, resp)
-						}
-						continue
-					}
-
-					var req btcjson.Request
-					err := json.Unmarshal(reqBytes, &req)
-					if err != nil {
-						jsonErr := &btcjson.RPCError{
-							Code: btcjson.ErrRPCInvalidRequest.Code,
-							Message: fmt.Sprintf("Invalid request: %v",
-								err),
-						}
-						resp, err = btcjson.MarshalResponse("", nil, nil, jsonErr)
-						if err != nil {
-							rpcsLog.Errorf("Failed to create reply: %v", err)
-						}
-
-						if resp != nil {
-							results = append(results, resp)
-						}
-						continue
-					}
-
-					resp = s.processRequest(&req, isAdmin, closeChan)
-					if resp != nil {
-						results = append(results, resp)
-					}
-				}
-			}
+		respWriter = buf
+	}
+
+	var msg json.RawMessage
+	switch {
+	case errResp != nil:
+		msg = errResp
+
+	case !batchedRequest:
+		// The JSON-RPC 1.0 spec defines that notifications must have their "id"
+		// set to null and states that notifications do not have a response.
+		//
+		// A JSON-RPC 2.0 notification is a request with "json-rpc":"2.0", and
+		// without an "id" member. The specification states that notifications
+		// must not be responded to. JSON-RPC 2.0 permits the null value as a
+		// valid request id, therefore such requests are not notifications.
+		//
+		// Bitcoin Core serves requests with "id":null or even an absent "id",
+		// and responds to such requests with "id":null in the response.
+		//
+		// Btcd does not respond to any request without and "id" or "id":null,
+		// regardless the indicated JSON-RPC protocol version unless RPC quirks
+		// are enabled. With RPC quirks enabled, such requests will be responded
+		// to if the reqeust does not indicate JSON-RPC version.
+		//
+		// RPC quirks can be enabled by the user to avoid compatibility issues
+		// with software relying on Core's behavior.
+		if single.ID == nil && !(cfg.RPCQuirks && single.Jsonrpc == "") {
+			return
 		}
-	}
+		msg = s.processRequest(&single, isAdmin, closeChan)
 
-	var msg = []byte{}
-	if batchedRequest && batchSize > 0 {
+	default:
+		// Form the batched response json from the ordered results.
+		results := s.processBatch(entries, isAdmin, closeChan)
 		if len(results) > 0 {
-			// Form the batched response json
 			var buffer bytes.Buffer
 			buffer.WriteByte('[')
 			for idx, reply := range results {
-				if idx == len(results)-1 {
-					buffer.Write(reply)
-					buffer.WriteByte(']')
-					break
+				if idx > 0 {
+					buffer.WriteByte(',')
 				}
 				buffer.Write(reply)
-				buffer.WriteByte(',')
 			}
+			buffer.WriteByte(']')
 			msg = buffer.Bytes()
 		}
 	}
 
-	if !batchedRequest || batchSize == 0 {
-		// Respond with the first results entry for single requests
-		if len(results) > 0 {
-			msg = results[0]
-		}
-	}
-
 	// Write the response.
-	err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
-	if err != nil {
-		rpcsLog.Error(err)
-		return
-	}
-	if _, err := buf.Write(msg); err != nil {
+	if _, err := respWriter.Write(msg); err != nil {
 		rpcsLog.Errorf("Failed to write marshalled reply: %v", err)
 	}
 
 	// Terminate with newline to maintain compatibility with Bitcoin Core.
-	if err := buf.WriteByte('\n'); err != nil {
+	if _, err := respWriter.Write([]byte{'\n'}); err != nil {
 		rpcsLog.Errorf("Failed to append terminating newline to reply: %v", err)
 	}
 }
@@ -4358,14 +4699,27 @@ func (s *rpcServer) Start() {
 		// Timeout connections which don't complete the initial
 		// handshake within the allowed timeframe.
 		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+
+		// Close kept alive connections which don't send another
+		// request within the allowed timeframe.
+		IdleTimeout: rpcIdleTimeout,
 	}
 	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Connection", "close")
+		// Allow browser-based clients of the configured origins to make
+		// cross-origin requests.  Preflight requests are answered
+		// without authentication since browsers don't send credentials
+		// with them.
+		if handleRPCCORS(w, r) {
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
-		r.Close = true
 
-		// Limit the number of connections to max allowed.
-		if s.limitConnections(w, r.RemoteAddr) {
+		// Limit the number of connections to max allowed and the rate
+		// of requests from browser origins.
+		remoteAddr := rpcClientAddr(r)
+		if s.limitConnections(w, remoteAddr) ||
+			s.limitOrigin(w, r, remoteAddr) {
+
 			return
 		}
 
@@ -4384,6 +4738,10 @@ func (s *rpcServer) Start() {
 
 	// Websocket endpoint.
 	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr := rpcClientAddr(r)
+		if s.limitOrigin(w, r, remoteAddr) {
+			return
+		}
 		authenticated, isAdmin, err := s.checkAuth(r, false)
 		if err != nil {
 			jsonAuthFail(w)
@@ -4401,7 +4759,7 @@ func (s *rpcServer) Start() {
 			http.Error(w, "400 Bad Request.", http.StatusBadRequest)
 			return
 		}
-		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
+		s.WebsocketHandler(ws, remoteAddr, authenticated, isAdmin)
 	})
 
 	for _, listener := range s.cfg.Listeners {
@@ -4460,6 +4818,13 @@ type rpcserverPeer interface {
 	// FeeFilter returns the requested current minimum fee rate for which
 	// transactions should be announced.
 	FeeFilter() int64
+
+	// IsPersistent returns whether or not the peer is reconnected to when
+	// the connection is lost.
+	IsPersistent() bool
+
+	// IsWhitelisted returns whether or not the peer is whitelisted.
+	IsWhitelisted() bool
 }
 
 // rpcserverConnManager represents a connection manager for use with the RPC
@@ -4595,9 +4960,10 @@ type rpcserverConfig struct {
 
 	// These fields define any optional indexes the RPC server can make use
 	// of to provide additional data when queried.
-	TxIndex   *indexers.TxIndex
-	AddrIndex *indexers.AddrIndex
-	CfIndex   *indexers.CfIndex
+	TxIndex        *indexers.TxIndex
+	AddrIndex      *indexers.AddrIndex
+	CfIndex        *indexers.CfIndex
+	SpecialTxIndex *indexers.SpecialTxIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -4614,6 +4980,13 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 		requestProcessShutdown: make(chan struct{}),
 		quit:                   make(chan int),
 	}
+	if !cfg.RPCSerialBatch {
+		workers := cfg.RPCMaxConcurrentReqs
+		if workers < 1 {
+			workers = 1
+		}
+		rpc.batchSem = makeSemaphore(workers)
+	}
 	if cfg.RPCUser != "" && cfg.RPCPass != "" {
 		login := cfg.RPCUser + ":" + cfg.RPCPass
 		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
@@ -4624,6 +4997,9 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
 		rpc.limitauthsha = sha256.Sum256([]byte(auth))
 	}
+	if cfg.RPCOriginRateLimit > 0 {
+		rpc.originLimiter = newRPCOriginLimiter(cfg.RPCOriginRateLimit)
+	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 	rpc.cfg.Chain.Subscribe(rpc.handleBlockchainNotification)
 
@@ -0,0 +1,148 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBatchedRequests ensures the entries of batched JSON-RPC requests are
+// processed in order, that notifications aren't responded to and that batches
+// over the maximum batch size are rejected.
+func TestBatchedRequests(t *testing.T) {
+	defer func(oldCfg *config) { cfg = oldCfg }(cfg)
+	defer func(handler commandHandler) {
+		rpcHandlers["uptime"] = handler
+	}(rpcHandlers["uptime"])
+
+	// The uptime handler returns the number of times it was called, so the
+	// results reveal the order the entries were processed in.
+	var calls int64
+	rpcHandlers["uptime"] = func(*rpcServer, interface{}, <-chan struct{}) (interface{}, error) {
+		return atomic.AddInt64(&calls, 1), nil
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		maxBatch int
+		want     string
+	}{{
+		name: "ordered entries",
+		body: `[{"jsonrpc":"2.0","method":"uptime","params":[],"id":1},` +
+			`{"jsonrpc":"2.0","method":"uptime","params":[],"id":2},` +
+			`{"jsonrpc":"2.0","method":"uptime","params":[],"id":3}]`,
+		maxBatch: 3,
+		want: `[{"jsonrpc":"2.0","result":1,"error":null,"id":1},` +
+			`{"jsonrpc":"2.0","result":2,"error":null,"id":2},` +
+			`{"jsonrpc":"2.0","result":3,"error":null,"id":3}]`,
+	}, {
+		name: "notifications are not responded to",
+		body: `[{"jsonrpc":"2.0","method":"uptime","params":[]},` +
+			`{"jsonrpc":"2.0","method":"uptime","params":[],"id":2}]`,
+		maxBatch: 3,
+		want:     `[{"jsonrpc":"2.0","result":1,"error":null,"id":2}]`,
+	}, {
+		name: "notifications only",
+		body: `[{"jsonrpc":"2.0","method":"uptime","params":[]},` +
+			`{"jsonrpc":"2.0","method":"uptime","params":[]}]`,
+		maxBatch: 3,
+		want:     ``,
+	}, {
+		name: "batch over the maximum size",
+		body: `[{"jsonrpc":"2.0","method":"uptime","params":[],"id":1},` +
+			`{"jsonrpc":"2.0","method":"uptime","params":[],"id":2}]`,
+		maxBatch: 1,
+		want: `{"jsonrpc":"2.0","result":null,"error":{"code":-32600,` +
+			`"message":"Invalid request: batch size 2 exceeds the ` +
+			`maximum of 1"},"id":null}`,
+	}}
+
+	s := &rpcServer{}
+	for _, test := range tests {
+		cfg = &config{RPCMaxBatchSize: test.maxBatch}
+		atomic.StoreInt64(&calls, 0)
+
+		r := httptest.NewRequest(http.MethodPost, "/",
+			strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		s.jsonRPCRead(w, r, true)
+		got := strings.TrimSuffix(w.Body.String(), "\n")
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestParallelBatchedRequests ensures the entries of batched JSON-RPC requests
+// are processed concurrently by default, bounded by the max number of
+// concurrent requests across all batches, and still responded to in order.
+func TestParallelBatchedRequests(t *testing.T) {
+	defer func(oldCfg *config) { cfg = oldCfg }(cfg)
+	defer func(handler commandHandler) {
+		rpcHandlers["uptime"] = handler
+	}(rpcHandlers["uptime"])
+
+	const maxConcurrent = 2
+	var active, maxActive int64
+	rpcHandlers["uptime"] = func(*rpcServer, interface{}, <-chan struct{}) (interface{}, error) {
+		n := atomic.AddInt64(&active, 1)
+		for {
+			max := atomic.LoadInt64(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt64(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt64(&active, -1)
+		return 0, nil
+	}
+
+	cfg = &config{
+		RPCMaxBatchSize:      10,
+		RPCMaxConcurrentReqs: maxConcurrent,
+	}
+	s := &rpcServer{batchSem: makeSemaphore(maxConcurrent)}
+
+	body := `[{"jsonrpc":"2.0","method":"uptime","params":[],"id":1},` +
+		`{"jsonrpc":"2.0","method":"uptime","params":[],"id":2},` +
+		`{"jsonrpc":"2.0","method":"uptime","params":[],"id":3},` +
+		`{"jsonrpc":"2.0","method":"uptime","params":[],"id":4}]`
+	want := `[{"jsonrpc":"2.0","result":0,"error":null,"id":1},` +
+		`{"jsonrpc":"2.0","result":0,"error":null,"id":2},` +
+		`{"jsonrpc":"2.0","result":0,"error":null,"id":3},` +
+		`{"jsonrpc":"2.0","result":0,"error":null,"id":4}]`
+
+	// Process several batches at once to ensure the limit applies across
+	// clients rather than to each batch.
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			r := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(body))
+			w := httptest.NewRecorder()
+			s.jsonRPCRead(w, r, true)
+			got := strings.TrimSuffix(w.Body.String(), "\n")
+			if got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if max := atomic.LoadInt64(&maxActive); max > maxConcurrent {
+		t.Fatalf("%d requests processed concurrently, want at most %d",
+			max, maxConcurrent)
+	}
+}
@@ -29,6 +29,44 @@ var helpDescsEnUS = map[string]string{
 	"debuglevel--result0":    "The string 'Done.'",
 	"debuglevel--result1":    "The list of subsystems",
 
+	// DumpPeerStateCmd help.
+	"dumppeerstate--synopsis": "Returns the internal state of each connected network peer as an array of json objects for diagnosing connection issues.",
+
+	// PeerStateResult help.
+	"peerstateresult-id":                 "A unique node ID",
+	"peerstateresult-addr":               "The ip address and port of the peer",
+	"peerstateresult-inbound":            "Whether or not the peer is an inbound connection",
+	"peerstateresult-persistent":         "Whether or not the peer is reconnected to when the connection is lost",
+	"peerstateresult-whitelisted":        "Whether or not the peer is whitelisted",
+	"peerstateresult-syncnode":           "Whether or not the peer is the sync peer",
+	"peerstateresult-connected":          "Whether or not the peer is still connected",
+	"peerstateresult-handshake":          "The state of the version handshake",
+	"peerstateresult-protocolversion":    "The negotiated protocol version",
+	"peerstateresult-services":           "The services advertised by the peer",
+	"peerstateresult-relaytxes":          "Peer has requested transactions be relayed to it",
+	"peerstateresult-wantsheaders":       "Peer has requested blocks be announced with headers messages",
+	"peerstateresult-blocksonly":         "Whether or not only blocks and headers are relayed with the peer",
+	"peerstateresult-lastblock":          "The height of the last block the peer is known to have",
+	"peerstateresult-lastannouncedblock": "The hash of the last block announced by the peer",
+	"peerstateresult-lastpingnonce":      "The nonce of a queued ping which has not been answered",
+	"peerstateresult-banscore":           "The ban score",
+	"peerstateresult-feefilter":          "The requested minimum fee a transaction must have to be announced to the peer",
+	"peerstateresult-bytessent":          "Total bytes sent",
+	"peerstateresult-bytesrecv":          "Total bytes received",
+
+	// GetProfileCmd help.
+	"getprofile--synopsis": "Captures a runtime profile of the process in the pprof format.\n" +
+		"The cpu profile samples the process for the requested number of seconds while the other profiles are captured immediately.\n" +
+		"A non-zero debug level formats the profiles other than cpu as text, such as the stacks of all goroutines for the goroutine profile with debug level 2.",
+	"getprofile-profile": "The name of the profile: cpu, goroutine, heap, allocs, threadcreate, block, or mutex",
+	"getprofile-seconds": "The number of seconds to sample the cpu profile for, up to 300",
+	"getprofile-debug":   "The debug level of the profile",
+
+	// GetProfileResult help.
+	"getprofileresult-profile":  "The name of the profile",
+	"getprofileresult-encoding": "The encoding of the data: base64 for pprof profiles or text",
+	"getprofileresult-data":     "The profile",
+
 	// AddNodeCmd help.
 	"addnode--synopsis": "Attempts to add or remove a persistent peer.",
 	"addnode-addr":      "IP address and port of the peer to operate on",
@@ -258,7 +296,7 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockverboseresult-strippedsize":      "The size of the block without witness data",
 	"getblockverboseresult-weight":            "The weight of the block",
-	"getblockverboseresult-chainlock":         "A bool to indicate the state of the chainlock",
+	"getblockverboseresult-chainlock":         "Whether the block is covered by the best verified ChainLock (always false when ChainLocks are not tracked since no BLS verifier is available)",
 
 	// GetBlockCountCmd help.
 	"getblockcount--synopsis": "Returns the number of blocks in the longest block chain.",
@@ -290,7 +328,7 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
-	"getblockheaderverboseresult-chainlock":         "A bool to indicate if the block has been chainlocked",
+	"getblockheaderverboseresult-chainlock":         "Whether the block is covered by the best verified ChainLock (always false when ChainLocks are not tracked since no BLS verifier is available)",
 
 	// TemplateRequest help.
 	"templaterequest-mode":         "This is 'template', 'proposal', or omitted",
@@ -494,6 +532,7 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-banscore":       "The ban score",
 	"getpeerinforesult-feefilter":      "The requested minimum fee a transaction must have to be announced to the peer",
 	"getpeerinforesult-syncnode":       "Whether or not the peer is the sync peer",
+	"getpeerinforesult-permissions":    "The permissions granted to the peer by the whitelist option, such as noban, relay, forcerelay and mempool",
 
 	// GetPeerInfoCmd help.
 	"getpeerinfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
@@ -524,6 +563,20 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetSpecialTxesCmd help.
+	"getspecialtxes--synopsis":   "Returns the special transactions of a block, or of a range of blocks starting at it when the special transaction index is enabled, optionally filtered by type.",
+	"getspecialtxes-blockhash":   "The hash of the first block to return special transactions from",
+	"getspecialtxes-type":        "The DIP0002 type of the special transactions to return or -1 for all types",
+	"getspecialtxes-count":       "The maximum number of transactions to return",
+	"getspecialtxes-skip":        "The number of transactions to skip",
+	"getspecialtxes-verbosity":   "0 returns the transaction hashes, 1 returns the hex-encoded transactions and 2 returns the transactions as JSON objects",
+	"getspecialtxes-numblocks":   "The number of blocks to return special transactions from, which requires the special transaction index when more than one (--specialtxindex)",
+	"getspecialtxes--condition0": "verbosity=0",
+	"getspecialtxes--condition1": "verbosity=1",
+	"getspecialtxes--condition2": "verbosity=2",
+	"getspecialtxes--result0":    "Array of transaction hashes",
+	"getspecialtxes--result1":    "Array of hex-encoded bytes of the serialized transactions",
+
 	// GetTxOutResult help.
 	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
 	"gettxoutresult-confirmations": "The number of confirmations",
@@ -704,6 +757,12 @@ var helpDescsEnUS = map[string]string{
 	"version--result0--key":   "Program or API name",
 	"version--result0--value": "Object containing the semantic version",
 
+	// DiscoverCmd help.
+	"rpc.discover--synopsis":       "Returns an OpenRPC document describing the methods supported by the server",
+	"rpc.discover--result0--desc":  "OpenRPC document (https://spec.open-rpc.org)",
+	"rpc.discover--result0--key":   "Document field",
+	"rpc.discover--result0--value": "Field value",
+
 	// VersionResult help.
 	"versionresult-versionstring": "The JSON-RPC API version (semver)",
 	"versionresult-major":         "The major component of the JSON-RPC API version",
@@ -720,6 +779,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"addnode":                nil,
 	"createrawtransaction":   {(*string)(nil)},
 	"debuglevel":             {(*string)(nil), (*string)(nil)},
+	"dumppeerstate":          {(*[]btcjson.PeerStateResult)(nil)},
 	"decoderawtransaction":   {(*btcjson.TxRawDecodeResult)(nil)},
 	"decodescript":           {(*btcjson.DecodeScriptResult)(nil)},
 	"estimatefee":            {(*float64)(nil)},
@@ -741,6 +801,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"getgenerate":            {(*bool)(nil)},
 	"gethashespersec":        {(*float64)(nil)},
 	"getheaders":             {(*[]string)(nil)},
+	"getprofile":             {(*btcjson.GetProfileResult)(nil)},
 	"getinfo":                {(*btcjson.InfoChainResult)(nil)},
 	"getmempoolinfo":         {(*btcjson.GetMempoolInfoResult)(nil)},
 	"getmininginfo":          {(*btcjson.GetMiningInfoResult)(nil)},
@@ -750,6 +811,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"getpeerinfo":            {(*[]btcjson.GetPeerInfoResult)(nil)},
 	"getrawmempool":          {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":      {(*string)(nil), (*btcjson.TxRawResult)(nil)},
+	"getspecialtxes":         {(*[]string)(nil), (*[]string)(nil), (*[]btcjson.TxRawResult)(nil)},
 	"gettxout":               {(*btcjson.GetTxOutResult)(nil)},
 	"node":                   nil,
 	"help":                   {(*string)(nil), (*string)(nil)},
@@ -765,6 +827,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"verifychain":            {(*bool)(nil)},
 	"verifymessage":          {(*bool)(nil)},
 	"version":                {(*map[string]btcjson.VersionResult)(nil)},
+	"rpc.discover":           {(*map[string]interface{})(nil)},
 
 	// Websocket commands.
 	"loadtxfilter":              nil,
@@ -787,6 +850,7 @@ type helpCacher struct {
 	sync.Mutex
 	usage      string
 	methodHelp map[string]string
+	openRPC    *btcjson.OpenRPCDocument
 }
 
 // rpcMethodHelp returns an RPC help string for the provided method.
@@ -855,6 +919,42 @@ func (c *helpCacher) rpcUsage(includeWebsockets bool) (string, error) {
 	return c.usage, nil
 }
 
+// rpcDiscover returns an OpenRPC document describing all of the RPC commands
+// supported over HTTP.
+//
+// This function is safe for concurrent access.
+func (c *helpCacher) rpcDiscover() (*btcjson.OpenRPCDocument, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	// Return the cached document if it is available.
+	if c.openRPC != nil {
+		return c.openRPC, nil
+	}
+
+	methodResultTypes := make(map[string][]interface{}, len(rpcHandlers))
+	for k := range rpcHandlers {
+		resultTypes, ok := rpcResultTypes[k]
+		if !ok {
+			return nil, errors.New("no result types specified for " +
+				"method " + k)
+		}
+		methodResultTypes[k] = resultTypes
+	}
+
+	info := btcjson.OpenRPCInfo{
+		Title:   "dashd-go JSON-RPC API",
+		Version: jsonrpcSemverString,
+	}
+	doc, err := btcjson.GenerateOpenRPC(info, helpDescsEnUS,
+		methodResultTypes)
+	if err != nil {
+		return nil, err
+	}
+	c.openRPC = doc
+	return doc, nil
+}
+
 // newHelpCacher returns a new instance of a help cacher which provides help and
 // usage for the RPC server commands and caches the results for future calls.
 func newHelpCacher() *helpCacher {
@@ -4,7 +4,10 @@
 
 package main
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 // TestHelp ensures the help is reasonably accurate by checking that every
 // command specified also has result types defined and the one-line usage and
@@ -63,3 +66,35 @@ func TestHelp(t *testing.T) {
 		}
 	}
 }
+
+// TestDiscover ensures an OpenRPC document describing every HTTP command can be
+// generated and encoded.
+func TestDiscover(t *testing.T) {
+	helpCacher := newHelpCacher()
+	doc, err := helpCacher.rpcDiscover()
+	if err != nil {
+		t.Fatalf("Failed to generate OpenRPC document: %v", err)
+	}
+	if len(doc.Methods) != len(rpcHandlers) {
+		t.Fatalf("OpenRPC document describes %d methods, want %d",
+			len(doc.Methods), len(rpcHandlers))
+	}
+	for _, m := range doc.Methods {
+		if _, ok := rpcHandlers[m.Name]; !ok {
+			t.Errorf("OpenRPC document describes unknown method %q",
+				m.Name)
+		}
+		if m.Summary == "" {
+			t.Errorf("OpenRPC document is missing the summary of %q",
+				m.Name)
+		}
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("Failed to encode OpenRPC document: %v", err)
+	}
+
+	cached, err := helpCacher.rpcDiscover()
+	if err != nil || cached != doc {
+		t.Fatalf("Failed to return cached OpenRPC document: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/binary"
@@ -14,6 +15,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"net/http"
+	"os"
 	"runtime"
 	"sort"
 	"strconv"
@@ -31,9 +34,11 @@ import (
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 	"github.com/dashpay/dashd-go/connmgr"
 	"github.com/dashpay/dashd-go/database"
+	"github.com/dashpay/dashd-go/llmq"
 	"github.com/dashpay/dashd-go/mempool"
 	"github.com/dashpay/dashd-go/mining"
 	"github.com/dashpay/dashd-go/mining/cpuminer"
+	"github.com/dashpay/dashd-go/mnlist"
 	"github.com/dashpay/dashd-go/netsync"
 	"github.com/dashpay/dashd-go/peer"
 	"github.com/dashpay/dashd-go/txscript"
@@ -57,6 +62,10 @@ const (
 	// retries when connecting to persistent peers.  It is adjusted by the
 	// number of retries such that there is a retry backoff.
 	connectionRetryInterval = time.Second * 5
+
+	// headersBootstrapTimeout is the maximum amount of time to spend on
+	// bootstrapping the header chain from header bundles on startup.
+	headersBootstrapTimeout = time.Minute * 2
 )
 
 var (
@@ -207,6 +216,7 @@ type server struct {
 
 	chainParams          *chaincfg.Params
 	addrManager          *addrmgr.AddrManager
+	asMap                *addrmgr.ASMap
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	hashCache            *txscript.HashCache
@@ -234,14 +244,21 @@ type server struct {
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
-	txIndex   *indexers.TxIndex
-	addrIndex *indexers.AddrIndex
-	cfIndex   *indexers.CfIndex
+	txIndex        *indexers.TxIndex
+	addrIndex      *indexers.AddrIndex
+	cfIndex        *indexers.CfIndex
+	specialTxIndex *indexers.SpecialTxIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
 	feeEstimator *mempool.FeeEstimator
 
+	// lockTracker keeps track of the ChainLocks and InstantSend locks
+	// relayed by peers once they have been verified against the quorums of
+	// the masternode list in quorums.
+	lockTracker *llmq.LockTracker
+	quorums     *quorumList
+
 	// cfCheckptCaches stores a cached slice of filter headers for cfcheckpt
 	// messages for each filter type.
 	cfCheckptCaches    map[wire.FilterType][]cfHeaderKV
@@ -271,7 +288,6 @@ type serverPeer struct {
 	relayMtx       sync.Mutex
 	disableRelayTx bool
 	sentAddrs      bool
-	isWhitelisted  bool
 	filter         *bloom.Filter
 	addressesMtx   sync.RWMutex
 	knownAddresses map[string]struct{}
@@ -280,6 +296,14 @@ type serverPeer struct {
 	// The following chans are used to sync blockmanager and server.
 	txProcessed    chan struct{}
 	blockProcessed chan struct{}
+	// pendingCLSig and pendingISLocks are the ChainLock and InstantSend
+	// locks which await the masternode list diff leading to the block at
+	// quorumsHeight requested from the peer while quorumsRequested is set.
+	// They are only accessed by the peer's input handler.
+	pendingCLSig     *wire.MsgCLSig
+	pendingISLocks   []*wire.MsgISLock
+	quorumsRequested bool
+	quorumsHeight    int32
 }
 
 // newServerPeer returns a new serverPeer instance. The peer needs to be set by
@@ -313,6 +337,16 @@ func (sp *serverPeer) addKnownAddresses(addresses []*wire.NetAddress) {
 	sp.addressesMtx.Unlock()
 }
 
+// addKnownAddressesV2 adds the given addrv2 addresses to the set of known
+// addresses to the peer to prevent sending duplicate addresses.
+func (sp *serverPeer) addKnownAddressesV2(addresses []*wire.NetAddressV2) {
+	sp.addressesMtx.Lock()
+	for _, na := range addresses {
+		sp.knownAddresses[addrmgr.NetAddressV2Key(na)] = struct{}{}
+	}
+	sp.addressesMtx.Unlock()
+}
+
 // addressKnown true if the given address is already known to the peer.
 func (sp *serverPeer) addressKnown(na *wire.NetAddress) bool {
 	sp.addressesMtx.RLock()
@@ -321,6 +355,14 @@ func (sp *serverPeer) addressKnown(na *wire.NetAddress) bool {
 	return exists
 }
 
+// addressKnownV2 true if the given addrv2 address is already known to the peer.
+func (sp *serverPeer) addressKnownV2(na *wire.NetAddressV2) bool {
+	sp.addressesMtx.RLock()
+	_, exists := sp.knownAddresses[addrmgr.NetAddressV2Key(na)]
+	sp.addressesMtx.RUnlock()
+	return exists
+}
+
 // setDisableRelayTx toggles relaying of transactions for the given peer.
 // It is safe for concurrent access.
 func (sp *serverPeer) setDisableRelayTx(disable bool) {
@@ -343,6 +385,17 @@ func (sp *serverPeer) relayTxDisabled() bool {
 // pushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.
 func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
+	// Peers which signaled support for addrv2 messages are sent those
+	// instead of addr messages.
+	if sp.WantsAddrV2() {
+		addrsV2 := make([]*wire.NetAddressV2, 0, len(addresses))
+		for _, addr := range addresses {
+			addrsV2 = append(addrsV2, wire.NewNetAddressV2FromLegacy(addr))
+		}
+		sp.pushAddrV2Msg(addrsV2)
+		return
+	}
+
 	// Filter addresses already known to the peer.
 	addrs := make([]*wire.NetAddress, 0, len(addresses))
 	for _, addr := range addresses {
@@ -350,6 +403,7 @@ func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
 			addrs = append(addrs, addr)
 		}
 	}
+
 	known, err := sp.PushAddrMsg(addrs)
 	if err != nil {
 		peerLog.Errorf("Can't push address message to %s: %v", sp.Peer, err)
@@ -359,6 +413,26 @@ func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
 	sp.addKnownAddresses(known)
 }
 
+// pushAddrV2Msg sends an addrv2 message to the connected peer using the
+// provided addresses.  The peer must have signaled support for addrv2 messages.
+func (sp *serverPeer) pushAddrV2Msg(addresses []*wire.NetAddressV2) {
+	// Filter addresses already known to the peer.
+	addrs := make([]*wire.NetAddressV2, 0, len(addresses))
+	for _, addr := range addresses {
+		if !sp.addressKnownV2(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	known, err := sp.PushAddrV2Msg(addrs)
+	if err != nil {
+		peerLog.Errorf("Can't push address message to %s: %v", sp.Peer, err)
+		sp.Disconnect()
+		return
+	}
+	sp.addKnownAddressesV2(known)
+}
+
 // addBanScore increases the persistent and decaying ban score fields by the
 // values passed as parameters. If the resulting score exceeds half of the ban
 // threshold, a warning is logged including the reason provided. Further, if
@@ -369,7 +443,7 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) b
 	if cfg.DisableBanning {
 		return false
 	}
-	if sp.isWhitelisted {
+	if sp.HasPermission(peer.PermissionNoBan) {
 		peerLog.Debugf("Misbehaving whitelisted peer %s: %s", sp, reason)
 		return false
 	}
@@ -488,8 +562,10 @@ func (sp *serverPeer) OnVerAck(_ *peer.Peer, _ *wire.MsgVerAck) {
 // bloom filter loaded, the contents are filtered accordingly.
 func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 	// Only allow mempool requests if the server has bloom filtering
-	// enabled.
-	if sp.server.services&wire.SFNodeBloom != wire.SFNodeBloom {
+	// enabled or the peer was granted the mempool permission.
+	if sp.server.services&wire.SFNodeBloom != wire.SFNodeBloom &&
+		!sp.HasPermission(peer.PermissionMempool) {
+
 		peerLog.Debugf("peer %v sent mempool request with bloom "+
 			"filtering disabled -- disconnecting", sp)
 		sp.Disconnect()
@@ -537,7 +613,7 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 // handler this does not serialize all transactions through a single thread
 // transactions don't rely on the previous one in a linear fashion like blocks.
 func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
-	if cfg.BlocksOnly {
+	if cfg.BlocksOnly && !sp.HasPermission(peer.PermissionRelay) {
 		peerLog.Tracef("Ignoring tx %v from %v - blocksonly enabled",
 			msg.TxHash(), sp)
 		return
@@ -590,7 +666,9 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 // accordingly.  We pass the message down to blockmanager which will call
 // QueueMessage with any appropriate responses.
 func (sp *serverPeer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
-	if !cfg.BlocksOnly {
+	sp.requestLocks(msg)
+
+	if !cfg.BlocksOnly || sp.HasPermission(peer.PermissionRelay) {
 		if len(msg.InvList) > 0 {
 			sp.server.syncManager.QueueInv(msg, sp.Peer)
 		}
@@ -1241,11 +1319,14 @@ func (sp *serverPeer) OnGetAddr(_ *peer.Peer, msg *wire.MsgGetAddr) {
 	}
 	sp.sentAddrs = true
 
-	// Get the current known addresses from the address manager.
-	addrCache := sp.server.addrManager.AddressCache()
-
-	// Push the addresses.
-	sp.pushAddrMsg(addrCache)
+	// Get the current known addresses from the address manager and push
+	// them.  Peers which signaled support for addrv2 messages are also sent
+	// the addresses which can't be relayed with addr messages.
+	if sp.WantsAddrV2() {
+		sp.pushAddrV2Msg(sp.server.addrManager.AddressCacheV2())
+		return
+	}
+	sp.pushAddrMsg(sp.server.addrManager.AddressCache())
 }
 
 // OnAddr is invoked when a peer receives an addr bitcoin message and is
@@ -1298,6 +1379,50 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 	sp.server.addrManager.AddAddresses(msg.AddrList, sp.NA())
 }
 
+// OnAddrV2 is invoked when a peer receives an addrv2 bitcoin message and is
+// used to notify the server about advertised addresses.  Unlike addr messages,
+// it may include the addresses of networks such as Tor v3 onion services, which
+// are stored by the address manager as well.
+func (sp *serverPeer) OnAddrV2(_ *peer.Peer, msg *wire.MsgAddrV2) {
+	// Ignore addresses when running on the simulation test network.  This
+	// helps prevent the network from becoming another public test network
+	// since it will not be able to learn about other peers that have not
+	// specifically been provided.
+	if cfg.SimNet {
+		return
+	}
+
+	// A message that has no addresses is invalid.
+	if len(msg.AddrList) == 0 {
+		peerLog.Errorf("Command [%s] from %s does not contain any addresses",
+			msg.Command(), sp.Peer)
+		sp.Disconnect()
+		return
+	}
+
+	for _, na := range msg.AddrList {
+		// Don't add more address if we're disconnecting.
+		if !sp.Connected() {
+			return
+		}
+
+		// Set the timestamp to 5 days ago if it's more than 24 hours
+		// in the future so this address is one of the first to be
+		// removed when space is needed.
+		now := time.Now()
+		if na.Timestamp.After(now.Add(time.Minute * 10)) {
+			na.Timestamp = now.Add(-1 * time.Hour * 24 * 5)
+		}
+
+		// Add address to known addresses for this peer.
+		sp.addKnownAddressesV2([]*wire.NetAddressV2{na})
+	}
+
+	// Add addresses to server address manager.  The address manager
+	// ignores addresses of unknown networks.
+	sp.server.addrManager.AddAddressesV2(msg.AddrList, sp.NA())
+}
+
 // OnRead is invoked when a peer receives a message and it is used to update
 // the bytes received by the server.
 func (sp *serverPeer) OnRead(_ *peer.Peer, bytesRead int, msg wire.Message, err error) {
@@ -1640,7 +1765,7 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		sp.Disconnect()
 		return false
 	}
-	if banEnd, ok := state.banned[host]; ok {
+	if banEnd, ok := state.banned[host]; ok && !sp.HasPermission(peer.PermissionNoBan) {
 		if time.Now().Before(banEnd) {
 			srvrLog.Debugf("Peer %s is banned for another %v - disconnecting",
 				host, time.Until(banEnd))
@@ -1669,7 +1794,7 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	if sp.Inbound() {
 		state.inboundPeers[sp.ID()] = sp
 	} else {
-		state.outboundGroups[addrmgr.GroupKey(sp.NA())]++
+		state.outboundGroups[s.asMap.GroupKey(sp.NA())]++
 		if sp.persistent {
 			state.persistentPeers[sp.ID()] = sp
 		} else {
@@ -1746,7 +1871,7 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 
 	if _, ok := list[sp.ID()]; ok {
 		if !sp.Inbound() && sp.VersionKnown() {
-			state.outboundGroups[addrmgr.GroupKey(sp.NA())]--
+			state.outboundGroups[s.asMap.GroupKey(sp.NA())]--
 		}
 		delete(list, sp.ID())
 		srvrLog.Debugf("Removed peer %s", sp)
@@ -1942,7 +2067,7 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 		found := disconnectPeer(state.persistentPeers, msg.cmp, func(sp *serverPeer) {
 			// Keep group counts ok since we remove from
 			// the list now.
-			state.outboundGroups[addrmgr.GroupKey(sp.NA())]--
+			state.outboundGroups[s.asMap.GroupKey(sp.NA())]--
 		})
 
 		if found {
@@ -1978,7 +2103,7 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 		found = disconnectPeer(state.outboundPeers, msg.cmp, func(sp *serverPeer) {
 			// Keep group counts ok since we remove from
 			// the list now.
-			state.outboundGroups[addrmgr.GroupKey(sp.NA())]--
+			state.outboundGroups[s.asMap.GroupKey(sp.NA())]--
 		})
 		if found {
 			// If there are multiple outbound connections to the same
@@ -1986,7 +2111,7 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 			// peers are found.
 			for found {
 				found = disconnectPeer(state.outboundPeers, msg.cmp, func(sp *serverPeer) {
-					state.outboundGroups[addrmgr.GroupKey(sp.NA())]--
+					state.outboundGroups[s.asMap.GroupKey(sp.NA())]--
 				})
 			}
 			msg.reply <- nil
@@ -2021,8 +2146,10 @@ func disconnectPeer(peerList map[int32]*serverPeer, compareFunc func(*serverPeer
 	return false
 }
 
-// newPeerConfig returns the configuration for the given serverPeer.
-func newPeerConfig(sp *serverPeer) *peer.Config {
+// newPeerConfig returns the configuration for the given serverPeer connected to
+// the given remote address.
+func newPeerConfig(sp *serverPeer, remoteAddr net.Addr) *peer.Config {
+	permissions := peerPermissions(remoteAddr)
 	return &peer.Config{
 		Listeners: peer.MessageListeners{
 			OnVersion:      sp.OnVersion,
@@ -2044,9 +2171,13 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnFilterLoad:   sp.OnFilterLoad,
 			OnGetAddr:      sp.OnGetAddr,
 			OnAddr:         sp.OnAddr,
+			OnAddrV2:       sp.OnAddrV2,
 			OnRead:         sp.OnRead,
 			OnWrite:        sp.OnWrite,
 			OnNotFound:     sp.OnNotFound,
+			OnMnListDiff:   sp.OnMnListDiff,
+			OnCLSig:        sp.OnCLSig,
+			OnISLock:       sp.OnISLock,
 
 			// Note: The reference client currently bans peers that send alerts
 			// not signed with its key.  We could verify against their key, but
@@ -2062,7 +2193,8 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		UserAgentComments:   cfg.UserAgentComments,
 		ChainParams:         sp.server.chainParams,
 		Services:            sp.server.services,
-		DisableRelayTx:      cfg.BlocksOnly,
+		DisableRelayTx:      cfg.BlocksOnly && permissions&peer.PermissionRelay == 0,
+		Permissions:         permissions,
 		ProtocolVersion:     peer.MaxProtocolVersion,
 		TrickleInterval:     cfg.TrickleInterval,
 		DisableStallHandler: cfg.DisableStallHandler,
@@ -2075,8 +2207,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 // for disconnection.
 func (s *server) inboundPeerConnected(conn net.Conn) {
 	sp := newServerPeer(s, false)
-	sp.isWhitelisted = isWhitelisted(conn.RemoteAddr())
-	sp.Peer = peer.NewInboundPeer(newPeerConfig(sp))
+	sp.Peer = peer.NewInboundPeer(newPeerConfig(sp, conn.RemoteAddr()))
 	sp.AssociateConnection(conn)
 	go s.peerDoneHandler(sp)
 }
@@ -2088,20 +2219,22 @@ func (s *server) inboundPeerConnected(conn net.Conn) {
 // manager of the attempt.
 func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, c.Permanent)
-	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
+	peerCfg := newPeerConfig(sp, conn.RemoteAddr())
+	peerCfg.BlocksOnly = cfg.BlocksOnlyOutbound ||
+		c.Group == connmgr.GroupBlocksOnly
+	p, err := peer.NewOutboundPeer(peerCfg, c.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %v", c.Addr, err)
 		if c.Permanent {
 			s.connManager.Disconnect(c.ID())
 		} else {
 			s.connManager.Remove(c.ID())
-			go s.connManager.NewConnReq()
+			go s.connManager.NewGroupConnReq(c.Group)
 		}
 		return
 	}
 	sp.Peer = p
 	sp.connReq = c
-	sp.isWhitelisted = isWhitelisted(conn.RemoteAddr())
 	sp.AssociateConnection(conn)
 	go s.peerDoneHandler(sp)
 }
@@ -2303,6 +2436,9 @@ func (s *server) UpdatePeerHeights(latestBlkHash *chainhash.Hash, latestHeight i
 // rebroadcastHandler keeps track of user submitted inventories that we have
 // sent out but have not yet made it into a block. We periodically rebroadcast
 // them in case our peers restarted or otherwise lost track of them.
+// Transactions which are no longer in the memory pool without having been
+// mined, such as those that expired or were double spent, are considered
+// abandoned and are no longer rebroadcast.
 func (s *server) rebroadcastHandler() {
 	// Wait 5 min before first tx rebroadcast.
 	timer := time.NewTimer(5 * time.Minute)
@@ -2327,6 +2463,16 @@ out:
 			// Any inventory we have has not made it into a block
 			// yet. We periodically resubmit them until they have.
 			for iv, data := range pendingInvs {
+				if iv.Type == wire.InvTypeTx &&
+					!s.txMemPool.IsTransactionInPool(&iv.Hash) {
+
+					srvrLog.Debugf("Abandoning rebroadcast of "+
+						"transaction %v which is no longer "+
+						"in the memory pool", iv.Hash)
+					delete(pendingInvs, iv)
+					continue
+				}
+
 				ivCopy := iv
 				s.RelayInventory(&ivCopy, data)
 			}
@@ -2368,10 +2514,11 @@ func (s *server) Start() {
 	// Server startup time. Used for the uptime command for uptime calculation.
 	s.startupTime = time.Now().Unix()
 
-	// Start the peer handler which in turn starts the address and block
-	// managers.
+	// The network is only started once the chain state is reindexed, when
+	// that is being done in the background, so the RPC server is available
+	// in the meantime.
 	s.wg.Add(1)
-	go s.peerHandler()
+	go s.startNetwork()
 
 	if s.nat != nil {
 		s.wg.Add(1)
@@ -2388,6 +2535,39 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+}
+
+// startNetwork waits for the reindex of the chain state to complete, if one is
+// being performed, and then starts the peer handler along with the header chain
+// bootstrap and the CPU miner.  A shutdown is requested when the reindex fails.
+//
+// It MUST be run as a goroutine.
+func (s *server) startNetwork() {
+	defer s.wg.Done()
+
+	select {
+	case <-s.chain.ReindexDone():
+	case <-s.quit:
+		return
+	}
+	if err := s.chain.ReindexErr(); err != nil {
+		shutdownRequestChannel <- struct{}{}
+		return
+	}
+
+	// Start the peer handler which in turn starts the address and block
+	// managers.
+	s.wg.Add(1)
+	go s.peerHandler()
+
+	// Bootstrap the header chain from the configured header bundles in the
+	// background.  Failing to do so is not fatal since the headers are
+	// downloaded from peers as usual then.
+	if len(cfg.HeadersBootstrapURLs) > 0 {
+		s.wg.Add(1)
+		go s.bootstrapHeaders()
+	}
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
@@ -2618,11 +2798,57 @@ func setupRPCListeners() ([]net.Listener, error) {
 	return listeners, nil
 }
 
+// bootstrapHeaders bootstraps the header chain from the header bundles at the
+// configured URLs.  The bundles are downloaded through the configured proxy,
+// if any.  Peers are connected concurrently, so the headers are downloaded
+// from them as usual until the bootstrapped headers are available.
+//
+// It MUST be run as a goroutine.
+func (s *server) bootstrapHeaders() {
+	defer s.wg.Done()
+
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return cfg.dial(network, addr, defaultConnectTimeout)
+	}
+	source := &blockchain.HTTPSHeaderSource{
+		URLs:   cfg.HeadersBootstrapURLs,
+		PubKey: cfg.headersBootstrapKey,
+		Net:    s.chainParams.Net,
+		Client: &http.Client{
+			Transport: &http.Transport{DialContext: dial},
+		},
+		MaxHeaders: blockchain.HeaderBundleLimit(s.chainParams,
+			time.Now()),
+	}
+
+	// Abort the download when the server is shutting down.
+	ctx, cancel := context.WithTimeout(context.Background(),
+		headersBootstrapTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	n, err := s.chain.BootstrapHeaders(ctx, source)
+	if err != nil {
+		srvrLog.Warnf("Unable to bootstrap block headers: %v", err)
+		return
+	}
+	if n == 0 {
+		srvrLog.Infof("Bootstrapped block headers are not ahead of " +
+			"the best chain")
+	}
+}
+
 // newServer returns a new btcd server configured to listen on addr for the
 // bitcoin network type specified by chainParams.  Use start to begin accepting
 // connections from peers.
 func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
-	db database.DB, chainParams *chaincfg.Params,
+	db database.DB, mnList *mnlist.State, chainParams *chaincfg.Params,
 	interrupt <-chan struct{}) (*server, error) {
 
 	services := defaultServices
@@ -2635,6 +2861,24 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 
 	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
 
+	// Load the optional AS map used to group outbound peers by the network
+	// operator announcing their addresses.
+	var asMap *addrmgr.ASMap
+	if cfg.ASMap != "" {
+		f, err := os.Open(cfg.ASMap)
+		if err != nil {
+			return nil, err
+		}
+		asMap, err = addrmgr.LoadASMap(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load AS map %s: %v",
+				cfg.ASMap, err)
+		}
+		srvrLog.Infof("Loaded AS map with %d prefixes from %s",
+			asMap.Len(), cfg.ASMap)
+	}
+
 	var listeners []net.Listener
 	var nat NAT
 	if !cfg.DisableListen {
@@ -2658,6 +2902,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	s := server{
 		chainParams:          chainParams,
 		addrManager:          amgr,
+		asMap:                asMap,
 		newPeers:             make(chan *serverPeer, cfg.MaxPeers),
 		donePeers:            make(chan *serverPeer, cfg.MaxPeers),
 		banPeers:             make(chan *serverPeer, cfg.MaxPeers),
@@ -2709,6 +2954,11 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
 		indexes = append(indexes, s.cfIndex)
 	}
+	if cfg.SpecialTxIndex {
+		indxLog.Info("Special transaction index is enabled")
+		s.specialTxIndex = indexers.NewSpecialTxIndex(db)
+		indexes = append(indexes, s.specialTxIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
@@ -2724,19 +2974,42 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
-	s.chain, err = blockchain.New(&blockchain.Config{
-		DB:           s.db,
-		Interrupt:    interrupt,
-		ChainParams:  s.chainParams,
-		Checkpoints:  checkpoints,
-		TimeSource:   s.timeSource,
-		SigCache:     s.sigCache,
-		IndexManager: indexManager,
-		HashCache:    s.hashCache,
+	chainCfg := blockchain.Config{
+		DB:            s.db,
+		Interrupt:     interrupt,
+		ChainParams:   s.chainParams,
+		Checkpoints:   checkpoints,
+		TimeSource:    s.timeSource,
+		SigCache:      s.sigCache,
+		IndexManager:  indexManager,
+		HashCache:     s.hashCache,
+		Reindex:       cfg.Reindex,
+		MaxReorgDepth: cfg.MaxReorgDepth,
+
+		// The masternode list is committed along with the chain state
+		// so the two never end up at different blocks.
+		StateCommitters: []blockchain.StateCommitter{mnList},
+	}
+	if s.txIndex != nil {
+		chainCfg.TxLocator = s.txIndex
+	}
+
+	// Track the ChainLocks and InstantSend locks relayed by peers so the
+	// chain and the mempool take them into account.
+	s.quorums = newQuorumList()
+	s.lockTracker = llmq.NewLockTracker(&llmq.Verifier{
+		Quorums:         s.quorums,
+		BLS:             llmq.BasicBLS{},
+		ChainLockType:   s.chainParams.ChainLocksLLMQType,
+		InstantSendType: s.chainParams.InstantSendLLMQType,
 	})
+	chainCfg.LockStatus = s.lockTracker
+	s.chain, err = blockchain.New(&chainCfg)
 	if err != nil {
 		return nil, err
 	}
+	s.quorums.chain = s.chain
+	s.chain.Subscribe(s.handleLockNotification)
 
 	// Search for a FeeEstimator state in the database. If none can be found
 	// or if it cannot be loaded, create a new one.
@@ -2768,6 +3041,14 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			mempool.DefaultEstimateFeeMinRegisteredBlocks)
 	}
 
+	// A data carrier size of zero only allows null data outputs without any
+	// data, which the mempool policy expresses with a negative size since
+	// its zero value selects the default.
+	maxDataCarrierSize := cfg.DataCarrierSize
+	if maxDataCarrierSize == 0 {
+		maxDataCarrierSize = -1
+	}
+
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			DisableRelayPriority: cfg.NoRelayPriority,
@@ -2775,10 +3056,12 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			FreeTxRelayLimit:     cfg.FreeTxRelayLimit,
 			MaxOrphanTxs:         cfg.MaxOrphanTxs,
 			MaxOrphanTxSize:      defaultMaxOrphanTxSize,
+			MaxDataCarrierSize:   maxDataCarrierSize,
 			MaxSigOpCostPerTx:    blockchain.MaxBlockSigOpsCost / 4,
 			MinRelayTxFee:        cfg.minRelayTxFee,
 			MaxTxVersion:         2,
 			RejectReplacement:    cfg.RejectReplacement,
+			ExpiryTimeout:        cfg.MempoolExpiry,
 		},
 		ChainParams:    chainParams,
 		FetchUtxoView:  s.chain.FetchUtxoView,
@@ -2787,14 +3070,21 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		CalcSequenceLock: func(tx *btcutil.Tx, view *blockchain.UtxoViewpoint) (*blockchain.SequenceLock, error) {
 			return s.chain.CalcSequenceLock(tx, view, true)
 		},
-		IsDeploymentActive: s.chain.IsDeploymentActive,
-		SigCache:           s.sigCache,
-		HashCache:          s.hashCache,
-		AddrIndex:          s.addrIndex,
-		FeeEstimator:       s.feeEstimator,
+		IsDeploymentActive:  s.chain.IsDeploymentActive,
+		SigCache:            s.sigCache,
+		HashCache:           s.hashCache,
+		AddrIndex:           s.addrIndex,
+		FeeEstimator:        s.feeEstimator,
+		IsInstantSendLocked: s.lockTracker.IsInstantSendLocked,
 	}
 	s.txMemPool = mempool.New(&txC)
 
+	// Transactions from remote peers are not accepted in blocks-only mode,
+	// so there is no point in requesting their mempools.
+	mempoolSyncPeers := cfg.MempoolSyncPeers
+	if cfg.BlocksOnly {
+		mempoolSyncPeers = 0
+	}
 	s.syncManager, err = netsync.New(&netsync.Config{
 		PeerNotifier:       &s,
 		Chain:              s.chain,
@@ -2803,6 +3093,8 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		DisableCheckpoints: cfg.DisableCheckpoints,
 		MaxPeers:           cfg.MaxPeers,
 		FeeEstimator:       s.feeEstimator,
+		MempoolSyncPeers:   mempoolSyncPeers,
+		MinRelayTxFee:      cfg.minRelayTxFee,
 	})
 	if err != nil {
 		return nil, err
@@ -2848,13 +3140,20 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 					break
 				}
 
+				// Addresses of networks which can only be relayed
+				// with addrv2 messages are not connected to.
+				na := addr.NetAddress()
+				if na == nil {
+					continue
+				}
+
 				// Address will not be invalid, local or unroutable
 				// because addrmanager rejects those on addition.
 				// Just check that we don't already have an address
 				// in the same group so that we are not connecting
 				// to the same network segment at the expense of
 				// others.
-				key := addrmgr.GroupKey(addr.NetAddress())
+				key := s.asMap.GroupKey(na)
 				if s.OutboundGroupCount(key) != 0 {
 					continue
 				}
@@ -2866,15 +3165,15 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 				}
 
 				// allow nondefault ports after 50 failed tries.
-				if tries < 50 && fmt.Sprintf("%d", addr.NetAddress().Port) !=
+				if tries < 50 && fmt.Sprintf("%d", na.Port) !=
 					activeNetParams.DefaultPort {
 					continue
 				}
 
 				// Mark an attempt for the valid address.
-				s.addrManager.Attempt(addr.NetAddress())
+				s.addrManager.Attempt(na)
 
-				addrString := addrmgr.NetAddressKey(addr.NetAddress())
+				addrString := addrmgr.NetAddressKey(na)
 				return addrStringToNetAddr(addrString)
 			}
 
@@ -2892,9 +3191,12 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		OnAccept:       s.inboundPeerConnected,
 		RetryDuration:  connectionRetryInterval,
 		TargetOutbound: uint32(targetOutbound),
-		Dial:           btcdDial,
-		OnConnection:   s.outboundPeerConnected,
-		GetNewAddress:  newAddressFunc,
+		TargetGroupOutbound: map[connmgr.ConnGroup]uint32{
+			connmgr.GroupBlocksOnly: uint32(cfg.BlocksOnlyConns),
+		},
+		Dial:          btcdDial,
+		OnConnection:  s.outboundPeerConnected,
+		GetNewAddress: newAddressFunc,
 	})
 	if err != nil {
 		return nil, err
@@ -2930,21 +3232,22 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		}
 
 		s.rpcServer, err = newRPCServer(&rpcserverConfig{
-			Listeners:    rpcListeners,
-			StartupTime:  s.startupTime,
-			ConnMgr:      &rpcConnManager{&s},
-			SyncMgr:      &rpcSyncMgr{&s, s.syncManager},
-			TimeSource:   s.timeSource,
-			Chain:        s.chain,
-			ChainParams:  chainParams,
-			DB:           db,
-			TxMemPool:    s.txMemPool,
-			Generator:    blockTemplateGenerator,
-			CPUMiner:     s.cpuMiner,
-			TxIndex:      s.txIndex,
-			AddrIndex:    s.addrIndex,
-			CfIndex:      s.cfIndex,
-			FeeEstimator: s.feeEstimator,
+			Listeners:      rpcListeners,
+			StartupTime:    s.startupTime,
+			ConnMgr:        &rpcConnManager{&s},
+			SyncMgr:        &rpcSyncMgr{&s, s.syncManager},
+			TimeSource:     s.timeSource,
+			Chain:          s.chain,
+			ChainParams:    chainParams,
+			DB:             db,
+			TxMemPool:      s.txMemPool,
+			Generator:      blockTemplateGenerator,
+			CPUMiner:       s.cpuMiner,
+			TxIndex:        s.txIndex,
+			AddrIndex:      s.addrIndex,
+			CfIndex:        s.cfIndex,
+			SpecialTxIndex: s.specialTxIndex,
+			FeeEstimator:   s.feeEstimator,
 		})
 		if err != nil {
 			return nil, err
@@ -2964,7 +3267,8 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 // addresses to the address manager. Returns the listeners and a NAT interface,
 // which is non-nil if UPnP is in use.
 func initListeners(amgr *addrmgr.AddrManager, listenAddrs []string, services wire.ServiceFlag) ([]net.Listener, NAT, error) {
-	// Listen for TCP connections at the configured addresses
+	// Listen for connections over the peer transport at the configured
+	// addresses
 	netAddrs, err := parseListeners(listenAddrs)
 	if err != nil {
 		return nil, nil, err
@@ -2972,7 +3276,7 @@ func initListeners(amgr *addrmgr.AddrManager, listenAddrs []string, services wir
 
 	listeners := make([]net.Listener, 0, len(netAddrs))
 	for _, addr := range netAddrs {
-		listener, err := net.Listen(addr.Network(), addr.String())
+		listener, err := cfg.listen(addr.Network(), addr.String())
 		if err != nil {
 			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
 			continue
@@ -3063,9 +3367,9 @@ func addrStringToNetAddr(addr string) (net.Addr, error) {
 
 	// Tor addresses cannot be resolved to an IP, so just return an onion
 	// address instead.
-	if strings.HasSuffix(host, ".onion") {
+	if connmgr.IsOnionHost(host) {
 		if cfg.NoOnion {
-			return nil, errors.New("tor has been disabled")
+			return nil, connmgr.ErrTorDisabled
 		}
 
 		return &onionAddr{addr: addr}, nil
@@ -3154,30 +3458,31 @@ func dynamicTickDuration(remaining time.Duration) time.Duration {
 	return time.Hour
 }
 
-// isWhitelisted returns whether the IP address is included in the whitelisted
-// networks and IPs.
-func isWhitelisted(addr net.Addr) bool {
+// peerPermissions returns the permissions granted to peers with the IP address
+// by the whitelisted networks and IPs which include it.
+func peerPermissions(addr net.Addr) peer.PermissionFlags {
 	if len(cfg.whitelists) == 0 {
-		return false
+		return 0
 	}
 
 	host, _, err := net.SplitHostPort(addr.String())
 	if err != nil {
 		srvrLog.Warnf("Unable to SplitHostPort on '%s': %v", addr, err)
-		return false
+		return 0
 	}
 	ip := net.ParseIP(host)
 	if ip == nil {
 		srvrLog.Warnf("Unable to parse IP '%s'", addr)
-		return false
+		return 0
 	}
 
-	for _, ipnet := range cfg.whitelists {
-		if ipnet.Contains(ip) {
-			return true
+	var permissions peer.PermissionFlags
+	for _, whitelist := range cfg.whitelists {
+		if whitelist.ipnet.Contains(ip) {
+			permissions |= whitelist.permissions
 		}
 	}
-	return false
+	return permissions
 }
 
 // checkpointSorter implements sort.Interface to allow a slice of checkpoints to
@@ -0,0 +1,181 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// shortFormOps holds a map of opcode names to values for use in short
+	// form parsing.  It is created on first use by ParseShortForm.
+	shortFormOps     map[string]byte
+	shortFormOpsOnce sync.Once
+
+	// onelineOps holds a map of the compact representations of the small
+	// integer opcodes produced by DisasmString to their values.
+	onelineOps = func() map[string]byte {
+		ops := make(map[string]byte, len(opcodeOnelineRepls))
+		for _, op := range opcodeArray {
+			if repl, ok := opcodeOnelineRepls[op.name]; ok {
+				ops[repl] = op.value
+			}
+		}
+		return ops
+	}()
+)
+
+// ParseShortForm parses a script in the short form used by the Bitcoin Core
+// reference tests into the script it came from.
+//
+// The format is pretty simple if ad-hoc:
+//   - Opcodes other than the push opcodes and unknown are present as
+//     either OP_NAME or just NAME
+//   - Plain numbers are made into push operations
+//   - Numbers beginning with 0x are inserted into the []byte as-is (so
+//     0x14 is OP_DATA_20)
+//   - Single quoted strings are pushed as data
+//   - Anything else is an error
+//
+// Since raw bytes are inserted as-is, the resulting script is not required to
+// parse successfully or to be within the script size limits.
+func ParseShortForm(script string) ([]byte, error) {
+	// Only create the short form opcode map once.
+	shortFormOpsOnce.Do(func() {
+		ops := make(map[string]byte)
+		for opcodeName, opcodeValue := range OpcodeByName {
+			if strings.Contains(opcodeName, "OP_UNKNOWN") {
+				continue
+			}
+			ops[opcodeName] = opcodeValue
+
+			// The opcodes named OP_# can't have the OP_ prefix
+			// stripped or they would conflict with the plain
+			// numbers.  Also, since OP_FALSE and OP_TRUE are
+			// aliases for the OP_0, and OP_1, respectively, they
+			// have the same value, so detect those by name and
+			// allow them.
+			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
+				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
+					opcodeValue > OP_16)) {
+
+				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
+			}
+		}
+		shortFormOps = ops
+	})
+
+	builder := NewScriptBuilder()
+	for _, tok := range strings.Fields(script) {
+		// if parses as a plain number
+		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			builder.AddInt64(num)
+		} else if strings.HasPrefix(tok, "0x") {
+			bts, err := hex.DecodeString(tok[2:])
+			if err != nil {
+				str := fmt.Sprintf("bad hex token %q: %v", tok, err)
+				return nil, scriptError(ErrInvalidScriptToken, str)
+			}
+
+			// Concatenate the bytes manually since scripts are
+			// allowed to be malformed or too large and would cause
+			// the builder to error otherwise.
+			if builder.err == nil {
+				builder.script = append(builder.script, bts...)
+			}
+		} else if len(tok) >= 2 &&
+			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
+			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
+		} else if opcode, ok := shortFormOps[tok]; ok {
+			builder.AddOp(opcode)
+		} else {
+			str := fmt.Sprintf("bad token %q", tok)
+			return nil, scriptError(ErrInvalidScriptToken, str)
+		}
+	}
+	return builder.Script()
+}
+
+// appendDataPush appends a push of the provided data to the script using the
+// smallest data push opcode able to represent the length of the data.  Unlike
+// the script builder, single byte data is never converted to a small integer
+// opcode since the caller explicitly asked for a data push.
+func appendDataPush(script []byte, data []byte) []byte {
+	dataLen := len(data)
+	switch {
+	case dataLen < OP_PUSHDATA1:
+		script = append(script, byte((OP_DATA_1-1)+dataLen))
+	case dataLen <= 0xff:
+		script = append(script, OP_PUSHDATA1, byte(dataLen))
+	case dataLen <= 0xffff:
+		var buf [2]byte
+		binary.LittleEndian.PutUint16(buf[:], uint16(dataLen))
+		script = append(script, OP_PUSHDATA2)
+		script = append(script, buf[:]...)
+	default:
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(dataLen))
+		script = append(script, OP_PUSHDATA4)
+		script = append(script, buf[:]...)
+	}
+	return append(script, data...)
+}
+
+// Assemble converts the one line disassembly produced by DisasmString back into
+// the script it describes.  Tokens are separated by whitespace and consist of:
+//   - The numbers -1 through 16, which represent OP_1NEGATE and OP_0 through
+//     OP_16, respectively
+//   - Opcode names as printed by DisasmString, such as OP_DUP and
+//     OP_UNKNOWN186, as well as their aliases, such as OP_CHECKLOCKTIMEVERIFY
+//   - Hex-encoded data, which is pushed with the smallest data push opcode
+//     able to represent its length
+//
+// The names of the data push opcodes themselves are rejected since the data
+// they push can't be expressed in the disassembly.  Likewise, the '[error]'
+// marker DisasmString appends to scripts that fail to parse is rejected.
+//
+// Any script containing only canonical data pushes round trips through
+// DisasmString and Assemble unchanged.  Scripts that push data with a larger
+// push opcode than necessary, or that push a single byte which happens to print
+// as a small integer, are reassembled into the canonical equivalent instead.
+//
+// NOTE: This function is only valid for version 0 scripts.  Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func Assemble(disasm string) ([]byte, error) {
+	tokens := strings.Fields(disasm)
+	script := make([]byte, 0, len(disasm)/2)
+	for _, tok := range tokens {
+		// Small integers are given priority over data since both are
+		// valid hex in the case of the numbers 10 through 16.
+		if opcode, ok := onelineOps[tok]; ok {
+			script = append(script, opcode)
+			continue
+		}
+
+		if opcode, ok := OpcodeByName[tok]; ok {
+			if opcodeArray[opcode].length != 1 {
+				str := fmt.Sprintf("data push opcode %s can not "+
+					"be assembled without its data", tok)
+				return nil, scriptError(ErrInvalidScriptToken, str)
+			}
+			script = append(script, opcode)
+			continue
+		}
+
+		data, err := hex.DecodeString(tok)
+		if err != nil || len(data) == 0 {
+			str := fmt.Sprintf("bad token %q", tok)
+			return nil, scriptError(ErrInvalidScriptToken, str)
+		}
+		script = appendDataPush(script, data)
+	}
+	return script, nil
+}
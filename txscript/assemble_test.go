@@ -0,0 +1,143 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestAssemble ensures Assemble converts disassembled scripts back into the
+// expected raw scripts and rejects invalid tokens.
+func TestAssemble(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		disasm  string
+		want    string // short form
+		wantErr bool
+	}{{
+		name:   "empty",
+		disasm: "",
+		want:   "",
+	}, {
+		name: "p2pkh",
+		disasm: "OP_DUP OP_HASH160 " +
+			"0102030405060708090a0b0c0d0e0f1011121314 " +
+			"OP_EQUALVERIFY OP_CHECKSIG",
+		want: "DUP HASH160 " +
+			"0x14 0x0102030405060708090a0b0c0d0e0f1011121314 " +
+			"EQUALVERIFY CHECKSIG",
+	}, {
+		name:   "small integers",
+		disasm: "-1 0 1 10 16",
+		want:   "0x4f 0x00 0x51 0x5a 0x60",
+	}, {
+		name:   "aliases and unknown opcodes",
+		disasm: "OP_TRUE OP_NOP2 OP_CHECKLOCKTIMEVERIFY OP_UNKNOWN186",
+		want:   "0x51 0xb1 0xb1 0xba",
+	}, {
+		name:   "single byte data pushes",
+		disasm: "00 81 1f",
+		want:   "0x01 0x00 0x01 0x81 0x01 0x1f",
+	}, {
+		name:   "extra whitespace",
+		disasm: " OP_NOP\t\n OP_NOP  ",
+		want:   "NOP NOP",
+	}, {
+		name:   "pushdata1",
+		disasm: string(bytes.Repeat([]byte("ab"), 76)),
+		want:   "0x4c 0x4c 0x" + string(bytes.Repeat([]byte("ab"), 76)),
+	}, {
+		name:    "data push opcode",
+		disasm:  "OP_DATA_1",
+		wantErr: true,
+	}, {
+		name:    "odd length hex",
+		disasm:  "abc",
+		wantErr: true,
+	}, {
+		name:   "numbers above 16 are data",
+		disasm: "17",
+		want:   "0x01 0x17",
+	}, {
+		name:    "short form opcode",
+		disasm:  "DUP",
+		wantErr: true,
+	}, {
+		name:    "error marker",
+		disasm:  "OP_NOP [error]",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		got, err := Assemble(test.disasm)
+		if test.wantErr {
+			if !IsErrorCode(err, ErrInvalidScriptToken) {
+				t.Errorf("%s: unexpected error - got %v, want %v",
+					test.name, err, ErrInvalidScriptToken)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		want := mustParseShortForm(test.want)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: unexpected script - got %x, want %x",
+				test.name, got, want)
+		}
+	}
+}
+
+// TestAssembleRoundTrip ensures canonical scripts are unchanged after being
+// disassembled and then assembled again.
+func TestAssembleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	scripts := []string{
+		"DUP HASH160 DATA_20 0x0102030405060708090a0b0c0d0e0f1011121314 " +
+			"EQUALVERIFY CHECKSIG",
+		"HASH160 DATA_20 0x433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUAL",
+		"2 DATA_33 0x02" + "11223344556677889900aabbccddeeff" +
+			"11223344556677889900aabbccddeeff 1 CHECKMULTISIG",
+		"RETURN 'hello-world'",
+		"IF 0 ELSE -1 ENDIF 0 DATA_1 0x00",
+		"CHECKLOCKTIMEVERIFY CHECKSEQUENCEVERIFY 0xba 0xff",
+	}
+	for _, short := range scripts {
+		script := mustParseShortForm(short)
+		disasm, err := DisasmString(script)
+		if err != nil {
+			t.Errorf("DisasmString(%q): unexpected error: %v", short,
+				err)
+			continue
+		}
+		got, err := Assemble(disasm)
+		if err != nil {
+			t.Errorf("Assemble(%q): unexpected error: %v", disasm, err)
+			continue
+		}
+		if !bytes.Equal(got, script) {
+			t.Errorf("Assemble(%q): unexpected script - got %x, "+
+				"want %x", disasm, got, script)
+		}
+	}
+}
+
+// TestParseShortFormErrors ensures ParseShortForm rejects invalid tokens.
+func TestParseShortFormErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, short := range []string{"0xzz", "FOO", "'unterminated", "OP_UNKNOWN186"} {
+		_, err := ParseShortForm(short)
+		if !IsErrorCode(err, ErrInvalidScriptToken) {
+			t.Errorf("ParseShortForm(%q): unexpected error - got %v, "+
+				"want %v", short, err, ErrInvalidScriptToken)
+		}
+	}
+}
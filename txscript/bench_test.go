@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcutil"
 	"github.com/dashpay/dashd-go/chaincfg"
 	"github.com/dashpay/dashd-go/wire"
 )
@@ -52,6 +54,23 @@ func BenchmarkCalcSigHash(b *testing.B) {
 	}
 }
 
+// BenchmarkCalcSigHashCached benchmarks how long it takes to calculate the
+// signature hashes for all inputs of a transaction with many inputs when making
+// use of the cached transaction fragments.
+func BenchmarkCalcSigHashCached(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sigHashes := NewTxSigHashes(&manyInputsBenchTx)
+		for j := 0; j < len(manyInputsBenchTx.TxIn); j++ {
+			_, err := CalcSignatureHashCached(prevOutScript, sigHashes,
+				SigHashAll, &manyInputsBenchTx, j)
+			if err != nil {
+				b.Fatalf("failed to calc signature hash: %v", err)
+			}
+		}
+	}
+}
+
 // BenchmarkCalcWitnessSigHash benchmarks how long it takes to calculate the
 // witness signature hashes for all inputs of a transaction with many inputs.
 func BenchmarkCalcWitnessSigHash(b *testing.B) {
@@ -535,3 +554,96 @@ func BenchmarkExtractPkScriptAddrs(b *testing.B) {
 		}
 	}
 }
+
+// multiSigBenchTx returns a transaction which spends a bare 2-of-3 multisig
+// output along with the public key script of the output.
+func multiSigBenchTx(b *testing.B) (*wire.MsgTx, []byte) {
+	b.Helper()
+
+	params := &chaincfg.MainNetParams
+	var privKeys []*btcec.PrivateKey
+	var pubKeys []*btcutil.AddressPubKey
+	for i := byte(1); i <= 3; i++ {
+		privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{i}, 32))
+		pubKey, err := btcutil.NewAddressPubKey(
+			privKey.PubKey().SerializeCompressed(), params)
+		if err != nil {
+			b.Fatalf("failed to create pubkey address: %v", err)
+		}
+		privKeys = append(privKeys, privKey)
+		pubKeys = append(pubKeys, pubKey)
+	}
+	pkScript, err := MultiSigScript(pubKeys, 2)
+	if err != nil {
+		b.Fatalf("failed to create multisig script: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{OP_TRUE}))
+	builder := NewScriptBuilder().AddOp(OP_0)
+	for _, privKey := range privKeys[:2] {
+		sig, err := RawTxInSignature(tx, 0, pkScript, SigHashAll,
+			privKey)
+		if err != nil {
+			b.Fatalf("failed to sign: %v", err)
+		}
+		builder.AddData(sig)
+	}
+	tx.TxIn[0].SignatureScript, err = builder.Script()
+	if err != nil {
+		b.Fatalf("failed to create signature script: %v", err)
+	}
+
+	return tx, pkScript
+}
+
+// BenchmarkExecuteCheckMultiSig benchmarks how long it takes to execute a
+// signature script which spends a bare 2-of-3 multisig output, including the
+// parsing of the signatures and public keys and the signature verification.
+func BenchmarkExecuteCheckMultiSig(b *testing.B) {
+	tx, pkScript := multiSigBenchTx(b)
+	flags := StandardVerifyFlags
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, 0)
+		if err != nil {
+			b.Fatalf("failed to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("failed to execute script: %v", err)
+		}
+	}
+}
+
+// BenchmarkExecuteArithmetic benchmarks how long it takes to execute a script
+// dominated by numeric opcodes, which exercises the conversion of stack items
+// to and from script numbers.
+func BenchmarkExecuteArithmetic(b *testing.B) {
+	var short bytes.Buffer
+	short.WriteString("1")
+	for i := 0; i < 40; i++ {
+		short.WriteString(" 1000 ADD 999 SUB 1ADD 2 SUB")
+	}
+	short.WriteString(" 1 NUMEQUAL")
+	pkScript := mustParseShortForm(short.String())
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{OP_TRUE}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			nil, 0)
+		if err != nil {
+			b.Fatalf("failed to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("failed to execute script: %v", err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// PushRenderer is an interface type provided to a Disassembler which renders
+// the data of data push opcodes in a human-readable form.
+type PushRenderer interface {
+	// RenderPush returns the rendering of the data pushed by a data push
+	// opcode within a script of the provided class.  The boolean is false
+	// when the renderer does not apply to the data, in which case the next
+	// renderer is tried.
+	RenderPush(class ScriptClass, data []byte) (string, bool)
+}
+
+// PushRendererFunc implements PushRenderer with a closure.
+type PushRendererFunc func(class ScriptClass, data []byte) (string, bool)
+
+// RenderPush implements PushRenderer by returning the result of calling the
+// closure.
+func (f PushRendererFunc) RenderPush(class ScriptClass, data []byte) (string, bool) {
+	return f(class, data)
+}
+
+// HexRenderer renders all data as hex, which is the rendering DisasmString uses
+// for data pushes.
+var HexRenderer = PushRendererFunc(func(_ ScriptClass, data []byte) (string, bool) {
+	return hex.EncodeToString(data), true
+})
+
+// UTF8Renderer renders data consisting entirely of printable UTF-8 text as a
+// double quoted Go string literal.  It only applies to null data scripts since
+// data in other scripts which happens to be printable is almost certainly
+// binary.
+var UTF8Renderer = PushRendererFunc(func(class ScriptClass, data []byte) (string, bool) {
+	if class != NullDataTy || len(data) == 0 || !utf8.Valid(data) {
+		return "", false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return strconv.Quote(string(data)), true
+})
+
+// AddressRenderer returns a renderer which renders the public key hashes of
+// pay-to-pubkey-hash scripts, the script hashes of pay-to-script-hash scripts
+// and the public keys of pay-to-pubkey and multisig scripts as the base58
+// encoded addresses they correspond to on the provided network.
+func AddressRenderer(chainParams *chaincfg.Params) PushRenderer {
+	return PushRendererFunc(func(class ScriptClass, data []byte) (string, bool) {
+		var addr btcutil.Address
+		var err error
+		switch class {
+		case PubKeyHashTy:
+			addr, err = btcutil.NewAddressPubKeyHash(data, chainParams)
+
+		case ScriptHashTy:
+			addr, err = btcutil.NewAddressScriptHashFromHash(data,
+				chainParams)
+
+		case PubKeyTy, MultiSigTy:
+			var pubKey *btcutil.AddressPubKey
+			pubKey, err = btcutil.NewAddressPubKey(data, chainParams)
+			if err == nil {
+				addr = pubKey.AddressPubKeyHash()
+			}
+
+		default:
+			return "", false
+		}
+		if err != nil {
+			return "", false
+		}
+		return addr.EncodeAddress(), true
+	})
+}
+
+// Disassembler produces one line disassemblies of scripts in the same format
+// as DisasmString, except the data of data push opcodes is rendered by the
+// renderers registered for the class of the script being disassembled.  Data
+// no registered renderer applies to is rendered as hex.
+//
+// A Disassembler must not be modified while it is in use by other goroutines.
+type Disassembler struct {
+	renderers        map[ScriptClass][]PushRenderer
+	defaultRenderers []PushRenderer
+}
+
+// NewDisassembler returns a new Disassembler without any registered renderers,
+// which therefore produces the same output as DisasmString.
+func NewDisassembler() *Disassembler {
+	return &Disassembler{
+		renderers: make(map[ScriptClass][]PushRenderer),
+	}
+}
+
+// Register adds a renderer for data pushes within scripts of the provided
+// class.  Renderers are tried in the order they are registered.
+func (d *Disassembler) Register(class ScriptClass, renderer PushRenderer) {
+	d.renderers[class] = append(d.renderers[class], renderer)
+}
+
+// RegisterDefault adds a renderer for data pushes within scripts of any class.
+// Default renderers are tried in the order they are registered after all of
+// the renderers registered for the specific class.
+func (d *Disassembler) RegisterDefault(renderer PushRenderer) {
+	d.defaultRenderers = append(d.defaultRenderers, renderer)
+}
+
+// renderPush writes the rendering of the provided data pushed in a script of
+// the provided class into the buffer.
+func (d *Disassembler) renderPush(buf *strings.Builder, class ScriptClass, data []byte) {
+	for _, renderers := range [2][]PushRenderer{d.renderers[class],
+		d.defaultRenderers} {
+
+		for _, renderer := range renderers {
+			if str, ok := renderer.RenderPush(class, data); ok {
+				buf.WriteString(str)
+				return
+			}
+		}
+	}
+	buf.WriteString(hex.EncodeToString(data))
+}
+
+// Disasm formats a disassembled script for one line printing using the
+// registered renderers.  As with DisasmString, when the script fails to parse,
+// the returned string will contain the disassembled script up to the point the
+// failure occurred along with the string '[error]' appended, and the reason
+// the script failed to parse is returned.
+//
+// NOTE: This function is only valid for version 0 scripts.  Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func (d *Disassembler) Disasm(script []byte) (string, error) {
+	const scriptVersion = 0
+
+	class := GetScriptClass(script)
+	var disbuf strings.Builder
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for first := true; tokenizer.Next(); first = false {
+		if !first {
+			disbuf.WriteByte(' ')
+		}
+
+		// Only data push opcodes are rendered.  Notably, this excludes
+		// the small integer opcodes.
+		op := tokenizer.op
+		if op.length == 1 {
+			disasmOpcode(&disbuf, op, nil, true)
+			continue
+		}
+		d.renderPush(&disbuf, class, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		if tokenizer.ByteIndex() != 0 {
+			disbuf.WriteByte(' ')
+		}
+		disbuf.WriteString("[error]")
+	}
+	return disbuf.String(), tokenizer.Err()
+}
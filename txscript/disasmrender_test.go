@@ -0,0 +1,106 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+)
+
+// TestDisassemblerDefault ensures a disassembler without any registered
+// renderers produces the same output as DisasmString.
+func TestDisassemblerDefault(t *testing.T) {
+	t.Parallel()
+
+	scripts := []string{
+		"DUP HASH160 DATA_20 0x0102030405060708090a0b0c0d0e0f1011121314 " +
+			"EQUALVERIFY CHECKSIG",
+		"RETURN DATA_5 0x68656c6c6f",
+		"0 1 16 -1 NOP",
+		"",
+		"DUP DATA_2 0x01",
+	}
+	d := NewDisassembler()
+	for _, short := range scripts {
+		script := mustParseShortForm(short)
+		want, wantErr := DisasmString(script)
+		got, err := d.Disasm(script)
+		if got != want || (err == nil) != (wantErr == nil) {
+			t.Errorf("Disasm(%q): got (%q, %v), want (%q, %v)", short,
+				got, err, want, wantErr)
+		}
+	}
+}
+
+// TestDisassemblerRenderers ensures registered renderers are applied to data
+// pushes according to the class of the script.
+func TestDisassemblerRenderers(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	pubKey := hexToBytes("02192d74d0cb94344c9569c2e77901573d8d7903c3e" +
+		"bec3a957724895dca52c6b4")
+	pkHash := btcutil.Hash160(pubKey)
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(pkHash, params)
+	p2sh, _ := btcutil.NewAddressScriptHashFromHash(pkHash, params)
+
+	d := NewDisassembler()
+	d.Register(NullDataTy, UTF8Renderer)
+	d.Register(MultiSigTy, PushRendererFunc(func(_ ScriptClass, data []byte) (string, bool) {
+		return "<pubkey>", true
+	}))
+	d.RegisterDefault(AddressRenderer(params))
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{{
+		name: "pay to pubkey hash",
+		script: "DUP HASH160 DATA_20 0x" + hex.EncodeToString(pkHash) +
+			" EQUALVERIFY CHECKSIG",
+		want: "OP_DUP OP_HASH160 " + p2pkh.EncodeAddress() +
+			" OP_EQUALVERIFY OP_CHECKSIG",
+	}, {
+		name:   "pay to script hash",
+		script: "HASH160 DATA_20 0x" + hex.EncodeToString(pkHash) + " EQUAL",
+		want:   "OP_HASH160 " + p2sh.EncodeAddress() + " OP_EQUAL",
+	}, {
+		name:   "pay to pubkey",
+		script: "DATA_33 0x" + hex.EncodeToString(pubKey) + " CHECKSIG",
+		want:   p2pkh.EncodeAddress() + " OP_CHECKSIG",
+	}, {
+		name: "class renderer takes precedence",
+		script: "1 DATA_33 0x" + hex.EncodeToString(pubKey) + " DATA_33 0x" +
+			hex.EncodeToString(pubKey) + " 2 CHECKMULTISIG",
+		want: "1 <pubkey> <pubkey> 2 OP_CHECKMULTISIG",
+	}, {
+		name:   "printable null data",
+		script: "RETURN DATA_11 0x68656c6c6f20776f726c64",
+		want:   `OP_RETURN "hello world"`,
+	}, {
+		name:   "binary null data",
+		script: "RETURN DATA_2 0x0001",
+		want:   "OP_RETURN 0001",
+	}, {
+		name:   "nonstandard",
+		script: "DATA_20 0x" + hex.EncodeToString(pkHash) + " DROP",
+		want:   hex.EncodeToString(pkHash) + " OP_DROP",
+	}}
+
+	for _, test := range tests {
+		got, err := d.Disasm(mustParseShortForm(test.script))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
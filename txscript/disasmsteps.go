@@ -0,0 +1,101 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// DisasmStep describes a single opcode of a disassembled script in a structured
+// form suitable for rich rendering, for example by block explorers.
+type DisasmStep struct {
+	// Offset is the byte offset of the opcode within the script.
+	Offset int `json:"offset"`
+
+	// Opcode is the value of the opcode.
+	Opcode byte `json:"opcode"`
+
+	// Name is the full name of the opcode, such as OP_DATA_20.
+	Name string `json:"name"`
+
+	// Raw is the hex encoding of all bytes of the opcode, including any
+	// length prefix and pushed data.
+	Raw string `json:"raw"`
+
+	// DataPush is true for the opcodes which push data, which are OP_0
+	// through OP_PUSHDATA4.
+	DataPush bool `json:"dataPush"`
+
+	// PushLen is the number of bytes pushed by a data push opcode.
+	PushLen int `json:"pushLen"`
+
+	// Data is the hex encoding of the data pushed by a data push opcode.
+	Data string `json:"data,omitempty"`
+
+	// Disasm is the compact one-line representation of the opcode as used
+	// by DisasmString.
+	Disasm string `json:"disasm"`
+}
+
+// DisasmSteps returns the structured disassembly of the provided script, one
+// entry per opcode.  In the case the script fails to parse, the returned steps
+// cover the script up to the point the failure occurred and the reason the
+// script failed to parse is returned.
+//
+// NOTE: This function is only valid for version 0 scripts.  Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func DisasmSteps(script []byte) ([]DisasmStep, error) {
+	const scriptVersion = 0
+
+	var steps []DisasmStep
+	var buf strings.Builder
+	offset := 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		end := int(tokenizer.ByteIndex())
+		op, data := tokenizer.op, tokenizer.Data()
+
+		buf.Reset()
+		disasmOpcode(&buf, op, data, true)
+		step := DisasmStep{
+			Offset:   offset,
+			Opcode:   op.value,
+			Name:     op.name,
+			Raw:      hex.EncodeToString(script[offset:end]),
+			DataPush: op.value <= OP_PUSHDATA4,
+			Disasm:   buf.String(),
+		}
+		if step.DataPush {
+			step.PushLen = len(data)
+			step.Data = hex.EncodeToString(data)
+		}
+		steps = append(steps, step)
+		offset = end
+	}
+	return steps, tokenizer.Err()
+}
+
+// DisasmJSON returns the structured disassembly of the provided script as
+// produced by DisasmSteps encoded as a JSON array.  As with DisasmSteps, a
+// script that fails to parse results in the JSON for the script up to the point
+// the failure occurred along with the reason the script failed to parse.
+//
+// NOTE: This function is only valid for version 0 scripts.  Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func DisasmJSON(script []byte) ([]byte, error) {
+	steps, parseErr := DisasmSteps(script)
+	if steps == nil {
+		steps = []DisasmStep{}
+	}
+	encoded, err := json.Marshal(steps)
+	if err != nil {
+		return nil, err
+	}
+	return encoded, parseErr
+}
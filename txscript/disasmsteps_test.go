@@ -0,0 +1,100 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDisasmSteps ensures the structured disassembly describes each opcode
+// with its offset, raw bytes and pushed data, and that parse failures return
+// the steps up to the failure.
+func TestDisasmSteps(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("0 1 DUP 0x02 0xabcd PUSHDATA1 0x01 0xef " +
+		"CHECKSIG")
+	want := []DisasmStep{{
+		Offset: 0, Opcode: OP_0, Name: "OP_0", Raw: "00",
+		DataPush: true, Disasm: "0",
+	}, {
+		Offset: 1, Opcode: OP_1, Name: "OP_1", Raw: "51", Disasm: "1",
+	}, {
+		Offset: 2, Opcode: OP_DUP, Name: "OP_DUP", Raw: "76",
+		Disasm: "OP_DUP",
+	}, {
+		Offset: 3, Opcode: OP_DATA_2, Name: "OP_DATA_2", Raw: "02abcd",
+		DataPush: true, PushLen: 2, Data: "abcd", Disasm: "abcd",
+	}, {
+		Offset: 6, Opcode: OP_PUSHDATA1, Name: "OP_PUSHDATA1",
+		Raw: "4c01ef", DataPush: true, PushLen: 1, Data: "ef",
+		Disasm: "ef",
+	}, {
+		Offset: 9, Opcode: OP_CHECKSIG, Name: "OP_CHECKSIG", Raw: "ac",
+		Disasm: "OP_CHECKSIG",
+	}}
+	steps, err := DisasmSteps(script)
+	if err != nil {
+		t.Fatalf("DisasmSteps: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("mismatched steps:\ngot: %+v\nwant: %+v", steps, want)
+	}
+
+	// Ensure a script that fails to parse returns the steps up to the
+	// failure along with the parse error.
+	steps, err = DisasmSteps(hexToBytes("7602ab"))
+	if !IsErrorCode(err, ErrMalformedPush) {
+		t.Fatalf("DisasmSteps: unexpected error: %v", err)
+	}
+	if len(steps) != 1 || steps[0].Name != "OP_DUP" {
+		t.Fatalf("unexpected steps for malformed script: %+v", steps)
+	}
+}
+
+// TestDisasmJSON ensures the JSON disassembly encodes the structured steps.
+func TestDisasmJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		script  []byte
+		want    string
+		errCode ErrorCode
+		wantErr bool
+	}{{
+		name:   "empty script",
+		script: nil,
+		want:   "[]",
+	}, {
+		name:   "data push and opcode",
+		script: hexToBytes("01ab87"),
+		want: `[{"offset":0,"opcode":1,"name":"OP_DATA_1","raw":"01ab",` +
+			`"dataPush":true,"pushLen":1,"data":"ab","disasm":"ab"},` +
+			`{"offset":2,"opcode":135,"name":"OP_EQUAL","raw":"87",` +
+			`"dataPush":false,"pushLen":0,"disasm":"OP_EQUAL"}]`,
+	}, {
+		name:    "malformed push",
+		script:  hexToBytes("02ab"),
+		want:    "[]",
+		wantErr: true,
+		errCode: ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		got, err := DisasmJSON(test.script)
+		if test.wantErr != (err != nil) ||
+			(test.wantErr && !IsErrorCode(err, test.errCode)) {
+
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: mismatched JSON:\ngot: %s\nwant: %s",
+				test.name, got, test.want)
+		}
+	}
+}
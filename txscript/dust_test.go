@@ -0,0 +1,63 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestIsDust ensures outputs are considered dust based on the cost of spending
+// them relative to the minimum relay fee.
+func TestIsDust(t *testing.T) {
+	// Pay-to-pubkey-hash output script.
+	p2pkh := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+
+	tests := []struct {
+		name     string
+		txOut    wire.TxOut
+		relayFee btcutil.Amount
+		isDust   bool
+	}{{
+		name:     "zero value with zero relay fee",
+		txOut:    wire.TxOut{Value: 0, PkScript: p2pkh},
+		relayFee: 0,
+		isDust:   false,
+	}, {
+		name:     "zero value with relay fee",
+		txOut:    wire.TxOut{Value: 0, PkScript: p2pkh},
+		relayFee: 1,
+		isDust:   true,
+	}, {
+		// A p2pkh output takes 34 bytes and the input spending it
+		// 148, so the threshold is 3 * 182 = 546 at 1000 per kB.
+		name:     "p2pkh with value 545",
+		txOut:    wire.TxOut{Value: 545, PkScript: p2pkh},
+		relayFee: 1000,
+		isDust:   true,
+	}, {
+		name:     "p2pkh with value 546",
+		txOut:    wire.TxOut{Value: 546, PkScript: p2pkh},
+		relayFee: 1000,
+		isDust:   false,
+	}, {
+		name:     "unspendable output",
+		txOut:    wire.TxOut{Value: 5000, PkScript: []byte{OP_RETURN}},
+		relayFee: 0,
+		isDust:   true,
+	}}
+	for _, test := range tests {
+		if got := IsDust(&test.txOut, test.relayFee); got != test.isDust {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.isDust)
+		}
+	}
+
+	if got := GetDustThreshold(&wire.TxOut{PkScript: p2pkh}); got != 546 {
+		t.Errorf("GetDustThreshold: got %d, want 546", got)
+	}
+}
@@ -7,11 +7,12 @@ package txscript
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"fmt"
-	"math/big"
 	"strings"
 
+	"github.com/btcsuite/btclog"
 	"github.com/dashpay/dashd-go/btcec/v2"
 	"github.com/dashpay/dashd-go/wire"
 )
@@ -26,7 +27,8 @@ const (
 	ScriptBip16 ScriptFlags = 1 << iota
 
 	// ScriptStrictMultiSig defines whether to verify the stack item
-	// used by CHECKMULTISIG is zero length.
+	// used by CHECKMULTISIG is zero length.  It is also available under
+	// the dashd name ScriptVerifyNullDummy.
 	ScriptStrictMultiSig
 
 	// ScriptDiscourageUpgradableNops defines whether to verify that
@@ -49,22 +51,25 @@ const (
 
 	// ScriptVerifyCleanStack defines that the stack must contain only
 	// one stack element after evaluation and that the element must be
-	// true if interpreted as a boolean.  This is rule 6 of BIP0062.
-	// This flag should never be used without the ScriptBip16 flag nor the
-	// ScriptVerifyWitness flag.
+	// true if interpreted as a boolean.  This is rule 6 of BIP0062 and
+	// corresponds to the CLEANSTACK flag of dashd.  This flag should never
+	// be used without the ScriptBip16 flag nor the ScriptVerifyWitness
+	// flag.
 	ScriptVerifyCleanStack
 
 	// ScriptVerifyDERSignatures defines that signatures are required
 	// to compily with the DER format.
 	ScriptVerifyDERSignatures
 
-	// ScriptVerifyLowS defines that signtures are required to comply with
+	// ScriptVerifyLowS defines that signatures are required to comply with
 	// the DER format and whose S value is <= order / 2.  This is rule 5
-	// of BIP0062.
+	// of BIP0062 and is named LOW_S in dashd.
 	ScriptVerifyLowS
 
-	// ScriptVerifyMinimalData defines that signatures must use the smallest
-	// push operator. This is both rules 3 and 4 of BIP0062.
+	// ScriptVerifyMinimalData defines that data pushes must use the smallest
+	// push operator and numeric stack items must be minimally encoded.  This
+	// is both rules 3 and 4 of BIP0062 (MINIMALDATA in dashd).  Pushes in
+	// branches which aren't executed are not checked.
 	ScriptVerifyMinimalData
 
 	// ScriptVerifyNullFail defines that signatures must be empty if
@@ -76,7 +81,11 @@ const (
 	ScriptVerifySigPushOnly
 
 	// ScriptVerifyStrictEncoding defines that signature scripts and
-	// public keys must follow the strict encoding requirements.
+	// public keys must follow the strict encoding requirements.  That is,
+	// signatures checked by OP_CHECKSIG and OP_CHECKMULTISIG must be
+	// canonically DER encoded with a defined hash type and public keys must
+	// be either compressed or uncompressed.  It is the STRICTENC flag of
+	// dashd.
 	ScriptVerifyStrictEncoding
 
 	// ScriptVerifyWitness defines whether or not to verify a transaction
@@ -95,8 +104,31 @@ const (
 	// operation whose public key isn't serialized in a compressed format
 	// non-standard.
 	ScriptVerifyWitnessPubKeyType
+
+	// ScriptConstantTimeEqual makes the OP_EQUAL and OP_EQUALVERIFY
+	// opcodes compare data elements of the same length in constant time.
+	// It does not alter the result of script execution and is intended
+	// for services which evaluate scripts involving secret data, such as
+	// hash preimages, where the time taken by a comparison could otherwise
+	// reveal how much of the secret was matched.
+	ScriptConstantTimeEqual
+
+	// ScriptVerifyNoTrailingData defines that signature scripts must not
+	// carry any data beyond what the public key script consumes.  It
+	// implies both ScriptVerifySigPushOnly and ScriptVerifyCleanStack, so
+	// signature scripts must only push data and leave exactly one item on
+	// the stack.  This rejects unknown extension data appended to signature
+	// scripts, in the style of the taproot annex, which would otherwise be
+	// ignored.  Like ScriptVerifyCleanStack, this flag should never be used
+	// without the ScriptBip16 flag nor the ScriptVerifyWitness flag.
+	ScriptVerifyNoTrailingData
 )
 
+// ScriptVerifyNullDummy is the name used for the ScriptStrictMultiSig flag by
+// dashd and the reference tests.  It requires the extra stack item consumed by
+// OP_CHECKMULTISIG to be empty.
+const ScriptVerifyNullDummy = ScriptStrictMultiSig
+
 const (
 	// MaxStackSize is the maximum combined height of stack and alt stack
 	// during execution.
@@ -114,9 +146,6 @@ const (
 	payToWitnessScriptHashDataSize = 32
 )
 
-// halforder is used to tame ECDSA malleability (see BIP0062).
-var halfOrder = new(big.Int).Rsh(btcec.S256().N, 1)
-
 // Engine is the virtual machine that executes scripts.
 type Engine struct {
 	// The following fields are set when the engine is created and must not be
@@ -187,6 +216,13 @@ type Engine struct {
 	//
 	// numOps tracks the total number of non-push operations in a script and is
 	// primarily used to enforce maximum limits.
+	//
+	// numSteps tracks the total number of opcodes, including data pushes and
+	// opcodes in non-executed branches, processed across all scripts and is
+	// used to enforce the optional step limit in maxSteps.
+	//
+	// maxCondDepth is the optional maximum number of nested conditionals
+	// allowed in condStack.
 	scripts         [][]byte
 	scriptIdx       int
 	opcodeIdx       int
@@ -197,11 +233,40 @@ type Engine struct {
 	astack          stack
 	condStack       []int
 	numOps          int
+	numSteps        int
+	maxSteps        int
+	maxCondDepth    int
+	stepCallback    func(*StepInfo) error
 	witnessVersion  int
 	witnessProgram  []byte
 	inputAmount     int64
 }
 
+// StepInfo houses information about the state of the engine immediately after
+// executing an opcode.  It is provided to the callback set via SetStepCallback.
+type StepInfo struct {
+	// ScriptIndex is the index of the script the opcode belongs to.  Index 0
+	// is the signature script, 1 is the public key script, and 2 is the
+	// redeem script in the case of pay-to-script-hash.
+	ScriptIndex int
+
+	// OpcodeIndex is the index of the opcode within the script.
+	OpcodeIndex int
+
+	// Opcode is the value of the executed opcode.
+	Opcode byte
+
+	// Disasm is the disassembly of the executed opcode in the same format
+	// as returned by DisasmPC.
+	Disasm string
+
+	// Stack and AltStack are copies of the contents of the data and
+	// alternate stacks after executing the opcode where the last item is
+	// the top of the stack.  They may be freely modified.
+	Stack    [][]byte
+	AltStack [][]byte
+}
+
 // hasFlag returns whether the script engine instance has the passed flag set.
 func (vm *Engine) hasFlag(flag ScriptFlags) bool {
 	return vm.flags&flag == flag
@@ -649,6 +714,12 @@ func (vm *Engine) Step() (done bool, err error) {
 		return true, err
 	}
 
+	// Enforce the step limit when one has been configured.
+	if vm.maxSteps > 0 && vm.numSteps >= vm.maxSteps {
+		str := fmt.Sprintf("exceeded step limit of %d", vm.maxSteps)
+		return true, scriptError(ErrStepLimitExceeded, str)
+	}
+
 	// Attempt to parse the next opcode from the current script.
 	if !vm.tokenizer.Next() {
 		// Note that due to the fact that all scripts are checked for parse
@@ -668,6 +739,7 @@ func (vm *Engine) Step() (done bool, err error) {
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per script,
 	// maximum script element sizes, and conditionals.
+	vm.numSteps++
 	err = vm.executeOpcode(vm.tokenizer.op, vm.tokenizer.Data())
 	if err != nil {
 		return true, err
@@ -682,6 +754,13 @@ func (vm *Engine) Step() (done bool, err error) {
 		return false, scriptError(ErrStackOverflow, str)
 	}
 
+	// Notify the step callback, if any, of the new state.
+	if vm.stepCallback != nil {
+		if err := vm.notifyStep(); err != nil {
+			return true, err
+		}
+	}
+
 	// Prepare for next instruction.
 	vm.opcodeIdx++
 	if vm.tokenizer.Done() {
@@ -761,9 +840,122 @@ func (vm *Engine) Step() (done bool, err error) {
 	return false, nil
 }
 
+// SetStepLimit sets the maximum number of opcodes the engine will process
+// before failing with ErrStepLimitExceeded.  Every opcode counts towards the
+// limit, including data pushes and opcodes in branches which are not executed,
+// and the count spans all of the scripts executed by the engine.  A limit of
+// zero, which is the default, disables the check.
+//
+// The step limit is not a consensus rule.  It is intended for callers that
+// evaluate untrusted scripts, such as RPC endpoints and fuzzers, and wish to
+// bound the work done by the engine beyond the consensus limits.
+func (vm *Engine) SetStepLimit(limit int) {
+	vm.maxSteps = limit
+}
+
+// SetMaxConditionalDepth sets the maximum number of conditionals which may be
+// nested at any point of execution before the engine fails with
+// ErrConditionalDepthExceeded.  Conditionals in branches which are not executed
+// count towards the depth since they are tracked all the same.  A depth of
+// zero, which is the default, disables the check.
+//
+// Like the step limit, the maximum depth is not a consensus rule.  It allows
+// callers that evaluate untrusted scripts to reject deeply nested scripts
+// which are valid but expensive to track.
+func (vm *Engine) SetMaxConditionalDepth(depth int) {
+	vm.maxCondDepth = depth
+}
+
+// ConditionalDepth returns the number of conditionals the engine is currently
+// nested in.
+func (vm *Engine) ConditionalDepth() int {
+	return len(vm.condStack)
+}
+
+// pushCondition enters a new conditional with the passed execution state while
+// enforcing the maximum conditional depth when one has been configured.
+func (vm *Engine) pushCondition(condVal int) error {
+	if vm.maxCondDepth > 0 && len(vm.condStack) >= vm.maxCondDepth {
+		str := fmt.Sprintf("conditional depth %d at script %d opcode %d "+
+			"exceeds max allowed %d", len(vm.condStack)+1, vm.scriptIdx,
+			vm.opcodeIdx, vm.maxCondDepth)
+		return scriptError(ErrConditionalDepthExceeded, str)
+	}
+	vm.condStack = append(vm.condStack, condVal)
+	return nil
+}
+
+// SetStepCallback sets a function which is invoked after each opcode is
+// successfully executed with information about the executed opcode and copies
+// of the stacks.  This allows script debuggers and tracing tools to observe
+// execution without modifying the engine.  Returning a non-nil error from the
+// callback aborts execution with that error.  Passing nil removes the callback.
+//
+// The callback is invoked for every opcode, including those in branches which
+// are not executed.
+func (vm *Engine) SetStepCallback(callback func(*StepInfo) error) {
+	vm.stepCallback = callback
+}
+
+// Copy returns a deep copy of the engine, including the stacks, the program
+// counter and the conditional execution state.  This allows a caller to Step
+// the copy speculatively down one path and then discard it, while the original
+// engine continues from the point the copy was made.
+//
+// The transaction, signature cache, hash cache and step callback are shared
+// with the original since executing scripts does not modify them other than
+// adding entries to the signature cache.
+func (vm *Engine) Copy() *Engine {
+	vmCopy := *vm
+	vmCopy.scripts = append([][]byte(nil), vm.scripts...)
+	vmCopy.dstack.stk = copyStack(&vm.dstack)
+	vmCopy.astack.stk = copyStack(&vm.astack)
+	vmCopy.condStack = append([]int(nil), vm.condStack...)
+	if vm.savedFirstStack != nil {
+		vmCopy.savedFirstStack = make([][]byte, len(vm.savedFirstStack))
+		for i, item := range vm.savedFirstStack {
+			vmCopy.savedFirstStack[i] = append([]byte(nil), item...)
+		}
+	}
+	return &vmCopy
+}
+
+// copyStack returns a deep copy of the contents of the passed stack as an
+// array where the last item in the array is the top of the stack.
+func copyStack(stack *stack) [][]byte {
+	array := getStack(stack)
+	for i, item := range array {
+		array[i] = append([]byte(nil), item...)
+	}
+	return array
+}
+
+// notifyStep invokes the step callback with the state of the engine after
+// executing the opcode most recently parsed by the tokenizer.
+func (vm *Engine) notifyStep() error {
+	var buf strings.Builder
+	disasmOpcode(&buf, vm.tokenizer.op, vm.tokenizer.Data(), false)
+	return vm.stepCallback(&StepInfo{
+		ScriptIndex: vm.scriptIdx,
+		OpcodeIndex: vm.opcodeIdx,
+		Opcode:      vm.tokenizer.Opcode(),
+		Disasm: fmt.Sprintf("%02x:%04x: %s", vm.scriptIdx,
+			vm.opcodeIdx, buf.String()),
+		Stack:    copyStack(&vm.dstack),
+		AltStack: copyStack(&vm.astack),
+	})
+}
+
 // Execute will execute all scripts in the script engine and return either nil
 // for successful validation or an error if one occurred.
 func (vm *Engine) Execute() (err error) {
+	return vm.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is the same as Execute except execution is aborted with
+// ErrExecutionCanceled as soon as the passed context is canceled or its
+// deadline is exceeded.  The context is checked prior to every step.
+func (vm *Engine) ExecuteContext(ctx context.Context) (err error) {
 	// All script versions other than 0 currently execute without issue,
 	// making all outputs to them anyone can pay. In the future this
 	// will allow for the addition of new scripting languages.
@@ -771,33 +963,49 @@ func (vm *Engine) Execute() (err error) {
 		return nil
 	}
 
+	ctxDone := ctx.Done()
 	done := false
 	for !done {
-		log.Tracef("%v", newLogClosure(func() string {
-			dis, err := vm.DisasmPC()
-			if err != nil {
-				return fmt.Sprintf("stepping - failed to disasm pc: %v", err)
-			}
-			return fmt.Sprintf("stepping %v", dis)
-		}))
+		select {
+		case <-ctxDone:
+			str := fmt.Sprintf("script execution aborted: %v", ctx.Err())
+			return scriptError(ErrExecutionCanceled, str)
+		default:
+		}
+
+		// The log closures are only constructed when tracing is enabled
+		// since doing so for every step is a notable source of allocations.
+		tracing := log.Level() <= btclog.LevelTrace
+		if tracing {
+			log.Tracef("%v", newLogClosure(func() string {
+				dis, err := vm.DisasmPC()
+				if err != nil {
+					return fmt.Sprintf("stepping - failed to "+
+						"disasm pc: %v", err)
+				}
+				return fmt.Sprintf("stepping %v", dis)
+			}))
+		}
 
 		done, err = vm.Step()
 		if err != nil {
 			return err
 		}
-		log.Tracef("%v", newLogClosure(func() string {
-			var dstr, astr string
-
-			// Log the non-empty stacks when tracing.
-			if vm.dstack.Depth() != 0 {
-				dstr = "Stack:\n" + vm.dstack.String()
-			}
-			if vm.astack.Depth() != 0 {
-				astr = "AltStack:\n" + vm.astack.String()
-			}
-
-			return dstr + astr
-		}))
+		if tracing {
+			log.Tracef("%v", newLogClosure(func() string {
+				var dstr, astr string
+
+				// Log the non-empty stacks when tracing.
+				if vm.dstack.Depth() != 0 {
+					dstr = "Stack:\n" + vm.dstack.String()
+				}
+				if vm.astack.Depth() != 0 {
+					astr = "AltStack:\n" + vm.astack.String()
+				}
+
+				return dstr + astr
+			}))
+		}
 	}
 
 	return vm.CheckErrorCondition(true)
@@ -1058,8 +1266,15 @@ func (vm *Engine) checkSignatureEncoding(sig []byte) error {
 	// verifies.  This would result in changing the transaction hash and thus is
 	// a source of malleability.
 	if vm.hasFlag(ScriptVerifyLowS) {
-		sValue := new(big.Int).SetBytes(sig[sOffset : sOffset+sLen])
-		if sValue.Cmp(halfOrder) > 0 {
+		// The leading zero padding permitted above is stripped so the value
+		// fits in a scalar without allocating a big integer.
+		sBytes := sig[sOffset : sOffset+sLen]
+		for len(sBytes) > 0 && sBytes[0] == 0x00 {
+			sBytes = sBytes[1:]
+		}
+		var sValue btcec.ModNScalar
+		if len(sBytes) > 32 || sValue.SetByteSlice(sBytes) ||
+			sValue.IsOverHalfOrder() {
 			return scriptError(ErrSigHighS, "signature is not canonical due "+
 				"to unnecessarily high S value")
 		}
@@ -1095,6 +1310,20 @@ func (vm *Engine) GetStack() [][]byte {
 	return getStack(&vm.dstack)
 }
 
+// ForEachStackItem calls fn for each item on the primary stack from the top
+// down, where index 0 is the top of the stack.  Iteration stops at the first
+// error returned by fn, which is then returned.  The items must not be
+// modified.
+func (vm *Engine) ForEachStackItem(fn func(i int, item []byte) error) error {
+	return vm.dstack.ForEach(fn)
+}
+
+// StackString returns the contents of the primary stack in a readable format
+// suitable for diagnostics.
+func (vm *Engine) StackString() string {
+	return vm.dstack.String()
+}
+
 // SetStack sets the contents of the primary stack to the contents of the
 // provided array where the last item in the array will be the top of the stack.
 func (vm *Engine) SetStack(data [][]byte) {
@@ -1107,6 +1336,20 @@ func (vm *Engine) GetAltStack() [][]byte {
 	return getStack(&vm.astack)
 }
 
+// ForEachAltStackItem calls fn for each item on the alternate stack from the
+// top down, where index 0 is the top of the stack.  Iteration stops at the
+// first error returned by fn, which is then returned.  The items must not be
+// modified.
+func (vm *Engine) ForEachAltStackItem(fn func(i int, item []byte) error) error {
+	return vm.astack.ForEach(fn)
+}
+
+// AltStackString returns the contents of the alternate stack in a readable
+// format suitable for diagnostics.
+func (vm *Engine) AltStackString() string {
+	return vm.astack.String()
+}
+
 // SetAltStack sets the contents of the alternate stack to the contents of the
 // provided array where the last item in the array will be the top of the stack.
 func (vm *Engine) SetAltStack(data [][]byte) {
@@ -1116,6 +1359,12 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 // NewEngine returns a new script engine for the provided public key script,
 // transaction, and input index.  The flags modify the behavior of the script
 // engine according to the description provided by each flag.
+//
+// The hashCache, when non-nil, must have been computed for the provided
+// transaction via NewTxSigHashes.  It allows the signature hashes of all inputs
+// to be derived from a single serialization of the transaction, so callers
+// validating every input of a transaction should compute it once and share it
+// amongst the engines.
 func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags,
 	sigCache *SigCache, hashCache *TxSigHashes, inputAmount int64) (*Engine, error) {
 	const scriptVersion = 0
@@ -1137,6 +1386,15 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 			"false stack entry at end of script execution")
 	}
 
+	vm := Engine{flags: flags, sigCache: sigCache, hashCache: hashCache,
+		inputAmount: inputAmount}
+
+	// Rejecting trailing data is enforced by the push only and clean stack
+	// checks.
+	if vm.hasFlag(ScriptVerifyNoTrailingData) {
+		vm.flags |= ScriptVerifySigPushOnly | ScriptVerifyCleanStack
+	}
+
 	// The clean stack flag (ScriptVerifyCleanStack) is not allowed without
 	// either the pay-to-script-hash (P2SH) evaluation (ScriptBip16)
 	// flag or the Segregated Witness (ScriptVerifyWitness) flag.
@@ -1147,8 +1405,6 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 	// it possible to have a situation where P2SH would not be a soft fork
 	// when it should be. The same goes for segwit which will pull in
 	// additional scripts for execution from the witness stack.
-	vm := Engine{flags: flags, sigCache: sigCache, hashCache: hashCache,
-		inputAmount: inputAmount}
 	if vm.hasFlag(ScriptVerifyCleanStack) && (!vm.hasFlag(ScriptBip16) &&
 		!vm.hasFlag(ScriptVerifyWitness)) {
 		return nil, scriptError(ErrInvalidFlags,
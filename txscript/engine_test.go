@@ -6,6 +6,9 @@
 package txscript
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
@@ -146,6 +149,70 @@ func TestCheckErrorCondition(t *testing.T) {
 	}
 }
 
+// TestConstantTimeEqual ensures the results of the equality opcodes are the same
+// regardless of whether or not the constant time comparison flag is set.
+func TestConstantTimeEqual(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sigScript string
+		pkScript  string
+		valid     bool
+	}{{
+		name:      "equal data",
+		sigScript: "DATA_4 0x01020304",
+		pkScript:  "DATA_4 0x01020304 EQUAL",
+		valid:     true,
+	}, {
+		name:      "differing data of the same length",
+		sigScript: "DATA_4 0x01020304",
+		pkScript:  "DATA_4 0x01020305 EQUAL",
+		valid:     false,
+	}, {
+		name:      "differing lengths",
+		sigScript: "DATA_3 0x010203",
+		pkScript:  "DATA_4 0x01020304 EQUAL",
+		valid:     false,
+	}, {
+		name:      "empty data",
+		sigScript: "0",
+		pkScript:  "0 EQUALVERIFY TRUE",
+		valid:     true,
+	}, {
+		name:      "equal verify failure",
+		sigScript: "DATA_1 0x01",
+		pkScript:  "DATA_1 0x02 EQUALVERIFY TRUE",
+		valid:     false,
+	}}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				SignatureScript: mustParseShortForm(test.sigScript),
+				Sequence:        wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 1}},
+		}
+		pkScript := mustParseShortForm(test.pkScript)
+
+		for _, flags := range []ScriptFlags{0, ScriptConstantTimeEqual} {
+			vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, 0)
+			if err != nil {
+				t.Fatalf("%s: failed to create engine: %v",
+					test.name, err)
+			}
+			err = vm.Execute()
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("%s (flags %x): unexpected result - got "+
+					"%v, want %v (err: %v)", test.name, flags,
+					valid, test.valid, err)
+			}
+		}
+	}
+}
+
 // TestInvalidFlagCombinations ensures the script engine returns the expected
 // error when disallowed flag combinations are specified.
 func TestInvalidFlagCombinations(t *testing.T) {
@@ -153,6 +220,7 @@ func TestInvalidFlagCombinations(t *testing.T) {
 
 	tests := []ScriptFlags{
 		ScriptVerifyCleanStack,
+		ScriptVerifyNoTrailingData,
 	}
 
 	// tx with almost empty scripts.
@@ -426,3 +494,382 @@ func TestCheckSignatureEncoding(t *testing.T) {
 		}
 	}
 }
+
+// TestExecutionLimits ensures the step limit and context cancellation abort
+// script execution with the expected errors.
+func TestExecutionLimits(t *testing.T) {
+	t.Parallel()
+
+	// The signature script pushes a single item and the public key script
+	// consists of 4 opcodes for a total of 5 steps.
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: mustParseShortForm("1"),
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1}},
+	}
+	pkScript := mustParseShortForm("DUP 1 EQUALVERIFY TRUE")
+
+	tests := []struct {
+		name     string
+		limit    int
+		exceeded bool
+	}{
+		{name: "no limit", limit: 0, exceeded: false},
+		{name: "exact limit", limit: 5, exceeded: false},
+		{name: "generous limit", limit: 100, exceeded: false},
+		{name: "limit exceeded", limit: 4, exceeded: true},
+		{name: "limit exceeded in first script", limit: 1, exceeded: true},
+	}
+	for _, test := range tests {
+		vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		vm.SetStepLimit(test.limit)
+		err = vm.Execute()
+		if test.exceeded != IsErrorCode(err, ErrStepLimitExceeded) ||
+			(!test.exceeded && err != nil) {
+
+			t.Errorf("%s: unexpected result - got err %v, want "+
+				"exceeded %v", test.name, err, test.exceeded)
+		}
+	}
+
+	// Ensure execution is aborted when the context is already canceled.
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = vm.ExecuteContext(ctx)
+	if !IsErrorCode(err, ErrExecutionCanceled) {
+		t.Fatalf("ExecuteContext: unexpected error - got %v, want %v",
+			err, ErrExecutionCanceled)
+	}
+
+	// Ensure the maximum conditional depth is enforced, including for
+	// conditionals in branches which are not executed.
+	tx.TxIn[0].SignatureScript = nil
+	condTests := []struct {
+		name     string
+		pkScript string
+		depth    int
+		exceeded bool
+	}{
+		{name: "no limit", pkScript: "1 IF 1 IF 1 IF 1 ENDIF ENDIF ENDIF",
+			depth: 0, exceeded: false},
+		{name: "exact depth", pkScript: "1 IF 1 IF 1 IF 1 ENDIF ENDIF ENDIF",
+			depth: 3, exceeded: false},
+		{name: "sequential", pkScript: "1 IF ENDIF 1 IF ENDIF 1 NOTIF ENDIF 1",
+			depth: 1, exceeded: false},
+		{name: "depth exceeded", pkScript: "1 IF 1 IF 1 IF 1 ENDIF ENDIF ENDIF",
+			depth: 2, exceeded: true},
+		{name: "exceeded by NOTIF", pkScript: "1 IF 0 NOTIF 1 ENDIF ENDIF",
+			depth: 1, exceeded: true},
+		{name: "exceeded in skipped branch", pkScript: "0 IF IF IF ENDIF ENDIF ENDIF 1",
+			depth: 2, exceeded: true},
+	}
+	for _, test := range condTests {
+		vm, err := NewEngine(mustParseShortForm(test.pkScript), tx, 0, 0,
+			nil, nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		vm.SetMaxConditionalDepth(test.depth)
+		err = vm.Execute()
+		if test.exceeded != IsErrorCode(err, ErrConditionalDepthExceeded) ||
+			(!test.exceeded && err != nil) {
+
+			t.Errorf("%s: unexpected result - got err %v, want "+
+				"exceeded %v", test.name, err, test.exceeded)
+		}
+	}
+
+	// Ensure the current depth is reported while stepping.
+	vm, err = NewEngine(mustParseShortForm("1 IF 1 IF 1 ENDIF ENDIF"), tx,
+		0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	var depths []int
+	for {
+		done, err := vm.Step()
+		if err != nil {
+			t.Fatalf("Step: unexpected error: %v", err)
+		}
+		depths = append(depths, vm.ConditionalDepth())
+		if done {
+			break
+		}
+	}
+	wantDepths := []int{0, 1, 1, 2, 2, 1, 0}
+	if !reflect.DeepEqual(depths, wantDepths) {
+		t.Fatalf("ConditionalDepth: got %v, want %v", depths, wantDepths)
+	}
+}
+
+// TestStepCallback ensures the step callback is invoked after every opcode
+// with the expected state and that errors returned from it abort execution.
+func TestStepCallback(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: mustParseShortForm("5"),
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1}},
+	}
+	pkScript := mustParseShortForm("TOALTSTACK 1 FROMALTSTACK 5 EQUAL")
+
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	var steps []StepInfo
+	vm.SetStepCallback(func(info *StepInfo) error {
+		steps = append(steps, *info)
+		return nil
+	})
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("Execute: unexpected error: %v", err)
+	}
+
+	want := []StepInfo{{
+		ScriptIndex: 0, OpcodeIndex: 0, Opcode: OP_5,
+		Disasm: "00:0000: OP_5",
+		Stack:  [][]byte{{5}}, AltStack: [][]byte{},
+	}, {
+		ScriptIndex: 1, OpcodeIndex: 0, Opcode: OP_TOALTSTACK,
+		Disasm: "01:0000: OP_TOALTSTACK",
+		Stack:  [][]byte{}, AltStack: [][]byte{{5}},
+	}, {
+		ScriptIndex: 1, OpcodeIndex: 1, Opcode: OP_1,
+		Disasm: "01:0001: OP_1",
+		Stack:  [][]byte{{1}}, AltStack: [][]byte{{5}},
+	}, {
+		ScriptIndex: 1, OpcodeIndex: 2, Opcode: OP_FROMALTSTACK,
+		Disasm: "01:0002: OP_FROMALTSTACK",
+		Stack:  [][]byte{{1}, {5}}, AltStack: [][]byte{},
+	}, {
+		ScriptIndex: 1, OpcodeIndex: 3, Opcode: OP_5,
+		Disasm: "01:0003: OP_5",
+		Stack:  [][]byte{{1}, {5}, {5}}, AltStack: [][]byte{},
+	}, {
+		ScriptIndex: 1, OpcodeIndex: 4, Opcode: OP_EQUAL,
+		Disasm: "01:0004: OP_EQUAL",
+		Stack:  [][]byte{{1}, {1}}, AltStack: [][]byte{},
+	}}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("mismatched steps:\ngot: %+v\nwant: %+v", steps, want)
+	}
+
+	// Ensure an error returned from the callback aborts execution.
+	vm, err = NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	errBreak := errors.New("breakpoint")
+	var numSteps int
+	vm.SetStepCallback(func(info *StepInfo) error {
+		numSteps++
+		if info.ScriptIndex == 1 && info.OpcodeIndex == 1 {
+			return errBreak
+		}
+		return nil
+	})
+	if err := vm.Execute(); err != errBreak {
+		t.Fatalf("Execute: unexpected error - got %v, want %v", err,
+			errBreak)
+	}
+	if numSteps != 3 {
+		t.Fatalf("callback invoked %d times, want 3", numSteps)
+	}
+}
+
+// TestNullDummyAndCleanStack ensures the NULLDUMMY and CLEANSTACK flags reject
+// scripts which are otherwise valid.
+func TestNullDummyAndCleanStack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sigScript string
+		pkScript  string
+		flags     ScriptFlags
+		wantErr   bool
+		errCode   ErrorCode
+	}{{
+		name:      "non-empty dummy without NULLDUMMY",
+		sigScript: "1",
+		pkScript:  "0 0 CHECKMULTISIG",
+		flags:     ScriptBip16,
+	}, {
+		name:      "non-empty dummy with NULLDUMMY",
+		sigScript: "1",
+		pkScript:  "0 0 CHECKMULTISIG",
+		flags:     ScriptBip16 | ScriptVerifyNullDummy,
+		wantErr:   true,
+		errCode:   ErrSigNullDummy,
+	}, {
+		name:      "empty dummy with NULLDUMMY",
+		sigScript: "0",
+		pkScript:  "0 0 CHECKMULTISIG",
+		flags:     ScriptBip16 | ScriptVerifyNullDummy,
+	}, {
+		name:      "extra stack items without CLEANSTACK",
+		sigScript: "1 1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16,
+	}, {
+		name:      "extra stack items with CLEANSTACK",
+		sigScript: "1 1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16 | ScriptVerifyCleanStack,
+		wantErr:   true,
+		errCode:   ErrCleanStack,
+	}, {
+		name:      "single stack item with CLEANSTACK",
+		sigScript: "1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16 | ScriptVerifyCleanStack,
+	}, {
+		name:      "extra stack items with CLEANSTACK after P2SH",
+		sigScript: "1 1 DATA_1 0x61",
+		pkScript:  "HASH160 DATA_20 0x994355199e516ff76c4fa4aab39337b9d84cf12b EQUAL",
+		flags:     ScriptBip16 | ScriptVerifyCleanStack,
+		wantErr:   true,
+		errCode:   ErrCleanStack,
+	}, {
+		name:      "trailing push without NOTRAILINGDATA",
+		sigScript: "1 1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16,
+	}, {
+		name:      "trailing push with NOTRAILINGDATA",
+		sigScript: "1 1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16 | ScriptVerifyNoTrailingData,
+		wantErr:   true,
+		errCode:   ErrCleanStack,
+	}, {
+		name:      "non-push signature script with NOTRAILINGDATA",
+		sigScript: "1 NOP",
+		pkScript:  "NOP",
+		flags:     ScriptBip16 | ScriptVerifyNoTrailingData,
+		wantErr:   true,
+		errCode:   ErrNotPushOnly,
+	}, {
+		name:      "single push with NOTRAILINGDATA",
+		sigScript: "1",
+		pkScript:  "NOP",
+		flags:     ScriptBip16 | ScriptVerifyNoTrailingData,
+	}}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				SignatureScript: mustParseShortForm(test.sigScript),
+				Sequence:        wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 0}},
+		}
+		pkScript := mustParseShortForm(test.pkScript)
+		vm, err := NewEngine(pkScript, tx, 0, test.flags, nil, nil, 0)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if !IsErrorCode(err, test.errCode) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.errCode)
+		}
+	}
+}
+
+// TestEngineCopy ensures a copied engine can be executed speculatively without
+// affecting the state of the original and vice versa.
+func TestEngineCopy(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: mustParseShortForm("1"),
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1}},
+	}
+	pkScript := mustParseShortForm("DUP TOALTSTACK IF 2 ELSE 3 ENDIF " +
+		"FROMALTSTACK ADD 3 EQUAL")
+
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	// Step through the signature script and up to and including the IF.
+	for i := 0; i < 4; i++ {
+		if _, err := vm.Step(); err != nil {
+			t.Fatalf("Step #%d: unexpected error: %v", i, err)
+		}
+	}
+	snapshot := vm.Copy()
+
+	// Run the original to completion and ensure the copy is unaffected.
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("Execute: unexpected error: %v", err)
+	}
+	if stack := snapshot.GetStack(); len(stack) != 0 {
+		t.Fatalf("copy stack modified: %x", stack)
+	}
+	if altStack := snapshot.GetAltStack(); !reflect.DeepEqual(altStack,
+		[][]byte{{1}}) {
+
+		t.Fatalf("copy alt stack modified: %x", altStack)
+	}
+	if !reflect.DeepEqual(snapshot.condStack, []int{OpCondTrue}) {
+		t.Fatalf("copy condition stack modified: %v", snapshot.condStack)
+	}
+	if snapshot.scriptIdx != 1 || snapshot.opcodeIdx != 3 {
+		t.Fatalf("copy program counter modified: %d:%d",
+			snapshot.scriptIdx, snapshot.opcodeIdx)
+	}
+
+	// Take the same branch in a further copy with a different alt stack and
+	// ensure it fails independently of the copy it was made from.
+	speculative := snapshot.Copy()
+	speculative.SetAltStack([][]byte{{2}})
+	err = speculative.Execute()
+	if !IsErrorCode(err, ErrEvalFalse) {
+		t.Fatalf("speculative Execute: unexpected error: %v", err)
+	}
+	if err := snapshot.Execute(); err != nil {
+		t.Fatalf("snapshot Execute: unexpected error: %v", err)
+	}
+
+	// Ensure modifying stack items of a copy does not modify the original.
+	vm, err = NewEngine(pkScript, tx, 0, 0, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if _, err := vm.Step(); err != nil {
+		t.Fatalf("Step: unexpected error: %v", err)
+	}
+	vmCopy := vm.Copy()
+	vmCopy.dstack.stk[0][0] = 0xff
+	if stack := vm.GetStack(); !reflect.DeepEqual(stack, [][]byte{{1}}) {
+		t.Fatalf("original stack modified through copy: %x", stack)
+	}
+}
@@ -6,6 +6,7 @@
 package txscript
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -343,6 +344,64 @@ const (
 	// serialized in a compressed format.
 	ErrWitnessPubKeyType
 
+	// -------------------------------------------------
+	// Failures related to caller imposed execution limits.
+	// -------------------------------------------------
+
+	// ErrStepLimitExceeded is returned when the engine executes more
+	// opcodes than the step limit configured via SetStepLimit.
+	ErrStepLimitExceeded
+
+	// ErrExecutionCanceled is returned when the context passed to
+	// ExecuteContext is canceled or its deadline is exceeded before
+	// execution completes.
+	ErrExecutionCanceled
+
+	// ErrConditionalDepthExceeded is returned when the conditionals of a
+	// script are nested deeper than the maximum depth configured via
+	// SetMaxConditionalDepth.
+	ErrConditionalDepthExceeded
+
+	// ------------------------------------------------
+	// Failures related to hash time locked contracts.
+	// ------------------------------------------------
+
+	// ErrNotHTLCScript is returned from ExtractHTLC when the provided
+	// script is not a hash time locked contract.
+	ErrNotHTLCScript
+
+	// ErrInvalidHTLC is returned when the parameters of a hash time locked
+	// contract are out of range or a secret does not match its secret
+	// hash.
+	ErrInvalidHTLC
+
+	// -------------------------------------------
+	// Failures related to verifying transactions.
+	// -------------------------------------------
+
+	// ErrMissingPrevOutput is returned when the output spent by a
+	// transaction input can't be found while verifying the transaction.
+	ErrMissingPrevOutput
+
+	// ---------------------------------------
+	// Failures related to assembling scripts.
+	// ---------------------------------------
+
+	// ErrInvalidScriptToken is returned from ParseShortForm and Assemble
+	// when the provided text contains a token that can't be converted into
+	// script.
+	ErrInvalidScriptToken
+
+	// ------------------------------------
+	// Failures related to parsing scripts.
+	// ------------------------------------
+
+	// ErrDeclaredPushTooBig is returned when a data push opcode declares a
+	// push of more than MaxScriptElementSize bytes which are also not left
+	// in the script.  Such a push could never succeed, so it is
+	// distinguished from a script that was merely truncated.
+	ErrDeclaredPushTooBig
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -415,6 +474,14 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrMinimalIf:                          "ErrMinimalIf",
 	ErrWitnessPubKeyType:                  "ErrWitnessPubKeyType",
 	ErrDiscourageUpgradableWitnessProgram: "ErrDiscourageUpgradableWitnessProgram",
+	ErrStepLimitExceeded:                  "ErrStepLimitExceeded",
+	ErrExecutionCanceled:                  "ErrExecutionCanceled",
+	ErrConditionalDepthExceeded:           "ErrConditionalDepthExceeded",
+	ErrNotHTLCScript:                      "ErrNotHTLCScript",
+	ErrInvalidHTLC:                        "ErrInvalidHTLC",
+	ErrMissingPrevOutput:                  "ErrMissingPrevOutput",
+	ErrInvalidScriptToken:                 "ErrInvalidScriptToken",
+	ErrDeclaredPushTooBig:                 "ErrDeclaredPushTooBig",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -425,6 +492,12 @@ func (e ErrorCode) String() string {
 	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
 }
 
+// Error satisfies the error interface so error codes can be used as targets
+// with errors.Is.
+func (e ErrorCode) Error() string {
+	return e.String()
+}
+
 // Error identifies a script-related error.  It is used to indicate three
 // classes of errors:
 // 1) Script execution failures due to violating one of the many requirements
@@ -435,7 +508,8 @@ func (e ErrorCode) String() string {
 // The caller can use type assertions on the returned errors to access the
 // ErrorCode field to ascertain the specific reason for the error.  As an
 // additional convenience, the caller may make use of the IsErrorCode function
-// to check for a specific error code.
+// or errors.Is with an ErrorCode target to check for a specific error code,
+// including when the error has been wrapped.
 type Error struct {
 	ErrorCode   ErrorCode
 	Description string
@@ -446,6 +520,11 @@ func (e Error) Error() string {
 	return e.Description
 }
 
+// Unwrap returns the error code of the error so errors.Is can match it.
+func (e Error) Unwrap() error {
+	return e.ErrorCode
+}
+
 // scriptError creates an Error given a set of arguments.
 func scriptError(c ErrorCode, desc string) Error {
 	return Error{ErrorCode: c, Description: desc}
@@ -454,6 +533,6 @@ func scriptError(c ErrorCode, desc string) Error {
 // IsErrorCode returns whether or not the provided error is a script error with
 // the provided error code.
 func IsErrorCode(err error, c ErrorCode) bool {
-	serr, ok := err.(Error)
-	return ok && serr.ErrorCode == c
+	var serr Error
+	return errors.As(err, &serr) && serr.ErrorCode == c
 }
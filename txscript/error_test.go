@@ -82,6 +82,14 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrMinimalIf, "ErrMinimalIf"},
 		{ErrWitnessPubKeyType, "ErrWitnessPubKeyType"},
 		{ErrDiscourageUpgradableWitnessProgram, "ErrDiscourageUpgradableWitnessProgram"},
+		{ErrStepLimitExceeded, "ErrStepLimitExceeded"},
+		{ErrExecutionCanceled, "ErrExecutionCanceled"},
+		{ErrConditionalDepthExceeded, "ErrConditionalDepthExceeded"},
+		{ErrNotHTLCScript, "ErrNotHTLCScript"},
+		{ErrInvalidHTLC, "ErrInvalidHTLC"},
+		{ErrMissingPrevOutput, "ErrMissingPrevOutput"},
+		{ErrInvalidScriptToken, "ErrInvalidScriptToken"},
+		{ErrDeclaredPushTooBig, "ErrDeclaredPushTooBig"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
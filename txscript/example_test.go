@@ -212,3 +212,40 @@ func ExampleScriptTokenizer() {
 	// Output:
 	// script contains 5 opcode(s)
 }
+
+// This example demonstrates using a script builder to construct a public key
+// script which locks funds to a public key hash until a given block height.
+// The builder takes care of choosing the canonical push for each data element
+// and integer, so there is no need to encode the push opcodes by hand.
+func ExampleScriptBuilder() {
+	const lockHeight = 1500000
+	hash160 := btcutil.Hash160([]byte("example"))
+	script, err := txscript.NewScriptBuilder().
+		AddInt64(lockHeight).AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+		AddOp(txscript.OP_DROP).AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(hash160).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		fmt.Printf("failed to build script: %v\n", err)
+		return
+	}
+	fmt.Printf("Script Hex: %x\n", script)
+
+	disasm, err := txscript.DisasmString(script)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Script Disassembly:", disasm)
+
+	// Attempting to build a script which exceeds the maximum allowed script
+	// size results in an error rather than an unspendable script.
+	_, err = txscript.NewScriptBuilder().
+		AddData(make([]byte, txscript.MaxScriptSize)).Script()
+	fmt.Println("Oversized script rejected:", err != nil)
+
+	// Output:
+	// Script Hex: 0360e316b17576a9149a0796862bc9dc6128b05ae43dd1807759e66e0788ac
+	// Script Disassembly: 60e316 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_DUP OP_HASH160 9a0796862bc9dc6128b05ae43dd1807759e66e07 OP_EQUALVERIFY OP_CHECKSIG
+	// Oversized script rejected: true
+}
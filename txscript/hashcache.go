@@ -5,30 +5,89 @@
 package txscript
 
 import (
+	"bytes"
+	"encoding/binary"
 	"sync"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 	"github.com/dashpay/dashd-go/wire"
 )
 
-// TxSigHashes houses the partial set of sighashes introduced within BIP0143.
-// This partial set of sighashes may be re-used within each input across a
-// transaction when validating all inputs. As a result, validation complexity
-// for SigHashAll can be reduced by a polynomial factor.
+// legacyTxInSize is the serialized size of a transaction input once its
+// signature script has been cleared for the purposes of the original signature
+// hash algorithm: 32-byte previous outpoint hash + 4-byte previous outpoint
+// index + 1-byte varint for the empty script length + 4-byte sequence.
+const legacyTxInSize = chainhash.HashSize + 4 + 1 + 4
+
+// TxSigHashes houses the partial set of sighashes introduced within BIP0143
+// along with the pre-serialized transaction fragments used by the original
+// signature hash algorithm.  This partial set of sighashes may be re-used
+// within each input across a transaction when validating all inputs. As a
+// result, validation complexity for SigHashAll can be reduced by a polynomial
+// factor.
 type TxSigHashes struct {
 	HashPrevOuts chainhash.Hash
 	HashSequence chainhash.Hash
 	HashOutputs  chainhash.Hash
+
+	// legacyInputs houses the serialization of every input of the
+	// transaction with its signature script cleared.  Each input occupies
+	// exactly legacyTxInSize bytes, so the serialization for the input at
+	// index i begins at offset i*legacyTxInSize.
+	//
+	// legacyOutputs houses the serialization of all outputs of the
+	// transaction, including the leading varint output count.
+	//
+	// Together they allow the SigHashAll digest of each input to be
+	// computed without copying and reserializing the entire transaction.
+	legacyInputs  []byte
+	legacyOutputs []byte
 }
 
 // NewTxSigHashes computes, and returns the cached sighashes of the given
 // transaction.
 func NewTxSigHashes(tx *wire.MsgTx) *TxSigHashes {
 	return &TxSigHashes{
-		HashPrevOuts: calcHashPrevOuts(tx),
-		HashSequence: calcHashSequence(tx),
-		HashOutputs:  calcHashOutputs(tx),
+		HashPrevOuts:  calcHashPrevOuts(tx),
+		HashSequence:  calcHashSequence(tx),
+		HashOutputs:   calcHashOutputs(tx),
+		legacyInputs:  calcLegacyInputs(tx),
+		legacyOutputs: calcLegacyOutputs(tx),
+	}
+}
+
+// calcLegacyInputs serializes all inputs of the passed transaction with their
+// signature scripts cleared as required by the original signature hash
+// algorithm.
+func calcLegacyInputs(tx *wire.MsgTx) []byte {
+	b := make([]byte, len(tx.TxIn)*legacyTxInSize)
+	for i, in := range tx.TxIn {
+		offset := i * legacyTxInSize
+		copy(b[offset:], in.PreviousOutPoint.Hash[:])
+		offset += chainhash.HashSize
+		binary.LittleEndian.PutUint32(b[offset:], in.PreviousOutPoint.Index)
+		offset += 4
+
+		// The script length varint is always zero, which the buffer
+		// is already initialized to.
+		offset++
+		binary.LittleEndian.PutUint32(b[offset:], in.Sequence)
 	}
+
+	return b
+}
+
+// calcLegacyOutputs serializes the output count and all outputs of the passed
+// transaction as they appear in the original signature hash algorithm
+// pre-image for SigHashAll.
+func calcLegacyOutputs(tx *wire.MsgTx) []byte {
+	var b bytes.Buffer
+	wire.WriteVarInt(&b, 0, uint64(len(tx.TxOut)))
+	for _, out := range tx.TxOut {
+		wire.WriteTxOut(&b, 0, 0, out)
+	}
+
+	return b.Bytes()
 }
 
 // HashCache houses a set of partial sighashes keyed by txid. The set of partial
@@ -5,7 +5,9 @@
 package txscript
 
 import (
+	"bytes"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -133,7 +135,7 @@ func TestHashCacheAddGet(t *testing.T) {
 
 	// Finally, the sighashes retrieved should exactly match the sighash
 	// originally inserted into the cache.
-	if *sigHashes != *cacheHashes {
+	if !reflect.DeepEqual(sigHashes, cacheHashes) {
 		t.Fatalf("sighashes don't match: expected %v, got %v",
 			spew.Sdump(sigHashes), spew.Sdump(cacheHashes))
 	}
@@ -178,3 +180,44 @@ func TestHashCachePurge(t *testing.T) {
 		}
 	}
 }
+
+// TestCalcSignatureHashCached ensures the signature hashes computed from the
+// cached transaction fragments are identical to those computed by the uncached
+// algorithm for every input and signature hash type.
+func TestCalcSignatureHashCached(t *testing.T) {
+	t.Parallel()
+
+	hashTypes := []SigHashType{
+		SigHashOld, SigHashAll, SigHashNone, SigHashSingle,
+		SigHashAll | SigHashAnyOneCanPay,
+		SigHashNone | SigHashAnyOneCanPay,
+		SigHashSingle | SigHashAnyOneCanPay,
+		0x04, 0x7f,
+	}
+	script := mustParseShortForm("DUP HASH160 DATA_20 0x00000000000000000000" +
+		"00000000000000000000 EQUALVERIFY CODESEPARATOR CHECKSIG")
+
+	for i := 0; i < 20; i++ {
+		tx, err := genTestTx()
+		if err != nil {
+			t.Fatalf("unable to generate test tx: %v", err)
+		}
+		sigHashes := NewTxSigHashes(tx)
+
+		for idx := range tx.TxIn {
+			for _, hashType := range hashTypes {
+				want := calcSignatureHash(script, hashType, tx, idx)
+				got, err := CalcSignatureHashCached(script, sigHashes,
+					hashType, tx, idx)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("mismatched sighash for input %d "+
+						"type %v: got %x, want %x", idx,
+						hashType, got, want)
+				}
+			}
+		}
+	}
+}
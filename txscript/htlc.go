@@ -0,0 +1,252 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+)
+
+// HTLC houses the parameters of a hash time locked contract.  The contract
+// pays to the holder of the key for the recipient hash once the secret whose
+// SHA-256 hash is the secret hash is revealed, or to the holder of the key for
+// the refund hash once the lock time is reached.
+//
+// This is the contract format used by cross-chain atomic swaps:
+//
+//	IF
+//	 SIZE <secret size> EQUALVERIFY SHA256 <32-byte secret hash> EQUALVERIFY
+//	 DUP HASH160 <20-byte recipient hash>
+//	ELSE
+//	 <lock time> CHECKLOCKTIMEVERIFY DROP DUP HASH160 <20-byte refund hash>
+//	ENDIF
+//	EQUALVERIFY CHECKSIG
+//
+// Contracts are not standard scripts and are expected to be paid to using
+// pay-to-script-hash.
+type HTLC struct {
+	// SecretHash is the SHA-256 hash of the secret.
+	SecretHash [32]byte
+
+	// SecretSize is the size of the secret in bytes.  Requiring the size
+	// prevents a secret that can be revealed on one chain from being too
+	// large to be revealed on another.
+	SecretSize int64
+
+	// RecipientHash160 is the hash of the public key that can redeem the
+	// contract with the secret.
+	RecipientHash160 [20]byte
+
+	// RefundHash160 is the hash of the public key that can refund the
+	// contract after the lock time.
+	RefundHash160 [20]byte
+
+	// LockTime is the block height or timestamp, using the same semantics
+	// as the transaction lock time, after which the contract can be
+	// refunded.
+	LockTime int64
+}
+
+// Validate returns an error when the parameters of the contract are out of the
+// range that can be satisfied.
+func (h *HTLC) Validate() error {
+	if h.SecretSize <= 0 || h.SecretSize > MaxScriptElementSize {
+		str := fmt.Sprintf("secret size %d is not in the range [1, %d]",
+			h.SecretSize, MaxScriptElementSize)
+		return scriptError(ErrInvalidHTLC, str)
+	}
+
+	// The lock time is compared against the lock time of the refund
+	// transaction, which is an unsigned 32-bit integer.
+	if h.LockTime <= 0 || h.LockTime > math.MaxUint32 {
+		str := fmt.Sprintf("lock time %d is not in the range [1, %d]",
+			h.LockTime, uint32(math.MaxUint32))
+		return scriptError(ErrInvalidHTLC, str)
+	}
+
+	return nil
+}
+
+// CheckSecret returns whether the passed secret redeems the contract.
+func (h *HTLC) CheckSecret(secret []byte) bool {
+	return int64(len(secret)) == h.SecretSize &&
+		sha256.Sum256(secret) == h.SecretHash
+}
+
+// HTLCScript returns a hash time locked contract script for the passed
+// parameters after validating them.
+func HTLCScript(h *HTLC) ([]byte, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt64(h.SecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(h.SecretHash[:]).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(h.RecipientHash160[:]).
+		AddOp(OP_ELSE).
+		AddInt64(h.LockTime).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(h.RefundHash160[:]).
+		AddOp(OP_ENDIF).
+		AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// extractHTLC returns the parameters of the passed hash time locked contract.
+// If the script is not a contract, (nil, nil) is returned.  Non-nil errors are
+// returned for unparsable scripts and non-canonical integers.
+func extractHTLC(version uint16, script []byte) (*HTLC, error) {
+	type templateMatch struct {
+		expectCanonicalInt bool
+		maxIntBytes        int
+		opcode             byte
+		extractedInt       int64
+		extractedData      []byte
+	}
+	var template = [20]templateMatch{
+		{opcode: OP_IF},
+		{opcode: OP_SIZE},
+		{expectCanonicalInt: true, maxIntBytes: maxScriptNumLen},
+		{opcode: OP_EQUALVERIFY},
+		{opcode: OP_SHA256},
+		{opcode: OP_DATA_32},
+		{opcode: OP_EQUALVERIFY},
+		{opcode: OP_DUP},
+		{opcode: OP_HASH160},
+		{opcode: OP_DATA_20},
+		{opcode: OP_ELSE},
+		{expectCanonicalInt: true, maxIntBytes: cltvMaxScriptNumLen},
+		{opcode: OP_CHECKLOCKTIMEVERIFY},
+		{opcode: OP_DROP},
+		{opcode: OP_DUP},
+		{opcode: OP_HASH160},
+		{opcode: OP_DATA_20},
+		{opcode: OP_ENDIF},
+		{opcode: OP_EQUALVERIFY},
+		{opcode: OP_CHECKSIG},
+	}
+
+	var templateOffset int
+	tokenizer := MakeScriptTokenizer(version, script)
+	for tokenizer.Next() {
+		// Not a contract if it has more opcodes than expected in the
+		// template.
+		if templateOffset >= len(template) {
+			return nil, nil
+		}
+
+		op := tokenizer.Opcode()
+		data := tokenizer.Data()
+		tplEntry := &template[templateOffset]
+		if tplEntry.expectCanonicalInt {
+			switch {
+			case data != nil:
+				val, err := makeScriptNum(data, true, tplEntry.maxIntBytes)
+				if err != nil {
+					return nil, err
+				}
+				tplEntry.extractedInt = int64(val)
+
+			case isSmallInt(op):
+				tplEntry.extractedInt = int64(asSmallInt(op))
+
+			// Not a contract if the opcode does not push an int.
+			default:
+				return nil, nil
+			}
+		} else {
+			if op != tplEntry.opcode {
+				return nil, nil
+			}
+
+			tplEntry.extractedData = data
+		}
+
+		templateOffset++
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+	if !tokenizer.Done() || templateOffset != len(template) {
+		return nil, nil
+	}
+
+	// At this point, the script appears to be a contract, so populate and
+	// return the extracted data.
+	htlc := HTLC{
+		SecretSize: template[2].extractedInt,
+		LockTime:   template[11].extractedInt,
+	}
+	copy(htlc.SecretHash[:], template[5].extractedData)
+	copy(htlc.RecipientHash160[:], template[9].extractedData)
+	copy(htlc.RefundHash160[:], template[16].extractedData)
+	return &htlc, nil
+}
+
+// ExtractHTLC returns the parameters of the passed version 0 hash time locked
+// contract script.  An error with ErrNotHTLCScript is returned when the script
+// is not a contract and one with ErrInvalidHTLC when the contract can never be
+// satisfied, such as when it has a negative lock time.
+func ExtractHTLC(script []byte) (*HTLC, error) {
+	const scriptVersion = 0
+
+	htlc, err := extractHTLC(scriptVersion, script)
+	if err != nil {
+		str := fmt.Sprintf("script is not a hash time locked contract: "+
+			"%v", err)
+		return nil, scriptError(ErrNotHTLCScript, str)
+	}
+	if htlc == nil {
+		str := "script is not a hash time locked contract"
+		return nil, scriptError(ErrNotHTLCScript, str)
+	}
+	if err := htlc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return htlc, nil
+}
+
+// IsHTLCScript returns whether or not the passed script is a valid version 0
+// hash time locked contract.
+func IsHTLCScript(script []byte) bool {
+	_, err := ExtractHTLC(script)
+	return err == nil
+}
+
+// HTLCRedeemSigScript returns the signature script that redeems the passed
+// contract, which is paid to using pay-to-script-hash, by revealing the secret.
+// The signature must be made by the key for the recipient hash and include the
+// hash type.  An error is returned when the contract is invalid or the secret
+// does not redeem it.
+func HTLCRedeemSigScript(contract, sig, pubKey, secret []byte) ([]byte, error) {
+	htlc, err := ExtractHTLC(contract)
+	if err != nil {
+		return nil, err
+	}
+	if !htlc.CheckSecret(secret) {
+		str := "secret does not match the secret hash of the contract"
+		return nil, scriptError(ErrInvalidHTLC, str)
+	}
+
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).
+		AddData(secret).AddInt64(1).AddData(contract).Script()
+}
+
+// HTLCRefundSigScript returns the signature script that refunds the passed
+// contract, which is paid to using pay-to-script-hash, after its lock time.
+// The signature must be made by the key for the refund hash and include the
+// hash type, and the refunding transaction must use the contract lock time or
+// a later one along with a non-final sequence number for the input.
+func HTLCRefundSigScript(contract, sig, pubKey []byte) ([]byte, error) {
+	if _, err := ExtractHTLC(contract); err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).
+		AddInt64(0).AddData(contract).Script()
+}
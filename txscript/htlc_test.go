@@ -0,0 +1,230 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestHTLCScript ensures contracts are built, recognized and extracted as
+// expected and that invalid contracts are rejected.
+func TestHTLCScript(t *testing.T) {
+	t.Parallel()
+
+	secret := bytes.Repeat([]byte{0x01}, 32)
+	htlc := &HTLC{
+		SecretHash: sha256.Sum256(secret),
+		SecretSize: 32,
+		LockTime:   300000,
+	}
+	htlc.RecipientHash160[19] = 0x01
+	htlc.RefundHash160[19] = 0x02
+
+	script, err := HTLCScript(htlc)
+	if err != nil {
+		t.Fatalf("HTLCScript: unexpected error: %v", err)
+	}
+	want := mustParseShortForm(fmt.Sprintf("IF SIZE 32 EQUALVERIFY SHA256 "+
+		"DATA_32 0x%x EQUALVERIFY DUP HASH160 DATA_20 0x%x ELSE 300000 "+
+		"CHECKLOCKTIMEVERIFY DROP DUP HASH160 DATA_20 0x%x ENDIF "+
+		"EQUALVERIFY CHECKSIG", htlc.SecretHash, htlc.RecipientHash160,
+		htlc.RefundHash160))
+	if !bytes.Equal(script, want) {
+		t.Fatalf("HTLCScript: unexpected script - got %x, want %x",
+			script, want)
+	}
+
+	got, err := ExtractHTLC(script)
+	if err != nil {
+		t.Fatalf("ExtractHTLC: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, htlc) {
+		t.Fatalf("ExtractHTLC: got %+v, want %+v", got, htlc)
+	}
+	if !IsHTLCScript(script) {
+		t.Fatalf("IsHTLCScript: contract not recognized")
+	}
+	if !htlc.CheckSecret(secret) || htlc.CheckSecret(secret[1:]) ||
+		htlc.CheckSecret(bytes.Repeat([]byte{0x02}, 32)) {
+
+		t.Fatalf("CheckSecret: unexpected result")
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		code   ErrorCode
+	}{{
+		name: "pay-to-pubkey-hash",
+		script: fmt.Sprintf("DUP HASH160 DATA_20 0x%x EQUALVERIFY "+
+			"CHECKSIG", htlc.RecipientHash160),
+		code: ErrNotHTLCScript,
+	}, {
+		name:   "trailing opcode",
+		script: fmt.Sprintf("0x%x NOP", script),
+		code:   ErrNotHTLCScript,
+	}, {
+		name: "non-minimal secret size",
+		script: fmt.Sprintf("IF SIZE 0x02 0x2000 EQUALVERIFY SHA256 "+
+			"DATA_32 0x%x EQUALVERIFY DUP HASH160 DATA_20 0x%x "+
+			"ELSE 1 CHECKLOCKTIMEVERIFY DROP DUP HASH160 DATA_20 "+
+			"0x%x ENDIF EQUALVERIFY CHECKSIG", htlc.SecretHash,
+			htlc.RecipientHash160, htlc.RefundHash160),
+		code: ErrNotHTLCScript,
+	}, {
+		name: "negative lock time",
+		script: fmt.Sprintf("IF SIZE 32 EQUALVERIFY SHA256 DATA_32 "+
+			"0x%x EQUALVERIFY DUP HASH160 DATA_20 0x%x ELSE 0x01 0x81 "+
+			"CHECKLOCKTIMEVERIFY DROP DUP HASH160 DATA_20 0x%x "+
+			"ENDIF EQUALVERIFY CHECKSIG", htlc.SecretHash,
+			htlc.RecipientHash160, htlc.RefundHash160),
+		code: ErrInvalidHTLC,
+	}, {
+		name: "zero secret size",
+		script: fmt.Sprintf("IF SIZE 0 EQUALVERIFY SHA256 DATA_32 "+
+			"0x%x EQUALVERIFY DUP HASH160 DATA_20 0x%x ELSE 1 "+
+			"CHECKLOCKTIMEVERIFY DROP DUP HASH160 DATA_20 0x%x "+
+			"ENDIF EQUALVERIFY CHECKSIG", htlc.SecretHash,
+			htlc.RecipientHash160, htlc.RefundHash160),
+		code: ErrInvalidHTLC,
+	}}
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		_, err := ExtractHTLC(script)
+		if !IsErrorCode(err, test.code) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.code)
+		}
+		if IsHTLCScript(script) {
+			t.Errorf("%s: script recognized as contract", test.name)
+		}
+	}
+
+	// Contracts that can't be satisfied must not be created.
+	for _, invalid := range []HTLC{
+		{SecretSize: 0, LockTime: 1},
+		{SecretSize: MaxScriptElementSize + 1, LockTime: 1},
+		{SecretSize: 32, LockTime: 0},
+		{SecretSize: 32, LockTime: 1 << 32},
+	} {
+		invalid := invalid
+		_, err := HTLCScript(&invalid)
+		if !IsErrorCode(err, ErrInvalidHTLC) {
+			t.Errorf("HTLCScript(%+v): unexpected error %v", invalid,
+				err)
+		}
+	}
+}
+
+// TestHTLCSpend ensures the signature scripts created for contracts paid to
+// using pay-to-script-hash redeem them with the secret and refund them after
+// the lock time only.
+func TestHTLCSpend(t *testing.T) {
+	t.Parallel()
+
+	recipientKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{1}, 32))
+	refundKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{2}, 32))
+	recipientPubKey := recipientKey.PubKey().SerializeCompressed()
+	refundPubKey := refundKey.PubKey().SerializeCompressed()
+	secret := bytes.Repeat([]byte{0x03}, 32)
+
+	htlc := &HTLC{
+		SecretHash: sha256.Sum256(secret),
+		SecretSize: int64(len(secret)),
+		LockTime:   500,
+	}
+	copy(htlc.RecipientHash160[:], btcutil.Hash160(recipientPubKey))
+	copy(htlc.RefundHash160[:], btcutil.Hash160(refundPubKey))
+	contract, err := HTLCScript(htlc)
+	if err != nil {
+		t.Fatalf("HTLCScript: unexpected error: %v", err)
+	}
+	pkScript, err := payToScriptHashScript(btcutil.Hash160(contract))
+	if err != nil {
+		t.Fatalf("payToScriptHashScript: unexpected error: %v", err)
+	}
+
+	// spend returns whether a transaction with the passed lock time can
+	// spend the contract using the signature script returned by the
+	// passed function.
+	spend := func(lockTime uint32, key *btcec.PrivateKey,
+		sigScript func(sig []byte) ([]byte, error)) error {
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{Sequence: 0})
+		tx.AddTxOut(&wire.TxOut{Value: 1000})
+		tx.LockTime = lockTime
+
+		sig, err := RawTxInSignature(tx, 0, contract, SigHashAll, key)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[0].SignatureScript, err = sigScript(sig)
+		if err != nil {
+			return err
+		}
+
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			nil, 2000)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+	redeem := func(pubKey, secret []byte) func([]byte) ([]byte, error) {
+		return func(sig []byte) ([]byte, error) {
+			return HTLCRedeemSigScript(contract, sig, pubKey, secret)
+		}
+	}
+	refund := func(pubKey []byte) func([]byte) ([]byte, error) {
+		return func(sig []byte) ([]byte, error) {
+			return HTLCRefundSigScript(contract, sig, pubKey)
+		}
+	}
+
+	// The recipient redeems the contract with the secret at any time.
+	err = spend(0, recipientKey, redeem(recipientPubKey, secret))
+	if err != nil {
+		t.Fatalf("redeem: unexpected error: %v", err)
+	}
+
+	// A wrong secret is rejected when creating the signature script.
+	wrongSecret := bytes.Repeat([]byte{0x04}, 32)
+	err = spend(0, recipientKey, redeem(recipientPubKey,
+		wrongSecret))
+	if !IsErrorCode(err, ErrInvalidHTLC) {
+		t.Fatalf("redeem with wrong secret: unexpected error: %v", err)
+	}
+
+	// The refund key can't redeem the contract even with the secret.
+	err = spend(0, refundKey, redeem(refundPubKey, secret))
+	if !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("redeem with refund key: unexpected error: %v", err)
+	}
+
+	// The refund is only possible once the lock time is reached.
+	err = spend(uint32(htlc.LockTime)-1, refundKey, refund(refundPubKey))
+	if !IsErrorCode(err, ErrUnsatisfiedLockTime) {
+		t.Fatalf("early refund: unexpected error: %v", err)
+	}
+	err = spend(uint32(htlc.LockTime), refundKey, refund(refundPubKey))
+	if err != nil {
+		t.Fatalf("refund: unexpected error: %v", err)
+	}
+
+	// The recipient key can't use the refund path.
+	err = spend(uint32(htlc.LockTime), recipientKey, refund(recipientPubKey))
+	if !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("refund with recipient key: unexpected error: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -807,8 +808,7 @@ func opcodeIf(op *opcode, data []byte, vm *Engine) error {
 	} else {
 		condVal = OpCondSkip
 	}
-	vm.condStack = append(vm.condStack, condVal)
-	return nil
+	return vm.pushCondition(condVal)
 }
 
 // opcodeNotIf treats the top item on the data stack as a boolean and removes
@@ -841,8 +841,7 @@ func opcodeNotIf(op *opcode, data []byte, vm *Engine) error {
 	} else {
 		condVal = OpCondSkip
 	}
-	vm.condStack = append(vm.condStack, condVal)
-	return nil
+	return vm.pushCondition(condVal)
 }
 
 // opcodeElse inverts conditional execution for other half of if/else/endif.
@@ -1299,6 +1298,11 @@ func opcodeEqual(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	if vm.hasFlag(ScriptConstantTimeEqual) {
+		vm.dstack.PushBool(subtle.ConstantTimeCompare(a, b) == 1)
+		return nil
+	}
+
 	vm.dstack.PushBool(bytes.Equal(a, b))
 	return nil
 }
@@ -1927,7 +1931,8 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		// to sign itself.
 		subScript = removeOpcodeByData(subScript, fullSigBytes)
 
-		hash = calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx)
+		hash = calcSignatureHashCached(subScript, vm.hashCache, hashType,
+			&vm.tx, vm.txIdx)
 	}
 
 	pubKey, err := btcec.ParsePubKey(pkBytes)
@@ -2194,7 +2199,8 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 				return err
 			}
 		} else {
-			hash = calcSignatureHash(script, hashType, &vm.tx, vm.txIdx)
+			hash = calcSignatureHashCached(script, vm.hashCache,
+				hashType, &vm.tx, vm.txIdx)
 		}
 
 		var valid bool
@@ -8,10 +8,8 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
-	"strconv"
 	"strings"
 	"testing"
 
@@ -49,14 +47,6 @@ func scriptTestName(test []interface{}) (string, error) {
 	return name, nil
 }
 
-// parse hex string into a []byte.
-func parseHex(tok string) ([]byte, error) {
-	if !strings.HasPrefix(tok, "0x") {
-		return nil, errors.New("not a hex number")
-	}
-	return hex.DecodeString(tok[2:])
-}
-
 // parseWitnessStack parses a json array of witness items encoded as hex into a
 // slice of witness elements.
 func parseWitnessStack(elements []interface{}) ([][]byte, error) {
@@ -73,81 +63,6 @@ func parseWitnessStack(elements []interface{}) ([][]byte, error) {
 	return witness, nil
 }
 
-// shortFormOps holds a map of opcode names to values for use in short form
-// parsing.  It is declared here so it only needs to be created once.
-var shortFormOps map[string]byte
-
-// parseShortForm parses a string as as used in the Bitcoin Core reference tests
-// into the script it came from.
-//
-// The format used for these tests is pretty simple if ad-hoc:
-//   - Opcodes other than the push opcodes and unknown are present as
-//     either OP_NAME or just NAME
-//   - Plain numbers are made into push operations
-//   - Numbers beginning with 0x are inserted into the []byte as-is (so
-//     0x14 is OP_DATA_20)
-//   - Single quoted strings are pushed as data
-//   - Anything else is an error
-func parseShortForm(script string) ([]byte, error) {
-	// Only create the short form opcode map once.
-	if shortFormOps == nil {
-		ops := make(map[string]byte)
-		for opcodeName, opcodeValue := range OpcodeByName {
-			if strings.Contains(opcodeName, "OP_UNKNOWN") {
-				continue
-			}
-			ops[opcodeName] = opcodeValue
-
-			// The opcodes named OP_# can't have the OP_ prefix
-			// stripped or they would conflict with the plain
-			// numbers.  Also, since OP_FALSE and OP_TRUE are
-			// aliases for the OP_0, and OP_1, respectively, they
-			// have the same value, so detect those by name and
-			// allow them.
-			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
-				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
-					opcodeValue > OP_16)) {
-
-				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
-			}
-		}
-		shortFormOps = ops
-	}
-
-	// Split only does one separator so convert all \n and tab into  space.
-	script = strings.Replace(script, "\n", " ", -1)
-	script = strings.Replace(script, "\t", " ", -1)
-	tokens := strings.Split(script, " ")
-	builder := NewScriptBuilder()
-
-	for _, tok := range tokens {
-		if len(tok) == 0 {
-			continue
-		}
-		// if parses as a plain number
-		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
-			builder.AddInt64(num)
-			continue
-		} else if bts, err := parseHex(tok); err == nil {
-			// Concatenate the bytes manually since the test code
-			// intentionally creates scripts that are too large and
-			// would cause the builder to error otherwise.
-			if builder.err == nil {
-				builder.script = append(builder.script, bts...)
-			}
-		} else if len(tok) >= 2 &&
-			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
-			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
-		} else if opcode, ok := shortFormOps[tok]; ok {
-			builder.AddOp(opcode)
-		} else {
-			return nil, fmt.Errorf("bad token %q", tok)
-		}
-
-	}
-	return builder.Script()
-}
-
 // parseScriptFlags parses the provided flags string from the format used in the
 // reference tests into ScriptFlags suitable for use in the script engine.
 func parseScriptFlags(flagStr string) (ScriptFlags, error) {
@@ -175,7 +90,7 @@ func parseScriptFlags(flagStr string) (ScriptFlags, error) {
 		case "NONE":
 			// Nothing.
 		case "NULLDUMMY":
-			flags |= ScriptStrictMultiSig
+			flags |= ScriptVerifyNullDummy
 		case "NULLFAIL":
 			flags |= ScriptVerifyNullFail
 		case "P2SH":
@@ -235,7 +150,8 @@ func parseExpectedResult(expected string) ([]ErrorCode, error) {
 	case "CLEANSTACK":
 		return []ErrorCode{ErrCleanStack}, nil
 	case "BAD_OPCODE":
-		return []ErrorCode{ErrReservedOpcode, ErrMalformedPush}, nil
+		return []ErrorCode{ErrReservedOpcode, ErrMalformedPush,
+			ErrDeclaredPushTooBig}, nil
 	case "UNBALANCED_CONDITIONAL":
 		return []ErrorCode{ErrUnbalancedConditional,
 			ErrInvalidStackOperation}, nil
@@ -388,7 +304,7 @@ func testScripts(t *testing.T, tests [][]interface{}, useSigCache bool) {
 			t.Errorf("%s: signature script is not a string", name)
 			continue
 		}
-		scriptSig, err := parseShortForm(scriptSigStr)
+		scriptSig, err := ParseShortForm(scriptSigStr)
 		if err != nil {
 			t.Errorf("%s: can't parse signature script: %v", name,
 				err)
@@ -401,7 +317,7 @@ func testScripts(t *testing.T, tests [][]interface{}, useSigCache bool) {
 			t.Errorf("%s: public key script is not a string", name)
 			continue
 		}
-		scriptPubKey, err := parseShortForm(scriptPubKeyStr)
+		scriptPubKey, err := ParseShortForm(scriptPubKeyStr)
 		if err != nil {
 			t.Errorf("%s: can't parse public key script: %v", name,
 				err)
@@ -616,7 +532,7 @@ testloop:
 				continue testloop
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := ParseShortForm(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
@@ -771,7 +687,7 @@ testloop:
 				continue
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := ParseShortForm(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
@@ -7,6 +7,7 @@ package txscript
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"strings"
@@ -484,10 +485,11 @@ func shallowCopyTx(tx *wire.MsgTx) wire.MsgTx {
 	// pointers into the contiguous arrays.  This avoids a lot of small
 	// allocations.
 	txCopy := wire.MsgTx{
-		Version:  tx.Version,
-		TxIn:     make([]*wire.TxIn, len(tx.TxIn)),
-		TxOut:    make([]*wire.TxOut, len(tx.TxOut)),
-		LockTime: tx.LockTime,
+		Version:      tx.Version,
+		TxIn:         make([]*wire.TxIn, len(tx.TxIn)),
+		TxOut:        make([]*wire.TxOut, len(tx.TxOut)),
+		LockTime:     tx.LockTime,
+		ExtraPayload: tx.ExtraPayload,
 	}
 	txIns := make([]wire.TxIn, len(tx.TxIn))
 	for i, oldTxIn := range tx.TxIn {
@@ -506,6 +508,16 @@ func shallowCopyTx(tx *wire.MsgTx) wire.MsgTx {
 // engine instance, calculate the signature hash to be used for signing and
 // verification.
 //
+// All combinations of SigHashAll, SigHashNone and SigHashSingle with and
+// without SigHashAnyOneCanPay are supported, and undefined base types are
+// treated as SigHashAll in the same way as consensus does.  Note that, for
+// compatibility with the reference implementation, a SigHashSingle input with
+// no corresponding output does not produce an error, but rather the hash of
+// the value one, which anybody can produce a valid signature for.
+//
+// An Error with the error code ErrInvalidIndex is returned when idx does not
+// refer to an input of the transaction.
+//
 // NOTE: This function is only valid for version 0 scripts. Since the function
 // does not accept a script version, the results are undefined for other script
 // versions.
@@ -514,10 +526,107 @@ func CalcSignatureHash(script []byte, hashType SigHashType, tx *wire.MsgTx, idx
 	if err := checkScriptParses(scriptVersion, script); err != nil {
 		return nil, err
 	}
+	if err := checkSigHashInputIndex(tx, idx); err != nil {
+		return nil, err
+	}
 
 	return calcSignatureHash(script, hashType, tx, idx), nil
 }
 
+// checkSigHashInputIndex returns an error when the passed index does not refer
+// to an input of the passed transaction.
+func checkSigHashInputIndex(tx *wire.MsgTx, idx int) error {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", idx, len(tx.TxIn))
+		return scriptError(ErrInvalidIndex, str)
+	}
+	return nil
+}
+
+// CalcSignatureHashCached is identical to CalcSignatureHash except it makes
+// use of the pre-serialized transaction fragments within the passed sighashes,
+// which must have been computed for the same transaction, in order to avoid
+// copying and reserializing the entire transaction for every input.  A nil
+// sighashes falls back to the uncached calculation.
+//
+// NOTE: This function is only valid for version 0 scripts. Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func CalcSignatureHashCached(script []byte, sigHashes *TxSigHashes,
+	hashType SigHashType, tx *wire.MsgTx, idx int) ([]byte, error) {
+
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+	if err := checkSigHashInputIndex(tx, idx); err != nil {
+		return nil, err
+	}
+
+	return calcSignatureHashCached(script, sigHashes, hashType, tx, idx), nil
+}
+
+// calcSignatureHashCached computes the signature hash for the specified input
+// of the target transaction observing the desired signature hash type.  When
+// the hash type commits to every input and output (SigHashAll and the undefined
+// types consensus treats the same way), the digest is computed directly from
+// the fragments cached within the passed sighashes, which reduces the cost of
+// validating all inputs of a transaction from O(N^2) serialization work to a
+// single serialization.  All other cases defer to calcSignatureHash.
+func calcSignatureHashCached(sigScript []byte, sigHashes *TxSigHashes,
+	hashType SigHashType, tx *wire.MsgTx, idx int) []byte {
+
+	// Only the full commitment case can make use of the cached fragments
+	// since the others modify the sequence numbers and outputs or strip
+	// the other inputs entirely.
+	if sigHashes == nil || hashType&SigHashAnyOneCanPay != 0 ||
+		hashType&sigHashMask == SigHashNone ||
+		hashType&sigHashMask == SigHashSingle ||
+		len(sigHashes.legacyInputs) != len(tx.TxIn)*legacyTxInSize {
+
+		return calcSignatureHash(sigScript, hashType, tx, idx)
+	}
+
+	// Remove all instances of OP_CODESEPARATOR from the script.
+	sigScript = removeOpcodeRaw(sigScript, OP_CODESEPARATOR)
+
+	// The pre-image is the serialized transaction with all signature
+	// scripts cleared except for the input being signed, which is replaced
+	// with the passed script, followed by the hash type.  Since every
+	// cleared input is of a fixed size, the cached inputs are written on
+	// either side of the input being signed.
+	h := sha256.New()
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], uint32(tx.Version))
+	h.Write(scratch[:])
+	wire.WriteVarInt(h, 0, uint64(len(tx.TxIn)))
+
+	start := idx * legacyTxInSize
+	h.Write(sigHashes.legacyInputs[:start])
+	txIn := tx.TxIn[idx]
+	h.Write(txIn.PreviousOutPoint.Hash[:])
+	binary.LittleEndian.PutUint32(scratch[:], txIn.PreviousOutPoint.Index)
+	h.Write(scratch[:])
+	wire.WriteVarBytes(h, 0, sigScript)
+	binary.LittleEndian.PutUint32(scratch[:], txIn.Sequence)
+	h.Write(scratch[:])
+	h.Write(sigHashes.legacyInputs[start+legacyTxInSize:])
+
+	h.Write(sigHashes.legacyOutputs)
+	binary.LittleEndian.PutUint32(scratch[:], tx.LockTime)
+	h.Write(scratch[:])
+	if tx.IsSpecial() {
+		wire.WriteVarBytes(h, 0, tx.ExtraPayload)
+	}
+	binary.LittleEndian.PutUint32(scratch[:], uint32(hashType))
+	h.Write(scratch[:])
+
+	first := h.Sum(nil)
+	second := sha256.Sum256(first)
+	return second[:]
+}
+
 // calcSignatureHash computes the signature hash for the specified input of the
 // target transaction observing the desired signature hash type.
 func calcSignatureHash(sigScript []byte, hashType SigHashType, tx *wire.MsgTx, idx int) []byte {
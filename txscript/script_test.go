@@ -169,6 +169,99 @@ func TestGetPreciseSigOps(t *testing.T) {
 	}
 }
 
+// TestGetSigOpCount ensures the quick and precise signature operation counting
+// mechanisms count the various signature checking opcodes as expected,
+// including those in P2SH redeem scripts.
+func TestGetSigOpCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		script  string
+		quick   int
+		precise int
+	}{{
+		name:    "empty script",
+		script:  "",
+		quick:   0,
+		precise: 0,
+	}, {
+		name: "p2pkh",
+		script: "DUP HASH160 DATA_20 0x433ec2ac1ffa1b7b7d027f564529c57197f" +
+			"9ae88 EQUALVERIFY CHECKSIG",
+		quick:   1,
+		precise: 1,
+	}, {
+		name:    "checksig and checksigverify",
+		script:  "CHECKSIGVERIFY CHECKSIG",
+		quick:   2,
+		precise: 2,
+	}, {
+		name:    "2-of-3 multisig",
+		script:  "2 0 0 0 3 CHECKMULTISIG",
+		quick:   MaxPubKeysPerMultiSig,
+		precise: 3,
+	}, {
+		name:    "16 key multisigverify",
+		script:  "1 16 CHECKMULTISIGVERIFY",
+		quick:   MaxPubKeysPerMultiSig,
+		precise: 16,
+	}, {
+		name:    "zero key multisig counts as max",
+		script:  "0 0 CHECKMULTISIG",
+		quick:   MaxPubKeysPerMultiSig,
+		precise: MaxPubKeysPerMultiSig,
+	}, {
+		name:    "multisig without small int key count",
+		script:  "CHECKMULTISIG",
+		quick:   MaxPubKeysPerMultiSig,
+		precise: MaxPubKeysPerMultiSig,
+	}, {
+		name:    "sigops in non-executed branch",
+		script:  "0 IF CHECKSIG ENDIF",
+		quick:   1,
+		precise: 1,
+	}, {
+		name:    "count up to parse failure",
+		script:  "CHECKSIG PUSHDATA1 0x02",
+		quick:   1,
+		precise: 1,
+	}}
+
+	p2shScript := mustParseShortForm("HASH160 DATA_20 0x433ec2ac1ffa1b7b7d0" +
+		"27f564529c57197f9ae88 EQUAL")
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		if count := GetSigOpCount(script); count != test.quick {
+			t.Errorf("%s: GetSigOpCount: got %d, want %d", test.name,
+				count, test.quick)
+		}
+
+		// The precise count applies to both public key scripts and to
+		// redeem scripts pushed by the signature script of a P2SH
+		// spend.
+		count := GetPreciseSigOpCount(nil, script, true)
+		if count != test.precise {
+			t.Errorf("%s: GetPreciseSigOpCount: got %d, want %d",
+				test.name, count, test.precise)
+		}
+		if len(script) == 0 || len(script) > MaxScriptElementSize {
+			continue
+		}
+		sigScript, err := NewScriptBuilder().AddOp(OP_0).
+			AddData(script).Script()
+		if err != nil {
+			t.Fatalf("%s: unable to build signature script: %v",
+				test.name, err)
+		}
+		count = GetPreciseSigOpCount(sigScript, p2shScript, true)
+		if count != test.precise {
+			t.Errorf("%s: GetPreciseSigOpCount (p2sh): got %d, "+
+				"want %d", test.name, count, test.precise)
+		}
+	}
+}
+
 // TestGetWitnessSigOpCount tests that the sig op counting for p2wkh, p2wsh,
 // nested p2sh, and invalid variants are counted properly.
 func TestGetWitnessSigOpCount(t *testing.T) {
@@ -657,3 +750,49 @@ func TestIsUnspendable(t *testing.T) {
 		}
 	}
 }
+
+// TestCalcSignatureHashSingleAndIndex ensures CalcSignatureHash produces the
+// hash of the value one for SigHashSingle inputs without a corresponding
+// output and rejects indices which do not refer to a transaction input.
+func TestCalcSignatureHashSingleAndIndex(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{
+			{Sequence: wire.MaxTxInSequenceNum},
+			{Sequence: wire.MaxTxInSequenceNum},
+		},
+		TxOut: []*wire.TxOut{{Value: 1}},
+	}
+	script := mustParseShortForm("TRUE")
+
+	want := make([]byte, 32)
+	want[0] = 0x01
+	for _, hashType := range []SigHashType{SigHashSingle,
+		SigHashSingle | SigHashAnyOneCanPay} {
+
+		got, err := CalcSignatureHash(script, hashType, tx, 1)
+		if err != nil {
+			t.Fatalf("CalcSignatureHash: unexpected error: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("unexpected hash for out of range SigHashSingle "+
+				"(%v) - got %x, want %x", hashType, got, want)
+		}
+	}
+
+	for _, idx := range []int{-1, 2} {
+		_, err := CalcSignatureHash(script, SigHashAll, tx, idx)
+		if !IsErrorCode(err, ErrInvalidIndex) {
+			t.Fatalf("CalcSignatureHash(idx %d): unexpected error "+
+				"%v", idx, err)
+		}
+		_, err = CalcSignatureHashCached(script, NewTxSigHashes(tx),
+			SigHashAll, tx, idx)
+		if !IsErrorCode(err, ErrInvalidIndex) {
+			t.Fatalf("CalcSignatureHashCached(idx %d): unexpected "+
+				"error %v", idx, err)
+		}
+	}
+}
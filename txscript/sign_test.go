@@ -1605,6 +1605,56 @@ var sigScriptTests = []tstSigScript{
 	},
 }
 
+// TestSignSpecialTxOutput ensures the signatures produced by SignTxOutput for
+// the inputs of a DIP2 special transaction commit to its extra payload.
+func TestSignSpecialTxOutput(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: int32(wire.TxTypeCoinbase)<<16 | wire.SpecialTxVersion,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 0},
+			Sequence:         wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{
+			Value: 1,
+		}},
+		ExtraPayload: []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00},
+	}
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	address, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(key.PubKey().SerializeCompressed()),
+		&chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkScript, err := PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+
+	kdb := mkGetKey(map[string]addressToKey{
+		address.EncodeAddress(): {key, true},
+	})
+	err = signAndCheck("special tx", tx, 0, 1, pkScript, SigHashAll, kdb,
+		mkGetScript(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Altering the payload must invalidate the signature.
+	tx.ExtraPayload[0] = 0x01
+	err = checkScripts("modified payload", tx, 0, 1,
+		tx.TxIn[0].SignatureScript, pkScript)
+	if err == nil {
+		t.Fatal("signature remained valid after modifying the payload")
+	}
+}
+
 // Test the sigscript generation for valid and invalid inputs, all
 // hashTypes, and with and without compression.  This test creates
 // sigscripts to spend fake coinbase inputs, as sigscripts cannot be
@@ -347,6 +347,22 @@ func (s *stack) RollN(n int32) error {
 	return nil
 }
 
+// ForEach calls fn for each item on the stack from the top down, where i is the
+// index of the item as accepted by PeekByteArray.  Iteration stops at the first
+// error returned by fn, which is then returned.
+//
+// The items must not be modified since they may be shared with other items on
+// the stack.
+func (s *stack) ForEach(fn func(i int, item []byte) error) error {
+	for i := len(s.stk) - 1; i >= 0; i-- {
+		if err := fn(len(s.stk)-i-1, s.stk[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // String returns the stack in a readable format.
 func (s *stack) String() string {
 	var result string
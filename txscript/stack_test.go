@@ -948,3 +948,94 @@ func TestStack(t *testing.T) {
 		}
 	}
 }
+
+// TestStackForEach ensures ForEach visits the stack items from the top down
+// with indices matching PeekByteArray and stops at the first callback error.
+func TestStackForEach(t *testing.T) {
+	t.Parallel()
+
+	s := stack{}
+	setStack(&s, [][]byte{{1}, {2}, {3}})
+
+	var visited [][]byte
+	err := s.ForEach(func(i int, item []byte) error {
+		want, err := s.PeekByteArray(int32(i))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(item, want) {
+			t.Errorf("item %d: got %x, want %x", i, item, want)
+		}
+		visited = append(visited, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]byte{{3}, {2}, {1}}
+	if !reflect.DeepEqual(visited, want) {
+		t.Fatalf("wrong items visited - got %x, want %x", visited, want)
+	}
+
+	// Ensure iteration stops at the first error and that it is returned
+	// unmodified.
+	errStop := errors.New("stop")
+	var calls int
+	err = s.ForEach(func(i int, item []byte) error {
+		calls++
+		if i == 1 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("wrong error - got %v, want %v", err, errStop)
+	}
+	if calls != 2 {
+		t.Fatalf("wrong number of calls - got %d, want 2", calls)
+	}
+
+	// Ensure an empty stack never invokes the callback.
+	err = (&stack{}).ForEach(func(int, []byte) error {
+		t.Fatal("callback invoked for empty stack")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestStackString ensures the readable stack format dumps each item and marks
+// empty items.
+func TestStackString(t *testing.T) {
+	t.Parallel()
+
+	s := stack{}
+	setStack(&s, [][]byte{{}, {0xab}})
+
+	want := "00000000  <empty>\n" +
+		"00000000  ab                                                |.|\n"
+	if got := s.String(); got != want {
+		t.Fatalf("wrong string - got %q, want %q", got, want)
+	}
+}
+
+// TestStackErrorsIs ensures stack errors can be matched by error code with
+// errors.Is and IsErrorCode, including when wrapped.
+func TestStackErrorsIs(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&stack{}).PeekByteArray(0)
+	wrapped := fmt.Errorf("inspect: %w", err)
+	for _, err := range []error{err, wrapped} {
+		if !errors.Is(err, ErrInvalidStackOperation) {
+			t.Errorf("errors.Is failed to match %v", err)
+		}
+		if errors.Is(err, ErrEmptyStack) {
+			t.Errorf("errors.Is matched wrong code for %v", err)
+		}
+		if !IsErrorCode(err, ErrInvalidStackOperation) {
+			t.Errorf("IsErrorCode failed to match %v", err)
+		}
+	}
+}
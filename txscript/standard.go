@@ -42,7 +42,8 @@ const (
 		ScriptVerifyWitness |
 		ScriptVerifyDiscourageUpgradeableWitnessProgram |
 		ScriptVerifyMinimalIf |
-		ScriptVerifyWitnessPubKeyType
+		ScriptVerifyWitnessPubKeyType |
+		ScriptVerifyNoTrailingData
 )
 
 // ScriptClass is an enumeration for the list of standard types of script.
@@ -827,8 +828,22 @@ func NullDataScript(data []byte) ([]byte, error) {
 // MultiSigScript returns a valid script for a multisignature redemption where
 // nrequired of the keys in pubkeys are required to have signed the transaction
 // for success.  An Error with the error code ErrTooManyRequiredSigs will be
-// returned if nrequired is larger than the number of keys provided.
+// returned if nrequired is larger than the number of keys provided, while
+// ErrInvalidPubKeyCount and ErrInvalidSignatureCount are returned when more
+// than MaxPubKeysPerMultiSig keys are provided or nrequired is not positive,
+// respectively.
 func MultiSigScript(pubkeys []*btcutil.AddressPubKey, nrequired int) ([]byte, error) {
+	if len(pubkeys) > MaxPubKeysPerMultiSig {
+		str := fmt.Sprintf("unable to generate multisig script with "+
+			"%d public keys which exceeds the max allowed of %d",
+			len(pubkeys), MaxPubKeysPerMultiSig)
+		return nil, scriptError(ErrInvalidPubKeyCount, str)
+	}
+	if nrequired < 1 {
+		str := fmt.Sprintf("unable to generate multisig script with "+
+			"%d required signatures", nrequired)
+		return nil, scriptError(ErrInvalidSignatureCount, str)
+	}
 	if len(pubkeys) < nrequired {
 		str := fmt.Sprintf("unable to generate multisig script with "+
 			"%d required signatures when there are only %d public "+
@@ -990,101 +1005,20 @@ type AtomicSwapDataPushes struct {
 // This function is only defined in the txscript package due to API limitations
 // which prevent callers using txscript to parse nonstandard scripts.
 //
-// DEPRECATED.  This will be removed in the next major version bump.  The error
-// should also likely be removed if the code is reimplemented by any callers
-// since any errors result in a nil result anyway.
+// DEPRECATED.  This will be removed in the next major version bump.  Use
+// ExtractHTLC instead.
 func ExtractAtomicSwapDataPushes(version uint16, pkScript []byte) (*AtomicSwapDataPushes, error) {
-	// An atomic swap is of the form:
-	//  IF
-	//   SIZE <secret size> EQUALVERIFY SHA256 <32-byte secret> EQUALVERIFY DUP
-	//   HASH160 <20-byte recipient hash>
-	//  ELSE
-	//   <locktime> CHECKLOCKTIMEVERIFY DROP DUP HASH160 <20-byte refund hash>
-	//  ENDIF
-	//  EQUALVERIFY CHECKSIG
-	type templateMatch struct {
-		expectCanonicalInt bool
-		maxIntBytes        int
-		opcode             byte
-		extractedInt       int64
-		extractedData      []byte
-	}
-	var template = [20]templateMatch{
-		{opcode: OP_IF},
-		{opcode: OP_SIZE},
-		{expectCanonicalInt: true, maxIntBytes: maxScriptNumLen},
-		{opcode: OP_EQUALVERIFY},
-		{opcode: OP_SHA256},
-		{opcode: OP_DATA_32},
-		{opcode: OP_EQUALVERIFY},
-		{opcode: OP_DUP},
-		{opcode: OP_HASH160},
-		{opcode: OP_DATA_20},
-		{opcode: OP_ELSE},
-		{expectCanonicalInt: true, maxIntBytes: cltvMaxScriptNumLen},
-		{opcode: OP_CHECKLOCKTIMEVERIFY},
-		{opcode: OP_DROP},
-		{opcode: OP_DUP},
-		{opcode: OP_HASH160},
-		{opcode: OP_DATA_20},
-		{opcode: OP_ENDIF},
-		{opcode: OP_EQUALVERIFY},
-		{opcode: OP_CHECKSIG},
-	}
-
-	var templateOffset int
-	tokenizer := MakeScriptTokenizer(version, pkScript)
-	for tokenizer.Next() {
-		// Not an atomic swap script if it has more opcodes than expected in the
-		// template.
-		if templateOffset >= len(template) {
-			return nil, nil
-		}
-
-		op := tokenizer.Opcode()
-		data := tokenizer.Data()
-		tplEntry := &template[templateOffset]
-		if tplEntry.expectCanonicalInt {
-			switch {
-			case data != nil:
-				val, err := makeScriptNum(data, true, tplEntry.maxIntBytes)
-				if err != nil {
-					return nil, err
-				}
-				tplEntry.extractedInt = int64(val)
-
-			case isSmallInt(op):
-				tplEntry.extractedInt = int64(asSmallInt(op))
-
-			// Not an atomic swap script if the opcode does not push an int.
-			default:
-				return nil, nil
-			}
-		} else {
-			if op != tplEntry.opcode {
-				return nil, nil
-			}
-
-			tplEntry.extractedData = data
-		}
-
-		templateOffset++
-	}
-	if err := tokenizer.Err(); err != nil {
+	htlc, err := extractHTLC(version, pkScript)
+	if htlc == nil || err != nil {
 		return nil, err
 	}
-	if !tokenizer.Done() || templateOffset != len(template) {
-		return nil, nil
-	}
 
-	// At this point, the script appears to be an atomic swap, so populate and
-	// return the extacted data.
 	pushes := AtomicSwapDataPushes{
-		SecretSize: template[2].extractedInt,
-		LockTime:   template[11].extractedInt,
+		RecipientHash160: htlc.RecipientHash160,
+		RefundHash160:    htlc.RefundHash160,
+		SecretHash:       htlc.SecretHash,
+		SecretSize:       htlc.SecretSize,
+		LockTime:         htlc.LockTime,
 	}
-	copy(pushes.SecretHash[:], template[5].extractedData)
-	copy(pushes.RecipientHash160[:], template[9].extractedData)
-	copy(pushes.RefundHash160[:], template[16].extractedData)
 	return &pushes, nil
 }
@@ -21,7 +21,7 @@ import (
 // tests as a helper since the only way it can fail is if there is an error in
 // the test source code.
 func mustParseShortForm(script string) []byte {
-	s, err := parseShortForm(script)
+	s, err := ParseShortForm(script)
 	if err != nil {
 		panic("invalid short form script in test source: err " +
 			err.Error() + ", script: " + script)
@@ -741,6 +741,11 @@ func TestMultiSigScript(t *testing.T) {
 			err)
 	}
 
+	tooManyKeys := make([]*btcutil.AddressPubKey, MaxPubKeysPerMultiSig+1)
+	for i := range tooManyKeys {
+		tooManyKeys[i] = p2pkCompressedMain
+	}
+
 	tests := []struct {
 		keys      []*btcutil.AddressPubKey
 		nrequired int
@@ -799,6 +804,20 @@ func TestMultiSigScript(t *testing.T) {
 			"",
 			scriptError(ErrTooManyRequiredSigs, ""),
 		},
+		{
+			[]*btcutil.AddressPubKey{
+				p2pkCompressedMain,
+			},
+			0,
+			"",
+			scriptError(ErrInvalidSignatureCount, ""),
+		},
+		{
+			tooManyKeys,
+			1,
+			"",
+			scriptError(ErrInvalidPubKeyCount, ""),
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+)
+
+// MatchTemplate returns whether or not the passed version 0 script matches the
+// passed template along with the data matched by the placeholders of the
+// template, in the order they appear.
+//
+// A template is a script in which every opcode and data push must appear in
+// the script exactly, except for the following placeholder opcodes:
+//   - OP_PUBKEYHASH matches a canonical push of 20 bytes, such as a public key
+//     hash or script hash
+//   - OP_PUBKEY matches a canonical push of a strictly encoded public key
+//
+// For example, the template OP_DUP OP_HASH160 OP_PUBKEYHASH OP_EQUALVERIFY
+// OP_CHECKSIG matches any standard pay-to-pubkey-hash script and returns its
+// public key hash.  This allows watch-only wallets to detect payments to
+// arbitrary user-supplied script patterns.
+//
+// Scripts and templates that fail to parse never match.
+func MatchTemplate(script, template []byte) ([][]byte, bool) {
+	const scriptVersion = 0
+
+	var matches [][]byte
+	scriptTokenizer := MakeScriptTokenizer(scriptVersion, script)
+	templateTokenizer := MakeScriptTokenizer(scriptVersion, template)
+	for templateTokenizer.Next() {
+		if !scriptTokenizer.Next() {
+			return nil, false
+		}
+		op, data := scriptTokenizer.Opcode(), scriptTokenizer.Data()
+
+		switch templateTokenizer.Opcode() {
+		case OP_PUBKEYHASH:
+			if op != OP_DATA_20 {
+				return nil, false
+			}
+			matches = append(matches, data)
+
+		case OP_PUBKEY:
+			if (op != OP_DATA_33 && op != OP_DATA_65) ||
+				!isStrictPubKeyEncoding(data) {
+
+				return nil, false
+			}
+			matches = append(matches, data)
+
+		default:
+			if op != templateTokenizer.Opcode() ||
+				!bytes.Equal(data, templateTokenizer.Data()) {
+
+				return nil, false
+			}
+		}
+	}
+	if templateTokenizer.Err() != nil {
+		return nil, false
+	}
+
+	// The script must not contain anything beyond the template.
+	if scriptTokenizer.Next() || scriptTokenizer.Err() != nil {
+		return nil, false
+	}
+
+	return matches, true
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMatchTemplate ensures scripts are matched against templates with
+// placeholders as expected and that the placeholder data is returned in order.
+func TestMatchTemplate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		hash20     = "0x14 0x0102030405060708090a0b0c0d0e0f1011121314"
+		hash20Data = "0102030405060708090a0b0c0d0e0f1011121314"
+		pubKey     = "0x21 0x02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4"
+		pubKeyData = "02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4"
+		p2pkh      = "DUP HASH160 PUBKEYHASH EQUALVERIFY CHECKSIG"
+	)
+
+	tests := []struct {
+		name     string
+		script   string
+		template string
+		matched  bool
+		want     []string
+	}{{
+		name:     "pay-to-pubkey-hash",
+		script:   "DUP HASH160 " + hash20 + " EQUALVERIFY CHECKSIG",
+		template: p2pkh,
+		matched:  true,
+		want:     []string{hash20Data},
+	}, {
+		name:     "pay-to-pubkey-hash with 21-byte hash",
+		script:   "DUP HASH160 0x15 0x" + hash20Data + "15 EQUALVERIFY CHECKSIG",
+		template: p2pkh,
+	}, {
+		name:     "pay-to-pubkey-hash with non-canonical push",
+		script:   "DUP HASH160 PUSHDATA1 0x14 0x" + hash20Data + " EQUALVERIFY CHECKSIG",
+		template: p2pkh,
+	}, {
+		name:     "pay-to-pubkey",
+		script:   pubKey + " CHECKSIG",
+		template: "PUBKEY CHECKSIG",
+		matched:  true,
+		want:     []string{pubKeyData},
+	}, {
+		name:     "pay-to-pubkey with invalid key prefix",
+		script:   "0x21 0x05192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4 CHECKSIG",
+		template: "PUBKEY CHECKSIG",
+	}, {
+		name:     "pubkey placeholder given a hash",
+		script:   hash20 + " CHECKSIG",
+		template: "PUBKEY CHECKSIG",
+	}, {
+		name: "custom script with several placeholders",
+		script: pubKey + " CHECKSIGVERIFY DUP HASH160 " + hash20 +
+			" EQUALVERIFY CHECKSIG",
+		template: "PUBKEY CHECKSIGVERIFY " + p2pkh,
+		matched:  true,
+		want:     []string{pubKeyData, hash20Data},
+	}, {
+		name:     "literal data must match",
+		script:   "0x02 0x0102 DROP",
+		template: "0x02 0x0102 DROP",
+		matched:  true,
+	}, {
+		name:     "literal data mismatch",
+		script:   "0x02 0x0103 DROP",
+		template: "0x02 0x0102 DROP",
+	}, {
+		name:     "literal opcode mismatch",
+		script:   "DUP HASH160 " + hash20 + " EQUAL CHECKSIG",
+		template: p2pkh,
+	}, {
+		name:     "script longer than template",
+		script:   "DUP HASH160 " + hash20 + " EQUALVERIFY CHECKSIG NOP",
+		template: p2pkh,
+	}, {
+		name:     "script shorter than template",
+		script:   "DUP HASH160 " + hash20 + " EQUALVERIFY",
+		template: p2pkh,
+	}, {
+		name:     "malformed script",
+		script:   "DUP HASH160 0x14 0x0102",
+		template: p2pkh,
+	}, {
+		name:     "malformed template",
+		script:   "DUP",
+		template: "DUP 0x14 0x0102",
+	}, {
+		name:     "empty script and template",
+		script:   "",
+		template: "",
+		matched:  true,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		template := mustParseShortForm(test.template)
+		got, matched := MatchTemplate(script, template)
+		if matched != test.matched {
+			t.Errorf("%s: unexpected match result - got %v, want %v",
+				test.name, matched, test.matched)
+			continue
+		}
+
+		var want [][]byte
+		for _, hexData := range test.want {
+			want = append(want, hexToBytes(hexData))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: unexpected matches - got %x, want %x",
+				test.name, got, want)
+		}
+	}
+}
@@ -101,14 +101,14 @@ func (t *ScriptTokenizer) Next() bool {
 		}
 
 		// Next -length bytes are little endian length of data.
-		var dataLen int32
+		var dataLen uint32
 		switch op.length {
 		case -1:
-			dataLen = int32(script[0])
+			dataLen = uint32(script[0])
 		case -2:
-			dataLen = int32(binary.LittleEndian.Uint16(script[:2]))
+			dataLen = uint32(binary.LittleEndian.Uint16(script[:2]))
 		case -4:
-			dataLen = int32(binary.LittleEndian.Uint32(script[:4]))
+			dataLen = binary.LittleEndian.Uint32(script[:4])
 		default:
 			// In practice it should be impossible to hit this
 			// check as each op code is predefined, and only uses
@@ -121,8 +121,20 @@ func (t *ScriptTokenizer) Next() bool {
 		// Move to the beginning of the data.
 		script = script[-op.length:]
 
-		// Disallow entries that do not fit script or were sign extended.
-		if dataLen > int32(len(script)) || dataLen < 0 {
+		// Disallow entries that do not fit script.  A declared length
+		// which could never be pushed is reported separately from a
+		// script that was merely truncated.
+		if dataLen > uint32(len(script)) {
+			if dataLen > MaxScriptElementSize {
+				str := fmt.Sprintf("opcode %s declares a push of %d "+
+					"bytes, which exceeds the max allowed element "+
+					"size of %d, but script only has %d remaining",
+					op.name, dataLen, MaxScriptElementSize,
+					len(script))
+				t.err = scriptError(ErrDeclaredPushTooBig, str)
+				return false
+			}
+
 			str := fmt.Sprintf("opcode %s pushes %d bytes, but script only "+
 				"has %d remaining", op.name, dataLen, len(script))
 			t.err = scriptError(ErrMalformedPush, str)
@@ -130,7 +142,7 @@ func (t *ScriptTokenizer) Next() bool {
 		}
 
 		// Move the offset forward and set the opcode and data accordingly.
-		t.offset += 1 + int32(-op.length) + dataLen
+		t.offset += 1 + int32(-op.length) + int32(dataLen)
 		t.op = op
 		t.data = script[:dataLen]
 		return true
@@ -108,6 +108,24 @@ func TestScriptTokenizer(t *testing.T) {
 		expected: nil,
 		finalIdx: 0,
 		err:      scriptError(ErrMalformedPush, ""),
+	}, {
+		name:     "OP_PUSHDATA2 short max element size data",
+		script:   mustParseShortForm("OP_PUSHDATA2 0x0802 0x01{519}"),
+		expected: nil,
+		finalIdx: 0,
+		err:      scriptError(ErrMalformedPush, ""),
+	}, {
+		name:     "OP_PUSHDATA2 short data over max element size",
+		script:   mustParseShortForm("OP_PUSHDATA2 0x0902 0x01{76}"),
+		expected: nil,
+		finalIdx: 0,
+		err:      scriptError(ErrDeclaredPushTooBig, ""),
+	}, {
+		name:     "OP_PUSHDATA4 short data with sign bit length",
+		script:   mustParseShortForm("OP_PUSHDATA4 0xffffffff 0x01{76}"),
+		expected: nil,
+		finalIdx: 0,
+		err:      scriptError(ErrDeclaredPushTooBig, ""),
 	}}...)
 
 	// Add tests for OP_0, and OP_1 through OP_16 (small integers/true/false).
@@ -0,0 +1,145 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// TraceDivergence describes the first point at which the executions of two
+// engines compared by DiffExecution diverge.
+type TraceDivergence struct {
+	// Step is the index of the step at which the engines diverged, which is
+	// also the number of preceding steps both engines executed with
+	// identical results.  When the engines only disagree on the result of
+	// the execution, it is the step at which their executions ended.
+	Step int
+
+	// A and B are the states of the respective engines after executing the
+	// diverging step.  They are nil when the engine did not execute an
+	// opcode in that step, either because it failed or because its
+	// execution had already ended.
+	A, B *StepInfo
+
+	// ErrA and ErrB are the results of the executions of the respective
+	// engines.  An engine which has not finished executing when the
+	// divergence was found has a nil result.
+	ErrA, ErrB error
+}
+
+// String returns a human-readable description of the divergence.
+func (d *TraceDivergence) String() string {
+	describe := func(info *StepInfo, err error) string {
+		switch {
+		case info != nil:
+			return fmt.Sprintf("%s with stack %x and alt stack %x",
+				info.Disasm, info.Stack, info.AltStack)
+		case err != nil:
+			return fmt.Sprintf("failed: %v", err)
+		}
+		return "succeeded"
+	}
+	return fmt.Sprintf("step %d: engine A %s, engine B %s", d.Step,
+		describe(d.A, d.ErrA), describe(d.B, d.ErrB))
+}
+
+// sameResult returns whether the passed execution results are equivalent,
+// which is the case when both are successful or both failed with the same
+// error code.  The descriptions of script errors are ignored since they are
+// not part of the behavior of the engine.
+func sameResult(errA, errB error) bool {
+	if errA == nil || errB == nil {
+		return errA == errB
+	}
+	var serrA, serrB Error
+	if errors.As(errA, &serrA) && errors.As(errB, &serrB) {
+		return serrA.ErrorCode == serrB.ErrorCode
+	}
+	return errA.Error() == errB.Error()
+}
+
+// traceStep executes the next step of the engine like Execute and returns
+// whether execution ended along with its result.
+func (vm *Engine) traceStep() (bool, error) {
+	// Scripts of unknown versions execute without issue.
+	if vm.version != 0 {
+		return true, nil
+	}
+
+	done, err := vm.Step()
+	if err != nil {
+		return true, err
+	}
+	if done {
+		return true, vm.CheckErrorCondition(true)
+	}
+	return false, nil
+}
+
+// DiffExecution executes the two passed engines in lockstep and returns the
+// first point at which their executions diverge, or nil when both engines
+// execute the same opcodes with the same stacks and arrive at the same result.
+// Results are considered the same when both executions succeed or both fail
+// with the same error code.
+//
+// The engines are expected to execute the same scripts with different
+// configurations, such as different script flags or limits, which makes this
+// useful for differential testing of changes to the engine that are not meant
+// to affect the outcome of any script.  For example:
+//
+//	vmA, _ := NewEngine(pkScript, tx, 0, flags, nil, nil, amount)
+//	vmB, _ := NewEngine(pkScript, tx, 0, flags|ScriptVerifyMinimalData,
+//		nil, nil, amount)
+//	if d := DiffExecution(vmA, vmB); d != nil {
+//		fmt.Println(d)
+//	}
+//
+// Any step callbacks of the engines are invoked as usual.  The engines are
+// consumed by the comparison and must not be executed again.
+func DiffExecution(a, b *Engine) *TraceDivergence {
+	// Capture the state of each engine after every step through its step
+	// callback while keeping any callback the caller configured.
+	var infoA, infoB *StepInfo
+	capture := func(vm *Engine, info **StepInfo) {
+		callback := vm.stepCallback
+		vm.SetStepCallback(func(stepInfo *StepInfo) error {
+			*info = stepInfo
+			if callback != nil {
+				return callback(stepInfo)
+			}
+			return nil
+		})
+	}
+	capture(a, &infoA)
+	capture(b, &infoB)
+
+	var doneA, doneB bool
+	var errA, errB error
+	for step := 0; !doneA || !doneB; step++ {
+		infoA, infoB = nil, nil
+		if !doneA {
+			doneA, errA = a.traceStep()
+		}
+		if !doneB {
+			doneB, errB = b.traceStep()
+		}
+
+		if !reflect.DeepEqual(infoA, infoB) ||
+			(doneA && doneB && !sameResult(errA, errB)) {
+
+			return &TraceDivergence{
+				Step: step,
+				A:    infoA,
+				B:    infoB,
+				ErrA: errA,
+				ErrB: errB,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestDiffExecution ensures the executions of engines with different
+// configurations are compared step by step and that the first divergence is
+// reported.
+func TestDiffExecution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sigScript string
+		pkScript  string
+		flagsA    ScriptFlags
+		flagsB    ScriptFlags
+		diverges  bool
+		step      int
+		stepA     bool
+		stepB     bool
+		errA      error
+		errB      error
+	}{{
+		name:      "identical executions",
+		sigScript: "1 2",
+		pkScript:  "ADD 3 EQUAL",
+		flagsB:    ScriptVerifyMinimalData,
+	}, {
+		name:      "identical failures",
+		sigScript: "1 2",
+		pkScript:  "ADD 4 EQUAL",
+		flagsB:    ScriptVerifyMinimalData,
+	}, {
+		name:      "non-minimal push rejected by one engine",
+		sigScript: "1 0x4c 0x01 0x02",
+		pkScript:  "ADD 3 EQUAL",
+		flagsB:    ScriptVerifyMinimalData,
+		diverges:  true,
+		step:      1,
+		stepA:     true,
+		errB:      ErrMinimalData,
+	}, {
+		name:      "upgradable nop rejected by one engine",
+		sigScript: "1",
+		pkScript:  "NOP10",
+		flagsA:    ScriptDiscourageUpgradableNops,
+		diverges:  true,
+		step:      1,
+		stepB:     true,
+		errA:      ErrDiscourageUpgradableNOPs,
+	}, {
+		name:      "clean stack only checked by one engine",
+		sigScript: "1 1",
+		pkScript:  "NOP",
+		flagsA:    ScriptBip16,
+		flagsB:    ScriptBip16 | ScriptVerifyCleanStack,
+		diverges:  true,
+		step:      2,
+		stepA:     true,
+		stepB:     true,
+		errB:      ErrCleanStack,
+	}}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				SignatureScript: mustParseShortForm(test.sigScript),
+				Sequence:        wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 1}},
+		}
+		pkScript := mustParseShortForm(test.pkScript)
+		vmA, err := NewEngine(pkScript, tx, 0, test.flagsA, nil, nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		vmB, err := NewEngine(pkScript, tx, 0, test.flagsB, nil, nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+
+		d := DiffExecution(vmA, vmB)
+		if !test.diverges {
+			if d != nil {
+				t.Errorf("%s: unexpected divergence: %v", test.name, d)
+			}
+			continue
+		}
+		if d == nil {
+			t.Errorf("%s: no divergence found", test.name)
+			continue
+		}
+		if d.Step != test.step || (d.A != nil) != test.stepA ||
+			(d.B != nil) != test.stepB {
+
+			t.Errorf("%s: unexpected divergence: %v", test.name, d)
+			continue
+		}
+		if !errors.Is(d.ErrA, test.errA) || !errors.Is(d.ErrB, test.errB) {
+			t.Errorf("%s: got errors %v and %v, want %v and %v",
+				test.name, d.ErrA, d.ErrB, test.errA, test.errB)
+		}
+	}
+}
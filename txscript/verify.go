@@ -0,0 +1,80 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// PrevOutputFetcher is an interface type provided to VerifyTransaction, it
+// encapsulates any user state required to look up the outputs spent by the
+// inputs of a transaction.  The blockchain.UtxoViewpoint type implements it.
+type PrevOutputFetcher interface {
+	// FetchPrevOutput returns the output referenced by the passed outpoint
+	// or nil when it is not available.
+	FetchPrevOutput(wire.OutPoint) *wire.TxOut
+}
+
+// PrevOutputClosure implements PrevOutputFetcher with a closure.
+type PrevOutputClosure func(wire.OutPoint) *wire.TxOut
+
+// FetchPrevOutput implements PrevOutputFetcher by returning the result of
+// calling the closure.
+func (pc PrevOutputClosure) FetchPrevOutput(op wire.OutPoint) *wire.TxOut {
+	return pc(op)
+}
+
+// VerifyTransaction executes the scripts of every input of the passed
+// transaction against the public key scripts and amounts of the outputs they
+// spend, as provided by prevOuts, using the passed flags and an optional
+// signature cache.
+//
+// It returns nil when all inputs are valid.  Otherwise, the returned slice has
+// one entry per input where the entries of valid inputs are nil and the
+// entries of invalid inputs contain the reason they failed.  Inputs which
+// spend an output that prevOuts does not provide fail with
+// ErrMissingPrevOutput.
+//
+// Coinbase transactions have no previous outputs to verify against and must
+// not be passed to this function.
+func VerifyTransaction(tx *wire.MsgTx, prevOuts PrevOutputFetcher,
+	flags ScriptFlags, sigCache *SigCache) []error {
+
+	// The signature hashes of all inputs are derived from the same
+	// intermediate hashes, so calculate them once up front.
+	hashCache := NewTxSigHashes(tx)
+
+	var errs []error
+	setErr := func(idx int, err error) {
+		if errs == nil {
+			errs = make([]error, len(tx.TxIn))
+		}
+		errs[idx] = err
+	}
+	for txIdx, txIn := range tx.TxIn {
+		prevOut := prevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			str := fmt.Sprintf("output %v referenced from transaction "+
+				"input %d is not available", txIn.PreviousOutPoint,
+				txIdx)
+			setErr(txIdx, scriptError(ErrMissingPrevOutput, str))
+			continue
+		}
+
+		vm, err := NewEngine(prevOut.PkScript, tx, txIdx, flags,
+			sigCache, hashCache, prevOut.Value)
+		if err != nil {
+			setErr(txIdx, err)
+			continue
+		}
+		if err := vm.Execute(); err != nil {
+			setErr(txIdx, err)
+		}
+	}
+
+	return errs
+}
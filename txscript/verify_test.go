@@ -0,0 +1,93 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"testing"
+
+	"github.com/dashpay/dashd-go/btcec/v2"
+	"github.com/dashpay/dashd-go/btcutil"
+	"github.com/dashpay/dashd-go/chaincfg"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/wire"
+)
+
+// TestVerifyTransaction ensures VerifyTransaction verifies every input against
+// the outputs provided by the fetcher and reports the failures per input.
+func TestVerifyTransaction(t *testing.T) {
+	t.Parallel()
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	address, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(key.PubKey().SerializeCompressed()),
+		&chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkScript, err := PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+
+	// Create a transaction spending three outputs paying to the key.
+	prevHash := chainhash.Hash{0x01}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevOutputs := make(map[wire.OutPoint]*wire.TxOut)
+	for i := uint32(0); i < 3; i++ {
+		outPoint := wire.OutPoint{Hash: prevHash, Index: i}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		prevOutputs[outPoint] = wire.NewTxOut(int64(i+1)*1e8, pkScript)
+	}
+	tx.AddTxOut(wire.NewTxOut(5e8, pkScript))
+
+	kdb := mkGetKey(map[string]addressToKey{
+		address.EncodeAddress(): {key, true},
+	})
+	for i := range tx.TxIn {
+		sigScript, err := SignTxOutput(&chaincfg.TestNet3Params, tx, i,
+			pkScript, SigHashAll, kdb, mkGetScript(nil), nil)
+		if err != nil {
+			t.Fatalf("failed to sign input %d: %v", i, err)
+		}
+		tx.TxIn[i].SignatureScript = sigScript
+	}
+
+	fetcher := PrevOutputClosure(func(op wire.OutPoint) *wire.TxOut {
+		return prevOutputs[op]
+	})
+	flags := StandardVerifyFlags
+	if errs := VerifyTransaction(tx, fetcher, flags, nil); errs != nil {
+		t.Fatalf("VerifyTransaction: unexpected errors for valid "+
+			"transaction: %v", errs)
+	}
+
+	// Make the first input reuse the signature of the last one, the
+	// second input spend an output that is not available, and the last
+	// input provide a bogus signature and public key.
+	tx.TxIn[0].SignatureScript = tx.TxIn[2].SignatureScript
+	prevOutputs[wire.OutPoint{Hash: prevHash, Index: 1}] = nil
+	tx.TxIn[2].SignatureScript = mustParseShortForm("0 0")
+
+	errs := VerifyTransaction(tx, fetcher, flags, nil)
+	if len(errs) != len(tx.TxIn) {
+		t.Fatalf("VerifyTransaction: got %d errors, want %d",
+			len(errs), len(tx.TxIn))
+	}
+	if !IsErrorCode(errs[0], ErrNullFail) {
+		t.Errorf("input 0: unexpected error - got %v, want %v",
+			errs[0], ErrNullFail)
+	}
+	if !IsErrorCode(errs[1], ErrMissingPrevOutput) {
+		t.Errorf("input 1: unexpected error - got %v, want %v",
+			errs[1], ErrMissingPrevOutput)
+	}
+	if !IsErrorCode(errs[2], ErrEqualVerify) {
+		t.Errorf("input 2: unexpected error - got %v, want %v",
+			errs[2], ErrEqualVerify)
+	}
+}
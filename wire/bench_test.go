@@ -632,3 +632,68 @@ func BenchmarkDoubleHashH(b *testing.B) {
 		_ = chainhash.DoubleHashH(txBytes)
 	}
 }
+
+// BenchmarkMsgTxRoundTrip performs a benchmark on how long it takes to
+// serialize and deserialize a transaction.
+func BenchmarkMsgTxRoundTrip(b *testing.B) {
+	tx := blockOne.Transactions[0]
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	var readTx MsgTx
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		tx.Serialize(buf)
+		readTx.Deserialize(buf)
+	}
+}
+
+// BenchmarkMsgBlockRoundTrip performs a benchmark on how long it takes to
+// serialize and deserialize a block.
+func BenchmarkMsgBlockRoundTrip(b *testing.B) {
+	buf := bytes.NewBuffer(make([]byte, 0, blockOne.SerializeSize()))
+	var block MsgBlock
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		blockOne.Serialize(buf)
+		block.Deserialize(buf)
+	}
+}
+
+// BenchmarkMessageRoundTrip performs a benchmark on how long it takes to write
+// and read a block message, including the message header.
+func BenchmarkMessageRoundTrip(b *testing.B) {
+	pver := ProtocolVersion
+	var buf bytes.Buffer
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		WriteMessage(&buf, &blockOne, pver, MainNet)
+		ReadMessage(&buf, pver, MainNet)
+	}
+}
+
+// BenchmarkSpecialTxPayloadRoundTrip performs a benchmark on how long it takes
+// to encode and decode the payload of a special transaction, which mostly
+// consists of fixed size keys and signatures.
+func BenchmarkSpecialTxPayloadRoundTrip(b *testing.B) {
+	qcTx := &QcTx{
+		Version:    1,
+		Height:     1000,
+		Commitment: *newTestQuorumCommitment(0x01),
+	}
+	tx := NewMsgTx(SpecialTxVersion)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx.SetPayload(qcTx)
+		tx.Payload()
+	}
+}
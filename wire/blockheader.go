@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/dashpay/dashd-go/x11"
 )
 
 // MaxBlockHeaderPayload is the maximum number of bytes a block header can be.
@@ -56,6 +57,20 @@ func (h *BlockHeader) BlockHash() chainhash.Hash {
 	return chainhash.DoubleHashH(buf.Bytes())
 }
 
+// PowHash computes the X11 hash of the given block header, which is the hash
+// checked against the target difficulty.  Blocks are still identified by the
+// double sha256 hash returned by BlockHash.
+func (h *BlockHeader) PowHash() chainhash.Hash {
+	// Encode the header and X11 hash everything prior to the number of
+	// transactions.  Ignore the error returns since there is no way the
+	// encode could fail except being out of memory which would cause a
+	// run-time panic.
+	buf := bytes.NewBuffer(make([]byte, 0, MaxBlockHeaderPayload))
+	_ = writeBlockHeader(buf, 0, h)
+
+	return chainhash.Hash(x11.Sum(buf.Bytes()))
+}
+
 // BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
 // This is part of the Message interface implementation.
 // See Deserialize for decoding block headers stored to disk, such as in a
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -259,3 +260,34 @@ func TestBlockHeaderSerialize(t *testing.T) {
 		}
 	}
 }
+
+// TestBlockHeaderPowHash ensures the proof of work hash of the Dash mainnet
+// genesis block header is its X11 hash, while the block hash remains the
+// double sha256 hash.
+func TestBlockHeaderPowHash(t *testing.T) {
+	merkleRoot, err := chainhash.NewHashFromStr("e0028eb9648db56b1ac77cf" +
+		"090b99048a8007e2bb64b68f092c03c7f56a662c7")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: unexpected error: %v", err)
+	}
+	bh := NewBlockHeader(1, &chainhash.Hash{}, merkleRoot, 0x1e0ffff0,
+		28917698)
+	bh.Timestamp = time.Unix(1390095618, 0)
+
+	want, err := chainhash.NewHashFromStr("00000ffd590b1485b3caadc19b22e6" +
+		"379c733355108f107a430458cdf3407ab6")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: unexpected error: %v", err)
+	}
+	if got := bh.PowHash(); got != *want {
+		t.Errorf("PowHash: got %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := bh.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	if got := bh.BlockHash(); got != chainhash.DoubleHashH(buf.Bytes()) {
+		t.Errorf("BlockHash: got %v, want the double sha256 hash", got)
+	}
+}
@@ -324,6 +324,86 @@ func readElement(r io.Reader, element interface{}) error {
 		}
 		*e = RejectCode(rv)
 		return nil
+
+	case *uint8:
+		rv, err := binarySerializer.Uint8(r)
+		if err != nil {
+			return err
+		}
+		*e = rv
+		return nil
+
+	case *uint16:
+		rv, err := binarySerializer.Uint16(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		*e = rv
+		return nil
+
+	case *int16:
+		rv, err := binarySerializer.Uint16(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		*e = int16(rv)
+		return nil
+
+	// Key ID.
+	case *[20]byte:
+		_, err := io.ReadFull(r, e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	// BLS public key.
+	case *[BLSPublicKeySize]byte:
+		_, err := io.ReadFull(r, e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	// BLS signature.
+	case *[BLSSignatureSize]byte:
+		_, err := io.ReadFull(r, e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case *FilterType:
+		rv, err := binarySerializer.Uint8(r)
+		if err != nil {
+			return err
+		}
+		*e = FilterType(rv)
+		return nil
+
+	case *LLMQType:
+		rv, err := binarySerializer.Uint8(r)
+		if err != nil {
+			return err
+		}
+		*e = LLMQType(rv)
+		return nil
+
+	case *MasternodeType:
+		rv, err := binarySerializer.Uint16(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		*e = MasternodeType(rv)
+		return nil
+
+	case *SnapshotSkipMode:
+		rv, err := binarySerializer.Uint32(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		*e = SnapshotSkipMode(rv)
+		return nil
 	}
 
 	// Fall back to the slower binary.Read if a fast path was not available
@@ -453,6 +533,86 @@ func writeElement(w io.Writer, element interface{}) error {
 			return err
 		}
 		return nil
+
+	case uint8:
+		err := binarySerializer.PutUint8(w, e)
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case uint16:
+		err := binarySerializer.PutUint16(w, littleEndian, e)
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case int16:
+		err := binarySerializer.PutUint16(w, littleEndian, uint16(e))
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case chainhash.Hash:
+		_, err := w.Write(e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	// Key ID.
+	case [20]byte:
+		_, err := w.Write(e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	// BLS public key.
+	case [BLSPublicKeySize]byte:
+		_, err := w.Write(e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	// BLS signature.
+	case [BLSSignatureSize]byte:
+		_, err := w.Write(e[:])
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case FilterType:
+		err := binarySerializer.PutUint8(w, uint8(e))
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case LLMQType:
+		err := binarySerializer.PutUint8(w, uint8(e))
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case MasternodeType:
+		err := binarySerializer.PutUint16(w, littleEndian, uint16(e))
+		if err != nil {
+			return err
+		}
+		return nil
+
+	case SnapshotSkipMode:
+		err := binarySerializer.PutUint32(w, littleEndian, uint32(e))
+		if err != nil {
+			return err
+		}
+		return nil
 	}
 
 	// Fall back to the slower binary.Write if a fast path was not available
@@ -129,6 +129,29 @@ func TestElementWire(t *testing.T) {
 			MainNet,
 			[]byte{0xbf, 0x0c, 0x6b, 0xbd},
 		},
+		{uint8(0xfe), []byte{0xfe}},
+		{uint16(0x0102), []byte{0x02, 0x01}},
+		{int16(-2), []byte{0xfe, 0xff}},
+		{
+			chainhash.Hash{0x01, 0x02},
+			append([]byte{0x01, 0x02}, make([]byte, 30)...),
+		},
+		{
+			[20]byte{0x01, 0x02},
+			append([]byte{0x01, 0x02}, make([]byte, 18)...),
+		},
+		{
+			[BLSPublicKeySize]byte{0x01, 0x02},
+			append([]byte{0x01, 0x02}, make([]byte, 46)...),
+		},
+		{
+			[BLSSignatureSize]byte{0x01, 0x02},
+			append([]byte{0x01, 0x02}, make([]byte, 94)...),
+		},
+		{GCSFilterRegular, []byte{0x00}},
+		{LLMQType(100), []byte{0x64}},
+		{MasternodeTypeEvo, []byte{0x01, 0x00}},
+		{SnapshotSkipMode(3), []byte{0x03, 0x00, 0x00, 0x00}},
 		// Type not supported by the "fast" path and requires reflection.
 		{
 			writeElementReflect(1),
@@ -188,6 +211,9 @@ func TestElementWireErrors(t *testing.T) {
 		{int64(65536), 0, io.ErrShortWrite, io.EOF},
 		{true, 0, io.ErrShortWrite, io.EOF},
 		{[4]byte{0x01, 0x02, 0x03, 0x04}, 0, io.ErrShortWrite, io.EOF},
+		{uint16(0x0102), 1, io.ErrShortWrite, io.ErrUnexpectedEOF},
+		{[BLSSignatureSize]byte{0x01}, 0, io.ErrShortWrite, io.EOF},
+		{LLMQType(100), 0, io.ErrShortWrite, io.EOF},
 		{
 			[CommandSize]byte{
 				0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// BLSSecretKeySize is the size of a serialized BLS secret key.
+const BLSSecretKeySize = 32
+
+// dkgSessionPayload is the size of the fields identifying the DKG session and
+// the member which start every DKG message.
+//
+// LLMQ type 1 byte + quorum hash 32 bytes + ProRegTx hash 32 bytes.
+const dkgSessionPayload = 1 + chainhash.HashSize + chainhash.HashSize
+
+// maxDynBitSetPayload is the max payload size of a dynamically sized bitset
+// of a DKG message.
+const maxDynBitSetPayload = MaxVarIntPayload + (maxQuorumMembers+7)/8
+
+// DKGSession identifies the DKG session of an LLMQ (DIP0006) along with the
+// quorum member which sent a DKG message.  It starts the qcontrib, qcomplaint,
+// qjustify and qpcommit messages.
+type DKGSession struct {
+	LLMQType   LLMQType
+	QuorumHash chainhash.Hash
+	ProTxHash  chainhash.Hash
+}
+
+// readDKGSession reads the DKG session of a DKG message from r.
+func readDKGSession(r io.Reader, s *DKGSession) error {
+	return readElements(r, &s.LLMQType, &s.QuorumHash, &s.ProTxHash)
+}
+
+// writeDKGSession writes the DKG session of a DKG message to w.
+func writeDKGSession(w io.Writer, s *DKGSession) error {
+	return writeElements(w, s.LLMQType, &s.QuorumHash, &s.ProTxHash)
+}
+
+// readDKGCount reads the number of items of a list within a DKG message and
+// ensures it doesn't exceed the number of members of the largest quorum.
+func readDKGCount(r io.Reader, pver uint32, fieldName string) (uint64, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxQuorumMembers {
+		str := fmt.Sprintf("too many %s for message [count %d, max %d]",
+			fieldName, count, maxQuorumMembers)
+		return 0, messageError("readDKGCount", str)
+	}
+	return count, nil
+}
+
+// checkDKGCount ensures the number of items of a list within a DKG message
+// doesn't exceed the number of members of the largest quorum.
+func checkDKGCount(count int, fieldName string) error {
+	if count > maxQuorumMembers {
+		str := fmt.Sprintf("too many %s for message [count %d, max %d]",
+			fieldName, count, maxQuorumMembers)
+		return messageError("checkDKGCount", str)
+	}
+	return nil
+}
+
+// checkDKGBitSetSizes ensures the passed bitsets of a DKG message, which have
+// a bit per quorum member, are of equal size.
+func checkDKGBitSetSizes(funcName string, a, b []bool) error {
+	if len(a) != len(b) {
+		str := fmt.Sprintf("bitsets of different sizes [%d and %d]",
+			len(a), len(b))
+		return messageError(funcName, str)
+	}
+	return nil
+}
+
+// checkLLMQVersion ensures the passed protocol version supports the LLMQ
+// messages.
+func checkLLMQVersion(funcName, command string, pver uint32) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("%s message invalid for protocol version %d",
+			command, pver)
+		return messageError(funcName, str)
+	}
+	return nil
+}
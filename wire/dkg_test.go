@@ -0,0 +1,182 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestDKGMessagesWire tests the wire encode and decode of the DKG messages.
+func TestDKGMessagesWire(t *testing.T) {
+	session := &DKGSession{LLMQType: 4, QuorumHash: chainhash.Hash{0x01},
+		ProTxHash: chainhash.Hash{0x02}}
+
+	contrib := NewMsgQContrib(session)
+	contrib.VVec = [][BLSPublicKeySize]byte{{0x03}, {0x04}}
+	contrib.EphemeralPubKey = [BLSPublicKeySize]byte{0x05}
+	contrib.IVSeed = chainhash.Hash{0x06}
+	contrib.Contributions = [][]byte{
+		bytes.Repeat([]byte{0x07}, BLSSecretKeySize),
+		bytes.Repeat([]byte{0x08}, BLSSecretKeySize),
+		bytes.Repeat([]byte{0x09}, BLSSecretKeySize),
+	}
+	contrib.Sig = [BLSSignatureSize]byte{0x0a}
+
+	complaint := NewMsgQComplaint(session, 9)
+	complaint.BadMembers[8] = true
+	complaint.ComplainForMembers[0] = true
+
+	justify := NewMsgQJustify(session)
+	justify.Contributions = []DKGJustifiedContribution{
+		{Index: 1, Key: [BLSSecretKeySize]byte{0x0b}},
+		{Index: 7, Key: [BLSSecretKeySize]byte{0x0c}},
+	}
+
+	pcommit := NewMsgQPCommit(session, 10)
+	pcommit.ValidMembers[0] = true
+	pcommit.ValidMembers[9] = true
+	pcommit.QuorumPubKey = [BLSPublicKeySize]byte{0x0d}
+
+	fcommit := NewMsgQFCommit(&QuorumCommitment{
+		Version:      QuorumCommitmentIndexedVersion,
+		LLMQType:     4,
+		QuorumHash:   chainhash.Hash{0x01},
+		QuorumIndex:  1,
+		Signers:      []bool{true, false, true},
+		ValidMembers: []bool{true, true, true},
+	})
+
+	// The complaint starts with the session followed by the bitsets.
+	wantComplaint := []byte{0x04}
+	wantComplaint = append(wantComplaint, session.QuorumHash[:]...)
+	wantComplaint = append(wantComplaint, session.ProTxHash[:]...)
+	wantComplaint = append(wantComplaint, 0x09, 0x00, 0x01) // BadMembers
+	wantComplaint = append(wantComplaint, 0x09, 0x01, 0x00) // ComplainForMembers
+	wantComplaint = append(wantComplaint, make([]byte, BLSSignatureSize)...)
+
+	tests := []struct {
+		in   Message
+		out  Message
+		want []byte
+	}{
+		{contrib, &MsgQContrib{}, nil},
+		{complaint, &MsgQComplaint{}, wantComplaint},
+		{justify, &MsgQJustify{}, nil},
+		{pcommit, &MsgQPCommit{}, nil},
+		{fcommit, &MsgQFCommit{}, nil},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, LLMQVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("%s: BtcEncode error %v", test.in.Command(), err)
+			continue
+		}
+		if test.want != nil && !bytes.Equal(buf.Bytes(), test.want) {
+			t.Errorf("%s: BtcEncode\n got: %s want: %s",
+				test.in.Command(), spew.Sdump(buf.Bytes()),
+				spew.Sdump(test.want))
+			continue
+		}
+		if uint32(buf.Len()) > test.in.MaxPayloadLength(LLMQVersion) {
+			t.Errorf("%s: payload of %d bytes exceeds max payload "+
+				"length %d", test.in.Command(), buf.Len(),
+				test.in.MaxPayloadLength(LLMQVersion))
+		}
+
+		err = test.out.BtcDecode(&buf, LLMQVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("%s: BtcDecode error %v", test.in.Command(), err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("%s: BtcDecode\n got: %s want: %s",
+				test.in.Command(), spew.Sdump(test.out),
+				spew.Sdump(test.in))
+		}
+	}
+}
+
+// TestDKGMessagesSizeValidation ensures DKG messages with invalid sizes can't
+// be encoded or decoded.
+func TestDKGMessagesSizeValidation(t *testing.T) {
+	session := &DKGSession{LLMQType: 4}
+
+	badComplaint := NewMsgQComplaint(session, 3)
+	badComplaint.ComplainForMembers = make([]bool, 4)
+
+	badContribSize := NewMsgQContrib(session)
+	badContribSize.Contributions = [][]byte{make([]byte, BLSSecretKeySize-1)}
+
+	tooManyContribs := NewMsgQContrib(session)
+	tooManyContribs.Contributions = make([][]byte, maxQuorumMembers+1)
+	for i := range tooManyContribs.Contributions {
+		tooManyContribs.Contributions[i] = make([]byte, BLSSecretKeySize)
+	}
+
+	badJustify := NewMsgQJustify(session)
+	badJustify.Contributions = []DKGJustifiedContribution{
+		{Index: maxQuorumMembers},
+	}
+
+	badFCommit := NewMsgQFCommit(&QuorumCommitment{Version: 1,
+		Signers: make([]bool, 3), ValidMembers: make([]bool, 2)})
+
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"complaint bitset sizes", badComplaint},
+		{"contribution size", badContribSize},
+		{"contribution count", tooManyContribs},
+		{"justified member index", badJustify},
+		{"final commitment bitset sizes", badFCommit},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := test.msg.BtcEncode(&buf, LLMQVersion, BaseEncoding)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: BtcEncode got error %v", test.name, err)
+		}
+	}
+
+	// Encode the invalid messages without the checks to ensure they are
+	// rejected when decoding as well.
+	var buf bytes.Buffer
+	writeDKGSession(&buf, session)
+	writeDynBitSet(&buf, badComplaint.BadMembers)
+	writeDynBitSet(&buf, badComplaint.ComplainForMembers)
+	buf.Write(make([]byte, BLSSignatureSize))
+	err := (&MsgQComplaint{}).BtcDecode(&buf, LLMQVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("complaint bitset sizes: BtcDecode got error %v", err)
+	}
+
+	buf.Reset()
+	writeDKGSession(&buf, session)
+	buf.Write([]byte{0x00}) // No verification vector
+	buf.Write(make([]byte, BLSPublicKeySize+chainhash.HashSize))
+	buf.Write([]byte{0x01, BLSSecretKeySize - 1})
+	buf.Write(make([]byte, BLSSecretKeySize-1+BLSSignatureSize))
+	err = (&MsgQContrib{}).BtcDecode(&buf, LLMQVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("contribution size: BtcDecode got error %v", err)
+	}
+
+	buf.Reset()
+	writeDKGSession(&buf, session)
+	WriteVarInt(&buf, 0, maxQuorumMembers+1)
+	err = (&MsgQJustify{}).BtcDecode(&buf, LLMQVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("contribution count: BtcDecode got error %v", err)
+	}
+}
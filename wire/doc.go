@@ -139,13 +139,29 @@ from a remote peer is:
 		// Log and handle the error
 	}
 
+Custom Messages
+
+Messages with commands that are not built into this package, such as those used
+for devnet experiments or by platform services, can be read by registering a
+function that creates an empty instance of a type implementing the Message
+interface.  The built-in commands can't be overridden.  Example syntax is:
+
+	err := wire.RegisterMessage("mycmd", func() wire.Message {
+		return &MyMsg{}
+	})
+	if err != nil {
+		// Log and handle the error
+	}
+
 Errors
 
 Errors returned by this package are either the raw errors provided by underlying
 calls to read/write from streams such as io.EOF, io.ErrUnexpectedEOF, and
 io.ErrShortWrite, or of type wire.MessageError.  This allows the caller to
 differentiate between general IO errors and malformed messages through type
-assertions.
+assertions.  Messages read by ReadMessageWithLimitsN which exceed the limits of
+the caller are rejected with errors of type wire.RejectReason, which house the
+details of the reject message to send to the remote peer.
 
 Bitcoin Improvement Proposals
 
@@ -5,8 +5,10 @@
 package wire
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 )
@@ -36,6 +38,15 @@ const (
 	InvTypeWitnessBlock         InvType = InvTypeBlock | InvWitnessFlag
 	InvTypeWitnessTx            InvType = InvTypeTx | InvWitnessFlag
 	InvTypeFilteredWitnessBlock InvType = InvTypeFilteredBlock | InvWitnessFlag
+
+	// InvTypeCmpctBlock requests a block as a cmpctblock message (BIP0152).
+	// Dash uses a different value than Bitcoin for it.
+	InvTypeCmpctBlock InvType = 20
+
+	// InvTypeCLSig and InvTypeISDLock announce ChainLocks and deterministic
+	// InstantSend locks, which are requested as clsig and isdlock messages.
+	InvTypeCLSig   InvType = 29
+	InvTypeISDLock InvType = 31
 )
 
 // Map of service flags back to their constant names for pretty printing.
@@ -47,6 +58,9 @@ var ivStrings = map[InvType]string{
 	InvTypeWitnessBlock:         "MSG_WITNESS_BLOCK",
 	InvTypeWitnessTx:            "MSG_WITNESS_TX",
 	InvTypeFilteredWitnessBlock: "MSG_FILTERED_WITNESS_BLOCK",
+	InvTypeCmpctBlock:           "MSG_CMPCT_BLOCK",
+	InvTypeCLSig:                "MSG_CLSIG",
+	InvTypeISDLock:              "MSG_ISDLOCK",
 }
 
 // String returns the InvType in human-readable form.
@@ -84,3 +98,80 @@ func readInvVect(r io.Reader, pver uint32, iv *InvVect) error {
 func writeInvVect(w io.Writer, pver uint32, iv *InvVect) error {
 	return writeElements(w, iv.Type, &iv.Hash)
 }
+
+// compareInvVects returns an integer comparing the two inventory vectors in
+// their canonical order, which is by type and then by the bytes of the hash.
+// The result is -1, 0 or 1 when a is less than, equal to or greater than b.
+func compareInvVects(a, b *InvVect) int {
+	switch {
+	case a.Type < b.Type:
+		return -1
+	case a.Type > b.Type:
+		return 1
+	}
+	return bytes.Compare(a.Hash[:], b.Hash[:])
+}
+
+// SortInvVects sorts the passed inventory vectors in place into their
+// canonical order, which is by type and then by the bytes of the hash, so
+// batches built from the same set of inventory are identical regardless of
+// the order it was collected in.
+func SortInvVects(invList []*InvVect) {
+	sort.Slice(invList, func(i, j int) bool {
+		return compareInvVects(invList[i], invList[j]) < 0
+	})
+}
+
+// DedupInvVects returns the passed inventory vectors with any duplicates
+// removed.  The first occurrence of each inventory vector is kept and the
+// relative order is otherwise preserved.  The passed slice is not modified.
+func DedupInvVects(invList []*InvVect) []*InvVect {
+	seen := make(map[InvVect]struct{}, len(invList))
+	deduped := make([]*InvVect, 0, len(invList))
+	for _, iv := range invList {
+		if _, ok := seen[*iv]; ok {
+			continue
+		}
+		seen[*iv] = struct{}{}
+		deduped = append(deduped, iv)
+	}
+	return deduped
+}
+
+// MergeInvVects returns the union of the passed lists of inventory vectors in
+// canonical order with all duplicates removed.  None of the passed slices are
+// modified.
+func MergeInvVects(lists ...[]*InvVect) []*InvVect {
+	var numInvs int
+	for _, list := range lists {
+		numInvs += len(list)
+	}
+	merged := make([]*InvVect, 0, numInvs)
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	merged = DedupInvVects(merged)
+	SortInvVects(merged)
+	return merged
+}
+
+// SplitInvVects splits the passed inventory vectors into consecutive batches
+// of at most maxPerMsg entries each, suitable for sending as individual inv,
+// getdata or notfound messages.  A maxPerMsg that is not positive or exceeds
+// MaxInvPerMsg is treated as MaxInvPerMsg.  The returned batches share the
+// backing array of the passed slice.
+func SplitInvVects(invList []*InvVect, maxPerMsg int) [][]*InvVect {
+	if maxPerMsg <= 0 || maxPerMsg > MaxInvPerMsg {
+		maxPerMsg = MaxInvPerMsg
+	}
+	numBatches := (len(invList) + maxPerMsg - 1) / maxPerMsg
+	batches := make([][]*InvVect, 0, numBatches)
+	for len(invList) > maxPerMsg {
+		batches = append(batches, invList[:maxPerMsg:maxPerMsg])
+		invList = invList[maxPerMsg:]
+	}
+	if len(invList) > 0 {
+		batches = append(batches, invList)
+	}
+	return batches
+}
@@ -22,6 +22,9 @@ func TestInvTypeStringer(t *testing.T) {
 		{InvTypeError, "ERROR"},
 		{InvTypeTx, "MSG_TX"},
 		{InvTypeBlock, "MSG_BLOCK"},
+		{InvTypeCmpctBlock, "MSG_CMPCT_BLOCK"},
+		{InvTypeCLSig, "MSG_CLSIG"},
+		{InvTypeISDLock, "MSG_ISDLOCK"},
 		{0xffffffff, "Unknown InvType (4294967295)"},
 	}
 
@@ -267,3 +270,111 @@ func TestInvVectWire(t *testing.T) {
 		}
 	}
 }
+
+// TestInvVectOrdering tests the inventory vector sorting, deduplication and
+// merging helpers.
+func TestInvVectOrdering(t *testing.T) {
+	txA := NewInvVect(InvTypeTx, &chainhash.Hash{0x01})
+	txB := NewInvVect(InvTypeTx, &chainhash.Hash{0x02})
+	txC := NewInvVect(InvTypeTx, &chainhash.Hash{0x01, 0x01})
+	blockA := NewInvVect(InvTypeBlock, &chainhash.Hash{0x00})
+	dupTxA := NewInvVect(InvTypeTx, &chainhash.Hash{0x01})
+
+	invList := []*InvVect{blockA, txB, txC, txA}
+	SortInvVects(invList)
+	want := []*InvVect{txA, txC, txB, blockA}
+	if !reflect.DeepEqual(invList, want) {
+		t.Errorf("SortInvVects: got %v, want %v", spew.Sdump(invList),
+			spew.Sdump(want))
+	}
+
+	invList = []*InvVect{txB, txA, blockA, dupTxA, txB}
+	deduped := DedupInvVects(invList)
+	want = []*InvVect{txB, txA, blockA}
+	if !reflect.DeepEqual(deduped, want) {
+		t.Errorf("DedupInvVects: got %v, want %v", spew.Sdump(deduped),
+			spew.Sdump(want))
+	}
+	if len(invList) != 5 || invList[3] != dupTxA {
+		t.Errorf("DedupInvVects: modified the passed slice")
+	}
+
+	merged := MergeInvVects([]*InvVect{blockA, txB}, nil,
+		[]*InvVect{dupTxA, txB, txC})
+	want = []*InvVect{dupTxA, txC, txB, blockA}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeInvVects: got %v, want %v", spew.Sdump(merged),
+			spew.Sdump(want))
+	}
+}
+
+// TestSplitInvVects tests splitting inventory vectors into batches around the
+// boundary sizes.
+func TestSplitInvVects(t *testing.T) {
+	makeInvs := func(n int) []*InvVect {
+		invList := make([]*InvVect, n)
+		for i := range invList {
+			hash := chainhash.Hash{byte(i), byte(i >> 8), byte(i >> 16)}
+			invList[i] = NewInvVect(InvTypeTx, &hash)
+		}
+		return invList
+	}
+
+	tests := []struct {
+		name      string
+		numInvs   int
+		maxPerMsg int
+		want      []int // sizes of the batches
+	}{
+		{"empty", 0, 10, []int{}},
+		{"single", 1, 10, []int{1}},
+		{"one below max", 9, 10, []int{9}},
+		{"exactly max", 10, 10, []int{10}},
+		{"one above max", 11, 10, []int{10, 1}},
+		{"multiple of max", 30, 10, []int{10, 10, 10}},
+		{"max of one", 3, 1, []int{1, 1, 1}},
+		{"zero max", MaxInvPerMsg + 1, 0, []int{MaxInvPerMsg, 1}},
+		{"negative max", MaxInvPerMsg, -1, []int{MaxInvPerMsg}},
+		{"max above protocol limit", MaxInvPerMsg*2 + 1, MaxInvPerMsg * 3,
+			[]int{MaxInvPerMsg, MaxInvPerMsg, 1}},
+	}
+
+	for _, test := range tests {
+		invList := makeInvs(test.numInvs)
+		batches := SplitInvVects(invList, test.maxPerMsg)
+		sizes := make([]int, 0, len(batches))
+		var joined []*InvVect
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch))
+			joined = append(joined, batch...)
+		}
+		if !reflect.DeepEqual(sizes, test.want) {
+			t.Errorf("%s: mismatched batch sizes - got %v, want %v",
+				test.name, sizes, test.want)
+			continue
+		}
+		if len(joined) != len(invList) {
+			t.Errorf("%s: got %d inventory vectors, want %d",
+				test.name, len(joined), len(invList))
+			continue
+		}
+		for i := range joined {
+			if joined[i] != invList[i] {
+				t.Errorf("%s: mismatched inventory vector at "+
+					"index %d", test.name, i)
+				break
+			}
+		}
+
+		// Appending to a batch must not affect the next one.
+		if len(batches) > 1 {
+			next := batches[1][0]
+			_ = append(batches[0], NewInvVect(InvTypeBlock,
+				&chainhash.Hash{}))
+			if batches[1][0] != next {
+				t.Errorf("%s: appending to a batch modified the "+
+					"next batch", test.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// MessageLimits houses limits on the messages read by ReadMessageWithLimitsN
+// which are stricter than the limits of the protocol.  They allow callers to
+// bound the resources a remote peer may consume with a single message.
+//
+// The zero value only enforces the limits of the protocol, however the counts
+// of inv, getdata, notfound, addr, addrv2 and headers messages are checked as
+// soon as they are read, before the rest of the payload is read.
+type MessageLimits struct {
+	// MaxPayloads maps message commands to the maximum length of the
+	// payload of messages of that type.  Lengths which exceed the maximum
+	// payload length of the message type for the protocol version have no
+	// effect.
+	MaxPayloads map[string]uint32
+
+	// MaxInvCount is the maximum number of inventory vectors in inv,
+	// getdata and notfound messages.  It defaults to MaxInvPerMsg when it
+	// is zero or exceeds it.
+	MaxInvCount uint32
+
+	// MaxAddrCount is the maximum number of addresses in addr and addrv2
+	// messages.  It defaults to MaxAddrPerMsg when it is zero or exceeds it.
+	MaxAddrCount uint32
+
+	// MaxHeadersCount is the maximum number of block headers in headers
+	// messages.  It defaults to MaxBlockHeadersPerMsg when it is zero or
+	// exceeds it.
+	MaxHeadersCount uint32
+}
+
+// maxPayload returns the maximum payload length of messages with the passed
+// command given the maximum payload length of the message type.
+func (l *MessageLimits) maxPayload(command string, mpl uint32) uint32 {
+	if max, ok := l.MaxPayloads[command]; ok && max < mpl {
+		return max
+	}
+	return mpl
+}
+
+// maxCount returns the maximum count of items in messages with the passed
+// command along with a description of the items.  False is returned for
+// messages whose payload doesn't start with a count.
+func (l *MessageLimits) maxCount(command string) (uint32, string, bool) {
+	limit := func(max, protocolMax uint32) uint32 {
+		if max == 0 || max > protocolMax {
+			return protocolMax
+		}
+		return max
+	}
+
+	switch command {
+	case CmdInv, CmdGetData, CmdNotFound:
+		return limit(l.MaxInvCount, MaxInvPerMsg), "inventory vectors", true
+	case CmdAddr, CmdAddrV2:
+		return limit(l.MaxAddrCount, MaxAddrPerMsg), "addresses", true
+	case CmdHeaders:
+		return limit(l.MaxHeadersCount, MaxBlockHeadersPerMsg),
+			"block headers", true
+	}
+	return 0, "", false
+}
+
+// RejectReason describes a message which was read in full or in part but was
+// rejected since it exceeds the limits of the reader.  It houses the details
+// of the reject message which may be sent to the remote peer.
+type RejectReason struct {
+	Cmd    string     // Command of the rejected message
+	Code   RejectCode // Code indicating why the message was rejected
+	Reason string     // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *RejectReason) Error() string {
+	return fmt.Sprintf("rejected %s message (%v): %s", e.Cmd, e.Code,
+		e.Reason)
+}
+
+// MsgReject returns a reject message which informs the remote peer of the
+// rejection.
+func (e *RejectReason) MsgReject() *MsgReject {
+	return NewMsgReject(e.Cmd, e.Code, e.Reason)
+}
+
+// rejectReason creates an error for the given command, code and reason.
+func rejectReason(cmd string, code RejectCode, reason string) *RejectReason {
+	return &RejectReason{Cmd: cmd, Code: code, Reason: reason}
+}
+
+// readCountPrefix reads the count at the start of the payload with the passed
+// length of messages with the passed command when the limits restrict it.  It
+// returns the bytes which were read so they can be prepended to the rest of
+// the payload.  The count is not checked when it can't be read, which is left
+// to the decoding of the message.
+func (l *MessageLimits) readCountPrefix(r io.Reader, pver uint32,
+	command string, length uint32) ([]byte, error) {
+
+	max, items, ok := l.maxCount(command)
+	if !ok {
+		return nil, nil
+	}
+
+	// Never read beyond the payload, which would consume the next message
+	// when the payload is too short to hold the count.
+	var prefix bytes.Buffer
+	tr := io.TeeReader(io.LimitReader(r, int64(length)), &prefix)
+	count, err := ReadVarInt(tr, pver)
+	if err != nil {
+		return prefix.Bytes(), nil
+	}
+	if count > uint64(max) {
+		discardInput(r, length-uint32(prefix.Len()))
+		str := fmt.Sprintf("message has %d %s, but at most %d are "+
+			"allowed", count, items, max)
+		return nil, rejectReason(command, RejectInvalid, str)
+	}
+	return prefix.Bytes(), nil
+}
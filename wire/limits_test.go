@@ -0,0 +1,171 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestReadMessageWithLimits ensures messages which exceed the limits of the
+// reader are rejected with the expected reject codes and that the rest of
+// their payloads is discarded so the following message can be read.
+func TestReadMessageWithLimits(t *testing.T) {
+	pver := ProtocolVersion
+	btcnet := MainNet
+
+	encode := func(msg Message) []byte {
+		var buf bytes.Buffer
+		if err := WriteMessage(&buf, msg, pver, btcnet); err != nil {
+			t.Fatalf("WriteMessage: unexpected error: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	inv := NewMsgInv()
+	for i := byte(0); i < 3; i++ {
+		inv.AddInvVect(NewInvVect(InvTypeTx, &chainhash.Hash{i}))
+	}
+	ping := NewMsgPing(123123)
+
+	// An addr message whose payload is too short to hold its count.
+	shortAddr := makeHeader(btcnet, CmdAddr, 1, 0)
+	shortAddr = append(shortAddr, 0xfd)
+
+	tests := []struct {
+		name   string
+		limits *MessageLimits
+		buf    []byte  // Wire encoding of the message
+		msg    Message // Expected decoded message
+		code   RejectCode
+		err    bool // Whether a non-reject error is expected
+	}{
+		{
+			name:   "inv within limits",
+			limits: &MessageLimits{MaxInvCount: 3},
+			buf:    encode(inv),
+			msg:    inv,
+		},
+		{
+			name:   "inv exceeds count",
+			limits: &MessageLimits{MaxInvCount: 2},
+			buf:    encode(inv),
+			code:   RejectInvalid,
+		},
+		{
+			name:   "inv without limits",
+			limits: nil,
+			buf:    encode(inv),
+			msg:    inv,
+		},
+		{
+			name: "ping exceeds payload",
+			limits: &MessageLimits{
+				MaxPayloads: map[string]uint32{CmdPing: 7},
+			},
+			buf:  encode(ping),
+			code: RejectInvalid,
+		},
+		{
+			name: "ping within payload",
+			limits: &MessageLimits{
+				MaxPayloads: map[string]uint32{CmdPing: 8},
+			},
+			buf: encode(ping),
+			msg: ping,
+		},
+		{
+			name:   "addr too short for count",
+			limits: &MessageLimits{},
+			buf:    shortAddr,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		// The message is followed by a ping which must be read in full
+		// regardless of how the message was handled.
+		buf := append(append([]byte{}, test.buf...), encode(ping)...)
+		r := bytes.NewReader(buf)
+
+		_, msg, payload, err := ReadMessageWithLimitsN(r, pver, btcnet,
+			BaseEncoding, test.limits)
+		var reject *RejectReason
+		switch {
+		case test.code != 0:
+			var ok bool
+			reject, ok = err.(*RejectReason)
+			if !ok || reject.Code != test.code {
+				t.Errorf("%s: got error %v, want code %v", test.name,
+					err, test.code)
+				continue
+			}
+		case test.err:
+			if err == nil {
+				t.Errorf("%s: read message without error", test.name)
+				continue
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		default:
+			if !reflect.DeepEqual(msg, test.msg) {
+				t.Errorf("%s: mismatched message - got %v, want %v",
+					test.name, spew.Sdump(msg),
+					spew.Sdump(test.msg))
+				continue
+			}
+			if !bytes.Equal(payload, test.buf[MessageHeaderSize:]) {
+				t.Errorf("%s: mismatched payload - got %x, want %x",
+					test.name, payload,
+					test.buf[MessageHeaderSize:])
+				continue
+			}
+		}
+		command := string(bytes.TrimRight(test.buf[4:16], "\x00"))
+		if reject != nil && reject.MsgReject().Cmd != command {
+			t.Errorf("%s: got reject message for %q, want %q",
+				test.name, reject.MsgReject().Cmd, command)
+		}
+
+		_, msg, _, err = ReadMessageN(r, pver, btcnet)
+		if err != nil || !reflect.DeepEqual(msg, ping) {
+			t.Errorf("%s: got next message %v (%v), want ping",
+				test.name, msg, err)
+		}
+	}
+}
+
+// TestReadMessageWithLimitsEarlyAbort ensures messages with counts that exceed
+// the limits are rejected without waiting for the rest of their payload.
+func TestReadMessageWithLimitsEarlyAbort(t *testing.T) {
+	pver := ProtocolVersion
+	btcnet := MainNet
+
+	// The header declares a large payload, but only the count of headers
+	// follows it.
+	buf := makeHeader(btcnet, CmdHeaders, 100000, 0)
+	buf = append(buf, 0xfd, 0xd1, 0x07)
+
+	r := newFixedReader(len(buf), buf)
+	_, _, _, err := ReadMessageWithLimitsN(r, pver, btcnet, BaseEncoding,
+		&MessageLimits{})
+	if _, ok := err.(*RejectReason); !ok {
+		t.Fatalf("got error %v <%T>, want *RejectReason", err, err)
+	}
+
+	// Without limits, the rest of the payload is read.
+	r = newFixedReader(len(buf), buf)
+	_, _, _, err = ReadMessageWithLimitsN(r, pver, btcnet, BaseEncoding, nil)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v <%T>, want %v", err, err,
+			io.ErrUnexpectedEOF)
+	}
+}
@@ -58,6 +58,30 @@ const (
 	CmdCFHeaders    = "cfheaders"
 	CmdCFCheckpt    = "cfcheckpt"
 	CmdSendAddrV2   = "sendaddrv2"
+	CmdAddrV2       = "addrv2"
+	CmdSendCmpct    = "sendcmpct"
+	CmdCmpctBlock   = "cmpctblock"
+	CmdGetBlockTxn  = "getblocktxn"
+	CmdBlockTxn     = "blocktxn"
+
+	// Dash specific messages.
+	CmdGetMnListDiff = "getmnlistd"
+	CmdMnListDiff    = "mnlistdiff"
+	CmdGetQRInfo     = "getqrinfo"
+	CmdQRInfo        = "qrinfo"
+	CmdSpork         = "spork"
+	CmdGetSporks     = "getsporks"
+	CmdSendDsq       = "senddsq"
+	CmdQSendRecSigs  = "qsendrecsigs"
+	CmdMNAuth        = "mnauth"
+	CmdCLSig         = "clsig"
+	CmdISLock        = "islock"
+	CmdISDLock       = "isdlock"
+	CmdQFCommit      = "qfcommit"
+	CmdQContrib      = "qcontrib"
+	CmdQComplaint    = "qcomplaint"
+	CmdQJustify      = "qjustify"
+	CmdQPCommit      = "qpcommit"
 )
 
 // MessageEncoding represents the wire message encoding format to be used.
@@ -90,8 +114,22 @@ type Message interface {
 }
 
 // makeEmptyMessage creates a message of the appropriate concrete type based
-// on the command.
+// on the command.  Commands that are not built into the package are looked up
+// in the registry of custom messages.
 func makeEmptyMessage(command string) (Message, error) {
+	if msg := makeBuiltinMessage(command); msg != nil {
+		return msg, nil
+	}
+	if msg := makeCustomMessage(command); msg != nil {
+		return msg, nil
+	}
+	return nil, fmt.Errorf("unhandled command [%s]", command)
+}
+
+// makeBuiltinMessage creates a message of the appropriate concrete type based
+// on the command for the commands built into the package.  It returns nil for
+// any other command.
+func makeBuiltinMessage(command string) Message {
 	var msg Message
 	switch command {
 	case CmdVersion:
@@ -109,6 +147,9 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdAddr:
 		msg = &MsgAddr{}
 
+	case CmdAddrV2:
+		msg = &MsgAddrV2{}
+
 	case CmdGetBlocks:
 		msg = &MsgGetBlocks{}
 
@@ -184,10 +225,70 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdCFCheckpt:
 		msg = &MsgCFCheckpt{}
 
-	default:
-		return nil, fmt.Errorf("unhandled command [%s]", command)
+	case CmdSendCmpct:
+		msg = &MsgSendCmpct{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
+	case CmdGetMnListDiff:
+		msg = &MsgGetMnListDiff{}
+
+	case CmdMnListDiff:
+		msg = &MsgMnListDiff{}
+
+	case CmdGetQRInfo:
+		msg = &MsgGetQRInfo{}
+
+	case CmdQRInfo:
+		msg = &MsgQRInfo{}
+
+	case CmdSpork:
+		msg = &MsgSpork{}
+
+	case CmdGetSporks:
+		msg = &MsgGetSporks{}
+
+	case CmdSendDsq:
+		msg = &MsgSendDsq{}
+
+	case CmdQSendRecSigs:
+		msg = &MsgQSendRecSigs{}
+
+	case CmdMNAuth:
+		msg = &MsgMNAuth{}
+
+	case CmdCLSig:
+		msg = &MsgCLSig{}
+
+	case CmdISLock:
+		msg = &MsgISLock{}
+
+	case CmdISDLock:
+		msg = &MsgISLock{Version: DeterministicISLockVersion}
+
+	case CmdQFCommit:
+		msg = &MsgQFCommit{}
+
+	case CmdQContrib:
+		msg = &MsgQContrib{}
+
+	case CmdQComplaint:
+		msg = &MsgQComplaint{}
+
+	case CmdQJustify:
+		msg = &MsgQJustify{}
+
+	case CmdQPCommit:
+		msg = &MsgQPCommit{}
 	}
-	return msg, nil
+	return msg
 }
 
 // messageHeader defines the header structure for all bitcoin protocol messages.
@@ -259,6 +360,52 @@ func WriteMessage(w io.Writer, msg Message, pver uint32, btcnet BitcoinNet) erro
 	return err
 }
 
+// payloadFreeList defines a free list of buffers (up to the maximum number
+// defined by the payloadFreeListMaxItems constant) used to encode the payloads
+// of messages in order to reduce the number of allocations required to write
+// messages.  Buffers which grew beyond payloadFreeListMaxBufferSize are not
+// kept so the occasional large message, such as a block, doesn't pin memory.
+type payloadFreeList chan *bytes.Buffer
+
+const (
+	// payloadFreeListMaxItems is the number of buffers to keep in the free
+	// list used to encode the payloads of messages.
+	payloadFreeListMaxItems = 64
+
+	// payloadFreeListMaxBufferSize is the largest capacity of the buffers
+	// kept in the free list used to encode the payloads of messages.
+	payloadFreeListMaxBufferSize = 1 << 20
+)
+
+// Borrow returns an empty buffer from the free list.  A new buffer is
+// allocated if there are not any available on the free list.
+func (l payloadFreeList) Borrow() *bytes.Buffer {
+	select {
+	case buf := <-l:
+		return buf
+	default:
+		return new(bytes.Buffer)
+	}
+}
+
+// Return resets the provided buffer and puts it back on the free list unless
+// it is too large to be kept.
+func (l payloadFreeList) Return(buf *bytes.Buffer) {
+	if buf.Cap() > payloadFreeListMaxBufferSize {
+		return
+	}
+	buf.Reset()
+	select {
+	case l <- buf:
+	default:
+		// Let it go to the garbage collector.
+	}
+}
+
+// payloadPool provides a free list of buffers to use for encoding the payloads
+// of messages.
+var payloadPool payloadFreeList = make(chan *bytes.Buffer, payloadFreeListMaxItems)
+
 // WriteMessageWithEncodingN writes a bitcoin Message to w including the
 // necessary header information and returns the number of bytes written.
 // This function is the same as WriteMessageN except it also allows the caller
@@ -280,8 +427,9 @@ func WriteMessageWithEncodingN(w io.Writer, msg Message, pver uint32,
 	copy(command[:], []byte(cmd))
 
 	// Encode the message payload.
-	var bw bytes.Buffer
-	err := msg.BtcEncode(&bw, pver, encoding)
+	bw := payloadPool.Borrow()
+	defer payloadPool.Return(bw)
+	err := msg.BtcEncode(bw, pver, encoding)
 	if err != nil {
 		return totalBytes, err
 	}
@@ -335,14 +483,17 @@ func WriteMessageWithEncodingN(w io.Writer, msg Message, pver uint32,
 	return totalBytes, err
 }
 
-// ReadMessageWithEncodingN reads, validates, and parses the next bitcoin Message
-// from r for the provided protocol version and bitcoin network.  It returns the
-// number of bytes read in addition to the parsed Message and raw bytes which
-// comprise the message.  This function is the same as ReadMessageN except it
-// allows the caller to specify which message encoding is to to consult when
-// decoding wire messages.
-func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
-	enc MessageEncoding) (int, Message, []byte, error) {
+// ReadMessageWithLimitsN reads, validates, and parses the next bitcoin Message
+// from r for the provided protocol version and bitcoin network, subject to the
+// passed limits.  It returns the number of bytes read in addition to the parsed
+// Message and raw bytes which comprise the message.  This function is the same
+// as ReadMessageWithEncodingN except it rejects messages which exceed the
+// limits with a *RejectReason error.  The counts of messages which start with
+// one are checked before the rest of the payload is read, so oversized
+// messages are rejected without reading them in full.  Nil limits only enforce
+// the limits of the protocol once the message is decoded.
+func ReadMessageWithLimitsN(r io.Reader, pver uint32, btcnet BitcoinNet,
+	enc MessageEncoding, limits *MessageLimits) (int, Message, []byte, error) {
 
 	totalBytes := 0
 	n, hdr, err := readMessageHeader(r)
@@ -395,9 +546,31 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 		return totalBytes, nil, nil, messageError("ReadMessage", str)
 	}
 
+	// Enforce the limits of the caller, which may be stricter than those of
+	// the message type.
+	var prefix []byte
+	if limits != nil {
+		max := limits.maxPayload(command, mpl)
+		if hdr.length > max {
+			discardInput(r, hdr.length)
+			str := fmt.Sprintf("payload of %d bytes exceeds the "+
+				"limit of %d bytes", hdr.length, max)
+			return totalBytes, nil, nil, rejectReason(command,
+				RejectInvalid, str)
+		}
+
+		prefix, err = limits.readCountPrefix(r, pver, command,
+			hdr.length)
+		totalBytes += len(prefix)
+		if err != nil {
+			return totalBytes, nil, nil, err
+		}
+	}
+
 	// Read payload.
 	payload := make([]byte, hdr.length)
-	n, err = io.ReadFull(r, payload)
+	copy(payload, prefix)
+	n, err = io.ReadFull(r, payload[len(prefix):])
 	totalBytes += n
 	if err != nil {
 		return totalBytes, nil, nil, err
@@ -423,6 +596,18 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	return totalBytes, msg, payload, nil
 }
 
+// ReadMessageWithEncodingN reads, validates, and parses the next bitcoin Message
+// from r for the provided protocol version and bitcoin network.  It returns the
+// number of bytes read in addition to the parsed Message and raw bytes which
+// comprise the message.  This function is the same as ReadMessageN except it
+// allows the caller to specify which message encoding is to to consult when
+// decoding wire messages.
+func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
+	enc MessageEncoding) (int, Message, []byte, error) {
+
+	return ReadMessageWithLimitsN(r, pver, btcnet, enc, nil)
+}
+
 // ReadMessageN reads, validates, and parses the next bitcoin Message from r for
 // the provided protocol version and bitcoin network.  It returns the number of
 // bytes read in addition to the parsed Message and raw bytes which comprise the
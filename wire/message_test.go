@@ -76,6 +76,40 @@ func TestMessage(t *testing.T) {
 		[]byte("payload"))
 	msgCFHeaders := NewMsgCFHeaders()
 	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &chainhash.Hash{}, 0)
+	msgSpork := NewMsgSpork(10001, 0, 1700000000, make([]byte, 65))
+	msgGetSporks := NewMsgGetSporks()
+	msgSendDsq := NewMsgSendDsq(true)
+	msgQSendRecSigs := NewMsgQSendRecSigs(true)
+	msgMNAuth := NewMsgMNAuth(&chainhash.Hash{0x01},
+		&[BLSSignatureSize]byte{0x02})
+	msgCLSig := NewMsgCLSig(1000, &chainhash.Hash{0x01},
+		&[BLSSignatureSize]byte{0x02})
+	msgISDLock := NewMsgISLock([]OutPoint{{Hash: chainhash.Hash{0x01}}},
+		&chainhash.Hash{0x02}, &chainhash.Hash{0x03},
+		&[BLSSignatureSize]byte{0x04})
+	msgISLock := *msgISDLock
+	msgISLock.Version = 0
+	msgISLock.CycleHash = chainhash.Hash{}
+	msgSendCmpct := NewMsgSendCmpct(true, CmpctBlockVersion)
+	msgCmpctBlock := NewMsgCmpctBlock(&MsgBlock{Header: *bh}, 1)
+	msgGetBlockTxn := NewMsgGetBlockTxn(&chainhash.Hash{0x01}, []uint32{0, 2})
+	msgBlockTxn := NewMsgBlockTxn(&chainhash.Hash{0x01},
+		[]*MsgTx{})
+	msgSendAddrV2 := NewMsgSendAddrV2()
+	msgAddrV2 := NewMsgAddrV2()
+	msgAddrV2.AddAddress(NewNetAddressV2(time.Unix(0x495fab29, 0),
+		SFNodeNetwork, NetTorV3, make([]byte, 32), 9999))
+	dkgSession := &DKGSession{LLMQType: 1, QuorumHash: chainhash.Hash{0x01},
+		ProTxHash: chainhash.Hash{0x02}}
+	msgQFCommit := NewMsgQFCommit(&QuorumCommitment{Version: 1,
+		Signers: make([]bool, 3), ValidMembers: make([]bool, 3)})
+	msgQContrib := NewMsgQContrib(dkgSession)
+	msgQContrib.VVec = make([][BLSPublicKeySize]byte, 1)
+	msgQContrib.Contributions = [][]byte{make([]byte, BLSSecretKeySize)}
+	msgQComplaint := NewMsgQComplaint(dkgSession, 3)
+	msgQJustify := NewMsgQJustify(dkgSession)
+	msgQJustify.Contributions = []DKGJustifiedContribution{{Index: 2}}
+	msgQPCommit := NewMsgQPCommit(dkgSession, 3)
 
 	tests := []struct {
 		in     Message    // Value to encode
@@ -84,7 +118,7 @@ func TestMessage(t *testing.T) {
 		btcnet BitcoinNet // Network to use for wire encoding
 		bytes  int        // Expected num bytes read/written
 	}{
-		{msgVersion, msgVersion, pver, MainNet, 125},
+		{msgVersion, msgVersion, pver, MainNet, 157},
 		{msgVerack, msgVerack, pver, MainNet, 24},
 		{msgGetAddr, msgGetAddr, pver, MainNet, 24},
 		{msgAddr, msgAddr, pver, MainNet, 25},
@@ -111,6 +145,25 @@ func TestMessage(t *testing.T) {
 		{msgCFilter, msgCFilter, pver, MainNet, 65},
 		{msgCFHeaders, msgCFHeaders, pver, MainNet, 90},
 		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},
+		{msgSpork, msgSpork, pver, MainNet, 110},
+		{msgGetSporks, msgGetSporks, pver, MainNet, 24},
+		{msgSendDsq, msgSendDsq, pver, MainNet, 25},
+		{msgQSendRecSigs, msgQSendRecSigs, pver, MainNet, 25},
+		{msgMNAuth, msgMNAuth, pver, MainNet, 152},
+		{msgCLSig, msgCLSig, pver, MainNet, 156},
+		{&msgISLock, &msgISLock, pver, MainNet, 189},
+		{msgISDLock, msgISDLock, pver, MainNet, 222},
+		{msgSendCmpct, msgSendCmpct, pver, MainNet, 33},
+		{msgCmpctBlock, msgCmpctBlock, pver, MainNet, 114},
+		{msgGetBlockTxn, msgGetBlockTxn, pver, MainNet, 59},
+		{msgBlockTxn, msgBlockTxn, pver, MainNet, 57},
+		{msgSendAddrV2, msgSendAddrV2, pver, MainNet, 24},
+		{msgAddrV2, msgAddrV2, pver, MainNet, 66},
+		{msgQFCommit, msgQFCommit, pver, MainNet, 335},
+		{msgQContrib, msgQContrib, pver, MainNet, 348},
+		{msgQComplaint, msgQComplaint, pver, MainNet, 189},
+		{msgQJustify, msgQJustify, pver, MainNet, 222},
+		{msgQPCommit, msgQPCommit, pver, MainNet, 363},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -457,3 +510,55 @@ func TestWriteMessageWireErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestDashMessageProtocolVersion ensures the Dash messages which were added by
+// later protocol versions can't be encoded or decoded with earlier ones.
+func TestDashMessageProtocolVersion(t *testing.T) {
+	islock := MsgISLock{}
+	tests := []struct {
+		msg         Message
+		minProtoVer uint32
+	}{
+		{NewMsgSendDsq(true), LLMQVersion},
+		{NewMsgQSendRecSigs(true), LLMQVersion},
+		{&MsgMNAuth{}, LLMQVersion},
+		{&MsgCLSig{}, LLMQVersion},
+		{&islock, LLMQVersion},
+		{&MsgISLock{Version: DeterministicISLockVersion}, ISDLockVersion},
+		{NewMsgSendCmpct(false, CmpctBlockVersion), ShortIDsBlocksVersion},
+		{&MsgCmpctBlock{}, ShortIDsBlocksVersion},
+		{&MsgGetBlockTxn{}, ShortIDsBlocksVersion},
+		{&MsgBlockTxn{}, ShortIDsBlocksVersion},
+		{NewMsgSendAddrV2(), AddrV2Version},
+		{NewMsgAddrV2(), AddrV2Version},
+		{&MsgQFCommit{}, LLMQVersion},
+		{&MsgQContrib{}, LLMQVersion},
+		{&MsgQComplaint{}, LLMQVersion},
+		{&MsgQJustify{}, LLMQVersion},
+		{&MsgQPCommit{}, LLMQVersion},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := test.msg.BtcEncode(&buf, test.minProtoVer, BaseEncoding)
+		if err != nil {
+			t.Errorf("%s: BtcEncode error %v", test.msg.Command(), err)
+			continue
+		}
+		encoded := buf.Bytes()
+
+		err = test.msg.BtcEncode(&buf, test.minProtoVer-1, BaseEncoding)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: BtcEncode with protocol version %d got "+
+				"error %v", test.msg.Command(), test.minProtoVer-1,
+				err)
+		}
+		err = test.msg.BtcDecode(bytes.NewReader(encoded),
+			test.minProtoVer-1, BaseEncoding)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: BtcDecode with protocol version %d got "+
+				"error %v", test.msg.Command(), test.minProtoVer-1,
+				err)
+		}
+	}
+}
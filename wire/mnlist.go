@@ -0,0 +1,343 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// SimplifiedMNList houses the simplified masternode list along with the set of
+// active quorums as of a given block.  It is built up by applying masternode
+// list diffs via ApplyMnListDiff and is never modified in place, so a list may
+// be safely shared once created.
+type SimplifiedMNList struct {
+	// BlockHash identifies the block the list is as of.
+	BlockHash chainhash.Hash
+
+	entries map[chainhash.Hash]*SimplifiedMNListEntry
+	quorums map[DeletedQuorum]*QuorumCommitment
+}
+
+// Len returns the number of masternodes in the list.
+func (l *SimplifiedMNList) Len() int {
+	return len(l.entries)
+}
+
+// Entry returns the entry of the masternode identified by the passed
+// registration transaction hash along with whether or not it exists.
+func (l *SimplifiedMNList) Entry(proRegTxHash *chainhash.Hash) (*SimplifiedMNListEntry, bool) {
+	entry, ok := l.entries[*proRegTxHash]
+	return entry, ok
+}
+
+// Entries returns all entries of the list sorted by their registration
+// transaction hash, which is the order they are committed to in the masternode
+// list merkle root.
+func (l *SimplifiedMNList) Entries() []*SimplifiedMNListEntry {
+	entries := make([]*SimplifiedMNListEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].ProRegTxHash[:],
+			entries[j].ProRegTxHash[:]) < 0
+	})
+
+	return entries
+}
+
+// Quorum returns the final commitment of the active quorum identified by the
+// passed type and quorum hash along with whether or not it exists.
+func (l *SimplifiedMNList) Quorum(llmqType LLMQType, quorumHash *chainhash.Hash) (*QuorumCommitment, bool) {
+	qc, ok := l.quorums[DeletedQuorum{LLMQType: llmqType, QuorumHash: *quorumHash}]
+	return qc, ok
+}
+
+// Quorums returns the final commitments of all active quorums in no particular
+// order.
+func (l *SimplifiedMNList) Quorums() []*QuorumCommitment {
+	quorums := make([]*QuorumCommitment, 0, len(l.quorums))
+	for _, qc := range l.quorums {
+		quorums = append(quorums, qc)
+	}
+
+	return quorums
+}
+
+// MerkleRoot returns the merkle root of the masternode list as committed to by
+// the MerkleRootMNList field of coinbase payloads.
+func (l *SimplifiedMNList) MerkleRoot() chainhash.Hash {
+	entries := l.Entries()
+	leaves := make([]chainhash.Hash, len(entries))
+	for i, entry := range entries {
+		leaves[i] = entry.Hash()
+	}
+
+	return calcMerkleRoot(leaves)
+}
+
+// QuorumsMerkleRoot returns the merkle root of the active quorums as committed
+// to by the MerkleRootQuorums field of version 2 coinbase payloads.  The leaves
+// are the hashes of the final commitments sorted in ascending order.
+func (l *SimplifiedMNList) QuorumsMerkleRoot() chainhash.Hash {
+	leaves := make([]chainhash.Hash, 0, len(l.quorums))
+	for _, qc := range l.quorums {
+		leaves = append(leaves, qc.Hash())
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i][:], leaves[j][:]) < 0
+	})
+
+	return calcMerkleRoot(leaves)
+}
+
+// VerifyCbTx ensures the masternode list, and for version 2 payloads and later
+// the active quorums, match the merkle roots committed to in the passed
+// coinbase payload.
+func (l *SimplifiedMNList) VerifyCbTx(cbTx *CbTx) error {
+	if root := l.MerkleRoot(); root != cbTx.MerkleRootMNList {
+		str := fmt.Sprintf("masternode list merkle root %v does not "+
+			"match coinbase commitment %v", root, cbTx.MerkleRootMNList)
+		return messageError("SimplifiedMNList.VerifyCbTx", str)
+	}
+
+	if cbTx.Version < 2 {
+		return nil
+	}
+	if root := l.QuorumsMerkleRoot(); root != cbTx.MerkleRootQuorums {
+		str := fmt.Sprintf("quorums merkle root %v does not match "+
+			"coinbase commitment %v", root, cbTx.MerkleRootQuorums)
+		return messageError("SimplifiedMNList.VerifyCbTx", str)
+	}
+
+	return nil
+}
+
+// ApplyMnListDiff applies the passed masternode list diff to the base list and
+// returns the resulting list as of the block the diff leads to.  The base list
+// is not modified.  A nil base list, which is required when the diff has a zero
+// BaseBlockHash, is treated as the empty list.
+//
+// Deleted masternodes and quorums are removed, masternodes in the diff are
+// added or replace the existing entries, and new quorums are added.  Deleting
+// entries which do not exist is rejected since it indicates the diff was not
+// built on top of the base list.
+//
+// Finally, the resulting list is verified against the merkle roots committed to
+// by the coinbase transaction of the diff.  Note that this does not prove the
+// coinbase transaction belongs to the block; callers must additionally compare
+// the root returned by the CbTxMerkleRoot method of the diff against the block
+// header.
+func ApplyMnListDiff(base *SimplifiedMNList, diff *MsgMnListDiff) (*SimplifiedMNList, error) {
+	const op = "ApplyMnListDiff"
+
+	var zeroHash chainhash.Hash
+	switch {
+	case base == nil && diff.BaseBlockHash != zeroHash:
+		str := fmt.Sprintf("diff is based on block %v but no base "+
+			"list was provided", diff.BaseBlockHash)
+		return nil, messageError(op, str)
+
+	case base != nil && base.BlockHash != diff.BaseBlockHash:
+		str := fmt.Sprintf("diff is based on block %v but the base "+
+			"list is as of block %v", diff.BaseBlockHash,
+			base.BlockHash)
+		return nil, messageError(op, str)
+	}
+
+	cbTx, err := diff.CbTx.CbTxPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the base list so it remains unmodified.
+	list := &SimplifiedMNList{
+		BlockHash: diff.BlockHash,
+		entries:   make(map[chainhash.Hash]*SimplifiedMNListEntry),
+		quorums:   make(map[DeletedQuorum]*QuorumCommitment),
+	}
+	if base != nil {
+		for hash, entry := range base.entries {
+			list.entries[hash] = entry
+		}
+		for key, qc := range base.quorums {
+			list.quorums[key] = qc
+		}
+	}
+
+	for i := range diff.DeletedMNs {
+		hash := diff.DeletedMNs[i]
+		if _, ok := list.entries[hash]; !ok {
+			str := fmt.Sprintf("diff deletes unknown masternode %v",
+				hash)
+			return nil, messageError(op, str)
+		}
+		delete(list.entries, hash)
+	}
+	for _, entry := range diff.MNList {
+		list.entries[entry.ProRegTxHash] = entry
+	}
+
+	for _, key := range diff.DeletedQuorums {
+		if _, ok := list.quorums[key]; !ok {
+			str := fmt.Sprintf("diff deletes unknown quorum %v of "+
+				"type %d", key.QuorumHash, key.LLMQType)
+			return nil, messageError(op, str)
+		}
+		delete(list.quorums, key)
+	}
+	for _, qc := range diff.NewQuorums {
+		key := DeletedQuorum{LLMQType: qc.LLMQType, QuorumHash: qc.QuorumHash}
+		list.quorums[key] = qc
+	}
+
+	if err := list.VerifyCbTx(cbTx); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// hashMerkleBranches concatenates the passed hashes and returns the double
+// sha256 of the result.
+func hashMerkleBranches(left, right *chainhash.Hash) chainhash.Hash {
+	var hash [chainhash.HashSize * 2]byte
+	copy(hash[:chainhash.HashSize], left[:])
+	copy(hash[chainhash.HashSize:], right[:])
+
+	return chainhash.DoubleHashH(hash[:])
+}
+
+// calcMerkleRoot returns the merkle root of the passed leaves using the same
+// algorithm as the transaction merkle root of blocks, where the last hash of a
+// level with an odd number of hashes is paired with itself.  The root of no
+// leaves is the zero hash.
+func calcMerkleRoot(leaves []chainhash.Hash) chainhash.Hash {
+	if len(leaves) == 0 {
+		return chainhash.Hash{}
+	}
+
+	level := make([]chainhash.Hash, len(leaves))
+	copy(level, leaves)
+	for len(level) > 1 {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashMerkleBranches(&level[i], &level[i+1]))
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// partialMerkleTree houses the state used when extracting the matched hashes
+// from a partial merkle tree as used by merkleblock and mnlistdiff messages.
+type partialMerkleTree struct {
+	numTx    uint32
+	hashes   []chainhash.Hash
+	flags    []byte
+	bitsUsed uint32
+	hashUsed uint32
+	matches  []chainhash.Hash
+	bad      bool
+}
+
+// calcTreeWidth calculates the number of nodes at the passed height of the
+// tree.
+func (t *partialMerkleTree) calcTreeWidth(height uint32) uint32 {
+	return (t.numTx + (1 << height) - 1) >> height
+}
+
+// traverseAndExtract recursively walks the tree in depth-first order,
+// consuming flag bits and hashes, and returns the hash of the node at the
+// passed height and position.
+func (t *partialMerkleTree) traverseAndExtract(height, pos uint32) chainhash.Hash {
+	if t.bitsUsed >= uint32(len(t.flags))*8 {
+		t.bad = true
+		return chainhash.Hash{}
+	}
+	parentOfMatch := t.flags[t.bitsUsed/8]&(1<<(t.bitsUsed%8)) != 0
+	t.bitsUsed++
+
+	if height == 0 || !parentOfMatch {
+		if t.hashUsed >= uint32(len(t.hashes)) {
+			t.bad = true
+			return chainhash.Hash{}
+		}
+		hash := t.hashes[t.hashUsed]
+		t.hashUsed++
+		if height == 0 && parentOfMatch {
+			t.matches = append(t.matches, hash)
+		}
+		return hash
+	}
+
+	left := t.traverseAndExtract(height-1, pos*2)
+	right := left
+	if pos*2+1 < t.calcTreeWidth(height-1) {
+		right = t.traverseAndExtract(height-1, pos*2+1)
+
+		// Identical children would allow a merkle tree malleation
+		// (CVE-2012-2459).
+		if right == left {
+			t.bad = true
+		}
+	}
+
+	return hashMerkleBranches(&left, &right)
+}
+
+// extractPartialMerkleRoot returns the merkle root of the partial merkle tree
+// described by the passed total number of transactions, hashes and flag bits
+// along with the hashes of the transactions it proves.
+func extractPartialMerkleRoot(numTx uint32, hashes []chainhash.Hash, flags []byte) (chainhash.Hash, []chainhash.Hash, error) {
+	const op = "extractPartialMerkleRoot"
+
+	switch {
+	case numTx == 0:
+		return chainhash.Hash{}, nil, messageError(op, "partial merkle "+
+			"tree has no transactions")
+
+	case uint64(len(hashes)) > uint64(numTx):
+		str := fmt.Sprintf("partial merkle tree has more hashes than "+
+			"transactions [hashes %d, transactions %d]", len(hashes),
+			numTx)
+		return chainhash.Hash{}, nil, messageError(op, str)
+
+	case len(flags)*8 < len(hashes):
+		str := fmt.Sprintf("partial merkle tree has fewer flag bits "+
+			"than hashes [bits %d, hashes %d]", len(flags)*8,
+			len(hashes))
+		return chainhash.Hash{}, nil, messageError(op, str)
+	}
+
+	t := partialMerkleTree{numTx: numTx, hashes: hashes, flags: flags}
+	var height uint32
+	for t.calcTreeWidth(height) > 1 {
+		height++
+	}
+
+	root := t.traverseAndExtract(height, 0)
+	switch {
+	case t.bad:
+		return chainhash.Hash{}, nil, messageError(op, "malformed "+
+			"partial merkle tree")
+
+	// All flag bytes and hashes must have been consumed.
+	case (t.bitsUsed+7)/8 != uint32(len(flags)),
+		t.hashUsed != uint32(len(hashes)):
+		return chainhash.Hash{}, nil, messageError(op, "partial merkle "+
+			"tree has unused data")
+	}
+
+	return root, t.matches, nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// TestApplyMnListDiff ensures masternode list diffs are applied with the
+// expected deletion and update semantics and verified against the coinbase
+// commitments.
+func TestApplyMnListDiff(t *testing.T) {
+	block1 := chainhash.Hash{0x01}
+	block2 := chainhash.Hash{0x02}
+
+	// Build the expected list for the full diff and commit to it in the
+	// coinbase transaction.
+	entries := []*SimplifiedMNListEntry{
+		newTestSMLEntry(3), newTestSMLEntry(1), newTestSMLEntry(2),
+	}
+	quorums := []*QuorumCommitment{
+		newTestQuorumCommitment(1), newTestQuorumCommitment(2),
+	}
+	expected := &SimplifiedMNList{
+		entries: make(map[chainhash.Hash]*SimplifiedMNListEntry),
+		quorums: make(map[DeletedQuorum]*QuorumCommitment),
+	}
+	for _, entry := range entries {
+		expected.entries[entry.ProRegTxHash] = entry
+	}
+	for _, qc := range quorums {
+		key := DeletedQuorum{LLMQType: qc.LLMQType, QuorumHash: qc.QuorumHash}
+		expected.quorums[key] = qc
+	}
+
+	full := NewMsgMnListDiff(&chainhash.Hash{}, &block1)
+	full.MNList = entries
+	full.NewQuorums = quorums
+	full.CbTx = newTestCbTx(1, expected.MerkleRoot(),
+		expected.QuorumsMerkleRoot())
+
+	list, err := ApplyMnListDiff(nil, full)
+	if err != nil {
+		t.Fatalf("ApplyMnListDiff: unexpected error: %v", err)
+	}
+	if list.BlockHash != block1 || list.Len() != 3 ||
+		len(list.Quorums()) != 2 {
+
+		t.Fatalf("ApplyMnListDiff: unexpected list %v", list)
+	}
+	sorted := list.Entries()
+	for i := range sorted {
+		if sorted[i].ProRegTxHash[0] != byte(i+1) {
+			t.Fatalf("Entries: unexpected order at %d", i)
+		}
+	}
+
+	// Delete one masternode and one quorum and update another masternode.
+	updated := newTestSMLEntry(2)
+	updated.IsValid = false
+	delete(expected.entries, entries[0].ProRegTxHash)
+	expected.entries[updated.ProRegTxHash] = updated
+	delete(expected.quorums, DeletedQuorum{LLMQType: 1, QuorumHash: quorums[0].QuorumHash})
+
+	diff := NewMsgMnListDiff(&block1, &block2)
+	diff.DeletedMNs = []chainhash.Hash{entries[0].ProRegTxHash}
+	diff.MNList = []*SimplifiedMNListEntry{updated}
+	diff.DeletedQuorums = []DeletedQuorum{
+		{LLMQType: 1, QuorumHash: quorums[0].QuorumHash},
+	}
+	diff.CbTx = newTestCbTx(2, expected.MerkleRoot(),
+		expected.QuorumsMerkleRoot())
+
+	list2, err := ApplyMnListDiff(list, diff)
+	if err != nil {
+		t.Fatalf("ApplyMnListDiff: unexpected error: %v", err)
+	}
+	if list2.Len() != 2 || len(list2.Quorums()) != 1 {
+		t.Fatalf("ApplyMnListDiff: unexpected list %v", list2)
+	}
+	entry, ok := list2.Entry(&updated.ProRegTxHash)
+	if !ok || entry.IsValid {
+		t.Fatalf("ApplyMnListDiff: entry not updated")
+	}
+	if _, ok := list2.Quorum(1, &quorums[1].QuorumHash); !ok {
+		t.Fatalf("ApplyMnListDiff: quorum unexpectedly removed")
+	}
+
+	// Ensure the base list was not modified.
+	if list.Len() != 3 || len(list.Quorums()) != 2 {
+		t.Fatalf("ApplyMnListDiff: base list modified")
+	}
+
+	// Ensure diffs with mismatched bases, unknown deletions and bad
+	// commitments are rejected.
+	if _, err := ApplyMnListDiff(list2, diff); err == nil {
+		t.Fatalf("ApplyMnListDiff: accepted mismatched base")
+	}
+	if _, err := ApplyMnListDiff(nil, diff); err == nil {
+		t.Fatalf("ApplyMnListDiff: accepted missing base")
+	}
+	badDelete := *diff
+	badDelete.DeletedMNs = []chainhash.Hash{{0xff}}
+	if _, err := ApplyMnListDiff(list, &badDelete); err == nil {
+		t.Fatalf("ApplyMnListDiff: accepted unknown deletion")
+	}
+	badRoot := *diff
+	badRoot.CbTx = newTestCbTx(2, chainhash.Hash{}, expected.QuorumsMerkleRoot())
+	if _, err := ApplyMnListDiff(list, &badRoot); err == nil {
+		t.Fatalf("ApplyMnListDiff: accepted bad merkle root")
+	}
+	badQuorumsRoot := *diff
+	badQuorumsRoot.CbTx = newTestCbTx(2, expected.MerkleRoot(), chainhash.Hash{})
+	if _, err := ApplyMnListDiff(list, &badQuorumsRoot); err == nil {
+		t.Fatalf("ApplyMnListDiff: accepted bad quorums merkle root")
+	}
+}
+
+// TestExtractPartialMerkleRoot ensures partial merkle trees are extracted and
+// malformed trees are rejected.
+func TestExtractPartialMerkleRoot(t *testing.T) {
+	leaves := []chainhash.Hash{{0x01}, {0x02}, {0x03}}
+	wantRoot := calcMerkleRoot(leaves)
+	right := hashMerkleBranches(&leaves[2], &leaves[2])
+
+	// Prove the first of three transactions.  The flag bits in traversal
+	// order are: root (1), left node (1), first leaf (1), second leaf (0),
+	// right node (0).
+	hashes := []chainhash.Hash{leaves[0], leaves[1], right}
+	root, matches, err := extractPartialMerkleRoot(3, hashes, []byte{0x07})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if root != wantRoot {
+		t.Fatalf("unexpected root - got %v, want %v", root, wantRoot)
+	}
+	if len(matches) != 1 || matches[0] != leaves[0] {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+
+	tests := []struct {
+		name   string
+		numTx  uint32
+		hashes []chainhash.Hash
+		flags  []byte
+	}{
+		{"no transactions", 0, hashes, []byte{0x07}},
+		{"too many hashes", 2, hashes, []byte{0x07}},
+		{"too few flags", 3, hashes, nil},
+		{"unused hashes", 3, append(hashes, right), []byte{0x07}},
+		{"unused flags", 3, hashes, []byte{0x07, 0x00}},
+		{"missing hashes", 3, hashes[:2], []byte{0x07}},
+	}
+	for _, test := range tests {
+		_, _, err := extractPartialMerkleRoot(test.numTx, test.hashes,
+			test.flags)
+		if err == nil {
+			t.Errorf("%s: unexpected success", test.name)
+		}
+	}
+}
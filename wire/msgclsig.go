@@ -0,0 +1,72 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// MsgCLSig implements the Message interface and represents a Dash clsig
+// message.  It relays a ChainLock (DIP0008), the recovered signature of the
+// ChainLocks LLMQ over the block at Height, which prevents the chain from
+// being reorganized below that block.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgCLSig struct {
+	Height    int32
+	BlockHash chainhash.Hash
+	Sig       [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCLSig) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("clsig message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCLSig.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.Height, &msg.BlockHash, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCLSig) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("clsig message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCLSig.BtcEncode", str)
+	}
+
+	return writeElements(w, msg.Height, &msg.BlockHash, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCLSig) Command() string {
+	return CmdCLSig
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCLSig) MaxPayloadLength(pver uint32) uint32 {
+	// Height 4 bytes + block hash + signature.
+	return 4 + chainhash.HashSize + BLSSignatureSize
+}
+
+// NewMsgCLSig returns a new Dash clsig message that conforms to the Message
+// interface using the passed parameters.  See MsgCLSig for details.
+func NewMsgCLSig(height int32, blockHash *chainhash.Hash, sig *[BLSSignatureSize]byte) *MsgCLSig {
+	return &MsgCLSig{
+		Height:    height,
+		BlockHash: *blockHash,
+		Sig:       *sig,
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCLSigWire tests the MsgCLSig wire encode and decode.
+func TestCLSigWire(t *testing.T) {
+	blockHash := chainhash.Hash{0x01}
+	sig := [BLSSignatureSize]byte{0x02}
+	msg := NewMsgCLSig(0x0102, &blockHash, &sig)
+
+	encoded := []byte{0x02, 0x01, 0x00, 0x00} // Height
+	encoded = append(encoded, blockHash[:]...)
+	encoded = append(encoded, sig[:]...)
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, LLMQVersion, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s", spew.Sdump(buf.Bytes()),
+			spew.Sdump(encoded))
+	}
+	if uint32(buf.Len()) != msg.MaxPayloadLength(LLMQVersion) {
+		t.Fatalf("MaxPayloadLength: got %d, want %d",
+			msg.MaxPayloadLength(LLMQVersion), buf.Len())
+	}
+
+	var readMsg MsgCLSig
+	err := readMsg.BtcDecode(bytes.NewReader(encoded), LLMQVersion,
+		BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(readMsg),
+			spew.Sdump(msg))
+	}
+}
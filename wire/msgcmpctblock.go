@@ -0,0 +1,260 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/aead/siphash"
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// ShortTxIDSize is the size of the short transaction ids of compact
+	// blocks in bytes.
+	ShortTxIDSize = 6
+
+	// maxCmpctBlockIndex is the maximum index of a transaction referenced by
+	// the differentially encoded indexes of the compact block messages.
+	maxCmpctBlockIndex = math.MaxUint16
+)
+
+// PrefilledTx is a transaction of a compact block sent in full along with its
+// index in the block, such as the coinbase transaction, which the receiver
+// can't possibly know about.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// MsgCmpctBlock implements the Message interface and represents a cmpctblock
+// message (BIP0152).  It relays a block as its header along with the short ids
+// of its transactions, which the receiver uses to reconstruct the block from
+// the transactions in its mempool, and any transactions the sender expects
+// the receiver to be missing.  The transactions which can't be matched are
+// requested with a getblocktxn message.
+//
+// The short ids are the SipHash-2-4 of the transaction hashes keyed by the
+// header and the nonce, truncated to ShortTxIDSize bytes.  See ShortIDKey and
+// ShortTxID.
+//
+// This message was not added until protocol version ShortIDsBlocksVersion.
+type MsgCmpctBlock struct {
+	Header      BlockHeader
+	Nonce       uint64
+	ShortIDs    []uint64
+	PrefilledTx []PrefilledTx
+}
+
+// ShortIDKey returns the SipHash key used to calculate the short transaction
+// ids of the compact block, which is the first 16 bytes of the sha256 hash of
+// the header followed by the nonce.
+func (msg *MsgCmpctBlock) ShortIDKey() [16]byte {
+	buf := bytes.NewBuffer(make([]byte, 0, MaxBlockHeaderPayload+8))
+	_ = writeBlockHeader(buf, 0, &msg.Header)
+	_ = binarySerializer.PutUint64(buf, littleEndian, msg.Nonce)
+
+	var key [16]byte
+	hash := sha256.Sum256(buf.Bytes())
+	copy(key[:], hash[:])
+	return key
+}
+
+// ShortTxID returns the short id of the transaction with the passed hash for
+// the compact block with the passed SipHash key.
+func ShortTxID(key *[16]byte, txHash *chainhash.Hash) uint64 {
+	return siphash.Sum64(txHash[:], key) & (1<<(8*ShortTxIDSize) - 1)
+}
+
+// readCmpctBlockIndex reads a differentially encoded transaction index that
+// follows the passed one, which is -1 for the first index.
+func readCmpctBlockIndex(r io.Reader, pver uint32, prev int64, op string) (uint32, error) {
+	diff, err := ReadVarInt(r, pver)
+	if err != nil {
+		return 0, err
+	}
+	if diff > maxCmpctBlockIndex || prev+1+int64(diff) > maxCmpctBlockIndex {
+		str := fmt.Sprintf("transaction index overflows 16 bits [prev %d, "+
+			"diff %d]", prev, diff)
+		return 0, messageError(op, str)
+	}
+	return uint32(prev + 1 + int64(diff)), nil
+}
+
+// writeCmpctBlockIndex writes the passed transaction index differentially
+// encoded against the previous one, which is -1 for the first index.
+func writeCmpctBlockIndex(w io.Writer, pver uint32, index uint32, prev int64, op string) error {
+	if int64(index) <= prev || index > maxCmpctBlockIndex {
+		str := fmt.Sprintf("transaction index %d is not in ascending order "+
+			"or overflows 16 bits [prev %d]", index, prev)
+		return messageError(op, str)
+	}
+	return WriteVarInt(w, pver, uint64(int64(index)-prev-1))
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < ShortIDsBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many short ids for message "+
+			"[count %v, max %v]", count, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+	msg.ShortIDs = make([]uint64, count)
+	var buf [8]byte
+	for i := range msg.ShortIDs {
+		if _, err := io.ReadFull(r, buf[:ShortTxIDSize]); err != nil {
+			return err
+		}
+		msg.ShortIDs[i] = binary.LittleEndian.Uint64(buf[:])
+	}
+
+	count, err = ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock-uint64(len(msg.ShortIDs)) {
+		str := fmt.Sprintf("too many prefilled transactions for message "+
+			"[count %v, max %v]", count,
+			maxTxPerBlock-len(msg.ShortIDs))
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+	msg.PrefilledTx = make([]PrefilledTx, count)
+	prev := int64(-1)
+	for i := range msg.PrefilledTx {
+		ptx := &msg.PrefilledTx[i]
+		ptx.Index, err = readCmpctBlockIndex(r, pver, prev,
+			"MsgCmpctBlock.BtcDecode")
+		if err != nil {
+			return err
+		}
+		prev = int64(ptx.Index)
+
+		ptx.Tx = new(MsgTx)
+		if err := ptx.Tx.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < ShortIDsBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+	if len(msg.ShortIDs)+len(msg.PrefilledTx) > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", len(msg.ShortIDs)+len(msg.PrefilledTx),
+			maxTxPerBlock)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(msg.ShortIDs)))
+	if err != nil {
+		return err
+	}
+	var buf [8]byte
+	for _, id := range msg.ShortIDs {
+		binary.LittleEndian.PutUint64(buf[:], id)
+		if _, err := w.Write(buf[:ShortTxIDSize]); err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(msg.PrefilledTx)))
+	if err != nil {
+		return err
+	}
+	prev := int64(-1)
+	for i := range msg.PrefilledTx {
+		ptx := &msg.PrefilledTx[i]
+		err := writeCmpctBlockIndex(w, pver, ptx.Index, prev,
+			"MsgCmpctBlock.BtcEncode")
+		if err != nil {
+			return err
+		}
+		prev = int64(ptx.Index)
+
+		if err := ptx.Tx.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	return MaxBlockPayload
+}
+
+// NewMsgCmpctBlock returns a new cmpctblock message that conforms to the
+// Message interface and relays the passed block with the passed nonce.  The
+// coinbase transaction is prefilled and all other transactions are represented
+// by their short ids.  See MsgCmpctBlock for details.
+func NewMsgCmpctBlock(block *MsgBlock, nonce uint64) *MsgCmpctBlock {
+	msg := &MsgCmpctBlock{
+		Header:      block.Header,
+		Nonce:       nonce,
+		ShortIDs:    make([]uint64, 0, len(block.Transactions)),
+		PrefilledTx: make([]PrefilledTx, 0, 1),
+	}
+	key := msg.ShortIDKey()
+	for i, tx := range block.Transactions {
+		if i == 0 {
+			msg.PrefilledTx = append(msg.PrefilledTx,
+				PrefilledTx{Index: 0, Tx: tx})
+			continue
+		}
+		txHash := tx.TxHash()
+		msg.ShortIDs = append(msg.ShortIDs, ShortTxID(&key, &txHash))
+	}
+	return msg
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// newTestCmpctBlockTxs returns n distinct transactions for use in the compact
+// block tests.
+func newTestCmpctBlockTxs(n int) []*MsgTx {
+	txs := make([]*MsgTx, n)
+	for i := range txs {
+		tx := NewMsgTx(1)
+		tx.AddTxIn(NewTxIn(&OutPoint{Hash: chainhash.Hash{byte(i)}},
+			[]byte{0x51}, nil))
+		tx.AddTxOut(NewTxOut(int64(i), []byte{0x51}))
+		txs[i] = tx
+	}
+	return txs
+}
+
+// testCmpctMessageWire ensures the passed message encodes to and decodes from
+// the passed number of bytes and that all truncations of the encoding fail to
+// decode.
+func testCmpctMessageWire(t *testing.T, msg Message, readMsg Message, size int) {
+	t.Helper()
+
+	pver := ShortIDsBlocksVersion
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver, BaseEncoding); err != nil {
+		t.Fatalf("%s: BtcEncode error %v", msg.Command(), err)
+	}
+	if buf.Len() != size {
+		t.Fatalf("%s: got encoded size %d, want %d", msg.Command(),
+			buf.Len(), size)
+	}
+	encoded := buf.Bytes()
+
+	err := readMsg.BtcDecode(bytes.NewReader(encoded), pver, BaseEncoding)
+	if err != nil {
+		t.Fatalf("%s: BtcDecode error %v", msg.Command(), err)
+	}
+	if !reflect.DeepEqual(readMsg, msg) {
+		t.Fatalf("%s: BtcDecode\n got: %s want: %s", msg.Command(),
+			spew.Sdump(readMsg), spew.Sdump(msg))
+	}
+
+	for i := 0; i < len(encoded); i++ {
+		msg := makeEmptyMessageOrFail(t, msg.Command())
+		err := msg.BtcDecode(bytes.NewReader(encoded[:i]), pver,
+			BaseEncoding)
+		if err == nil {
+			t.Fatalf("%s: decoded message truncated to %d bytes",
+				msg.Command(), i)
+		}
+	}
+}
+
+// makeEmptyMessageOrFail returns an empty message for the passed command.
+func makeEmptyMessageOrFail(t *testing.T, command string) Message {
+	t.Helper()
+
+	msg, err := makeEmptyMessage(command)
+	if err != nil {
+		t.Fatalf("makeEmptyMessage(%s): unexpected error: %v", command, err)
+	}
+	return msg
+}
+
+// TestCmpctBlockWire tests the MsgCmpctBlock wire encode and decode and the
+// calculation of the short transaction ids.
+func TestCmpctBlockWire(t *testing.T) {
+	block := &MsgBlock{
+		Header: *NewBlockHeader(1, &chainhash.Hash{0x01},
+			&chainhash.Hash{0x02}, 0x1e0ffff0, 3),
+		Transactions: newTestCmpctBlockTxs(3),
+	}
+	msg := NewMsgCmpctBlock(block, 0x0102030405060708)
+
+	// The key is derived from the header and nonce.
+	var buf bytes.Buffer
+	if err := block.Header.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize error %v", err)
+	}
+	buf.Write([]byte{0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01})
+	hash := sha256.Sum256(buf.Bytes())
+	key := msg.ShortIDKey()
+	if !bytes.Equal(key[:], hash[:16]) {
+		t.Fatalf("ShortIDKey: got %x, want %x", key, hash[:16])
+	}
+
+	// The coinbase is prefilled and the other transactions are represented
+	// by their short ids.
+	wantPrefilled := []PrefilledTx{{Index: 0, Tx: block.Transactions[0]}}
+	if !reflect.DeepEqual(msg.PrefilledTx, wantPrefilled) {
+		t.Fatalf("got prefilled transactions %v, want %v",
+			spew.Sdump(msg.PrefilledTx), spew.Sdump(wantPrefilled))
+	}
+	if len(msg.ShortIDs) != 2 {
+		t.Fatalf("got %d short ids, want 2", len(msg.ShortIDs))
+	}
+	for i, id := range msg.ShortIDs {
+		txHash := block.Transactions[i+1].TxHash()
+		if id != ShortTxID(&key, &txHash) || id>>48 != 0 {
+			t.Fatalf("got short id %x for transaction %d", id, i+1)
+		}
+	}
+	if msg.ShortIDs[0] == msg.ShortIDs[1] {
+		t.Fatalf("got identical short ids %x", msg.ShortIDs[0])
+	}
+
+	// Prefill another transaction, which is differentially encoded.
+	msg.ShortIDs = msg.ShortIDs[1:]
+	msg.PrefilledTx = append(msg.PrefilledTx,
+		PrefilledTx{Index: 1, Tx: block.Transactions[1]})
+	size := MaxBlockHeaderPayload + 8 + 1 + ShortTxIDSize + 1 + 1 +
+		block.Transactions[0].SerializeSize() + 1 +
+		block.Transactions[1].SerializeSize()
+	testCmpctMessageWire(t, msg, &MsgCmpctBlock{}, size)
+
+	// Prefilled transactions which aren't in ascending order can't be
+	// encoded.
+	msg.PrefilledTx[0], msg.PrefilledTx[1] = msg.PrefilledTx[1],
+		msg.PrefilledTx[0]
+	buf.Reset()
+	if err := msg.BtcEncode(&buf, ShortIDsBlocksVersion, BaseEncoding); err == nil {
+		t.Fatal("BtcEncode: encoded unordered prefilled transactions")
+	}
+}
+
+// TestGetBlockTxnWire tests the MsgGetBlockTxn wire encode and decode,
+// including the differential encoding of the transaction indexes.
+func TestGetBlockTxnWire(t *testing.T) {
+	blockHash := chainhash.Hash{0x01}
+	msg := NewMsgGetBlockTxn(&blockHash, []uint32{1, 2, 5, 300})
+
+	encoded := append([]byte{}, blockHash[:]...)
+	encoded = append(encoded, 0x04, 0x01, 0x00, 0x02, 0xfd, 0x26, 0x01)
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ShortIDsBlocksVersion, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s", spew.Sdump(buf.Bytes()),
+			spew.Sdump(encoded))
+	}
+	testCmpctMessageWire(t, msg, &MsgGetBlockTxn{}, len(encoded))
+
+	// Indexes which aren't strictly ascending can't be encoded.
+	for _, indexes := range [][]uint32{{1, 1}, {2, 1}, {1 << 16}} {
+		msg := NewMsgGetBlockTxn(&blockHash, indexes)
+		err := msg.BtcEncode(&buf, ShortIDsBlocksVersion, BaseEncoding)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("BtcEncode: got error %v for indexes %v", err,
+				indexes)
+		}
+	}
+
+	// Indexes which overflow 16 bits are rejected.
+	overflow := append([]byte{}, blockHash[:]...)
+	overflow = append(overflow, 0x02, 0xfd, 0xff, 0xff, 0x00)
+	var readMsg MsgGetBlockTxn
+	err := readMsg.BtcDecode(bytes.NewReader(overflow),
+		ShortIDsBlocksVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: got error %v for overflowing indexes", err)
+	}
+}
+
+// TestBlockTxnWire tests the MsgBlockTxn wire encode and decode.
+func TestBlockTxnWire(t *testing.T) {
+	txs := newTestCmpctBlockTxs(2)
+	msg := NewMsgBlockTxn(&chainhash.Hash{0x01}, txs)
+	size := chainhash.HashSize + 1 + txs[0].SerializeSize() +
+		txs[1].SerializeSize()
+	testCmpctMessageWire(t, msg, &MsgBlockTxn{}, size)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// MaxQRInfoBaseBlockHashes is the maximum number of base block hashes allowed
+// per getqrinfo message.
+const MaxQRInfoBaseBlockHashes = 4
+
+// MsgGetQRInfo implements the Message interface and represents a Dash
+// getqrinfo message.  It is used to request the quorum rotation information
+// needed to verify the rotated quorums active as of BlockRequestHash (DIP0024).
+// The response is returned via a qrinfo message (MsgQRInfo).
+//
+// The masternode list diffs of the response are based on the most recent of
+// the passed BaseBlockHashes, which are the blocks the requester already knows
+// the masternode lists of.  ExtraShare requests the quorum snapshot and
+// masternode list diff of the fourth rotation cycle before the requested block
+// as well.
+type MsgGetQRInfo struct {
+	BaseBlockHashes  []chainhash.Hash
+	BlockRequestHash chainhash.Hash
+	ExtraShare       bool
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetQRInfo) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxQRInfoBaseBlockHashes {
+		str := fmt.Sprintf("too many base block hashes for message "+
+			"[count %v, max %v]", count, MaxQRInfoBaseBlockHashes)
+		return messageError("MsgGetQRInfo.BtcDecode", str)
+	}
+
+	msg.BaseBlockHashes = make([]chainhash.Hash, count)
+	for i := range msg.BaseBlockHashes {
+		if err := readElement(r, &msg.BaseBlockHashes[i]); err != nil {
+			return err
+		}
+	}
+
+	return readElements(r, &msg.BlockRequestHash, &msg.ExtraShare)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetQRInfo) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	count := len(msg.BaseBlockHashes)
+	if count > MaxQRInfoBaseBlockHashes {
+		str := fmt.Sprintf("too many base block hashes for message "+
+			"[count %v, max %v]", count, MaxQRInfoBaseBlockHashes)
+		return messageError("MsgGetQRInfo.BtcEncode", str)
+	}
+
+	if err := writeHashes(w, pver, msg.BaseBlockHashes); err != nil {
+		return err
+	}
+
+	return writeElements(w, &msg.BlockRequestHash, msg.ExtraShare)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetQRInfo) Command() string {
+	return CmdGetQRInfo
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetQRInfo) MaxPayloadLength(pver uint32) uint32 {
+	// Num base block hashes (varInt) + max allowed base block hashes +
+	// block request hash + extra share flag 1 byte.
+	return MaxVarIntPayload + MaxQRInfoBaseBlockHashes*chainhash.HashSize +
+		chainhash.HashSize + 1
+}
+
+// NewMsgGetQRInfo returns a new Dash getqrinfo message that conforms to the
+// Message interface using the passed parameters.
+func NewMsgGetQRInfo(baseBlockHashes []chainhash.Hash,
+	blockRequestHash *chainhash.Hash, extraShare bool) *MsgGetQRInfo {
+
+	return &MsgGetQRInfo{
+		BaseBlockHashes:  baseBlockHashes,
+		BlockRequestHash: *blockRequestHash,
+		ExtraShare:       extraShare,
+	}
+}
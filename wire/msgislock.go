@@ -0,0 +1,201 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// DeterministicISLockVersion is the version of the deterministic
+	// InstantSend locks relayed by isdlock messages.
+	DeterministicISLockVersion = 1
+
+	// outPointSize is the serialized size of an outpoint.
+	outPointSize = chainhash.HashSize + 4
+
+	// maxISLockInputsPerMsg is the maximum number of inputs that could
+	// possibly fit into an islock or isdlock message.
+	maxISLockInputsPerMsg = MaxMessagePayload / outPointSize
+)
+
+// MsgISLock implements the Message interface and represents a Dash islock or
+// isdlock message.  It relays an InstantSend lock (DIP0010), the recovered
+// signature of the InstantSend LLMQ over the inputs of the transaction
+// identified by TxHash, which prevents any conflicting transaction from being
+// mined.
+//
+// A zero Version denotes the legacy islock message, which was not added until
+// protocol versions starting with LLMQVersion.  Other versions denote the
+// isdlock message (DIP0022), which was not added until protocol versions
+// starting with ISDLockVersion, and which additionally commits to CycleHash,
+// the hash of the first block of the DKG cycle of the quorum that signed the
+// lock.  CycleHash is not encoded for the legacy islock message.
+type MsgISLock struct {
+	Version   uint8
+	Inputs    []OutPoint
+	TxHash    chainhash.Hash
+	CycleHash chainhash.Hash
+	Sig       [BLSSignatureSize]byte
+}
+
+// checkVersion returns an error when the message is not valid for the passed
+// protocol version.
+func (msg *MsgISLock) checkVersion(pver uint32, funcName string) error {
+	minVersion := LLMQVersion
+	if msg.Version != 0 {
+		minVersion = ISDLockVersion
+	}
+	if pver < minVersion {
+		str := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(funcName, str)
+	}
+	return nil
+}
+
+// CheckSanity performs the checks of the lock which don't depend on the chain
+// or the quorums.  A lock must cover at least one input and must not list an
+// input more than once.  Peers relaying locks which fail these checks are
+// misbehaving.
+func (msg *MsgISLock) CheckSanity() error {
+	if len(msg.Inputs) == 0 {
+		str := fmt.Sprintf("%s message has no inputs", msg.Command())
+		return messageError("MsgISLock.CheckSanity", str)
+	}
+
+	seen := make(map[OutPoint]struct{}, len(msg.Inputs))
+	for _, op := range msg.Inputs {
+		if _, ok := seen[op]; ok {
+			str := fmt.Sprintf("%s message has duplicate input %v",
+				msg.Command(), op)
+			return messageError("MsgISLock.CheckSanity", str)
+		}
+		seen[op] = struct{}{}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// The receiver decodes an isdlock message when its Version is not zero and an
+// islock message otherwise.  This is part of the Message interface
+// implementation.
+func (msg *MsgISLock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if err := msg.checkVersion(pver, "MsgISLock.BtcDecode"); err != nil {
+		return err
+	}
+
+	deterministic := msg.Version != 0
+	if deterministic {
+		if err := readElement(r, &msg.Version); err != nil {
+			return err
+		}
+		if msg.Version == 0 {
+			return messageError("MsgISLock.BtcDecode", "isdlock "+
+				"message with version 0")
+		}
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxISLockInputsPerMsg {
+		str := fmt.Sprintf("too many inputs for message [count %v, "+
+			"max %v]", count, maxISLockInputsPerMsg)
+		return messageError("MsgISLock.BtcDecode", str)
+	}
+	msg.Inputs = make([]OutPoint, count)
+	for i := range msg.Inputs {
+		err := readOutPoint(r, pver, 0, &msg.Inputs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := readElement(r, &msg.TxHash); err != nil {
+		return err
+	}
+	if deterministic {
+		if err := readElement(r, &msg.CycleHash); err != nil {
+			return err
+		}
+	}
+	return readElement(r, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgISLock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if err := msg.checkVersion(pver, "MsgISLock.BtcEncode"); err != nil {
+		return err
+	}
+
+	deterministic := msg.Version != 0
+	if deterministic {
+		if err := writeElement(w, msg.Version); err != nil {
+			return err
+		}
+	}
+
+	count := len(msg.Inputs)
+	if count > maxISLockInputsPerMsg {
+		str := fmt.Sprintf("too many inputs for message [count %v, "+
+			"max %v]", count, maxISLockInputsPerMsg)
+		return messageError("MsgISLock.BtcEncode", str)
+	}
+	if err := WriteVarInt(w, pver, uint64(count)); err != nil {
+		return err
+	}
+	for i := range msg.Inputs {
+		err := writeOutPoint(w, pver, 0, &msg.Inputs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writeElement(w, &msg.TxHash); err != nil {
+		return err
+	}
+	if deterministic {
+		if err := writeElement(w, &msg.CycleHash); err != nil {
+			return err
+		}
+	}
+	return writeElement(w, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgISLock) Command() string {
+	if msg.Version != 0 {
+		return CmdISDLock
+	}
+	return CmdISLock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgISLock) MaxPayloadLength(pver uint32) uint32 {
+	return MaxMessagePayload
+}
+
+// NewMsgISLock returns a new Dash isdlock message that conforms to the Message
+// interface using the passed parameters.  Set the Version of the returned
+// message to zero for a legacy islock message.  See MsgISLock for details.
+func NewMsgISLock(inputs []OutPoint, txHash, cycleHash *chainhash.Hash,
+	sig *[BLSSignatureSize]byte) *MsgISLock {
+
+	return &MsgISLock{
+		Version:   DeterministicISLockVersion,
+		Inputs:    inputs,
+		TxHash:    *txHash,
+		CycleHash: *cycleHash,
+		Sig:       *sig,
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestISLockWire tests the MsgISLock wire encode and decode of both the legacy
+// islock and the deterministic isdlock messages.
+func TestISLockWire(t *testing.T) {
+	inputs := []OutPoint{
+		{Hash: chainhash.Hash{0x01}, Index: 1},
+		{Hash: chainhash.Hash{0x02}, Index: 2},
+	}
+	txHash := chainhash.Hash{0x03}
+	cycleHash := chainhash.Hash{0x04}
+	sig := [BLSSignatureSize]byte{0x05}
+
+	isdlock := NewMsgISLock(inputs, &txHash, &cycleHash, &sig)
+	islock := *isdlock
+	islock.Version = 0
+	islock.CycleHash = chainhash.Hash{}
+
+	encodedInputs := []byte{0x02}
+	for _, in := range inputs {
+		encodedInputs = append(encodedInputs, in.Hash[:]...)
+		encodedInputs = append(encodedInputs, byte(in.Index), 0, 0, 0)
+	}
+	var islockEncoded []byte
+	islockEncoded = append(islockEncoded, encodedInputs...)
+	islockEncoded = append(islockEncoded, txHash[:]...)
+	islockEncoded = append(islockEncoded, sig[:]...)
+	isdlockEncoded := []byte{DeterministicISLockVersion}
+	isdlockEncoded = append(isdlockEncoded, encodedInputs...)
+	isdlockEncoded = append(isdlockEncoded, txHash[:]...)
+	isdlockEncoded = append(isdlockEncoded, cycleHash[:]...)
+	isdlockEncoded = append(isdlockEncoded, sig[:]...)
+
+	tests := []struct {
+		in   *MsgISLock // Message to encode
+		cmd  string     // Expected command
+		buf  []byte     // Wire encoding
+		pver uint32     // Protocol version for wire encoding
+	}{
+		{&islock, CmdISLock, islockEncoded, LLMQVersion},
+		{&islock, CmdISLock, islockEncoded, ProtocolVersion},
+		{isdlock, CmdISDLock, isdlockEncoded, ISDLockVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		if cmd := test.in.Command(); cmd != test.cmd {
+			t.Errorf("Command #%d: got %v, want %v", i, cmd, test.cmd)
+		}
+
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format with the message created
+		// for its command.
+		msg, err := makeEmptyMessage(test.cmd)
+		if err != nil {
+			t.Errorf("makeEmptyMessage #%d error %v", i, err)
+			continue
+		}
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(msg, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(msg), spew.Sdump(test.in))
+			continue
+		}
+	}
+}
+
+// TestISLockWireErrors performs negative tests against wire encode and decode
+// of MsgISLock to confirm error paths work correctly.
+func TestISLockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	wireErr := &MessageError{}
+
+	isdlock := NewMsgISLock([]OutPoint{{}}, &chainhash.Hash{},
+		&chainhash.Hash{}, &[BLSSignatureSize]byte{})
+	var buf bytes.Buffer
+	if err := isdlock.BtcEncode(&buf, pver, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	isdlockEncoded := buf.Bytes()
+	zeroVersion := append([]byte{0x00}, isdlockEncoded[1:]...)
+	tooManyInputs := []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0x0f}
+
+	tests := []struct {
+		in       *MsgISLock // Value to encode
+		buf      []byte     // Wire encoding
+		pver     uint32     // Protocol version for wire encoding
+		max      int        // Max size of fixed buffer to induce errors
+		writeErr error      // Expected write error
+		readErr  error      // Expected read error
+	}{
+		// Force errors in the version, inputs, tx hash, cycle hash and
+		// signature.
+		{isdlock, isdlockEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		{isdlock, isdlockEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		{isdlock, isdlockEncoded, pver, 2, io.ErrShortWrite, io.EOF},
+		{isdlock, isdlockEncoded, pver, 38, io.ErrShortWrite, io.EOF},
+		{isdlock, isdlockEncoded, pver, 70, io.ErrShortWrite, io.EOF},
+		{isdlock, isdlockEncoded, pver, 102, io.ErrShortWrite, io.EOF},
+		// Force error due to unsupported protocol version.
+		{isdlock, isdlockEncoded, ISDLockVersion - 1, 300, wireErr, wireErr},
+		// Force error due to a zero version in an isdlock message.
+		{isdlock, zeroVersion, pver, 300, nil, wireErr},
+		// Force error due to too many inputs.
+		{isdlock, tooManyInputs, pver, 300, nil, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver, BaseEncoding)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		msg := MsgISLock{Version: DeterministicISLockVersion}
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver, BaseEncoding)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
+
+// TestISLockCheckSanity ensures locks without inputs or with duplicate inputs
+// are rejected.
+func TestISLockCheckSanity(t *testing.T) {
+	txHash := chainhash.Hash{0x03}
+	sig := [BLSSignatureSize]byte{0x05}
+	op1 := OutPoint{Hash: chainhash.Hash{0x01}, Index: 1}
+	op2 := OutPoint{Hash: chainhash.Hash{0x01}, Index: 2}
+
+	tests := []struct {
+		name   string
+		inputs []OutPoint
+		valid  bool
+	}{
+		{"no inputs", nil, false},
+		{"single input", []OutPoint{op1}, true},
+		{"distinct inputs", []OutPoint{op1, op2}, true},
+		{"duplicate inputs", []OutPoint{op1, op2, op1}, false},
+	}
+
+	for _, test := range tests {
+		isdlock := NewMsgISLock(test.inputs, &txHash, &chainhash.Hash{},
+			&sig)
+		islock := *isdlock
+		islock.Version = 0
+		for _, msg := range []*MsgISLock{isdlock, &islock} {
+			err := msg.CheckSanity()
+			if test.valid && err != nil {
+				t.Errorf("%s (%s): unexpected error %v", test.name,
+					msg.Command(), err)
+				continue
+			}
+			if _, ok := err.(*MessageError); !test.valid && !ok {
+				t.Errorf("%s (%s): got error %v, want %T",
+					test.name, msg.Command(), err, &MessageError{})
+			}
+		}
+	}
+}
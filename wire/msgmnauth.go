@@ -0,0 +1,74 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// MsgMNAuth implements the Message interface and represents a Dash mnauth
+// message.  It is sent by a masternode after the version handshake to prove
+// that it controls the operator key of the masternode registered by the
+// ProRegTx identified by ProRegTxHash, which allows the peers to give it the
+// privileges of a masternode.
+//
+// Sig is the BLS signature of the operator key over the challenge the peer
+// sent in its version message.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgMNAuth struct {
+	ProRegTxHash chainhash.Hash
+	Sig          [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgMNAuth) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("mnauth message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMNAuth.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.ProRegTxHash, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgMNAuth) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("mnauth message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMNAuth.BtcEncode", str)
+	}
+
+	return writeElements(w, &msg.ProRegTxHash, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgMNAuth) Command() string {
+	return CmdMNAuth
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgMNAuth) MaxPayloadLength(pver uint32) uint32 {
+	// ProRegTx hash + signature.
+	return chainhash.HashSize + BLSSignatureSize
+}
+
+// NewMsgMNAuth returns a new Dash mnauth message that conforms to the Message
+// interface using the passed parameters.  See MsgMNAuth for details.
+func NewMsgMNAuth(proRegTxHash *chainhash.Hash, sig *[BLSSignatureSize]byte) *MsgMNAuth {
+	return &MsgMNAuth{
+		ProRegTxHash: *proRegTxHash,
+		Sig:          *sig,
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// smlEntrySize is the serialized size of a simplified masternode list
+	// entry: ProRegTxHash 32 bytes + ConfirmedHash 32 bytes + IP 16 bytes +
+	// Port 2 bytes + PubKeyOperator 48 bytes + KeyIDVoting 20 bytes +
+	// IsValid 1 byte.
+	smlEntrySize = chainhash.HashSize*2 + 16 + 2 + BLSPublicKeySize + 20 + 1
+
+	// maxSMLEntriesPerMsg is the maximum number of simplified masternode
+	// list entries that could possibly fit into a message.
+	maxSMLEntriesPerMsg = MaxMessagePayload / smlEntrySize
+
+	// maxHashesPerMnListDiff is the maximum number of hashes that could
+	// possibly fit into a mnlistdiff message.
+	maxHashesPerMnListDiff = MaxMessagePayload / chainhash.HashSize
+
+	// minQuorumCommitmentSize is the minimum serialized size of a final
+	// commitment and is used to bound the number of commitments read.
+	minQuorumCommitmentSize = 2 + 1 + chainhash.HashSize + 1 + 1 +
+		BLSPublicKeySize + chainhash.HashSize + BLSSignatureSize*2
+)
+
+// SimplifiedMNListEntry is an entry of the simplified masternode list as
+// defined by DIP0004.  The hash of its serialization is the leaf of the
+// masternode list merkle tree committed to by coinbase special transactions.
+type SimplifiedMNListEntry struct {
+	ProRegTxHash   chainhash.Hash
+	ConfirmedHash  chainhash.Hash
+	IP             net.IP
+	Port           uint16
+	PubKeyOperator [BLSPublicKeySize]byte
+	KeyIDVoting    [20]byte
+	IsValid        bool
+}
+
+// Hash returns the hash of the serialized entry.
+func (e *SimplifiedMNListEntry) Hash() chainhash.Hash {
+	var buf bytes.Buffer
+	buf.Grow(smlEntrySize)
+	_ = e.Serialize(&buf)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// Deserialize decodes an entry from r into the receiver.
+func (e *SimplifiedMNListEntry) Deserialize(r io.Reader) error {
+	var ip [16]byte
+	err := readElements(r, &e.ProRegTxHash, &e.ConfirmedHash, &ip)
+	if err != nil {
+		return err
+	}
+	e.IP = net.IP(ip[:])
+
+	// The port is encoded as big endian like the rest of the network
+	// addresses.
+	e.Port, err = binarySerializer.Uint16(r, bigEndian)
+	if err != nil {
+		return err
+	}
+
+	return readElements(r, &e.PubKeyOperator, &e.KeyIDVoting, &e.IsValid)
+}
+
+// Serialize encodes the entry to w.
+func (e *SimplifiedMNListEntry) Serialize(w io.Writer) error {
+	// Ensure to always write 16 bytes even if the ip is nil.
+	var ip [16]byte
+	if e.IP != nil {
+		copy(ip[:], e.IP.To16())
+	}
+	err := writeElements(w, &e.ProRegTxHash, &e.ConfirmedHash, ip)
+	if err != nil {
+		return err
+	}
+
+	err = binarySerializer.PutUint16(w, bigEndian, e.Port)
+	if err != nil {
+		return err
+	}
+
+	return writeElements(w, e.PubKeyOperator, e.KeyIDVoting, e.IsValid)
+}
+
+// DeletedQuorum identifies a quorum removed from the list of active quorums by
+// a masternode list diff.
+type DeletedQuorum struct {
+	LLMQType   LLMQType
+	QuorumHash chainhash.Hash
+}
+
+// MsgMnListDiff implements the Message interface and represents a Dash
+// mnlistdiff message.  It is sent in response to a getmnlistd message
+// (MsgGetMnListDiff) and describes how to transform the simplified masternode
+// list and the set of active quorums as of BaseBlockHash into those as of
+// BlockHash.
+//
+// The coinbase transaction of the block identified by BlockHash is included
+// along with a partial merkle tree proving its inclusion in the block, so the
+// resulting list can be verified against the merkle roots committed to in the
+// coinbase payload without any other data.
+type MsgMnListDiff struct {
+	BaseBlockHash     chainhash.Hash
+	BlockHash         chainhash.Hash
+	TotalTransactions uint32
+	MerkleHashes      []chainhash.Hash
+	MerkleFlags       []byte
+	CbTx              MsgTx
+	DeletedMNs        []chainhash.Hash
+	MNList            []*SimplifiedMNListEntry
+	DeletedQuorums    []DeletedQuorum
+	NewQuorums        []*QuorumCommitment
+}
+
+// readHashes reads a varint count prefixed list of hashes from r.
+func readHashes(r io.Reader, pver uint32, fieldName string) ([]chainhash.Hash, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxHashesPerMnListDiff {
+		str := fmt.Sprintf("too many %s for message [count %v, max %v]",
+			fieldName, count, maxHashesPerMnListDiff)
+		return nil, messageError("MsgMnListDiff.BtcDecode", str)
+	}
+
+	hashes := make([]chainhash.Hash, count)
+	for i := range hashes {
+		if err := readElement(r, &hashes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return hashes, nil
+}
+
+// writeHashes writes a varint count prefixed list of hashes to w.
+func writeHashes(w io.Writer, pver uint32, hashes []chainhash.Hash) error {
+	if err := WriteVarInt(w, pver, uint64(len(hashes))); err != nil {
+		return err
+	}
+	for i := range hashes {
+		if err := writeElement(w, &hashes[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgMnListDiff) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := readElements(r, &msg.BaseBlockHash, &msg.BlockHash,
+		&msg.TotalTransactions)
+	if err != nil {
+		return err
+	}
+
+	msg.MerkleHashes, err = readHashes(r, pver, "merkle hashes")
+	if err != nil {
+		return err
+	}
+	msg.MerkleFlags, err = ReadVarBytes(r, pver, MaxMessagePayload,
+		"merkle flags")
+	if err != nil {
+		return err
+	}
+
+	if err := msg.CbTx.BtcDecode(r, pver, BaseEncoding); err != nil {
+		return err
+	}
+
+	msg.DeletedMNs, err = readHashes(r, pver, "deleted masternodes")
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxSMLEntriesPerMsg {
+		str := fmt.Sprintf("too many masternode list entries for "+
+			"message [count %v, max %v]", count, maxSMLEntriesPerMsg)
+		return messageError("MsgMnListDiff.BtcDecode", str)
+	}
+	entries := make([]SimplifiedMNListEntry, count)
+	msg.MNList = make([]*SimplifiedMNListEntry, count)
+	for i := range entries {
+		if err := entries[i].Deserialize(r); err != nil {
+			return err
+		}
+		msg.MNList[i] = &entries[i]
+	}
+
+	count, err = ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxHashesPerMnListDiff {
+		str := fmt.Sprintf("too many deleted quorums for message "+
+			"[count %v, max %v]", count, maxHashesPerMnListDiff)
+		return messageError("MsgMnListDiff.BtcDecode", str)
+	}
+	msg.DeletedQuorums = make([]DeletedQuorum, count)
+	for i := range msg.DeletedQuorums {
+		dq := &msg.DeletedQuorums[i]
+		if err := readElements(r, &dq.LLMQType, &dq.QuorumHash); err != nil {
+			return err
+		}
+	}
+
+	count, err = ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxMessagePayload/minQuorumCommitmentSize {
+		str := fmt.Sprintf("too many new quorums for message "+
+			"[count %v, max %v]", count,
+			MaxMessagePayload/minQuorumCommitmentSize)
+		return messageError("MsgMnListDiff.BtcDecode", str)
+	}
+	msg.NewQuorums = make([]*QuorumCommitment, count)
+	for i := range msg.NewQuorums {
+		var qc QuorumCommitment
+		if err := qc.Deserialize(r); err != nil {
+			return err
+		}
+		msg.NewQuorums[i] = &qc
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgMnListDiff) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := writeElements(w, &msg.BaseBlockHash, &msg.BlockHash,
+		msg.TotalTransactions)
+	if err != nil {
+		return err
+	}
+
+	if err := writeHashes(w, pver, msg.MerkleHashes); err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, pver, msg.MerkleFlags); err != nil {
+		return err
+	}
+
+	if err := msg.CbTx.BtcEncode(w, pver, BaseEncoding); err != nil {
+		return err
+	}
+
+	if err := writeHashes(w, pver, msg.DeletedMNs); err != nil {
+		return err
+	}
+
+	if err := WriteVarInt(w, pver, uint64(len(msg.MNList))); err != nil {
+		return err
+	}
+	for _, entry := range msg.MNList {
+		if err := entry.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(msg.DeletedQuorums)))
+	if err != nil {
+		return err
+	}
+	for _, dq := range msg.DeletedQuorums {
+		if err := writeElements(w, dq.LLMQType, &dq.QuorumHash); err != nil {
+			return err
+		}
+	}
+
+	if err := WriteVarInt(w, pver, uint64(len(msg.NewQuorums))); err != nil {
+		return err
+	}
+	for _, qc := range msg.NewQuorums {
+		if err := qc.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgMnListDiff) Command() string {
+	return CmdMnListDiff
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgMnListDiff) MaxPayloadLength(pver uint32) uint32 {
+	return MaxMessagePayload
+}
+
+// CbTxMerkleRoot extracts the block merkle root from the partial merkle tree
+// included in the message and ensures the coinbase transaction is the one and
+// only transaction it proves.  The returned root must match the merkle root of
+// the header of the block identified by BlockHash for the coinbase transaction,
+// and therefore the merkle roots committed to in its payload, to be trusted.
+func (msg *MsgMnListDiff) CbTxMerkleRoot() (chainhash.Hash, error) {
+	root, matches, err := extractPartialMerkleRoot(msg.TotalTransactions,
+		msg.MerkleHashes, msg.MerkleFlags)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+
+	cbTxHash := msg.CbTx.TxHash()
+	if len(matches) != 1 || matches[0] != cbTxHash {
+		str := fmt.Sprintf("partial merkle tree does not prove "+
+			"coinbase transaction %v", cbTxHash)
+		return chainhash.Hash{}, messageError("MsgMnListDiff.CbTxMerkleRoot",
+			str)
+	}
+
+	return root, nil
+}
+
+// NewMsgMnListDiff returns a new Dash mnlistdiff message that conforms to the
+// Message interface.  See MsgMnListDiff for details.
+func NewMsgMnListDiff(baseBlockHash, blockHash *chainhash.Hash) *MsgMnListDiff {
+	return &MsgMnListDiff{
+		BaseBlockHash: *baseBlockHash,
+		BlockHash:     *blockHash,
+	}
+}
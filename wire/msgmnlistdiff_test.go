@@ -0,0 +1,148 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// newTestSMLEntry returns a simplified masternode list entry with fields
+// derived from the passed seed.
+func newTestSMLEntry(seed byte) *SimplifiedMNListEntry {
+	entry := &SimplifiedMNListEntry{
+		IP:      net.ParseIP("10.0.0.1"),
+		Port:    9999,
+		IsValid: true,
+	}
+	entry.ProRegTxHash[0] = seed
+	entry.ConfirmedHash[1] = seed
+	entry.PubKeyOperator[2] = seed
+	entry.KeyIDVoting[3] = seed
+	entry.IP[15] = seed
+
+	return entry
+}
+
+// newTestCbTx returns a coinbase special transaction committing to the passed
+// merkle roots.
+func newTestCbTx(height int32, mnRoot, quorumsRoot chainhash.Hash) MsgTx {
+	payload := CbTx{
+		Version:           2,
+		Height:            height,
+		MerkleRootMNList:  mnRoot,
+		MerkleRootQuorums: quorumsRoot,
+	}
+	var buf bytes.Buffer
+	_ = payload.Serialize(&buf)
+
+	tx := NewMsgTx(int32(TxTypeCoinbase)<<16 | SpecialTxVersion)
+	tx.AddTxIn(NewTxIn(&OutPoint{Index: MaxPrevOutIndex}, []byte{0x51}, nil))
+	tx.AddTxOut(NewTxOut(500000000, []byte{0x51}))
+	tx.ExtraPayload = buf.Bytes()
+
+	return *tx
+}
+
+// newTestQuorumCommitment returns a final commitment with fields derived from
+// the passed seed.
+func newTestQuorumCommitment(seed byte) *QuorumCommitment {
+	qc := &QuorumCommitment{
+		Version:      1,
+		LLMQType:     1,
+		Signers:      []bool{true, false, true},
+		ValidMembers: []bool{true, true, true},
+	}
+	qc.QuorumHash[0] = seed
+	qc.QuorumPubKey[0] = seed
+	return qc
+}
+
+// TestMnListDiffWire tests the MsgMnListDiff wire encode and decode round trip.
+func TestMnListDiffWire(t *testing.T) {
+	msg := NewMsgMnListDiff(&chainhash.Hash{1}, &chainhash.Hash{2})
+	msg.CbTx = newTestCbTx(100, chainhash.Hash{3}, chainhash.Hash{4})
+	msg.TotalTransactions = 1
+	msg.MerkleHashes = []chainhash.Hash{msg.CbTx.TxHash()}
+	msg.MerkleFlags = []byte{0x01}
+	msg.DeletedMNs = []chainhash.Hash{{5}}
+	msg.MNList = []*SimplifiedMNListEntry{newTestSMLEntry(6)}
+	msg.DeletedQuorums = []DeletedQuorum{{LLMQType: 1, QuorumHash: chainhash.Hash{7}}}
+	qc := newTestQuorumCommitment(8)
+	qc.Version = QuorumCommitmentIndexedVersion
+	qc.QuorumIndex = 3
+	msg.NewQuorums = []*QuorumCommitment{qc}
+
+	if cmd := msg.Command(); cmd != CmdMnListDiff {
+		t.Fatalf("wrong command - got %v want %v", cmd, CmdMnListDiff)
+	}
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err != nil {
+		t.Fatalf("encode of MsgMnListDiff failed: %v", err)
+	}
+
+	var readMsg MsgMnListDiff
+	err := readMsg.BtcDecode(bytes.NewReader(buf.Bytes()), ProtocolVersion,
+		BaseEncoding)
+	if err != nil {
+		t.Fatalf("decode of MsgMnListDiff failed: %v", err)
+	}
+
+	// The decoded IP is always 16 bytes.
+	msg.MNList[0].IP = msg.MNList[0].IP.To16()
+	if !reflect.DeepEqual(msg, &readMsg) {
+		t.Fatalf("mismatched message - got %v, want %v",
+			spew.Sdump(&readMsg), spew.Sdump(msg))
+	}
+
+	root, err := readMsg.CbTxMerkleRoot()
+	if err != nil {
+		t.Fatalf("CbTxMerkleRoot: unexpected error: %v", err)
+	}
+	if root != msg.CbTx.TxHash() {
+		t.Fatalf("CbTxMerkleRoot: got %v, want %v", root,
+			msg.CbTx.TxHash())
+	}
+
+	// Ensure truncated messages are rejected.
+	for i := 0; i < buf.Len(); i += 37 {
+		var readMsg MsgMnListDiff
+		err := readMsg.BtcDecode(bytes.NewReader(buf.Bytes()[:i]),
+			ProtocolVersion, BaseEncoding)
+		if err == nil {
+			t.Fatalf("decode of truncated message of %d bytes "+
+				"succeeded", i)
+		}
+	}
+}
+
+// TestGetMnListDiffWire tests the MsgGetMnListDiff wire encode and decode
+// round trip.
+func TestGetMnListDiffWire(t *testing.T) {
+	msg := NewMsgGetMnListDiff(&chainhash.Hash{1}, &chainhash.Hash{2})
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err != nil {
+		t.Fatalf("encode of MsgGetMnListDiff failed: %v", err)
+	}
+	if uint32(buf.Len()) != msg.MaxPayloadLength(ProtocolVersion) {
+		t.Fatalf("unexpected payload length %d", buf.Len())
+	}
+
+	var readMsg MsgGetMnListDiff
+	err := readMsg.BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("decode of MsgGetMnListDiff failed: %v", err)
+	}
+	if readMsg != *msg {
+		t.Fatalf("mismatched message - got %v, want %v", readMsg, msg)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"io"
+)
+
+// MsgQComplaint implements the Message interface and represents a Dash
+// qcomplaint message.  It is sent by a quorum member during the complaint
+// phase of an LLMQ DKG session (DIP0006) and identifies the members which
+// didn't send a valid contribution and the members whose secret key share
+// for the sender was invalid.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQComplaint struct {
+	DKGSession
+	BadMembers         []bool
+	ComplainForMembers []bool
+	Sig                [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQComplaint) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQComplaint.BtcDecode", CmdQComplaint, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := readDKGSession(r, &msg.DKGSession); err != nil {
+		return err
+	}
+	msg.BadMembers, err = readDynBitSet(r, "BadMembers")
+	if err != nil {
+		return err
+	}
+	msg.ComplainForMembers, err = readDynBitSet(r, "ComplainForMembers")
+	if err != nil {
+		return err
+	}
+	err = checkDKGBitSetSizes("MsgQComplaint.BtcDecode", msg.BadMembers,
+		msg.ComplainForMembers)
+	if err != nil {
+		return err
+	}
+
+	return readElement(r, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQComplaint) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQComplaint.BtcEncode", CmdQComplaint, pver)
+	if err != nil {
+		return err
+	}
+
+	err = checkDKGBitSetSizes("MsgQComplaint.BtcEncode", msg.BadMembers,
+		msg.ComplainForMembers)
+	if err != nil {
+		return err
+	}
+	if err := checkDKGCount(len(msg.BadMembers), "members"); err != nil {
+		return err
+	}
+
+	if err := writeDKGSession(w, &msg.DKGSession); err != nil {
+		return err
+	}
+	if err := writeDynBitSet(w, msg.BadMembers); err != nil {
+		return err
+	}
+	if err := writeDynBitSet(w, msg.ComplainForMembers); err != nil {
+		return err
+	}
+
+	return writeElement(w, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQComplaint) Command() string {
+	return CmdQComplaint
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQComplaint) MaxPayloadLength(pver uint32) uint32 {
+	// DKG session + bitsets + signature.
+	return dkgSessionPayload + 2*maxDynBitSetPayload + BLSSignatureSize
+}
+
+// NewMsgQComplaint returns a new Dash qcomplaint message that conforms to the
+// Message interface using the passed DKG session and an empty bitset of the
+// passed number of quorum members for each of the bitsets.  See MsgQComplaint
+// for details.
+func NewMsgQComplaint(session *DKGSession, members int) *MsgQComplaint {
+	return &MsgQComplaint{
+		DKGSession:         *session,
+		BadMembers:         make([]bool, members),
+		ComplainForMembers: make([]bool, members),
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// MsgQContrib implements the Message interface and represents a Dash qcontrib
+// message.  It is sent by a quorum member during the contribution phase of an
+// LLMQ DKG session (DIP0006) and carries the verification vector of the member
+// along with a secret key share for each quorum member.  The shares are
+// encrypted to the operator key of each recipient using BLS IES with a single
+// ephemeral key and a per-recipient IV derived from IVSeed.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQContrib struct {
+	DKGSession
+	VVec            [][BLSPublicKeySize]byte
+	EphemeralPubKey [BLSPublicKeySize]byte
+	IVSeed          chainhash.Hash
+
+	// Contributions houses an encrypted secret key share per quorum
+	// member.  Secret keys are encrypted without padding, so each share
+	// is BLSSecretKeySize bytes.
+	Contributions [][]byte
+	Sig           [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQContrib) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQContrib.BtcDecode", CmdQContrib, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := readDKGSession(r, &msg.DKGSession); err != nil {
+		return err
+	}
+
+	count, err := readDKGCount(r, pver, "verification vector entries")
+	if err != nil {
+		return err
+	}
+	msg.VVec = make([][BLSPublicKeySize]byte, count)
+	for i := range msg.VVec {
+		if err := readElement(r, &msg.VVec[i]); err != nil {
+			return err
+		}
+	}
+
+	err = readElements(r, &msg.EphemeralPubKey, &msg.IVSeed)
+	if err != nil {
+		return err
+	}
+
+	count, err = readDKGCount(r, pver, "contributions")
+	if err != nil {
+		return err
+	}
+	msg.Contributions = make([][]byte, count)
+	for i := range msg.Contributions {
+		contribution, err := ReadVarBytes(r, pver, BLSSecretKeySize,
+			"contribution")
+		if err != nil {
+			return err
+		}
+		if len(contribution) != BLSSecretKeySize {
+			str := fmt.Sprintf("invalid contribution size [size %d, "+
+				"want %d]", len(contribution), BLSSecretKeySize)
+			return messageError("MsgQContrib.BtcDecode", str)
+		}
+		msg.Contributions[i] = contribution
+	}
+
+	return readElement(r, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQContrib) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQContrib.BtcEncode", CmdQContrib, pver)
+	if err != nil {
+		return err
+	}
+
+	err = checkDKGCount(len(msg.VVec), "verification vector entries")
+	if err != nil {
+		return err
+	}
+	if err := checkDKGCount(len(msg.Contributions), "contributions"); err != nil {
+		return err
+	}
+	for _, contribution := range msg.Contributions {
+		if len(contribution) != BLSSecretKeySize {
+			str := fmt.Sprintf("invalid contribution size [size %d, "+
+				"want %d]", len(contribution), BLSSecretKeySize)
+			return messageError("MsgQContrib.BtcEncode", str)
+		}
+	}
+
+	if err := writeDKGSession(w, &msg.DKGSession); err != nil {
+		return err
+	}
+	if err := WriteVarInt(w, pver, uint64(len(msg.VVec))); err != nil {
+		return err
+	}
+	for _, pubKey := range msg.VVec {
+		if err := writeElement(w, pubKey); err != nil {
+			return err
+		}
+	}
+
+	err = writeElements(w, msg.EphemeralPubKey, &msg.IVSeed)
+	if err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(len(msg.Contributions)))
+	if err != nil {
+		return err
+	}
+	for _, contribution := range msg.Contributions {
+		if err := WriteVarBytes(w, pver, contribution); err != nil {
+			return err
+		}
+	}
+
+	return writeElement(w, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQContrib) Command() string {
+	return CmdQContrib
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQContrib) MaxPayloadLength(pver uint32) uint32 {
+	// DKG session + verification vector + ephemeral public key + IV seed +
+	// contributions + signature.
+	return dkgSessionPayload +
+		MaxVarIntPayload + maxQuorumMembers*BLSPublicKeySize +
+		BLSPublicKeySize + chainhash.HashSize +
+		MaxVarIntPayload + maxQuorumMembers*(1+BLSSecretKeySize) +
+		BLSSignatureSize
+}
+
+// NewMsgQContrib returns a new Dash qcontrib message that conforms to the
+// Message interface using the passed DKG session.  See MsgQContrib for
+// details.
+func NewMsgQContrib(session *DKGSession) *MsgQContrib {
+	return &MsgQContrib{DKGSession: *session}
+}
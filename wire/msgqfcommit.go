@@ -0,0 +1,83 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// maxQuorumCommitmentPayload is the max payload size of a final commitment.
+//
+// Version 2 bytes + LLMQ type 1 byte + quorum hash + quorum index 2 bytes +
+// the bitsets + quorum public key + verification vector hash + quorum and
+// members signatures.
+const maxQuorumCommitmentPayload = 2 + 1 + chainhash.HashSize + 2 +
+	2*maxDynBitSetPayload + BLSPublicKeySize + chainhash.HashSize +
+	2*BLSSignatureSize
+
+// MsgQFCommit implements the Message interface and represents a Dash qfcommit
+// message.  It relays the final commitment of an LLMQ DKG session (DIP0006)
+// which is mined in a quorum commitment special transaction.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQFCommit struct {
+	QuorumCommitment
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQFCommit) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQFCommit.BtcDecode", CmdQFCommit, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := msg.Deserialize(r); err != nil {
+		return err
+	}
+	return checkDKGBitSetSizes("MsgQFCommit.BtcDecode", msg.Signers,
+		msg.ValidMembers)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQFCommit) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQFCommit.BtcEncode", CmdQFCommit, pver)
+	if err != nil {
+		return err
+	}
+
+	err = checkDKGBitSetSizes("MsgQFCommit.BtcEncode", msg.Signers,
+		msg.ValidMembers)
+	if err != nil {
+		return err
+	}
+	if err := checkDKGCount(len(msg.Signers), "members"); err != nil {
+		return err
+	}
+	return msg.Serialize(w)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQFCommit) Command() string {
+	return CmdQFCommit
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQFCommit) MaxPayloadLength(pver uint32) uint32 {
+	return maxQuorumCommitmentPayload
+}
+
+// NewMsgQFCommit returns a new Dash qfcommit message that conforms to the
+// Message interface using the passed final commitment.  See MsgQFCommit for
+// details.
+func NewMsgQFCommit(qc *QuorumCommitment) *MsgQFCommit {
+	return &MsgQFCommit{QuorumCommitment: *qc}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// DKGJustifiedContribution is a secret key share revealed in a qjustify
+// message along with the index of the quorum member it belongs to.
+type DKGJustifiedContribution struct {
+	Index uint32
+	Key   [BLSSecretKeySize]byte
+}
+
+// MsgQJustify implements the Message interface and represents a Dash qjustify
+// message.  It is sent by a quorum member during the justification phase of
+// an LLMQ DKG session (DIP0006) and reveals the secret key shares other
+// members complained about so all members can verify them.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQJustify struct {
+	DKGSession
+	Contributions []DKGJustifiedContribution
+	Sig           [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQJustify) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQJustify.BtcDecode", CmdQJustify, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := readDKGSession(r, &msg.DKGSession); err != nil {
+		return err
+	}
+
+	count, err := readDKGCount(r, pver, "contributions")
+	if err != nil {
+		return err
+	}
+	msg.Contributions = make([]DKGJustifiedContribution, count)
+	for i := range msg.Contributions {
+		c := &msg.Contributions[i]
+		if err := readElement(r, &c.Index); err != nil {
+			return err
+		}
+		if c.Index >= maxQuorumMembers {
+			str := fmt.Sprintf("contribution for invalid member "+
+				"index %d", c.Index)
+			return messageError("MsgQJustify.BtcDecode", str)
+		}
+		if _, err := io.ReadFull(r, c.Key[:]); err != nil {
+			return err
+		}
+	}
+
+	return readElement(r, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQJustify) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQJustify.BtcEncode", CmdQJustify, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDKGCount(len(msg.Contributions), "contributions"); err != nil {
+		return err
+	}
+
+	if err := writeDKGSession(w, &msg.DKGSession); err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(len(msg.Contributions)))
+	if err != nil {
+		return err
+	}
+	for i := range msg.Contributions {
+		c := &msg.Contributions[i]
+		if c.Index >= maxQuorumMembers {
+			str := fmt.Sprintf("contribution for invalid member "+
+				"index %d", c.Index)
+			return messageError("MsgQJustify.BtcEncode", str)
+		}
+		if err := writeElement(w, c.Index); err != nil {
+			return err
+		}
+		if _, err := w.Write(c.Key[:]); err != nil {
+			return err
+		}
+	}
+
+	return writeElement(w, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQJustify) Command() string {
+	return CmdQJustify
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQJustify) MaxPayloadLength(pver uint32) uint32 {
+	// DKG session + contributions of a 4 byte index and a secret key +
+	// signature.
+	return dkgSessionPayload + MaxVarIntPayload +
+		maxQuorumMembers*(4+BLSSecretKeySize) + BLSSignatureSize
+}
+
+// NewMsgQJustify returns a new Dash qjustify message that conforms to the
+// Message interface using the passed DKG session.  See MsgQJustify for
+// details.
+func NewMsgQJustify(session *DKGSession) *MsgQJustify {
+	return &MsgQJustify{DKGSession: *session}
+}
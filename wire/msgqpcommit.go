@@ -0,0 +1,96 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// MsgQPCommit implements the Message interface and represents a Dash qpcommit
+// message.  It is sent by a quorum member during the commitment phase of an
+// LLMQ DKG session (DIP0006) and carries the premature commitment of the
+// member, which the final commitment relayed with the qfcommit message is
+// aggregated from.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQPCommit struct {
+	DKGSession
+	ValidMembers   []bool
+	QuorumPubKey   [BLSPublicKeySize]byte
+	QuorumVvecHash chainhash.Hash
+	QuorumSig      [BLSSignatureSize]byte
+	Sig            [BLSSignatureSize]byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQPCommit) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQPCommit.BtcDecode", CmdQPCommit, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := readDKGSession(r, &msg.DKGSession); err != nil {
+		return err
+	}
+	msg.ValidMembers, err = readDynBitSet(r, "ValidMembers")
+	if err != nil {
+		return err
+	}
+
+	return readElements(r, &msg.QuorumPubKey, &msg.QuorumVvecHash,
+		&msg.QuorumSig, &msg.Sig)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQPCommit) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := checkLLMQVersion("MsgQPCommit.BtcEncode", CmdQPCommit, pver)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDKGCount(len(msg.ValidMembers), "members"); err != nil {
+		return err
+	}
+
+	if err := writeDKGSession(w, &msg.DKGSession); err != nil {
+		return err
+	}
+	if err := writeDynBitSet(w, msg.ValidMembers); err != nil {
+		return err
+	}
+
+	return writeElements(w, msg.QuorumPubKey, &msg.QuorumVvecHash,
+		msg.QuorumSig, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQPCommit) Command() string {
+	return CmdQPCommit
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQPCommit) MaxPayloadLength(pver uint32) uint32 {
+	// DKG session + bitset + quorum public key + verification vector
+	// hash + quorum signature + signature.
+	return dkgSessionPayload + maxDynBitSetPayload + BLSPublicKeySize +
+		chainhash.HashSize + 2*BLSSignatureSize
+}
+
+// NewMsgQPCommit returns a new Dash qpcommit message that conforms to the
+// Message interface using the passed DKG session and an empty bitset of the
+// passed number of quorum members.  See MsgQPCommit for details.
+func NewMsgQPCommit(session *DKGSession, members int) *MsgQPCommit {
+	return &MsgQPCommit{
+		DKGSession:   *session,
+		ValidMembers: make([]bool, members),
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// minQuorumSnapshotSize is the minimum serialized size of a quorum
+	// snapshot: skip list mode 4 bytes + empty active members bitset 1 byte
+	// + empty skip list 1 byte.
+	minQuorumSnapshotSize = 4 + 1 + 1
+
+	// minMnListDiffSize is the minimum serialized size of a masternode list
+	// diff: base block hash + block hash + total transactions 4 bytes +
+	// empty merkle hashes and flags 2 bytes + coinbase transaction + empty
+	// deleted masternodes, masternode list, deleted quorums and new quorums
+	// 4 bytes.
+	minMnListDiffSize = chainhash.HashSize*2 + 4 + 2 + minTxPayload + 4
+)
+
+// SnapshotSkipMode describes how the skip list of a quorum snapshot is to be
+// interpreted.
+type SnapshotSkipMode int32
+
+// These constants define the known skip list modes of quorum snapshots.
+const (
+	// SnapshotSkipModeNoSkipping indicates no masternodes were skipped and
+	// the skip list is empty.
+	SnapshotSkipModeNoSkipping SnapshotSkipMode = 0
+
+	// SnapshotSkipModeSkippingEntries indicates the skip list holds the
+	// indexes of the skipped masternodes.
+	SnapshotSkipModeSkippingEntries SnapshotSkipMode = 1
+
+	// SnapshotSkipModeNoSkippingEntries indicates the skip list holds the
+	// indexes of the masternodes which were not skipped.
+	SnapshotSkipModeNoSkippingEntries SnapshotSkipMode = 2
+
+	// SnapshotSkipModeAllSkipped indicates all masternodes were skipped and
+	// the skip list is empty.
+	SnapshotSkipModeAllSkipped SnapshotSkipMode = 3
+)
+
+// QuorumSnapshot records which masternodes were active members of the rotated
+// quorums of a cycle and which were skipped while selecting them, which is
+// needed to reconstruct the members of the quorums of later cycles.
+type QuorumSnapshot struct {
+	SkipListMode        SnapshotSkipMode
+	ActiveQuorumMembers []bool
+	SkipList            []int32
+}
+
+// Deserialize decodes a quorum snapshot from r into the receiver.
+func (s *QuorumSnapshot) Deserialize(r io.Reader) error {
+	err := readElement(r, &s.SkipListMode)
+	if err != nil {
+		return err
+	}
+
+	s.ActiveQuorumMembers, err = readDynBitSet(r, "ActiveQuorumMembers")
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	if count > maxQuorumMembers {
+		str := fmt.Sprintf("skip list is larger than the max allowed "+
+			"size [count %d, max %d]", count, maxQuorumMembers)
+		return messageError("QuorumSnapshot.Deserialize", str)
+	}
+	s.SkipList = make([]int32, count)
+	for i := range s.SkipList {
+		if err := readElement(r, &s.SkipList[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Serialize encodes the quorum snapshot to w.
+func (s *QuorumSnapshot) Serialize(w io.Writer) error {
+	if err := writeElement(w, s.SkipListMode); err != nil {
+		return err
+	}
+	if err := writeDynBitSet(w, s.ActiveQuorumMembers); err != nil {
+		return err
+	}
+
+	if err := WriteVarInt(w, 0, uint64(len(s.SkipList))); err != nil {
+		return err
+	}
+	for _, index := range s.SkipList {
+		if err := writeElement(w, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MsgQRInfo implements the Message interface and represents a Dash qrinfo
+// message.  It is sent in response to a getqrinfo message (MsgGetQRInfo) and
+// provides the quorum snapshots and masternode list diffs needed to verify the
+// rotated quorums active as of the requested block (DIP0024).
+//
+// The fields named after heights refer to the block H of the most recent quorum
+// rotation cycle before the requested block and the blocks C, 2C, 3C and 4C
+// blocks earlier, where C is the length of a rotation cycle.  MnListDiffTip
+// describes the masternode list as of the requested block.
+//
+// QuorumSnapshotAtHMinus4C and MnListDiffAtHMinus4C are only included when
+// the extra share was requested and must either both be set or both be nil.
+type MsgQRInfo struct {
+	QuorumSnapshotAtHMinusC  QuorumSnapshot
+	QuorumSnapshotAtHMinus2C QuorumSnapshot
+	QuorumSnapshotAtHMinus3C QuorumSnapshot
+	MnListDiffTip            MsgMnListDiff
+	MnListDiffH              MsgMnListDiff
+	MnListDiffAtHMinusC      MsgMnListDiff
+	MnListDiffAtHMinus2C     MsgMnListDiff
+	MnListDiffAtHMinus3C     MsgMnListDiff
+	QuorumSnapshotAtHMinus4C *QuorumSnapshot
+	MnListDiffAtHMinus4C     *MsgMnListDiff
+
+	// LastCommitmentPerIndex holds the most recent final commitment of
+	// each quorum index, while QuorumSnapshotList and MnListDiffList hold
+	// the snapshots and diffs needed to verify those commitments which
+	// are older than the fixed fields cover.
+	LastCommitmentPerIndex []*QuorumCommitment
+	QuorumSnapshotList     []*QuorumSnapshot
+	MnListDiffList         []*MsgMnListDiff
+}
+
+// ExtraShare returns whether or not the message includes the quorum snapshot
+// and masternode list diff of the fourth rotation cycle before block H.
+func (msg *MsgQRInfo) ExtraShare() bool {
+	return msg.QuorumSnapshotAtHMinus4C != nil
+}
+
+// readListCount reads the varint count of a list of the qrinfo message and
+// ensures the passed maximum is not exceeded.
+func readListCount(r io.Reader, pver uint32, max uint64, fieldName string) (uint64, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return 0, err
+	}
+	if count > max {
+		str := fmt.Sprintf("too many %s for message [count %v, max %v]",
+			fieldName, count, max)
+		return 0, messageError("MsgQRInfo.BtcDecode", str)
+	}
+
+	return count, nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQRInfo) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	snapshots := []*QuorumSnapshot{&msg.QuorumSnapshotAtHMinusC,
+		&msg.QuorumSnapshotAtHMinus2C, &msg.QuorumSnapshotAtHMinus3C}
+	for _, snapshot := range snapshots {
+		if err := snapshot.Deserialize(r); err != nil {
+			return err
+		}
+	}
+
+	diffs := []*MsgMnListDiff{&msg.MnListDiffTip, &msg.MnListDiffH,
+		&msg.MnListDiffAtHMinusC, &msg.MnListDiffAtHMinus2C,
+		&msg.MnListDiffAtHMinus3C}
+	for _, diff := range diffs {
+		if err := diff.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	var extraShare bool
+	if err := readElement(r, &extraShare); err != nil {
+		return err
+	}
+	msg.QuorumSnapshotAtHMinus4C = nil
+	msg.MnListDiffAtHMinus4C = nil
+	if extraShare {
+		var snapshot QuorumSnapshot
+		if err := snapshot.Deserialize(r); err != nil {
+			return err
+		}
+		var diff MsgMnListDiff
+		if err := diff.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+		msg.QuorumSnapshotAtHMinus4C = &snapshot
+		msg.MnListDiffAtHMinus4C = &diff
+	}
+
+	count, err := readListCount(r, pver,
+		MaxMessagePayload/minQuorumCommitmentSize, "commitments")
+	if err != nil {
+		return err
+	}
+	msg.LastCommitmentPerIndex = make([]*QuorumCommitment, count)
+	for i := range msg.LastCommitmentPerIndex {
+		var qc QuorumCommitment
+		if err := qc.Deserialize(r); err != nil {
+			return err
+		}
+		msg.LastCommitmentPerIndex[i] = &qc
+	}
+
+	count, err = readListCount(r, pver,
+		MaxMessagePayload/minQuorumSnapshotSize, "quorum snapshots")
+	if err != nil {
+		return err
+	}
+	msg.QuorumSnapshotList = make([]*QuorumSnapshot, count)
+	for i := range msg.QuorumSnapshotList {
+		var snapshot QuorumSnapshot
+		if err := snapshot.Deserialize(r); err != nil {
+			return err
+		}
+		msg.QuorumSnapshotList[i] = &snapshot
+	}
+
+	count, err = readListCount(r, pver, MaxMessagePayload/minMnListDiffSize,
+		"masternode list diffs")
+	if err != nil {
+		return err
+	}
+	msg.MnListDiffList = make([]*MsgMnListDiff, count)
+	for i := range msg.MnListDiffList {
+		var diff MsgMnListDiff
+		if err := diff.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+		msg.MnListDiffList[i] = &diff
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQRInfo) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	extraShare := msg.ExtraShare()
+	if extraShare != (msg.MnListDiffAtHMinus4C != nil) {
+		return messageError("MsgQRInfo.BtcEncode", "the quorum "+
+			"snapshot and masternode list diff of the extra share "+
+			"must both be set or both be nil")
+	}
+
+	snapshots := []*QuorumSnapshot{&msg.QuorumSnapshotAtHMinusC,
+		&msg.QuorumSnapshotAtHMinus2C, &msg.QuorumSnapshotAtHMinus3C}
+	for _, snapshot := range snapshots {
+		if err := snapshot.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	diffs := []*MsgMnListDiff{&msg.MnListDiffTip, &msg.MnListDiffH,
+		&msg.MnListDiffAtHMinusC, &msg.MnListDiffAtHMinus2C,
+		&msg.MnListDiffAtHMinus3C}
+	for _, diff := range diffs {
+		if err := diff.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	if err := writeElement(w, extraShare); err != nil {
+		return err
+	}
+	if extraShare {
+		if err := msg.QuorumSnapshotAtHMinus4C.Serialize(w); err != nil {
+			return err
+		}
+		if err := msg.MnListDiffAtHMinus4C.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	count := uint64(len(msg.LastCommitmentPerIndex))
+	if err := WriteVarInt(w, pver, count); err != nil {
+		return err
+	}
+	for _, qc := range msg.LastCommitmentPerIndex {
+		if err := qc.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	count = uint64(len(msg.QuorumSnapshotList))
+	if err := WriteVarInt(w, pver, count); err != nil {
+		return err
+	}
+	for _, snapshot := range msg.QuorumSnapshotList {
+		if err := snapshot.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	count = uint64(len(msg.MnListDiffList))
+	if err := WriteVarInt(w, pver, count); err != nil {
+		return err
+	}
+	for _, diff := range msg.MnListDiffList {
+		if err := diff.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQRInfo) Command() string {
+	return CmdQRInfo
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQRInfo) MaxPayloadLength(pver uint32) uint32 {
+	return MaxMessagePayload
+}
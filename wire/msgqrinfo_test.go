@@ -0,0 +1,158 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// newTestMnListDiff returns a masternode list diff with fields derived from the
+// passed seed.
+func newTestMnListDiff(seed byte) *MsgMnListDiff {
+	msg := NewMsgMnListDiff(&chainhash.Hash{seed}, &chainhash.Hash{seed + 1})
+	msg.CbTx = newTestCbTx(int32(seed), chainhash.Hash{seed + 2},
+		chainhash.Hash{seed + 3})
+	msg.TotalTransactions = 1
+	msg.MerkleHashes = []chainhash.Hash{msg.CbTx.TxHash()}
+	msg.MerkleFlags = []byte{0x01}
+	msg.DeletedMNs = []chainhash.Hash{{seed + 4}}
+	msg.MNList = []*SimplifiedMNListEntry{newTestSMLEntry(seed + 5)}
+	msg.DeletedQuorums = []DeletedQuorum{
+		{LLMQType: 1, QuorumHash: chainhash.Hash{seed + 6}},
+	}
+	msg.NewQuorums = []*QuorumCommitment{newTestQuorumCommitment(seed + 7)}
+	return msg
+}
+
+// newTestQuorumSnapshot returns a quorum snapshot with fields derived from the
+// passed seed.
+func newTestQuorumSnapshot(seed byte) *QuorumSnapshot {
+	return &QuorumSnapshot{
+		SkipListMode:        SnapshotSkipModeSkippingEntries,
+		ActiveQuorumMembers: []bool{true, seed%2 == 0, true},
+		SkipList:            []int32{int32(seed), int32(seed) + 2},
+	}
+}
+
+// TestQRInfoWire tests the MsgQRInfo wire encode and decode round trip with
+// and without the extra share.
+func TestQRInfoWire(t *testing.T) {
+	qc := newTestQuorumCommitment(100)
+	qc.Version = QuorumCommitmentIndexedVersion
+	qc.QuorumIndex = 1
+	msg := &MsgQRInfo{
+		QuorumSnapshotAtHMinusC:  *newTestQuorumSnapshot(1),
+		QuorumSnapshotAtHMinus2C: *newTestQuorumSnapshot(2),
+		QuorumSnapshotAtHMinus3C: *newTestQuorumSnapshot(3),
+		MnListDiffTip:            *newTestMnListDiff(10),
+		MnListDiffH:              *newTestMnListDiff(20),
+		MnListDiffAtHMinusC:      *newTestMnListDiff(30),
+		MnListDiffAtHMinus2C:     *newTestMnListDiff(40),
+		MnListDiffAtHMinus3C:     *newTestMnListDiff(50),
+		LastCommitmentPerIndex:   []*QuorumCommitment{qc},
+		QuorumSnapshotList:       []*QuorumSnapshot{newTestQuorumSnapshot(4)},
+		MnListDiffList:           []*MsgMnListDiff{newTestMnListDiff(60)},
+	}
+	withExtraShare := *msg
+	withExtraShare.QuorumSnapshotAtHMinus4C = newTestQuorumSnapshot(5)
+	withExtraShare.MnListDiffAtHMinus4C = newTestMnListDiff(70)
+
+	if cmd := msg.Command(); cmd != CmdQRInfo {
+		t.Fatalf("wrong command - got %v want %v", cmd, CmdQRInfo)
+	}
+
+	for _, msg := range []*MsgQRInfo{msg, &withExtraShare} {
+		var buf bytes.Buffer
+		err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Fatalf("encode of MsgQRInfo failed: %v", err)
+		}
+
+		var readMsg MsgQRInfo
+		err = readMsg.BtcDecode(bytes.NewReader(buf.Bytes()),
+			ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Fatalf("decode of MsgQRInfo failed: %v", err)
+		}
+		if !reflect.DeepEqual(msg, &readMsg) {
+			t.Fatalf("mismatched message - got %v, want %v",
+				spew.Sdump(&readMsg), spew.Sdump(msg))
+		}
+		if readMsg.ExtraShare() != msg.ExtraShare() {
+			t.Fatalf("got extra share %v, want %v",
+				readMsg.ExtraShare(), msg.ExtraShare())
+		}
+
+		// Ensure truncated messages are rejected.
+		for i := 0; i < buf.Len(); i += 61 {
+			var readMsg MsgQRInfo
+			err := readMsg.BtcDecode(bytes.NewReader(buf.Bytes()[:i]),
+				ProtocolVersion, BaseEncoding)
+			if err == nil {
+				t.Fatalf("decode of truncated message of %d "+
+					"bytes succeeded", i)
+			}
+		}
+	}
+
+	// The extra share must be complete.
+	withExtraShare.MnListDiffAtHMinus4C = nil
+	var buf bytes.Buffer
+	err := withExtraShare.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if err == nil {
+		t.Fatal("encode of MsgQRInfo with partial extra share succeeded")
+	}
+}
+
+// TestGetQRInfoWire tests the MsgGetQRInfo wire encode and decode round trip
+// and the limit of base block hashes.
+func TestGetQRInfoWire(t *testing.T) {
+	baseHashes := []chainhash.Hash{{1}, {2}}
+	msg := NewMsgGetQRInfo(baseHashes, &chainhash.Hash{3}, true)
+
+	// Num base block hashes + 2 hashes + block request hash + extra share.
+	wantBuf := append([]byte{0x02}, baseHashes[0][:]...)
+	wantBuf = append(wantBuf, baseHashes[1][:]...)
+	wantBuf = append(wantBuf, msg.BlockRequestHash[:]...)
+	wantBuf = append(wantBuf, 0x01)
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err != nil {
+		t.Fatalf("encode of MsgGetQRInfo failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), wantBuf) {
+		t.Fatalf("BtcEncode: got %x, want %x", buf.Bytes(), wantBuf)
+	}
+
+	var readMsg MsgGetQRInfo
+	err := readMsg.BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("decode of MsgGetQRInfo failed: %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("mismatched message - got %v, want %v",
+			spew.Sdump(&readMsg), spew.Sdump(msg))
+	}
+
+	// Messages with too many base block hashes must be rejected both ways.
+	msg.BaseBlockHashes = make([]chainhash.Hash, MaxQRInfoBaseBlockHashes+1)
+	buf.Reset()
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err == nil {
+		t.Fatal("encode of MsgGetQRInfo with too many base block " +
+			"hashes succeeded")
+	}
+	wantBuf[0] = MaxQRInfoBaseBlockHashes + 1
+	err = readMsg.BtcDecode(bytes.NewReader(wantBuf), ProtocolVersion,
+		BaseEncoding)
+	if err == nil {
+		t.Fatal("decode of MsgGetQRInfo with too many base block " +
+			"hashes succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgQSendRecSigs implements the Message interface and represents a Dash
+// qsendrecsigs message.  It is used to signal whether or not the receiving
+// peer should relay the recovered signatures of the LLMQs (qsigrec messages)
+// to the sender.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgQSendRecSigs struct {
+	SendRecSigs bool
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgQSendRecSigs) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("qsendrecsigs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgQSendRecSigs.BtcDecode", str)
+	}
+
+	return readElement(r, &msg.SendRecSigs)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgQSendRecSigs) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("qsendrecsigs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgQSendRecSigs.BtcEncode", str)
+	}
+
+	return writeElement(w, msg.SendRecSigs)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgQSendRecSigs) Command() string {
+	return CmdQSendRecSigs
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgQSendRecSigs) MaxPayloadLength(pver uint32) uint32 {
+	return 1
+}
+
+// NewMsgQSendRecSigs returns a new Dash qsendrecsigs message that conforms to
+// the Message interface.  See MsgQSendRecSigs for details.
+func NewMsgQSendRecSigs(sendRecSigs bool) *MsgQSendRecSigs {
+	return &MsgQSendRecSigs{
+		SendRecSigs: sendRecSigs,
+	}
+}
@@ -1,6 +1,7 @@
 package wire
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -8,18 +9,31 @@ import (
 // to signal support for receiving ADDRV2 messages (BIP155). It implements the
 // Message interface.
 //
-// This message has no payload.
+// This message has no payload and was not added until protocol versions
+// starting with AddrV2Version.  It must be sent before the verack message.
 type MsgSendAddrV2 struct{}
 
 // BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
 // This is part of the Message interface implementation.
 func (msg *MsgSendAddrV2) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("sendaddrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendAddrV2.BtcDecode", str)
+	}
+
 	return nil
 }
 
 // BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
 // This is part of the Message interface implementation.
 func (msg *MsgSendAddrV2) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("sendaddrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendAddrV2.BtcEncode", str)
+	}
+
 	return nil
 }
 
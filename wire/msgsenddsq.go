@@ -0,0 +1,64 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgSendDsq implements the Message interface and represents a Dash senddsq
+// message.  It is used to signal whether or not the receiving peer should
+// relay the CoinJoin queue announcements (dsq messages) to the sender.
+//
+// This message was not added until protocol versions starting with
+// LLMQVersion.
+type MsgSendDsq struct {
+	SendDsq bool
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSendDsq) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("senddsq message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendDsq.BtcDecode", str)
+	}
+
+	return readElement(r, &msg.SendDsq)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSendDsq) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < LLMQVersion {
+		str := fmt.Sprintf("senddsq message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendDsq.BtcEncode", str)
+	}
+
+	return writeElement(w, msg.SendDsq)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSendDsq) Command() string {
+	return CmdSendDsq
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSendDsq) MaxPayloadLength(pver uint32) uint32 {
+	return 1
+}
+
+// NewMsgSendDsq returns a new Dash senddsq message that conforms to the
+// Message interface.  See MsgSendDsq for details.
+func NewMsgSendDsq(sendDsq bool) *MsgSendDsq {
+	return &MsgSendDsq{
+		SendDsq: sendDsq,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"io"
+)
+
+// MaxSporkSigSize is the maximum size of the signature of a spork message,
+// which is a compact ECDSA signature.
+const MaxSporkSigSize = 65
+
+// MsgSpork implements the Message interface and represents a Dash spork
+// message.  It is used to relay the value of a spork, a network wide setting
+// which enables or disables features without a hard fork, as signed by the
+// spork key of the network.
+//
+// Sporks are announced to peers upon receipt of a getsporks message
+// (MsgGetSporks).
+type MsgSpork struct {
+	SporkID    int32
+	Value      int64
+	TimeSigned int64
+	Sig        []byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSpork) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	err := readElements(r, &msg.SporkID, &msg.Value, &msg.TimeSigned)
+	if err != nil {
+		return err
+	}
+
+	msg.Sig, err = ReadVarBytes(r, pver, MaxSporkSigSize, "spork signature")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSpork) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	err := writeElements(w, msg.SporkID, msg.Value, msg.TimeSigned)
+	if err != nil {
+		return err
+	}
+
+	return WriteVarBytes(w, pver, msg.Sig)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSpork) Command() string {
+	return CmdSpork
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSpork) MaxPayloadLength(pver uint32) uint32 {
+	// Spork id 4 bytes + value 8 bytes + time signed 8 bytes + signature
+	// length + signature.
+	return 4 + 8 + 8 + MaxVarIntPayload + MaxSporkSigSize
+}
+
+// NewMsgSpork returns a new Dash spork message that conforms to the Message
+// interface using the passed parameters.  See MsgSpork for details.
+func NewMsgSpork(sporkID int32, value, timeSigned int64, sig []byte) *MsgSpork {
+	return &MsgSpork{
+		SporkID:    sporkID,
+		Value:      value,
+		TimeSigned: timeSigned,
+		Sig:        sig,
+	}
+}
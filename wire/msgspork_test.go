@@ -0,0 +1,62 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestSporkWire tests the MsgSpork wire encode and decode.
+func TestSporkWire(t *testing.T) {
+	sig := bytes.Repeat([]byte{0x01}, MaxSporkSigSize)
+	msg := NewMsgSpork(10001, 1, 0x65000000, sig)
+	encoded := []byte{
+		0x11, 0x27, 0x00, 0x00, // Spork id 10001
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Value
+		0x00, 0x00, 0x00, 0x65, 0x00, 0x00, 0x00, 0x00, // Time signed
+		MaxSporkSigSize, // Signature length
+	}
+	encoded = append(encoded, sig...)
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s", spew.Sdump(buf.Bytes()),
+			spew.Sdump(encoded))
+	}
+	if uint32(buf.Len()) != msg.MaxPayloadLength(ProtocolVersion)-
+		MaxVarIntPayload+1 {
+
+		t.Fatalf("MaxPayloadLength: got %d for payload of %d bytes",
+			msg.MaxPayloadLength(ProtocolVersion), buf.Len())
+	}
+
+	var readMsg MsgSpork
+	err := readMsg.BtcDecode(bytes.NewReader(encoded), ProtocolVersion,
+		BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(readMsg),
+			spew.Sdump(msg))
+	}
+
+	// Signatures longer than the maximum must be rejected.
+	tooLong := append(encoded[:20:20], MaxSporkSigSize+1)
+	tooLong = append(tooLong, sig...)
+	tooLong = append(tooLong, 0x01)
+	err = readMsg.BtcDecode(bytes.NewReader(tooLong), ProtocolVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcDecode: got error %v for oversized signature", err)
+	}
+}
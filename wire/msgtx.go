@@ -17,6 +17,11 @@ const (
 	// TxVersion is the current latest supported transaction version.
 	TxVersion = 1
 
+	// SpecialTxVersion is the transaction version which introduced DIP0002
+	// special transactions.  Transactions of this version with a non-zero
+	// type carry an extra payload.
+	SpecialTxVersion = 3
+
 	// MaxTxInSequenceNum is the maximum sequence number the sequence field
 	// of a transaction input can be.
 	MaxTxInSequenceNum uint32 = 0xffffffff
@@ -312,11 +317,40 @@ func NewTxOut(value int64, pkScript []byte) *TxOut {
 //
 // Use the AddTxIn and AddTxOut functions to build up the list of transaction
 // inputs and outputs.
+//
+// Dash packs the DIP0002 transaction type into the upper 16 bits of the Version
+// field, leaving the actual version in the lower 16 bits.  Special transactions,
+// as reported by IsSpecial, are additionally followed by the ExtraPayload.
 type MsgTx struct {
-	Version  int32
-	TxIn     []*TxIn
-	TxOut    []*TxOut
-	LockTime uint32
+	Version      int32
+	TxIn         []*TxIn
+	TxOut        []*TxOut
+	LockTime     uint32
+	ExtraPayload []byte
+}
+
+// TxVersion returns the version of the transaction with the DIP0002
+// transaction type stripped.
+func (msg *MsgTx) TxVersion() uint16 {
+	return uint16(uint32(msg.Version))
+}
+
+// TxType returns the DIP0002 transaction type of the transaction.
+func (msg *MsgTx) TxType() TxType {
+	return TxType(uint32(msg.Version) >> 16)
+}
+
+// IsSpecial returns whether or not the transaction is a DIP0002 special
+// transaction and therefore carries an extra payload.
+func (msg *MsgTx) IsSpecial() bool {
+	return isSpecialTxVersion(msg.Version)
+}
+
+// isSpecialTxVersion returns whether or not the passed raw transaction version
+// identifies a DIP0002 special transaction.
+func isSpecialTxVersion(version int32) bool {
+	return uint16(uint32(version)) == SpecialTxVersion &&
+		TxType(uint32(version)>>16) != TxTypeNormal
 }
 
 // AddTxIn adds a transaction input to the message.
@@ -367,6 +401,12 @@ func (msg *MsgTx) Copy() *MsgTx {
 		LockTime: msg.LockTime,
 	}
 
+	// Deep copy the extra payload of special transactions.
+	if msg.ExtraPayload != nil {
+		newTx.ExtraPayload = make([]byte, len(msg.ExtraPayload))
+		copy(newTx.ExtraPayload, msg.ExtraPayload)
+	}
+
 	// Deep copy the old TxIn data.
 	for _, oldTxIn := range msg.TxIn {
 		// Deep copy the old previous outpoint.
@@ -448,8 +488,11 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 
 	// A count of zero (meaning no TxIn's to the uninitiated) means that the
 	// value is a TxFlagMarker, and hence indicates the presence of a flag.
+	// Special transactions never make use of the witness encoding and may
+	// legitimately have no inputs, so the marker does not apply to them.
 	var flag [1]TxFlag
-	if count == TxFlagMarker && enc == WitnessEncoding {
+	special := isSpecialTxVersion(msg.Version)
+	if count == TxFlagMarker && enc == WitnessEncoding && !special {
 		// The count varint was in fact the flag marker byte. Next, we need to
 		// read the flag value, which is a single byte.
 		if _, err = io.ReadFull(r, flag[:]); err != nil {
@@ -605,6 +648,17 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 		return err
 	}
 
+	// Special transactions are followed by their extra payload.
+	msg.ExtraPayload = nil
+	if special {
+		msg.ExtraPayload, err = ReadVarBytes(r, pver, MaxMessagePayload,
+			"ExtraPayload")
+		if err != nil {
+			returnScriptBuffers()
+			return err
+		}
+	}
+
 	// Create a single allocation to house all of the scripts and set each
 	// input signature script and output public key script to the
 	// appropriate subslice of the overall contiguous buffer.  Then, return
@@ -764,7 +818,17 @@ func (msg *MsgTx) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error
 		}
 	}
 
-	return binarySerializer.PutUint32(w, littleEndian, msg.LockTime)
+	err = binarySerializer.PutUint32(w, littleEndian, msg.LockTime)
+	if err != nil {
+		return err
+	}
+
+	// Special transactions are followed by their extra payload.
+	if msg.IsSpecial() {
+		return WriteVarBytes(w, pver, msg.ExtraPayload)
+	}
+
+	return nil
 }
 
 // HasWitness returns false if none of the inputs within the transaction
@@ -824,6 +888,11 @@ func (msg *MsgTx) baseSize() int {
 		n += txOut.SerializeSize()
 	}
 
+	if msg.IsSpecial() {
+		n += VarIntSerializeSize(uint64(len(msg.ExtraPayload))) +
+			len(msg.ExtraPayload)
+	}
+
 	return n
 }
 
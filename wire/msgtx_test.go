@@ -755,6 +755,50 @@ func TestTxSerializeSizeStripped(t *testing.T) {
 	}
 }
 
+// TestSpecialTx ensures DIP0002 special transactions, including those without
+// any inputs or outputs, round trip along with their extra payload.
+func TestSpecialTx(t *testing.T) {
+	tx := NewMsgTx(int32(TxTypeQuorumCommitment)<<16 | SpecialTxVersion)
+	tx.ExtraPayload = []byte{0x01, 0x02, 0x03}
+	if !tx.IsSpecial() || tx.TxType() != TxTypeQuorumCommitment ||
+		tx.TxVersion() != SpecialTxVersion {
+
+		t.Fatalf("unexpected special tx details: special %v, type %v, "+
+			"version %d", tx.IsSpecial(), tx.TxType(), tx.TxVersion())
+	}
+
+	// Version + no inputs + no outputs + locktime + payload.
+	wantBuf := []byte{
+		0x03, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x03, 0x01, 0x02, 0x03,
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), wantBuf) {
+		t.Fatalf("Serialize: got %x, want %x", buf.Bytes(), wantBuf)
+	}
+	if tx.SerializeSize() != len(wantBuf) {
+		t.Fatalf("SerializeSize: got %d, want %d", tx.SerializeSize(),
+			len(wantBuf))
+	}
+
+	var readTx MsgTx
+	if err := readTx.Deserialize(bytes.NewReader(wantBuf)); err != nil {
+		t.Fatalf("Deserialize: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tx.Copy(), readTx.Copy()) {
+		t.Fatalf("Deserialize: mismatched tx - got %v, want %v",
+			spew.Sdump(&readTx), spew.Sdump(tx))
+	}
+
+	// The payload of non-coinbase transactions is not a coinbase payload.
+	if _, err := tx.CbTxPayload(); err == nil {
+		t.Fatalf("CbTxPayload: unexpected success")
+	}
+}
+
 // TestTxWitnessSize performs tests to ensure that the serialized size for
 // various types of transactions that include witness data is accurate.
 func TestTxWitnessSize(t *testing.T) {
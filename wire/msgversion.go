@@ -10,6 +10,8 @@ import (
 	"io"
 	"strings"
 	"time"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 )
 
 // MaxUserAgentLen is the maximum allowed length for the user agent field in a
@@ -55,6 +57,11 @@ type MsgVersion struct {
 
 	// Don't announce transactions to peer.
 	DisableRelayTx bool
+
+	// Random challenge the remote peer must sign with the operator key of
+	// its masternode to authenticate with a mnauth message.  It is only
+	// encoded when ProtocolVersion is at least LLMQVersion.
+	MNAuthChallenge chainhash.Hash
 }
 
 // HasService returns whether the specified service is supported by the peer
@@ -144,6 +151,16 @@ func (msg *MsgVersion) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding)
 		msg.DisableRelayTx = !relayTx
 	}
 
+	// Dash protocol versions >= LLMQVersion added the mnauth challenge.
+	// It is only considered present if there are bytes remaining in the
+	// message.
+	if buf.Len() > 0 {
+		err = readElement(buf, &msg.MNAuthChallenge)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -195,6 +212,18 @@ func (msg *MsgVersion) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding)
 			return err
 		}
 	}
+
+	// The mnauth challenge is only understood by peers that support
+	// mnauth messages, which is determined by the version advertised in
+	// the message since the protocol version isn't negotiated yet.
+	if pver >= BIP0037Version &&
+		uint32(msg.ProtocolVersion) >= LLMQVersion {
+
+		err = writeElement(w, &msg.MNAuthChallenge)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -212,9 +241,9 @@ func (msg *MsgVersion) MaxPayloadLength(pver uint32) uint32 {
 	// Protocol version 4 bytes + services 8 bytes + timestamp 8 bytes +
 	// remote and local net addresses + nonce 8 bytes + length of user
 	// agent (varInt) + max allowed useragent length + last block 4 bytes +
-	// relay transactions flag 1 byte.
+	// relay transactions flag 1 byte + mnauth challenge 32 bytes.
 	return 33 + (maxNetAddressPayload(pver) * 2) + MaxVarIntPayload +
-		MaxUserAgentLen
+		MaxUserAgentLen + chainhash.HashSize
 }
 
 // NewMsgVersion returns a new bitcoin version message that conforms to the
@@ -6,6 +6,7 @@ package wire
 
 import (
 	"bytes"
+	"encoding/binary"
 	"io"
 	"net"
 	"reflect"
@@ -13,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -106,8 +108,8 @@ func TestVersion(t *testing.T) {
 	// Protocol version 4 bytes + services 8 bytes + timestamp 8 bytes +
 	// remote and local net addresses + nonce 8 bytes + length of user agent
 	// (varInt) + max allowed user agent length + last block 4 bytes +
-	// relay transactions flag 1 byte.
-	wantPayload := uint32(358)
+	// relay transactions flag 1 byte + mnauth challenge 32 bytes.
+	wantPayload := uint32(390)
 	maxPayload := msg.MaxPayloadLength(pver)
 	if maxPayload != wantPayload {
 		t.Errorf("MaxPayloadLength: wrong max payload length for "+
@@ -138,6 +140,18 @@ func TestVersionWire(t *testing.T) {
 	copy(verRelayTxFalseEncoded, baseVersionBIP0037Encoded)
 	verRelayTxFalseEncoded[len(verRelayTxFalseEncoded)-1] = 0
 
+	// verMNAuth and verMNAuthEncoded is a version message of a Dash peer
+	// supporting mnauth messages which carries an mnauth challenge.
+	verMNAuthCopy := *baseVersionBIP0037
+	verMNAuth := &verMNAuthCopy
+	verMNAuth.ProtocolVersion = int32(LLMQVersion)
+	verMNAuth.MNAuthChallenge = chainhash.Hash{0x01, 31: 0xff}
+	verMNAuthEncoded := make([]byte, len(baseVersionBIP0037Encoded))
+	copy(verMNAuthEncoded, baseVersionBIP0037Encoded)
+	binary.LittleEndian.PutUint32(verMNAuthEncoded, LLMQVersion)
+	verMNAuthEncoded = append(verMNAuthEncoded,
+		verMNAuth.MNAuthChallenge[:]...)
+
 	tests := []struct {
 		in   *MsgVersion     // Message to encode
 		out  *MsgVersion     // Expected decoded message
@@ -154,6 +168,15 @@ func TestVersionWire(t *testing.T) {
 			BaseEncoding,
 		},
 
+		// Dash protocol version with mnauth challenge.
+		{
+			verMNAuth,
+			verMNAuth,
+			verMNAuthEncoded,
+			ProtocolVersion,
+			BaseEncoding,
+		},
+
 		// Protocol version BIP0037Version with relay transactions field
 		// true.
 		{
@@ -0,0 +1,316 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// MaxAddrV2Size is the maximum size of the address of a NetAddressV2 in bytes
+// as defined by BIP0155.
+const MaxAddrV2Size = 512
+
+// maxNetAddressV2Payload is the max payload size for a NetAddressV2.
+//
+// Timestamp 4 bytes + services varint + network id 1 byte + address size
+// varint + address + port 2 bytes.
+const maxNetAddressV2Payload = 4 + MaxVarIntPayload + 1 + MaxVarIntPayload +
+	MaxAddrV2Size + 2
+
+// NetworkID identifies the network of the address of a NetAddressV2 as
+// defined by BIP0155.
+type NetworkID uint8
+
+// These constants define the networks of BIP0155.
+const (
+	// NetIPv4 identifies IPv4 addresses.
+	NetIPv4 NetworkID = 1
+
+	// NetIPv6 identifies IPv6 addresses.
+	NetIPv6 NetworkID = 2
+
+	// NetTorV2 identifies the deprecated Tor v2 onion services.
+	NetTorV2 NetworkID = 3
+
+	// NetTorV3 identifies Tor v3 onion services.
+	NetTorV3 NetworkID = 4
+
+	// NetI2P identifies I2P overlay network addresses.
+	NetI2P NetworkID = 5
+
+	// NetCJDNS identifies CJDNS overlay network addresses.
+	NetCJDNS NetworkID = 6
+)
+
+// networkIDAddrSizes maps the known networks to the size of their addresses.
+var networkIDAddrSizes = map[NetworkID]int{
+	NetIPv4:  net.IPv4len,
+	NetIPv6:  net.IPv6len,
+	NetTorV2: 10,
+	NetTorV3: 32,
+	NetI2P:   32,
+	NetCJDNS: net.IPv6len,
+}
+
+// networkIDStrings is a map of networks back to their constant names for
+// pretty printing.
+var networkIDStrings = map[NetworkID]string{
+	NetIPv4:  "NetIPv4",
+	NetIPv6:  "NetIPv6",
+	NetTorV2: "NetTorV2",
+	NetTorV3: "NetTorV3",
+	NetI2P:   "NetI2P",
+	NetCJDNS: "NetCJDNS",
+}
+
+// String returns the NetworkID in human-readable form.
+func (n NetworkID) String() string {
+	if s, ok := networkIDStrings[n]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown NetworkID (%d)", uint8(n))
+}
+
+// IsKnown returns whether the network is one of the networks known to this
+// package.  Addresses of unknown networks should be ignored.
+func (n NetworkID) IsKnown() bool {
+	_, ok := networkIDAddrSizes[n]
+	return ok
+}
+
+// onionCatPrefix is the IPv6 prefix used to embed Tor v2 addresses into the
+// IP field of legacy addresses.
+var onionCatPrefix = []byte{0xfd, 0x87, 0xd8, 0x7e, 0xeb, 0x43}
+
+// torV3Version is the version byte of Tor v3 onion service addresses.
+const torV3Version = 0x03
+
+// addrEncoding is the base32 encoding used by the Tor and I2P addresses.
+var addrEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NetAddressV2 defines information about a peer on the network including the
+// time it was last seen, the services it supports, the network and address it
+// is reachable at, and its port.  Unlike NetAddress, it supports addresses of
+// overlay networks which don't fit into an IPv6 address such as Tor v3 onion
+// services (BIP0155).
+type NetAddressV2 struct {
+	// Last time the address was seen.  This is encoded as a uint32 on the
+	// wire and therefore is limited to 2106.
+	Timestamp time.Time
+
+	// Bitfield which identifies the services supported by the address.
+	Services ServiceFlag
+
+	// NetworkID identifies the network of the address.
+	NetworkID NetworkID
+
+	// Addr is the address within the network.  Its size depends on the
+	// network.
+	Addr []byte
+
+	// Port the peer is using.  This is encoded in big endian on the wire.
+	Port uint16
+}
+
+// HasService returns whether the specified service is supported by the address.
+func (na *NetAddressV2) HasService(service ServiceFlag) bool {
+	return na.Services&service == service
+}
+
+// AddService adds service as a supported service by the peer generating the
+// message.
+func (na *NetAddressV2) AddService(service ServiceFlag) {
+	na.Services |= service
+}
+
+// Host returns the address formatted as it is used to connect to the peer,
+// which is the textual form of IP addresses and the hostname of Tor and I2P
+// addresses.  Addresses with unknown networks or invalid sizes are returned as
+// hex.
+func (na *NetAddressV2) Host() string {
+	if size, ok := networkIDAddrSizes[na.NetworkID]; !ok || len(na.Addr) != size {
+		return fmt.Sprintf("%x", na.Addr)
+	}
+
+	switch na.NetworkID {
+	case NetTorV2:
+		return strings.ToLower(addrEncoding.EncodeToString(na.Addr)) +
+			".onion"
+
+	case NetTorV3:
+		// The onion address is the base32 encoding of the public key,
+		// a checksum and the version (rend-spec-v3).
+		checksum := torV3Checksum(na.Addr)
+		b := make([]byte, 0, len(na.Addr)+len(checksum)+1)
+		b = append(b, na.Addr...)
+		b = append(b, checksum[:]...)
+		b = append(b, torV3Version)
+		return strings.ToLower(addrEncoding.EncodeToString(b)) + ".onion"
+
+	case NetI2P:
+		return strings.ToLower(addrEncoding.EncodeToString(na.Addr)) +
+			".b32.i2p"
+	}
+
+	return net.IP(na.Addr).String()
+}
+
+// String returns the host and port of the address.
+func (na *NetAddressV2) String() string {
+	return net.JoinHostPort(na.Host(), fmt.Sprint(na.Port))
+}
+
+// ToLegacy converts the address to a NetAddress as used by the addr message.
+// This is only possible for IPv4, IPv6 and Tor v2 addresses, which are
+// embedded into IPv6 addresses.  False is returned for other addresses.
+func (na *NetAddressV2) ToLegacy() (*NetAddress, bool) {
+	if len(na.Addr) != networkIDAddrSizes[na.NetworkID] {
+		return nil, false
+	}
+
+	var ip net.IP
+	switch na.NetworkID {
+	case NetIPv4, NetIPv6:
+		ip = make(net.IP, len(na.Addr))
+		copy(ip, na.Addr)
+	case NetTorV2:
+		ip = make(net.IP, 0, net.IPv6len)
+		ip = append(ip, onionCatPrefix...)
+		ip = append(ip, na.Addr...)
+	default:
+		return nil, false
+	}
+
+	return &NetAddress{
+		Timestamp: na.Timestamp,
+		Services:  na.Services,
+		IP:        ip,
+		Port:      na.Port,
+	}, true
+}
+
+// NewNetAddressV2 returns a new NetAddressV2 using the provided timestamp,
+// services, network, address and port.  The timestamp is rounded to single
+// second precision.
+func NewNetAddressV2(timestamp time.Time, services ServiceFlag,
+	networkID NetworkID, addr []byte, port uint16) *NetAddressV2 {
+
+	return &NetAddressV2{
+		Timestamp: time.Unix(timestamp.Unix(), 0),
+		Services:  services,
+		NetworkID: networkID,
+		Addr:      addr,
+		Port:      port,
+	}
+}
+
+// NewNetAddressV2FromLegacy converts a NetAddress to a NetAddressV2.  IPv4
+// mapped IPv6 addresses become IPv4 addresses and Tor v2 addresses embedded
+// into IPv6 addresses become Tor v2 addresses.
+func NewNetAddressV2FromLegacy(na *NetAddress) *NetAddressV2 {
+	networkID, addr := NetIPv6, make([]byte, net.IPv6len)
+	copy(addr, na.IP.To16())
+	switch {
+	case na.IP.To4() != nil:
+		networkID, addr = NetIPv4, addr[12:]
+	case strings.HasPrefix(string(addr), string(onionCatPrefix)):
+		networkID, addr = NetTorV2, addr[len(onionCatPrefix):]
+	}
+
+	return &NetAddressV2{
+		Timestamp: na.Timestamp,
+		Services:  na.Services,
+		NetworkID: networkID,
+		Addr:      addr,
+		Port:      na.Port,
+	}
+}
+
+// torV3Checksum returns the checksum of the passed Tor v3 public key.
+func torV3Checksum(pubKey []byte) [2]byte {
+	h := sha3.New256()
+	h.Write([]byte(".onion checksum"))
+	h.Write(pubKey)
+	h.Write([]byte{torV3Version})
+
+	var checksum [2]byte
+	copy(checksum[:], h.Sum(nil))
+	return checksum
+}
+
+// readNetAddressV2 reads an encoded NetAddressV2 from r.  An error is returned
+// when the address exceeds MaxAddrV2Size or doesn't have the size of the
+// addresses of a known network.  Addresses of unknown networks are read as is.
+func readNetAddressV2(r io.Reader, pver uint32, na *NetAddressV2) error {
+	err := readElement(r, (*uint32Time)(&na.Timestamp))
+	if err != nil {
+		return err
+	}
+
+	// Unlike NetAddress, the services are encoded as a variable length
+	// integer.
+	services, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	na.Services = ServiceFlag(services)
+
+	err = readElement(r, (*uint8)(&na.NetworkID))
+	if err != nil {
+		return err
+	}
+
+	addr, err := ReadVarBytes(r, pver, MaxAddrV2Size, "addr")
+	if err != nil {
+		return err
+	}
+	if size, ok := networkIDAddrSizes[na.NetworkID]; ok && len(addr) != size {
+		str := fmt.Sprintf("invalid %v address size [size %d, want %d]",
+			na.NetworkID, len(addr), size)
+		return messageError("readNetAddressV2", str)
+	}
+	na.Addr = addr
+
+	// Sigh.  Bitcoin protocol mixes little and big endian.
+	na.Port, err = binarySerializer.Uint16(r, bigEndian)
+	return err
+}
+
+// writeNetAddressV2 serializes a NetAddressV2 to w.
+func writeNetAddressV2(w io.Writer, pver uint32, na *NetAddressV2) error {
+	if len(na.Addr) > MaxAddrV2Size {
+		str := fmt.Sprintf("address is too large [size %d, max %d]",
+			len(na.Addr), MaxAddrV2Size)
+		return messageError("writeNetAddressV2", str)
+	}
+
+	err := writeElement(w, uint32(na.Timestamp.Unix()))
+	if err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(na.Services))
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, uint8(na.NetworkID))
+	if err != nil {
+		return err
+	}
+	err = WriteVarBytes(w, pver, na.Addr)
+	if err != nil {
+		return err
+	}
+
+	// Sigh.  Bitcoin protocol mixes little and big endian.
+	return binary.Write(w, bigEndian, na.Port)
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected.  It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// TestNetAddressV2Host ensures the addresses of the various networks are
+// formatted as expected.
+func TestNetAddressV2Host(t *testing.T) {
+	tests := []struct {
+		networkID NetworkID
+		addr      []byte
+		want      string
+	}{
+		{NetIPv4, []byte{127, 0, 0, 1}, "127.0.0.1"},
+		{NetIPv6, net.ParseIP("2001:db8::1"), "2001:db8::1"},
+		{NetTorV2, hexToBytes("f1f2f3f4f5f6f7f8f9fa"),
+			"6hzph5hv6337r6p2.onion"},
+		{NetTorV3, hexToBytes("79bcc625184b05194975c28b66b66b0469f7f655" +
+			"6fb1ac3189a79b40dda32f1f"),
+			"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"},
+		{NetI2P, hexToBytes("a2894dabaec08c0051a481a6dac88b64f98232ae42d4" +
+			"b6fd2fa81952dfe36a87"),
+			"ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p"},
+		{NetCJDNS, net.ParseIP("fc00:1:2:3:4:5:6:7"), "fc00:1:2:3:4:5:6:7"},
+		{NetworkID(0xaa), []byte{0x01, 0x02}, "0102"},
+		{NetTorV3, []byte{0x01, 0x02}, "0102"},
+	}
+
+	for i, test := range tests {
+		na := NewNetAddressV2(time.Now(), 0, test.networkID, test.addr, 9999)
+		if got := na.Host(); got != test.want {
+			t.Errorf("Host #%d (%v): got %q, want %q", i, test.networkID,
+				got, test.want)
+		}
+	}
+
+	na := NewNetAddressV2(time.Now(), 0, NetIPv6, net.ParseIP("::1"), 9999)
+	if got := na.String(); got != "[::1]:9999" {
+		t.Errorf("String: got %q, want %q", got, "[::1]:9999")
+	}
+}
+
+// TestNetAddressV2Legacy ensures addresses are converted to and from legacy
+// addresses as expected.
+func TestNetAddressV2Legacy(t *testing.T) {
+	ts := time.Unix(0x495fab29, 0)
+	tests := []struct {
+		legacy    net.IP
+		networkID NetworkID
+		addr      []byte
+	}{
+		{net.ParseIP("127.0.0.1"), NetIPv4, []byte{127, 0, 0, 1}},
+		{net.ParseIP("2001:db8::1"), NetIPv6, net.ParseIP("2001:db8::1")},
+		{net.ParseIP("fd87:d87e:eb43:f1f2:f3f4:f5f6:f7f8:f9fa"), NetTorV2,
+			hexToBytes("f1f2f3f4f5f6f7f8f9fa")},
+	}
+
+	for i, test := range tests {
+		legacy := NewNetAddressTimestamp(ts, SFNodeNetwork, test.legacy, 9999)
+		na := NewNetAddressV2FromLegacy(legacy)
+		want := NewNetAddressV2(ts, SFNodeNetwork, test.networkID,
+			test.addr, 9999)
+		if !reflect.DeepEqual(na, want) {
+			t.Errorf("NewNetAddressV2FromLegacy #%d\n got: %s want: %s",
+				i, spew.Sdump(na), spew.Sdump(want))
+			continue
+		}
+
+		got, ok := na.ToLegacy()
+		if !ok || !got.IP.Equal(legacy.IP) || got.Port != legacy.Port ||
+			got.Services != legacy.Services ||
+			!got.Timestamp.Equal(legacy.Timestamp) {
+
+			t.Errorf("ToLegacy #%d: got %v (%v), want %v", i,
+				spew.Sdump(got), ok, spew.Sdump(legacy))
+		}
+	}
+
+	// Addresses of overlay networks without an IPv6 encoding can't be
+	// converted.
+	na := NewNetAddressV2(ts, 0, NetTorV3, make([]byte, 32), 9999)
+	if _, ok := na.ToLegacy(); ok {
+		t.Errorf("ToLegacy: converted Tor v3 address")
+	}
+}
+
+// TestNetAddressV2Wire tests the NetAddressV2 wire encode and decode including
+// the errors for invalid address sizes.
+func TestNetAddressV2Wire(t *testing.T) {
+	pver := ProtocolVersion
+	wireErr := &MessageError{}
+
+	torV3 := NewNetAddressV2(time.Unix(0x495fab29, 0), SFNodeNetwork,
+		NetTorV3, hexToBytes("79bcc625184b05194975c28b66b66b0469f7f6556fb1"+
+			"ac3189a79b40dda32f1f"), 9999)
+	torV3Encoded := append([]byte{
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x01, // Varint for SFNodeNetwork
+		0x04, // NetTorV3
+		0x20, // Varint for address size
+	}, torV3.Addr...)
+	torV3Encoded = append(torV3Encoded, 0x27, 0x0f) // Port 9999 in big-endian
+
+	unknown := NewNetAddressV2(time.Unix(0x495fab29, 0), 0, 0xaa,
+		[]byte{0x01, 0x02, 0x03}, 8333)
+	unknownEncoded := []byte{
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x00,             // Varint for services
+		0xaa,             // Unknown network
+		0x03,             // Varint for address size
+		0x01, 0x02, 0x03, // Address
+		0x20, 0x8d, // Port 8333 in big-endian
+	}
+
+	tests := []struct {
+		in  *NetAddressV2 // NetAddressV2 to encode
+		buf []byte        // Wire encoding
+	}{
+		{torV3, torV3Encoded},
+		{unknown, unknownEncoded},
+	}
+
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := writeNetAddressV2(&buf, pver, test.in)
+		if err != nil {
+			t.Errorf("writeNetAddressV2 #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("writeNetAddressV2 #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		var na NetAddressV2
+		err = readNetAddressV2(bytes.NewReader(test.buf), pver, &na)
+		if err != nil {
+			t.Errorf("readNetAddressV2 #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&na, test.in) {
+			t.Errorf("readNetAddressV2 #%d\n got: %s want: %s", i,
+				spew.Sdump(na), spew.Sdump(test.in))
+		}
+	}
+
+	// Addresses of known networks with the wrong size are rejected.
+	badSize := append([]byte{}, unknownEncoded...)
+	badSize[5] = byte(NetIPv4)
+	var na NetAddressV2
+	err := readNetAddressV2(bytes.NewReader(badSize), pver, &na)
+	if reflect.TypeOf(err) != reflect.TypeOf(wireErr) {
+		t.Errorf("readNetAddressV2: got error %v, want %T", err, wireErr)
+	}
+
+	// Addresses which exceed the maximum size are rejected.
+	tooLarge := NewNetAddressV2(time.Now(), 0, 0xaa,
+		make([]byte, MaxAddrV2Size+1), 8333)
+	err = writeNetAddressV2(io.Discard, pver, tooLarge)
+	if reflect.TypeOf(err) != reflect.TypeOf(wireErr) {
+		t.Errorf("writeNetAddressV2: got error %v, want %T", err, wireErr)
+	}
+	var buf bytes.Buffer
+	tooLargeEncoded := append([]byte{}, unknownEncoded[:6]...)
+	WriteVarBytes(&buf, pver, tooLarge.Addr)
+	tooLargeEncoded = append(tooLargeEncoded, buf.Bytes()...)
+	err = readNetAddressV2(bytes.NewReader(tooLargeEncoded), pver, &na)
+	if reflect.TypeOf(err) != reflect.TypeOf(wireErr) {
+		t.Errorf("readNetAddressV2: got error %v, want %T", err, wireErr)
+	}
+}
@@ -10,10 +10,9 @@ import (
 	"strings"
 )
 
-// XXX pedro: we will probably need to bump this.
 const (
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 70013
+	ProtocolVersion uint32 = AddrV2Version
 
 	// MultipleAddressVersion is the protocol version which added multiple
 	// addresses per message (pver >= MultipleAddressVersion).
@@ -51,6 +50,33 @@ const (
 	// FeeFilterVersion is the protocol version which added a new
 	// feefilter message.
 	FeeFilterVersion uint32 = 70013
+
+	// ShortIDsBlocksVersion is the protocol version which added the
+	// sendcmpct, cmpctblock, getblocktxn and blocktxn messages of compact
+	// blocks (BIP0152).
+	ShortIDsBlocksVersion uint32 = 70209
+
+	// LLMQVersion is the protocol version which added the Dash mnauth,
+	// senddsq, qsendrecsigs, clsig and islock messages along with the long
+	// living masternode quorums (DIP0006) they are used with and the
+	// qfcommit, qcontrib, qcomplaint, qjustify and qpcommit messages of
+	// their DKG sessions.
+	LLMQVersion uint32 = 70214
+
+	// MNAuthNodeVersionVersion is the protocol version from which the
+	// signature of a Dash mnauth message also commits to the protocol
+	// version of the signing masternode.
+	MNAuthNodeVersionVersion uint32 = 70218
+
+	// ISDLockVersion is the protocol version which added the Dash isdlock
+	// message, the deterministic replacement of the islock message
+	// (DIP0022).
+	ISDLockVersion uint32 = 70220
+
+	// AddrV2Version is the protocol version which added the sendaddrv2 and
+	// addrv2 messages for relaying addresses of overlay networks such as
+	// Tor v3 onion services (BIP0155).
+	AddrV2Version uint32 = 70223
 )
 
 // ServiceFlag identifies services supported by a bitcoin peer.
@@ -0,0 +1,442 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"io"
+	"net"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// ProTxBasicBLSVersion is the provider transaction version which
+	// switched the operator keys and signatures to the basic BLS scheme and
+	// introduced the Evo masternodes.
+	ProTxBasicBLSVersion = 2
+
+	// maxProTxScriptSize is the maximum size of the payout scripts of
+	// provider transactions.  It matches the maximum size of a script.
+	maxProTxScriptSize = 10000
+
+	// maxProTxSigSize is the maximum size of the compact ECDSA signatures
+	// of provider transactions.
+	maxProTxSigSize = 65
+
+	// serviceAddrSize is the size of a serialized masternode service
+	// address, which is a 16 byte IP address and a 2 byte port.
+	serviceAddrSize = 18
+
+	// platformFieldsSize is the size of the platform fields of Evo
+	// masternodes, which are a 20 byte node ID and two 2 byte ports.
+	platformFieldsSize = 24
+)
+
+// MasternodeType identifies the type of a masternode registered by a provider
+// transaction.
+type MasternodeType uint16
+
+const (
+	// MasternodeTypeRegular identifies a regular masternode.
+	MasternodeTypeRegular MasternodeType = 0
+
+	// MasternodeTypeEvo identifies an Evo masternode, which also serves
+	// Dash Platform.
+	MasternodeTypeEvo MasternodeType = 1
+)
+
+// readServiceAddr reads the service address of a masternode, which is encoded
+// as a 16 byte IP address followed by the port in big endian.
+func readServiceAddr(r io.Reader) (net.IP, uint16, error) {
+	var ip [16]byte
+	if err := readElement(r, &ip); err != nil {
+		return nil, 0, err
+	}
+	port, err := binarySerializer.Uint16(r, bigEndian)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return net.IP(ip[:]), port, nil
+}
+
+// writeServiceAddr writes the service address of a masternode.
+func writeServiceAddr(w io.Writer, addr net.IP, port uint16) error {
+	// Ensure to always write 16 bytes even if the ip is nil.
+	var ip [16]byte
+	if addr != nil {
+		copy(ip[:], addr.To16())
+	}
+	if err := writeElement(w, ip); err != nil {
+		return err
+	}
+
+	return binarySerializer.PutUint16(w, bigEndian, port)
+}
+
+// PlatformFields houses the Dash Platform details of an Evo masternode.
+type PlatformFields struct {
+	PlatformNodeID   [20]byte
+	PlatformP2PPort  uint16
+	PlatformHTTPPort uint16
+}
+
+// ProRegTx is the payload of a DIP0003 provider registration special
+// transaction which registers a masternode.
+type ProRegTx struct {
+	Version            uint16
+	Type               MasternodeType
+	Mode               uint16
+	CollateralOutpoint OutPoint
+	IP                 net.IP
+	Port               uint16
+	KeyIDOwner         [20]byte
+	PubKeyOperator     [BLSPublicKeySize]byte
+	KeyIDVoting        [20]byte
+	OperatorReward     uint16
+	ScriptPayout       []byte
+	InputsHash         chainhash.Hash
+
+	// Platform is only serialized for Evo masternodes registered with a
+	// version of at least ProTxBasicBLSVersion.
+	Platform PlatformFields
+
+	PayloadSig []byte
+}
+
+// hasPlatformFields returns whether or not the payload includes the platform
+// fields of Evo masternodes.
+func (p *ProRegTx) hasPlatformFields() bool {
+	return p.Version >= ProTxBasicBLSVersion && p.Type == MasternodeTypeEvo
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (p *ProRegTx) Deserialize(r io.Reader) error {
+	err := readElements(r, &p.Version, &p.Type, &p.Mode)
+	if err != nil {
+		return err
+	}
+	if err := readOutPoint(r, 0, 0, &p.CollateralOutpoint); err != nil {
+		return err
+	}
+	p.IP, p.Port, err = readServiceAddr(r)
+	if err != nil {
+		return err
+	}
+	err = readElements(r, &p.KeyIDOwner, &p.PubKeyOperator,
+		&p.KeyIDVoting, &p.OperatorReward)
+	if err != nil {
+		return err
+	}
+	p.ScriptPayout, err = ReadVarBytes(r, 0, maxProTxScriptSize,
+		"ScriptPayout")
+	if err != nil {
+		return err
+	}
+	if err := readElement(r, &p.InputsHash); err != nil {
+		return err
+	}
+	if p.hasPlatformFields() {
+		err := readElements(r, &p.Platform.PlatformNodeID,
+			&p.Platform.PlatformP2PPort, &p.Platform.PlatformHTTPPort)
+		if err != nil {
+			return err
+		}
+	}
+	p.PayloadSig, err = ReadVarBytes(r, 0, maxProTxSigSize, "PayloadSig")
+	return err
+}
+
+// Serialize encodes the payload to w.
+func (p *ProRegTx) Serialize(w io.Writer) error {
+	err := writeElements(w, p.Version, p.Type, p.Mode)
+	if err != nil {
+		return err
+	}
+	if err := writeOutPoint(w, 0, 0, &p.CollateralOutpoint); err != nil {
+		return err
+	}
+	if err := writeServiceAddr(w, p.IP, p.Port); err != nil {
+		return err
+	}
+	err = writeElements(w, p.KeyIDOwner, p.PubKeyOperator, p.KeyIDVoting,
+		p.OperatorReward)
+	if err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, 0, p.ScriptPayout); err != nil {
+		return err
+	}
+	if err := writeElement(w, &p.InputsHash); err != nil {
+		return err
+	}
+	if p.hasPlatformFields() {
+		err := writeElements(w, p.Platform.PlatformNodeID,
+			p.Platform.PlatformP2PPort, p.Platform.PlatformHTTPPort)
+		if err != nil {
+			return err
+		}
+	}
+
+	return WriteVarBytes(w, 0, p.PayloadSig)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (p *ProRegTx) SerializeSize() int {
+	// Version 2 bytes + type 2 bytes + mode 2 bytes + collateral outpoint
+	// 36 bytes + service address + owner key ID 20 bytes + operator public
+	// key + voting key ID 20 bytes + operator reward 2 bytes + serialized
+	// varint size and payout script + inputs hash 32 bytes + serialized
+	// varint size and signature.
+	n := 2 + 2 + 2 + 36 + serviceAddrSize + 20 + BLSPublicKeySize + 20 +
+		2 + VarIntSerializeSize(uint64(len(p.ScriptPayout))) +
+		len(p.ScriptPayout) + chainhash.HashSize +
+		VarIntSerializeSize(uint64(len(p.PayloadSig))) + len(p.PayloadSig)
+	if p.hasPlatformFields() {
+		n += platformFieldsSize
+	}
+
+	return n
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (p *ProRegTx) TxType() TxType {
+	return TxTypeProRegister
+}
+
+// ProUpServTx is the payload of a DIP0003 provider update service special
+// transaction which updates the service address and operator payout of a
+// masternode.
+type ProUpServTx struct {
+	Version              uint16
+	Type                 MasternodeType
+	ProTxHash            chainhash.Hash
+	IP                   net.IP
+	Port                 uint16
+	ScriptOperatorPayout []byte
+	InputsHash           chainhash.Hash
+
+	// Platform is only serialized for Evo masternodes updated with a
+	// version of at least ProTxBasicBLSVersion.
+	Platform PlatformFields
+
+	Sig [BLSSignatureSize]byte
+}
+
+// hasType returns whether or not the payload includes the masternode type.
+func (p *ProUpServTx) hasType() bool {
+	return p.Version >= ProTxBasicBLSVersion
+}
+
+// hasPlatformFields returns whether or not the payload includes the platform
+// fields of Evo masternodes.
+func (p *ProUpServTx) hasPlatformFields() bool {
+	return p.hasType() && p.Type == MasternodeTypeEvo
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (p *ProUpServTx) Deserialize(r io.Reader) error {
+	if err := readElement(r, &p.Version); err != nil {
+		return err
+	}
+	p.Type = MasternodeTypeRegular
+	if p.hasType() {
+		if err := readElement(r, &p.Type); err != nil {
+			return err
+		}
+	}
+	err := readElement(r, &p.ProTxHash)
+	if err != nil {
+		return err
+	}
+	p.IP, p.Port, err = readServiceAddr(r)
+	if err != nil {
+		return err
+	}
+	p.ScriptOperatorPayout, err = ReadVarBytes(r, 0, maxProTxScriptSize,
+		"ScriptOperatorPayout")
+	if err != nil {
+		return err
+	}
+	if err := readElement(r, &p.InputsHash); err != nil {
+		return err
+	}
+	if p.hasPlatformFields() {
+		err := readElements(r, &p.Platform.PlatformNodeID,
+			&p.Platform.PlatformP2PPort, &p.Platform.PlatformHTTPPort)
+		if err != nil {
+			return err
+		}
+	}
+
+	return readElement(r, &p.Sig)
+}
+
+// Serialize encodes the payload to w.
+func (p *ProUpServTx) Serialize(w io.Writer) error {
+	if err := writeElement(w, p.Version); err != nil {
+		return err
+	}
+	if p.hasType() {
+		if err := writeElement(w, p.Type); err != nil {
+			return err
+		}
+	}
+	if err := writeElement(w, &p.ProTxHash); err != nil {
+		return err
+	}
+	if err := writeServiceAddr(w, p.IP, p.Port); err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, 0, p.ScriptOperatorPayout); err != nil {
+		return err
+	}
+	if err := writeElement(w, &p.InputsHash); err != nil {
+		return err
+	}
+	if p.hasPlatformFields() {
+		err := writeElements(w, p.Platform.PlatformNodeID,
+			p.Platform.PlatformP2PPort, p.Platform.PlatformHTTPPort)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeElement(w, p.Sig)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (p *ProUpServTx) SerializeSize() int {
+	// Version 2 bytes + ProTx hash 32 bytes + service address + serialized
+	// varint size and operator payout script + inputs hash 32 bytes + BLS
+	// signature.
+	n := 2 + chainhash.HashSize + serviceAddrSize +
+		VarIntSerializeSize(uint64(len(p.ScriptOperatorPayout))) +
+		len(p.ScriptOperatorPayout) + chainhash.HashSize +
+		BLSSignatureSize
+	if p.hasType() {
+		n += 2
+	}
+	if p.hasPlatformFields() {
+		n += platformFieldsSize
+	}
+
+	return n
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (p *ProUpServTx) TxType() TxType {
+	return TxTypeProUpdateService
+}
+
+// ProUpRegTx is the payload of a DIP0003 provider update registrar special
+// transaction which updates the operator and voting keys and the payout of a
+// masternode.
+type ProUpRegTx struct {
+	Version        uint16
+	ProTxHash      chainhash.Hash
+	Mode           uint16
+	PubKeyOperator [BLSPublicKeySize]byte
+	KeyIDVoting    [20]byte
+	ScriptPayout   []byte
+	InputsHash     chainhash.Hash
+	PayloadSig     []byte
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (p *ProUpRegTx) Deserialize(r io.Reader) error {
+	err := readElements(r, &p.Version, &p.ProTxHash, &p.Mode,
+		&p.PubKeyOperator, &p.KeyIDVoting)
+	if err != nil {
+		return err
+	}
+	p.ScriptPayout, err = ReadVarBytes(r, 0, maxProTxScriptSize,
+		"ScriptPayout")
+	if err != nil {
+		return err
+	}
+	if err := readElement(r, &p.InputsHash); err != nil {
+		return err
+	}
+	p.PayloadSig, err = ReadVarBytes(r, 0, maxProTxSigSize, "PayloadSig")
+	return err
+}
+
+// Serialize encodes the payload to w.
+func (p *ProUpRegTx) Serialize(w io.Writer) error {
+	err := writeElements(w, p.Version, &p.ProTxHash, p.Mode,
+		p.PubKeyOperator, p.KeyIDVoting)
+	if err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, 0, p.ScriptPayout); err != nil {
+		return err
+	}
+	if err := writeElement(w, &p.InputsHash); err != nil {
+		return err
+	}
+
+	return WriteVarBytes(w, 0, p.PayloadSig)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (p *ProUpRegTx) SerializeSize() int {
+	// Version 2 bytes + ProTx hash 32 bytes + mode 2 bytes + operator
+	// public key + voting key ID 20 bytes + serialized varint size and
+	// payout script + inputs hash 32 bytes + serialized varint size and
+	// signature.
+	return 2 + chainhash.HashSize + 2 + BLSPublicKeySize + 20 +
+		VarIntSerializeSize(uint64(len(p.ScriptPayout))) +
+		len(p.ScriptPayout) + chainhash.HashSize +
+		VarIntSerializeSize(uint64(len(p.PayloadSig))) + len(p.PayloadSig)
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (p *ProUpRegTx) TxType() TxType {
+	return TxTypeProUpdateRegistrar
+}
+
+// ProUpRevTx is the payload of a DIP0003 provider update revocation special
+// transaction with which the operator of a masternode stops serving it.
+type ProUpRevTx struct {
+	Version    uint16
+	ProTxHash  chainhash.Hash
+	Reason     uint16
+	InputsHash chainhash.Hash
+	Sig        [BLSSignatureSize]byte
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (p *ProUpRevTx) Deserialize(r io.Reader) error {
+	return readElements(r, &p.Version, &p.ProTxHash, &p.Reason,
+		&p.InputsHash, &p.Sig)
+}
+
+// Serialize encodes the payload to w.
+func (p *ProUpRevTx) Serialize(w io.Writer) error {
+	return writeElements(w, p.Version, &p.ProTxHash, p.Reason,
+		&p.InputsHash, p.Sig)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (p *ProUpRevTx) SerializeSize() int {
+	// Version 2 bytes + ProTx hash 32 bytes + reason 2 bytes + inputs hash
+	// 32 bytes + BLS signature.
+	return 2 + chainhash.HashSize + 2 + chainhash.HashSize +
+		BLSSignatureSize
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (p *ProUpRevTx) TxType() TxType {
+	return TxTypeProUpdateRevoke
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+const (
+	// QuorumCommitmentIndexedVersion is the final commitment version which
+	// introduced the quorum index used by rotating quorums.
+	QuorumCommitmentIndexedVersion = 2
+
+	// QuorumCommitmentBasicBLSIndexedVersion is the final commitment
+	// version which carries a quorum index along with a public key in the
+	// basic BLS scheme.
+	QuorumCommitmentBasicBLSIndexedVersion = 4
+
+	// maxQuorumMembers is the maximum number of members a quorum may have
+	// and therefore the maximum size of the bitsets within a final
+	// commitment.
+	maxQuorumMembers = 1000
+)
+
+// LLMQType identifies the type of a long living masternode quorum.
+type LLMQType uint8
+
+// QuorumCommitment is a final commitment of an LLMQ DKG session as mined in
+// quorum commitment special transactions and relayed within masternode list
+// diffs.
+type QuorumCommitment struct {
+	Version        uint16
+	LLMQType       LLMQType
+	QuorumHash     chainhash.Hash
+	QuorumIndex    int16
+	Signers        []bool
+	ValidMembers   []bool
+	QuorumPubKey   [BLSPublicKeySize]byte
+	QuorumVvecHash chainhash.Hash
+	QuorumSig      [BLSSignatureSize]byte
+	MembersSig     [BLSSignatureSize]byte
+}
+
+// hasQuorumIndex returns whether or not the version of the commitment includes
+// the quorum index.
+func (qc *QuorumCommitment) hasQuorumIndex() bool {
+	return qc.Version == QuorumCommitmentIndexedVersion ||
+		qc.Version == QuorumCommitmentBasicBLSIndexedVersion
+}
+
+// Hash returns the hash of the serialized commitment.
+func (qc *QuorumCommitment) Hash() chainhash.Hash {
+	var buf bytes.Buffer
+	_ = qc.Serialize(&buf)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// Deserialize decodes a final commitment from r into the receiver.
+func (qc *QuorumCommitment) Deserialize(r io.Reader) error {
+	err := readElements(r, &qc.Version, &qc.LLMQType, &qc.QuorumHash)
+	if err != nil {
+		return err
+	}
+	if qc.hasQuorumIndex() {
+		if err := readElement(r, &qc.QuorumIndex); err != nil {
+			return err
+		}
+	}
+
+	qc.Signers, err = readDynBitSet(r, "Signers")
+	if err != nil {
+		return err
+	}
+	qc.ValidMembers, err = readDynBitSet(r, "ValidMembers")
+	if err != nil {
+		return err
+	}
+
+	return readElements(r, &qc.QuorumPubKey, &qc.QuorumVvecHash,
+		&qc.QuorumSig, &qc.MembersSig)
+}
+
+// Serialize encodes the final commitment to w.
+func (qc *QuorumCommitment) Serialize(w io.Writer) error {
+	err := writeElements(w, qc.Version, qc.LLMQType, &qc.QuorumHash)
+	if err != nil {
+		return err
+	}
+	if qc.hasQuorumIndex() {
+		if err := writeElement(w, qc.QuorumIndex); err != nil {
+			return err
+		}
+	}
+
+	if err := writeDynBitSet(w, qc.Signers); err != nil {
+		return err
+	}
+	if err := writeDynBitSet(w, qc.ValidMembers); err != nil {
+		return err
+	}
+
+	return writeElements(w, qc.QuorumPubKey, &qc.QuorumVvecHash,
+		qc.QuorumSig, qc.MembersSig)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// final commitment.
+func (qc *QuorumCommitment) SerializeSize() int {
+	// Version 2 bytes + LLMQ type 1 byte + quorum hash 32 bytes + the
+	// bitsets + quorum public key + verification vector hash 32 bytes +
+	// quorum and members signatures.
+	n := 2 + 1 + chainhash.HashSize + dynBitSetSerializeSize(qc.Signers) +
+		dynBitSetSerializeSize(qc.ValidMembers) + BLSPublicKeySize +
+		chainhash.HashSize + 2*BLSSignatureSize
+	if qc.hasQuorumIndex() {
+		n += 2
+	}
+
+	return n
+}
+
+// dynBitSetSerializeSize returns the number of bytes it would take to
+// serialize the passed bits as a dynamically sized bitset.
+func dynBitSetSerializeSize(bits []bool) int {
+	return VarIntSerializeSize(uint64(len(bits))) + (len(bits)+7)/8
+}
+
+// readDynBitSet reads a dynamically sized bitset, which is encoded as a varint
+// number of bits followed by the bits packed least significant bit first.
+func readDynBitSet(r io.Reader, fieldName string) ([]bool, error) {
+	count, err := ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxQuorumMembers {
+		str := fmt.Sprintf("%s bitset is larger than the max allowed "+
+			"size [count %d, max %d]", fieldName, count,
+			maxQuorumMembers)
+		return nil, messageError("readDynBitSet", str)
+	}
+
+	packed := make([]byte, (count+7)/8)
+	if _, err := io.ReadFull(r, packed); err != nil {
+		return nil, err
+	}
+
+	bits := make([]bool, count)
+	for i := range bits {
+		bits[i] = packed[i/8]&(1<<uint(i%8)) != 0
+	}
+
+	return bits, nil
+}
+
+// writeDynBitSet writes the passed bits as a dynamically sized bitset.
+func writeDynBitSet(w io.Writer, bits []bool) error {
+	if err := WriteVarInt(w, 0, uint64(len(bits))); err != nil {
+		return err
+	}
+
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			packed[i/8] |= 1 << uint(i%8)
+		}
+	}
+
+	_, err := w.Write(packed)
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	// customMessagesMtx protects customMessages.
+	customMessagesMtx sync.RWMutex
+
+	// customMessages maps the commands registered via RegisterMessage to
+	// the functions that create empty messages for them.
+	customMessages = make(map[string]func() Message)
+)
+
+// RegisterMessage registers a custom message command so ReadMessage and the
+// related functions decode messages with that command using the message
+// returned by newMsg.  The returned message must be a new empty instance for
+// every call and is decoded and encoded via its BtcDecode and BtcEncode
+// methods.
+//
+// This allows external packages to add messages, such as for devnet
+// experiments or platform messages, without modifying this package.  The
+// commands built into the package can't be overridden, and the command must be
+// a non-empty valid UTF-8 string of at most CommandSize bytes without NUL
+// characters that isn't already registered.
+//
+// This function is safe for concurrent access.
+func RegisterMessage(command string, newMsg func() Message) error {
+	const funcName = "RegisterMessage"
+
+	switch {
+	case newMsg == nil:
+		str := fmt.Sprintf("nil constructor for command [%s]", command)
+		return messageError(funcName, str)
+
+	case len(command) == 0 || len(command) > CommandSize:
+		str := fmt.Sprintf("command [%s] must be 1 to %d bytes", command,
+			CommandSize)
+		return messageError(funcName, str)
+
+	case !utf8.ValidString(command) || strings.ContainsRune(command, 0):
+		str := fmt.Sprintf("invalid command %v", []byte(command))
+		return messageError(funcName, str)
+
+	case makeBuiltinMessage(command) != nil:
+		str := fmt.Sprintf("command [%s] is built in and can't be "+
+			"overridden", command)
+		return messageError(funcName, str)
+	}
+
+	customMessagesMtx.Lock()
+	defer customMessagesMtx.Unlock()
+
+	if _, ok := customMessages[command]; ok {
+		str := fmt.Sprintf("command [%s] is already registered", command)
+		return messageError(funcName, str)
+	}
+	customMessages[command] = newMsg
+	return nil
+}
+
+// UnregisterMessage removes a custom message command previously registered via
+// RegisterMessage.  It returns whether or not the command was registered.
+//
+// This function is safe for concurrent access.
+func UnregisterMessage(command string) bool {
+	customMessagesMtx.Lock()
+	defer customMessagesMtx.Unlock()
+
+	_, ok := customMessages[command]
+	delete(customMessages, command)
+	return ok
+}
+
+// makeCustomMessage creates an empty message for a command registered via
+// RegisterMessage.  It returns nil when the command is not registered.
+func makeCustomMessage(command string) Message {
+	customMessagesMtx.RLock()
+	newMsg := customMessages[command]
+	customMessagesMtx.RUnlock()
+
+	if newMsg == nil {
+		return nil
+	}
+	return newMsg()
+}
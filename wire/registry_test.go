@@ -0,0 +1,115 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// testCustomMsg is a custom message used to test the message registry.
+type testCustomMsg struct {
+	Payload []byte
+}
+
+// BtcDecode decodes r into the receiver.  It satisfies the Message interface.
+func (msg *testCustomMsg) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	payload, err := ReadVarBytes(r, pver, 100, "payload")
+	msg.Payload = payload
+	return err
+}
+
+// BtcEncode encodes the receiver to w.  It satisfies the Message interface.
+func (msg *testCustomMsg) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	return WriteVarBytes(w, pver, msg.Payload)
+}
+
+// Command returns the command of the message.  It satisfies the Message
+// interface.
+func (msg *testCustomMsg) Command() string {
+	return "testcustom"
+}
+
+// MaxPayloadLength returns the maximum length of the payload.  It satisfies
+// the Message interface.
+func (msg *testCustomMsg) MaxPayloadLength(pver uint32) uint32 {
+	return MaxVarIntPayload + 100
+}
+
+// TestRegisterMessage ensures custom messages can be registered, round trip
+// through the wire and be unregistered, and that invalid registrations are
+// rejected.
+func TestRegisterMessage(t *testing.T) {
+	newMsg := func() Message { return &testCustomMsg{} }
+	if err := RegisterMessage("testcustom", newMsg); err != nil {
+		t.Fatalf("RegisterMessage: unexpected error: %v", err)
+	}
+	defer UnregisterMessage("testcustom")
+
+	// Ensure the custom message round trips.
+	msg := &testCustomMsg{Payload: []byte{1, 2, 3}}
+	var buf bytes.Buffer
+	_, err := WriteMessageN(&buf, msg, ProtocolVersion, MainNet)
+	if err != nil {
+		t.Fatalf("WriteMessageN: unexpected error: %v", err)
+	}
+	encoded := buf.Bytes()
+	_, got, _, err := ReadMessageN(bytes.NewReader(encoded),
+		ProtocolVersion, MainNet)
+	if err != nil {
+		t.Fatalf("ReadMessageN: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Fatalf("wrong message - got %+v, want %+v", got, msg)
+	}
+
+	// Ensure invalid registrations are rejected.
+	tests := []struct {
+		name    string
+		command string
+		newMsg  func() Message
+	}{
+		{"duplicate", "testcustom", newMsg},
+		{"built in", CmdVersion, newMsg},
+		{"built in dash", CmdMnListDiff, newMsg},
+		{"empty", "", newMsg},
+		{"too long", "thirteenchars", newMsg},
+		{"nul character", "test\x00cmd", newMsg},
+		{"invalid utf8", "test\xff", newMsg},
+		{"nil constructor", "testnil", nil},
+	}
+	for _, test := range tests {
+		err := RegisterMessage(test.command, test.newMsg)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: unexpected error - got %v (%T), want "+
+				"*MessageError", test.name, err, err)
+		}
+	}
+
+	// Ensure built in commands still decode to their own types.
+	empty, err := makeEmptyMessage(CmdVersion)
+	if err != nil {
+		t.Fatalf("makeEmptyMessage: unexpected error: %v", err)
+	}
+	if _, ok := empty.(*MsgVersion); !ok {
+		t.Fatalf("built in command decoded to %T", empty)
+	}
+
+	// Ensure the command is no longer decoded once unregistered.
+	if !UnregisterMessage("testcustom") {
+		t.Fatal("UnregisterMessage: command not registered")
+	}
+	if UnregisterMessage("testcustom") {
+		t.Fatal("UnregisterMessage: command unregistered twice")
+	}
+	_, _, _, err = ReadMessageN(bytes.NewReader(encoded), ProtocolVersion,
+		MainNet)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("ReadMessageN: unexpected error - got %v (%T), want "+
+			"*MessageError", err, err)
+	}
+}
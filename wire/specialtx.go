@@ -0,0 +1,265 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+)
+
+// TxType identifies the type of a DIP0002 special transaction.
+type TxType uint16
+
+// These constants define the known DIP0002 special transaction types.
+const (
+	// TxTypeNormal identifies a regular transaction without a payload.
+	TxTypeNormal TxType = 0
+
+	// TxTypeProRegister identifies a masternode registration.
+	TxTypeProRegister TxType = 1
+
+	// TxTypeProUpdateService identifies a masternode service update.
+	TxTypeProUpdateService TxType = 2
+
+	// TxTypeProUpdateRegistrar identifies a masternode registrar update.
+	TxTypeProUpdateRegistrar TxType = 3
+
+	// TxTypeProUpdateRevoke identifies a masternode revocation.
+	TxTypeProUpdateRevoke TxType = 4
+
+	// TxTypeCoinbase identifies a coinbase transaction carrying the
+	// masternode list and quorum commitments of its block (DIP0004).
+	TxTypeCoinbase TxType = 5
+
+	// TxTypeQuorumCommitment identifies a mined LLMQ final commitment.
+	TxTypeQuorumCommitment TxType = 6
+)
+
+// txTypeStrings is a map of transaction types back to their constant names for
+// pretty printing.
+var txTypeStrings = map[TxType]string{
+	TxTypeNormal:             "TxTypeNormal",
+	TxTypeProRegister:        "TxTypeProRegister",
+	TxTypeProUpdateService:   "TxTypeProUpdateService",
+	TxTypeProUpdateRegistrar: "TxTypeProUpdateRegistrar",
+	TxTypeProUpdateRevoke:    "TxTypeProUpdateRevoke",
+	TxTypeCoinbase:           "TxTypeCoinbase",
+	TxTypeQuorumCommitment:   "TxTypeQuorumCommitment",
+}
+
+// String returns the TxType in human-readable form.
+func (t TxType) String() string {
+	if s, ok := txTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown TxType (%d)", uint16(t))
+}
+
+const (
+	// BLSPublicKeySize is the size of a serialized BLS public key.
+	BLSPublicKeySize = 48
+
+	// BLSSignatureSize is the size of a serialized BLS signature.
+	BLSSignatureSize = 96
+)
+
+// SpecialTxPayload is the interface implemented by the typed payloads of
+// DIP0002 special transactions.
+type SpecialTxPayload interface {
+	// Deserialize decodes the payload from r into the receiver.
+	Deserialize(r io.Reader) error
+
+	// Serialize encodes the payload to w.
+	Serialize(w io.Writer) error
+
+	// SerializeSize returns the number of bytes it would take to
+	// serialize the payload.
+	SerializeSize() int
+
+	// TxType returns the type of special transaction carrying the
+	// payload.
+	TxType() TxType
+}
+
+// CbTx is the payload of a DIP0004 coinbase special transaction which commits
+// to the masternode list and, starting with version 2, the active quorums as of
+// the block containing it.
+type CbTx struct {
+	Version           uint16
+	Height            int32
+	MerkleRootMNList  chainhash.Hash
+	MerkleRootQuorums chainhash.Hash
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (cb *CbTx) Deserialize(r io.Reader) error {
+	err := readElements(r, &cb.Version, &cb.Height, &cb.MerkleRootMNList)
+	if err != nil {
+		return err
+	}
+	if cb.Version >= 2 {
+		return readElement(r, &cb.MerkleRootQuorums)
+	}
+
+	return nil
+}
+
+// Serialize encodes the payload to w.
+func (cb *CbTx) Serialize(w io.Writer) error {
+	err := writeElements(w, cb.Version, cb.Height, &cb.MerkleRootMNList)
+	if err != nil {
+		return err
+	}
+	if cb.Version >= 2 {
+		return writeElement(w, &cb.MerkleRootQuorums)
+	}
+
+	return nil
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (cb *CbTx) SerializeSize() int {
+	// Version 2 bytes + height 4 bytes + masternode list merkle root 32
+	// bytes.
+	n := 2 + 4 + chainhash.HashSize
+	if cb.Version >= 2 {
+		n += chainhash.HashSize
+	}
+
+	return n
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (cb *CbTx) TxType() TxType {
+	return TxTypeCoinbase
+}
+
+// QcTx is the payload of a quorum commitment special transaction which mines
+// the final commitment of an LLMQ DKG session.
+type QcTx struct {
+	Version    uint16
+	Height     uint32
+	Commitment QuorumCommitment
+}
+
+// Deserialize decodes the payload from r into the receiver.
+func (qc *QcTx) Deserialize(r io.Reader) error {
+	if err := readElements(r, &qc.Version, &qc.Height); err != nil {
+		return err
+	}
+
+	return qc.Commitment.Deserialize(r)
+}
+
+// Serialize encodes the payload to w.
+func (qc *QcTx) Serialize(w io.Writer) error {
+	if err := writeElements(w, qc.Version, qc.Height); err != nil {
+		return err
+	}
+
+	return qc.Commitment.Serialize(w)
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// payload.
+func (qc *QcTx) SerializeSize() int {
+	// Version 2 bytes + height 4 bytes + final commitment.
+	return 2 + 4 + qc.Commitment.SerializeSize()
+}
+
+// TxType returns the type of special transaction carrying the payload.  This
+// is part of the SpecialTxPayload interface implementation.
+func (qc *QcTx) TxType() TxType {
+	return TxTypeQuorumCommitment
+}
+
+// newSpecialTxPayload returns an empty payload of the passed special
+// transaction type or nil when the type is unknown.
+func newSpecialTxPayload(txType TxType) SpecialTxPayload {
+	switch txType {
+	case TxTypeProRegister:
+		return &ProRegTx{}
+	case TxTypeProUpdateService:
+		return &ProUpServTx{}
+	case TxTypeProUpdateRegistrar:
+		return &ProUpRegTx{}
+	case TxTypeProUpdateRevoke:
+		return &ProUpRevTx{}
+	case TxTypeCoinbase:
+		return &CbTx{}
+	case TxTypeQuorumCommitment:
+		return &QcTx{}
+	}
+
+	return nil
+}
+
+// Payload decodes the extra payload of the special transaction into the typed
+// payload of its transaction type, such as *ProRegTx for provider registration
+// transactions.  An error is returned when the transaction is not a special
+// transaction, its type is unknown or the payload is malformed, including when
+// it has trailing bytes.
+func (msg *MsgTx) Payload() (SpecialTxPayload, error) {
+	if !msg.IsSpecial() {
+		return nil, messageError("MsgTx.Payload",
+			"transaction is not a special transaction")
+	}
+
+	payload := newSpecialTxPayload(msg.TxType())
+	if payload == nil {
+		str := fmt.Sprintf("unknown special transaction type %v",
+			msg.TxType())
+		return nil, messageError("MsgTx.Payload", str)
+	}
+
+	r := bytes.NewReader(msg.ExtraPayload)
+	if err := payload.Deserialize(r); err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		str := fmt.Sprintf("%v payload has %d trailing bytes",
+			msg.TxType(), r.Len())
+		return nil, messageError("MsgTx.Payload", str)
+	}
+
+	return payload, nil
+}
+
+// SetPayload makes the transaction a special transaction of the type of the
+// passed payload and sets its extra payload to the serialized payload.
+func (msg *MsgTx) SetPayload(payload SpecialTxPayload) error {
+	var buf bytes.Buffer
+	buf.Grow(payload.SerializeSize())
+	if err := payload.Serialize(&buf); err != nil {
+		return err
+	}
+
+	msg.Version = int32(payload.TxType())<<16 | SpecialTxVersion
+	msg.ExtraPayload = buf.Bytes()
+	return nil
+}
+
+// CbTxPayload decodes the extra payload of the transaction as a DIP0004
+// coinbase payload.  An error is returned when the transaction is not a
+// coinbase special transaction.
+func (msg *MsgTx) CbTxPayload() (*CbTx, error) {
+	if !msg.IsSpecial() || msg.TxType() != TxTypeCoinbase {
+		str := fmt.Sprintf("transaction type %v is not %v", msg.TxType(),
+			TxTypeCoinbase)
+		return nil, messageError("MsgTx.CbTxPayload", str)
+	}
+
+	var cb CbTx
+	if err := cb.Deserialize(bytes.NewReader(msg.ExtraPayload)); err != nil {
+		return nil, err
+	}
+
+	return &cb, nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/dashpay/dashd-go/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestSpecialTxPayloads ensures the typed payloads of all special transaction
+// types round trip through the extra payload of a transaction and that their
+// serialized sizes are accounted for.
+func TestSpecialTxPayloads(t *testing.T) {
+	proRegTx := &ProRegTx{
+		Version:            1,
+		Mode:               0,
+		CollateralOutpoint: OutPoint{Hash: chainhash.Hash{0x01}, Index: 1},
+		IP:                 net.ParseIP("1.2.3.4"),
+		Port:               9999,
+		KeyIDOwner:         [20]byte{0x02},
+		PubKeyOperator:     [BLSPublicKeySize]byte{0x03},
+		KeyIDVoting:        [20]byte{0x04},
+		OperatorReward:     500,
+		ScriptPayout:       []byte{0x76, 0xa9, 0x14},
+		InputsHash:         chainhash.Hash{0x05},
+		PayloadSig:         bytes.Repeat([]byte{0x06}, 65),
+	}
+	evoProRegTx := *proRegTx
+	evoProRegTx.Version = ProTxBasicBLSVersion
+	evoProRegTx.Type = MasternodeTypeEvo
+	evoProRegTx.Platform = PlatformFields{
+		PlatformNodeID:   [20]byte{0x07},
+		PlatformP2PPort:  26656,
+		PlatformHTTPPort: 443,
+	}
+	proUpServTx := &ProUpServTx{
+		Version:              1,
+		ProTxHash:            chainhash.Hash{0x08},
+		IP:                   net.ParseIP("::1"),
+		Port:                 19999,
+		ScriptOperatorPayout: []byte{0x51},
+		InputsHash:           chainhash.Hash{0x09},
+		Sig:                  [BLSSignatureSize]byte{0x0a},
+	}
+	evoProUpServTx := *proUpServTx
+	evoProUpServTx.Version = ProTxBasicBLSVersion
+	evoProUpServTx.Type = MasternodeTypeEvo
+	evoProUpServTx.Platform = evoProRegTx.Platform
+
+	tests := []struct {
+		name    string
+		payload SpecialTxPayload
+		size    int
+	}{
+		{"ProRegTx", proRegTx, 2 + 2 + 2 + 36 + 18 + 20 + 48 + 20 + 2 +
+			1 + 3 + 32 + 1 + 65},
+		{"Evo ProRegTx", &evoProRegTx, 2 + 2 + 2 + 36 + 18 + 20 + 48 +
+			20 + 2 + 1 + 3 + 32 + 24 + 1 + 65},
+		{"ProUpServTx", proUpServTx, 2 + 32 + 18 + 1 + 1 + 32 + 96},
+		{"Evo ProUpServTx", &evoProUpServTx, 2 + 2 + 32 + 18 + 1 + 1 +
+			32 + 24 + 96},
+		{"ProUpRegTx", &ProUpRegTx{
+			Version:        1,
+			ProTxHash:      chainhash.Hash{0x0b},
+			Mode:           0,
+			PubKeyOperator: [BLSPublicKeySize]byte{0x0c},
+			KeyIDVoting:    [20]byte{0x0d},
+			ScriptPayout:   []byte{0x51, 0x52},
+			InputsHash:     chainhash.Hash{0x0e},
+			PayloadSig:     []byte{0x0f},
+		}, 2 + 32 + 2 + 48 + 20 + 1 + 2 + 32 + 1 + 1},
+		{"ProUpRevTx", &ProUpRevTx{
+			Version:    1,
+			ProTxHash:  chainhash.Hash{0x10},
+			Reason:     2,
+			InputsHash: chainhash.Hash{0x11},
+			Sig:        [BLSSignatureSize]byte{0x12},
+		}, 2 + 32 + 2 + 32 + 96},
+		{"CbTx", &CbTx{
+			Version:           2,
+			Height:            1000,
+			MerkleRootMNList:  chainhash.Hash{0x13},
+			MerkleRootQuorums: chainhash.Hash{0x14},
+		}, 2 + 4 + 32 + 32},
+		{"QcTx", &QcTx{
+			Version:    1,
+			Height:     1000,
+			Commitment: *newTestQuorumCommitment(0x15),
+		}, 2 + 4 + 2 + 1 + 32 + 1 + 1 + 1 + 1 + 48 + 32 + 96 + 96},
+	}
+
+	for _, test := range tests {
+		if size := test.payload.SerializeSize(); size != test.size {
+			t.Errorf("%s: got size %d, want %d", test.name, size,
+				test.size)
+			continue
+		}
+
+		tx := NewMsgTx(1)
+		tx.AddTxIn(NewTxIn(&OutPoint{}, nil, nil))
+		if err := tx.SetPayload(test.payload); err != nil {
+			t.Errorf("%s: SetPayload: unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if tx.TxType() != test.payload.TxType() ||
+			tx.TxVersion() != SpecialTxVersion {
+
+			t.Errorf("%s: got type %v and version %d", test.name,
+				tx.TxType(), tx.TxVersion())
+			continue
+		}
+
+		// The transaction must round trip along with the payload.
+		var buf bytes.Buffer
+		if err := tx.Serialize(&buf); err != nil {
+			t.Errorf("%s: Serialize: unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if tx.SerializeSize() != buf.Len() {
+			t.Errorf("%s: got tx size %d, want %d", test.name,
+				tx.SerializeSize(), buf.Len())
+			continue
+		}
+		var readTx MsgTx
+		if err := readTx.Deserialize(&buf); err != nil {
+			t.Errorf("%s: Deserialize: unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		payload, err := readTx.Payload()
+		if err != nil {
+			t.Errorf("%s: Payload: unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if !reflect.DeepEqual(payload, test.payload) {
+			t.Errorf("%s: mismatched payload - got %v, want %v",
+				test.name, spew.Sdump(payload),
+				spew.Sdump(test.payload))
+			continue
+		}
+
+		// Truncated payloads must be rejected.
+		readTx.ExtraPayload = readTx.ExtraPayload[:len(readTx.ExtraPayload)-1]
+		if _, err := readTx.Payload(); err == nil {
+			t.Errorf("%s: Payload: decoded truncated payload",
+				test.name)
+		}
+	}
+}
+
+// TestSpecialTxPayloadErrors ensures the payloads of transactions which aren't
+// special transactions of a known type, as well as payloads with trailing
+// data, are rejected.
+func TestSpecialTxPayloadErrors(t *testing.T) {
+	tx := NewMsgTx(2)
+	if _, err := tx.Payload(); err == nil {
+		t.Fatal("Payload: decoded payload of a regular transaction")
+	}
+
+	tx.Version = 7<<16 | SpecialTxVersion
+	tx.ExtraPayload = []byte{0x01}
+	if _, err := tx.Payload(); err == nil {
+		t.Fatal("Payload: decoded payload of an unknown type")
+	}
+
+	if err := tx.SetPayload(&ProUpRevTx{Version: 1}); err != nil {
+		t.Fatalf("SetPayload: unexpected error: %v", err)
+	}
+	tx.ExtraPayload = append(tx.ExtraPayload, 0x00)
+	if _, err := tx.Payload(); err == nil {
+		t.Fatal("Payload: decoded payload with trailing data")
+	}
+}
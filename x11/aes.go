@@ -0,0 +1,70 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+// aesSbox is the AES S-box, which Grøstl, SHAvite-3 and ECHO build upon.
+var aesSbox = func() [256]byte {
+	// Walk the multiplicative group with the generator 3 to find the
+	// inverse of each element, then apply the affine transformation.
+	var sbox [256]byte
+	p, q := byte(1), byte(1)
+	for {
+		p ^= xtime(p)
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		if q&0x80 != 0 {
+			q ^= 0x09
+		}
+		x := q ^ (q<<1 | q>>7) ^ (q<<2 | q>>6) ^ (q<<3 | q>>5) ^
+			(q<<4 | q>>4)
+		sbox[p] = x ^ 0x63
+		if p == 1 {
+			break
+		}
+	}
+	sbox[0] = 0x63
+	return sbox
+}()
+
+// xtime multiplies b by x in the AES field GF(2^8).
+func xtime(b byte) byte {
+	return b<<1 ^ (b>>7)*0x1b
+}
+
+// aesRound applies an AES encryption round without the key addition, that is
+// SubBytes, ShiftRows and MixColumns, to the state held in four little endian
+// columns.
+func aesRound(w *[4]uint32) {
+	var s, t [16]byte
+	for i, v := range w {
+		s[4*i] = byte(v)
+		s[4*i+1] = byte(v >> 8)
+		s[4*i+2] = byte(v >> 16)
+		s[4*i+3] = byte(v >> 24)
+	}
+
+	// SubBytes and ShiftRows, which rotates row r left by r columns.
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			t[4*c+r] = aesSbox[s[4*((c+r)%4)+r]]
+		}
+	}
+
+	// MixColumns.
+	for c := 0; c < 4; c++ {
+		a0, a1, a2, a3 := t[4*c], t[4*c+1], t[4*c+2], t[4*c+3]
+		all := a0 ^ a1 ^ a2 ^ a3
+		t[4*c] = a0 ^ all ^ xtime(a0^a1)
+		t[4*c+1] = a1 ^ all ^ xtime(a1^a2)
+		t[4*c+2] = a2 ^ all ^ xtime(a2^a3)
+		t[4*c+3] = a3 ^ all ^ xtime(a3^a0)
+	}
+
+	for i := range w {
+		w[i] = uint32(t[4*i]) | uint32(t[4*i+1])<<8 |
+			uint32(t[4*i+2])<<16 | uint32(t[4*i+3])<<24
+	}
+}
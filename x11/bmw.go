@@ -0,0 +1,216 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// bmwW lists, for each word of the W vector of BMW-512, the indexes of the
+// five words of M xor H it sums and whether each is subtracted rather than
+// added.
+var bmwW = [16][5]struct {
+	i   uint8
+	neg bool
+}{
+	{{5, false}, {7, true}, {10, false}, {13, false}, {14, false}},
+	{{6, false}, {8, true}, {11, false}, {14, false}, {15, true}},
+	{{0, false}, {7, false}, {9, false}, {12, true}, {15, false}},
+	{{0, false}, {1, true}, {8, false}, {10, true}, {13, false}},
+	{{1, false}, {2, false}, {9, false}, {11, true}, {14, true}},
+	{{3, false}, {2, true}, {10, false}, {12, true}, {15, false}},
+	{{4, false}, {0, true}, {3, true}, {11, true}, {13, false}},
+	{{1, false}, {4, true}, {5, true}, {12, true}, {14, true}},
+	{{2, false}, {5, true}, {6, true}, {13, false}, {15, true}},
+	{{0, false}, {3, true}, {6, false}, {7, true}, {14, false}},
+	{{8, false}, {1, true}, {4, true}, {7, true}, {15, false}},
+	{{8, false}, {0, true}, {2, true}, {5, true}, {9, false}},
+	{{1, false}, {3, false}, {6, true}, {9, true}, {10, false}},
+	{{2, false}, {4, false}, {7, false}, {10, false}, {11, false}},
+	{{3, false}, {5, true}, {8, false}, {11, true}, {12, true}},
+	{{12, false}, {4, true}, {6, true}, {9, true}, {13, false}},
+}
+
+func bmwS0(x uint64) uint64 {
+	return x>>1 ^ x<<3 ^ bits.RotateLeft64(x, 4) ^ bits.RotateLeft64(x, 37)
+}
+
+func bmwS1(x uint64) uint64 {
+	return x>>1 ^ x<<2 ^ bits.RotateLeft64(x, 13) ^ bits.RotateLeft64(x, 43)
+}
+
+func bmwS2(x uint64) uint64 {
+	return x>>2 ^ x<<1 ^ bits.RotateLeft64(x, 19) ^ bits.RotateLeft64(x, 53)
+}
+
+func bmwS3(x uint64) uint64 {
+	return x>>2 ^ x<<2 ^ bits.RotateLeft64(x, 28) ^ bits.RotateLeft64(x, 59)
+}
+
+func bmwS4(x uint64) uint64 {
+	return x>>1 ^ x
+}
+
+func bmwS5(x uint64) uint64 {
+	return x>>2 ^ x
+}
+
+// bmwCompress compresses the message words m into the chaining value h.
+func bmwCompress(h *[16]uint64, m *[16]uint64) {
+	var q [32]uint64
+
+	// f0: the bijective transform of the words of M xor H.
+	for j := range bmwW {
+		var w uint64
+		for _, t := range bmwW[j] {
+			if t.neg {
+				w -= m[t.i] ^ h[t.i]
+			} else {
+				w += m[t.i] ^ h[t.i]
+			}
+		}
+		switch j % 5 {
+		case 0:
+			w = bmwS0(w)
+		case 1:
+			w = bmwS1(w)
+		case 2:
+			w = bmwS2(w)
+		case 3:
+			w = bmwS3(w)
+		case 4:
+			w = bmwS4(w)
+		}
+		q[j] = w + h[(j+1)%16]
+	}
+
+	// f1: the expansion of the quadruple pipe.
+	addElement := func(j int) uint64 {
+		rotM := func(i int) uint64 {
+			i %= 16
+			return bits.RotateLeft64(m[i], i+1)
+		}
+		return (rotM(j-16) + rotM(j-13) - rotM(j-6) +
+			uint64(j)*0x0555555555555555) ^ h[(j-16+7)%16]
+	}
+	for j := 16; j < 18; j++ {
+		var sum uint64
+		for k := 0; k < 16; k++ {
+			x := q[j-16+k]
+			switch k % 4 {
+			case 0:
+				sum += bmwS1(x)
+			case 1:
+				sum += bmwS2(x)
+			case 2:
+				sum += bmwS3(x)
+			case 3:
+				sum += bmwS0(x)
+			}
+		}
+		q[j] = sum + addElement(j)
+	}
+	rot := [7]int{5, 11, 27, 32, 37, 43, 53}
+	for j := 18; j < 32; j++ {
+		sum := bmwS4(q[j-2]) + bmwS5(q[j-1])
+		for k := 0; k < 14; k++ {
+			x := q[j-16+k]
+			if k%2 == 1 {
+				x = bits.RotateLeft64(x, rot[k/2])
+			}
+			sum += x
+		}
+		q[j] = sum + addElement(j)
+	}
+
+	// f2: the folding of the quadruple pipe into the new chaining value.
+	var xl, xh uint64
+	for _, x := range q[16:24] {
+		xl ^= x
+	}
+	xh = xl
+	for _, x := range q[24:32] {
+		xh ^= x
+	}
+	h[0] = (xh<<5 ^ q[16]>>5 ^ m[0]) + (xl ^ q[24] ^ q[0])
+	h[1] = (xh>>7 ^ q[17]<<8 ^ m[1]) + (xl ^ q[25] ^ q[1])
+	h[2] = (xh>>5 ^ q[18]<<5 ^ m[2]) + (xl ^ q[26] ^ q[2])
+	h[3] = (xh>>1 ^ q[19]<<5 ^ m[3]) + (xl ^ q[27] ^ q[3])
+	h[4] = (xh>>3 ^ q[20] ^ m[4]) + (xl ^ q[28] ^ q[4])
+	h[5] = (xh<<6 ^ q[21]>>6 ^ m[5]) + (xl ^ q[29] ^ q[5])
+	h[6] = (xh>>4 ^ q[22]<<6 ^ m[6]) + (xl ^ q[30] ^ q[6])
+	h[7] = (xh>>11 ^ q[23]<<2 ^ m[7]) + (xl ^ q[31] ^ q[7])
+	h[8] = bits.RotateLeft64(h[4], 9) + (xh ^ q[24] ^ m[8]) +
+		(xl<<8 ^ q[23] ^ q[8])
+	h[9] = bits.RotateLeft64(h[5], 10) + (xh ^ q[25] ^ m[9]) +
+		(xl>>6 ^ q[16] ^ q[9])
+	h[10] = bits.RotateLeft64(h[6], 11) + (xh ^ q[26] ^ m[10]) +
+		(xl<<6 ^ q[17] ^ q[10])
+	h[11] = bits.RotateLeft64(h[7], 12) + (xh ^ q[27] ^ m[11]) +
+		(xl<<4 ^ q[18] ^ q[11])
+	h[12] = bits.RotateLeft64(h[0], 13) + (xh ^ q[28] ^ m[12]) +
+		(xl>>3 ^ q[19] ^ q[12])
+	h[13] = bits.RotateLeft64(h[1], 14) + (xh ^ q[29] ^ m[13]) +
+		(xl>>4 ^ q[20] ^ q[13])
+	h[14] = bits.RotateLeft64(h[2], 15) + (xh ^ q[30] ^ m[14]) +
+		(xl>>7 ^ q[21] ^ q[14])
+	h[15] = bits.RotateLeft64(h[3], 16) + (xh ^ q[31] ^ m[15]) +
+		(xl>>2 ^ q[22] ^ q[15])
+}
+
+// bmw512 returns the BMW-512 digest of data.
+func bmw512(data []byte) [64]byte {
+	// The initial chaining value is made of the consecutive bytes 0x80
+	// through 0xff, in big endian words.
+	var h [16]uint64
+	for i := range h {
+		for j := 0; j < 8; j++ {
+			h[i] |= uint64(0x80+8*i+j) << (56 - 8*j)
+		}
+	}
+
+	n := len(data)
+	var m [16]uint64
+	compress := func(block []byte) {
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[8*i:])
+		}
+		bmwCompress(&h, &m)
+	}
+	for len(data) >= 128 {
+		compress(data[:128])
+		data = data[128:]
+	}
+
+	// The message is padded with a one bit, zeros and the 64-bit message
+	// length.
+	var block [256]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	end := 128
+	if len(data) >= 120 {
+		end = 256
+	}
+	binary.LittleEndian.PutUint64(block[end-8:], uint64(n)*8)
+	compress(block[:128])
+	if end == 256 {
+		compress(block[128:])
+	}
+
+	// The final chaining value is compressed as a message with a constant
+	// chaining value.
+	final := h
+	for i := range h {
+		h[i] = 0xaaaaaaaaaaaaaaa0 + uint64(i)
+	}
+	bmwCompress(&h, &final)
+
+	var out [64]byte
+	for i, v := range h[8:] {
+		binary.LittleEndian.PutUint64(out[8*i:], v)
+	}
+	return out
+}
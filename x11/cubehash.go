@@ -0,0 +1,84 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// cubehashRounds applies n rounds of the CubeHash permutation to the state.
+func cubehashRounds(x *[32]uint32, n int) {
+	for ; n > 0; n-- {
+		for i := 0; i < 16; i++ {
+			x[i+16] += x[i]
+			x[i] = bits.RotateLeft32(x[i], 7)
+		}
+		for i := 0; i < 8; i++ {
+			x[i], x[i+8] = x[i+8], x[i]
+		}
+		for i := 0; i < 16; i++ {
+			x[i] ^= x[i+16]
+		}
+		for i := 16; i < 32; i++ {
+			if i&2 == 0 {
+				x[i], x[i+2] = x[i+2], x[i]
+			}
+		}
+		for i := 0; i < 16; i++ {
+			x[i+16] += x[i]
+			x[i] = bits.RotateLeft32(x[i], 11)
+		}
+		for i := 0; i < 16; i++ {
+			if i&4 == 0 {
+				x[i], x[i+4] = x[i+4], x[i]
+			}
+		}
+		for i := 0; i < 16; i++ {
+			x[i] ^= x[i+16]
+		}
+		for i := 16; i < 32; i += 2 {
+			x[i], x[i+1] = x[i+1], x[i]
+		}
+	}
+}
+
+// cubehashIV is the initial state of CubeHash16/32-512, which is derived from
+// its parameters.
+var cubehashIV = func() [32]uint32 {
+	x := [32]uint32{64, 32, 16}
+	cubehashRounds(&x, 160)
+	return x
+}()
+
+// cubehash512 returns the CubeHash16/32-512 digest of data.
+func cubehash512(data []byte) [64]byte {
+	x := cubehashIV
+	process := func(block []byte) {
+		for i := 0; i < 8; i++ {
+			x[i] ^= binary.LittleEndian.Uint32(block[4*i:])
+		}
+		cubehashRounds(&x, 16)
+	}
+	for len(data) >= 32 {
+		process(data[:32])
+		data = data[32:]
+	}
+
+	// The message is padded with a one bit and zeros, after which the
+	// state is finalized with a flipped bit and 160 more rounds.
+	var block [32]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	process(block[:])
+	x[31] ^= 1
+	cubehashRounds(&x, 160)
+
+	var out [64]byte
+	for i := 0; i < 16; i++ {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i])
+	}
+	return out
+}
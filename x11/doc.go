@@ -0,0 +1,18 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package x11 implements the X11 proof-of-work hash function used by Dash.
+
+X11 chains eleven of the SHA-3 competition candidates, each hashing the 512-bit
+output of the previous one: BLAKE, BMW, Grøstl, Skein, JH, Keccak, Luffa,
+CubeHash, SHAvite-3, SIMD and ECHO, all in their 512-bit variants.  The X11 hash
+is the first 256 bits of the final ECHO digest.
+
+The functions follow the round 2 and final round specifications of the
+candidates as implemented by sphlib, which is what Dash Core uses, rather than
+any later revisions.  Only one-shot hashing is provided since X11 is only ever
+applied to block headers.
+*/
+package x11
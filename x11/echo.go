@@ -0,0 +1,128 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+)
+
+// echoCompress compresses the 128 byte block into the chaining value v with
+// the passed 128-bit count of message bits.
+func echoCompress(v *[8][4]uint32, block []byte, count [4]uint32) {
+	// The state is a 4x4 matrix of 128-bit words, with the chaining value
+	// in the first two columns and the message in the last two.
+	var w, m [16][4]uint32
+	copy(w[:8], v[:])
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 4; j++ {
+			m[i][j] = binary.LittleEndian.Uint32(block[16*i+4*j:])
+		}
+	}
+	copy(w[8:], m[:8])
+
+	k := count
+	for round := 0; round < 10; round++ {
+		// BIG.SubWords applies two AES rounds to each word, the first
+		// keyed by the counter, which increments for every word.
+		for i := range w {
+			aesRound(&w[i])
+			for j := range k {
+				w[i][j] ^= k[j]
+			}
+			aesRound(&w[i])
+			for j := range k {
+				k[j]++
+				if k[j] != 0 {
+					break
+				}
+			}
+		}
+
+		// BIG.ShiftRows rotates row r of words left by r columns.
+		var t [16][4]uint32
+		for c := 0; c < 4; c++ {
+			for r := 0; r < 4; r++ {
+				t[4*c+r] = w[4*((c+r)%4)+r]
+			}
+		}
+
+		// BIG.MixColumns applies the AES MixColumns to the bytes at the
+		// same position of the four words of each column.
+		for c := 0; c < 4; c++ {
+			for j := 0; j < 4; j++ {
+				for shift := 0; shift < 32; shift += 8 {
+					a0 := byte(t[4*c][j] >> shift)
+					a1 := byte(t[4*c+1][j] >> shift)
+					a2 := byte(t[4*c+2][j] >> shift)
+					a3 := byte(t[4*c+3][j] >> shift)
+					all := a0 ^ a1 ^ a2 ^ a3
+					out := [4]byte{
+						a0 ^ all ^ xtime(a0^a1),
+						a1 ^ all ^ xtime(a1^a2),
+						a2 ^ all ^ xtime(a2^a3),
+						a3 ^ all ^ xtime(a3^a0),
+					}
+					for r := 0; r < 4; r++ {
+						t[4*c+r][j] &^= 0xff << shift
+						t[4*c+r][j] |= uint32(out[r]) << shift
+					}
+				}
+			}
+		}
+		w = t
+	}
+
+	// BIG.Final.
+	for i := range v {
+		for j := range v[i] {
+			v[i][j] ^= m[i][j] ^ w[i][j] ^ w[i+8][j]
+		}
+	}
+}
+
+// echo512 returns the ECHO-512 digest of data.
+func echo512(data []byte) [64]byte {
+	var v [8][4]uint32
+	for i := range v {
+		v[i][0] = 512
+	}
+	bitCount := func(n int) [4]uint32 {
+		bitLen := uint64(n) * 8
+		return [4]uint32{uint32(bitLen), uint32(bitLen >> 32), 0, 0}
+	}
+
+	n := len(data)
+	for len(data) >= 128 {
+		echoCompress(&v, data[:128], bitCount(n-len(data)+128))
+		data = data[128:]
+	}
+
+	// The message is padded with a one bit, zeros, the 16-bit output size
+	// and the 128-bit message length.  Blocks holding no message bits are
+	// compressed with a zero count.
+	var block [256]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	count := bitCount(n)
+	if len(data) == 0 {
+		count = [4]uint32{}
+	}
+	final := block[:128]
+	if len(data) >= 110 {
+		echoCompress(&v, block[:128], count)
+		final, count = block[128:], [4]uint32{}
+	}
+	binary.LittleEndian.PutUint16(final[110:], 512)
+	binary.LittleEndian.PutUint64(final[112:], uint64(n)*8)
+	echoCompress(&v, final, count)
+
+	var out [64]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			binary.LittleEndian.PutUint32(out[16*i+4*j:], v[i][j])
+		}
+	}
+	return out
+}
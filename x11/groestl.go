@@ -0,0 +1,128 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+)
+
+// groestlState is the 8x16 byte state of the permutations of Grøstl-512,
+// stored column by column.
+type groestlState [128]byte
+
+// groestlShift holds the number of columns each row is rotated to the left by
+// the ShiftBytes step of the P and Q permutations.
+var groestlShift = [2][8]int{
+	{0, 1, 2, 3, 4, 5, 6, 11},
+	{1, 3, 5, 11, 0, 2, 4, 6},
+}
+
+// groestlMix holds the first row of the circulant matrix of MixBytes.
+var groestlMix = [8]byte{2, 2, 3, 4, 5, 3, 5, 7}
+
+// gfMul multiplies a and b in the AES field GF(2^8).
+func gfMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		a = xtime(a)
+		b >>= 1
+	}
+	return p
+}
+
+// permute applies the P permutation of Grøstl-512 to the state when q is false
+// and the Q permutation otherwise.
+func (s *groestlState) permute(q bool) {
+	variant := 0
+	if q {
+		variant = 1
+	}
+	var t groestlState
+	for r := 0; r < 14; r++ {
+		// AddRoundConstant.
+		for c := 0; c < 16; c++ {
+			k := byte(c<<4) ^ byte(r)
+			if q {
+				for row := 0; row < 7; row++ {
+					s[8*c+row] ^= 0xff
+				}
+				s[8*c+7] ^= 0xff ^ k
+			} else {
+				s[8*c] ^= k
+			}
+		}
+
+		// SubBytes and ShiftBytes.
+		for c := 0; c < 16; c++ {
+			for row := 0; row < 8; row++ {
+				from := (c + groestlShift[variant][row]) % 16
+				t[8*c+row] = aesSbox[s[8*from+row]]
+			}
+		}
+
+		// MixBytes.
+		for c := 0; c < 16; c++ {
+			col := t[8*c : 8*c+8]
+			for row := 0; row < 8; row++ {
+				var v byte
+				for k := 0; k < 8; k++ {
+					v ^= gfMul(groestlMix[(k-row+8)%8], col[k])
+				}
+				s[8*c+row] = v
+			}
+		}
+	}
+}
+
+// groestl512 returns the Grøstl-512 digest of data.
+func groestl512(data []byte) [64]byte {
+	var h groestlState
+	h[126] = 0x02
+
+	compress := func(block []byte) {
+		var p, q groestlState
+		for i := range h {
+			p[i] = h[i] ^ block[i]
+			q[i] = block[i]
+		}
+		p.permute(false)
+		q.permute(true)
+		for i := range h {
+			h[i] ^= p[i] ^ q[i]
+		}
+	}
+
+	n := len(data)
+	for len(data) >= 128 {
+		compress(data[:128])
+		data = data[128:]
+	}
+
+	// The message is padded with a one bit, zeros and the 64-bit number
+	// of blocks including the padding.
+	var block [256]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	end := 128
+	if len(data) >= 120 {
+		end = 256
+	}
+	binary.BigEndian.PutUint64(block[end-8:], uint64(n/128+end/128))
+	for i := 0; i < end; i += 128 {
+		compress(block[i : i+128])
+	}
+
+	// The output transformation truncates P(h) xor h.
+	p := h
+	p.permute(false)
+	var out [64]byte
+	for i := range out {
+		out[i] = p[64+i] ^ h[64+i]
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// The S-boxes of JH.  Each round picks one of them per element by the bits of
+// the round constant.
+var jhSbox = [2][16]byte{
+	{9, 0, 4, 11, 13, 12, 3, 15, 1, 10, 2, 6, 7, 5, 8, 14},
+	{3, 12, 6, 13, 5, 7, 1, 9, 15, 2, 0, 4, 11, 10, 14, 8},
+}
+
+// jhLinear applies the linear transformation L of JH, an MDS code over
+// GF(2^4), to the pair of 4-bit elements.
+func jhLinear(a, b byte) (byte, byte) {
+	b ^= (a<<1 ^ a>>3 ^ (a>>2)&2) & 0xf
+	a ^= (b<<1 ^ b>>3 ^ (b>>2)&2) & 0xf
+	return a, b
+}
+
+// jhRound applies the round function R_d of JH with the passed round constant
+// bits to the 2^d elements of v.
+func jhRound(v []byte, constant []byte) {
+	n := len(v)
+	for i := range v {
+		bit := constant[i/8] >> (7 - uint(i%8)) & 1
+		v[i] = jhSbox[bit][v[i]]
+	}
+	for i := 0; i < n; i += 2 {
+		v[i], v[i+1] = jhLinear(v[i], v[i+1])
+	}
+
+	// The permutation P_d is the composition of pi_d, P'_d and phi_d.
+	t := make([]byte, n)
+	for i := 0; i < n; i += 4 {
+		v[i+2], v[i+3] = v[i+3], v[i+2]
+	}
+	for i := 0; i < n/2; i++ {
+		t[i] = v[2*i]
+		t[i+n/2] = v[2*i+1]
+	}
+	for i := n / 2; i < n; i += 2 {
+		t[i], t[i+1] = t[i+1], t[i]
+	}
+	copy(v, t)
+}
+
+// jhConstants holds the round constants of the 42 rounds of E8, each derived
+// from the previous one with the round function R_6.
+var jhConstants = func() [42][32]byte {
+	var c [42][32]byte
+	c0, _ := hex.DecodeString("6a09e667f3bcc908b2fb1366ea957d3e" +
+		"3adec17512775099da2f590b0667322a")
+	copy(c[0][:], c0)
+
+	var zero [8]byte
+	v := make([]byte, 64)
+	for r := 1; r < 42; r++ {
+		for i := range v {
+			v[i] = c[r-1][i/2] >> (4 * uint(1-i%2)) & 0xf
+		}
+		jhRound(v, zero[:])
+		for i := range v {
+			c[r][i/2] |= v[i] << (4 * uint(1-i%2))
+		}
+	}
+	return c
+}()
+
+// jhE8 applies the bijective function E8 of JH to the state.
+func jhE8(h *[128]byte) {
+	bit := func(i int) byte {
+		return h[i/8] >> (7 - uint(i%8)) & 1
+	}
+
+	// Group the bits into 256 4-bit elements.
+	var v [256]byte
+	for i := 0; i < 128; i++ {
+		v[2*i] = bit(i)<<3 | bit(i+256)<<2 | bit(i+512)<<1 |
+			bit(i+768)
+		v[2*i+1] = bit(i+128)<<3 | bit(i+384)<<2 | bit(i+640)<<1 |
+			bit(i+896)
+	}
+
+	for r := 0; r < 42; r++ {
+		jhRound(v[:], jhConstants[r][:])
+	}
+
+	// Degroup the elements back into bits.
+	*h = [128]byte{}
+	set := func(i int, b byte) {
+		h[i/8] |= (b & 1) << (7 - uint(i%8))
+	}
+	for i := 0; i < 128; i++ {
+		set(i, v[2*i]>>3)
+		set(i+256, v[2*i]>>2)
+		set(i+512, v[2*i]>>1)
+		set(i+768, v[2*i])
+		set(i+128, v[2*i+1]>>3)
+		set(i+384, v[2*i+1]>>2)
+		set(i+640, v[2*i+1]>>1)
+		set(i+896, v[2*i+1])
+	}
+}
+
+// jh512 returns the JH-512 digest of data.
+func jh512(data []byte) [64]byte {
+	var h [128]byte
+	compress := func(block []byte) {
+		for i := 0; i < 64; i++ {
+			h[i] ^= block[i]
+		}
+		jhE8(&h)
+		for i := 0; i < 64; i++ {
+			h[64+i] ^= block[i]
+		}
+	}
+
+	// The initial chaining value is the compression of a zero block into
+	// the output size.
+	h[0] = 0x02
+	compress(make([]byte, 64))
+
+	n := len(data)
+	for len(data) >= 64 {
+		compress(data[:64])
+		data = data[64:]
+	}
+
+	// The message is padded with a one bit, zeros and the 128-bit message
+	// length, such that the padding is at least 512 bits long.
+	var block [128]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	end := 64
+	if len(data) > 0 {
+		end = 128
+	}
+	binary.BigEndian.PutUint64(block[end-8:], uint64(n)*8)
+	for i := 0; i < end; i += 64 {
+		compress(block[i : i+64])
+	}
+
+	var out [64]byte
+	copy(out[:], h[64:])
+	return out
+}
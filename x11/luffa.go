@@ -0,0 +1,181 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// luffaIV is the initial chaining value of Luffa-512, made of five 256-bit
+// words.
+var luffaIV = [5][8]uint32{
+	{0x6d251e69, 0x44b051e0, 0x4eaa6fb4, 0xdbf78465,
+		0x6e292011, 0x90152df4, 0xee058139, 0xdef610bb},
+	{0xc3b44b95, 0xd9d2f256, 0x70eee9a0, 0xde099fa3,
+		0x5d9b0557, 0x8fc944b3, 0xcf1ccf0e, 0x746cd581},
+	{0xf7efc89d, 0x5dba5781, 0x04016ce5, 0xad659c05,
+		0x0306194f, 0x666d1836, 0x24aa230a, 0x8b264ae7},
+	{0x858075d5, 0x36d79cce, 0xe571f7d7, 0x204b1f67,
+		0x35870c6a, 0x57e9e923, 0x14bcb808, 0x7cde72ce},
+	{0x6c68e9be, 0x5ec41e22, 0xc825b7c7, 0xaffb4363,
+		0xf5df3999, 0x0fc688f1, 0xb07224cc, 0x03e86cea},
+}
+
+// luffaRC holds the step constants of the five permutations, which are added
+// to the first and the fifth word of the permuted 256-bit word.
+var luffaRC = [5][8][2]uint32{
+	{
+		{0x303994a6, 0xe0337818}, {0xc0e65299, 0x441ba90d},
+		{0x6cc33a12, 0x7f34d442}, {0xdc56983e, 0x9389217f},
+		{0x1e00108f, 0xe5a8bce6}, {0x7800423d, 0x5274baf4},
+		{0x8f5b7882, 0x26889ba7}, {0x96e1db12, 0x9a226e9d},
+	},
+	{
+		{0xb6de10ed, 0x01685f3d}, {0x70f47aae, 0x05a17cf4},
+		{0x0707a3d4, 0xbd09caca}, {0x1c1e8f51, 0xf4272b28},
+		{0x707a3d45, 0x144ae5cc}, {0xaeb28562, 0xfaa7ae2b},
+		{0xbaca1589, 0x2e48f1c1}, {0x40a46f3e, 0xb923c704},
+	},
+	{
+		{0xfc20d9d2, 0xe25e72c1}, {0x34552e25, 0xe623bb72},
+		{0x7ad8818f, 0x5c58a4a4}, {0x8438764a, 0x1e38e2e7},
+		{0xbb6de032, 0x78e38b9d}, {0xedb780c8, 0x27586719},
+		{0xd9847356, 0x36eda57f}, {0xa2c78434, 0x703aace7},
+	},
+	{
+		{0xb213afa5, 0xe028c9bf}, {0xc84ebe95, 0x44756f91},
+		{0x4e608a22, 0x7e8fce32}, {0x56d858fe, 0x956548be},
+		{0x343b138f, 0xfe191be2}, {0xd0ec4e3d, 0x3cb226e5},
+		{0x2ceb4882, 0x5944a28e}, {0xb3ad2208, 0xa1c4c355},
+	},
+	{
+		{0xf0d2e9e3, 0x5090d577}, {0xac11d7fa, 0x2d1925ab},
+		{0x1bcb66f2, 0xb46496ac}, {0x6f2d9bc9, 0xd1925ab0},
+		{0x78602649, 0x29131ab6}, {0x8edae952, 0x0fc053c3},
+		{0x3b6ba548, 0x3f014f0c}, {0xedae9520, 0xfc053c31},
+	},
+}
+
+// luffaMul2 multiplies the 256-bit word by x in GF(2^32)^8 modulo
+// x^8 + x^4 + x^3 + x + 1.
+func luffaMul2(a [8]uint32) [8]uint32 {
+	return [8]uint32{a[7], a[0] ^ a[7], a[1], a[2] ^ a[7], a[3] ^ a[7],
+		a[4], a[5], a[6]}
+}
+
+func luffaXor(a, b [8]uint32) [8]uint32 {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+// luffaSubCrumb applies the 4-bit S-box of Luffa to the four words in bitslice
+// fashion.
+func luffaSubCrumb(a0, a1, a2, a3 *uint32) {
+	tmp := *a0
+	*a0 |= *a1
+	*a2 ^= *a3
+	*a1 = ^*a1
+	*a0 ^= *a3
+	*a3 &= tmp
+	*a1 ^= *a3
+	*a3 ^= *a2
+	*a2 &= *a0
+	*a0 = ^*a0
+	*a2 ^= *a1
+	*a1 |= *a3
+	tmp ^= *a1
+	*a3 ^= *a2
+	*a2 &= *a1
+	*a1 ^= *a0
+	*a0 = tmp
+}
+
+// luffaMixWord applies the MixWord linear permutation of Luffa to the pair of
+// words.
+func luffaMixWord(u, v *uint32) {
+	*v ^= *u
+	*u = bits.RotateLeft32(*u, 2) ^ *v
+	*v = bits.RotateLeft32(*v, 14) ^ *u
+	*u = bits.RotateLeft32(*u, 10) ^ *v
+	*v = bits.RotateLeft32(*v, 1)
+}
+
+// luffaRound injects the message block into the chaining value and applies
+// the five permutations.
+func luffaRound(v *[5][8]uint32, m [8]uint32) {
+	// The message injection of Luffa-512.
+	a := luffaMul2(luffaXor(luffaXor(luffaXor(v[0], v[1]),
+		luffaXor(v[2], v[3])), v[4]))
+	for j := range v {
+		v[j] = luffaXor(v[j], a)
+	}
+	b := luffaXor(luffaMul2(v[0]), v[1])
+	v[1] = luffaXor(luffaMul2(v[1]), v[2])
+	v[2] = luffaXor(luffaMul2(v[2]), v[3])
+	v[3] = luffaXor(luffaMul2(v[3]), v[4])
+	v[4] = luffaXor(luffaMul2(v[4]), v[0])
+	v[0] = luffaXor(luffaMul2(b), v[4])
+	v[4] = luffaXor(luffaMul2(v[4]), v[3])
+	v[3] = luffaXor(luffaMul2(v[3]), v[2])
+	v[2] = luffaXor(luffaMul2(v[2]), v[1])
+	v[1] = luffaXor(luffaMul2(v[1]), b)
+	for j := range v {
+		v[j] = luffaXor(v[j], m)
+		m = luffaMul2(m)
+	}
+
+	// The permutations, each tweaked by rotating its last four words.
+	for j := range v {
+		x := &v[j]
+		for i := 4; i < 8; i++ {
+			x[i] = bits.RotateLeft32(x[i], j)
+		}
+		for r := 0; r < 8; r++ {
+			luffaSubCrumb(&x[0], &x[1], &x[2], &x[3])
+			luffaSubCrumb(&x[5], &x[6], &x[7], &x[4])
+			for i := 0; i < 4; i++ {
+				luffaMixWord(&x[i], &x[i+4])
+			}
+			x[0] ^= luffaRC[j][r][0]
+			x[4] ^= luffaRC[j][r][1]
+		}
+	}
+}
+
+// luffa512 returns the Luffa-512 digest of data.
+func luffa512(data []byte) [64]byte {
+	v := luffaIV
+	process := func(block []byte) {
+		var m [8]uint32
+		for i := range m {
+			m[i] = binary.BigEndian.Uint32(block[4*i:])
+		}
+		luffaRound(&v, m)
+	}
+	for len(data) >= 32 {
+		process(data[:32])
+		data = data[32:]
+	}
+
+	// The message is padded with a one bit and zeros.
+	var block [32]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	process(block[:])
+
+	// Each half of the output is taken after a blank round.
+	var out [64]byte
+	for half := 0; half < 2; half++ {
+		luffaRound(&v, [8]uint32{})
+		for i := 0; i < 8; i++ {
+			w := v[0][i] ^ v[1][i] ^ v[2][i] ^ v[3][i] ^ v[4][i]
+			binary.BigEndian.PutUint32(out[32*half+4*i:], w)
+		}
+	}
+	return out
+}
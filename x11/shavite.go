@@ -0,0 +1,139 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+)
+
+// shaviteIV is the initial chaining value of SHAvite-3-512.
+var shaviteIV = [16]uint32{
+	0x72fccdd8, 0x79ca4727, 0x128a077b, 0x40d55aec,
+	0xd1901a06, 0x430ae307, 0xb29f5cd1, 0xdf07fbfc,
+	0x8e45d73d, 0x681ab538, 0xbde86578, 0xdd577e47,
+	0xe275eade, 0x502d9fcd, 0xb9357178, 0x022a4b9a,
+}
+
+// shaviteCompress compresses the 128 byte block into the chaining value h with
+// the passed 128-bit count of message bits.
+func shaviteCompress(h *[16]uint32, block []byte, count [4]uint32) {
+	// Expand the message into the round keys.  Rounds alternate between
+	// a nonlinear expansion using AES rounds and a linear one, and the
+	// counter is mixed into four of the keys.
+	var rk [448]uint32
+	for i := 0; i < 32; i++ {
+		rk[i] = binary.LittleEndian.Uint32(block[4*i:])
+	}
+	for i := 32; i < 448; {
+		if (i/32)%2 == 1 {
+			for end := i + 32; i < end; i += 4 {
+				x := [4]uint32{rk[i-31], rk[i-30], rk[i-29], rk[i-32]}
+				aesRound(&x)
+				for j := 0; j < 4; j++ {
+					rk[i+j] = x[j] ^ rk[i-4+j]
+				}
+				switch i {
+				case 32:
+					rk[32] ^= count[0]
+					rk[33] ^= count[1]
+					rk[34] ^= count[2]
+					rk[35] ^= ^count[3]
+				case 164:
+					rk[164] ^= count[3]
+					rk[165] ^= count[2]
+					rk[166] ^= count[1]
+					rk[167] ^= ^count[0]
+				case 316:
+					rk[316] ^= count[2]
+					rk[317] ^= count[3]
+					rk[318] ^= count[0]
+					rk[319] ^= ^count[1]
+				case 440:
+					rk[440] ^= count[1]
+					rk[441] ^= count[0]
+					rk[442] ^= count[3]
+					rk[443] ^= ^count[2]
+				}
+			}
+		} else {
+			for end := i + 32; i < end; i++ {
+				rk[i] = rk[i-32] ^ rk[i-7]
+			}
+		}
+	}
+
+	// The 14 rounds of the generalized Feistel network, each updating two
+	// of the four 128-bit words with the others.  The words rotate by one
+	// position every round.
+	var p [4][4]uint32
+	for i := range p {
+		copy(p[i][:], h[4*i:])
+	}
+	f := func(x [4]uint32, k []uint32) [4]uint32 {
+		for j := 0; j < 16; j += 4 {
+			for i := range x {
+				x[i] ^= k[j+i]
+			}
+			aesRound(&x)
+		}
+		return x
+	}
+	for r := 0; r < 14; r++ {
+		a, b, c, d := (4-r%4)%4, (5-r%4)%4, (6-r%4)%4, (7-r%4)%4
+		k := rk[32*r:]
+		x := f(p[b], k[:16])
+		y := f(p[d], k[16:32])
+		for i := 0; i < 4; i++ {
+			p[a][i] ^= x[i]
+			p[c][i] ^= y[i]
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			h[4*i+j] ^= p[(i+2)%4][j]
+		}
+	}
+}
+
+// shavite512 returns the SHAvite-3-512 digest of data.
+func shavite512(data []byte) [64]byte {
+	h := shaviteIV
+	bitCount := func(n int) [4]uint32 {
+		bitLen := uint64(n) * 8
+		return [4]uint32{uint32(bitLen), uint32(bitLen >> 32), 0, 0}
+	}
+
+	n := len(data)
+	for len(data) >= 128 {
+		shaviteCompress(&h, data[:128], bitCount(n-len(data)+128))
+		data = data[128:]
+	}
+
+	// The message is padded with a one bit, zeros, the 128-bit message
+	// length and the 16-bit output size.  Blocks holding no message bits
+	// are compressed with a zero count.
+	var block [256]byte
+	copy(block[:], data)
+	block[len(data)] = 0x80
+	count := bitCount(n)
+	if len(data) == 0 {
+		count = [4]uint32{}
+	}
+	final := block[:128]
+	if len(data) >= 110 {
+		shaviteCompress(&h, block[:128], count)
+		final, count = block[128:], [4]uint32{}
+	}
+	binary.LittleEndian.PutUint64(final[110:], uint64(n)*8)
+	binary.LittleEndian.PutUint16(final[126:], 512)
+	shaviteCompress(&h, final, count)
+
+	var out [64]byte
+	for i, v := range h {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
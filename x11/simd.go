@@ -0,0 +1,179 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// simdIV is the initial chaining value of SIMD-512.
+var simdIV = [32]uint32{
+	0x0ba16b95, 0x72f999ad, 0x9fecc2ae, 0xba3264fc,
+	0x5e894929, 0x8e9f30e5, 0x2f1daa37, 0xf0f2c558,
+	0xac506643, 0xa90635a5, 0xe25b878b, 0xaab7878f,
+	0x88817f7a, 0x0a02892b, 0x559a7550, 0x598f657e,
+	0x7eef60a1, 0x6b70e3e8, 0x9c1714d1, 0xb958e2a8,
+	0xab02675e, 0xed1c014f, 0xcd8d65bb, 0xfdb7a257,
+	0x09254899, 0xd699c7bc, 0x9019b6dc, 0x2b9022e4,
+	0x8fa14956, 0x21bf9bd3, 0xb94d0943, 0x6ffddc22,
+}
+
+// simdWords lists, for each step of the four rounds, the index of the block
+// of 16 expanded message elements its words are read from.
+var simdWords = [32]int{
+	4, 6, 0, 2, 7, 5, 3, 1,
+	15, 11, 12, 8, 9, 13, 10, 14,
+	17, 18, 23, 20, 22, 21, 16, 19,
+	30, 24, 25, 31, 27, 29, 28, 26,
+}
+
+// simdRotations lists the rotation amounts of the four rounds.
+var simdRotations = [4][4]int{
+	{3, 23, 17, 27},
+	{28, 19, 22, 7},
+	{29, 9, 15, 5},
+	{4, 13, 10, 25},
+}
+
+// simdPermutations lists the xor masks of the permutations of the words the
+// steps mix in, which cycle with a period of seven steps.
+var simdPermutations = [7]int{1, 6, 2, 3, 5, 7, 4}
+
+// simdExpand returns the message expansion of the block, which is the number
+// theoretic transform of its bytes over the integers modulo 257 with the
+// tweak of the final block, centered around zero.
+func simdExpand(block []byte, final bool) [256]int32 {
+	// 41 is a 256th root of unity modulo 257, and the tweak adds the terms
+	// of degree 255 and, for the final block, 253, whose values are the
+	// powers of 163 and 40.
+	var q [256]int32
+	w, t0, t1 := int32(1), int32(1), int32(1)
+	for i := range q {
+		var sum, x int32 = 0, 1
+		for _, b := range block {
+			sum = (sum + int32(b)*x) % 257
+			x = x * w % 257
+		}
+		sum += t0
+		if final {
+			sum += t1
+		}
+		sum %= 257
+		if sum > 128 {
+			sum -= 257
+		}
+		q[i] = sum
+		w, t0, t1 = w*41%257, t0*163%257, t1*40%257
+	}
+	return q
+}
+
+// simdStep applies a step of the SIMD compression function, which mixes the
+// eight words of w into the state using the boolean function f and the
+// rotation amounts r and s.
+func simdStep(a, b, c, d *[8]uint32, w *[8]uint32, f func(x, y, z uint32) uint32,
+	r, s, perm int) {
+
+	var t [8]uint32
+	for i := range t {
+		t[i] = bits.RotateLeft32(a[i], r)
+	}
+	for i := range a {
+		v := d[i] + w[i] + f(a[i], b[i], c[i])
+		a[i] = bits.RotateLeft32(v, s) + t[i^perm]
+		d[i], c[i], b[i] = c[i], b[i], t[i]
+	}
+}
+
+func simdIF(x, y, z uint32) uint32 {
+	return (y^z)&x ^ z
+}
+
+func simdMAJ(x, y, z uint32) uint32 {
+	return x&y | (x|y)&z
+}
+
+// simdCompress compresses the 128 byte block into the chaining value h.
+func simdCompress(h *[32]uint32, block []byte, final bool) {
+	q := simdExpand(block, final)
+
+	var a, b, c, d [8]uint32
+	for i := 0; i < 8; i++ {
+		a[i] = h[i] ^ binary.LittleEndian.Uint32(block[4*i:])
+		b[i] = h[8+i] ^ binary.LittleEndian.Uint32(block[32+4*i:])
+		c[i] = h[16+i] ^ binary.LittleEndian.Uint32(block[64+4*i:])
+		d[i] = h[24+i] ^ binary.LittleEndian.Uint32(block[96+4*i:])
+	}
+
+	for step := 0; step < 32; step++ {
+		// The words of the first two rounds are read from pairs of
+		// neighbouring elements, and those of the last two from
+		// elements of both halves of the expansion.
+		base := 16 * simdWords[step]
+		lo, hi, mul := 0, 1, int32(185)
+		switch {
+		case step >= 24:
+			lo, hi, mul = -383, -255, 233
+		case step >= 16:
+			lo, hi, mul = -256, -128, 233
+		}
+		var w [8]uint32
+		for i := range w {
+			l := q[base+2*i+lo] * mul
+			u := q[base+2*i+hi] * mul
+			w[i] = uint32(l)&0xffff + uint32(u)<<16
+		}
+
+		round := simdRotations[step/8]
+		r, s := round[step%4], round[(step+1)%4]
+		f := simdIF
+		if step%8 >= 4 {
+			f = simdMAJ
+		}
+		simdStep(&a, &b, &c, &d, &w, f, r, s, simdPermutations[step%7])
+	}
+
+	// The feed-forward mixes the previous chaining value in with four more
+	// steps.
+	for i, rs := range [4][2]int{{4, 13}, {13, 10}, {10, 25}, {25, 4}} {
+		var w [8]uint32
+		copy(w[:], h[8*i:])
+		simdStep(&a, &b, &c, &d, &w, simdIF, rs[0], rs[1],
+			simdPermutations[(32+i)%7])
+	}
+
+	copy(h[0:], a[:])
+	copy(h[8:], b[:])
+	copy(h[16:], c[:])
+	copy(h[24:], d[:])
+}
+
+// simd512 returns the SIMD-512 digest of data.
+func simd512(data []byte) [64]byte {
+	h := simdIV
+	n := len(data)
+	for len(data) >= 128 {
+		simdCompress(&h, data[:128], false)
+		data = data[128:]
+	}
+
+	// The last partial block is padded with zeros, and a final block holds
+	// the message length in bits.
+	var block [128]byte
+	if len(data) > 0 {
+		copy(block[:], data)
+		simdCompress(&h, block[:], false)
+		block = [128]byte{}
+	}
+	binary.LittleEndian.PutUint64(block[:], uint64(n)*8)
+	simdCompress(&h, block[:], true)
+
+	var out [64]byte
+	for i := 0; i < 16; i++ {
+		binary.LittleEndian.PutUint32(out[4*i:], h[i])
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Types and flags of the UBI tweak of Skein.
+const (
+	skeinTypeCfg   = 4
+	skeinTypeMsg   = 48
+	skeinTypeOut   = 63
+	skeinFlagFirst = 1 << 62
+	skeinFlagFinal = 1 << 63
+)
+
+// threefishRot holds the rotation constants of Threefish-512.
+var threefishRot = [8][4]int{
+	{46, 36, 19, 37},
+	{33, 27, 14, 42},
+	{17, 49, 36, 39},
+	{44, 9, 54, 56},
+	{39, 30, 34, 24},
+	{13, 50, 10, 17},
+	{25, 29, 39, 43},
+	{8, 35, 56, 22},
+}
+
+// threefishPerm is the word permutation of Threefish-512.
+var threefishPerm = [8]int{2, 1, 4, 7, 6, 5, 0, 3}
+
+// threefish512 encrypts the block with Threefish-512 under the passed key and
+// tweak.
+func threefish512(key *[8]uint64, t0, t1 uint64, block *[8]uint64) [8]uint64 {
+	var k [9]uint64
+	copy(k[:], key[:])
+	k[8] = 0x1bd11bdaa9fc1a22
+	for _, v := range key {
+		k[8] ^= v
+	}
+	t := [3]uint64{t0, t1, t0 ^ t1}
+
+	v := *block
+	injectKey := func(s int) {
+		for i := range v {
+			v[i] += k[(s+i)%9]
+		}
+		v[5] += t[s%3]
+		v[6] += t[(s+1)%3]
+		v[7] += uint64(s)
+	}
+	for d := 0; d < 72; d++ {
+		if d%4 == 0 {
+			injectKey(d / 4)
+		}
+		var f [8]uint64
+		for j := 0; j < 4; j++ {
+			x0, x1 := v[2*j], v[2*j+1]
+			y0 := x0 + x1
+			f[2*j] = y0
+			f[2*j+1] = bits.RotateLeft64(x1, threefishRot[d%8][j]) ^ y0
+		}
+		for i := range v {
+			v[i] = f[threefishPerm[i]]
+		}
+	}
+	injectKey(18)
+	return v
+}
+
+// skeinUBI processes the passed message of the passed type with the UBI
+// chaining mode into the chaining value h.
+func skeinUBI(h *[8]uint64, msg []byte, typ uint64) {
+	pos := 0
+	first := uint64(skeinFlagFirst)
+	for {
+		var block [64]byte
+		n := copy(block[:], msg[pos:])
+		pos += n
+		t1 := typ<<56 | first
+		if pos == len(msg) {
+			t1 |= skeinFlagFinal
+		}
+
+		var m [8]uint64
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[8*i:])
+		}
+		c := threefish512(h, uint64(pos), t1, &m)
+		for i := range h {
+			h[i] = c[i] ^ m[i]
+		}
+
+		first = 0
+		if pos == len(msg) {
+			return
+		}
+	}
+}
+
+// skeinIV is the initial chaining value of Skein-512-512, which is the result
+// of processing its configuration block.
+var skeinIV = func() [8]uint64 {
+	var cfg [32]byte
+	copy(cfg[:], "SHA3")
+	cfg[4] = 1
+	binary.LittleEndian.PutUint64(cfg[8:], 512)
+
+	var h [8]uint64
+	skeinUBI(&h, cfg[:], skeinTypeCfg)
+	return h
+}()
+
+// skein512 returns the Skein-512-512 digest of data.
+func skein512(data []byte) [64]byte {
+	h := skeinIV
+	skeinUBI(&h, data, skeinTypeMsg)
+	skeinUBI(&h, make([]byte, 8), skeinTypeOut)
+
+	var out [64]byte
+	for i, v := range h {
+		binary.LittleEndian.PutUint64(out[8*i:], v)
+	}
+	return out
+}
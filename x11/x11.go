@@ -0,0 +1,41 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+// Size is the size of an X11 hash in bytes.
+const Size = 32
+
+// keccak512 returns the Keccak-512 digest of data, which uses the original
+// Keccak padding rather than the one of the standardized SHA3-512.
+func keccak512(data []byte) [64]byte {
+	var out [64]byte
+	h := sha3.NewLegacyKeccak512()
+	h.Write(data)
+	h.Sum(out[:0])
+	return out
+}
+
+// Sum returns the X11 hash of data.
+func Sum(data []byte) [Size]byte {
+	h := blake512(data)
+	h = bmw512(h[:])
+	h = groestl512(h[:])
+	h = skein512(h[:])
+	h = jh512(h[:])
+	h = keccak512(h[:])
+	h = luffa512(h[:])
+	h = cubehash512(h[:])
+	h = shavite512(h[:])
+	h = simd512(h[:])
+	h = echo512(h[:])
+
+	var out [Size]byte
+	copy(out[:], h[:Size])
+	return out
+}
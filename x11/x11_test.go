@@ -0,0 +1,99 @@
+// Copyright (c) 2023 The Dash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package x11
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestComponents ensures the digests of the empty message of the chained hash
+// functions match the known answers of their reference implementations.
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func([]byte) [64]byte
+		want string
+	}{
+		{"BLAKE-512", blake512, "a8cfbbd73726062df0c6864dda65defe58ef0cc" +
+			"52a5625090fa17601e1eecd1b628e94f396ae402a00acc9eab77b4d4c2e" +
+			"852aaaa25a636d80af3fc7913ef5b8"},
+		{"BMW-512", bmw512, "6a725655c42bc8a2a20549dd5a233a6a2beb0161697" +
+			"5851fd122504e604b46af7d96697d0b6333db1d1709d6df328d2a6c7865" +
+			"51b0cce2255e8c7332b4819c0e"},
+		{"Grøstl-512", groestl512, "6d3ad29d279110eef3adbd66de2a0345a77b" +
+			"aede1557f5d099fce0c03d6dc2ba8e6d4a6633dfbd66053c20faa87d1a1" +
+			"1f39a7fbe4a6c2f009801370308fc4ad8"},
+		{"Skein-512", skein512, "bc5b4c50925519c290cc634277ae3d6257212395" +
+			"cba733bbad37a4af0fa06af41fca7903d06564fea7a2d3730dbdb80c1f8" +
+			"5562dfcc070334ea4d1d9e72cba7a"},
+		{"JH-512", jh512, "90ecf2f76f9d2c8017d979ad5ab96b87d58fc8fc4b8306" +
+			"0f3f900774faa2c8fabe69c5f4ff1ec2b61d6b316941cedee117fb04b1f" +
+			"4c5bc1b919ae841c50eec4f"},
+		{"Keccak-512", keccak512, "0eab42de4c3ceb9235fc91acffe746b29c29a" +
+			"8c366b7c60e4e67c466f36a4304c00fa9caf9d87976ba469bcbe06713b4" +
+			"35f091ef2769fb160cdab33d3670680e"},
+		{"Luffa-512", luffa512, "6e7de4501189b3ca58f3ac114916654bbcd49220" +
+			"24b4cc1cd764acfe8ab4b7805df133eab345ffdb1c414564c924f48e0a3" +
+			"01824e2ac4c34bd4efde2e43da90e"},
+		{"CubeHash-512", cubehash512, "4a1d00bbcfcb5a9562fb981e7f7db3350" +
+			"fe2658639d948b9d57452c22328bb32f468b072208450bad5ee17827140" +
+			"8be0b16e5633ac8a1e3cf9864cfbfc8e043a"},
+		{"SHAvite-3-512", shavite512, "a485c1b2578459d1efc5dddd840bb0b4a" +
+			"650ac82fe68f58c4442ccda747da006b2d1dc6b4a4eb7d84ff91e1f466f" +
+			"ef429d259acd995dddcad16fa545c7a6e5ba"},
+		{"SIMD-512", simd512, "51a5af7e243cd9a5989f7792c880c4c3168c3d60c4" +
+			"518725fe5757d1f7a69c6366977eaba7905ce2da5d7cfd07773725f0935" +
+			"b55f3efb954996689a49b6d29e0"},
+		{"ECHO-512", echo512, "158f58cc79d300a9aa292515049275d051a28ab931" +
+			"726d0ec44bdd9faef4a702c36db9e7922fff077402236465833c5cc76af" +
+			"4efc352b4b44c7fa15aa0ef234e"},
+	}
+
+	for _, test := range tests {
+		got := test.f(nil)
+		if hex.EncodeToString(got[:]) != test.want {
+			t.Errorf("%s: got %x, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestSum ensures the X11 hashes of the genesis block headers of the Dash
+// networks match their known hashes.
+func TestSum(t *testing.T) {
+	// The headers share the version, the null previous block hash and the
+	// merkle root of the genesis coinbase.
+	const prefix = "01000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"c762a6567f3cc092f0684bb62b7e00a84890b990f07cc71a6bb58d64b98e02e0"
+	tests := []struct {
+		name   string
+		suffix string
+		want   string
+	}{
+		{"mainnet", "022ddb52f0ff0f1ec23fb901", "00000ffd590b1485b3caadc19b" +
+			"22e6379c733355108f107a430458cdf3407ab6"},
+		{"testnet", "dee1e352f0ff0f1ec3c927e6", "00000bafbc94add76cb75e2ec9" +
+			"2894837288a481e5c005f6563d91623bf8bc2c"},
+		{"regtest", "b9968054ffff7f20ffba1000", "000008ca1832a4baf228eb1553" +
+			"c03d3a2c8e02399550dd6ea8d65cec3ef23d2e"},
+	}
+
+	for _, test := range tests {
+		header, err := hex.DecodeString(prefix + test.suffix)
+		if err != nil {
+			t.Fatalf("%s: unable to decode header: %v", test.name, err)
+		}
+		got := Sum(header)
+
+		// The known hashes are displayed in reverse byte order.
+		for i, j := 0, Size-1; i < j; i, j = i+1, j-1 {
+			got[i], got[j] = got[j], got[i]
+		}
+		if hex.EncodeToString(got[:]) != test.want {
+			t.Errorf("%s: got %x, want %s", test.name, got, test.want)
+		}
+	}
+}